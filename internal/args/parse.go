@@ -6,6 +6,7 @@ import (
 	"path"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/go-playground/validator/v10"
@@ -58,6 +59,7 @@ type IgnoredConfig struct {
 	Atimes          bool   `short:"U"`
 	Crtimes         bool   `short:"N"`
 	OmitDirTimes    bool   `short:"O"`
+	ModifyWindow    int    `short:"@"`
 	Rsh             string `short:"e"`
 	Delete          bool
 	PruneEmptyDirs  bool `short:"m"`
@@ -78,6 +80,108 @@ type ExodusConfig struct {
 	Commit string `help:"Commit publish using this mode" validate:"omitempty,max=20"`
 
 	Diag bool `help:"Diagnostic mode, dumps various information about the environment."`
+
+	ShowConfig bool `help:"Show the configuration in effect for DEST, and the source of each value, then exit."`
+
+	ListPublishes bool `help:"List the publishes in the exodus-gw environment for DEST, then exit."`
+
+	ListState []string `placeholder:"STATE,..." help:"With --exodus-list-publishes, list only publishes in these states, e.g. PENDING." validate:"dive,min=1,max=50"`
+
+	ListFormat string `placeholder:"table|json" help:"Format of the output of --exodus-list-publishes, --exodus-diff-publishes, --exodus-whoami and --exodus-checksum-self-check; table by default." validate:"omitempty,oneof=table json"`
+
+	DiffPublishes []string `placeholder:"ID1,ID2" help:"Compare the items of two publishes in the exodus-gw environment for DEST, then exit." validate:"omitempty,len=2,dive,min=1,max=200"`
+
+	WhoAmI bool `name:"whoami" help:"Show who the configured credentials authenticate as with the exodus-gw environment for DEST, and which environments they're authorized for, then exit."`
+
+	CheckCert bool `help:"Check that the certificate configured for DEST to authenticate with exodus-gw is valid and won't expire within 'gwcertexpirywarning' days, then exit."`
+
+	ChecksumSelfCheck bool `help:"Show how object keys are computed for DEST, and the key computed for a known input, for comparing machines, then exit."`
+
+	Benchmark bool `help:"Benchmark uploads and adding items at several concurrency and batch settings, using synthetic content under the scratch path DEST which is never committed, then exit."`
+
+	Output string `placeholder:"text|json" help:"With 'json', write the result of the command to stdout as JSON, and logs to stderr; text logs on stdout by default." validate:"omitempty,oneof=text json"`
+
+	Tar bool `help:"SRC is a tar archive; publish its content as if it were an extracted directory."`
+
+	Glob bool `help:"Expand wildcards and braces in SRC, e.g. 'src/{a,b}/*.rpm', for callers without a shell."`
+
+	Offline string `placeholder:"DIR" help:"Don't contact exodus-gw; write the requests which would be made into DIR." validate:"max=2000"`
+
+	Transcript string `placeholder:"FILE" help:"Record every request made to exodus-gw and its response into FILE, with secrets redacted." validate:"max=2000"`
+
+	WritePublishID string `placeholder:"FILE" help:"Write the ID of each publish into FILE as soon as it's created, e.g. for cleaning up after a failed sync." validate:"max=2000"`
+
+	WriteTaskID string `placeholder:"FILE" help:"Write the ID of the exodus-gw task of each commit into FILE, for correlating with operations within exodus-gw." validate:"max=2000"`
+
+	DumpItems string `placeholder:"FILE" help:"Write the items which would be published into FILE, as CSV or JSON according to its extension or --exodus-dump-format." validate:"max=2000"`
+
+	DumpFormat string `placeholder:"csv|json" help:"Format of the file written by --exodus-dump-items." validate:"omitempty,oneof=csv json"`
+
+	BaseManifest string `placeholder:"FILE" help:"Publish only the items which are new or changed since the items written into FILE by an earlier --exodus-dump-items." validate:"max=2000"`
+
+	ContinueFromManifest string `placeholder:"FILE" help:"Record into FILE the blobs and items confirmed uploaded and added, and continue from those recorded by an earlier sync which didn't complete, skipping them." validate:"max=2000"`
+
+	FilterFiles bool `help:"Apply include and exclude rules from a .exodus-rsync-filter file in each directory of SRC to that directory's content."`
+
+	NewerThan string `placeholder:"TIME|FILE" help:"Only publish files modified after TIME, e.g. 2024-01-02T03:04:05Z, or after the reference file FILE was." validate:"max=2000"`
+
+	Remap string `placeholder:"FILE" help:"Rewrite paths of source files using rules from FILE." validate:"max=2000"`
+
+	Env []string `placeholder:"ENV,..." help:"Publish to each of these exodus-gw environments rather than the configured gwenv." validate:"dive,min=1,max=200"`
+
+	DestSet []string `placeholder:"VALUE,..." help:"Publish to each of the destinations given by replacing the placeholder in DEST, e.g. {arch}, with each VALUE." validate:"dive,min=1,max=200"`
+
+	GwBatchSize string `placeholder:"N|auto" help:"Max number of items per request to exodus-gw, or 'auto' to adapt it to request latency." validate:"omitempty,numeric|eq=auto"`
+
+	Progress string `placeholder:"DEST" help:"Write progress events as lines of JSON to DEST: an 'fd:N' file descriptor, a Unix socket or a file." validate:"max=2000"`
+
+	ProgressSnapshot string `placeholder:"FILE" help:"Periodically replace the content of FILE with a JSON snapshot of the sync's progress, for watching from another terminal." validate:"max=2000"`
+
+	Pipeline bool `help:"Add items onto the publish and commit them as their content is uploaded, where exodus-gw supports it."`
+
+	HoldCommit string `placeholder:"PATH" help:"Before committing, wait for 'commit' to be written to the named pipe PATH, or for the lock file PATH to be removed." validate:"max=2000"`
+
+	OnConflict string `placeholder:"retry|fail" help:"If the commit conflicts with another publish, 'retry' the whole publish, or 'fail' with a distinct exit code (default)." validate:"omitempty,oneof=retry fail"`
+
+	ExpireAfter time.Duration `placeholder:"DURATION" help:"Ask exodus-gw to expire the created publish after DURATION, e.g. 72h, for ephemeral content; ignored if exodus-gw doesn't support it." validate:"min=0"`
+
+	Label []string `placeholder:"KEY=VALUE" sep:"none" help:"Label the created publish with KEY=VALUE, e.g. a build ID, for tracking it; may be repeated. Ignored if exodus-gw doesn't support labels." validate:"dive,min=1,max=2000"`
+
+	OnEmpty string `placeholder:"skip|error|commit-empty" help:"If there are no items to publish, 'skip' creating a publish (default), fail with an 'error', or 'commit-empty' publish." validate:"omitempty,oneof=skip error commit-empty"`
+
+	MinFreeSpace int64 `placeholder:"BYTES" help:"Fail if the directory for temporary files would have less than BYTES free; overrides 'tempminfree' from config." validate:"min=0"`
+
+	ForceUpload []string `placeholder:"PATTERN" help:"Upload the content of files whose web URIs match PATTERN even if already present, e.g. to replace a corrupted blob; may be repeated." validate:"dive,min=1,max=2000"`
+
+	NoReplace bool `help:"Refuse to replace any item already published at the same path, failing the sync instead."`
+
+	SkipUnchanged bool `help:"Before committing, skip the commit if every item is already published identically, as fetched from the CDN at 'cdnurl'."`
+
+	Visibility []string `placeholder:"RULE,..." help:"Set the visibility of published files, public or restricted, by rules of the form MATCH=VISIBILITY, where MATCH is a permission test such as o-r or a pattern." validate:"dive,min=1,max=2000"`
+
+	Yes bool `help:"Confirm a publish to a protected exodus-gw environment, as matched by 'protectedenvs' in configuration."`
+
+	CaptureXattrs bool `help:"Attach the extended attributes named by 'xattrs' in configuration from each source file to its published item as metadata."`
+
+	FixContentTypes bool `help:"Publish items with their content types as determined now, to correct those of already published items, without uploading any content; content not already present is an error."`
+
+	ThrottleOnError bool `help:"Reduce the number of uploads at once whenever uploads or requests to exodus-gw fail, and ramp it back up as they succeed; see concurrencymin and concurrencymax."`
+
+	KeepGoing bool `help:"Continue past files which can't be uploaded, and report them at the end; see --exodus-on-failed-items."`
+
+	// Skips source files and directories which can't be read, where a sync
+	// otherwise stops at the first one. This isn't rsync's --ignore-errors,
+	// which makes --delete proceed despite I/O errors, so it's never passed
+	// to rsync.
+	SkipUnreadable bool `help:"Skip source files and directories which can't be read, with a warning, rather than failing the sync."`
+
+	OnFailedItems string `placeholder:"skip|fail" help:"With --exodus-keep-going, 'skip' files which couldn't be uploaded and publish the others (default), or 'fail' without committing." validate:"omitempty,oneof=skip fail"`
+
+	MaxErrors int `placeholder:"N" help:"With --exodus-keep-going, stop once N files couldn't be uploaded, as something is likely broken; unlimited by default." validate:"min=0"`
+
+	Force bool `help:"Publish even if the publish exceeds maxpublishbytes or maxpublishitems."`
+
+	VerifyAfterCommit int `placeholder:"N" help:"After commit, verify N randomly chosen published files can be fetched from the CDN." validate:"min=0"`
 }
 
 // Config contains the subset of arguments which are returned by the parser and
@@ -93,6 +197,10 @@ type Config struct {
 	Links  bool `short:"l" help:"Copy symlinks as symlinks without following"`
 	DryRun bool `short:"n" help:"Perform a trial run with no changes made"`
 
+	// Publishes are already atomic, but this prevents anything from being
+	// committed before the final commit.
+	DelayUpdates bool `help:"Put all updated files into place at end"`
+
 	// Mostly ignored, but causes a failure if publish contains any files.
 	// See comments where the argument is checked for the explanation why.
 	IgnoreExisting bool `hidden:"1"`
@@ -102,7 +210,11 @@ type Config struct {
 	Include   []string        `placeholder:"PATTERN" help:"Don't exclude files matching this pattern" validate:"dive,max=2000"`
 	FilesFrom string          `placeholder:"FILE" help:"Read list of source-file names from FILE" validate:"max=2000"`
 
-	Src  string `arg:"1" placeholder:"SRC" help:"Local path to a file or directory for sync" validate:"max=2000"`
+	// exodus-gw identifies content only by its SHA-256 digest, so exodus
+	// publishes accept only choices consistent with that.
+	ChecksumChoice string `aliases:"cc" placeholder:"STR" help:"Choose the checksum algorithm" validate:"max=100"`
+
+	Src  string `arg:"1" placeholder:"SRC" help:"Local path, or http(s) URL, of a file or directory for sync" validate:"max=2000"`
 	Dest string `arg:"1" placeholder:"[USER@]HOST:DEST" help:"Remote destination for sync" validate:"max=2000"`
 
 	IgnoredConfig `embed:"1" group:"ignored"`
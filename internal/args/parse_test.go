@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/alecthomas/kong"
 )
@@ -29,6 +30,7 @@ func TestParseOk(t *testing.T) {
 				"--atimes",
 				"--crtimes",
 				"--omit-dir-times",
+				"--modify-window", "2",
 				"--rsh", "abc",
 				"--delete",
 				"--prune-empty-dirs",
@@ -57,6 +59,7 @@ func TestParseOk(t *testing.T) {
 					Atimes:          true,
 					Crtimes:         true,
 					OmitDirTimes:    true,
+					ModifyWindow:    2,
 					Rsh:             "abc",
 					Delete:          true,
 					PruneEmptyDirs:  true,
@@ -66,6 +69,280 @@ func TestParseOk(t *testing.T) {
 					ItemizeChanges:  true,
 				}}},
 
+		"modify window": {
+			input: []string{
+				"exodus-rsync",
+				"-@", "1",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", IgnoredConfig: IgnoredConfig{ModifyWindow: 1}}},
+
+		"checksum choice": {
+			input: []string{
+				"exodus-rsync",
+				"--cc", "sha256",
+				"x",
+				"y"},
+			want: Config{ChecksumChoice: "sha256", Src: "x", Dest: "y"}},
+
+		"skip unreadable": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-skip-unreadable",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{SkipUnreadable: true}}},
+
+		"hold commit": {
+			input: []string{
+				"exodus-rsync",
+				"--delay-updates",
+				"--exodus-hold-commit", "/run/go-live",
+				"x",
+				"y"},
+			want: Config{
+				DelayUpdates: true, Src: "x", Dest: "y",
+				ExodusConfig: ExodusConfig{HoldCommit: "/run/go-live"}}},
+
+		"whoami": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-whoami",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{WhoAmI: true}}},
+
+		"check cert": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-check-cert",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{CheckCert: true}}},
+
+		"checksum self check": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-checksum-self-check",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{ChecksumSelfCheck: true}}},
+
+		"list publishes": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-list-publishes",
+				"--exodus-list-state", "PENDING,COMMITTING",
+				"--exodus-list-format", "json",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{
+				ListPublishes: true, ListState: []string{"PENDING", "COMMITTING"}, ListFormat: "json"}}},
+
+		"on conflict": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-on-conflict=retry",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{OnConflict: "retry"}}},
+
+		"on empty": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-on-empty=commit-empty",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{OnEmpty: "commit-empty"}}},
+
+		"min free space": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-min-free-space=1000000",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{MinFreeSpace: 1000000}}},
+
+		"newer than": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-newer-than=2024-01-02T03:04:05Z",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{NewerThan: "2024-01-02T03:04:05Z"}}},
+
+		"transcript": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-transcript=gw.jsonl",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{Transcript: "gw.jsonl"}}},
+
+		"skip unchanged": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-skip-unchanged",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{SkipUnchanged: true}}},
+
+		"yes": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-yes",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{Yes: true}}},
+
+		"capture xattrs": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-capture-xattrs",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{CaptureXattrs: true}}},
+
+		"progress snapshot": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-progress-snapshot=progress.json",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{ProgressSnapshot: "progress.json"}}},
+
+		"write publish id": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-write-publish-id=publish-id",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{WritePublishID: "publish-id"}}},
+
+		"continue from manifest": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-continue-from-manifest=continue.jsonl",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{ContinueFromManifest: "continue.jsonl"}}},
+
+		"write task id": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-write-task-id=task-id",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{WriteTaskID: "task-id"}}},
+
+		"dest set": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-dest-set=x86_64,aarch64",
+				"x",
+				"exodus:/content/{arch}/os"},
+			want: Config{Src: "x", Dest: "exodus:/content/{arch}/os", ExodusConfig: ExodusConfig{DestSet: []string{"x86_64", "aarch64"}}}},
+
+		"dump items": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-dump-items=items.txt",
+				"--exodus-dump-format=csv",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{DumpItems: "items.txt", DumpFormat: "csv"}}},
+
+		"base manifest": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-base-manifest=items.json",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{BaseManifest: "items.json"}}},
+
+		"filter files": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-filter-files",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{FilterFiles: true}}},
+
+		"benchmark": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-benchmark",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{Benchmark: true}}},
+
+		"no replace": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-no-replace",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{NoReplace: true}}},
+
+		"fix content types": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-fix-content-types",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{FixContentTypes: true}}},
+
+		"output": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-output=json",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{Output: "json"}}},
+
+		"labels": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-label=build=1234",
+				"--exodus-label", "requester=a,b",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{Label: []string{"build=1234", "requester=a,b"}}}},
+
+		"expire after": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-expire-after=36h",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{ExpireAfter: 36 * time.Hour}}},
+
+		"throttle on error": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-throttle-on-error",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{ThrottleOnError: true}}},
+
+		"keep going": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-keep-going",
+				"--exodus-on-failed-items=fail",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{KeepGoing: true, OnFailedItems: "fail"}}},
+
+		"keep going, max errors": {
+			input: []string{
+				"exodus-rsync",
+				"--exodus-keep-going",
+				"--exodus-max-errors", "5",
+				"x",
+				"y"},
+			want: Config{Src: "x", Dest: "y", ExodusConfig: ExodusConfig{KeepGoing: true, MaxErrors: 5}}},
+
 		"verbose": {
 			input: []string{
 				"exodus-rsync",
@@ -222,3 +499,104 @@ func TestConfigValidationErrors(t *testing.T) {
 		}
 	}
 }
+
+func TestGwBatchSizeValidation(t *testing.T) {
+	tests := map[string]bool{
+		"auto":  true,
+		"500":   true,
+		"fast":  false,
+		"-auto": false,
+	}
+
+	for value, valid := range tests {
+		t.Run(value, func(t *testing.T) {
+			config := Parse([]string{"exodus-rsync", "--exodus-gw-batch-size=" + value, "x", "y"}, "", nil)
+			if config.GwBatchSize != value {
+				t.Fatalf("unexpected batch size %q", config.GwBatchSize)
+			}
+
+			err := config.ValidateConfig()
+			if valid && err != nil {
+				t.Errorf("unexpected validation error: %v", err)
+			}
+			if !valid && (err == nil || !strings.Contains(err.Error(), "'GwBatchSize' failed")) {
+				t.Errorf("didn't get expected validation error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestOnConflictValidation(t *testing.T) {
+	tests := map[string]bool{
+		"retry":  true,
+		"fail":   true,
+		"ignore": false,
+	}
+
+	for value, valid := range tests {
+		t.Run(value, func(t *testing.T) {
+			config := Parse([]string{"exodus-rsync", "--exodus-on-conflict=" + value, "x", "y"}, "", nil)
+
+			err := config.ValidateConfig()
+			if valid && err != nil {
+				t.Errorf("unexpected validation error: %v", err)
+			}
+			if !valid && (err == nil || !strings.Contains(err.Error(), "'OnConflict' failed")) {
+				t.Errorf("didn't get expected validation error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestOnFailedItemsValidation(t *testing.T) {
+	tests := map[string]bool{
+		"skip":   true,
+		"fail":   true,
+		"commit": false,
+	}
+
+	for value, valid := range tests {
+		t.Run(value, func(t *testing.T) {
+			config := Parse([]string{"exodus-rsync", "--exodus-keep-going", "--exodus-on-failed-items=" + value, "x", "y"}, "", nil)
+
+			err := config.ValidateConfig()
+			if valid && err != nil {
+				t.Errorf("unexpected validation error: %v", err)
+			}
+			if !valid && (err == nil || !strings.Contains(err.Error(), "'OnFailedItems' failed")) {
+				t.Errorf("didn't get expected validation error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestMaxErrorsValidation(t *testing.T) {
+	config := Parse([]string{"exodus-rsync", "--exodus-keep-going", "--exodus-max-errors=-1", "x", "y"}, "", nil)
+
+	err := config.ValidateConfig()
+	if err == nil || !strings.Contains(err.Error(), "'MaxErrors' failed") {
+		t.Errorf("didn't get expected validation error, got: %v", err)
+	}
+}
+
+func TestExpireAfterValidation(t *testing.T) {
+	tests := map[string]bool{
+		"72h": true,
+		"90m": true,
+		"-1h": false,
+	}
+
+	for value, valid := range tests {
+		t.Run(value, func(t *testing.T) {
+			config := Parse([]string{"exodus-rsync", "--exodus-expire-after=" + value, "x", "y"}, "", nil)
+
+			err := config.ValidateConfig()
+			if valid && err != nil {
+				t.Errorf("unexpected validation error: %v", err)
+			}
+			if !valid && (err == nil || !strings.Contains(err.Error(), "'ExpireAfter' failed")) {
+				t.Errorf("didn't get expected validation error, got: %v", err)
+			}
+		})
+	}
+}
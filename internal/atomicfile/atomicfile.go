@@ -0,0 +1,40 @@
+// Package atomicfile writes files such that they're never seen partially
+// written, by a run which is killed or another run reading concurrently.
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFile writes data to the file at path, replacing any existing file.
+//
+// The data is written to a temporary file in the same directory, flushed to
+// disk, then renamed over path, so that path holds either its old content or
+// all of data. The file is readable by all, like one written by os.WriteFile
+// with mode 0644, rather than only by its owner as temporary files are.
+func WriteFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,42 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file")
+
+	for _, content := range []string{"first", "second"} {
+		if err := WriteFile(path, []byte(content)); err != nil {
+			t.Fatalf("can't write %q, err = %v", content, err)
+		}
+
+		got, err := os.ReadFile(path)
+		if err != nil || string(got) != content {
+			t.Errorf("read back %q, err = %v", got, err)
+		}
+	}
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0644 {
+		t.Errorf("unexpected mode of file %v, err = %v", info.Mode(), err)
+	}
+
+	// Nothing else is left behind.
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Errorf("unexpected entries %v, err = %v", entries, err)
+	}
+}
+
+func TestWriteFileError(t *testing.T) {
+	// The temporary file can't be created in a missing directory.
+	path := filepath.Join(t.TempDir(), "missing", "file")
+
+	if err := WriteFile(path, []byte("content")); err == nil {
+		t.Error("unexpectedly wrote file")
+	}
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+// aliasRule is an 'aliases' entry with its pattern compiled.
+type aliasRule struct {
+	conf.AliasRule
+	pattern *regexp.Regexp
+}
+
+// aliasRules publish links to published items at other web URIs, e.g. so
+// that a "latest" directory resolves to the most recent version of some
+// content.
+type aliasRules []aliasRule
+
+func newAliasRules(rules []conf.AliasRule) (aliasRules, error) {
+	out := aliasRules{}
+
+	for _, rule := range rules {
+		if rule.Alias == "" {
+			return nil, fmt.Errorf("alias rule '%s' sets no alias", rule.Pattern)
+		}
+
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern in alias rule: %w", err)
+		}
+
+		out = append(out, aliasRule{rule, pattern})
+	}
+
+	return out, nil
+}
+
+// items returns a link item for each alias of the given published items,
+// sorted by web URI.
+//
+// The part of each item's URI matched by the first rule matching it is
+// replaced by the rule's alias, which may refer to capture groups, e.g. "$1".
+// Where the matches of several items are replaced by the same alias, such as
+// "1.9/" and "1.10/" by "latest/", only items under the match sorting last by
+// compareVersions are aliased. The aliased tree therefore mirrors a single
+// version. Links are made only to files, which are therefore present in the
+// same publish.
+func (r aliasRules) items(publishItems []gw.ItemInput, normalizer uriNormalizer) ([]gw.ItemInput, error) {
+	if len(r) == 0 {
+		return nil, nil
+	}
+
+	type match struct {
+		uri string
+
+		// The URI up to the end of the matched part, and up to the end of
+		// its replacement by the alias.
+		prefix, alias string
+	}
+
+	matches := []match{}
+	latest := map[string]string{}
+	published := make(map[string]bool, len(publishItems))
+
+	for _, item := range publishItems {
+		published[item.WebURI] = true
+		if item.LinkTo != "" {
+			continue
+		}
+
+		for _, rule := range r {
+			loc := rule.pattern.FindStringSubmatchIndex(item.WebURI)
+			if loc == nil {
+				continue
+			}
+
+			m := match{
+				uri:    item.WebURI,
+				prefix: item.WebURI[:loc[1]],
+				alias:  string(rule.pattern.ExpandString([]byte(item.WebURI[:loc[0]]), rule.Alias, item.WebURI, loc)),
+			}
+			matches = append(matches, m)
+
+			if prefix, ok := latest[m.alias]; !ok || compareVersions(prefix, m.prefix) < 0 {
+				latest[m.alias] = m.prefix
+			}
+			break
+		}
+	}
+
+	out := []gw.ItemInput{}
+	for _, m := range matches {
+		if latest[m.alias] != m.prefix {
+			continue
+		}
+
+		alias, err := normalizer.normalize(m.alias + m.uri[len(m.prefix):])
+		if err != nil {
+			return nil, fmt.Errorf("alias of '%s': %w", m.uri, err)
+		}
+		if published[alias] {
+			return nil, fmt.Errorf("refusing to publish alias '%s' of '%s': conflicts with another item", alias, m.uri)
+		}
+		published[alias] = true
+
+		out = append(out, gw.ItemInput{WebURI: alias, LinkTo: m.uri})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].WebURI < out[j].WebURI })
+
+	return out, nil
+}
+
+var versionParts = regexp.MustCompile(`[0-9]+|[a-zA-Z]+`)
+
+// compareVersions compares a and b in the manner of rpm: runs of digits are
+// compared as numbers and runs of letters as strings, ignoring any separators
+// between them. A number sorts after letters, and a string with further runs
+// after another. It returns a negative number if a sorts before b, a positive
+// number if after, or 0 if they're equal.
+func compareVersions(a, b string) int {
+	aParts := versionParts.FindAllString(a, -1)
+	bParts := versionParts.FindAllString(b, -1)
+
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aNum, aErr := strconv.ParseUint(aParts[i], 10, 64)
+		bNum, bErr := strconv.ParseUint(bParts[i], 10, 64)
+
+		switch {
+		case aErr == nil && bErr == nil:
+			if aNum < bNum {
+				return -1
+			}
+			if aNum > bNum {
+				return 1
+			}
+		case aErr == nil:
+			return 1
+		case bErr == nil:
+			return -1
+		case aParts[i] < bParts[i]:
+			return -1
+		case aParts[i] > bParts[i]:
+			return 1
+		}
+	}
+
+	return len(aParts) - len(bParts)
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"/foo/1.9/", "/foo/1.10/", -1},
+		{"/foo/1.10/", "/foo/1.9/", 1},
+		{"/foo/1.10/", "/foo/1.10/", 0},
+		{"/foo/2/", "/foo/10/", -1},
+		{"/foo/1.0/", "/foo/1.0.1/", -1},
+		{"/foo/1.0-beta/", "/foo/1.0-rc/", -1},
+		{"/foo/1.0/", "/foo/1.0a/", -1},
+		{"/foo/a/", "/foo/1/", -1},
+	}
+
+	for _, tt := range tests {
+		got := compareVersions(tt.a, tt.b)
+		if (got < 0) != (tt.expected < 0) || (got > 0) != (tt.expected > 0) {
+			t.Errorf("compareVersions(%q, %q) = %d, expected %d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
+
+func TestAliasRules(t *testing.T) {
+	rules, err := newAliasRules([]conf.AliasRule{
+		{Pattern: `^(/content/[^/]+)/[0-9][^/]*/`, Alias: "$1/latest/"},
+		{Pattern: `^/iso/[^/]+\.iso$`, Alias: "/iso/latest.iso"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	normalizer, err := newURINormalizer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publishItems := []gw.ItemInput{
+		{WebURI: "/content/foo/1.9/a", ObjectKey: "1"},
+		{WebURI: "/content/foo/1.9/old", ObjectKey: "2"},
+		{WebURI: "/content/foo/1.10/a", ObjectKey: "3"},
+		{WebURI: "/content/foo/1.10/sub/b", ObjectKey: "4"},
+		{WebURI: "/content/foo/1.10/link", LinkTo: "/content/foo/1.10/a"},
+		{WebURI: "/content/bar/2/c", ObjectKey: "5"},
+		{WebURI: "/content/bar/docs/d", ObjectKey: "6"},
+		{WebURI: "/iso/x-1.2.iso", ObjectKey: "7"},
+		{WebURI: "/iso/x-1.11.iso", ObjectKey: "8"},
+	}
+
+	got, err := rules.items(publishItems, normalizer)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []gw.ItemInput{
+		{WebURI: "/content/bar/latest/c", LinkTo: "/content/bar/2/c"},
+		{WebURI: "/content/foo/latest/a", LinkTo: "/content/foo/1.10/a"},
+		{WebURI: "/content/foo/latest/sub/b", LinkTo: "/content/foo/1.10/sub/b"},
+		{WebURI: "/iso/latest.iso", LinkTo: "/iso/x-1.11.iso"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got aliases %v, expected %v", got, expected)
+	}
+}
+
+func TestAliasRulesConflict(t *testing.T) {
+	rules, err := newAliasRules([]conf.AliasRule{{Pattern: `/[0-9.]+/`, Alias: "/latest/"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	normalizer, err := newURINormalizer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = rules.items([]gw.ItemInput{
+		{WebURI: "/dest/1.0/a", ObjectKey: "1"},
+		{WebURI: "/dest/latest/a", ObjectKey: "2"},
+	}, normalizer)
+
+	expected := "refusing to publish alias '/dest/latest/a' of '/dest/1.0/a': conflicts with another item"
+	if err == nil || err.Error() != expected {
+		t.Errorf("did not get expected error, err = %v", err)
+	}
+}
+
+func TestAliasRulesInvalid(t *testing.T) {
+	tests := []struct {
+		rule conf.AliasRule
+		err  string
+	}{
+		{conf.AliasRule{Pattern: `(/[0-9]+/`, Alias: "/latest/"}, "invalid pattern in alias rule"},
+		{conf.AliasRule{Pattern: `/[0-9]+/`}, "alias rule '/[0-9]+/' sets no alias"},
+	}
+
+	for _, tt := range tests {
+		_, err := newAliasRules([]conf.AliasRule{tt.rule})
+		if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+			t.Errorf("%v: did not get expected error, err = %v", tt.rule, err)
+		}
+	}
+}
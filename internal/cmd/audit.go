@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"os/user"
+	"time"
+)
+
+// auditRecord is a line of the audit log configured by 'auditlog', recording
+// a publish committed.
+type auditRecord struct {
+	Time time.Time `json:"time"`
+
+	// Who published, as the local user and host; exodus-gw knows the
+	// identity of its client by the certificate.
+	User string `json:"user"`
+	Host string `json:"host"`
+
+	Env     string `json:"env"`
+	Dest    string `json:"dest"`
+	Publish string `json:"publish"`
+	Task    string `json:"task,omitempty"`
+
+	// The items added onto the publish by this sync, and the size of their
+	// content.
+	Items int   `json:"items"`
+	Bytes int64 `json:"bytes"`
+
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// auditUser returns the name of the user running exodus-rsync.
+func auditUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// appendAudit appends record to the audit log at path, which is created if
+// missing, stamped with the time and who's publishing.
+//
+// The file is only ever appended to, by a single write of a whole line, so
+// that the lines of concurrent syncs appending to the same file aren't
+// interleaved.
+func appendAudit(path string, record auditRecord) error {
+	record.Time = time.Now().UTC()
+	record.User = auditUser()
+	record.Host, _ = os.Hostname()
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+// The settings swept by --exodus-benchmark, and the amount of synthetic
+// content used for each.
+var (
+	benchmarkThreads    = []int{1, 4, 16}
+	benchmarkBatchSizes = []int{10, 100, 1000}
+	benchmarkBlobs      = 32
+	benchmarkBlobSize   = 1024 * 1024
+	benchmarkItems      = 2000
+)
+
+// benchmarkConfig overrides the settings of cfg being benchmarked.
+type benchmarkConfig struct {
+	conf.Config
+	threads   int
+	batchSize int
+}
+
+func (c benchmarkConfig) UploadThreads() int {
+	return c.threads
+}
+
+func (c benchmarkConfig) GwBatchSize() int {
+	return c.batchSize
+}
+
+func (c benchmarkConfig) GwBatchSizeAuto() bool {
+	return false
+}
+
+type uploadResult struct {
+	threads  int
+	blobs    int
+	uploaded int
+	bytes    int64
+	elapsed  time.Duration
+}
+
+type addItemsResult struct {
+	batchSize int
+	items     int
+	elapsed   time.Duration
+}
+
+// benchmark measures the throughput of uploads to the exodus-gw environment
+// of cfg at each of benchmarkThreads, and of adding items onto a publish at
+// each of benchmarkBatchSizes, for --exodus-benchmark, and returns the exit
+// code.
+//
+// The content for each thread setting is pseudo-random, and salted for each
+// run, so that every run measures actual uploads rather than checks for
+// blobs uploaded by an earlier run. Blobs can't be removed via exodus-gw, so
+// each run leaves its content behind. Items are added under DEST onto a
+// single publish, which is never committed, so nothing is published;
+// exodus-gw has no means of discarding the publish, which is why DEST must be
+// a scratch path given explicitly.
+func benchmark(ctx context.Context, cfg conf.Config, args args.Config) int {
+	logger := log.FromContext(ctx)
+
+	dest := path.Clean(args.DestPath())
+	if !path.IsAbs(dest) || dest == "/" {
+		logger.F("dest", args.Dest).Error("--exodus-benchmark requires a scratch path in DEST, e.g. exodus:/scratch/benchmark")
+		return 23
+	}
+
+	logger.F("dest", dest, "env", cfg.GwEnv()).Warn(
+		"Benchmarking: uploading synthetic content, and adding items under DEST which won't be committed")
+
+	dir, err := os.MkdirTemp(cfg.TempDir(), "exodus-rsync-benchmark-")
+	if err != nil {
+		logger.F("error", err).Error("can't prepare benchmark content")
+		return 73
+	}
+	defer os.RemoveAll(dir)
+
+	salt := make([]byte, 16)
+	if _, err := cryptorand.Read(salt); err != nil {
+		logger.F("error", err).Error("can't prepare benchmark content")
+		return 73
+	}
+
+	var (
+		uploads []uploadResult
+		keys    []string
+	)
+
+	for _, threads := range benchmarkThreads {
+		items, err := benchmarkContent(ctx, filepath.Join(dir, fmt.Sprint(threads)), threads, salt)
+		if err != nil {
+			logger.F("error", err).Error("can't prepare benchmark content")
+			return 73
+		}
+
+		gwClient, err := ext.gw.NewClient(ctx, benchmarkConfig{cfg, threads, cfg.GwBatchSize()})
+		if err != nil {
+			logger.F("error", err).Error("can't initialize exodus-gw client")
+			return 101
+		}
+
+		uploaded := 0
+		onUploaded := func(walk.SyncItem) error {
+			uploaded++
+			return nil
+		}
+		noop := func(walk.SyncItem) error { return nil }
+
+		start := time.Now()
+		if err := gwClient.EnsureUploaded(ctx, items, onUploaded, noop, noop); err != nil {
+			logger.F("error", err).Error("can't upload files")
+			return 25
+		}
+		result := uploadResult{threads, len(items), uploaded, int64(uploaded * benchmarkBlobSize), time.Since(start)}
+
+		logger.F("threads", threads, "uploaded", uploaded, "seconds", result.elapsed.Seconds()).Info("Benchmarked uploads")
+		uploads = append(uploads, result)
+
+		for _, item := range items {
+			keys = append(keys, item.Key)
+		}
+	}
+
+	var (
+		addItems  []addItemsResult
+		publishID string
+	)
+
+	for i, batchSize := range benchmarkBatchSizes {
+		gwClient, err := ext.gw.NewClient(ctx, benchmarkConfig{cfg, cfg.UploadThreads(), batchSize})
+		if err != nil {
+			logger.F("error", err).Error("can't initialize exodus-gw client")
+			return 101
+		}
+
+		// A publish is bound to the client which created or got it, and so to
+		// its batch size; the same publish is used for every batch size, so
+		// that only one is left behind.
+		var publish gw.Publish
+		if publishID == "" {
+			publish, err = gwClient.NewPublish(ctx)
+			if err != nil {
+				logger.F("env", cfg.GwEnv(), "error", err).Error("can't create publish")
+				return 62
+			}
+			publishID = publish.ID()
+			logger.F("publish", publishID).Warn("Created publish, which won't be committed")
+		} else {
+			publish, err = gwClient.GetPublish(ctx, publishID)
+			if err != nil {
+				logger.F("env", cfg.GwEnv(), "publish", publishID, "error", err).Error("can't get publish")
+				return 62
+			}
+		}
+
+		publishItems := make([]gw.ItemInput, benchmarkItems)
+		for j := range publishItems {
+			publishItems[j] = gw.ItemInput{
+				WebURI:      fmt.Sprintf("%s/%d/item-%05d", dest, i, j),
+				ObjectKey:   keys[j%len(keys)],
+				ContentType: "application/octet-stream",
+			}
+		}
+
+		start := time.Now()
+		if err := publish.AddItems(ctx, publishItems); err != nil {
+			logger.F("error", err).Error("can't add items to publish")
+			return 51
+		}
+		result := addItemsResult{batchSize, len(publishItems), time.Since(start)}
+
+		logger.F("batch size", batchSize, "seconds", result.elapsed.Seconds()).Info("Benchmarked adding items")
+		addItems = append(addItems, result)
+	}
+
+	if err := writeBenchmarkResults(stdout, uploads, addItems); err != nil {
+		logger.F("error", err).Error("can't write benchmark results")
+		return 73
+	}
+
+	return 0
+}
+
+// benchmarkContent writes benchmarkBlobs files of pseudo-random content for
+// the given number of threads and salt into dir, returning them as items for
+// sync.
+func benchmarkContent(ctx context.Context, dir string, threads int, salt []byte) ([]walk.SyncItem, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < benchmarkBlobs; i++ {
+		f, err := os.Create(filepath.Join(dir, fmt.Sprintf("blob-%05d", i)))
+		if err != nil {
+			return nil, err
+		}
+		// Seeded by the run's salt, the blob and the thread setting, so each
+		// run and setting has content of its own.
+		seed := sha256.Sum256([]byte(fmt.Sprintf("exodus-rsync benchmark %x %d %d %d", salt, threads, i, benchmarkBlobSize)))
+		_, err = io.CopyN(f, rand.NewChaCha8(seed), int64(benchmarkBlobSize))
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var items []walk.SyncItem
+	err := walk.Walk(ctx, args.Config{Src: dir}, nil, func(item walk.SyncItem) error {
+		items = append(items, item)
+		return nil
+	})
+	return items, err
+}
+
+func writeBenchmarkResults(w io.Writer, uploads []uploadResult, addItems []addItemsResult) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "UPLOAD THREADS\tBLOBS\tUPLOADED\tBYTES\tSECONDS\tMB/S")
+	for _, r := range uploads {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%.2f\t%.2f\n",
+			r.threads, r.blobs, r.uploaded, r.bytes, r.elapsed.Seconds(), float64(r.bytes)/1e6/r.elapsed.Seconds())
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "GW BATCH SIZE\tITEMS\tSECONDS\tITEMS/S")
+	for _, r := range addItems {
+		fmt.Fprintf(tw, "%d\t%d\t%.2f\t%.1f\n",
+			r.batchSize, r.items, r.elapsed.Seconds(), float64(r.items)/r.elapsed.Seconds())
+	}
+
+	return tw.Flush()
+}
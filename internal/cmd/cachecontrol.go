@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+)
+
+// cacheControlRule is a single rule of 'cachecontrolrules', with its pattern
+// split into segments.
+type cacheControlRule struct {
+	conf.CacheControlRule
+	segments []string
+}
+
+// cacheControlRules set the Cache-Control of published items by their web
+// URI, as given by 'cachecontrolrules', or else 'cachecontroldefault'.
+type cacheControlRules struct {
+	rules    []cacheControlRule
+	fallback string
+}
+
+func newCacheControlRules(rules []conf.CacheControlRule, fallback string) (cacheControlRules, error) {
+	out := cacheControlRules{fallback: fallback}
+
+	for _, rule := range rules {
+		if rule.CacheControl == "" {
+			return out, fmt.Errorf("cache control rule '%s' sets no cachecontrol", rule.Pattern)
+		}
+
+		segments := strings.Split(strings.TrimPrefix(rule.Pattern, "/"), "/")
+		for _, segment := range segments {
+			if _, err := path.Match(segment, ""); err != nil || segment == "" {
+				return out, fmt.Errorf("invalid pattern '%s' in cache control rule", rule.Pattern)
+			}
+		}
+
+		out.rules = append(out.rules, cacheControlRule{rule, segments})
+	}
+
+	return out, nil
+}
+
+// match returns the Cache-Control of the first rule matching a web URI, or
+// else the default.
+//
+// A pattern without a "/" is matched against the item's name, and otherwise
+// against its whole web URI, segment by segment, where a "**" segment matches
+// any number of directories.
+func (r cacheControlRules) match(uri string) string {
+	segments := strings.Split(strings.TrimPrefix(uri, "/"), "/")
+
+	for _, rule := range r.rules {
+		var matched bool
+		if len(rule.segments) == 1 {
+			matched, _ = path.Match(rule.segments[0], path.Base(uri))
+		} else {
+			matched = matchSegments(rule.segments, segments)
+		}
+
+		if matched {
+			return rule.CacheControl
+		}
+	}
+	return r.fallback
+}
+
+// matchSegments returns true if the segments of a path match those of a
+// pattern, each as for path.Match except "**", which matches zero or more
+// segments.
+func matchSegments(pattern []string, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+	matched, _ := path.Match(pattern[0], segments[0])
+	return matched && matchSegments(pattern[1:], segments[1:])
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+)
+
+func TestCacheControlRules(t *testing.T) {
+	rules, err := newCacheControlRules([]conf.CacheControlRule{
+		{Pattern: "**/repodata/*", CacheControl: "max-age=300"},
+		{Pattern: "*.rpm", CacheControl: "max-age=31536000, immutable"},
+		{Pattern: "/content/**/iso/**", CacheControl: "no-store"},
+	}, "max-age=3600")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		uri      string
+		expected string
+	}{
+		// Metadata changes with each publish, so is cached briefly.
+		{"/content/dist/rhel/os/repodata/repomd.xml", "max-age=300"},
+		{"/repodata/repomd.xml", "max-age=300"},
+		// But not beneath a subdirectory of repodata.
+		{"/content/dist/rhel/os/repodata/extra/x.xml", "max-age=3600"},
+		// Packages never change, wherever they are.
+		{"/content/dist/rhel/os/Packages/b/bash-5.1-1.x86_64.rpm", "max-age=31536000, immutable"},
+		{"/bash.rpm", "max-age=31536000, immutable"},
+		// First match wins.
+		{"/content/dist/iso/repodata/x.rpm", "max-age=300"},
+		{"/content/dist/iso/boot.iso", "no-store"},
+		{"/content/iso/sub/dir/boot.iso", "no-store"},
+		// Anything else gets the default.
+		{"/content/dist/rhel/os/readme", "max-age=3600"},
+	}
+
+	for _, tt := range tests {
+		if got := rules.match(tt.uri); got != tt.expected {
+			t.Errorf("match(%q) = %q, expected %q", tt.uri, got, tt.expected)
+		}
+	}
+}
+
+func TestCacheControlRulesInvalid(t *testing.T) {
+	tests := []struct {
+		rule     conf.CacheControlRule
+		expected string
+	}{
+		{conf.CacheControlRule{Pattern: "*.rpm"}, "cache control rule '*.rpm' sets no cachecontrol"},
+		{conf.CacheControlRule{Pattern: "/content/[", CacheControl: "no-store"},
+			"invalid pattern '/content/[' in cache control rule"},
+		{conf.CacheControlRule{Pattern: "/content//x", CacheControl: "no-store"},
+			"invalid pattern '/content//x' in cache control rule"},
+	}
+
+	for _, tt := range tests {
+		_, err := newCacheControlRules([]conf.CacheControlRule{tt.rule}, "")
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("rule %v: got error %v, expected %q", tt.rule, err, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+// checkCert checks the certificate used to authenticate with the exodus-gw
+// environment of cfg for --exodus-check-cert, and returns the exit code.
+//
+// A certificate which has expired, or isn't yet valid, is an error, so that
+// scheduled jobs can be kept from failing part way through; one expiring
+// within 'gwcertexpirywarning' days only warrants a warning.
+func checkCert(ctx context.Context, cfg conf.Config) int {
+	logger := log.FromContext(ctx)
+
+	cert, err := ext.gw.LoadCert(ctx, cfg)
+	if err != nil {
+		logger.F("error", err).Error("can't load certificate")
+		return 23
+	}
+
+	now := time.Now()
+	remaining := cert.NotAfter.Sub(now)
+	entry := logger.F(
+		"env", cfg.GwEnv(),
+		"subject", cert.Subject.String(),
+		"expires", cert.NotAfter.UTC().Format(time.RFC3339),
+		"days", int(math.Floor(remaining.Hours()/24)),
+	)
+
+	switch {
+	case now.Before(cert.NotBefore):
+		entry.Error("Certificate for exodus-gw is not yet valid")
+		return 68
+	case remaining <= 0:
+		entry.Error("Certificate for exodus-gw has expired")
+		return 68
+	case remaining < time.Duration(cfg.GwCertExpiryWarning())*24*time.Hour:
+		entry.Warn("Certificate for exodus-gw expires soon")
+	default:
+		entry.Info("Certificate for exodus-gw is valid")
+	}
+
+	return 0
+}
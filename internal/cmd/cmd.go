@@ -2,13 +2,17 @@ package cmd
 
 import (
 	"context"
+	"time"
 
 	"github.com/release-engineering/exodus-rsync/internal/args"
 	"github.com/release-engineering/exodus-rsync/internal/conf"
 	"github.com/release-engineering/exodus-rsync/internal/diag"
 	"github.com/release-engineering/exodus-rsync/internal/gw"
 	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/progress"
 	"github.com/release-engineering/exodus-rsync/internal/rsync"
+	"github.com/release-engineering/exodus-rsync/internal/uuid"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
 )
 
 var ext = struct {
@@ -25,6 +29,9 @@ var ext = struct {
 	diag.Package,
 }
 
+// How often --exodus-progress-snapshot is rewritten during a sync.
+var snapshotInterval = time.Second
+
 // This version should be written at build time, see Makefile.
 var version string = "(unknown version)"
 
@@ -57,6 +64,12 @@ func Main(rawArgs []string) int {
 
 	logger := ext.log.NewLogger(parsedArgs)
 
+	// Identify this run in every log message and request to exodus-gw,
+	// for correlating the two.
+	requestID := uuid.New()
+	logger.AddField("request_id", requestID)
+	ctx = gw.WithRequestID(ctx, requestID)
+
 	err := parsedArgs.ValidateConfig()
 	if err != nil {
 		logger.WithField("error", err).Error("argument validation failed")
@@ -65,11 +78,93 @@ func Main(rawArgs []string) int {
 
 	ctx = log.NewContext(ctx, logger)
 
+	if parsedArgs.Progress != "" {
+		stream, err := progress.Open(parsedArgs.Progress)
+		if err != nil {
+			logger.F("error", err).Error("can't open progress stream")
+			return 23
+		}
+		defer func() {
+			if err := stream.Close(); err != nil {
+				logger.F("error", err).Warn("can't write progress events")
+			}
+		}()
+		ctx = progress.NewContext(ctx, stream)
+	}
+
+	if parsedArgs.ProgressSnapshot != "" {
+		snapshotter := progress.NewSnapshotter(parsedArgs.ProgressSnapshot, snapshotInterval)
+		defer func() {
+			if err := snapshotter.Close(); err != nil {
+				logger.F("error", err).Warn("can't write progress snapshot")
+			}
+		}()
+		ctx = progress.NewContext(ctx, progress.FromContext(ctx).Observe(snapshotter.Observe))
+	}
+
+	// With --exodus-output=json, the result is put together from the same
+	// events, and the errors logged. Other modes have their own output.
+	var results *resultCollector
+	if parsedArgs.Output == "json" && !hasOwnOutput(parsedArgs) {
+		results = newResultCollector()
+		logger.AddHandler(results)
+		ctx = progress.NewContext(ctx, progress.FromContext(ctx).Observe(results.observe))
+	}
+
+	// With --verbose, a sync ends with a summary like rsync's, except where
+	// stdout holds the result as JSON.
+	var summary *summaryCollector
+	if parsedArgs.Verbose > 0 && results == nil {
+		summary = newSummaryCollector(parsedArgs.DryRun)
+		ctx = progress.NewContext(ctx, progress.FromContext(ctx).Observe(summary.observe))
+	}
+
+	events := progress.FromContext(ctx)
+	events.Emit(progress.Event{Type: progress.TypeStart, Src: parsedArgs.Src, Dest: parsedArgs.Dest})
+
+	code := mainWithArgs(ctx, parsedArgs)
+
+	events.Emit(progress.Event{Type: progress.TypeEnd, ExitCode: &code})
+
+	if results != nil {
+		if err := results.write(stdout); err != nil {
+			logger.F("error", err).Warn("can't write result")
+		}
+	}
+	if summary != nil {
+		if err := summary.write(stdout); err != nil {
+			logger.F("error", err).Warn("can't write summary")
+		}
+	}
+
+	return code
+}
+
+// hasOwnOutput is true for the modes which do something other than sync,
+// and so neither fall back to rsync nor produce a JSON result.
+func hasOwnOutput(parsedArgs args.Config) bool {
+	return parsedArgs.ShowConfig ||
+		parsedArgs.ListPublishes ||
+		parsedArgs.DiffPublishes != nil ||
+		parsedArgs.WhoAmI ||
+		parsedArgs.CheckCert ||
+		parsedArgs.ChecksumSelfCheck ||
+		parsedArgs.Benchmark
+}
+
+// mainWithArgs is the counterpart of Main after arguments have been parsed.
+func mainWithArgs(ctx context.Context, parsedArgs args.Config) int {
+	logger := log.FromContext(ctx)
+
 	cfg, err := ext.conf.Load(ctx, parsedArgs)
 	if err != nil {
-		if _, ok := err.(*conf.MissingConfigFile); ok {
+		if _, ok := err.(*conf.MissingConfigFile); ok && !hasOwnOutput(parsedArgs) {
 			// Failed to find any config files, fallback to rsync
 			logger.WithField("error", err).Debug("setting rsyncmode to 'rsync'")
+			if walk.IsURL(parsedArgs.Src) {
+				logger.F("src", parsedArgs.Src).Error("a URL source can only be used with rsyncmode 'exodus'")
+				return 23
+			}
 			return rsyncMain(ctx, nil, parsedArgs)
 		}
 		logger.WithField("error", err).Error("can't load config")
@@ -78,19 +173,61 @@ func Main(rawArgs []string) int {
 
 	var env conf.Config = cfg.EnvironmentForDest(ctx, parsedArgs.Dest)
 	var main mainFunc = invalidMain
+	publishes, fetchesURLs := false, false
 
 	if env == nil || env.RsyncMode() == "rsync" {
 		main = rsyncMain
 	} else if env.RsyncMode() == "exodus" {
 		main = exodusMain
+		publishes, fetchesURLs = true, true
 	} else if env.RsyncMode() == "mixed" {
 		main = mixedMain
+		publishes = true
 	}
 
 	if env == nil {
 		env = cfg
 	}
 
+	if parsedArgs.ShowConfig {
+		if err := showConfig(stdout, env, parsedArgs.Dest); err != nil {
+			logger.F("error", err).Error("can't show config")
+			return 23
+		}
+		return 0
+	}
+
+	if parsedArgs.ListPublishes {
+		return listPublishes(ctx, env, parsedArgs)
+	}
+
+	if parsedArgs.DiffPublishes != nil {
+		return diffPublishes(ctx, env, parsedArgs)
+	}
+
+	if parsedArgs.WhoAmI {
+		return whoAmI(ctx, env, parsedArgs)
+	}
+
+	if parsedArgs.CheckCert {
+		return checkCert(ctx, env)
+	}
+
+	if parsedArgs.ChecksumSelfCheck {
+		return checksumSelfCheck(ctx, env, parsedArgs)
+	}
+
+	if parsedArgs.Benchmark {
+		return benchmark(ctx, env, parsedArgs)
+	}
+
+	// Only exodus-rsync itself can fetch a URL; rsync would take it as a
+	// local path.
+	if walk.IsURL(parsedArgs.Src) && !fetchesURLs {
+		logger.F("src", parsedArgs.Src).Error("a URL source can only be used with rsyncmode 'exodus'")
+		return 23
+	}
+
 	logger.StartPlatformLogger(env)
 
 	// We've now decided more or less what we're going to do.
@@ -102,5 +239,11 @@ func Main(rawArgs []string) int {
 		ext.diag.Run(ctx, env, parsedArgs)
 	}
 
+	// Publishing to a protected environment needs confirmation before
+	// anything starts, including rsync in mixed mode.
+	if publishes && !confirmProtected(ctx, syncEnvs(env, parsedArgs), parsedArgs) {
+		return 23
+	}
+
 	return main(ctx, env, parsedArgs)
 }
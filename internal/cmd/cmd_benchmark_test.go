@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+// smallBenchmark makes --exodus-benchmark use little content in tests.
+func smallBenchmark(t *testing.T) {
+	oldThreads, oldBatchSizes := benchmarkThreads, benchmarkBatchSizes
+	oldBlobs, oldBlobSize, oldItems := benchmarkBlobs, benchmarkBlobSize, benchmarkItems
+	t.Cleanup(func() {
+		benchmarkThreads, benchmarkBatchSizes = oldThreads, oldBatchSizes
+		benchmarkBlobs, benchmarkBlobSize, benchmarkItems = oldBlobs, oldBlobSize, oldItems
+	})
+
+	benchmarkThreads = []int{1, 2}
+	benchmarkBatchSizes = []int{2, 5}
+	benchmarkBlobs = 3
+	benchmarkBlobSize = 10
+	benchmarkItems = 7
+}
+
+func TestMainBenchmark(t *testing.T) {
+	SetConfig(t, CONFIG)
+	smallBenchmark(t)
+	ctrl := MockController(t)
+	out := captureStdout(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+
+	// The settings are overridden for each client, in the environment of DEST.
+	var threads, batchSizes []int
+	mockGw.EXPECT().NewClient(gomock.Any(), gomock.Any()).Times(4).DoAndReturn(
+		func(_ context.Context, cfg conf.Config) (gw.Client, error) {
+			if cfg.GwEnv() != "best-env" {
+				t.Errorf("client for unexpected env %s", cfg.GwEnv())
+			}
+			threads = append(threads, cfg.UploadThreads())
+			batchSizes = append(batchSizes, cfg.GwBatchSize())
+			return &client, nil
+		})
+
+	got := Main([]string{"rsync", "--exodus-benchmark", ".", "exodus:/scratch/bench"})
+
+	if got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	// Each thread setting should have uploaded its own random content.
+	if len(client.blobs) != 6 {
+		t.Errorf("expected 6 blobs uploaded, got %d", len(client.blobs))
+	}
+	if threads[0] != 1 || threads[1] != 2 || batchSizes[2] != 2 || batchSizes[3] != 5 {
+		t.Errorf("unexpected settings, threads %v, batch sizes %v", threads, batchSizes)
+	}
+
+	// Items should have been added under DEST onto a single publish, which
+	// is never committed.
+	if len(client.publishes) != 1 {
+		t.Fatalf("expected 1 publish, got %v", client.publishes)
+	}
+	for _, publish := range client.publishes {
+		if publish.committed != 0 {
+			t.Error("benchmark publish was committed")
+		}
+		if len(publish.items) != 14 {
+			t.Errorf("expected 14 items, got %v", publish.items)
+		}
+		for _, item := range publish.items {
+			if !strings.HasPrefix(item.WebURI, "/scratch/bench/") || client.blobs[item.ObjectKey] == "" {
+				t.Errorf("unexpected item %v", item)
+			}
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+	for i, prefix := range []string{"UPLOAD THREADS", "1 ", "2 ", "", "GW BATCH SIZE", "2 ", "5 "} {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("unexpected output line %q", lines[i])
+		}
+	}
+	if !strings.Contains(lines[1], " 3 ") || !strings.Contains(lines[1], " 30 ") || !strings.Contains(lines[5], " 7 ") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestMainBenchmarkRepeated(t *testing.T) {
+	SetConfig(t, CONFIG+"loglevel: none\n")
+	smallBenchmark(t)
+	ctrl := MockController(t)
+	out := captureStdout(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), gomock.Any()).AnyTimes().Return(&client, nil)
+
+	for run := 0; run < 2; run++ {
+		if got := Main([]string{"rsync", "--exodus-benchmark", ".", "exodus:/scratch/bench"}); got != 0 {
+			t.Fatal("returned incorrect exit code", got)
+		}
+	}
+
+	// Different content is generated each run, so the second run uploads
+	// as much as the first, rather than finding its content present.
+	if len(client.blobs) != 12 {
+		t.Errorf("expected 12 blobs uploaded, got %d", len(client.blobs))
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 14 || !strings.HasPrefix(lines[8], "1 ") || !strings.Contains(lines[8], " 3 ") || !strings.Contains(lines[8], " 30 ") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestMainBenchmarkNeedsScratchPath(t *testing.T) {
+	for _, dest := range []string{"exodus:/", "exodus:", "exodus:relative"} {
+		SetConfig(t, CONFIG+"loglevel: none\n")
+		smallBenchmark(t)
+		logs := CaptureLogger(t)
+		ctrl := MockController(t)
+
+		// It should bail out before contacting exodus-gw.
+		ext.gw = gw.NewMockInterface(ctrl)
+
+		got := Main([]string{"rsync", "--exodus-benchmark", ".", dest})
+
+		if got != 23 {
+			t.Errorf("%s: returned incorrect exit code %d", dest, got)
+		}
+		if FindEntry(logs, "--exodus-benchmark requires a scratch path in DEST, e.g. exodus:/scratch/benchmark") == nil {
+			t.Errorf("%s: missing expected log message", dest)
+		}
+	}
+}
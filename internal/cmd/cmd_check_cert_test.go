@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Writes a self-signed certificate valid between notBefore and notAfter,
+// and its key, returning config using them to authenticate with exodus-gw.
+func certConfig(t *testing.T, notBefore time.Time, notAfter time.Time) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "svc-publisher"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return CONFIG + "gwcert: " + certPath + "\ngwkey: " + keyPath + "\n"
+}
+
+func TestMainCheckCert(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name      string
+		notBefore time.Time
+		notAfter  time.Time
+		extra     string
+		code      int
+		message   string
+	}{
+		{"valid", now.Add(-day), now.Add(90 * day), "", 0, "Certificate for exodus-gw is valid"},
+		{"near expiry", now.Add(-day), now.Add(10*day + time.Hour), "", 0, "Certificate for exodus-gw expires soon"},
+		{"near expiry by config", now.Add(-day), now.Add(20*day + time.Hour), "gwcertexpirywarning: 30\n",
+			0, "Certificate for exodus-gw expires soon"},
+		{"warning disabled", now.Add(-day), now.Add(10*day + time.Hour), "gwcertexpirywarning: 0\n",
+			0, "Certificate for exodus-gw is valid"},
+		{"expired", now.Add(-90 * day), now.Add(-day), "", 68, "Certificate for exodus-gw has expired"},
+		{"not yet valid", now.Add(day), now.Add(90 * day), "", 68, "Certificate for exodus-gw is not yet valid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, certConfig(t, tt.notBefore, tt.notAfter)+tt.extra+"loglevel: none\n")
+			logs := CaptureLogger(t)
+
+			got := Main([]string{"rsync", "--exodus-check-cert", ".", "exodus:/dest"})
+
+			if got != tt.code {
+				t.Error("returned incorrect exit code", got)
+			}
+
+			entry := FindEntry(logs, tt.message)
+			if entry == nil {
+				t.Fatal("missing expected log message")
+			}
+			if entry.Fields["env"] != "best-env" || entry.Fields["subject"] != "CN=svc-publisher" ||
+				entry.Fields["expires"] != tt.notAfter.UTC().Format(time.RFC3339) {
+				t.Errorf("unexpected fields %v", entry.Fields)
+			}
+			if tt.name == "near expiry" && entry.Fields["days"] != 10 {
+				t.Errorf("unexpected days %v", entry.Fields["days"])
+			}
+		})
+	}
+}
+
+func TestMainCheckCertMissing(t *testing.T) {
+	SetConfig(t, CONFIG+"gwcert: /not/exist/cert\ngwkey: /not/exist/key\nloglevel: none\n")
+	logs := CaptureLogger(t)
+
+	got := Main([]string{"rsync", "--exodus-check-cert", ".", "exodus:/dest"})
+
+	if got != 23 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if FindEntry(logs, "can't load certificate") == nil {
+		t.Error("missing expected log message")
+	}
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The SHA-256 digest of selfCheckInput, as given by e.g. sha256sum.
+const selfCheckDigest = "d7a8fbb307d7809469ca9abcb0082e4f8d5651e46d3cdb762d02d0bf37c9e592"
+
+func TestMainChecksumSelfCheck(t *testing.T) {
+	SetConfig(t, CONFIG)
+	MockController(t)
+	CaptureLogger(t)
+	out := captureStdout(t)
+
+	// exodus-gw isn't contacted, so there's no client.
+	got := Main([]string{"rsync", "--exodus-checksum-self-check", ".", "exodus:/dest"})
+	if got != 0 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	want := []string{
+		"ENV        best-env",
+		"ALGORITHM  sha256",
+		"PREFIX     ",
+		`INPUT      "The quick brown fox jumps over the lazy dog"`,
+		"KEY        " + selfCheckDigest,
+		"BLOB       " + selfCheckDigest,
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestMainChecksumSelfCheckJSON(t *testing.T) {
+	SetConfig(t, CONFIG+"blobkeyprefix: tenant/\n")
+	MockController(t)
+	CaptureLogger(t)
+	out := captureStdout(t)
+
+	got := Main([]string{"rsync", "--exodus-checksum-self-check", "--exodus-list-format", "json", ".", "exodus:/dest"})
+	if got != 0 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	var computed keyComputation
+	if err := json.Unmarshal(out.Bytes(), &computed); err != nil {
+		t.Fatalf("output is not valid JSON, err = %v:\n%s", err, out.String())
+	}
+	want := keyComputation{
+		Env:       "best-env",
+		Algorithm: "sha256",
+		Prefix:    "tenant/",
+		Input:     selfCheckInput,
+		Key:       selfCheckDigest,
+		Blob:      "tenant/" + selfCheckDigest,
+	}
+	if computed != want {
+		t.Errorf("got %+v, want %+v", computed, want)
+	}
+}
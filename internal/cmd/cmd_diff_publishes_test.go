@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+// diffClient returns a client having two publishes with overlapping and
+// differing items.
+func diffClient() *FakeClient {
+	return &FakeClient{publishes: []FakePublish{
+		{id: "staged", items: []gw.ItemInput{
+			{WebURI: "/dest/same", ObjectKey: "aaa"},
+			{WebURI: "/dest/changed", ObjectKey: "bbb"},
+			{WebURI: "/dest/relinked", LinkTo: "/dest/same"},
+			{WebURI: "/dest/staged-only", ObjectKey: "ccc"},
+		}},
+		{id: "promoted", frozen: true, items: []gw.ItemInput{
+			{WebURI: "/dest/promoted-only", ObjectKey: "ddd"},
+			{WebURI: "/dest/relinked", LinkTo: "/dest/changed"},
+			{WebURI: "/dest/changed", ObjectKey: "eee"},
+			{WebURI: "/dest/same", ObjectKey: "aaa"},
+		}},
+	}}
+}
+
+func TestMainDiffPublishes(t *testing.T) {
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+	out := captureStdout(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(diffClient(), nil)
+
+	got := Main([]string{"rsync", "--exodus-diff-publishes", "staged,promoted", ".", "exodus:/dest"})
+
+	if got != 0 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	want := []string{
+		"STATUS       WEB URI              staged         promoted",
+		"only-first   /dest/staged-only                   ",
+		"only-second  /dest/promoted-only                 ",
+		"differing    /dest/changed        bbb            eee",
+		"differing    /dest/relinked       -> /dest/same  -> /dest/changed",
+		"identical    /dest/same                          ",
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestMainDiffPublishesJSON(t *testing.T) {
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+	out := captureStdout(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(diffClient(), nil)
+
+	got := Main([]string{
+		"rsync", "--exodus-diff-publishes", "staged,promoted", "--exodus-list-format", "json",
+		".", "exodus:/dest",
+	})
+
+	if got != 0 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	var diff publishDiff
+	if err := json.Unmarshal(out.Bytes(), &diff); err != nil {
+		t.Fatalf("output is not valid JSON, err = %v:\n%s", err, out.String())
+	}
+
+	want := publishDiff{
+		First:      "staged",
+		Second:     "promoted",
+		OnlyFirst:  []string{"/dest/staged-only"},
+		OnlySecond: []string{"/dest/promoted-only"},
+		Differing: []differingItem{
+			{"/dest/changed", itemTarget{ObjectKey: "bbb"}, itemTarget{ObjectKey: "eee"}},
+			{"/dest/relinked", itemTarget{LinkTo: "/dest/same"}, itemTarget{LinkTo: "/dest/changed"}},
+		},
+		Identical: []string{"/dest/same"},
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("got diff %+v, want %+v", diff, want)
+	}
+}
+
+func TestMainDiffPublishesIdentical(t *testing.T) {
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+	out := captureStdout(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(diffClient(), nil)
+
+	// A publish compared with itself has only identical items, and empty
+	// lists otherwise rather than nulls.
+	got := Main([]string{
+		"rsync", "--exodus-diff-publishes", "promoted,promoted", "--exodus-list-format", "json",
+		".", "exodus:/dest",
+	})
+
+	if got != 0 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if !strings.Contains(out.String(), `"only_first": []`) || !strings.Contains(out.String(), `"differing": []`) {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestMainDiffPublishesMissing(t *testing.T) {
+	SetConfig(t, CONFIG+"loglevel: none\n")
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(diffClient(), nil)
+
+	got := Main([]string{"rsync", "--exodus-diff-publishes", "staged,unknown", ".", "exodus:/dest"})
+
+	if got != 68 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	entry := FindEntry(logs, "can't get items of publish")
+	if entry == nil || entry.Fields["publish"] != "unknown" {
+		t.Errorf("missing expected log message, got %v", entry)
+	}
+}
+
+func TestMainDiffPublishesInvalid(t *testing.T) {
+	SetConfig(t, CONFIG)
+
+	// Exactly two publishes are compared.
+	got := Main([]string{"rsync", "--exodus-diff-publishes", "staged", ".", "exodus:/dest"})
+
+	if got != 23 {
+		t.Error("returned incorrect exit code", got)
+	}
+}
@@ -15,8 +15,9 @@ func TestMainDryRunSync(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	SetConfig(t, CONFIG)
+	SetConfig(t, CONFIG+"blobkeyprefix: tenant/\n")
 	ctrl := MockController(t)
+	logs := CaptureLogger(t)
 
 	mockGw := gw.NewMockInterface(ctrl)
 	ext.gw = mockGw
@@ -40,4 +41,59 @@ func TestMainDryRunSync(t *testing.T) {
 		t.Error("returned incorrect exit code", got)
 	}
 
+	// How keys are computed is logged, for comparison with other machines.
+	entry := FindEntry(logs, "Computing object keys")
+	if entry == nil || entry.Fields["algorithm"] != "sha256" || entry.Fields["prefix"] != "tenant/" {
+		t.Errorf("missing expected log message, got %v", entry)
+	}
+}
+
+func TestMainDryRunEstimate(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG+"\ngwbatchsize: 2\n")
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	// One of the blobs is already present.
+	client := FakeClient{blobs: map[string]string{
+		"c66f610d98b2c9fe0175a3e99ba64d7fc7de45046515ff325be56329a9347dd6": "some-binary",
+	}}
+	mockGw.EXPECT().NewDryRunClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	got := Main([]string{"rsync", "--dry-run", srcPath + "/", "exodus:/some/target"})
+
+	if got != 0 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	entry := FindEntry(logs, "Estimated cost of sync")
+	if entry == nil {
+		t.Fatal("missing expected log message")
+	}
+
+	// Only one copy of the hello file needs uploading, and the three items
+	// are added two at a time.
+	expected := map[string]interface{}{
+		"env":               "best-env",
+		"uploadBytes":       int64(6),
+		"uploads":           1,
+		"puts":              1,
+		"multipartRequests": 0,
+		"itemBatches":       2,
+		"commit":            true,
+	}
+	for key, value := range expected {
+		if entry.Fields[key] != value {
+			t.Errorf("unexpected %s: %v (%T)", key, entry.Fields[key], entry.Fields[key])
+		}
+	}
 }
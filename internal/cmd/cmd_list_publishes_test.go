@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+// A client for exodus-gw which can't list publishes.
+type noListClient struct {
+	FakeClient
+}
+
+func (*noListClient) ListPublishes(context.Context, gw.PublishFilter) ([]gw.PublishInfo, error) {
+	return nil, fmt.Errorf("exodus-gw does not support listing publishes")
+}
+
+// listClient returns a client having publishes in mixed states.
+func listClient() *FakeClient {
+	return &FakeClient{publishes: []FakePublish{
+		{id: "publish-1"},
+		{id: "publish-2", frozen: true},
+		{id: "publish-3"},
+	}}
+}
+
+func TestMainListPublishes(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"table", nil, []string{
+			"ID         ENV       STATE      UPDATED",
+			"publish-1  best-env  PENDING    ",
+			"publish-2  best-env  COMMITTED  ",
+			"publish-3  best-env  PENDING    ",
+		}},
+		{"filtered", []string{"--exodus-list-state", "pending"}, []string{
+			"ID         ENV       STATE    UPDATED",
+			"publish-1  best-env  PENDING  ",
+			"publish-3  best-env  PENDING  ",
+		}},
+		{"none matching", []string{"--exodus-list-state", "FAILED"}, []string{
+			"ID  ENV  STATE  UPDATED",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG)
+			ctrl := MockController(t)
+			out := captureStdout(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(listClient(), nil)
+
+			args := append([]string{"rsync", "--exodus-list-publishes"}, tt.args...)
+			got := Main(append(args, ".", "exodus:/dest"))
+
+			if got != 0 {
+				t.Error("returned incorrect exit code", got)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+			if !reflect.DeepEqual(lines, tt.want) {
+				t.Errorf("unexpected output:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestMainListPublishesJSON(t *testing.T) {
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+	out := captureStdout(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(listClient(), nil)
+
+	got := Main([]string{
+		"rsync", "--exodus-list-publishes", "--exodus-list-format", "json",
+		"--exodus-list-state", "COMMITTED", ".", "exodus:/dest",
+	})
+
+	if got != 0 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	publishes := []gw.PublishInfo{}
+	if err := json.Unmarshal(out.Bytes(), &publishes); err != nil {
+		t.Fatalf("output is not valid JSON, err = %v:\n%s", err, out.String())
+	}
+
+	want := []gw.PublishInfo{{ID: "publish-2", Env: "best-env", State: "COMMITTED"}}
+	if !reflect.DeepEqual(publishes, want) {
+		t.Errorf("got publishes %v, want %v", publishes, want)
+	}
+}
+
+func TestMainListPublishesUnsupported(t *testing.T) {
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+	out := captureStdout(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&noListClient{}, nil)
+
+	got := Main([]string{"rsync", "--exodus-list-publishes", ".", "exodus:/dest"})
+
+	if got != 68 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if FindEntry(logs, "can't list publishes") == nil {
+		t.Error("missing expected log message")
+	}
+	if out.Len() != 0 {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+}
+
+func TestMainListPublishesClientError(t *testing.T) {
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(nil, fmt.Errorf("simulated error"))
+
+	got := Main([]string{"rsync", "--exodus-list-publishes", ".", "exodus:/dest"})
+
+	if got != 101 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if FindEntry(logs, "can't initialize exodus-gw client") == nil {
+		t.Error("missing expected log message")
+	}
+}
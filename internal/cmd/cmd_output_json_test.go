@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainOutputJSON(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	tests := []struct {
+		name      string
+		args      []string
+		fail      map[string]bool
+		wantCode  int
+		wantStats resultStats
+		committed bool
+		errors    []string
+	}{
+		{"success", nil, nil, 0,
+			resultStats{Uploaded: 2, Duplicate: 1}, true, []string{}},
+		{"failure", []string{"--exodus-keep-going"}, map[string]bool{"some-binary": true}, 27,
+			resultStats{Uploaded: 1, Duplicate: 1, Failed: 1}, true, []string{
+				"can't upload file: simulated error for " + srcPath + "/subdir/some-binary",
+				"Some files could not be uploaded",
+				"Completed, but some files could not be uploaded and were not published",
+			}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Without a platform logger, which may fail to start in tests.
+			SetConfig(t, CONFIG+"loglevel: none\n")
+			ctrl := MockController(t)
+			CaptureLogger(t)
+			out := captureStdout(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := &failingUploadClient{FakeClient: FakeClient{blobs: map[string]string{}}, fail: tt.fail}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(client, nil)
+
+			argv := append([]string{"rsync", "--exodus-output=json"}, tt.args...)
+			argv = append(argv, srcPath+"/", "exodus:/dest")
+
+			got := Main(argv)
+			if got != tt.wantCode {
+				t.Errorf("returned incorrect exit code %d, wanted %d", got, tt.wantCode)
+			}
+
+			// stdout holds nothing but the result.
+			var result syncResult
+			dec := json.NewDecoder(strings.NewReader(out.String()))
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(&result); err != nil {
+				t.Fatalf("invalid result %q: %v", out.String(), err)
+			}
+			if dec.More() {
+				t.Errorf("unexpected content after result: %q", out.String())
+			}
+
+			if result.ExitCode != tt.wantCode || result.Src != srcPath+"/" || result.Dest != "exodus:/dest" {
+				t.Errorf("unexpected result %+v", result)
+			}
+
+			wantPublishes := []resultPublish{{Env: "best-env", ID: "3e0a4539-be4a-437e-a45f-6d72f7192f17", Committed: tt.committed}}
+			if !reflect.DeepEqual(result.Publishes, wantPublishes) {
+				t.Errorf("unexpected publishes %+v", result.Publishes)
+			}
+
+			if result.Stats != tt.wantStats {
+				t.Errorf("unexpected stats %+v", result.Stats)
+			}
+
+			statuses := map[string]string{}
+			for _, item := range result.Items {
+				if item.Env != "best-env" || item.Key == "" {
+					t.Errorf("unexpected item %+v", item)
+				}
+				statuses[path.Base(item.Path)] = item.Status
+			}
+			if len(result.Items) != 3 || (tt.fail != nil && statuses["some-binary"] != "failed") {
+				t.Errorf("unexpected items %+v", result.Items)
+			}
+
+			if !reflect.DeepEqual(result.Errors, tt.errors) {
+				t.Errorf("unexpected errors %q", result.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/progress"
+)
+
+func readProgress(t *testing.T, path string) []progress.Event {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := []progress.Event{}
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var event progress.Event
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("invalid event %q: %v", line, err)
+		}
+		out = append(out, event)
+	}
+	return out
+}
+
+// Summarizes an event as its type and the fields most relevant to it.
+func describeEvent(event progress.Event) string {
+	switch event.Type {
+	case progress.TypePhase:
+		return "phase " + event.Phase
+	case progress.TypeUpload:
+		return fmt.Sprintf("upload %s %d/%d", event.Status, event.Done, event.Total)
+	case progress.TypePublish:
+		return "publish " + event.Status
+	case progress.TypeCommit:
+		return "commit " + event.Status
+	case progress.TypeEnd:
+		return fmt.Sprintf("end %d", *event.ExitCode)
+	}
+	return event.Type
+}
+
+func TestMainSyncProgress(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+	eventsPath := filepath.Join(t.TempDir(), "events.json")
+
+	got := Main([]string{"rsync", "--exodus-progress", eventsPath, srcPath + "/", "exodus:/dest"})
+
+	if got != 0 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	events := readProgress(t, eventsPath)
+
+	described := []string{}
+	for _, event := range events {
+		described = append(described, describeEvent(event))
+	}
+
+	expected := []string{
+		"start",
+		"phase walk",
+		"publish created",
+		"phase upload",
+		"upload uploaded 1/3",
+		"upload duplicate 2/3",
+		"upload uploaded 3/3",
+		"phase publish",
+		"phase commit",
+		"commit started",
+		"commit succeeded",
+		"end 0",
+	}
+	if fmt.Sprint(described) != fmt.Sprint(expected) {
+		t.Errorf("unexpected events\n got: %v\nwant: %v", described, expected)
+	}
+
+	if events[0].Src != srcPath+"/" || events[0].Dest != "exodus:/dest" {
+		t.Errorf("unexpected start event %+v", events[0])
+	}
+	for _, event := range events[2:11] {
+		if event.Env != "best-env" {
+			t.Errorf("unexpected env in %+v", event)
+		}
+	}
+	for _, i := range []int{2, 9} {
+		if events[i].Publish != client.publishes[0].id {
+			t.Errorf("unexpected publish in %+v", events[i])
+		}
+	}
+}
+
+func TestMainSyncProgressJoined(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	publishID := "3e0a4539-be4a-437e-a45f-6d72f7192f17"
+	client.publishes = []FakePublish{{items: make([]gw.ItemInput, 0), id: publishID}}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+	eventsPath := filepath.Join(t.TempDir(), "events.json")
+
+	got := Main([]string{"rsync", "--exodus-progress", eventsPath, "--exodus-publish", publishID,
+		srcPath + "/", "exodus:/dest"})
+
+	if got != 0 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	// Joining a publish is reported, without labels.
+	var joined []progress.Event
+	for _, event := range readProgress(t, eventsPath) {
+		if event.Type == progress.TypePublish {
+			joined = append(joined, event)
+		}
+	}
+	if len(joined) != 1 || joined[0].Status != "joined" || joined[0].Publish != publishID ||
+		joined[0].Env != "best-env" || joined[0].Labels != nil {
+		t.Errorf("unexpected publish events %+v", joined)
+	}
+}
+
+func TestMainSyncProgressFailed(t *testing.T) {
+	SetConfig(t, CONFIG)
+	MockController(t)
+
+	eventsPath := filepath.Join(t.TempDir(), "events.json")
+
+	// No cert/key are configured, so the client can't be created.
+	got := Main([]string{"rsync", "--exodus-progress", eventsPath, ".", "exodus:/dest"})
+
+	if got != 101 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	// Even a failed sync should end the stream with its exit code.
+	events := readProgress(t, eventsPath)
+	last := events[len(events)-1]
+	if describeEvent(last) != "end 101" {
+		t.Errorf("unexpected final event %+v", last)
+	}
+}
+
+func TestMainSyncProgressOpenError(t *testing.T) {
+	SetConfig(t, CONFIG)
+	logs := CaptureLogger(t)
+
+	got := Main([]string{"rsync", "--exodus-progress", "fd:foo", ".", "exodus:/dest"})
+
+	if got != 23 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if FindEntry(logs, "can't open progress stream") == nil {
+		t.Error("missing expected log message")
+	}
+}
+
+func TestMainSyncProgressSnapshot(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+	snapshotPath := filepath.Join(t.TempDir(), "snapshot.json")
+
+	got := Main([]string{"rsync", "--exodus-progress-snapshot", snapshotPath, srcPath + "/", "exodus:/dest"})
+
+	if got != 0 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	// The snapshot left behind describes the end of the sync.
+	content, err := os.ReadFile(snapshotPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snap progress.Snapshot
+	if err := json.Unmarshal(content, &snap); err != nil {
+		t.Fatalf("invalid snapshot %q: %v", content, err)
+	}
+
+	if snap.ExitCode == nil || *snap.ExitCode != 0 || snap.Phase != progress.PhaseCommit ||
+		snap.Commit != "succeeded" || snap.Env != "best-env" || snap.Publish != client.publishes[0].id ||
+		snap.Uploads != (progress.Counts{Done: 3, Total: 3}) {
+		t.Errorf("unexpected snapshot %+v", snap)
+	}
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncRequestID(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	runIDs := []string{}
+	for i := 0; i < 2; i++ {
+		logs := CaptureLogger(t)
+
+		client := FakeClient{blobs: make(map[string]string)}
+		var clientID string
+		mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).DoAndReturn(
+			func(ctx context.Context, _ conf.Config) (gw.Client, error) {
+				clientID = gw.RequestIDFromContext(ctx)
+				return &client, nil
+			})
+
+		got := Main([]string{"rsync", "-vv", srcPath, "exodus:/dest"})
+		if got != 0 {
+			t.Fatal("returned incorrect exit code", got)
+		}
+
+		// The client should have been given an ID to send to exodus-gw.
+		if clientID == "" {
+			t.Fatal("no request ID given to exodus-gw client")
+		}
+
+		// Every log message should include the same ID.
+		if len(logs.Entries) < 2 {
+			t.Fatal("unexpectedly few log entries:", logs.Entries)
+		}
+		for _, entry := range logs.Entries {
+			if entry.Fields["request_id"] != clientID {
+				t.Errorf("entry %q has request_id %v, expected %v",
+					entry.Message, entry.Fields["request_id"], clientID)
+			}
+		}
+
+		runIDs = append(runIDs, clientID)
+	}
+
+	// Each run should have its own ID.
+	if runIDs[0] == runIDs[1] {
+		t.Errorf("runs unexpectedly shared request ID %v", runIDs[0])
+	}
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func captureStdout(t *testing.T) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	old := stdout
+	stdout = buf
+	t.Cleanup(func() { stdout = old })
+	return buf
+}
+
+func TestMainShowConfig(t *testing.T) {
+	SetConfig(t, `
+gwurl: https://exodus-gw.example.com
+gwcommit: phase2
+gwkeycommand: vault read -token=abc123 key
+environments:
+- prefix: exodus
+  gwenv: $TEST_EXODUS_SHOW_ENV
+  uploadthreads: 2
+`)
+	t.Setenv("TEST_EXODUS_SHOW_ENV", "best-env")
+	MockController(t)
+	out := captureStdout(t)
+
+	got := Main([]string{
+		"rsync", "--exodus-show-config", "--exodus-gw-batch-size", "500", ".", "exodus:/dest",
+	})
+
+	if got != 0 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	lines := strings.Split(out.String(), "\n")
+
+	for _, expected := range []string{
+		"# Configuration in effect for exodus:/dest",
+		"# Using environment with prefix: exodus",
+		"gwurl: https://exodus-gw.example.com  # file",
+		"gwcommit: phase2  # file",
+		"gwenv: best-env  # env var $TEST_EXODUS_SHOW_ENV",
+		"uploadthreads: 2  # environment",
+		"gwbatchsize: 500  # argument --exodus-gw-batch-size",
+		"gwpollinterval: 5000  # default",
+		"noproxy: []  # default",
+		"gwkeycommand: <redacted>  # file",
+	} {
+		found := false
+		for _, line := range lines {
+			found = found || line == expected
+		}
+		if !found {
+			t.Errorf("missing line %q in output:\n%s", expected, out)
+		}
+	}
+
+	if strings.Contains(out.String(), "abc123") {
+		t.Errorf("secret not redacted from output:\n%s", out)
+	}
+}
+
+func TestMainShowConfigNoEnvironment(t *testing.T) {
+	SetConfig(t, CONFIG)
+	MockController(t)
+	out := captureStdout(t)
+
+	got := Main([]string{"rsync", "--exodus-show-config", ".", "elsewhere:/dest"})
+
+	if got != 0 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	if !strings.Contains(out.String(), "# No environment matches, using global configuration only\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestMainShowConfigMissing(t *testing.T) {
+	MockController(t)
+	logs := CaptureLogger(t)
+	out := captureStdout(t)
+
+	got := Main([]string{
+		"rsync", "--exodus-show-config", "--exodus-conf", "this-file-does-not-exist.conf", ".", "exodus:/dest",
+	})
+
+	// Rather than running rsync, it should report there's no config.
+	if got != 23 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if FindEntry(logs, "can't load config") == nil {
+		t.Error("missing expected log message")
+	}
+	if out.Len() != 0 {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/progress"
+)
+
+// Patterns with which tools commonly parse the last lines written by rsync.
+var (
+	sentPattern  = regexp.MustCompile(`(?m)^sent ([\d,]+) bytes\s+received ([\d,]+) bytes\s+([\d,.]+) bytes/sec$`)
+	totalPattern = regexp.MustCompile(`(?m)^total size is ([\d,]+)\s+speedup is ([\d,.]+)( \(DRY RUN\))?$`)
+)
+
+func TestMainSyncSummary(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	tests := []struct {
+		name    string
+		args    []string
+		summary bool
+	}{
+		{"verbose", []string{"-v"}, true},
+		{"verbose dry run", []string{"-v", "--dry-run"}, true},
+		{"not verbose", nil, false},
+		{"json output", []string{"-v", "--exodus-output", "json"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG)
+			ctrl := MockController(t)
+			CaptureLogger(t)
+			out := captureStdout(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil).AnyTimes()
+			mockGw.EXPECT().NewDryRunClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil).AnyTimes()
+
+			args := append([]string{"rsync"}, tt.args...)
+			got := Main(append(args, srcPath+"/", "exodus:/dest"))
+
+			if got != 0 {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			sent := sentPattern.FindStringSubmatch(out.String())
+			total := totalPattern.FindStringSubmatch(out.String())
+			if !tt.summary {
+				if sent != nil || total != nil {
+					t.Errorf("unexpected summary in output:\n%s", out.String())
+				}
+				return
+			}
+			if sent == nil || total == nil {
+				t.Fatalf("missing summary in output:\n%s", out.String())
+			}
+
+			// The two copies of hello have the same content, which is sent
+			// only once.
+			if sent[1] != "206" || sent[2] != "0" {
+				t.Errorf("unexpected sent %q, received %q", sent[1], sent[2])
+			}
+			if total[1] != "212" || total[2] != "1.03" {
+				t.Errorf("unexpected total size %q, speedup %q", total[1], total[2])
+			}
+			if (total[3] != "") != strings.Contains(tt.name, "dry run") {
+				t.Errorf("unexpected dry run marker %q", total[3])
+			}
+		})
+	}
+}
+
+func TestSummaryCollector(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	c := newSummaryCollector(false)
+	for _, e := range []progress.Event{
+		{Time: start, Type: progress.TypeStart},
+		{Type: progress.TypePhase, Phase: progress.PhaseUpload, Env: "one"},
+		{Type: progress.TypeUpload, Env: "one", Path: "big", Size: 4000000, Status: "uploaded"},
+		{Type: progress.TypeUpload, Env: "one", Path: "small", Size: 1234, Status: "existing"},
+		{Type: progress.TypePhase, Phase: progress.PhaseUpload, Env: "two"},
+		{Type: progress.TypeUpload, Env: "two", Path: "big", Size: 4000000, Status: "existing"},
+		{Type: progress.TypeUpload, Env: "two", Path: "small", Size: 1234, Status: "uploaded"},
+		{Time: start.Add(4 * time.Second), Type: progress.TypeEnd},
+	} {
+		c.observe(e)
+	}
+
+	out := strings.Builder{}
+	if err := c.write(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	// Content is sent to each environment, but counted once in the total.
+	want := "sent 4,001,234 bytes  received 0 bytes  1,000,308.50 bytes/sec\n" +
+		"total size is 4,001,234  speedup is 1.00\n"
+	if out.String() != want {
+		t.Errorf("got summary %q, want %q", out.String(), want)
+	}
+
+	// Nothing is written for anything other than a sync.
+	out.Reset()
+	if err := newSummaryCollector(false).write(&out); err != nil || out.Len() != 0 {
+		t.Errorf("unexpected summary %q, err = %v", out.String(), err)
+	}
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+// writeVersionedTree creates a tree holding several versions of some content
+// within dir.
+func writeVersionedTree(t *testing.T, dir string) {
+	files := map[string]string{
+		"1.9/a":      "old a",
+		"1.9/old":    "only in old",
+		"1.10/a":     "new a",
+		"1.10/sub/b": "new b",
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMainSyncAliases(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		fail    map[string]bool
+		code    int
+		aliases map[string]string
+	}{
+		{"latest", nil, nil, 0, map[string]string{
+			"/dest/latest/a":     "/dest/1.10/a",
+			"/dest/latest/sub/b": "/dest/1.10/sub/b",
+		}},
+
+		// Aliases of files which couldn't be uploaded aren't published, as
+		// they'd be dangling.
+		{"keep going", []string{"--exodus-keep-going"}, map[string]bool{"b": true}, 27, map[string]string{
+			"/dest/latest/a": "/dest/1.10/a",
+		}},
+		{"keep going, pipelined", []string{"--exodus-keep-going", "--exodus-pipeline"}, map[string]bool{"b": true}, 27,
+			map[string]string{
+				"/dest/latest/a": "/dest/1.10/a",
+			}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG+`
+aliases:
+- pattern: '^/dest/[0-9][^/]*/'
+  alias: /dest/latest/
+`)
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := &failingUploadClient{FakeClient: FakeClient{blobs: map[string]string{}}, fail: tt.fail}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(client, nil)
+
+			srcPath := t.TempDir()
+			writeVersionedTree(t, srcPath)
+
+			argv := append([]string{"rsync"}, tt.args...)
+			got := Main(append(argv, srcPath+"/", "exodus:/dest"))
+
+			if got != tt.code {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			if len(client.publishes) != 1 {
+				t.Fatalf("expected 1 publish, got %v", client.publishes)
+			}
+
+			aliases := map[string]string{}
+			files := 0
+			for _, item := range client.publishes[0].items {
+				if item.LinkTo != "" {
+					aliases[item.WebURI] = item.LinkTo
+				} else {
+					files++
+				}
+			}
+
+			if !reflect.DeepEqual(aliases, tt.aliases) {
+				t.Errorf("got aliases %v, expected %v", aliases, tt.aliases)
+			}
+			if files != 4-len(tt.fail) {
+				t.Errorf("unexpected items %v", client.publishes[0].items)
+			}
+		})
+	}
+}
+
+func TestMainSyncAliasesInvalid(t *testing.T) {
+	SetConfig(t, CONFIG+`loglevel: none
+aliases:
+- pattern: '^/dest/[0-9][^/]*/'
+`)
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	srcPath := t.TempDir()
+	writeVersionedTree(t, srcPath)
+
+	got := Main([]string{"rsync", srcPath + "/", "exodus:/dest"})
+
+	if got != 23 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if FindEntry(logs, "invalid aliases configuration") == nil {
+		t.Error("missing expected log message")
+	}
+	if len(client.publishes) != 0 {
+		t.Errorf("unexpectedly created publishes %v", client.publishes)
+	}
+}
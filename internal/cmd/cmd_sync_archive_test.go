@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func writeArchive(t *testing.T, files map[string]string) string {
+	archive := filepath.Join(t.TempDir(), "content.tar")
+
+	file, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	tw := tar.NewWriter(file)
+	defer tw.Close()
+
+	for name, content := range files {
+		hdr := tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}
+		if err := tw.WriteHeader(&hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return archive
+}
+
+func TestMainSyncArchive(t *testing.T) {
+	archive := writeArchive(t, map[string]string{
+		"hello":         "hello world\n",
+		"subdir/hello2": "hello world\n",
+	})
+
+	tests := []struct {
+		name     string
+		src      string
+		expected map[string]string
+	}{
+		{"slash", archive + "/", map[string]string{
+			"/dest/hello":         "a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447",
+			"/dest/subdir/hello2": "a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447",
+		}},
+		{"no slash", archive, map[string]string{
+			"/dest/content.tar/hello":         "a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447",
+			"/dest/content.tar/subdir/hello2": "a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG)
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			got := Main([]string{"rsync", "--exodus-tar", tt.src, "exodus:/dest"})
+
+			// It should complete successfully.
+			if got != 0 {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			if len(client.publishes) != 1 {
+				t.Fatal("expected to create 1 publish, instead created", len(client.publishes))
+			}
+
+			p := client.publishes[0]
+
+			itemMap := make(map[string]string)
+			for _, item := range p.items {
+				itemMap[item.WebURI] = item.ObjectKey
+
+				if item.ContentType != "text/plain; charset=utf-8" {
+					t.Errorf("unexpected content type for %s: %s", item.WebURI, item.ContentType)
+				}
+			}
+
+			if !reflect.DeepEqual(itemMap, tt.expected) {
+				t.Error("did not publish expected items, published:", itemMap)
+			}
+
+			if p.committed != 1 {
+				t.Error("expected to commit publish (once), instead p.committed ==", p.committed)
+			}
+		})
+	}
+}
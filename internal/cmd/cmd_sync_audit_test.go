@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+// readAuditLog returns the records of the audit log at path.
+func readAuditLog(t *testing.T, path string) []auditRecord {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("can't read audit log, err = %v", err)
+	}
+
+	var out []auditRecord
+	for _, line := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+		var record auditRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid line %q in audit log, err = %v", line, err)
+		}
+		out = append(out, record)
+	}
+	return out
+}
+
+func TestMainSyncAuditLog(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	ctrl := MockController(t)
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil).AnyTimes()
+	mockGw.EXPECT().NewDryRunClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil).AnyTimes()
+
+	config := CONFIG + "auditlog: " + auditPath + "\n"
+
+	// Nothing is audited unless committed.
+	for _, args := range [][]string{{"--dry-run"}, {"--exodus-commit", "none"}} {
+		SetConfig(t, config)
+		CaptureLogger(t)
+		if got := Main(append(append([]string{"rsync"}, args...), srcPath+"/", "exodus:/dest")); got != 0 {
+			t.Fatalf("%v returned incorrect exit code %d", args, got)
+		}
+		if _, err := os.Stat(auditPath); !os.IsNotExist(err) {
+			t.Fatalf("%v unexpectedly wrote audit log, err = %v", args, err)
+		}
+	}
+
+	before := time.Now().UTC()
+	for i := 0; i < 2; i++ {
+		SetConfig(t, config)
+		CaptureLogger(t)
+		got := Main([]string{"rsync", "--exodus-label", "build=1234", srcPath + "/", "exodus:/dest"})
+		if got != 0 {
+			t.Fatal("returned incorrect exit code", got)
+		}
+	}
+
+	// Each publish committed appends a line, without replacing any earlier.
+	records := readAuditLog(t, auditPath)
+	if len(records) != 2 {
+		t.Fatalf("got %d audit records, want 2", len(records))
+	}
+
+	record := records[1]
+	last := client.publishes[len(client.publishes)-1]
+	if record.Time.Before(before) || record.User == "" || record.Host == "" {
+		t.Errorf("unexpected time or identity in %+v", record)
+	}
+	if record.Env != "best-env" || record.Dest != "/dest" || record.Publish != last.id {
+		t.Errorf("unexpected destination in %+v", record)
+	}
+	if record.Task != last.TaskID() {
+		t.Errorf("got task %q, want %q", record.Task, last.TaskID())
+	}
+	if record.Items != len(last.items) || record.Bytes != 212 {
+		t.Errorf("got %d items of %d bytes", record.Items, record.Bytes)
+	}
+	if !reflect.DeepEqual(record.Labels, map[string]string{"build": "1234"}) {
+		t.Errorf("unexpected labels %v", record.Labels)
+	}
+}
+
+func TestMainSyncAuditLogUnwritable(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+	auditPath := filepath.Join(t.TempDir(), "missing", "audit.jsonl")
+
+	SetConfig(t, CONFIG+"loglevel: none\nauditlog: "+auditPath+"\n")
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	got := Main([]string{"rsync", srcPath + "/", "exodus:/dest"})
+
+	// The publish is committed regardless, so the sync doesn't fail.
+	if got != 0 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if len(client.publishes) != 1 || client.publishes[0].committed != 1 {
+		t.Errorf("unexpected publishes %+v", client.publishes)
+	}
+	if FindEntry(logs, "can't write audit log") == nil {
+		t.Error("missing expected log message")
+	}
+}
+
+func TestAppendAuditConcurrent(t *testing.T) {
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	// Labels make each line long enough to be split if it weren't written
+	// all at once.
+	labels := map[string]string{"padding": strings.Repeat("x", 4096)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				record := auditRecord{Publish: fmt.Sprintf("publish-%d-%d", i, j), Labels: labels}
+				if err := appendAudit(auditPath, record); err != nil {
+					t.Error(err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	records := readAuditLog(t, auditPath)
+	if len(records) != 160 {
+		t.Errorf("got %d audit records, want 160", len(records))
+	}
+}
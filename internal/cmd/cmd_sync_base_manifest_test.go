@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncBaseManifest(t *testing.T) {
+	srcPath := t.TempDir()
+	writeFile := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(srcPath, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("same", "same")
+	writeFile("changed", "before")
+	writeFile("removed", "removed")
+
+	manifestPath := filepath.Join(t.TempDir(), "manifest.json")
+
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: map[string]string{}}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).AnyTimes().Return(&client, nil)
+
+	sync := func(extra ...string) {
+		argv := append([]string{"rsync"}, extra...)
+		argv = append(argv, srcPath+"/", "exodus:/dest")
+		if got := Main(argv); got != 0 {
+			t.Fatal("returned incorrect exit code", got)
+		}
+	}
+	publishedURIs := func(publish FakePublish) []string {
+		out := []string{}
+		for _, item := range publish.items {
+			out = append(out, item.WebURI)
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	// The first run writes the manifest of everything published.
+	sync("--exodus-dump-items=" + manifestPath)
+
+	writeFile("changed", "after")
+	writeFile("added", "added")
+	if err := os.Remove(filepath.Join(srcPath, "removed")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Against that manifest, only new and changed items are published, and
+	// the manifest written is still complete.
+	sync("--exodus-base-manifest="+manifestPath, "--exodus-dump-items="+manifestPath)
+
+	if len(client.publishes) != 2 {
+		t.Fatalf("expected 2 publishes, got %v", client.publishes)
+	}
+	if got := publishedURIs(client.publishes[1]); !reflect.DeepEqual(got, []string{"/dest/added", "/dest/changed"}) {
+		t.Errorf("unexpected items published against base manifest: %v", got)
+	}
+
+	entry := FindEntry(logs, "Compared items with base manifest")
+	if entry == nil {
+		t.Fatal("missing log of delta")
+	}
+	for key, value := range map[string]int{"added": 1, "updated": 1, "unchanged": 1, "removed": 1} {
+		if entry.Fields[key] != value {
+			t.Errorf("logged %s %v, want %d", key, entry.Fields[key], value)
+		}
+	}
+	if FindEntry(logs, "Items removed since the base manifest remain published") == nil {
+		t.Error("missing warning of removed items")
+	}
+
+	base, err := readManifest(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(base) != 3 {
+		t.Errorf("expected complete manifest, got %v", base)
+	}
+
+	// With nothing changed since, there's nothing to publish.
+	sync("--exodus-base-manifest=" + manifestPath)
+	if len(client.publishes) != 2 {
+		t.Errorf("unchanged sync created a publish, got %v", client.publishes)
+	}
+}
+
+func TestMainSyncBaseManifestNotCommitted(t *testing.T) {
+	srcPath := t.TempDir()
+	writeFile := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(srcPath, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("same", "same")
+
+	manifestPath := filepath.Join(t.TempDir(), "manifest.json")
+
+	SetConfig(t, CONFIG+"loglevel: none\n")
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := &failingUploadClient{FakeClient: FakeClient{blobs: map[string]string{}}, fail: map[string]bool{}}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).AnyTimes().Return(client, nil)
+
+	sync := func(want int) {
+		got := Main([]string{"rsync", "--exodus-base-manifest=" + manifestPath, "--exodus-dump-items=" + manifestPath,
+			srcPath + "/", "exodus:/dest"})
+		if got != want {
+			t.Fatalf("returned incorrect exit code %d, want %d", got, want)
+		}
+	}
+
+	if got := Main([]string{"rsync", "--exodus-dump-items=" + manifestPath, srcPath + "/", "exodus:/dest"}); got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	// A sync failing before commit leaves the base as it was.
+	writeFile("added", "added")
+	client.fail["added"] = true
+	sync(25)
+
+	if base, err := readManifest(manifestPath); err != nil || len(base) != 1 {
+		t.Fatalf("base manifest was updated by failed sync: %v, %v", base, err)
+	}
+	if FindEntry(logs, "Not updating base manifest, as items weren't all committed") == nil {
+		t.Error("missing expected log message")
+	}
+
+	// So the next sync still publishes what failed.
+	delete(client.fail, "added")
+	sync(0)
+
+	last := client.publishes[len(client.publishes)-1]
+	if len(last.items) != 1 || last.items[0].WebURI != "/dest/added" || last.committed != 1 {
+		t.Errorf("unexpected publish %+v", last)
+	}
+	if base, err := readManifest(manifestPath); err != nil || len(base) != 2 {
+		t.Errorf("base manifest wasn't updated: %v, %v", base, err)
+	}
+}
+
+func TestMainSyncBaseManifestUnreadable(t *testing.T) {
+	SetConfig(t, CONFIG+"loglevel: none\n")
+	logs := CaptureLogger(t)
+
+	got := Main([]string{"rsync", "--exodus-base-manifest=" + filepath.Join(t.TempDir(), "missing.json"),
+		".", "exodus:/dest"})
+	if got != 73 {
+		t.Errorf("returned incorrect exit code %d", got)
+	}
+	if FindEntry(logs, "can't read base manifest") == nil {
+		t.Error("missing expected error log")
+	}
+}
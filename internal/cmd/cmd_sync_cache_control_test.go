@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncCacheControl(t *testing.T) {
+	srcPath := t.TempDir()
+	for _, name := range []string{"repodata/repomd.xml", "Packages/bash.rpm", "readme"} {
+		path := filepath.Join(srcPath, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("readme", filepath.Join(srcPath, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG+`
+gwcachecontrol: true
+cachecontrolrules:
+- pattern: '**/repodata/*'
+  cachecontrol: max-age=300
+- pattern: '*.rpm'
+  cachecontrol: max-age=31536000, immutable
+cachecontroldefault: max-age=3600
+`)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	got := Main([]string{"rsync", "-l", srcPath + "/", "exodus:/dest"})
+	if got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	cacheControl := make(map[string]string)
+	for _, item := range client.publishes[0].items {
+		cacheControl[item.WebURI] = item.CacheControl
+	}
+
+	expected := map[string]string{
+		"/dest/repodata/repomd.xml": "max-age=300",
+		"/dest/Packages/bash.rpm":   "max-age=31536000, immutable",
+		"/dest/readme":              "max-age=3600",
+		// Links are served as their targets.
+		"/dest/link": "",
+	}
+	if !reflect.DeepEqual(cacheControl, expected) {
+		t.Errorf("unexpected cache control %v", cacheControl)
+	}
+}
+
+func TestMainSyncCacheControlInvalid(t *testing.T) {
+	SetConfig(t, CONFIG+"loglevel: none\ngwcachecontrol: true\ncachecontrolrules:\n- pattern: '*.rpm'\n")
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	got := Main([]string{"rsync", ".", "exodus:/dest"})
+	if got != 23 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	if FindEntry(logs, "invalid cachecontrolrules configuration") == nil {
+		t.Error("missing expected error log")
+	}
+	if len(client.publishes) != 0 {
+		t.Errorf("unexpected publishes %v", client.publishes)
+	}
+}
+
+func TestMainSyncCacheControlUnsupported(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{"rules", "cachecontrolrules:\n- pattern: '*.rpm'\n  cachecontrol: max-age=300\n"},
+		{"default", "cachecontroldefault: max-age=3600\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG+"loglevel: none\n"+tt.config)
+			ctrl := MockController(t)
+			logs := CaptureLogger(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			// Without gwcachecontrol, exodus-gw might silently ignore the
+			// Cache-Control of each item.
+			got := Main([]string{"rsync", ".", "exodus:/dest"})
+			if got != 23 {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			if FindEntry(logs, "'cachecontrolrules' and 'cachecontroldefault' require 'gwcachecontrol' in configuration") == nil {
+				t.Error("missing expected error log")
+			}
+			if len(client.publishes) != 0 {
+				t.Errorf("unexpected publishes %v", client.publishes)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncChecksumChoice(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	tests := []struct {
+		choice   string
+		expected int
+	}{
+		{"auto", 0},
+		{"sha256", 0},
+		{"sha256,auto", 0},
+		{"md5", 23},
+		{"xxh128", 23},
+		{"auto,md5", 23},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.choice, func(t *testing.T) {
+			SetConfig(t, CONFIG)
+			ctrl := MockController(t)
+			logs := CaptureLogger(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			if tt.expected == 0 {
+				mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+			}
+
+			got := Main([]string{"rsync", "--checksum-choice", tt.choice, srcPath + "/", "exodus:/dest"})
+
+			if got != tt.expected {
+				t.Error("returned incorrect exit code", got)
+			}
+
+			if tt.expected == 0 {
+				// Keys are SHA-256 digests as always.
+				if _, ok := client.blobs["5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"]; !ok {
+					t.Errorf("did not upload expected blobs, uploaded %v", client.blobs)
+				}
+				return
+			}
+
+			// Nothing should have been attempted if the choice is unsupported.
+			entry := FindEntry(logs, "Unsupported --checksum-choice, exodus-gw only supports sha256")
+			if entry == nil {
+				t.Fatal("missing expected log message")
+			}
+			if entry.Fields["checksum-choice"] != tt.choice {
+				t.Errorf("unexpected checksum-choice in log: %v", entry.Fields["checksum-choice"])
+			}
+		})
+	}
+}
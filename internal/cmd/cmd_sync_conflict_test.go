@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+// A publish whose commit conflicts with another publish.
+type conflictPublish struct {
+	FakePublish
+	conflict bool
+}
+
+func (p *conflictPublish) Commit(ctx context.Context, mode string) error {
+	if p.conflict {
+		return fmt.Errorf("%w: POST /commit: 409 Conflict", gw.ErrConflict)
+	}
+	return p.FakePublish.Commit(ctx, mode)
+}
+
+// A client whose first publishes conflict on commit.
+type conflictClient struct {
+	FakeClient
+	conflicts int
+	uploads   int
+	publishes []*conflictPublish
+}
+
+func (c *conflictClient) EnsureUploaded(ctx context.Context, items []walk.SyncItem,
+	onUploaded func(walk.SyncItem) error,
+	onExisting func(walk.SyncItem) error,
+	onDuplicate func(walk.SyncItem) error,
+) error {
+	c.uploads++
+	return c.FakeClient.EnsureUploaded(ctx, items, onUploaded, onExisting, onDuplicate)
+}
+
+func (c *conflictClient) NewPublish(context.Context) (gw.Publish, error) {
+	p := &conflictPublish{
+		FakePublish: FakePublish{id: fmt.Sprintf("publish-%d", len(c.publishes)+1)},
+		conflict:    len(c.publishes) < c.conflicts,
+	}
+	c.publishes = append(c.publishes, p)
+	return p, nil
+}
+
+func (c *conflictClient) GetPublish(_ context.Context, id string) (gw.Publish, error) {
+	return c.NewPublish(context.Background())
+}
+
+func TestMainSyncConflict(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := conflictRetryDelay
+	conflictRetryDelay = 0
+	t.Cleanup(func() { conflictRetryDelay = old })
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	tests := []struct {
+		name      string
+		args      []string
+		conflicts int
+		want      int
+		publishes int
+		message   string
+	}{
+		{"default fails", nil, 1, 75, 1,
+			"can't commit publish, it conflicts with another publish"},
+		{"fail", []string{"--exodus-on-conflict=fail"}, 1, 75, 1,
+			"can't commit publish, it conflicts with another publish"},
+		{"retry", []string{"--exodus-on-conflict=retry"}, 1, 0, 2,
+			"Publish conflicts with another publish, retrying"},
+		{"retry gives up", []string{"--exodus-on-conflict=retry"}, 10, 75, 1 + conflictRetries,
+			"Publish still conflicts with another publish, giving up"},
+		{"retry joined publish",
+			[]string{"--exodus-on-conflict=retry", "--exodus-publish", "3e0a4539-be4a-437e-a45f-6d72f7192f17", "--exodus-commit", "phase2"}, 1, 75, 1,
+			"can't retry a publish joined via --exodus-publish"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG)
+			ctrl := MockController(t)
+			logs := CaptureLogger(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := &conflictClient{FakeClient: FakeClient{blobs: map[string]string{}}, conflicts: tt.conflicts}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(client, nil)
+
+			args := append([]string{"rsync"}, tt.args...)
+			got := Main(append(args, srcPath+"/", "exodus:/dest"))
+
+			if got != tt.want {
+				t.Error("returned incorrect exit code", got)
+			}
+			if FindEntry(logs, tt.message) == nil {
+				t.Errorf("missing expected log message %q", tt.message)
+			}
+
+			// The whole publish is repeated, uploads included, for each attempt.
+			if len(client.publishes) != tt.publishes || client.uploads != tt.publishes {
+				t.Fatalf("unexpected attempts: %d publishes, %d uploads", len(client.publishes), client.uploads)
+			}
+
+			last := client.publishes[len(client.publishes)-1]
+			if len(last.items) != 3 {
+				t.Errorf("did not add expected items: %v", last.items)
+			}
+			if committed := last.committed == 1; committed != (tt.want == 0) {
+				t.Errorf("unexpected commits of last publish: %d", last.committed)
+			}
+		})
+	}
+}
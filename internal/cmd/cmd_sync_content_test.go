@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncContentRules(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG+`
+contentrules:
+- pattern: '/repodata/.*\.xml\.gz$'
+  contentencoding: gzip
+- pattern: '/other\.txt\.gz$'
+  contenttype: text/plain
+  contentencoding: gzip
+`)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/precompressed")
+
+	got := Main([]string{"rsync", srcPath + "/", "exodus:/dest"})
+
+	if got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	if len(client.publishes) != 1 {
+		t.Fatalf("expected 1 publish, got %v", client.publishes)
+	}
+
+	items := map[string]gw.ItemInput{}
+	for _, item := range client.publishes[0].items {
+		items[item.WebURI] = item
+	}
+
+	tests := []struct {
+		uri         string
+		contentType string
+		encoding    string
+	}{
+		// The type is detected from the decompressed content.
+		{"/dest/repodata/primary.xml.gz", "text/xml; charset=UTF-8", "gzip"},
+
+		// The type is as configured.
+		{"/dest/repodata/other.txt.gz", "text/plain", "gzip"},
+
+		// Files not matching any rule are served as archives, as before.
+		{"/dest/repodata/plain.gz", "application/gzip", ""},
+	}
+
+	for _, tt := range tests {
+		item, ok := items[tt.uri]
+		if !ok {
+			t.Errorf("missing item %s in %v", tt.uri, items)
+			continue
+		}
+		if item.ContentType != tt.contentType || item.ContentEncoding != tt.encoding {
+			t.Errorf("%s: got content type %q, encoding %q; expected %q, %q",
+				tt.uri, item.ContentType, item.ContentEncoding, tt.contentType, tt.encoding)
+		}
+	}
+}
+
+func TestMainSyncContentRulesInvalid(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG+`
+contentrules:
+- pattern: '(\.gz$'
+  contentencoding: gzip
+`)
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/precompressed")
+
+	got := Main([]string{"rsync", srcPath + "/", "exodus:/dest"})
+
+	if got != 23 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if FindEntry(logs, "invalid contentrules configuration") == nil {
+		t.Error("missing expected log message")
+	}
+	if len(client.publishes) != 0 {
+		t.Errorf("unexpectedly published %v", client.publishes)
+	}
+}
+
+func TestMainSyncMIMESniff(t *testing.T) {
+	// Not detected by content alone, but recognized by sniffing.
+	webm := "\x1aE\xdf\xa3"
+
+	tests := []struct {
+		name     string
+		config   string
+		expected map[string]string
+	}{
+		{"default", "", map[string]string{
+			"/dest/video":     "application/octet-stream",
+			"/dest/video.bin": "application/octet-stream",
+		}},
+		{"enabled", "mimesniff: true\n", map[string]string{
+			"/dest/video": "video/webm",
+			// Files with an extension are never sniffed.
+			"/dest/video.bin": "application/octet-stream",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG+tt.config)
+			ctrl := MockController(t)
+
+			srcPath := t.TempDir()
+			for _, name := range []string{"video", "video.bin"} {
+				if err := os.WriteFile(path.Join(srcPath, name), []byte(webm), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			got := Main([]string{"rsync", srcPath + "/", "exodus:/dest"})
+			if got != 0 {
+				t.Fatal("returned incorrect exit code", got)
+			}
+			if len(client.publishes) != 1 {
+				t.Fatalf("expected 1 publish, got %v", client.publishes)
+			}
+
+			types := map[string]string{}
+			for _, item := range client.publishes[0].items {
+				types[item.WebURI] = item.ContentType
+			}
+			if !reflect.DeepEqual(types, tt.expected) {
+				t.Errorf("got content types %v, expected %v", types, tt.expected)
+			}
+		})
+	}
+}
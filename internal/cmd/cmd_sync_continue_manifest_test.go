@@ -0,0 +1,372 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+// readContinueRecords returns the records of the continue manifest at path,
+// decompressed if needed.
+func readContinueRecords(t *testing.T, path string) []continueRecord {
+	content, err := readMaybeCompressed(path)
+	if err != nil {
+		t.Fatalf("can't read continue manifest, err = %v", err)
+	}
+
+	var out []continueRecord
+	for _, line := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+		var record continueRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid line %q in continue manifest, err = %v", line, err)
+		}
+		out = append(out, record)
+	}
+	return out
+}
+
+func TestMainSyncContinueFromManifest(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+	manifestPath := filepath.Join(t.TempDir(), "continue.jsonl")
+
+	ctrl := MockController(t)
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil).AnyTimes()
+
+	args := []string{"rsync", "--exodus-continue-from-manifest", manifestPath, srcPath + "/", "exodus:/dest"}
+
+	// A first sync gets as far as adding items, but isn't committed.
+	SetConfig(t, CONFIG+"gwcommit: none\n")
+	CaptureLogger(t)
+	if got := Main(args); got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	records := readContinueRecords(t, manifestPath)
+	if len(client.publishes) != 1 || records[0].Publish != client.publishes[0].id || records[0].Item != nil {
+		t.Fatalf("unexpected first record %+v", records[0])
+	}
+	allItems := len(client.publishes[0].items)
+
+	// Suppose it had crashed after recording one blob and one item, and
+	// exodus-gw remembers nothing more than what was recorded.
+	var partial []string
+	var recordedBlob string
+	var recordedItem gw.ItemInput
+	for _, record := range records {
+		switch {
+		case record.Blob != "" && recordedBlob == "":
+			recordedBlob = record.Blob
+		case record.Item != nil && recordedItem.WebURI == "":
+			recordedItem = *record.Item
+		case record.Publish != "" && record.Item == nil:
+		default:
+			continue
+		}
+		line, err := json.Marshal(record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		partial = append(partial, string(line))
+	}
+	if recordedBlob == "" || recordedItem.WebURI == "" {
+		t.Fatalf("missing records of blobs or items, got %+v", records)
+	}
+	if err := os.WriteFile(manifestPath, []byte(strings.Join(partial, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	client.blobs = make(map[string]string)
+	client.publishes[0].items = []gw.ItemInput{recordedItem}
+
+	// The second sync continues from the same publish.
+	SetConfig(t, CONFIG)
+	logs := CaptureLogger(t)
+	if got := Main(args); got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	entry := FindEntry(logs, "Continuing publish from manifest")
+	if entry == nil || entry.Fields["publish"] != client.publishes[0].id || entry.Fields["skipped"] != 1 {
+		t.Errorf("missing expected log message, got %v", entry)
+	}
+
+	if len(client.publishes) != 1 {
+		t.Fatalf("unexpectedly created publish, got %d publishes", len(client.publishes))
+	}
+	p := client.publishes[0]
+	if p.committed != 1 {
+		t.Errorf("publish committed %d times", p.committed)
+	}
+
+	// Only what wasn't recorded is uploaded and added again.
+	if _, ok := client.blobs[recordedBlob]; ok {
+		t.Errorf("recorded blob %s was uploaded again", recordedBlob)
+	}
+	if len(client.blobs) == 0 {
+		t.Error("blobs which weren't recorded were not uploaded")
+	}
+	if len(p.items) != allItems {
+		t.Errorf("publish has %d items, want %d", len(p.items), allItems)
+	}
+	for _, item := range p.items[1:] {
+		if item.WebURI == recordedItem.WebURI {
+			t.Errorf("recorded item %s was added again", item.WebURI)
+		}
+	}
+
+	// The publish is finished with, so isn't continued by another sync.
+	records = readContinueRecords(t, manifestPath)
+	if last := records[len(records)-1]; last.Publish != p.id || !last.Finished {
+		t.Errorf("unexpected last record %+v", last)
+	}
+	resume, err := openContinueManifest(context.Background(), manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resume.close()
+	// Nor are its blobs trusted to still be present.
+	if env := resume.env("best-env"); env.publish != "" || len(env.added) != 0 || len(env.blobs) != 0 {
+		t.Errorf("unexpected recorded state %+v", env)
+	}
+}
+
+func TestMainSyncContinueFromManifestExpired(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+	manifestPath := filepath.Join(t.TempDir(), "continue.jsonl")
+
+	// The recorded publish is unknown to exodus-gw, e.g. having expired.
+	record := `{"env":"best-env","publish":"expired-publish"}` + "\n"
+	if err := os.WriteFile(manifestPath, []byte(record), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	got := Main([]string{"rsync", "--exodus-continue-from-manifest", manifestPath, srcPath + "/", "exodus:/dest"})
+
+	if got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	entry := FindEntry(logs, "Can't continue publish from manifest, creating a new publish")
+	if entry == nil || entry.Fields["publish"] != "expired-publish" {
+		t.Errorf("missing expected log message, got %v", entry)
+	}
+	if len(client.publishes) != 1 || client.publishes[0].committed != 1 {
+		t.Fatalf("unexpected publishes %+v", client.publishes)
+	}
+}
+
+func TestMainSyncContinueFromManifestCommitted(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+	manifestPath := filepath.Join(t.TempDir(), "continue.jsonl")
+
+	// The recorded publish was committed, but the sync stopped before
+	// recording so.
+	records := `{"env":"best-env","publish":"committed-publish"}` + "\n" +
+		`{"env":"best-env","blob":"5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"}` + "\n"
+	if err := os.WriteFile(manifestPath, []byte(records), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	client.publishes = []FakePublish{{id: "committed-publish", committed: 1, frozen: true}}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	got := Main([]string{"rsync", "--exodus-continue-from-manifest", manifestPath, srcPath + "/", "exodus:/dest"})
+
+	if got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	entry := FindEntry(logs, "Can't continue publish from manifest, creating a new publish")
+	if entry == nil || entry.Fields["publish"] != "committed-publish" {
+		t.Errorf("missing expected log message, got %v", entry)
+	}
+	if len(client.publishes) != 2 || len(client.publishes[0].items) != 0 || client.publishes[1].committed != 1 {
+		t.Fatalf("unexpected publishes %+v", client.publishes)
+	}
+
+	// The blobs recorded for that publish are uploaded again.
+	if len(client.blobs) != 2 {
+		t.Errorf("unexpected blobs %v", client.blobs)
+	}
+}
+
+func TestMainSyncContinueFromManifestInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		code    int
+		message string
+	}{
+		{"with publish", []string{"--exodus-publish", "3e0a4539-be4a-437e-a45f-6d72f7192f17"},
+			23, "--exodus-continue-from-manifest can't be used with --exodus-publish"},
+		{"unwritable", nil, 73, "can't open continue manifest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifestPath := filepath.Join(t.TempDir(), "missing", "continue.jsonl")
+
+			SetConfig(t, CONFIG+"loglevel: none\n")
+			ctrl := MockController(t)
+			logs := CaptureLogger(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&FakeClient{}, nil).AnyTimes()
+
+			args := append([]string{"rsync", "--exodus-continue-from-manifest", manifestPath}, tt.args...)
+			got := Main(append(args, ".", "exodus:/dest"))
+
+			if got != tt.code {
+				t.Error("returned incorrect exit code", got)
+			}
+			if FindEntry(logs, tt.message) == nil {
+				t.Errorf("missing expected log message %q", tt.message)
+			}
+		})
+	}
+}
+
+func TestMainSyncContinueFromManifestCompressed(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+	manifestPath := filepath.Join(t.TempDir(), "continue.jsonl.gz")
+
+	ctrl := MockController(t)
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil).AnyTimes()
+
+	args := []string{"rsync", "--exodus-continue-from-manifest", manifestPath, srcPath + "/", "exodus:/dest"}
+
+	// A first sync gets as far as adding items, but isn't committed.
+	SetConfig(t, CONFIG+"gwcommit: none\n")
+	CaptureLogger(t)
+	if got := Main(args); got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	raw, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(raw, gzipMagic) {
+		t.Fatalf("continue manifest isn't compressed: %q", raw)
+	}
+	records := readContinueRecords(t, manifestPath)
+	if len(client.publishes) != 1 || records[0].Publish != client.publishes[0].id {
+		t.Fatalf("unexpected first record %+v", records[0])
+	}
+
+	// Suppose it then crashed part way through writing a record.
+	truncated, err := compressFor(manifestPath, []byte(`{"env":"best-env","blob":"abc"}`+"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(manifestPath, append(raw, truncated[:len(truncated)/2]...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The second sync continues from the same publish, despite the truncated
+	// record.
+	SetConfig(t, CONFIG)
+	logs := CaptureLogger(t)
+	if got := Main(args); got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	if FindEntry(logs, "Ignoring the rest of unreadable continue manifest") == nil {
+		t.Error("missing expected warning")
+	}
+	entry := FindEntry(logs, "Continuing publish from manifest")
+	if entry == nil || entry.Fields["publish"] != client.publishes[0].id {
+		t.Errorf("missing expected log message, got %v", entry)
+	}
+	if len(client.publishes) != 1 || client.publishes[0].committed != 1 {
+		t.Errorf("unexpected publishes %v", client.publishes)
+	}
+}
+
+func TestMainSyncContinueManifestBlobKeyPrefix(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+	manifestPath := filepath.Join(t.TempDir(), "continue.jsonl")
+
+	ctrl := MockController(t)
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	SetConfig(t, CONFIG+"blobkeyprefix: tenant/\ngwcommit: none\n")
+	CaptureLogger(t)
+	if got := Main([]string{"rsync", "--exodus-continue-from-manifest", manifestPath, srcPath + "/", "exodus:/dest"}); got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	// Blobs are recorded by their key within the bucket, so they aren't
+	// mistaken for present should the prefix change.
+	blobs := 0
+	for _, record := range readContinueRecords(t, manifestPath) {
+		if record.Blob == "" {
+			continue
+		}
+		blobs++
+		if !strings.HasPrefix(record.Blob, "tenant/") {
+			t.Errorf("blob recorded without prefix: %+v", record)
+		}
+	}
+	if blobs == 0 {
+		t.Error("no blobs were recorded")
+	}
+}
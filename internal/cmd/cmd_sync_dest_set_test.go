@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncDestSet(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	// Pipelining adds items as each upload completes, which must still add
+	// every destination of a file.
+	for _, extra := range [][]string{nil, {"--exodus-pipeline"}} {
+		t.Run(fmt.Sprint(extra), func(t *testing.T) {
+			SetConfig(t, CONFIG)
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			argv := append([]string{"rsync", "--exodus-dest-set", "x86_64,aarch64"}, extra...)
+			got := Main(append(argv, srcPath+"/", "exodus:/content/{arch}/os"))
+			if got != 0 {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			// Everything should be in a single publish.
+			if len(client.publishes) != 1 {
+				t.Fatalf("expected 1 publish, got %v", client.publishes)
+			}
+
+			itemMap := make(map[string]string)
+			for _, item := range client.publishes[0].items {
+				if _, ok := itemMap[item.WebURI]; ok {
+					t.Error("tried to publish this URI more than once:", item.WebURI)
+				}
+				itemMap[item.WebURI] = item.ObjectKey
+			}
+
+			expectedItems := map[string]string{
+				"/content/x86_64/os/hello-copy-one":      "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+				"/content/x86_64/os/hello-copy-two":      "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+				"/content/x86_64/os/subdir/some-binary":  "c66f610d98b2c9fe0175a3e99ba64d7fc7de45046515ff325be56329a9347dd6",
+				"/content/aarch64/os/hello-copy-one":     "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+				"/content/aarch64/os/hello-copy-two":     "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+				"/content/aarch64/os/subdir/some-binary": "c66f610d98b2c9fe0175a3e99ba64d7fc7de45046515ff325be56329a9347dd6",
+			}
+			if !reflect.DeepEqual(itemMap, expectedItems) {
+				t.Errorf("unexpected items %v", itemMap)
+			}
+
+			// Content shared by the destinations is uploaded once.
+			if len(client.blobs) != 2 {
+				t.Errorf("unexpected blobs %v", client.blobs)
+			}
+		})
+	}
+}
+
+func TestMainSyncDestSetInvalid(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	tests := []struct {
+		name   string
+		set    string
+		dest   string
+		errMsg string
+	}{
+		{"no placeholder", "x86_64,aarch64", "exodus:/content/os",
+			"DEST must contain one placeholder such as {arch} for use with --exodus-dest-set, found 0"},
+		{"several placeholders", "x86_64", "exodus:/content/{arch}/{variant}",
+			"DEST must contain one placeholder such as {arch} for use with --exodus-dest-set, found 2"},
+		{"duplicate value", "x86_64,x86_64", "exodus:/content/{arch}/os",
+			"duplicate value 'x86_64' in --exodus-dest-set"},
+		{"escaping value", "x86_64,..", "exodus:/content/{arch}/os",
+			"invalid value '..' in --exodus-dest-set, must be a single path component"},
+		{"nested value", "x86_64/debug", "exodus:/content/{arch}/os",
+			"invalid value 'x86_64/debug' in --exodus-dest-set, must be a single path component"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG+"loglevel: none\n")
+			logs := CaptureLogger(t)
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			got := Main([]string{"rsync", "--exodus-dest-set", tt.set, srcPath + "/", tt.dest})
+			if got != 23 {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			entry := FindEntry(logs, "can't expand destination")
+			if entry == nil {
+				t.Fatal("missing expected log message")
+			}
+			if entry.Fields["error"].(error).Error() != tt.errMsg {
+				t.Errorf("unexpected error: %v", entry.Fields["error"])
+			}
+
+			// Nothing should be uploaded or published.
+			if len(client.blobs) != 0 || len(client.publishes) != 0 {
+				t.Errorf("unexpected blobs %v, publishes %v", client.blobs, client.publishes)
+			}
+		})
+	}
+}
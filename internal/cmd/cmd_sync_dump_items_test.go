@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncDumpItems(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	sizes := map[string]int64{
+		"/dest/hello-copy-one":     6,
+		"/dest/hello-copy-two":     6,
+		"/dest/subdir/some-binary": 200,
+	}
+
+	tests := []struct {
+		name   string
+		file   string
+		format string
+		read   func(t *testing.T, path string) []dumpedItem
+	}{
+		{"json by extension", "items.json", "", readDumpedJSON},
+		{"csv by extension", "items.CSV", "", readDumpedCSV},
+		{"format overrides extension", "items.json", "csv", readDumpedCSV},
+		{"format without extension", "items", "json", readDumpedJSON},
+		{"compressed json by extension", "items.json.gz", "", readDumpedJSON},
+		{"compressed csv by extension", "items.csv.gz", "", readDumpedCSV},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG)
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			dumpPath := filepath.Join(t.TempDir(), tt.file)
+			rsyncArgs := []string{"rsync", "--exodus-dump-items", dumpPath}
+			if tt.format != "" {
+				rsyncArgs = append(rsyncArgs, "--exodus-dump-format", tt.format)
+			}
+
+			got := Main(append(rsyncArgs, srcPath+"/", "exodus:/dest"))
+			if got != 0 {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			if len(client.publishes) != 1 {
+				t.Fatalf("expected 1 publish, got %v", client.publishes)
+			}
+
+			var want []dumpedItem
+			for _, item := range client.publishes[0].items {
+				want = append(want, dumpedItem{
+					WebURI:          item.WebURI,
+					ObjectKey:       item.ObjectKey,
+					ContentType:     item.ContentType,
+					ContentEncoding: item.ContentEncoding,
+					LinkTo:          item.LinkTo,
+					Size:            sizes[item.WebURI],
+				})
+			}
+			if len(want) != len(sizes) {
+				t.Fatalf("unexpected published items %v", client.publishes[0].items)
+			}
+
+			if dumped := tt.read(t, dumpPath); !reflect.DeepEqual(dumped, want) {
+				t.Errorf("dumped items %v, want %v", dumped, want)
+			}
+
+			// The items can be read back as a base manifest, compressed or
+			// not, if named for their format.
+			if tt.format == "" {
+				base, err := readManifest(dumpPath)
+				if err != nil || len(base) != len(want) {
+					t.Errorf("can't read items as manifest: %v, err = %v", base, err)
+				}
+			}
+
+			raw, err := os.ReadFile(dumpPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.HasPrefix(raw, gzipMagic) != isCompressedPath(dumpPath) {
+				t.Errorf("unexpected compression of %s", dumpPath)
+			}
+		})
+	}
+}
+
+func TestMainSyncDumpItemsUnknownFormat(t *testing.T) {
+	SetConfig(t, CONFIG+"loglevel: none\n")
+	logs := CaptureLogger(t)
+
+	dumpPath := filepath.Join(t.TempDir(), "items.txt")
+
+	got := Main([]string{"rsync", "--exodus-dump-items", dumpPath, ".", "exodus:/dest"})
+	if got != 23 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	entry := FindEntry(logs, "can't dump items")
+	if entry == nil {
+		t.Fatal("missing expected log message")
+	}
+	if _, err := os.Stat(dumpPath); !os.IsNotExist(err) {
+		t.Errorf("unexpected dump file, stat: %v", err)
+	}
+}
+
+func TestMainSyncDumpItemsWriteFails(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	SetConfig(t, CONFIG+"loglevel: none\n")
+	logs := CaptureLogger(t)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	dumpPath := filepath.Join(t.TempDir(), "no-such-dir", "items.json")
+
+	got := Main([]string{"rsync", "--exodus-dump-items", dumpPath, srcPath + "/", "exodus:/dest"})
+	if got != 73 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	if FindEntry(logs, "can't dump items") == nil {
+		t.Fatal("missing expected log message")
+	}
+
+	// Nothing should be published.
+	if len(client.publishes) != 0 {
+		t.Errorf("unexpected publishes %v", client.publishes)
+	}
+}
+
+func readDumpedJSON(t *testing.T, path string) []dumpedItem {
+	data, err := readMaybeCompressed(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out []dumpedItem
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("invalid JSON %q: %v", data, err)
+	}
+	return out
+}
+
+func readDumpedCSV(t *testing.T, path string) []dumpedItem {
+	data, err := readMaybeCompressed(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) == 0 || !reflect.DeepEqual(rows[0],
+		[]string{"web_uri", "object_key", "content_type", "content_encoding", "link_to", "size", "labels"}) {
+		t.Fatalf("unexpected header in %v", rows)
+	}
+
+	var out []dumpedItem
+	for _, row := range rows[1:] {
+		size, err := strconv.ParseInt(row[5], 10, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		item := dumpedItem{
+			WebURI:          row[0],
+			ObjectKey:       row[1],
+			ContentType:     row[2],
+			ContentEncoding: row[3],
+			LinkTo:          row[4],
+			Size:            size,
+		}
+		if row[6] != "" {
+			if err := json.Unmarshal([]byte(row[6]), &item.Labels); err != nil {
+				t.Fatal(err)
+			}
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+func TestMainSyncDumpItemsLabels(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	want := map[string]string{"build": "1234"}
+
+	for _, tt := range []struct {
+		file string
+		read func(t *testing.T, path string) []dumpedItem
+	}{
+		{"items.json", readDumpedJSON},
+		{"items.csv", readDumpedCSV},
+	} {
+		t.Run(tt.file, func(t *testing.T) {
+			SetConfig(t, CONFIG)
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			dumpPath := filepath.Join(t.TempDir(), tt.file)
+			got := Main([]string{"rsync", "--exodus-dump-items", dumpPath, "--exodus-label", "build=1234",
+				srcPath + "/", "exodus:/dest"})
+			if got != 0 {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			dumped := tt.read(t, dumpPath)
+			if len(dumped) == 0 {
+				t.Fatal("no items dumped")
+			}
+			for _, item := range dumped {
+				if !reflect.DeepEqual(item.Labels, want) {
+					t.Errorf("dumped %v with labels %v, want %v", item.WebURI, item.Labels, want)
+				}
+			}
+
+			// Labelled items can still be read back as a manifest.
+			if base, err := readManifest(dumpPath); err != nil || len(base) != len(dumped) {
+				t.Errorf("can't read items as manifest: %v, err = %v", base, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+const emptyKey = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+func TestMainSyncEmptyFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		expected map[string]string
+	}{
+		{"default", CONFIG, map[string]string{
+			"/dest/empty": emptyKey,
+			"/dest/hello": "a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447",
+		}},
+		{"skipped", CONFIG + "\nskipemptyfiles: true\n", map[string]string{
+			"/dest/hello": "a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srcPath := t.TempDir()
+			if err := os.WriteFile(filepath.Join(srcPath, "empty"), []byte{}, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(srcPath, "hello"), []byte("hello world\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			SetConfig(t, tt.config)
+			logs := CaptureLogger(t)
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			got := Main([]string{"rsync", srcPath + "/", "exodus:/dest"})
+
+			// It should complete successfully.
+			if got != 0 {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			itemMap := make(map[string]string)
+			for _, item := range client.publishes[0].items {
+				itemMap[item.WebURI] = item.ObjectKey
+			}
+
+			if !reflect.DeepEqual(itemMap, tt.expected) {
+				t.Error("did not publish expected items, published:", itemMap)
+			}
+
+			// The empty blob should be uploaded if and only if it's published.
+			_, uploaded := client.blobs[emptyKey]
+			_, published := tt.expected["/dest/empty"]
+			if uploaded != published {
+				t.Errorf("empty blob uploaded = %v, expected %v", uploaded, published)
+			}
+
+			// Skipping should be warned about.
+			if (FindEntry(logs, "Skipping empty file") != nil) == published {
+				t.Error("unexpected presence/absence of warning for skipped file")
+			}
+		})
+	}
+}
+
+func TestMainSyncOnEmpty(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Everything in this tree is excluded by each test.
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantCode  int
+		publishes int
+		committed int
+		message   string
+	}{
+		{"default", nil, 0, 0, 0, "No items to publish, not creating a publish"},
+		{"skip", []string{"--exodus-on-empty=skip"}, 0, 0, 0, "No items to publish, not creating a publish"},
+		{"error", []string{"--exodus-on-empty=error"}, 29, 0, 0, "No items to publish"},
+		{"commit empty", []string{"--exodus-on-empty=commit-empty"}, 0, 1, 1, "No items to publish, committing an empty publish"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG)
+			ctrl := MockController(t)
+			logs := CaptureLogger(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			argv := append([]string{"rsync", "--exclude", "*"}, tt.args...)
+			argv = append(argv, srcPath+"/", "exodus:/dest")
+
+			got := Main(argv)
+			if got != tt.wantCode {
+				t.Errorf("returned incorrect exit code %d, wanted %d", got, tt.wantCode)
+			}
+
+			if len(client.publishes) != tt.publishes {
+				t.Fatalf("expected %d publishes, got %v", tt.publishes, client.publishes)
+			}
+			if tt.publishes > 0 && client.publishes[0].committed != tt.committed {
+				t.Errorf("publish committed %d times, wanted %d", client.publishes[0].committed, tt.committed)
+			}
+
+			if FindEntry(logs, tt.message) == nil {
+				t.Errorf("missing expected log message %q", tt.message)
+			}
+		})
+	}
+}
+
+func TestMainSyncOnEmptyJoined(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	client.publishes = []FakePublish{{id: "3e0a4539-be4a-437e-a45f-6d72f7192f17"}}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	// A joined publish is committed as usual, as it may hold other items.
+	got := Main([]string{"rsync", "--exclude", "*", "--exodus-publish", "3e0a4539-be4a-437e-a45f-6d72f7192f17",
+		"--exodus-commit", "phase1", "--exodus-on-empty=error", srcPath + "/", "exodus:/dest"})
+
+	if got != 0 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if client.publishes[0].committed != 1 {
+		t.Errorf("joined publish was not committed, %v", client.publishes)
+	}
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncEnvFilters(t *testing.T) {
+	srcPath := t.TempDir()
+	for _, name := range []string{"pkg.rpm", "pkg.src.rpm", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(srcPath, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		config string
+		want   []string
+	}{
+		{"global", "exclude: ['*.src.rpm']\nenvironments:\n- prefix: exodus\n  gwenv: best-env\n",
+			[]string{"/dest/pkg.rpm"}},
+
+		{"environment", "environments:\n- prefix: exodus\n  gwenv: best-env\n  exclude: ['*.src.rpm']\n",
+			[]string{"/dest/pkg.rpm"}},
+
+		// The environment's includes except its own excludes.
+		{"environment include", "environments:\n- prefix: exodus\n  gwenv: best-env\n" +
+			"  exclude: ['*.rpm']\n  include: [pkg.src.rpm]\n",
+			[]string{"/dest/pkg.src.rpm"}},
+
+		// An environment's filters replace the global filters.
+		{"environment replaces global", "exclude: ['*.rpm']\nenvironments:\n- prefix: exodus\n  gwenv: best-env\n" +
+			"  exclude: ['*.src.rpm']\n",
+			[]string{"/dest/pkg.rpm"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, tt.config)
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: map[string]string{}}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			// The command line includes every RPM, but the environment's
+			// filters apply first.
+			got := Main([]string{"rsync", "--include", "*.rpm", "--exclude", "*", srcPath + "/", "exodus:/dest"})
+			if got != 0 {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			if len(client.publishes) != 1 {
+				t.Fatalf("expected one publish, got %v", client.publishes)
+			}
+			uris := []string{}
+			for _, item := range client.publishes[0].items {
+				uris = append(uris, item.WebURI)
+			}
+			sort.Strings(uris)
+			if !reflect.DeepEqual(uris, tt.want) {
+				t.Errorf("published %v, want %v", uris, tt.want)
+			}
+		})
+	}
+}
+
+func TestMainSyncEnvFiltersSingleFile(t *testing.T) {
+	srcPath := t.TempDir()
+	for _, name := range []string{"pkg.rpm", "pkg.src.rpm"} {
+		if err := os.WriteFile(filepath.Join(srcPath, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"pkg.rpm", "pkg.src.rpm"} {
+		t.Run(name, func(t *testing.T) {
+			SetConfig(t, "exclude: ['*.src.rpm']\nenvironments:\n- prefix: exodus\n  gwenv: best-env\n")
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: map[string]string{}}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil).AnyTimes()
+
+			// A SRC which is a single file is filtered by its name.
+			got := Main([]string{"rsync", filepath.Join(srcPath, name), "exodus:/dest/" + name})
+			if got != 0 {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			uris := []string{}
+			for _, publish := range client.publishes {
+				for _, item := range publish.items {
+					uris = append(uris, item.WebURI)
+				}
+			}
+			want := []string{}
+			if name == "pkg.rpm" {
+				want = []string{"/dest/pkg.rpm"}
+			}
+			if !reflect.DeepEqual(uris, want) {
+				t.Errorf("published %v, want %v", uris, want)
+			}
+		})
+	}
+}
+
+func TestMainSyncEnvFiltersMixed(t *testing.T) {
+	srcPath := t.TempDir()
+
+	environment := "environments:\n- prefix: exodus-mixed\n  gwenv: best-env\n  rsyncmode: mixed\n"
+
+	t.Run("exclude", func(t *testing.T) {
+		SetConfig(t, "exclude: ['*.src.rpm']\n"+environment)
+		ctrl := MockController(t)
+		logs := CaptureLogger(t)
+
+		// rsync just echoes its arguments.
+		ext.rsync = &fakeRsync{delegate: ext.rsync, prefix: []string{"/bin/sh", "-c", `echo "$@"`, "--"}}
+
+		mockGw := gw.NewMockInterface(ctrl)
+		ext.gw = mockGw
+		mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&FakeClient{blobs: map[string]string{}}, nil)
+
+		got := Main([]string{"rsync", "--include", "*.rpm", srcPath + "/", "exodus-mixed:/dest"})
+		if got != 0 {
+			t.Fatal("returned incorrect exit code", got)
+		}
+
+		// rsync is given the excludes from configuration before the
+		// command line's filters.
+		rsyncText := ""
+		for _, entry := range logs.Entries {
+			if _, ok := entry.Fields["rsync"]; ok {
+				rsyncText += entry.Message + "\n"
+			}
+		}
+		if !strings.Contains(rsyncText, "--exclude *.src.rpm --include *.rpm") {
+			t.Errorf("rsync not given configured excludes, output: %q", rsyncText)
+		}
+	})
+
+	t.Run("include", func(t *testing.T) {
+		SetConfig(t, "loglevel: none\nexclude: ['*.rpm']\ninclude: [pkg.rpm]\n"+environment)
+		MockController(t)
+		logs := CaptureLogger(t)
+
+		ext.rsync = &fakeRsync{err: fmt.Errorf("this test is not supposed to run rsync")}
+
+		got := Main([]string{"rsync", srcPath + "/", "exodus-mixed:/dest"})
+		if got != 23 {
+			t.Error("returned incorrect exit code", got)
+		}
+		if FindEntry(logs, "'include' configuration can't be used in mixed mode") == nil {
+			t.Error("missing expected log message")
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncExpireAfter(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: map[string]string{}}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).DoAndReturn(
+		func(ctx context.Context, _ conf.Config) (gw.Client, error) {
+			if got := gw.ExpireAfterFromContext(ctx); got != 72*time.Hour {
+				t.Errorf("client created with expiration %v", got)
+			}
+			return &client, nil
+		})
+
+	if got := Main([]string{"rsync", "--exodus-expire-after=72h", srcPath + "/", "exodus:/dest"}); got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+	if len(client.publishes) != 1 {
+		t.Errorf("expected one publish, got %v", client.publishes)
+	}
+}
+
+func TestMainSyncExpireAfterJoined(t *testing.T) {
+	SetConfig(t, CONFIG+"loglevel: none\n")
+	logs := CaptureLogger(t)
+
+	got := Main([]string{"rsync", "--exodus-expire-after=72h",
+		"--exodus-publish=3e0a4539-be4a-437e-a45f-6d72f7192f17", ".", "exodus:/dest"})
+	if got != 23 {
+		t.Errorf("returned incorrect exit code %d", got)
+	}
+	if FindEntry(logs, "--exodus-expire-after can't be used with --exodus-publish") == nil {
+		t.Error("missing expected error log")
+	}
+}
+
+func TestMainSyncExpireAfterTooShort(t *testing.T) {
+	SetConfig(t, CONFIG+"loglevel: none\n")
+	logs := CaptureLogger(t)
+
+	got := Main([]string{"rsync", "--exodus-expire-after=500ms", ".", "exodus:/dest"})
+	if got != 23 {
+		t.Errorf("returned incorrect exit code %d", got)
+	}
+	if FindEntry(logs, "--exodus-expire-after must be at least 1s") == nil {
+		t.Error("missing expected error log")
+	}
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncFixContentTypes(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Times(2).Return(&client, nil)
+
+	// Content is first published with the detected content types...
+	SetConfig(t, CONFIG)
+	if got := Main([]string{"rsync", srcPath + "/", "exodus:/dest"}); got != 0 {
+		t.Fatal("initial sync returned incorrect exit code", got)
+	}
+
+	blobs := map[string]string{}
+	for key, src := range client.blobs {
+		blobs[key] = src
+	}
+
+	// ...then republished with the types given by rules.
+	SetConfig(t, CONFIG+`
+contentrules:
+- pattern: '/hello-copy-[a-z]+$'
+  contenttype: text/x-greeting
+`)
+	if got := Main([]string{"rsync", "--exodus-fix-content-types", srcPath + "/", "exodus:/dest"}); got != 0 {
+		t.Fatal("fixing content types returned incorrect exit code", got)
+	}
+
+	if len(client.publishes) != 2 {
+		t.Fatalf("expected 2 publishes, got %v", client.publishes)
+	}
+
+	// Nothing was uploaded again.
+	if !reflect.DeepEqual(client.blobs, blobs) {
+		t.Errorf("blobs changed from %v to %v", blobs, client.blobs)
+	}
+
+	first := map[string]gw.ItemInput{}
+	for _, item := range client.publishes[0].items {
+		first[item.WebURI] = item
+	}
+
+	fixed := client.publishes[1]
+	if fixed.committed != 1 || len(fixed.items) != 3 {
+		t.Fatalf("unexpected publish %+v", fixed)
+	}
+
+	for _, item := range fixed.items {
+		expected := first[item.WebURI]
+		if item.WebURI != "/dest/subdir/some-binary" {
+			expected.ContentType = "text/x-greeting"
+		}
+		if !reflect.DeepEqual(item, expected) {
+			t.Errorf("got item %+v, expected %+v", item, expected)
+		}
+	}
+}
+
+func TestMainSyncFixContentTypesMissingBlob(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	SetConfig(t, CONFIG+"loglevel: none\n")
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	got := Main([]string{"rsync", "--exodus-fix-content-types", srcPath + "/", "exodus:/dest"})
+
+	// It should fail without uploading or committing anything.
+	if got != 25 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if FindEntry(logs, "can't upload files") == nil {
+		t.Error("missing expected log message")
+	}
+	if len(client.blobs) != 0 {
+		t.Errorf("unexpectedly uploaded %v", client.blobs)
+	}
+	if len(client.publishes) != 1 || client.publishes[0].committed != 0 || len(client.publishes[0].items) != 0 {
+		t.Errorf("unexpected publishes %+v", client.publishes)
+	}
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncForceUpload(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	tests := []struct {
+		name     string
+		patterns []string
+		uploaded []string
+	}{
+		{"by name", []string{"some-binary"}, []string{"subdir/some-binary"}},
+		{"by web URI", []string{"/dest/hello-copy-*"}, []string{"hello-copy-one"}},
+		{"no match", []string{"*.iso"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG)
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: map[string]string{}}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil).Times(2)
+
+			// The first sync uploads everything...
+			captureStdout(t)
+			if got := Main([]string{"rsync", srcPath + "/", "exodus:/dest"}); got != 0 {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			// ...after which only matching files are uploaded again.
+			out := captureStdout(t)
+			args := []string{"rsync", "--exodus-output", "json"}
+			for _, pattern := range tt.patterns {
+				args = append(args, "--exodus-force-upload", pattern)
+			}
+			if got := Main(append(args, srcPath+"/", "exodus:/dest")); got != 0 {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			var result syncResult
+			if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+				t.Fatalf("output is not valid JSON, err = %v:\n%s", err, out.String())
+			}
+			uploaded := []string{}
+			for _, item := range result.Items {
+				if item.Status == "uploaded" {
+					uploaded = append(uploaded, item.Path[len(srcPath)+1:])
+				}
+			}
+			if !reflect.DeepEqual(uploaded, tt.uploaded) {
+				t.Errorf("uploaded %v, want %v", uploaded, tt.uploaded)
+			}
+			// The two copies of hello have the same content, which is
+			// uploaded at most once.
+			if result.Stats.Existing+result.Stats.Duplicate != 3-len(tt.uploaded) {
+				t.Errorf("unexpected stats %+v", result.Stats)
+			}
+		})
+	}
+}
+
+func TestMainSyncForceUploadInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		message string
+	}{
+		{"bad pattern", []string{"--exodus-force-upload=[abc"}, "invalid --exodus-force-upload"},
+		{"fix content types", []string{"--exodus-force-upload=*.rpm", "--exodus-fix-content-types"},
+			"--exodus-force-upload can't be used with --exodus-fix-content-types"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG+"loglevel: none\n")
+			logs := CaptureLogger(t)
+
+			args := append([]string{"rsync"}, tt.args...)
+			got := Main(append(args, ".", "exodus:/dest"))
+			if got != 23 {
+				t.Errorf("returned incorrect exit code %d", got)
+			}
+			if FindEntry(logs, tt.message) == nil {
+				t.Error("missing expected error log")
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncGlob(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dataPath := path.Clean(wd + "/../../test/data")
+	srcPath := dataPath + "/srctrees/just-files"
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{"braces and wildcard", []string{srcPath + "/{hello-copy-one,subdir/*}"},
+			[]string{"/dest/hello-copy-one", "/dest/subdir/some-binary"}},
+
+		{"wildcard", []string{srcPath + "/hello-*"},
+			[]string{"/dest/hello-copy-one", "/dest/hello-copy-two"}},
+
+		// As with rsync, --relative retains the path of the source.
+		{"relative", []string{"--relative", "srctrees/just-files/sub*/*"},
+			[]string{"/dest/srctrees/just-files/subdir/some-binary"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Relative patterns are relative to the test data rather than
+			// the directory of the config.
+			SetConfig(t, CONFIG)
+			conf, err := os.Getwd()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chdir(dataPath); err != nil {
+				t.Fatal(err)
+			}
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			args := append([]string{"rsync", "--exodus-glob", "--exodus-conf", conf + "/exodus-rsync.conf"}, tt.args...)
+			got := Main(append(args, "exodus:/dest"))
+
+			if got != 0 {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			uris := []string{}
+			for _, item := range client.publishes[0].items {
+				uris = append(uris, item.WebURI)
+			}
+			sort.Strings(uris)
+
+			if !reflect.DeepEqual(uris, tt.expected) {
+				t.Errorf("published %q, expected %q", uris, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMainSyncGlobNoMatch(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	got := Main([]string{"rsync", "--exodus-glob", srcPath + "/{hello-*,*.rpm}", "exodus:/dest"})
+
+	// It should fail rather than publish only some of the requested files.
+	if got != 73 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if FindEntry(logs, "can't expand source") == nil {
+		t.Error("missing expected log message")
+	}
+	if len(client.publishes) != 0 {
+		t.Errorf("unexpectedly published %v", client.publishes)
+	}
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/gw/gwtest"
+)
+
+func TestMainSyncInMemoryGw(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	tests := []struct {
+		name      string
+		failAt    gwtest.Step
+		exitCode  int
+		committed bool
+	}{
+		{"success", "", 0, true},
+		{"add items fails", gwtest.StepAddItems, 51, false},
+		{"commit fails", gwtest.StepCommit, 71, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG+"loglevel: none\n")
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := gwtest.NewClient()
+			if tt.failAt != "" {
+				client.FailAt(tt.failAt, fmt.Errorf("simulated error"))
+			}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(client, nil)
+
+			got := Main([]string{"rsync", srcPath + "/", "exodus:/dest"})
+			if got != tt.exitCode {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			publishes := client.Publishes()
+			if len(publishes) != 1 {
+				t.Fatalf("expected 1 publish, got %v", publishes)
+			}
+			if publishes[0].Committed() != tt.committed {
+				t.Errorf("publish committed: %v", publishes[0].Committed())
+			}
+
+			items, _ := publishes[0].Items(context.Background())
+			if tt.failAt != gwtest.StepAddItems && len(items) != 3 {
+				t.Errorf("unexpected items %v", items)
+			}
+			if len(client.Blobs()) != 2 {
+				t.Errorf("unexpected blobs %v", client.Blobs())
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+// A publish recording whether a lock file still existed when committed.
+type holdPublish struct {
+	FakePublish
+	lock           string
+	lockedAtCommit bool
+}
+
+func (p *holdPublish) Commit(ctx context.Context, mode string) error {
+	_, err := os.Stat(p.lock)
+	p.lockedAtCommit = err == nil
+	return p.FakePublish.Commit(ctx, mode)
+}
+
+type holdClient struct {
+	FakeClient
+	publish *holdPublish
+}
+
+func (c *holdClient) NewPublish(context.Context) (gw.Publish, error) {
+	return c.publish, nil
+}
+
+func TestMainSyncHoldCommitLockFile(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shortHoldPoll(t)
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	lock := filepath.Join(t.TempDir(), "go-live.lock")
+	if err := os.WriteFile(lock, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	publish := &holdPublish{FakePublish: FakePublish{id: "3e0a4539-be4a-437e-a45f-6d72f7192f17"}, lock: lock}
+	client := &holdClient{FakeClient{blobs: map[string]string{}}, publish}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(client, nil)
+
+	// The lock is released after a while, which is the signal to commit.
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		os.Remove(lock)
+	}()
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	got := Main([]string{"rsync", "--exodus-hold-commit", lock, srcPath + "/", "exodus:/dest"})
+
+	if got != 0 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	if FindEntry(logs, "Holding commit until signalled") == nil {
+		t.Error("missing expected log message")
+	}
+
+	if len(publish.items) != 3 || publish.committed != 1 {
+		t.Errorf("did not publish as expected: %v", publish.FakePublish)
+	}
+	if publish.lockedAtCommit {
+		t.Error("committed while the lock file still existed")
+	}
+}
+
+func TestMainSyncHoldCommitAbort(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: map[string]string{}}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	fifo := filepath.Join(t.TempDir(), "go-live")
+	if err := syscall.Mkfifo(fifo, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		f, err := os.OpenFile(fifo, os.O_WRONLY, 0)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		f.WriteString("abort\n")
+	}()
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	got := Main([]string{"rsync", "--exodus-hold-commit", fifo, srcPath + "/", "exodus:/dest"})
+
+	if got != 45 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	if FindEntry(logs, "commit hold was not released, not committing publish") == nil {
+		t.Error("missing expected log message")
+	}
+
+	// The content is on the publish, but not visible.
+	if len(client.publishes) != 1 || len(client.publishes[0].items) != 3 || client.publishes[0].committed != 0 {
+		t.Errorf("did not publish as expected: %v", client.publishes)
+	}
+}
+
+func TestMainSyncHoldCommitMissing(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG)
+	MockController(t)
+	logs := CaptureLogger(t)
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+	lock := filepath.Join(t.TempDir(), "no-such-lock")
+
+	got := Main([]string{"rsync", "--exodus-hold-commit", lock, srcPath + "/", "exodus:/dest"})
+
+	// It should fail before doing anything, rather than commit without waiting.
+	if got != 23 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if FindEntry(logs, "can't hold commit") == nil {
+		t.Error("missing expected log message")
+	}
+}
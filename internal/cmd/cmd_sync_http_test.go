@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncHTTPSource(t *testing.T) {
+	files := map[string]string{
+		"/files/hello":        "hello world\n",
+		"/files/subdir/other": "other content\n",
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, content)
+	})
+	mux.HandleFunc("/moved/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/files/hello", http.StatusMovedPermanently)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	filesFrom := filepath.Join(t.TempDir(), "files")
+	if err := os.WriteFile(filesFrom, []byte("hello\nsubdir/other\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"single file", []string{server.URL + "/files/hello", "exodus:/dest/hello"},
+			[]string{"/dest/hello"}},
+		{"redirected", []string{server.URL + "/moved/hello", "exodus:/dest/hello"},
+			[]string{"/dest/hello"}},
+		{"files from", []string{"--files-from", filesFrom, server.URL + "/files/", "exodus:/dest"},
+			[]string{"/dest/hello", "/dest/subdir/other"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG)
+			ctrl := MockController(t)
+			CaptureLogger(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			got := Main(append([]string{"rsync"}, tt.args...))
+
+			if got != 0 {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			if len(client.publishes) != 1 || client.publishes[0].committed != 1 {
+				t.Fatalf("unexpected publishes %+v", client.publishes)
+			}
+
+			var uris []string
+			for _, item := range client.publishes[0].items {
+				uris = append(uris, item.WebURI)
+				if item.ContentType != "text/plain; charset=utf-8" {
+					t.Errorf("unexpected content type %q of %s", item.ContentType, item.WebURI)
+				}
+			}
+			sort.Strings(uris)
+			if !reflect.DeepEqual(uris, tt.want) {
+				t.Errorf("published %v, want %v", uris, tt.want)
+			}
+			if len(client.blobs) != len(tt.want) {
+				t.Errorf("uploaded %v", client.blobs)
+			}
+		})
+	}
+}
+
+func TestMainSyncHTTPSourceInvalid(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		args    []string
+		code    int
+		message string
+	}{
+		{"not found", nil, 73, "can't read files for sync"},
+		{"with tar", []string{"--exodus-tar"}, 23, "a URL source can't be used with --exodus-tar or --exodus-glob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG+"loglevel: none\n")
+			ctrl := MockController(t)
+			logs := CaptureLogger(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&FakeClient{}, nil).AnyTimes()
+
+			args := append([]string{"rsync"}, tt.args...)
+			got := Main(append(args, server.URL+"/files/missing", "exodus:/dest"))
+
+			if got != tt.code {
+				t.Error("returned incorrect exit code", got)
+			}
+			if FindEntry(logs, tt.message) == nil {
+				t.Errorf("missing expected log message %q", tt.message)
+			}
+		})
+	}
+}
+
+func TestMainSyncHTTPSourceNotExodus(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		dest   string
+	}{
+		{"rsync mode", CONFIG, "otherhost:/dest"},
+		{"mixed mode", CONFIG, "exodus-mixed:/dest"},
+		{"no config", "", "exodus:/dest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, tt.config+"loglevel: none\n")
+			if tt.config == "" {
+				os.Remove("exodus-rsync.conf")
+			}
+			MockController(t)
+			logs := CaptureLogger(t)
+
+			// rsync would take the URL as a local path, so mustn't run.
+			ext.rsync = &fakeRsync{err: fmt.Errorf("this test is not supposed to run rsync")}
+
+			got := Main([]string{"rsync", "--exodus-conf", "exodus-rsync.conf", "https://example.com/hello", tt.dest})
+
+			if got != 23 {
+				t.Error("returned incorrect exit code", got)
+			}
+			if FindEntry(logs, "a URL source can only be used with rsyncmode 'exodus'") == nil {
+				t.Error("missing expected log message")
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+// A client failing to upload the files with the given names. With abort,
+// it reports having stopped uploading as on reaching --exodus-max-errors,
+// the counting towards which is tested in gw.
+type failingUploadClient struct {
+	FakeClient
+	fail  map[string]bool
+	abort bool
+
+	maxErrors int
+}
+
+func (c *failingUploadClient) EnsureUploaded(ctx context.Context, items []walk.SyncItem,
+	onUploaded func(walk.SyncItem) error,
+	onExisting func(walk.SyncItem) error,
+	onDuplicate func(walk.SyncItem) error,
+) error {
+	uploaded := []walk.SyncItem{}
+	uploadErrs := &gw.UploadErrors{}
+	c.maxErrors = gw.MaxErrorsFromContext(ctx)
+
+	for _, item := range items {
+		if item.LinkTo == "" && c.fail[filepath.Base(item.SrcPath)] {
+			err := fmt.Errorf("simulated error for %s", item.SrcPath)
+			if !gw.KeepGoingFromContext(ctx) {
+				return err
+			}
+			uploadErrs.Failed = append(uploadErrs.Failed, gw.ItemError{Item: item, Err: err})
+			continue
+		}
+		uploaded = append(uploaded, item)
+	}
+
+	if c.abort {
+		uploadErrs.Aborted = true
+		return uploadErrs
+	}
+	if err := c.FakeClient.EnsureUploaded(ctx, uploaded, onUploaded, onExisting, onDuplicate); err != nil {
+		return err
+	}
+	if len(uploadErrs.Failed) > 0 {
+		return uploadErrs
+	}
+	return nil
+}
+
+func TestMainSyncKeepGoing(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantCode  int
+		wantItems int
+		committed int
+		wantLogs  []string
+	}{
+		{"stop at first error", nil, 25, 0, 0,
+			[]string{"can't upload files"}},
+		{"keep going", []string{"--exodus-keep-going"}, 27, 2, 1,
+			[]string{"can't upload file", "Some files could not be uploaded", "Completed, but some files could not be uploaded and were not published"}},
+		{"keep going, pipelined", []string{"--exodus-keep-going", "--exodus-pipeline", "--delay-updates"}, 27, 2, 1,
+			[]string{"Some files could not be uploaded"}},
+		{"keep going, then fail", []string{"--exodus-keep-going", "--exodus-on-failed-items=fail"}, 25, 0, 0,
+			[]string{"can't upload file", "Some files could not be uploaded", "can't upload files"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG)
+			ctrl := MockController(t)
+			logs := CaptureLogger(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := &failingUploadClient{FakeClient: FakeClient{blobs: map[string]string{}}, fail: map[string]bool{"some-binary": true}}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(client, nil)
+
+			argv := append([]string{"rsync"}, tt.args...)
+			argv = append(argv, srcPath+"/", "exodus:/dest")
+
+			got := Main(argv)
+			if got != tt.wantCode {
+				t.Errorf("returned incorrect exit code %d, wanted %d", got, tt.wantCode)
+			}
+
+			for _, msg := range tt.wantLogs {
+				if FindEntry(logs, msg) == nil {
+					t.Errorf("missing expected log message %q", msg)
+				}
+			}
+
+			// When keeping going, the other files were uploaded either way.
+			if len(tt.args) > 0 && len(client.blobs) != 1 {
+				t.Errorf("unexpected uploads %v", client.blobs)
+			}
+
+			if len(client.publishes) != 1 {
+				t.Fatalf("unexpected publishes %v", client.publishes)
+			}
+			publish := client.publishes[0]
+
+			if len(publish.items) != tt.wantItems || publish.committed != tt.committed {
+				t.Errorf("did not publish as expected: %v", publish)
+			}
+			for _, item := range publish.items {
+				if path.Base(item.WebURI) == "some-binary" {
+					t.Errorf("published file which failed to upload: %v", item)
+				}
+			}
+		})
+	}
+}
+
+func TestMainSyncKeepGoingMaxErrors(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	tests := []struct {
+		name      string
+		abort     bool
+		wantCode  int
+		committed int
+		wantLog   string
+	}{
+		{"reached the limit", true, 25, 0, "Stopped uploading, reached --exodus-max-errors"},
+		{"under the limit", false, 27, 1, "Completed, but some files could not be uploaded and were not published"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG)
+			ctrl := MockController(t)
+			logs := CaptureLogger(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := &failingUploadClient{
+				FakeClient: FakeClient{blobs: map[string]string{}},
+				fail:       map[string]bool{"some-binary": true},
+				abort:      tt.abort,
+			}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(client, nil)
+
+			got := Main([]string{"rsync", "--exodus-keep-going", "--exodus-max-errors", "2", srcPath + "/", "exodus:/dest"})
+			if got != tt.wantCode {
+				t.Errorf("returned incorrect exit code %d, wanted %d", got, tt.wantCode)
+			}
+
+			if client.maxErrors != 2 {
+				t.Errorf("uploaded with max errors %d, wanted 2", client.maxErrors)
+			}
+
+			if FindEntry(logs, tt.wantLog) == nil {
+				t.Errorf("missing expected log message %q", tt.wantLog)
+			}
+
+			if len(client.publishes) != 1 || client.publishes[0].committed != tt.committed {
+				t.Errorf("did not publish as expected: %v", client.publishes)
+			}
+		})
+	}
+}
+
+func TestMainSyncMaxErrorsWithoutKeepGoing(t *testing.T) {
+	SetConfig(t, CONFIG+"loglevel: none\n")
+	logs := CaptureLogger(t)
+
+	got := Main([]string{"rsync", "--exodus-max-errors", "3", ".", "exodus:/dest"})
+
+	if got != 23 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if FindEntry(logs, "--exodus-max-errors requires --exodus-keep-going") == nil {
+		t.Error("missing expected log message")
+	}
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncLabels(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+	out := captureStdout(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	want := map[string]string{"build": "1234", "requester": "someone=else"}
+
+	client := FakeClient{blobs: map[string]string{}}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).DoAndReturn(
+		func(ctx context.Context, _ conf.Config) (gw.Client, error) {
+			if got := gw.LabelsFromContext(ctx); !reflect.DeepEqual(got, want) {
+				t.Errorf("client created with labels %v", got)
+			}
+			return &client, nil
+		})
+
+	got := Main([]string{"rsync", "--exodus-label", "build=1234", "--exodus-label=requester=someone=else",
+		"--exodus-output", "json", srcPath + "/", "exodus:/dest"})
+	if got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+	if len(client.publishes) != 1 {
+		t.Errorf("expected one publish, got %v", client.publishes)
+	}
+
+	// The labels are recorded in the result.
+	var result syncResult
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("output is not valid JSON, err = %v:\n%s", err, out.String())
+	}
+	if !reflect.DeepEqual(result.Labels, want) {
+		t.Errorf("got labels %v in result, want %v", result.Labels, want)
+	}
+}
+
+func TestMainSyncLabelsInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		message string
+	}{
+		{"no value", []string{"--exodus-label=build"}, "invalid --exodus-label"},
+		{"no key", []string{"--exodus-label==1234"}, "invalid --exodus-label"},
+		{"duplicate", []string{"--exodus-label=build=1", "--exodus-label=build=2"}, "invalid --exodus-label"},
+		{"joined", []string{"--exodus-label=build=1234", "--exodus-publish=3e0a4539-be4a-437e-a45f-6d72f7192f17"},
+			"--exodus-label can't be used with --exodus-publish"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG+"loglevel: none\n")
+			logs := CaptureLogger(t)
+
+			args := append([]string{"rsync"}, tt.args...)
+			got := Main(append(args, ".", "exodus:/dest"))
+			if got != 23 {
+				t.Errorf("returned incorrect exit code %d", got)
+			}
+			if FindEntry(logs, tt.message) == nil {
+				t.Error("missing expected error log")
+			}
+		})
+	}
+}
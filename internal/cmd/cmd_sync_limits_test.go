@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncPublishLimits(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// This tree has 3 files totalling 212 bytes.
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	tests := []struct {
+		name     string
+		limits   string
+		force    bool
+		expected int
+	}{
+		{"under limits", "maxpublishbytes: 212\nmaxpublishitems: 3\n", false, 0},
+		{"over bytes", "maxpublishbytes: 211\n", false, 26},
+		{"over items", "maxpublishitems: 2\n", false, 26},
+		{"over limits with force", "maxpublishbytes: 1\nmaxpublishitems: 1\n", true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG+tt.limits)
+			ctrl := MockController(t)
+			logs := CaptureLogger(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			args := []string{"rsync", srcPath + "/", "exodus:/dest"}
+			if tt.force {
+				args = append(args, "--exodus-force")
+			}
+
+			got := Main(args)
+
+			if got != tt.expected {
+				t.Error("returned incorrect exit code", got)
+			}
+
+			if tt.expected != 0 {
+				// It should have failed before uploading or publishing anything.
+				if len(client.blobs) != 0 || len(client.publishes) != 0 {
+					t.Errorf("unexpectedly uploaded %v, published %v", client.blobs, client.publishes)
+				}
+
+				entry := FindEntry(logs, "Publish exceeds configured limits, use --exodus-force to publish anyway")
+				if entry == nil {
+					t.Fatal("missing expected log message")
+				}
+				if entry.Fields["bytes"] != int64(212) || entry.Fields["items"] != 3 {
+					t.Errorf("unexpected fields in log: %v", entry.Fields)
+				}
+				return
+			}
+
+			if len(client.publishes) != 1 || client.publishes[0].committed != 1 {
+				t.Errorf("did not publish as expected: %v", client.publishes)
+			}
+
+			warning := FindEntry(logs, "Publish exceeds configured limits, proceeding due to --exodus-force")
+			if tt.force != (warning != nil) {
+				t.Errorf("unexpected warning: %v", warning)
+			}
+		})
+	}
+}
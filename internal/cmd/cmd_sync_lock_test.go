@@ -0,0 +1,387 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+// fakeLockService is an HTTP lock service holding locks in memory, without
+// expiring them.
+type fakeLockService struct {
+	mu sync.Mutex
+
+	// The owners holding each lock, and whether it's shared.
+	held   map[string]map[string]bool
+	shared map[string]bool
+
+	// Each request received.
+	requests []httpLockRequest
+	renewals int
+}
+
+func newFakeLockService(t *testing.T) (*fakeLockService, *httptest.Server) {
+	service := &fakeLockService{held: map[string]map[string]bool{}, shared: map[string]bool{}}
+	server := httptest.NewServer(service)
+	t.Cleanup(server.Close)
+	return service, server
+}
+
+func (s *fakeLockService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var req httpLockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.requests = append(s.requests, req)
+
+	key := r.URL.EscapedPath()
+	owners := s.held[key]
+
+	switch r.Method {
+	case "POST":
+		if len(owners) > 0 && !(s.shared[key] && req.Shared) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		if owners == nil {
+			owners = map[string]bool{}
+			s.held[key] = owners
+		}
+		owners[req.Owner] = true
+		s.shared[key] = req.Shared
+		w.WriteHeader(http.StatusCreated)
+	case "PUT":
+		if !owners[req.Owner] {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		s.renewals++
+		w.WriteHeader(http.StatusNoContent)
+	case "DELETE":
+		delete(owners, req.Owner)
+		if len(owners) == 0 {
+			delete(s.held, key)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// Returns the path of the source tree synced while testing locks, which must
+// be found before SetConfig changes directory.
+func lockTestSrc(t *testing.T) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path.Clean(wd + "/../../test/data/srctrees/just-files")
+}
+
+// holdLock acquires the lock on key, as a sync to its path would, until the
+// test ends or the returned function is called.
+func holdLock(t *testing.T, location string, key string) func() {
+	lock := newPublishLock(location, key, false)
+	ok, err := lock.tryAcquire(context.Background())
+	if !ok || err != nil {
+		t.Fatalf("can't acquire lock, ok = %v, err = %v", ok, err)
+	}
+	var once sync.Once
+	release := func() {
+		once.Do(func() { lock.release(context.Background()) })
+	}
+	t.Cleanup(release)
+	return release
+}
+
+func TestMainSyncLock(t *testing.T) {
+	srcPath := lockTestSrc(t)
+	_, server := newFakeLockService(t)
+
+	tests := []struct {
+		name     string
+		location string
+	}{
+		{"file", t.TempDir()},
+		{"http", server.URL + "/locks"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG+"publishlock: "+tt.location+"\n")
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: map[string]string{}}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).DoAndReturn(
+				func(context.Context, conf.Config) (gw.Client, error) {
+					// The lock is held while syncing...
+					lock := newPublishLock(tt.location, "best-env:/dest", false)
+					if ok, err := lock.tryAcquire(context.Background()); ok || err != nil {
+						t.Errorf("lock was not held, ok = %v, err = %v", ok, err)
+					}
+					return &client, nil
+				})
+
+			if got := Main([]string{"rsync", srcPath + "/", "exodus:/dest"}); got != 0 {
+				t.Fatal("returned incorrect exit code", got)
+			}
+			if len(client.publishes) != 1 || client.publishes[0].committed != 1 {
+				t.Errorf("unexpected publishes %v", client.publishes)
+			}
+
+			// ...and released at the end.
+			holdLock(t, tt.location, "best-env:/")
+			holdLock(t, tt.location, "best-env:/dest")
+		})
+	}
+}
+
+func TestMainSyncLockContention(t *testing.T) {
+	srcPath := lockTestSrc(t)
+	_, server := newFakeLockService(t)
+
+	tests := []struct {
+		name     string
+		location string
+	}{
+		{"file", t.TempDir()},
+		{"http", server.URL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG+"loglevel: none\npublishlock: "+tt.location+"\n")
+			ctrl := MockController(t)
+			logs := CaptureLogger(t)
+
+			holdLock(t, tt.location, "best-env:/dest")
+
+			// Nothing should be done at all while another sync holds the lock.
+			ext.gw = gw.NewMockInterface(ctrl)
+
+			if got := Main([]string{"rsync", srcPath + "/", "exodus:/dest"}); got != 75 {
+				t.Error("returned incorrect exit code", got)
+			}
+			entry := FindEntry(logs, "Destination is locked by another sync")
+			if entry == nil || entry.Fields["lock"] != "best-env:/dest" {
+				t.Errorf("missing expected error log, got %v", entry)
+			}
+		})
+	}
+}
+
+func TestMainSyncLockWait(t *testing.T) {
+	srcPath := lockTestSrc(t)
+	oldInterval := lockPollInterval
+	lockPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { lockPollInterval = oldInterval })
+
+	location := t.TempDir()
+	SetConfig(t, CONFIG+"publishlock: "+location+"\npublishlockwait: 30\n")
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: map[string]string{}}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	// The other sync ends a little later.
+	release := holdLock(t, location, "best-env:/dest")
+	time.AfterFunc(100*time.Millisecond, release)
+
+	if got := Main([]string{"rsync", srcPath + "/", "exodus:/dest"}); got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+	if FindEntry(logs, "Waiting for another sync to the destination") == nil {
+		t.Error("missing expected log message")
+	}
+	if len(client.publishes) != 1 {
+		t.Errorf("unexpected publishes %v", client.publishes)
+	}
+}
+
+func TestMainSyncLockReleasedOnFailure(t *testing.T) {
+	srcPath := lockTestSrc(t)
+	service, server := newFakeLockService(t)
+
+	SetConfig(t, CONFIG+"loglevel: none\npublishlock: "+server.URL+"\n")
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(nil, errors.New("simulated error"))
+
+	if got := Main([]string{"rsync", srcPath + "/", "exodus:/dest"}); got != 101 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	if len(service.held) != 0 {
+		t.Errorf("locks still held after failure: %v", service.held)
+	}
+}
+
+func TestMainSyncLockError(t *testing.T) {
+	srcPath := lockTestSrc(t)
+	SetConfig(t, CONFIG+"loglevel: none\npublishlock: /not/exist/dir\n")
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	ext.gw = gw.NewMockInterface(ctrl)
+
+	if got := Main([]string{"rsync", srcPath + "/", "exodus:/dest"}); got != 75 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if FindEntry(logs, "can't acquire publish lock") == nil {
+		t.Error("missing expected error log")
+	}
+}
+
+func TestMainSyncLockOverlapping(t *testing.T) {
+	srcPath := lockTestSrc(t)
+	_, server := newFakeLockService(t)
+
+	tests := []struct {
+		name    string
+		heldEnv string
+		held    string
+		dest    string
+		locked  string
+	}{
+		{"beneath", "best-env", "/dest/sub", "exodus:/dest", "best-env:/dest"},
+		{"above", "best-env", "/dest", "exodus:/dest/sub", "best-env:/dest"},
+		{"root", "best-env", "/", "exodus:/dest", "best-env:/"},
+		{"sibling", "best-env", "/other", "exodus:/dest", ""},
+		{"sibling beneath", "best-env", "/dest-other/sub", "exodus:/dest", ""},
+		{"other env", "other-env", "/dest", "exodus:/dest", ""},
+	}
+
+	for _, location := range []string{"file", "http"} {
+		for _, tt := range tests {
+			t.Run(location+" "+tt.name, func(t *testing.T) {
+				lockLocation := server.URL
+				if location == "file" {
+					lockLocation = t.TempDir()
+				}
+
+				SetConfig(t, CONFIG+"loglevel: none\npublishlock: "+lockLocation+"\n")
+				ctrl := MockController(t)
+				logs := CaptureLogger(t)
+
+				// The locks are held as by another sync to the path.
+				paths := lockPaths(tt.held)
+				for i, lockPath := range paths {
+					lock := newPublishLock(lockLocation, tt.heldEnv+":"+lockPath, i < len(paths)-1)
+					if ok, err := lock.tryAcquire(context.Background()); !ok || err != nil {
+						t.Fatalf("can't acquire lock, ok = %v, err = %v", ok, err)
+					}
+					t.Cleanup(func() { lock.release(context.Background()) })
+				}
+
+				mockGw := gw.NewMockInterface(ctrl)
+				ext.gw = mockGw
+
+				want := 0
+				if tt.locked != "" {
+					want = 75
+				} else {
+					client := FakeClient{blobs: map[string]string{}}
+					mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+				}
+
+				if got := Main([]string{"rsync", srcPath + "/", tt.dest}); got != want {
+					t.Fatal("returned incorrect exit code", got)
+				}
+				if tt.locked != "" {
+					entry := FindEntry(logs, "Destination is locked by another sync")
+					if entry == nil || entry.Fields["lock"] != tt.locked {
+						t.Errorf("missing expected error log, got %v", entry)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestHTTPLockLease(t *testing.T) {
+	oldLease := lockLease
+	lockLease = 30 * time.Millisecond
+	t.Cleanup(func() { lockLease = oldLease })
+
+	service, server := newFakeLockService(t)
+
+	lock := newPublishLock(server.URL, "best-env:/dest", false)
+	if ok, err := lock.tryAcquire(context.Background()); !ok || err != nil {
+		t.Fatalf("can't acquire lock, ok = %v, err = %v", ok, err)
+	}
+
+	// The lease is renewed while the lock is held...
+	time.Sleep(10 * lockLease)
+	if err := lock.release(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	service.mu.Lock()
+	renewals := service.renewals
+	service.mu.Unlock()
+	if renewals < 2 {
+		t.Errorf("lease renewed %d times", renewals)
+	}
+
+	// ...and no longer once released.
+	time.Sleep(5 * lockLease)
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	if service.renewals != renewals {
+		t.Errorf("lease renewed after release")
+	}
+	if len(service.held) != 0 {
+		t.Errorf("lock still held: %v", service.held)
+	}
+	for _, req := range service.requests {
+		if req.TTL != 1 || req.Owner != lockOwner() {
+			t.Errorf("unexpected request %+v", req)
+		}
+	}
+}
+
+func TestHTTPLockTimeout(t *testing.T) {
+	oldTimeout := lockRequestTimeout
+	lockRequestTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { lockRequestTimeout = oldTimeout })
+
+	// A service which never responds.
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(unblock) })
+
+	lock := newPublishLock(server.URL, "best-env:/dest", false)
+	if ok, err := lock.tryAcquire(context.Background()); ok || err == nil {
+		t.Errorf("unexpectedly acquired lock, ok = %v, err = %v", ok, err)
+	}
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+// Matches any config by its exodus-gw environment.
+type GwEnvMatcher struct {
+	name string
+}
+
+func (m GwEnvMatcher) Matches(x interface{}) bool {
+	cfg, ok := x.(conf.Config)
+	if !ok {
+		return false
+	}
+	return cfg.GwEnv() == m.name
+}
+
+func (m GwEnvMatcher) String() string {
+	return fmt.Sprintf("gwenv '%s'", m.name)
+}
+
+// A client unable to create any publish.
+type noPublishClient struct {
+	FakeClient
+}
+
+func (c *noPublishClient) NewPublish(context.Context) (gw.Publish, error) {
+	return nil, fmt.Errorf("simulated error")
+}
+
+func TestMainSyncMultiEnv(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	// Both environments share a bucket.
+	blobs := make(map[string]string)
+	stage := FakeClient{blobs: blobs}
+	prod := FakeClient{blobs: blobs}
+
+	gomock.InOrder(
+		mockGw.EXPECT().NewClient(gomock.Any(), GwEnvMatcher{"stage"}).Return(&stage, nil),
+		mockGw.EXPECT().NewClient(gomock.Any(), GwEnvMatcher{"prod"}).Return(&prod, nil),
+	)
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	got := Main([]string{"rsync", "--exodus-env", "stage,prod", srcPath + "/", "exodus:/dest"})
+
+	if got != 0 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	// Each environment should have its own committed publish of every item.
+	for name, client := range map[string]*FakeClient{"stage": &stage, "prod": &prod} {
+		if len(client.publishes) != 1 {
+			t.Fatalf("%s: expected 1 publish, got %d", name, len(client.publishes))
+		}
+		p := client.publishes[0]
+		if len(p.items) != 3 {
+			t.Errorf("%s: expected 3 items, got %d", name, len(p.items))
+		}
+		if p.committed != 1 {
+			t.Errorf("%s: expected 1 commit, got %d", name, p.committed)
+		}
+	}
+
+	// Blobs uploaded for stage should not have been uploaded again for prod.
+	uploads := map[string]interface{}{}
+	completed := map[string]interface{}{}
+	for _, entry := range logs.Entries {
+		switch entry.Message {
+		case "Completed uploads":
+			uploads[fmt.Sprint(entry.Fields["env"])] = entry.Fields["uploaded"]
+		case "Completed successfully!":
+			completed[fmt.Sprint(entry.Fields["env"])] = entry.Fields["publish"]
+		}
+	}
+	if uploads["stage"] != 2 || uploads["prod"] != 0 {
+		t.Errorf("unexpected uploads per environment: %v", uploads)
+	}
+
+	// Each publish ID should be reported.
+	if len(completed) != 2 || completed["stage"] != stage.publishes[0].id || completed["prod"] != prod.publishes[0].id {
+		t.Errorf("did not report publish for each environment: %v", completed)
+	}
+}
+
+func TestMainSyncMultiEnvFailure(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	stage := noPublishClient{FakeClient{blobs: make(map[string]string)}}
+	prod := FakeClient{blobs: make(map[string]string)}
+
+	mockGw.EXPECT().NewClient(gomock.Any(), GwEnvMatcher{"stage"}).Return(&stage, nil)
+	mockGw.EXPECT().NewClient(gomock.Any(), GwEnvMatcher{"prod"}).Return(&prod, nil)
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	got := Main([]string{"rsync", "--exodus-env", "stage,prod", srcPath + "/", "exodus:/dest"})
+
+	// It should fail as publishing to stage failed...
+	if got != 62 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	// ...but should still have published to prod.
+	if len(prod.publishes) != 1 || prod.publishes[0].committed != 1 {
+		t.Errorf("did not publish to prod: %v", prod.publishes)
+	}
+
+	entry := FindEntry(logs, "Failed to publish to some environments")
+	if entry == nil {
+		t.Fatal("missing expected log message")
+	}
+	if entry.Fields["failed"] != "stage" {
+		t.Errorf("unexpected failed environments: %v", entry.Fields["failed"])
+	}
+}
+
+func TestMainSyncMultiEnvJoinPublish(t *testing.T) {
+	SetConfig(t, CONFIG)
+	MockController(t)
+	logs := CaptureLogger(t)
+
+	got := Main([]string{
+		"rsync", "--exodus-env", "stage,prod",
+		"--exodus-publish", "3e0a4539-be4a-437e-a45f-6d72f7192f17",
+		".", "exodus:/dest",
+	})
+
+	if got != 23 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	if FindEntry(logs, "--exodus-publish can't be used with multiple environments in --exodus-env") == nil {
+		t.Error("missing expected log message")
+	}
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncNoReplace(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	tests := []struct {
+		name      string
+		config    string
+		content   map[string]string
+		exitCode  int
+		publishes int
+		noReplace bool
+		errMsg    string
+	}{
+		{"supported by gw", "gwnoreplace: true\n", nil, 0, 1, true, ""},
+
+		{"none published", "", map[string]string{"/other": "x"}, 0, 1, false, ""},
+
+		{"already published", "",
+			map[string]string{"/dest/hello-copy-two": "x"},
+			34, 0, false, "1 item(s) already published: /dest/hello-copy-two"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cdn := fakeCDN(t, tt.content)
+
+			SetConfig(t, CONFIG+"loglevel: none\ncdnurl: "+cdn.URL+"\n"+tt.config)
+			logs := CaptureLogger(t)
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			got := Main([]string{"rsync", "--exodus-no-replace", srcPath + "/", "exodus:/dest"})
+
+			if got != tt.exitCode {
+				t.Fatal("returned incorrect exit code", got)
+			}
+			if len(client.publishes) != tt.publishes {
+				t.Fatalf("expected %d publishes, got %v", tt.publishes, client.publishes)
+			}
+
+			// Items should only be marked where exodus-gw can handle it.
+			for _, publish := range client.publishes {
+				for _, item := range publish.items {
+					if item.NoReplace != tt.noReplace {
+						t.Errorf("item %v: expected NoReplace %v", item, tt.noReplace)
+					}
+				}
+			}
+
+			if tt.errMsg == "" {
+				return
+			}
+
+			entry := FindEntry(logs, "refusing to replace published items")
+			if entry == nil {
+				t.Fatal("missing expected log message")
+			}
+			if entry.Fields["error"].(error).Error() != tt.errMsg {
+				t.Errorf("unexpected error: %v", entry.Fields["error"])
+			}
+		})
+	}
+}
+
+func TestMainSyncNoReplaceNoCDN(t *testing.T) {
+	SetConfig(t, CONFIG+"loglevel: none\n")
+	logs := CaptureLogger(t)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	got := Main([]string{"rsync", "--exodus-no-replace", ".", "exodus:/dest"})
+
+	if got != 23 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if FindEntry(logs, "--exodus-no-replace requires 'gwnoreplace' or 'cdnurl' in configuration") == nil {
+		t.Error("missing expected log message")
+	}
+	if len(client.publishes) != 0 {
+		t.Errorf("unexpectedly created publishes %v", client.publishes)
+	}
+}
+
+func TestCheckNotPublishedConcurrent(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		active   int
+		maxSeen  int
+		requests int
+	)
+
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		active++
+		requests++
+		maxSeen = max(maxSeen, active)
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+
+		if r.URL.Path == "/dest/3" || r.URL.Path == "/dest/1" {
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(cdn.Close)
+
+	var items []gw.ItemInput
+	for _, uri := range []string{"/dest/0", "/dest/1", "/dest/2", "/dest/3", "/dest/4", "/dest/5"} {
+		items = append(items, gw.ItemInput{WebURI: uri})
+	}
+
+	err := checkNotPublished(testContext(), cdn.URL, items, 3)
+
+	// Published items are reported in order, whatever order they're found.
+	if err == nil || err.Error() != "2 item(s) already published: /dest/1, /dest/3" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Every item is checked, several at once but no more than requested.
+	if requests != len(items) {
+		t.Errorf("expected %d requests, got %d", len(items), requests)
+	}
+	if maxSeen < 2 || maxSeen > 3 {
+		t.Errorf("unexpected number of concurrent requests: %d", maxSeen)
+	}
+}
+
+func TestCheckNotPublishedError(t *testing.T) {
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dest/0" {
+			// Only ends once cancelled by the failure of the other check.
+			<-r.Context().Done()
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(cdn.Close)
+
+	items := []gw.ItemInput{{WebURI: "/dest/0"}, {WebURI: "/dest/1"}}
+
+	// The failure is reported, rather than the cancellation it caused.
+	err := checkNotPublished(testContext(), cdn.URL, items, 2)
+	if err == nil || err.Error() != "checking /dest/1: 500 Internal Server Error" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
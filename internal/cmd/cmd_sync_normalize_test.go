@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncURINormalize(t *testing.T) {
+	srcPath := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(srcPath, "Some Dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcPath, "Some Dir", "Ünïcode.TXT"), []byte("hello world\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG+`
+urinormalize: [lowercase, escape]
+`)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	got := Main([]string{"rsync", srcPath + "/", "exodus:/Dest"})
+
+	// It should complete successfully.
+	if got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	itemMap := make(map[string]string)
+	for _, item := range client.publishes[0].items {
+		itemMap[item.WebURI] = item.ObjectKey
+	}
+
+	expectedItems := map[string]string{
+		"/dest/some%20dir/%C3%BCn%C3%AFcode.txt": "a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447",
+	}
+
+	if !reflect.DeepEqual(itemMap, expectedItems) {
+		t.Error("did not publish expected items, published:", itemMap)
+	}
+}
+
+func TestMainSyncURINormalizeBadRule(t *testing.T) {
+	SetConfig(t, CONFIG+`
+urinormalize: [sideways]
+`)
+	logs := CaptureLogger(t)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	got := Main([]string{"rsync", ".", "exodus:/dest"})
+
+	if got != 23 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	if FindEntry(logs, "invalid urinormalize configuration") == nil {
+		t.Error("missing expected log message")
+	}
+
+	// It should have bailed out before creating a publish.
+	if len(client.publishes) != 0 {
+		t.Error("unexpectedly created publish")
+	}
+}
+
+func TestMainSyncURINormalizeTraversal(t *testing.T) {
+	srcPath := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(srcPath, "file"), []byte("hello world\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG)
+	logs := CaptureLogger(t)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	// A relative destination path with '..' would escape the destination.
+	got := Main([]string{"rsync", srcPath + "/", "exodus:dest/../../escaped"})
+
+	if got != 49 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	if FindEntry(logs, "can't determine web URI") == nil {
+		t.Error("missing expected log message")
+	}
+
+	// It should have bailed out before creating a publish.
+	if len(client.publishes) != 0 {
+		t.Error("unexpectedly created publish")
+	}
+}
+
+func TestMainSyncURICaseInsensitive(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		wantCode  int
+		wantItems int
+	}{
+		{"case-sensitive by default", "", 0, 3},
+		{"case-insensitive", "uricaseinsensitive: true\n", 49, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srcPath := t.TempDir()
+
+			for _, name := range []string{"Foo.rpm", "foo.rpm", "bar.rpm"} {
+				if err := os.WriteFile(filepath.Join(srcPath, name), []byte(name), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			SetConfig(t, CONFIG+tt.config)
+			logs := CaptureLogger(t)
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			got := Main([]string{"rsync", srcPath + "/", "exodus:/dest"})
+
+			if got != tt.wantCode {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			if tt.wantCode != 0 {
+				entry := FindEntry(logs, "can't determine web URI")
+				if entry == nil {
+					t.Fatal("missing expected log message")
+				}
+				if err := entry.Fields["error"].(error).Error(); err != "refusing to publish to '/dest/foo.rpm': differs only in case from '/dest/Foo.rpm'" {
+					t.Errorf("unexpected error: %s", err)
+				}
+
+				// It should have bailed out before creating a publish.
+				if len(client.publishes) != 0 {
+					t.Error("unexpectedly created publish")
+				}
+				return
+			}
+
+			if len(client.publishes[0].items) != tt.wantItems {
+				t.Error("did not publish expected items, published:", client.publishes[0].items)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncOffline(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The config doesn't provide any cert or key, which is fine since
+	// exodus-gw isn't contacted.
+	SetConfig(t, CONFIG)
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+	outDir := filepath.Join(t.TempDir(), "out")
+
+	got := Main([]string{"rsync", "--exodus-offline", outDir, srcPath + "/", "exodus:/dest"})
+
+	// It should complete successfully.
+	if got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	var items []gw.ItemInput
+	content, err := os.ReadFile(filepath.Join(outDir, "items-0001.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(content, &items); err != nil {
+		t.Fatal(err)
+	}
+
+	itemMap := make(map[string]string)
+	for _, item := range items {
+		itemMap[item.WebURI] = item.ObjectKey
+	}
+
+	expectedItems := map[string]string{
+		"/dest/hello-copy-one":     "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+		"/dest/hello-copy-two":     "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+		"/dest/subdir/some-binary": "c66f610d98b2c9fe0175a3e99ba64d7fc7de45046515ff325be56329a9347dd6",
+	}
+
+	if !reflect.DeepEqual(itemMap, expectedItems) {
+		t.Error("did not write expected items, wrote:", itemMap)
+	}
+
+	var uploads []gw.OfflineUpload
+	content, err = os.ReadFile(filepath.Join(outDir, "uploads.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(content, &uploads); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each blob should be planned for upload only once.
+	if len(uploads) != 2 {
+		t.Error("unexpected planned uploads:", uploads)
+	}
+
+	// It should have recorded the commit.
+	if _, err := os.Stat(filepath.Join(outDir, "commit.json")); err != nil {
+		t.Error("commit not recorded:", err)
+	}
+}
@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+// A publish recording the order in which items are added and committed.
+type pipelinePublish struct {
+	mu    sync.Mutex
+	calls []string
+
+	// Whether phase1 commits are supported, and whether adding items fails.
+	phase1  bool
+	failAdd bool
+
+	// Signalled each time the pipeline is ready for the next upload.
+	idle chan struct{}
+}
+
+func (p *pipelinePublish) record(call string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, call)
+}
+
+func (p *pipelinePublish) AddItems(ctx context.Context, items []gw.ItemInput) error {
+	if p.failAdd {
+		return fmt.Errorf("simulated error")
+	}
+	p.record(fmt.Sprintf("add %d", len(items)))
+	if !p.phase1 {
+		p.idle <- struct{}{}
+	}
+	return nil
+}
+
+func (p *pipelinePublish) Items(ctx context.Context) ([]gw.ItemInput, error) {
+	return nil, nil
+}
+
+func (p *pipelinePublish) TaskID() string {
+	return ""
+}
+
+func (p *pipelinePublish) State() string {
+	return ""
+}
+
+func (p *pipelinePublish) Commit(ctx context.Context, mode string) error {
+	if mode != "phase1" {
+		p.record(strings.TrimSpace("commit " + mode))
+		return nil
+	}
+	if !p.phase1 {
+		return fmt.Errorf("simulated error: invalid commit_mode")
+	}
+	p.record("commit phase1")
+	p.idle <- struct{}{}
+	return nil
+}
+
+func (p *pipelinePublish) ID() string {
+	return "3e0a4539-be4a-437e-a45f-6d72f7192f17"
+}
+
+// A client which uploads one blob at a time, each only once the pipeline has
+// handled the previous one, so that the interleaving is deterministic.
+type pipelineClient struct {
+	FakeClient
+	publish *pipelinePublish
+}
+
+func (c *pipelineClient) NewPublish(context.Context) (gw.Publish, error) {
+	return c.publish, nil
+}
+
+func (c *pipelineClient) EnsureUploaded(ctx context.Context, items []walk.SyncItem,
+	onUploaded func(walk.SyncItem) error,
+	onExisting func(walk.SyncItem) error,
+	onDuplicate func(walk.SyncItem) error,
+) error {
+	seen := map[string]bool{}
+
+	for _, item := range items {
+		name := filepath.Base(item.SrcPath)
+		if seen[item.Key] {
+			c.publish.record("duplicate " + name)
+			onDuplicate(item)
+		} else {
+			c.publish.record("upload " + name)
+			seen[item.Key] = true
+			onUploaded(item)
+		}
+
+		select {
+		case <-c.publish.idle:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			return fmt.Errorf("timed out waiting for pipeline")
+		}
+	}
+
+	return nil
+}
+
+func TestMainSyncPipeline(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	tests := []struct {
+		name     string
+		args     []string
+		phase1   bool
+		warning  bool
+		expected []string
+	}{
+		{"incremental commit", nil, true, false, []string{
+			"upload hello-copy-one", "add 1", "commit phase1",
+			"duplicate hello-copy-two", "add 1", "commit phase1",
+			"upload some-binary", "add 1", "commit phase1",
+			"commit",
+		}},
+
+		// Items are still added while uploading, but only committed at the end.
+		{"incremental commit unsupported", nil, false, true, []string{
+			"upload hello-copy-one", "add 1",
+			"duplicate hello-copy-two", "add 1",
+			"upload some-binary", "add 1",
+			"commit",
+		}},
+
+		// Nothing may become visible before the final commit, so incremental
+		// commits aren't even attempted.
+		{"delay updates", []string{"--delay-updates"}, false, false, []string{
+			"upload hello-copy-one", "add 1",
+			"duplicate hello-copy-two", "add 1",
+			"upload some-binary", "add 1",
+			"commit",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG)
+			ctrl := MockController(t)
+			logs := CaptureLogger(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			publish := &pipelinePublish{phase1: tt.phase1, idle: make(chan struct{}, 10)}
+			client := &pipelineClient{FakeClient{blobs: map[string]string{}}, publish}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(client, nil)
+
+			args := append([]string{"rsync", "--exodus-pipeline"}, tt.args...)
+			got := Main(append(args, srcPath+"/", "exodus:/dest"))
+
+			if got != 0 {
+				t.Error("returned incorrect exit code", got)
+			}
+
+			if fmt.Sprint(publish.calls) != fmt.Sprint(tt.expected) {
+				t.Errorf("unexpected calls\n got: %q\nwant: %q", publish.calls, tt.expected)
+			}
+
+			warning := FindEntry(logs, "Can't commit publish incrementally, content will be committed at the end")
+			if tt.warning != (warning != nil) {
+				t.Errorf("unexpected warning: %v", warning)
+			}
+		})
+	}
+}
+
+func TestMainSyncPipelineAddFails(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	publish := &pipelinePublish{phase1: true, failAdd: true, idle: make(chan struct{}, 10)}
+	client := &pipelineClient{FakeClient{blobs: map[string]string{}}, publish}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(client, nil)
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	got := Main([]string{"rsync", "--exodus-pipeline", srcPath + "/", "exodus:/dest"})
+
+	// It should fail due to the pipeline rather than the uploads it cancelled.
+	if got != 51 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	if fmt.Sprint(publish.calls) != fmt.Sprint([]string{"upload hello-copy-one"}) {
+		t.Errorf("unexpected calls %q", publish.calls)
+	}
+
+	entry := FindEntry(logs, "can't add items to publish")
+	if entry == nil || fmt.Sprint(entry.Fields["error"]) != "simulated error" {
+		t.Errorf("missing expected log message, got %v", entry)
+	}
+}
+
+func TestMainSyncPipelineNoCommit(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: map[string]string{}}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	got := Main([]string{
+		"rsync", "--exodus-pipeline", "--exodus-commit", "none", srcPath + "/", "exodus:/dest",
+	})
+
+	if got != 0 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	// Without a commit, there's nothing to pipeline, so items are added as usual.
+	if FindEntry(logs, "Ignoring --exodus-pipeline as the publish won't be committed") == nil {
+		t.Error("missing expected log message")
+	}
+	if len(client.publishes) != 1 || len(client.publishes[0].items) != 3 || client.publishes[0].committed != 0 {
+		t.Errorf("did not publish as expected: %v", client.publishes)
+	}
+}
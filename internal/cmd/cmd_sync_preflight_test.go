@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncInvalidSource(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(t *testing.T)
+		args   []string
+		errMsg string
+	}{
+		{"nonexistent",
+			func(t *testing.T) {},
+			[]string{"rsync", "no-such-src", "exodus:/dest"},
+			"stat no-such-src: no such file or directory"},
+
+		{"unreadable directory",
+			func(t *testing.T) {
+				if os.Geteuid() == 0 {
+					t.Skip("permissions are not enforced for root")
+				}
+				os.Mkdir("src", 0000)
+			},
+			[]string{"rsync", "src/", "exodus:/dest"},
+			"open src/: permission denied"},
+
+		{"directory as tar",
+			func(t *testing.T) {
+				os.Mkdir("src", 0755)
+			},
+			[]string{"rsync", "--exodus-tar", "src/", "exodus:/dest"},
+			"src: not a tar archive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG)
+			tt.setup(t)
+
+			logs := CaptureLogger(t)
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			got := Main(tt.args)
+
+			// It should fail.
+			if got != 73 {
+				t.Error("returned incorrect exit code", got)
+			}
+
+			// It should have told us why.
+			entry := FindEntry(logs, "invalid source")
+			if entry == nil {
+				t.Fatal("missing expected log message")
+			}
+
+			err := fmt.Sprint(entry.Fields["error"])
+			if !strings.Contains(err, tt.errMsg) {
+				t.Error("unexpected error message", err)
+			}
+
+			// It should not have gotten as far as creating a publish.
+			if len(client.publishes) != 0 {
+				t.Error("unexpectedly created publish")
+			}
+		})
+	}
+}
+
+func TestMainSyncValidSource(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"file", "src/file"},
+		{"directory", "src"},
+		{"directory contents", "src/"},
+		{"empty directory", "src/empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG)
+
+			os.MkdirAll("src/empty", 0755)
+			os.WriteFile("src/file", []byte("hello"), 0644)
+
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			if got := Main([]string{"rsync", tt.src, "exodus:/dest"}); got != 0 {
+				t.Error("returned incorrect exit code", got)
+			}
+		})
+	}
+}
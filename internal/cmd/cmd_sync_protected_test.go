@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+// Sets the terminal from which syncs are confirmed, with nil for none.
+func setConfirmIn(t *testing.T, in io.Reader) *bytes.Buffer {
+	out := &bytes.Buffer{}
+	oldIn, oldOut := confirmIn, confirmOut
+	confirmIn, confirmOut = in, out
+	t.Cleanup(func() { confirmIn, confirmOut = oldIn, oldOut })
+	return out
+}
+
+func TestMainSyncProtected(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	tests := []struct {
+		name      string
+		protected string
+		args      []string
+		terminal  io.Reader
+		want      int
+	}{
+		{"not protected", "^prod", nil, nil, 0},
+		{"protected", "best", nil, nil, 23},
+		{"protected with --exodus-yes", "best", []string{"--exodus-yes"}, nil, 0},
+		{"protected dry run", "best", []string{"--dry-run"}, nil, 0},
+		{"protected confirmed", "best", nil, strings.NewReader("y\n"), 0},
+		{"protected declined", "best", nil, strings.NewReader("n\n"), 23},
+		{"protected no answer", "best", nil, strings.NewReader(""), 23},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, "protectedenvs: '"+tt.protected+"'\n"+CONFIG)
+			ctrl := MockController(t)
+			logs := CaptureLogger(t)
+			prompt := setConfirmIn(t, tt.terminal)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			if tt.want == 0 {
+				mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil).AnyTimes()
+				mockGw.EXPECT().NewDryRunClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil).AnyTimes()
+			}
+
+			args := append([]string{"rsync"}, tt.args...)
+			got := Main(append(args, srcPath+"/", "exodus:/dest"))
+
+			if got != tt.want {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			warning := FindEntry(logs, "!!! Syncing to protected exodus-gw environment !!!")
+			if (tt.protected == "best") != (warning != nil) {
+				t.Errorf("unexpected warning %v", warning)
+			}
+			if tt.want != 0 && client.publishes != nil {
+				t.Error("published despite refusal")
+			}
+			if (tt.terminal != nil) != strings.Contains(prompt.String(), "Publish to protected environment best-env?") {
+				t.Errorf("unexpected prompt %q", prompt.String())
+			}
+		})
+	}
+}
+
+func TestMainSyncProtectedMixed(t *testing.T) {
+	SetConfig(t, "protectedenvs: best\n"+CONFIG)
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+	setConfirmIn(t, nil)
+
+	// Neither rsync nor exodus-gw are used without confirmation.
+	ext.gw = gw.NewMockInterface(ctrl)
+	ext.rsync = &fakeRsync{err: fmt.Errorf("rsync was run")}
+
+	got := Main([]string{"rsync", ".", "exodus-mixed:/dest"})
+
+	if got != 23 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if FindEntry(logs, "Refusing to publish to protected environment without confirmation; use --exodus-yes to confirm") == nil {
+		t.Error("missing expected log message")
+	}
+}
+
+func TestMainSyncProtectedInvalid(t *testing.T) {
+	SetConfig(t, "protectedenvs: '('\n"+CONFIG)
+	logs := CaptureLogger(t)
+	setConfirmIn(t, nil)
+
+	got := Main([]string{"rsync", ".", "exodus:/dest"})
+
+	if got != 23 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if FindEntry(logs, "can't check for protected environments") == nil {
+		t.Error("missing expected log message")
+	}
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncWritePublishID(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	tests := []struct {
+		name string
+		fail map[string]bool
+		code int
+	}{
+		{"success", nil, 0},
+
+		// The ID is written before anything is uploaded, so it's available
+		// for cleaning up a publish which was never committed.
+		{"upload fails", map[string]bool{"some-binary": true}, 25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG+"loglevel: none\n")
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := &failingUploadClient{FakeClient: FakeClient{blobs: map[string]string{}}, fail: tt.fail}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(client, nil)
+
+			idFile := filepath.Join(t.TempDir(), "publish-id")
+
+			got := Main([]string{"rsync", "--exodus-write-publish-id=" + idFile, srcPath + "/", "exodus:/dest"})
+
+			if got != tt.code {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			content, err := os.ReadFile(idFile)
+			if err != nil {
+				t.Fatalf("can't read publish ID, err = %v", err)
+			}
+			if string(content) != "3e0a4539-be4a-437e-a45f-6d72f7192f17\n" {
+				t.Errorf("unexpected publish ID %q", content)
+			}
+
+			// Nothing else is left behind by the atomic write.
+			entries, err := os.ReadDir(filepath.Dir(idFile))
+			if err != nil || len(entries) != 1 {
+				t.Errorf("unexpected entries %v, err = %v", entries, err)
+			}
+		})
+	}
+}
+
+func TestMainSyncWritePublishIDMultiEnv(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: map[string]string{}}
+	mockGw.EXPECT().NewClient(gomock.Any(), gomock.Any()).Times(2).Return(&client, nil)
+
+	idFile := filepath.Join(t.TempDir(), "publish-id")
+
+	got := Main([]string{"rsync", "--exodus-env=one,two", "--exodus-write-publish-id=" + idFile,
+		srcPath + "/", "exodus:/dest"})
+
+	if got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	// Each publish is recorded.
+	content, err := os.ReadFile(idFile)
+	if err != nil {
+		t.Fatalf("can't read publish ID, err = %v", err)
+	}
+	id := "3e0a4539-be4a-437e-a45f-6d72f7192f17\n"
+	if string(content) != id+id {
+		t.Errorf("unexpected publish IDs %q", content)
+	}
+}
+
+func TestMainSyncWritePublishIDFails(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	SetConfig(t, CONFIG+"loglevel: none\n")
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: map[string]string{}}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	idFile := filepath.Join(t.TempDir(), "missing", "publish-id")
+
+	got := Main([]string{"rsync", "--exodus-write-publish-id=" + idFile, srcPath + "/", "exodus:/dest"})
+
+	if got != 33 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if FindEntry(logs, "can't write publish ID") == nil {
+		t.Error("missing expected log message")
+	}
+
+	// Nothing should have been uploaded.
+	if len(client.blobs) != 0 {
+		t.Errorf("unexpectedly uploaded %v", client.blobs)
+	}
+}
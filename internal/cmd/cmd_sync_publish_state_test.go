@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncPublishState(t *testing.T) {
+	srcPath := t.TempDir()
+	writeFile := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(srcPath, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("file1", "hello")
+	writeFile("file2", "world")
+
+	statePath := filepath.Join(t.TempDir(), "publishes.json")
+
+	SetConfig(t, CONFIG+"publishstate: "+statePath+"\n")
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: map[string]string{}}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).AnyTimes().Return(&client, nil)
+
+	sync := func() {
+		if got := Main([]string{"rsync", srcPath + "/", "exodus:/dest"}); got != 0 {
+			t.Fatal("returned incorrect exit code", got)
+		}
+	}
+
+	sync()
+	if len(client.publishes) != 1 || client.publishes[0].committed != 1 {
+		t.Fatalf("expected one committed publish, got %v", client.publishes)
+	}
+
+	// Running again with identical content doesn't publish again.
+	sync()
+	if len(client.publishes) != 1 {
+		t.Fatalf("identical sync created a publish, got %v", client.publishes)
+	}
+	if FindEntry(logs, "Items are identical to the last committed publish, not publishing") == nil {
+		t.Error("missing log for skipped publish")
+	}
+
+	// Changing a single file publishes again.
+	writeFile("file2", "world, again")
+	sync()
+	if len(client.publishes) != 2 || client.publishes[1].committed != 1 {
+		t.Fatalf("expected a second committed publish, got %v", client.publishes)
+	}
+	if len(client.publishes[1].items) != 2 {
+		t.Errorf("expected every item in the new publish, got %v", client.publishes[1].items)
+	}
+
+	// The new publish is the one compared with next.
+	sync()
+	if len(client.publishes) != 2 {
+		t.Fatalf("identical sync created a publish, got %v", client.publishes)
+	}
+
+	// Forcing an upload publishes identical items again, uploading their
+	// content.
+	client.blobs = map[string]string{}
+	if got := Main([]string{"rsync", "--exodus-force-upload", "file1", srcPath + "/", "exodus:/dest"}); got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+	if len(client.publishes) != 3 || len(client.blobs) == 0 {
+		t.Errorf("forced upload was skipped, got publishes %v, blobs %v", client.publishes, client.blobs)
+	}
+}
+
+func TestMainSyncPublishStateCommitMode(t *testing.T) {
+	srcPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(srcPath, "file1"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	statePath := filepath.Join(t.TempDir(), "publishes.json")
+
+	ctrl := MockController(t)
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: map[string]string{}}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).AnyTimes().Return(&client, nil)
+
+	sync := func(mode string) {
+		SetConfig(t, CONFIG+"publishstate: "+statePath+"\n")
+		CaptureLogger(t)
+		if got := Main([]string{"rsync", "--exodus-commit", mode, srcPath + "/", "exodus:/dest"}); got != 0 {
+			t.Fatal("returned incorrect exit code", got)
+		}
+	}
+
+	// Committing the same items in phase 2 after phase 1 makes them live,
+	// so isn't skipped; repeating either commit is.
+	for _, mode := range []string{"phase1", "phase1", "phase2", "phase2"} {
+		sync(mode)
+	}
+
+	if len(client.publishes) != 2 {
+		t.Fatalf("expected 2 publishes, got %v", client.publishes)
+	}
+	for i, want := range []string{"phase1", "phase2"} {
+		if modes := client.publishes[i].commitmodes; len(modes) != 1 || modes[0] != want {
+			t.Errorf("publish %d committed in modes %v, want %s", i, modes, want)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a := gw.ItemInput{WebURI: "/dest/a", ObjectKey: "abc", ContentType: "text/plain"}
+	b := gw.ItemInput{WebURI: "/dest/b", ObjectKey: "def", ContentType: "text/plain"}
+
+	if fingerprint([]gw.ItemInput{a, b}) != fingerprint([]gw.ItemInput{b, a}) {
+		t.Error("fingerprint depends on the order of items")
+	}
+
+	changed := b
+	changed.ContentType = "text/html"
+	if fingerprint([]gw.ItemInput{a, b}) == fingerprint([]gw.ItemInput{a, changed}) {
+		t.Error("fingerprint doesn't depend on the content type of items")
+	}
+}
+
+func TestPublishStateCompressed(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "publishes.json.gz")
+
+	state := newPublishState(path)
+	if err := state.record(ctx, "key", "fingerprint", "publish2", ""); err != nil {
+		t.Fatalf("failed to record publish, err = %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(content, gzipMagic) {
+		t.Fatalf("state file is not compressed: %q", content)
+	}
+
+	// A later run resumes from the compressed state.
+	state = newPublishState(path)
+	if record, ok := state.lookup(ctx, "key"); !ok || record.Fingerprint != "fingerprint" || record.Publish != "publish2" {
+		t.Errorf("got record %v, %v", record, ok)
+	}
+
+	// Compression is recognized by content, so a state file which was
+	// compressed is still read after being renamed.
+	renamed := filepath.Join(t.TempDir(), "publishes.json")
+	if err := os.Rename(path, renamed); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := newPublishState(renamed).lookup(ctx, "key"); !ok {
+		t.Error("can't read renamed compressed state")
+	}
+}
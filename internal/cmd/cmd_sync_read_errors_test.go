@@ -120,3 +120,63 @@ func TestMainSyncUnreadableDir(t *testing.T) {
 		t.Error("unexpected error message", err)
 	}
 }
+
+func TestMainSyncSkipUnreadable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permissions are not enforced for root")
+	}
+
+	SetConfig(t, CONFIG)
+
+	logs := CaptureLogger(t)
+
+	// Make a couple of readable files, alongside a file and directory
+	// which can't be read.
+	os.Mkdir("src", 0755)
+	os.WriteFile("src/file1", []byte("hello"), 0644)
+	os.WriteFile("src/file2", []byte("can't read me"), 0000)
+	os.Mkdir("src/unreadable-dir", 0000)
+	os.Mkdir("src/subdir", 0755)
+	os.WriteFile("src/subdir/file3", []byte("world"), 0644)
+
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	args := []string{"rsync", "--exodus-skip-unreadable", "src/", "exodus:/some/target"}
+
+	got := Main(args)
+
+	// It should succeed, publishing only the readable files.
+	if got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	uris := []string{}
+	for _, item := range client.publishes[0].items {
+		uris = append(uris, item.WebURI)
+	}
+	if fmt.Sprint(uris) != "[/some/target/file1 /some/target/subdir/file3]" {
+		t.Errorf("unexpected published items %v", uris)
+	}
+
+	// It should have warned about each unreadable entry, and counted them.
+	skipped := map[string]bool{}
+	for _, entry := range logs.Entries {
+		if entry.Message == "Skipping unreadable file" {
+			skipped[fmt.Sprint(entry.Fields["src"])] = true
+		}
+	}
+	if !skipped["src/file2"] || !skipped["src/unreadable-dir"] || len(skipped) != 2 {
+		t.Errorf("unexpected skipped entries %v", skipped)
+	}
+
+	entry := FindEntry(logs, "Skipped files and directories which couldn't be read")
+	if entry == nil || entry.Fields["skipped"] != 2 {
+		t.Errorf("missing expected log message, got %v", entry)
+	}
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncRemap(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+	rules := writeRemapRules(t, `
+^hello-copy-(.*)$  greetings/$1.txt
+^subdir/           binaries/
+`)
+
+	got := Main([]string{"rsync", "--exodus-remap", rules, srcPath, "exodus:/dest"})
+
+	// It should complete successfully.
+	if got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	itemMap := make(map[string]string)
+	for _, item := range client.publishes[0].items {
+		itemMap[item.WebURI] = item.ObjectKey
+	}
+
+	// Remapped paths are relative to SRC, even without a trailing slash.
+	expectedItems := map[string]string{
+		"/dest/greetings/one.txt":    "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+		"/dest/greetings/two.txt":    "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+		"/dest/binaries/some-binary": "c66f610d98b2c9fe0175a3e99ba64d7fc7de45046515ff325be56329a9347dd6",
+	}
+
+	if !reflect.DeepEqual(itemMap, expectedItems) {
+		t.Error("did not publish expected items, published:", itemMap)
+	}
+}
+
+func TestMainSyncRemapUnmatched(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+	rules := writeRemapRules(t, "^subdir/(some)-(binary)$ $2-$1\n")
+
+	got := Main([]string{"rsync", "--exodus-remap", rules, srcPath, "exodus:/dest"})
+
+	// It should complete successfully.
+	if got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	itemMap := make(map[string]string)
+	for _, item := range client.publishes[0].items {
+		itemMap[item.WebURI] = item.ObjectKey
+	}
+
+	// Unmatched paths use the default mapping.
+	expectedItems := map[string]string{
+		"/dest/just-files/hello-copy-one": "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+		"/dest/just-files/hello-copy-two": "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+		"/dest/binary-some":               "c66f610d98b2c9fe0175a3e99ba64d7fc7de45046515ff325be56329a9347dd6",
+	}
+
+	if !reflect.DeepEqual(itemMap, expectedItems) {
+		t.Error("did not publish expected items, published:", itemMap)
+	}
+}
+
+func TestMainSyncRemapMissingFile(t *testing.T) {
+	SetConfig(t, CONFIG)
+	logs := CaptureLogger(t)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	got := Main([]string{"rsync", "--exodus-remap", "/does/not/exist", ".", "exodus:/dest"})
+
+	if got != 73 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	if FindEntry(logs, "can't read --exodus-remap file") == nil {
+		t.Error("missing expected log message")
+	}
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+// A client joining publishes which can't be used.
+type brokenPublishClient struct {
+	FakeClient
+}
+
+func (c *brokenPublishClient) GetPublish(_ context.Context, id string) (gw.Publish, error) {
+	return &BrokenPublish{id: id}, nil
+}
+
+func TestMainSyncResumePublish(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	const (
+		helloKey  = "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+		binaryKey = "c66f610d98b2c9fe0175a3e99ba64d7fc7de45046515ff325be56329a9347dd6"
+	)
+
+	helloOne := gw.ItemInput{WebURI: "/dest/hello-copy-one", ObjectKey: helloKey, ContentType: "text/plain; charset=utf-8"}
+	binary := gw.ItemInput{WebURI: "/dest/subdir/some-binary", ObjectKey: binaryKey, ContentType: "application/octet-stream"}
+
+	tests := []struct {
+		name      string
+		published []gw.ItemInput
+		wantAdded []string
+		wantBlobs []string
+	}{
+		{"nothing yet", nil,
+			[]string{"/dest/hello-copy-one", "/dest/hello-copy-two", "/dest/subdir/some-binary"},
+			[]string{helloKey, binaryKey}},
+
+		// The blob of an item already on the publish isn't even checked.
+		{"binary already added", []gw.ItemInput{binary},
+			[]string{"/dest/hello-copy-one", "/dest/hello-copy-two"},
+			[]string{helloKey}},
+
+		// Content shared with an item still to be added is still uploaded.
+		{"half already added", []gw.ItemInput{helloOne, binary},
+			[]string{"/dest/hello-copy-two"},
+			[]string{helloKey}},
+
+		// An item added with different content is added again.
+		{"stale item", []gw.ItemInput{{WebURI: binary.WebURI, ObjectKey: helloKey, ContentType: binary.ContentType}},
+			[]string{"/dest/hello-copy-one", "/dest/hello-copy-two", "/dest/subdir/some-binary"},
+			[]string{helloKey, binaryKey}},
+
+		{"everything already added", []gw.ItemInput{helloOne, binary,
+			{WebURI: "/dest/hello-copy-two", ObjectKey: helloKey, ContentType: "text/plain; charset=utf-8"}},
+			[]string{},
+			[]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG)
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			existing := append([]gw.ItemInput{}, tt.published...)
+			client.publishes = []FakePublish{{items: existing, id: "3e0a4539-be4a-437e-a45f-6d72f7192f17"}}
+
+			got := Main([]string{
+				"rsync", "--exodus-publish", "3e0a4539-be4a-437e-a45f-6d72f7192f17",
+				srcPath + "/", "exodus:/dest",
+			})
+			if got != 0 {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			added := []string{}
+			for _, item := range client.publishes[0].items[len(tt.published):] {
+				added = append(added, item.WebURI)
+			}
+			sort.Strings(added)
+			if !reflect.DeepEqual(added, tt.wantAdded) {
+				t.Errorf("added items %v, want %v", added, tt.wantAdded)
+			}
+
+			blobs := []string{}
+			for key := range client.blobs {
+				blobs = append(blobs, key)
+			}
+			sort.Strings(blobs)
+			if !reflect.DeepEqual(blobs, tt.wantBlobs) {
+				t.Errorf("uploaded blobs %v, want %v", blobs, tt.wantBlobs)
+			}
+		})
+	}
+}
+
+// A client whose publishes fail once some of the items have been added onto
+// them, as if a request partway through adding items failed.
+type partialAddClient struct {
+	FakeClient
+	limit int
+}
+
+type partialAddPublish struct {
+	*FakePublish
+	limit int
+}
+
+func (c *partialAddClient) NewPublish(ctx context.Context) (gw.Publish, error) {
+	publish, err := c.FakeClient.NewPublish(ctx)
+	return &partialAddPublish{publish.(*FakePublish), c.limit}, err
+}
+
+func (p *partialAddPublish) AddItems(ctx context.Context, items []gw.ItemInput) error {
+	if len(items) > p.limit {
+		p.FakePublish.AddItems(ctx, items[:p.limit])
+		return fmt.Errorf("simulated error after %d items", p.limit)
+	}
+	return p.FakePublish.AddItems(ctx, items)
+}
+
+func TestMainSyncResumeAfterFailedAdd(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	SetConfig(t, CONFIG+"loglevel: none\n")
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := partialAddClient{FakeClient: FakeClient{blobs: make(map[string]string)}, limit: 2}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil).Times(2)
+
+	// The first attempt fails after adding 2 of the 3 items...
+	if got := Main([]string{"rsync", srcPath + "/", "exodus:/dest"}); got != 51 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+	if len(client.publishes) != 1 || len(client.publishes[0].items) != 2 {
+		t.Fatalf("unexpected publishes %v", client.publishes)
+	}
+	added := client.publishes[0].items[:2]
+
+	// ...and resuming it by joining the publish adds only the remaining item.
+	got := Main([]string{
+		"rsync", "--exodus-publish", client.publishes[0].id, srcPath + "/", "exodus:/dest",
+	})
+	if got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	items := client.publishes[0].items
+	if len(items) != 3 || !reflect.DeepEqual(items[:2], added) {
+		t.Fatalf("unexpected items %v", items)
+	}
+	if items[2].WebURI == added[0].WebURI || items[2].WebURI == added[1].WebURI {
+		t.Errorf("item %s added again", items[2].WebURI)
+	}
+}
+
+func TestMainSyncResumePublishItemsFail(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	SetConfig(t, CONFIG+"loglevel: none\n")
+	logs := CaptureLogger(t)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := brokenPublishClient{}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	got := Main([]string{"rsync", "--exodus-publish", "3e0a4539-be4a-437e-a45f-6d72f7192f17", srcPath + "/", "exodus:/dest"})
+	if got != 67 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	if FindEntry(logs, "can't get items of publish") == nil {
+		t.Error("missing expected log message")
+	}
+}
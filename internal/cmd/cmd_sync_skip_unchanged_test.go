@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"crypto/md5"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+// servedItem is an item as served by fakeServingCDN.
+type servedItem struct {
+	body        string
+	contentType string
+	etag        string
+}
+
+// fakeServingCDN serves items with their headers, as the CDN does, counting
+// the requests which fetched content.
+func fakeServingCDN(t *testing.T, content map[string]servedItem) (*httptest.Server, *int32) {
+	var gets int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		item, ok := content[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", item.contentType)
+		w.Header().Set("Content-Length", fmt.Sprint(len(item.body)))
+		if item.etag != "" {
+			w.Header().Set("ETag", item.etag)
+		}
+		if r.Method == "GET" {
+			atomic.AddInt32(&gets, 1)
+			fmt.Fprint(w, item.body)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &gets
+}
+
+// served returns body as served from S3, with its MD5 checksum as ETag.
+func served(body, contentType string) servedItem {
+	return servedItem{body, contentType, fmt.Sprintf(`"%x"`, md5.Sum([]byte(body)))}
+}
+
+func TestMainSyncSkipUnchanged(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	helloBytes, err := os.ReadFile(srcPath + "/hello-copy-one")
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryBytes, err := os.ReadFile(srcPath + "/subdir/some-binary")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hello := served(string(helloBytes), "text/plain; charset=utf-8")
+	binary := served(string(binaryBytes), "application/octet-stream")
+
+	live := map[string]servedItem{
+		"/dest/hello-copy-one":     hello,
+		"/dest/hello-copy-two":     hello,
+		"/dest/subdir/some-binary": binary,
+	}
+
+	noETag := binary
+	noETag.etag = ""
+
+	changed := served(strings.ToUpper(hello.body), hello.contentType)
+
+	retyped := binary
+	retyped.contentType = "text/plain"
+
+	tests := []struct {
+		name      string
+		content   map[string]servedItem
+		args      []string
+		committed int
+		gets      int32
+	}{
+		{"identical", live, nil, 0, 0},
+
+		{"without ETag",
+			map[string]servedItem{
+				"/dest/hello-copy-one":     hello,
+				"/dest/hello-copy-two":     hello,
+				"/dest/subdir/some-binary": noETag,
+			},
+			nil, 0, 1},
+
+		{"changed content",
+			map[string]servedItem{
+				"/dest/hello-copy-one":     hello,
+				"/dest/hello-copy-two":     changed,
+				"/dest/subdir/some-binary": binary,
+			},
+			nil, 1, 1},
+
+		{"changed content type",
+			map[string]servedItem{
+				"/dest/hello-copy-one":     hello,
+				"/dest/hello-copy-two":     hello,
+				"/dest/subdir/some-binary": retyped,
+			},
+			nil, 1, 0},
+
+		{"missing item",
+			map[string]servedItem{
+				"/dest/hello-copy-one": hello,
+				"/dest/hello-copy-two": hello,
+			},
+			nil, 1, 0},
+
+		{"with visibility", live, []string{"--exodus-visibility", "*=restricted"}, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cdn, gets := fakeServingCDN(t, tt.content)
+
+			// With one thread, no check is cancelled by another finding a
+			// change, so the content fetched is as expected.
+			SetConfig(t, CONFIG+"cdnurl: "+cdn.URL+"\nuploadthreads: 1\n")
+			logs := CaptureLogger(t)
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			args := append([]string{"rsync", "--exodus-skip-unchanged"}, tt.args...)
+			got := Main(append(args, srcPath+"/", "exodus:/dest"))
+
+			if got != 0 {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			// The items are added onto the publish either way.
+			if len(client.publishes) != 1 || len(client.publishes[0].items) != 3 {
+				t.Fatalf("unexpected publishes %v", client.publishes)
+			}
+			if client.publishes[0].committed != tt.committed {
+				t.Errorf("publish committed %d time(s), want %d", client.publishes[0].committed, tt.committed)
+			}
+
+			skipped := FindEntry(logs, "Nothing to commit, items are identical to those published")
+			if (tt.committed == 0) != (skipped != nil) {
+				t.Errorf("unexpected log message %v", skipped)
+			}
+
+			// Content is only fetched where the ETag can't tell it's the same.
+			if *gets > tt.gets {
+				t.Errorf("content fetched %d time(s), want at most %d", *gets, tt.gets)
+			}
+		})
+	}
+}
+
+func TestMainSyncSkipUnchangedLinks(t *testing.T) {
+	srcPath := t.TempDir()
+	for name, content := range map[string]string{"a": "first\n", "b": "other\n"} {
+		if err := os.WriteFile(filepath.Join(srcPath, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("a", filepath.Join(srcPath, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	a := served("first\n", "text/plain; charset=utf-8")
+	b := served("other\n", "text/plain; charset=utf-8")
+
+	tests := []struct {
+		name      string
+		link      servedItem
+		committed int
+	}{
+		{"same target", a, 0},
+		{"other target", b, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cdn, _ := fakeServingCDN(t, map[string]servedItem{
+				"/dest/a": a, "/dest/b": b, "/dest/link": tt.link,
+			})
+
+			SetConfig(t, CONFIG+"cdnurl: "+cdn.URL+"\n")
+			CaptureLogger(t)
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			got := Main([]string{"rsync", "-rl", "--exodus-skip-unchanged", srcPath + "/", "exodus:/dest"})
+
+			if got != 0 {
+				t.Fatal("returned incorrect exit code", got)
+			}
+			if len(client.publishes) != 1 {
+				t.Fatalf("unexpected publishes %v", client.publishes)
+			}
+			if client.publishes[0].committed != tt.committed {
+				t.Errorf("publish committed %d time(s), want %d", client.publishes[0].committed, tt.committed)
+			}
+		})
+	}
+}
+
+func TestMainSyncSkipUnchangedInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		args   []string
+		errMsg string
+	}{
+		{"without cdnurl", "", nil,
+			"--exodus-skip-unchanged requires 'cdnurl' in configuration"},
+		{"joined publish", "cdnurl: https://cdn.example.com\n", []string{"--exodus-publish", "3e0a4539-be4a-437e-a45f-6d72f7192f17"},
+			"--exodus-skip-unchanged can't be used with --exodus-publish or --exodus-pipeline"},
+		{"pipeline", "cdnurl: https://cdn.example.com\n", []string{"--exodus-pipeline"},
+			"--exodus-skip-unchanged can't be used with --exodus-publish or --exodus-pipeline"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG+"loglevel: none\n"+tt.config)
+			logs := CaptureLogger(t)
+
+			args := append([]string{"rsync", "--exodus-skip-unchanged"}, tt.args...)
+			got := Main(append(args, ".", "exodus:/dest"))
+
+			if got != 23 {
+				t.Error("returned incorrect exit code", got)
+			}
+			if FindEntry(logs, tt.errMsg) == nil {
+				t.Error("missing expected log message")
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncWriteTaskID(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"one env", nil, "task-3e0a4539-be4a-437e-a45f-6d72f7192f17-1\n"},
+
+		// Each commit is recorded.
+		{"multiple envs", []string{"--exodus-env=one,two"},
+			"task-3e0a4539-be4a-437e-a45f-6d72f7192f17-1\ntask-3e0a4539-be4a-437e-a45f-6d72f7192f17-1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG)
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: map[string]string{}}
+			mockGw.EXPECT().NewClient(gomock.Any(), gomock.Any()).Return(&client, nil).AnyTimes()
+
+			idFile := filepath.Join(t.TempDir(), "task-id")
+
+			args := append([]string{"rsync", "--exodus-write-task-id=" + idFile}, tt.args...)
+			got := Main(append(args, srcPath+"/", "exodus:/dest"))
+
+			if got != 0 {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			content, err := os.ReadFile(idFile)
+			if err != nil {
+				t.Fatalf("can't read task ID, err = %v", err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("unexpected task IDs %q", content)
+			}
+		})
+	}
+}
+
+func TestMainSyncWriteTaskIDNotCommitted(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: map[string]string{}}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	idFile := filepath.Join(t.TempDir(), "task-id")
+
+	got := Main([]string{"rsync", "--exodus-write-task-id=" + idFile, "--exodus-commit=none",
+		srcPath + "/", "exodus:/dest"})
+
+	if got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	// Without a commit, there's no task to record.
+	if _, err := os.Stat(idFile); !os.IsNotExist(err) {
+		t.Errorf("unexpectedly wrote task ID, err = %v", err)
+	}
+}
+
+func TestMainSyncWriteTaskIDFails(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	SetConfig(t, CONFIG+"loglevel: none\n")
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: map[string]string{}}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	idFile := filepath.Join(t.TempDir(), "missing", "task-id")
+
+	got := Main([]string{"rsync", "--exodus-write-task-id=" + idFile, srcPath + "/", "exodus:/dest"})
+
+	// The publish is committed regardless, so the sync doesn't fail.
+	if got != 0 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if len(client.publishes) != 1 || client.publishes[0].committed != 1 {
+		t.Errorf("unexpected publishes %+v", client.publishes)
+	}
+	entry := FindEntry(logs, "can't write task ID")
+	if entry == nil || entry.Fields["task"] != "task-3e0a4539-be4a-437e-a45f-6d72f7192f17-1" {
+		t.Errorf("missing expected log message, got %v", entry)
+	}
+}
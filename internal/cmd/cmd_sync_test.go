@@ -75,10 +75,18 @@ func (c *FakeClient) EnsureUploaded(ctx context.Context, items []walk.SyncItem,
 	processedItems := make(map[string]walk.SyncItem)
 
 	for _, item := range items {
+		// As with the real client, unfollowed symlinks have no content to
+		// upload.
+		if item.Key == "" && item.LinkTo != "" {
+			continue
+		}
+
 		if _, ok := processedItems[item.Key]; ok {
 			err = onDuplicate(item)
-		} else if _, ok := c.blobs[item.Key]; ok {
+		} else if _, ok := c.blobs[item.Key]; (ok || gw.PresentBlobsFromContext(ctx)[item.Key]) && !gw.ForceUploadFromContext(ctx)[item.Key] {
 			err = onExisting(item)
+		} else if gw.NoUploadFromContext(ctx) {
+			err = fmt.Errorf("blob %s of %s is not present, and uploads are disabled", item.Key, item.SrcPath)
 		} else {
 			c.blobs[item.Key] = item.SrcPath
 			processedItems[item.Key] = item
@@ -106,6 +114,20 @@ func (c *FakeClient) GetPublish(ctx context.Context, id string) (gw.Publish, err
 	return nil, fmt.Errorf("publish not found: '%s'", id)
 }
 
+func (c *FakeClient) ListPublishes(ctx context.Context, filter gw.PublishFilter) ([]gw.PublishInfo, error) {
+	out := []gw.PublishInfo{}
+	for _, p := range c.publishes {
+		state := "PENDING"
+		if p.frozen {
+			state = "COMMITTED"
+		}
+		if len(filter.States) == 0 || contains(filter.States, state) {
+			out = append(out, gw.PublishInfo{ID: p.id, Env: "best-env", State: state})
+		}
+	}
+	return out, nil
+}
+
 func (c *FakeClient) WhoAmI(context.Context) (map[string]interface{}, error) {
 	out := make(map[string]interface{})
 	out["whoami"] = "fake-info"
@@ -120,10 +142,18 @@ func (p *FakePublish) AddItems(ctx context.Context, items []gw.ItemInput) error
 	return nil
 }
 
+func (p *FakePublish) Items(ctx context.Context) ([]gw.ItemInput, error) {
+	return p.items, nil
+}
+
 func (p *BrokenPublish) AddItems(_ context.Context, _ []gw.ItemInput) error {
 	return fmt.Errorf("invalid publish")
 }
 
+func (p *BrokenPublish) Items(_ context.Context) ([]gw.ItemInput, error) {
+	return nil, fmt.Errorf("invalid publish")
+}
+
 func (p *BrokenPublish) Commit(_ context.Context, _ string) error {
 	return fmt.Errorf("invalid publish")
 }
@@ -141,10 +171,32 @@ func (p *FakePublish) ID() string {
 	return p.id
 }
 
+func (p *FakePublish) State() string {
+	if p.frozen {
+		return "COMMITTED"
+	}
+	return "PENDING"
+}
+
+func (p *FakePublish) TaskID() string {
+	if p.committed == 0 {
+		return ""
+	}
+	return fmt.Sprintf("task-%s-%d", p.id, p.committed)
+}
+
 func (p *BrokenPublish) ID() string {
 	return p.id
 }
 
+func (p *BrokenPublish) State() string {
+	return ""
+}
+
+func (p *BrokenPublish) TaskID() string {
+	return ""
+}
+
 func TestMainTypicalSync(t *testing.T) {
 	wd, err := os.Getwd()
 	if err != nil {
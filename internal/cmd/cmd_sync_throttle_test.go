@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncThrottleOnError(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	for _, throttle := range []bool{false, true} {
+		SetConfig(t, CONFIG)
+		ctrl := MockController(t)
+
+		mockGw := gw.NewMockInterface(ctrl)
+		ext.gw = mockGw
+
+		client := FakeClient{blobs: map[string]string{}}
+		mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).DoAndReturn(
+			func(ctx context.Context, _ conf.Config) (gw.Client, error) {
+				if gw.ThrottleOnErrorFromContext(ctx) != throttle {
+					t.Errorf("client created with throttle on error %v, want %v", !throttle, throttle)
+				}
+				return &client, nil
+			})
+
+		argv := []string{"rsync", srcPath + "/", "exodus:/dest"}
+		if throttle {
+			argv = append(argv[:1], append([]string{"--exodus-throttle-on-error"}, argv[1:]...)...)
+		}
+		if got := Main(argv); got != 0 {
+			t.Fatal("returned incorrect exit code", got)
+		}
+	}
+}
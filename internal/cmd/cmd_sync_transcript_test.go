@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncTranscript(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).DoAndReturn(
+		func(ctx context.Context, _ conf.Config) (gw.Client, error) {
+			// The client is responsible for recording into the transcript.
+			if gw.TranscriptFromContext(ctx) == nil {
+				t.Error("client created without a transcript")
+			}
+			return &client, nil
+		})
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+	transcript := filepath.Join(t.TempDir(), "gw.jsonl")
+
+	got := Main([]string{"rsync", "--exodus-transcript", transcript, srcPath + "/", "exodus:/dest"})
+
+	if got != 0 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if _, err := os.Stat(transcript); err != nil {
+		t.Errorf("transcript not created: %v", err)
+	}
+}
+
+func TestMainSyncTranscriptInvalid(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG)
+	MockController(t)
+	logs := CaptureLogger(t)
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+	transcript := filepath.Join(t.TempDir(), "missing", "gw.jsonl")
+
+	got := Main([]string{"rsync", "--exodus-transcript", transcript, srcPath + "/", "exodus:/dest"})
+
+	if got != 23 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if FindEntry(logs, "can't create transcript") == nil {
+		t.Error("missing expected log message")
+	}
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+// Sets up a source tree at "src" within the current directory, along with a
+// file outside of the source tree.
+func makeTraversalTree(t *testing.T) {
+	for _, dir := range []string{"src/sub", "outside"} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"src/sub/file", "outside/secret"} {
+		if err := os.WriteFile(file, []byte("hello world\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("../../outside/secret", "src/sub/link"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMainSyncRelativeTraversal(t *testing.T) {
+	SetConfig(t, CONFIG)
+	makeTraversalTree(t)
+
+	if err := os.Chdir("src"); err != nil {
+		t.Fatal(err)
+	}
+
+	logs := CaptureLogger(t)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	// With --relative, this SRC would place content at /outside/outside/secret,
+	// which is not within /dest.
+	got := Main([]string{"rsync", "--exodus-conf", "../exodus-rsync.conf", "-R", "../outside", "exodus:/dest"})
+
+	if got != 49 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	entry := FindEntry(logs, "can't determine web URI")
+	if entry == nil {
+		t.Fatal("missing expected log message")
+	}
+
+	expectedErr := "refusing to publish to '/outside/outside/secret': outside of destination '/dest'"
+	if errMessage := entry.Fields["error"].(error).Error(); errMessage != expectedErr {
+		t.Error("unexpected error message", errMessage)
+	}
+
+	// Nothing should have been uploaded or published.
+	if len(client.blobs) != 0 {
+		t.Error("unexpectedly uploaded content", client.blobs)
+	}
+	if len(client.publishes) != 0 {
+		t.Error("unexpectedly created publish")
+	}
+}
+
+func TestMainSyncLinksOutsideSrc(t *testing.T) {
+	SetConfig(t, CONFIG)
+	makeTraversalTree(t)
+
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	// Following a link out of the source tree publishes the link's target
+	// within the destination, at the path of the link.
+	got := Main([]string{"rsync", "-L", "src/", "exodus:/dest"})
+
+	if got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	itemMap := make(map[string]string)
+	for _, item := range client.publishes[0].items {
+		itemMap[item.WebURI] = item.ObjectKey
+	}
+
+	expectedItems := map[string]string{
+		"/dest/sub/file": "a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447",
+		"/dest/sub/link": "a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447",
+	}
+
+	if !reflect.DeepEqual(itemMap, expectedItems) {
+		t.Error("did not publish expected items, published:", itemMap)
+	}
+
+	// The uploaded blob must come from the link's resolved target rather
+	// than anywhere derived from the web URI.
+	for _, srcPath := range client.blobs {
+		if filepath.Dir(srcPath) != "src/sub" {
+			t.Error("uploaded from unexpected path", srcPath)
+		}
+	}
+}
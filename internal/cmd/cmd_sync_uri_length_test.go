@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncMaxURILength(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	tests := []struct {
+		name     string
+		config   string
+		exitCode int
+		errMsg   string
+	}{
+		{"no limit", "", 0, ""},
+
+		// The longest URI is /dest/subdir/some-binary, at 24 bytes.
+		{"within limit", "maxurilength: 24\n", 0, ""},
+
+		{"over limit", "maxurilength: 20\n", 49,
+			"1 web URI(s) longer than 20 bytes: /dest/subdir/some-binary (24 bytes)"},
+
+		{"several over limit", "maxurilength: 19\n", 49,
+			"3 web URI(s) longer than 19 bytes: /dest/hello-copy-one (20 bytes), " +
+				"/dest/hello-copy-two (20 bytes), /dest/subdir/some-binary (24 bytes)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG+"loglevel: none\n"+tt.config)
+			logs := CaptureLogger(t)
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			got := Main([]string{"rsync", srcPath + "/", "exodus:/dest"})
+
+			if got != tt.exitCode {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			if tt.errMsg == "" {
+				if len(client.publishes) != 1 {
+					t.Errorf("expected 1 publish, got %v", client.publishes)
+				}
+				return
+			}
+
+			// Nothing should be uploaded or published.
+			if len(client.blobs) != 0 || len(client.publishes) != 0 {
+				t.Errorf("unexpected blobs %v, publishes %v", client.blobs, client.publishes)
+			}
+
+			entry := FindEntry(logs, "can't publish items with overly long web URIs")
+			if entry == nil {
+				t.Fatal("missing expected log message")
+			}
+			if entry.Fields["error"].(error).Error() != tt.errMsg {
+				t.Errorf("unexpected error: %v", entry.Fields["error"])
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+// Returns a fake CDN serving the given content by path.
+func fakeCDN(t *testing.T, content map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := content[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestMainSyncVerifyAfterCommit(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	hello, err := os.ReadFile(srcPath + "/hello-copy-one")
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary, err := os.ReadFile(srcPath + "/subdir/some-binary")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		content  map[string]string
+		exitCode int
+		errMsg   string
+	}{
+		{"all present",
+			map[string]string{
+				"/dest/hello-copy-one":     string(hello),
+				"/dest/hello-copy-two":     string(hello),
+				"/dest/subdir/some-binary": string(binary),
+			},
+			0, ""},
+
+		{"missing item",
+			map[string]string{
+				"/dest/hello-copy-one": string(hello),
+				"/dest/hello-copy-two": string(hello),
+			},
+			72, "1 of 3 item(s) failed verification: /dest/subdir/some-binary"},
+
+		{"wrong content",
+			map[string]string{
+				"/dest/hello-copy-one":     string(hello),
+				"/dest/hello-copy-two":     strings.ToUpper(string(hello)),
+				"/dest/subdir/some-binary": string(binary),
+			},
+			72, "1 of 3 item(s) failed verification: /dest/hello-copy-two"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cdn := fakeCDN(t, tt.content)
+
+			SetConfig(t, CONFIG+"\ncdnurl: "+cdn.URL+"\n")
+			logs := CaptureLogger(t)
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: make(map[string]string)}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			// Verify with a sample larger than the publish, so every item is checked.
+			got := Main([]string{"rsync", "--exodus-verify-after-commit", "10", srcPath + "/", "exodus:/dest"})
+
+			if got != tt.exitCode {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			// It should have committed before verifying.
+			if client.publishes[0].committed != 1 {
+				t.Error("publish was not committed")
+			}
+
+			if tt.errMsg == "" {
+				return
+			}
+
+			entry := FindEntry(logs, "can't verify published items")
+			if entry == nil {
+				t.Fatal("missing expected log message")
+			}
+
+			if errMessage := fmt.Sprint(entry.Fields["error"]); errMessage != tt.errMsg {
+				t.Error("unexpected error message", errMessage)
+			}
+		})
+	}
+}
+
+func TestMainSyncVerifySample(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	// Nothing is present on the CDN, so every fetch fails.
+	cdn := fakeCDN(t, map[string]string{})
+
+	SetConfig(t, CONFIG+"\ncdnurl: "+cdn.URL+"\n")
+	logs := CaptureLogger(t)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	got := Main([]string{"rsync", "--exodus-verify-after-commit", "2", srcPath + "/", "exodus:/dest"})
+
+	if got != 72 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	// Only the requested number of items should have been checked.
+	entry := FindEntry(logs, "can't verify published items")
+	if entry == nil {
+		t.Fatal("missing expected log message")
+	}
+
+	if errMessage := fmt.Sprint(entry.Fields["error"]); !strings.HasPrefix(errMessage, "2 of 2 item(s) failed verification") {
+		t.Error("unexpected error message", errMessage)
+	}
+}
+
+func TestMainSyncVerifyNoCdnURL(t *testing.T) {
+	SetConfig(t, CONFIG)
+	logs := CaptureLogger(t)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	got := Main([]string{"rsync", "--exodus-verify-after-commit", "1", ".", "exodus:/dest"})
+
+	if got != 23 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	if FindEntry(logs, "--exodus-verify-after-commit requires 'cdnurl' in configuration") == nil {
+		t.Error("missing expected log message")
+	}
+
+	// It should have failed before publishing anything.
+	if len(client.publishes) != 0 {
+		t.Error("unexpectedly created publish")
+	}
+}
+
+func TestMainSyncVerifyMismatch(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcPath := path.Clean(wd + "/../../test/data/srctrees/just-files")
+
+	hello, err := os.ReadFile(srcPath + "/hello-copy-one")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := map[string]string{
+		// Same length as published, but not the same content.
+		"/dest/hello-copy-one": strings.ToUpper(string(hello)),
+		// Too long, with and without declaring a length.
+		"/dest/hello-copy-two":     string(hello) + "extra",
+		"/dest/subdir/some-binary": strings.Repeat("x", 10000),
+	}
+
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dest/subdir/some-binary" {
+			// Flushing before writing the content means it's sent chunked,
+			// with no Content-Length.
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, content[r.URL.Path])
+	}))
+	t.Cleanup(cdn.Close)
+
+	SetConfig(t, CONFIG+"\ncdnurl: "+cdn.URL+"\n")
+	logs := CaptureLogger(t)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	got := Main([]string{"rsync", "--exodus-verify-after-commit", "10", srcPath + "/", "exodus:/dest"})
+
+	if got != 72 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	// Each mismatch should be reported for its URI.
+	failures := map[string]string{}
+	for _, entry := range logs.Entries {
+		if entry.Message == "Verification failed" {
+			failures[fmt.Sprint(entry.Fields["uri"])] = fmt.Sprint(entry.Fields["error"])
+		}
+	}
+
+	expected := map[string]string{
+		"/dest/hello-copy-one":     "expected checksum 5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03, got",
+		"/dest/hello-copy-two":     "expected 6 bytes, got Content-Length 11",
+		"/dest/subdir/some-binary": "expected 200 bytes, got 201",
+	}
+	if len(failures) != len(expected) {
+		t.Errorf("unexpected failures %v", failures)
+	}
+	for uri, msg := range expected {
+		if !strings.HasPrefix(failures[uri], msg) {
+			t.Errorf("%s: got error %q, expected %q", uri, failures[uri], msg)
+		}
+	}
+}
+
+func TestVerifyPublishedContentEncoding(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	fmt.Fprint(gz, "hello world\n")
+	gz.Close()
+
+	// As for an item published with a content encoding, the CDN serves the
+	// blob as is, declaring its encoding.
+	var acceptEncoding string
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(compressed.Bytes())
+	}))
+	t.Cleanup(cdn.Close)
+
+	item := verifyItem{
+		uri:  "/dest/hello.gz",
+		key:  fmt.Sprintf("%x", sha256.Sum256(compressed.Bytes())),
+		size: int64(compressed.Len()),
+	}
+
+	// The blob should be verified without decompressing it.
+	if err := verifyPublished(testContext(), cdn.URL, []verifyItem{item}); err != nil {
+		t.Errorf("verification failed, err = %v", err)
+	}
+	if acceptEncoding != "identity" {
+		t.Errorf("unexpected Accept-Encoding %q", acceptEncoding)
+	}
+}
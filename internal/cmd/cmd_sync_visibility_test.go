@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+func TestMainSyncVisibility(t *testing.T) {
+	srcPath := t.TempDir()
+
+	files := map[string]os.FileMode{
+		"public.txt":     0644,
+		"private.txt":    0640,
+		"images/dvd.iso": 0644,
+	}
+	for name, mode := range files {
+		path := filepath.Join(srcPath, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), mode); err != nil {
+			t.Fatal(err)
+		}
+		// Not subject to the umask.
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("public.txt", filepath.Join(srcPath, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+
+	client := FakeClient{blobs: make(map[string]string)}
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+	got := Main([]string{
+		"rsync", "-l", "--exodus-visibility", "*.iso=restricted,o-r=restricted,o+r=public",
+		srcPath + "/", "exodus:/dest",
+	})
+	if got != 0 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	visibility := make(map[string]string)
+	for _, item := range client.publishes[0].items {
+		visibility[item.WebURI] = item.Visibility
+	}
+
+	expected := map[string]string{
+		"/dest/public.txt":     "public",
+		"/dest/private.txt":    "restricted",
+		"/dest/images/dvd.iso": "restricted",
+		// Links have no visibility of their own.
+		"/dest/link": "",
+	}
+	if !reflect.DeepEqual(visibility, expected) {
+		t.Errorf("unexpected visibility %v", visibility)
+	}
+}
+
+func TestMainSyncVisibilityInvalid(t *testing.T) {
+	SetConfig(t, CONFIG+"loglevel: none\n")
+	logs := CaptureLogger(t)
+
+	got := Main([]string{"rsync", "--exodus-visibility", "o-r=hidden", ".", "exodus:/dest"})
+	if got != 23 {
+		t.Fatal("returned incorrect exit code", got)
+	}
+
+	if FindEntry(logs, "invalid --exodus-visibility") == nil {
+		t.Error("missing expected log message")
+	}
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+// Returns a response from /whoami for a service account granted roles.
+func whoAmIResponseFor(roles ...string) map[string]interface{} {
+	anyRoles := []interface{}{}
+	for _, role := range roles {
+		anyRoles = append(anyRoles, role)
+	}
+	return map[string]interface{}{
+		"client": map[string]interface{}{
+			"roles":            anyRoles,
+			"authenticated":    true,
+			"serviceAccountId": "svc-publisher",
+		},
+		"user": map[string]interface{}{
+			"roles":            []interface{}{},
+			"authenticated":    false,
+			"internalUsername": nil,
+		},
+	}
+}
+
+func TestMainWhoAmI(t *testing.T) {
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+	out := captureStdout(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+	client := gw.NewMockClient(ctrl)
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(client, nil)
+	client.EXPECT().WhoAmI(gomock.Any()).Return(
+		whoAmIResponseFor("best-env-publisher", "best-env-blob-uploader", "pre-publisher"), nil)
+
+	got := Main([]string{"rsync", "--exodus-whoami", ".", "exodus:/dest"})
+
+	if got != 0 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	want := []string{
+		"ENV            best-env",
+		"AUTHENTICATED  true",
+		"CLIENT         svc-publisher",
+		"ROLES          best-env-blob-uploader, best-env-publisher, pre-publisher",
+		"ENVIRONMENTS   best-env, pre",
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	if FindEntry(logs, "Not authorized for the exodus-gw environment") != nil {
+		t.Error("unexpectedly warned about authorization")
+	}
+}
+
+func TestMainWhoAmIJSON(t *testing.T) {
+	SetConfig(t, CONFIG)
+	ctrl := MockController(t)
+	logs := CaptureLogger(t)
+	out := captureStdout(t)
+
+	mockGw := gw.NewMockInterface(ctrl)
+	ext.gw = mockGw
+	client := gw.NewMockClient(ctrl)
+	mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(client, nil)
+	client.EXPECT().WhoAmI(gomock.Any()).Return(whoAmIResponseFor("pre-publisher"), nil)
+
+	got := Main([]string{"rsync", "--exodus-whoami", "--exodus-list-format", "json", ".", "exodus:/dest"})
+
+	// The credentials work, but not for this environment, which is worth a
+	// warning rather than a failure.
+	if got != 0 {
+		t.Error("returned incorrect exit code", got)
+	}
+
+	var who identity
+	if err := json.Unmarshal(out.Bytes(), &who); err != nil {
+		t.Fatalf("output is not valid JSON, err = %v:\n%s", err, out.String())
+	}
+	want := identity{
+		Env:           "best-env",
+		Authenticated: true,
+		Client:        "svc-publisher",
+		Roles:         []string{"pre-publisher"},
+		Environments:  []string{"pre"},
+	}
+	if !reflect.DeepEqual(who, want) {
+		t.Errorf("got identity %+v, want %+v", who, want)
+	}
+
+	entry := FindEntry(logs, "Not authorized for the exodus-gw environment")
+	if entry == nil || entry.Fields["environments"] != "pre" {
+		t.Errorf("missing expected log message, got %v", entry)
+	}
+}
+
+func TestMainWhoAmIUnauthorized(t *testing.T) {
+	tests := []struct {
+		name     string
+		response map[string]interface{}
+		err      error
+		message  string
+	}{
+		{"request fails", nil, errors.New("401 Unauthorized"), "can't authenticate with exodus-gw"},
+		{"not authenticated", map[string]interface{}{
+			"client": map[string]interface{}{"roles": []interface{}{}, "authenticated": false},
+		}, nil, "Not authenticated with exodus-gw"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(t, CONFIG+"loglevel: none\n")
+			ctrl := MockController(t)
+			logs := CaptureLogger(t)
+			captureStdout(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+			client := gw.NewMockClient(ctrl)
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(client, nil)
+			client.EXPECT().WhoAmI(gomock.Any()).Return(tt.response, tt.err)
+
+			got := Main([]string{"rsync", "--exodus-whoami", ".", "exodus:/dest"})
+
+			if got != 68 {
+				t.Error("returned incorrect exit code", got)
+			}
+
+			entry := FindEntry(logs, tt.message)
+			if entry == nil || entry.Fields["env"] != "best-env" {
+				t.Errorf("missing expected log message, got %v", entry)
+			}
+		})
+	}
+}
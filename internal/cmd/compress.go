@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Files written by exodus-rsync, such as the publish state and
+// --exodus-dump-items, are gzip-compressed if their name ends in ".gz".
+// Compressed files are recognized on read by their content, whatever their
+// name.
+
+// gzipMagic starts the content of every gzip-compressed file.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// isCompressedPath returns whether the file at path is written compressed.
+func isCompressedPath(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".gz"
+}
+
+// uncompressedExt returns the extension of path, ignoring any ".gz", so that
+// "items.csv.gz" has the extension ".csv".
+func uncompressedExt(path string) string {
+	if isCompressedPath(path) {
+		path = path[:len(path)-len(".gz")]
+	}
+	return strings.ToLower(filepath.Ext(path))
+}
+
+// compressFor returns content as it should be written into the file at path.
+func compressFor(path string, content []byte) ([]byte, error) {
+	if !isCompressedPath(path) {
+		return content, nil
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// readMaybeCompressed returns the content of the file at path, decompressed
+// if needed.
+func readMaybeCompressed(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil || !bytes.HasPrefix(content, gzipMagic) {
+		return content, err
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
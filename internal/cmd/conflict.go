@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+// Number of times a publish is retried after its commit conflicts with
+// another publish, when using --exodus-on-conflict=retry.
+const conflictRetries = 3
+
+// Delay before the first retry of a conflicting publish, doubling for each
+// retry after it.
+var conflictRetryDelay = 10 * time.Second
+
+// publishRetryingConflicts is like publishToEnv, but with
+// --exodus-on-conflict=retry it repeats the whole publish if the commit
+// conflicts with another publish.
+//
+// Each retry uses a new publish, so that the content is checked against the
+// state of exodus-gw at the time rather than that of the conflicting attempt.
+func publishRetryingConflicts(
+	ctx context.Context,
+	cfg conf.Config,
+	gwClient gw.Client,
+	args args.Config,
+	items []walk.SyncItem,
+	publishItems []gw.ItemInput,
+	verify bool,
+	hold *commitHold,
+	publishIDs *idFile,
+	taskIDs *idFile,
+	resume *continueManifest,
+) int {
+	logger := log.FromContext(ctx)
+	delay := conflictRetryDelay
+
+	for attempt := 1; ; attempt++ {
+		code := publishToEnv(ctx, cfg, gwClient, args, items, publishItems, verify, hold, publishIDs, taskIDs, resume)
+		if code != 75 || args.OnConflict != "retry" {
+			return code
+		}
+
+		if args.Publish != "" {
+			// The publish belongs to the caller, who must decide what to do with it.
+			logger.F("publish", args.Publish).Error("can't retry a publish joined via --exodus-publish")
+			return code
+		}
+
+		if attempt > conflictRetries {
+			logger.F("env", cfg.GwEnv(), "attempts", attempt).Error("Publish still conflicts with another publish, giving up")
+			return code
+		}
+
+		logger.F("env", cfg.GwEnv(), "attempt", attempt, "delay", delay).Warn("Publish conflicts with another publish, retrying")
+
+		select {
+		case <-ctx.Done():
+			return code
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+// contentRule is a 'contentrules' entry with its pattern compiled.
+type contentRule struct {
+	conf.ContentRule
+	pattern *regexp.Regexp
+}
+
+// contentRules override the content type and encoding which would otherwise
+// be used for published items, e.g. so that precompressed files are served
+// with a Content-Encoding rather than as an archive.
+type contentRules []contentRule
+
+func newContentRules(rules []conf.ContentRule) (contentRules, error) {
+	out := contentRules{}
+
+	for _, rule := range rules {
+		if rule.ContentType == "" && rule.ContentEncoding == "" {
+			return nil, fmt.Errorf("content rule '%s' sets neither contenttype nor contentencoding", rule.Pattern)
+		}
+
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern in content rule: %w", err)
+		}
+
+		out = append(out, contentRule{rule, pattern})
+	}
+
+	return out, nil
+}
+
+// match returns the first rule matching the given web URI, or nil if none match.
+func (r contentRules) match(uri string) *contentRule {
+	for i := range r {
+		if r[i].pattern.MatchString(uri) {
+			return &r[i]
+		}
+	}
+	return nil
+}
+
+// detectDecodedMIME is like detectMIME, but detects the type of an item's
+// content after decoding it according to encoding. Encodings other than gzip
+// aren't decoded.
+func detectDecodedMIME(ctx context.Context, item walk.SyncItem, encoding string) (*mimetype.MIME, error) {
+	if encoding != "gzip" {
+		return detectMIME(ctx, item)
+	}
+
+	r, err := item.OpenContext(ctx)
+	if err != nil {
+		return mimetype.Lookup("application/octet-stream"), err
+	}
+	defer r.Close()
+
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return mimetype.Lookup("application/octet-stream"), err
+	}
+	defer zr.Close()
+
+	return mimetype.DetectReader(zr)
+}
+
+// sniffLimit is the most content considered by http.DetectContentType.
+const sniffLimit = 512
+
+// sniffMIME returns the content type of an item as determined by
+// http.DetectContentType, reading only as much of the item as
+// that considers. As with detectDecodedMIME, gzip content is decoded.
+//
+// It's a fallback for the few kinds of content which mimetype can't classify
+// from a short prefix, such as WebM video.
+func sniffMIME(ctx context.Context, item walk.SyncItem, encoding string) (string, error) {
+	r, err := item.OpenContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	if encoding == "gzip" {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return "", err
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	buf := make([]byte, sniffLimit)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+
+	return http.DetectContentType(buf[:n]), nil
+}
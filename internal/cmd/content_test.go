@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+func TestContentRulesMatch(t *testing.T) {
+	rules, err := newContentRules([]conf.ContentRule{
+		{Pattern: `/repodata/.*\.xml\.gz$`, ContentType: "application/xml", ContentEncoding: "gzip"},
+		{Pattern: `\.gz$`, ContentEncoding: "gzip"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		uri      string
+		expected string
+	}{
+		// The first matching rule wins.
+		{"/dest/repodata/primary.xml.gz", "application/xml"},
+		{"/dest/other.gz", ""},
+	}
+
+	for _, tt := range tests {
+		rule := rules.match(tt.uri)
+		if rule == nil || rule.ContentType != tt.expected || rule.ContentEncoding != "gzip" {
+			t.Errorf("match(%q) = %v, expected content type %q", tt.uri, rule, tt.expected)
+		}
+	}
+
+	if rule := rules.match("/dest/repodata/repomd.xml"); rule != nil {
+		t.Errorf("unexpected match %v", rule)
+	}
+}
+
+func TestContentRulesInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  conf.ContentRule
+		error string
+	}{
+		{"bad pattern", conf.ContentRule{Pattern: "(", ContentEncoding: "gzip"}, "invalid pattern in content rule"},
+		{"no effect", conf.ContentRule{Pattern: `\.gz$`}, "sets neither contenttype nor contentencoding"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newContentRules([]conf.ContentRule{tt.rule})
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("did not get expected error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSniffMIME(t *testing.T) {
+	dir := t.TempDir()
+
+	gzipped := &bytes.Buffer{}
+	zw := gzip.NewWriter(gzipped)
+	zw.Write([]byte("a sha256sum  file\n"))
+	zw.Close()
+
+	tests := []struct {
+		name     string
+		content  []byte
+		encoding string
+		expected string
+	}{
+		{"text", []byte("a sha256sum  file\n"), "", "text/plain; charset=utf-8"},
+		{"binary", []byte{0x00, 0x01, 0x02, 0xff}, "", "application/octet-stream"},
+		{"webm", []byte("\x1aE\xdf\xa3"), "", "video/webm"},
+		{"gzipped text", gzipped.Bytes(), "gzip", "text/plain; charset=utf-8"},
+
+		// Only the prefix is considered, so binary content after it is
+		// not noticed.
+		{"long", append(bytes.Repeat([]byte("x"), sniffLimit), 0x00), "", "text/plain; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, tt.content, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := sniffMIME(context.Background(), walk.SyncItem{SrcPath: path}, tt.encoding)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("sniffMIME = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := sniffMIME(context.Background(), walk.SyncItem{SrcPath: filepath.Join(dir, "missing")}, ""); !os.IsNotExist(err) {
+		t.Errorf("did not get expected error, got %v", err)
+	}
+}
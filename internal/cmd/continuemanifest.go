@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+// continueManifest records what a sync has confirmed done, in the file named
+// by --exodus-continue-from-manifest, so that a later sync continuing after a
+// crash can skip it without asking exodus-gw: the blobs found or made present,
+// the publish created in each environment, and the items added onto it.
+//
+// The file holds one JSON record per line, and is only ever appended to, so
+// that records written before a crash survive it. If its name ends in ".gz",
+// each batch of records is appended as a gzip member of its own, so that the
+// file remains a valid gzip stream after each write.
+type continueManifest struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	envs map[string]*continueEnv
+}
+
+// continueRecord is a single line of a continue manifest.
+type continueRecord struct {
+	Env string `json:"env"`
+
+	// A blob found or made present, by its key within the bucket, while
+	// adding onto the last publish created.
+	Blob string `json:"blob,omitempty"`
+
+	// A publish created, or onto which Item was added, or which is finished
+	// with: committed, or failed to commit such that it can't be continued.
+	Publish  string        `json:"publish,omitempty"`
+	Item     *gw.ItemInput `json:"item,omitempty"`
+	Finished bool          `json:"finished,omitempty"`
+}
+
+// continueEnv is what's recorded as done in one environment.
+type continueEnv struct {
+	// The last publish created and not committed, and the items added onto it.
+	publish string
+	added   []gw.ItemInput
+
+	// The blobs found or made present for that publish. They're trusted to
+	// be present only until it's finished, since they may later be removed,
+	// e.g. by lifecycle rules of the bucket.
+	blobs map[string]bool
+}
+
+// openContinueManifest returns the manifest recorded in the file at path,
+// which is created if missing, or nil if path is empty.
+//
+// A sync may crash part way through writing a record, so reading stops with a
+// warning at the first line which can't be parsed, or at a truncated gzip
+// member.
+func openContinueManifest(ctx context.Context, path string) (*continueManifest, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &continueManifest{path: path, file: file, envs: make(map[string]*continueEnv)}
+	logger := log.FromContext(ctx)
+
+	// As for other files, compression is recognized by the content.
+	var content io.Reader = bufio.NewReader(file)
+	if magic, _ := content.(*bufio.Reader).Peek(len(gzipMagic)); bytes.Equal(magic, gzipMagic) {
+		if content, err = gzip.NewReader(content); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	scanner := bufio.NewScanner(content)
+	scanner.Buffer(nil, 1024*1024)
+	line := 1
+	for ; scanner.Scan(); line++ {
+		var record continueRecord
+		if err = json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			break
+		}
+		out.apply(record)
+	}
+	if err == nil {
+		err = scanner.Err()
+		// A truncated gzip member is a record being written when a sync
+		// crashed, like a partial line; other errors are real failures.
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, gzip.ErrChecksum) && !errors.Is(err, gzip.ErrHeader) {
+			file.Close()
+			return nil, err
+		}
+	}
+	if err != nil {
+		logger.F("path", path, "line", line, "error", err).Warn("Ignoring the rest of unreadable continue manifest")
+	}
+
+	return out, nil
+}
+
+func (m *continueManifest) apply(record continueRecord) {
+	env := m.envs[record.Env]
+	if env == nil {
+		env = &continueEnv{blobs: make(map[string]bool)}
+		m.envs[record.Env] = env
+	}
+
+	switch {
+	case record.Blob != "":
+		env.blobs[record.Blob] = true
+	case record.Item != nil:
+		if record.Publish == env.publish {
+			env.added = append(env.added, *record.Item)
+		}
+	case record.Finished:
+		if record.Publish == env.publish {
+			env.publish, env.added, env.blobs = "", nil, make(map[string]bool)
+		}
+	case record.Publish != "":
+		env.publish, env.added, env.blobs = record.Publish, nil, make(map[string]bool)
+	}
+}
+
+// env returns a copy of what's recorded as done in the named environment.
+func (m *continueManifest) env(name string) continueEnv {
+	out := continueEnv{blobs: make(map[string]bool)}
+	if m == nil {
+		return out
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if env := m.envs[name]; env != nil {
+		for key := range env.blobs {
+			out.blobs[key] = true
+		}
+		out.publish = env.publish
+		out.added = append(out.added, env.added...)
+	}
+	return out
+}
+
+// record appends records to the manifest.
+func (m *continueManifest) record(records ...continueRecord) error {
+	if m == nil {
+		return nil
+	}
+
+	var buf []byte
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+
+	buf, err := compressFor(m.path, buf)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.file.Write(buf); err != nil {
+		return err
+	}
+	for _, record := range records {
+		m.apply(record)
+	}
+	return nil
+}
+
+// publish returns publish in the named environment, recording the items
+// added onto it.
+func (m *continueManifest) publish(env string, publish gw.Publish) gw.Publish {
+	if m == nil {
+		return publish
+	}
+	return continuePublish{publish, m, env}
+}
+
+func (m *continueManifest) close() error {
+	if m == nil {
+		return nil
+	}
+	return m.file.Close()
+}
+
+// continuePublish is a gw.Publish recording the items added onto it in a
+// continueManifest.
+type continuePublish struct {
+	gw.Publish
+	manifest *continueManifest
+	env      string
+}
+
+func (p continuePublish) AddItems(ctx context.Context, items []gw.ItemInput) error {
+	if err := p.Publish.AddItems(ctx, items); err != nil {
+		return err
+	}
+
+	records := make([]continueRecord, len(items))
+	for i := range items {
+		records[i] = continueRecord{Env: p.env, Publish: p.ID(), Item: &items[i]}
+	}
+	if err := p.manifest.record(records...); err != nil {
+		return fmt.Errorf("writing continue manifest: %w", err)
+	}
+	return nil
+}
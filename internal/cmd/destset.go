@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// destPlaceholder matches a placeholder in DEST, such as {arch}, replaced by
+// each value of --exodus-dest-set.
+var destPlaceholder = regexp.MustCompile(`\{[A-Za-z0-9_]+\}`)
+
+// expandDest returns the destinations given by replacing the placeholder in
+// dest with each of values, in order.
+//
+// Each value must be a single path component, so that every destination
+// stays beneath the part of dest preceding the placeholder.
+func expandDest(dest string, values []string) ([]string, error) {
+	matches := destPlaceholder.FindAllStringIndex(dest, -1)
+	if len(matches) != 1 {
+		return nil, fmt.Errorf("DEST must contain one placeholder such as {arch} for use with --exodus-dest-set, found %d", len(matches))
+	}
+	start, end := matches[0][0], matches[0][1]
+
+	seen := make(map[string]bool)
+	out := []string{}
+	for _, value := range values {
+		if value == "" || value == "." || value == ".." || strings.Contains(value, "/") {
+			return nil, fmt.Errorf("invalid value '%s' in --exodus-dest-set, must be a single path component", value)
+		}
+		if seen[value] {
+			return nil, fmt.Errorf("duplicate value '%s' in --exodus-dest-set", value)
+		}
+		seen[value] = true
+
+		out = append(out, dest[:start]+value+dest[end:])
+	}
+
+	return out, nil
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+// publishDiff is the difference between the items of two publishes, as
+// written by --exodus-diff-publishes. Each list is sorted by web URI.
+type publishDiff struct {
+	First  string `json:"first"`
+	Second string `json:"second"`
+
+	// Web URIs of items only in the first or second publish.
+	OnlyFirst  []string `json:"only_first"`
+	OnlySecond []string `json:"only_second"`
+
+	// Items in both publishes, with different content or link targets.
+	Differing []differingItem `json:"differing"`
+
+	// Web URIs of items identical in both publishes.
+	Identical []string `json:"identical"`
+}
+
+// differingItem is an item at the same web URI in both publishes.
+type differingItem struct {
+	WebURI string     `json:"web_uri"`
+	First  itemTarget `json:"first"`
+	Second itemTarget `json:"second"`
+}
+
+// itemTarget is what an item publishes: either content or a link.
+type itemTarget struct {
+	ObjectKey string `json:"object_key,omitempty"`
+	LinkTo    string `json:"link_to,omitempty"`
+}
+
+func (t itemTarget) String() string {
+	if t.LinkTo != "" {
+		return "-> " + t.LinkTo
+	}
+	return t.ObjectKey
+}
+
+// diffItems compares the items of two publishes by web URI. As for
+// --exodus-base-manifest, items are the same if they have the same object
+// key and link target.
+func diffItems(firstID string, first []gw.ItemInput, secondID string, second []gw.ItemInput) publishDiff {
+	out := publishDiff{
+		First:      firstID,
+		Second:     secondID,
+		OnlyFirst:  []string{},
+		OnlySecond: []string{},
+		Differing:  []differingItem{},
+		Identical:  []string{},
+	}
+
+	targets := make(map[string]itemTarget, len(second))
+	for _, item := range second {
+		targets[item.WebURI] = itemTarget{item.ObjectKey, item.LinkTo}
+	}
+
+	seen := make(map[string]bool, len(first))
+	for _, item := range first {
+		seen[item.WebURI] = true
+
+		target := itemTarget{item.ObjectKey, item.LinkTo}
+		other, ok := targets[item.WebURI]
+		switch {
+		case !ok:
+			out.OnlyFirst = append(out.OnlyFirst, item.WebURI)
+		case other != target:
+			out.Differing = append(out.Differing, differingItem{item.WebURI, target, other})
+		default:
+			out.Identical = append(out.Identical, item.WebURI)
+		}
+	}
+
+	for _, item := range second {
+		if !seen[item.WebURI] {
+			out.OnlySecond = append(out.OnlySecond, item.WebURI)
+		}
+	}
+
+	sort.Strings(out.OnlyFirst)
+	sort.Strings(out.OnlySecond)
+	sort.Strings(out.Identical)
+	sort.Slice(out.Differing, func(i, j int) bool {
+		return out.Differing[i].WebURI < out.Differing[j].WebURI
+	})
+
+	return out
+}
+
+// diffPublishes writes the difference between the items of the two publishes
+// given by --exodus-diff-publishes to stdout, and returns the exit code.
+func diffPublishes(ctx context.Context, cfg conf.Config, args args.Config) int {
+	logger := log.FromContext(ctx)
+
+	gwClient, err := ext.gw.NewClient(ctx, cfg)
+	if err != nil {
+		logger.F("error", err).Error("can't initialize exodus-gw client")
+		return 101
+	}
+
+	items := make([][]gw.ItemInput, len(args.DiffPublishes))
+	for i, id := range args.DiffPublishes {
+		publish, err := gwClient.GetPublish(ctx, id)
+		if err == nil {
+			items[i], err = publish.Items(ctx)
+		}
+		if err != nil {
+			logger.F("env", cfg.GwEnv(), "publish", id, "error", err).Error("can't get items of publish")
+			return 68
+		}
+	}
+
+	diff := diffItems(args.DiffPublishes[0], items[0], args.DiffPublishes[1], items[1])
+
+	if args.ListFormat == "json" {
+		err = writeJSON(stdout, diff)
+	} else {
+		err = writeDiffTable(stdout, diff)
+	}
+	if err != nil {
+		logger.F("error", err).Error("can't write difference of publishes")
+		return 68
+	}
+
+	return 0
+}
+
+func writeDiffTable(w io.Writer, diff publishDiff) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "STATUS\tWEB URI\t"+diff.First+"\t"+diff.Second)
+	for _, uri := range diff.OnlyFirst {
+		fmt.Fprintf(tw, "only-first\t%s\t\t\n", uri)
+	}
+	for _, uri := range diff.OnlySecond {
+		fmt.Fprintf(tw, "only-second\t%s\t\t\n", uri)
+	}
+	for _, item := range diff.Differing {
+		fmt.Fprintf(tw, "differing\t%s\t%s\t%s\n", item.WebURI, item.First, item.Second)
+	}
+	for _, uri := range diff.Identical {
+		fmt.Fprintf(tw, "identical\t%s\t\t\n", uri)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/atomicfile"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+// dumpedItem is an item as written by --exodus-dump-items.
+type dumpedItem struct {
+	WebURI          string `json:"web_uri"`
+	ObjectKey       string `json:"object_key"`
+	ContentType     string `json:"content_type"`
+	ContentEncoding string `json:"content_encoding"`
+	LinkTo          string `json:"link_to"`
+	Size            int64  `json:"size"`
+
+	// Labels of the publish created for the item, from --exodus-label.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// dumpFormat returns the format in which to write --exodus-dump-items:
+// that given by --exodus-dump-format, or else according to the extension of
+// the file, ignoring any ".gz" by which it's compressed.
+func dumpFormat(args args.Config) (string, error) {
+	if args.DumpFormat != "" {
+		return args.DumpFormat, nil
+	}
+
+	switch ext := uncompressedExt(args.DumpItems); ext {
+	case ".csv", ".json":
+		return ext[1:], nil
+	default:
+		return "", fmt.Errorf("can't determine format of '%s' by its extension, use --exodus-dump-format", args.DumpItems)
+	}
+}
+
+// sameFile returns whether paths a and b name the same file, whether or not
+// it exists yet.
+func sameFile(a, b string) bool {
+	aInfo, aErr := os.Stat(a)
+	bInfo, bErr := os.Stat(b)
+	if aErr == nil && bErr == nil {
+		return os.SameFile(aInfo, bInfo)
+	}
+
+	aAbs, aErr := filepath.Abs(a)
+	bAbs, bErr := filepath.Abs(b)
+	return aErr == nil && bErr == nil && aAbs == bAbs
+}
+
+// dumpItems writes the items which would be published into the file at
+// path, in the given format, "csv" or "json", each with the labels of the
+// publish created for it.
+//
+// Each of publishItems corresponds to the item at the same index in items,
+// whose size is written; links have a size of 0.
+func dumpItems(path string, format string, items []walk.SyncItem, publishItems []gw.ItemInput, labels map[string]string) error {
+	// As a single CSV field, labels are written as a JSON object.
+	csvLabels := ""
+	if len(labels) > 0 {
+		encoded, err := json.Marshal(labels)
+		if err != nil {
+			return err
+		}
+		csvLabels = string(encoded)
+	}
+
+	out := make([]dumpedItem, len(publishItems))
+	for i, item := range publishItems {
+		out[i] = dumpedItem{
+			WebURI:          item.WebURI,
+			ObjectKey:       item.ObjectKey,
+			ContentType:     item.ContentType,
+			ContentEncoding: item.ContentEncoding,
+			LinkTo:          item.LinkTo,
+			Labels:          labels,
+		}
+		if item.LinkTo == "" && items[i].Info != nil {
+			out[i].Size = items[i].Info.Size()
+		}
+	}
+
+	var buf bytes.Buffer
+
+	switch format {
+	case "json":
+		if err := writeJSON(&buf, out); err != nil {
+			return err
+		}
+	case "csv":
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"web_uri", "object_key", "content_type", "content_encoding", "link_to", "size", "labels"})
+		for _, item := range out {
+			w.Write([]string{
+				item.WebURI, item.ObjectKey, item.ContentType, item.ContentEncoding,
+				item.LinkTo, fmt.Sprint(item.Size), csvLabels,
+			})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported format '%s'", format)
+	}
+
+	content, err := compressFor(path, buf.Bytes())
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, content)
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+// costEstimate describes the work a sync would do, as reported in dry-run mode.
+type costEstimate struct {
+	// Total size of the blobs which would be uploaded.
+	uploadBytes int64
+
+	// Number of blobs which would be uploaded.
+	uploads int
+
+	// Number of S3 PUT requests needed to upload them; large blobs are
+	// uploaded in several parts, each with its own request.
+	puts int
+
+	// Number of further S3 requests creating and completing multipart
+	// uploads, two for each blob uploaded in several parts.
+	multipartRequests int
+
+	// Number of requests to exodus-gw which would add items onto the publish.
+	itemBatches int
+}
+
+// estimateCost returns the cost of uploading the given blobs and adding the
+// given number of items onto a publish, batchSize items at a time.
+//
+// Blobs already present or duplicated within the sync should not be included
+// in uploaded, as they wouldn't be uploaded.
+func estimateCost(uploaded []walk.SyncItem, items int, batchSize int) costEstimate {
+	out := costEstimate{uploads: len(uploaded)}
+
+	for _, item := range uploaded {
+		var size int64
+		if item.Info != nil {
+			size = item.Info.Size()
+		}
+		out.uploadBytes += size
+
+		// Matches the uploader's default part size, which is used for
+		// every upload.
+		parts := int((size + s3manager.DefaultUploadPartSize - 1) / s3manager.DefaultUploadPartSize)
+		if parts < 1 {
+			parts = 1
+		}
+		out.puts += parts
+
+		// The uploader only makes a multipart upload if there's more than
+		// one part, otherwise making a single PutObject request.
+		if parts > 1 {
+			out.multipartRequests += 2
+		}
+	}
+
+	// As in the client, a batch size below 1 means all items are added at once.
+	if batchSize < 1 {
+		batchSize = items
+	}
+	if items > 0 {
+		out.itemBatches = (items + batchSize - 1) / batchSize
+	}
+
+	return out
+}
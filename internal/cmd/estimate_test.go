@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+func TestEstimateCost(t *testing.T) {
+	dir := t.TempDir()
+
+	sizes := map[string]int64{
+		"empty": 0,
+		"small": 100,
+		// Large enough to need three parts.
+		"large": 12 * 1024 * 1024,
+	}
+
+	var items []walk.SyncItem
+	for name, size := range sizes {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Truncate(path, size); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, walk.SyncItem{SrcPath: path, Info: info})
+	}
+
+	got := estimateCost(items, 10, 4)
+	expected := costEstimate{
+		uploadBytes: 100 + 12*1024*1024,
+		uploads:     3,
+		puts:        5,
+
+		// Creating and completing the upload of the large blob.
+		multipartRequests: 2,
+
+		itemBatches: 3,
+	}
+	if got != expected {
+		t.Errorf("got %+v, expected %+v", got, expected)
+	}
+
+	// A batch size below 1 adds every item at once.
+	if got := estimateCost(nil, 10, 0); got.itemBatches != 1 {
+		t.Errorf("expected 1 batch, got %d", got.itemBatches)
+	}
+	if got := estimateCost(nil, 0, 0); got != (costEstimate{}) {
+		t.Errorf("expected no cost, got %+v", got)
+	}
+}
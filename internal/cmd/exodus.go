@@ -3,17 +3,22 @@ package cmd
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/gabriel-vasile/mimetype"
 	"github.com/release-engineering/exodus-rsync/internal/args"
 	"github.com/release-engineering/exodus-rsync/internal/conf"
 	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/jsonutil"
 	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/progress"
 	"github.com/release-engineering/exodus-rsync/internal/walk"
 )
 
@@ -58,6 +63,52 @@ func webURI(srcPath string, srcTree string, destTree string, srcIsDir bool) stri
 	return path.Join(destTree, relPath)
 }
 
+// checkSource ensures that src exists and is readable, returning its info.
+func checkSource(src string, isTar bool) (os.FileInfo, error) {
+	if isTar {
+		// A trailing slash is meaningful for the destination path, but the
+		// archive itself is a file.
+		src = strings.TrimSuffix(src, "/")
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+
+	if isTar && !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s: not a tar archive", src)
+	}
+	if !info.IsDir() && !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s: not a regular file or directory", src)
+	}
+
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if info.IsDir() {
+		// Listing the directory is needed in order to walk it.
+		if _, err := f.Readdirnames(1); err != nil && err != io.EOF {
+			return nil, err
+		}
+	}
+
+	return info, nil
+}
+
+func detectMIME(ctx context.Context, item walk.SyncItem) (*mimetype.MIME, error) {
+	r, err := item.OpenContext(ctx)
+	if err != nil {
+		return mimetype.Lookup("application/octet-stream"), err
+	}
+	defer r.Close()
+
+	return mimetype.DetectReader(r)
+}
+
 func commitMode(cfg conf.Config, args args.Config) (bool, string) {
 	// Calculates effective commit mode for current run, given arguments and config.
 	//
@@ -80,23 +131,302 @@ func commitMode(cfg conf.Config, args args.Config) (bool, string) {
 	return true, mode
 }
 
+// checkPublishLimits returns a non-zero exit code if items exceed the limits
+// on the size of a publish, unless overridden by --exodus-force.
+//
+// The limits guard against accidentally publishing a much larger tree than
+// intended, so they're checked before anything is uploaded.
+func checkPublishLimits(ctx context.Context, cfg conf.Config, args args.Config, items []walk.SyncItem) int {
+	logger := log.FromContext(ctx)
+
+	var totalBytes int64
+	for _, item := range items {
+		if item.LinkTo == "" && item.Info != nil {
+			totalBytes += item.Info.Size()
+		}
+	}
+
+	maxBytes, maxItems := cfg.MaxPublishBytes(), cfg.MaxPublishItems()
+	exceeded := (maxBytes > 0 && totalBytes > maxBytes) || (maxItems > 0 && len(items) > maxItems)
+	if !exceeded {
+		return 0
+	}
+
+	entry := logger.F(
+		"bytes", totalBytes, "maxpublishbytes", maxBytes,
+		"items", len(items), "maxpublishitems", maxItems,
+	)
+	if args.Force {
+		entry.Warn("Publish exceeds configured limits, proceeding due to --exodus-force")
+		return 0
+	}
+
+	entry.Error("Publish exceeds configured limits, use --exodus-force to publish anyway")
+	return 26
+}
+
+// checksumChoiceOK returns true if a --checksum-choice can be honored when
+// publishing via exodus-gw.
+//
+// exodus-gw identifies every blob by its SHA-256 digest and provides no means
+// of using anything else, so that's the only algorithm which can be chosen.
+// As with rsync, the choice may be a comma-separated pair of algorithms.
+func checksumChoiceOK(choice string) bool {
+	if choice == "" {
+		return true
+	}
+	for _, alg := range strings.Split(choice, ",") {
+		if alg != "auto" && alg != "sha256" {
+			return false
+		}
+	}
+	return true
+}
+
+// envConfig overrides the exodus-gw environment of a config, as requested
+// by --exodus-env.
+type envConfig struct {
+	conf.Config
+	gwEnv string
+}
+
+func (c envConfig) GwEnv() string {
+	return c.gwEnv
+}
+
 func exodusMain(ctx context.Context, cfg conf.Config, args args.Config) int {
 	logger := log.FromContext(ctx)
 
+	// Each publish is bound to one environment, so it can't be joined
+	// from several.
+	if len(args.Env) > 1 && args.Publish != "" {
+		logger.Error("--exodus-publish can't be used with multiple environments in --exodus-env")
+		return 23
+	}
+
+	if !checksumChoiceOK(args.ChecksumChoice) {
+		logger.F("checksum-choice", args.ChecksumChoice).Error(
+			"Unsupported --checksum-choice, exodus-gw only supports sha256")
+		return 23
+	}
+
+	// exodus-gw takes an expiration in whole seconds.
+	if args.ExpireAfter > 0 && args.ExpireAfter < time.Second {
+		logger.F("expire-after", args.ExpireAfter).Error("--exodus-expire-after must be at least 1s")
+		return 23
+	}
+
+	// An expiration is set when creating a publish.
+	if args.ExpireAfter > 0 && args.Publish != "" {
+		logger.Error("--exodus-expire-after can't be used with --exodus-publish")
+		return 23
+	}
+
+	labels, err := newLabels(args.Label)
+	if err != nil {
+		logger.F("error", err).Error("invalid --exodus-label")
+		return 23
+	}
+
+	if args.MaxErrors > 0 && !args.KeepGoing {
+		logger.Error("--exodus-max-errors requires --exodus-keep-going")
+		return 23
+	}
+
+	// The publish to continue is the one recorded, not one given.
+	if args.ContinueFromManifest != "" && args.Publish != "" {
+		logger.Error("--exodus-continue-from-manifest can't be used with --exodus-publish")
+		return 23
+	}
+
+	// Like an expiration, labels are set when creating a publish.
+	if labels != nil && args.Publish != "" {
+		logger.Error("--exodus-label can't be used with --exodus-publish")
+		return 23
+	}
+
+	// Only the attributes named in configuration are captured, so that
+	// arbitrary attributes of source files aren't published.
+	if args.CaptureXattrs && len(cfg.Xattrs()) == 0 {
+		logger.Error("--exodus-capture-xattrs requires xattrs in configuration")
+		return 23
+	}
+
+	// A joined publish may hold items from elsewhere, and a pipelined publish
+	// is committed while uploading, so neither commit can be skipped.
+	if args.SkipUnchanged && (args.Publish != "" || args.Pipeline) {
+		logger.Error("--exodus-skip-unchanged can't be used with --exodus-publish or --exodus-pipeline")
+		return 23
+	}
+	if args.SkipUnchanged && cfg.CdnURL() == "" {
+		logger.Error("--exodus-skip-unchanged requires 'cdnurl' in configuration")
+		return 23
+	}
+
+	// Patterns select files from beneath a directory, which --files-from
+	// and --exodus-tar also do in their own ways.
+	if args.Glob && (args.FilesFrom != "" || args.Tar) {
+		logger.Error("--exodus-glob can't be used with --files-from or --exodus-tar")
+		return 23
+	}
+
+	visibility, err := newVisibilityRules(args.Visibility)
+	if err != nil {
+		logger.F("error", err).Error("invalid --exodus-visibility")
+		return 23
+	}
+
+	forceUpload, err := newForceUploadPatterns(args.ForceUpload)
+	if err != nil {
+		logger.F("error", err).Error("invalid --exodus-force-upload")
+		return 23
+	}
+
+	// Nothing is uploaded at all with --exodus-fix-content-types.
+	if len(forceUpload) > 0 && args.FixContentTypes {
+		logger.Error("--exodus-force-upload can't be used with --exodus-fix-content-types")
+		return 23
+	}
+
+	var itemsFormat string
+	if args.DumpItems != "" {
+		var err error
+		if itemsFormat, err = dumpFormat(args); err != nil {
+			logger.F("error", err).Error("can't dump items")
+			return 23
+		}
+	}
+
+	var base manifest
+	if args.BaseManifest != "" {
+		if base, err = readManifest(args.BaseManifest); err != nil {
+			logger.F("error", err).Error("can't read base manifest")
+			return 73
+		}
+	}
+
+	hold, err := newCommitHold(args.HoldCommit)
+	if err != nil {
+		logger.F("error", err).Error("can't hold commit")
+		return 23
+	}
+
+	publishIDs := newIDFile(args.WritePublishID)
+	taskIDs := newIDFile(args.WriteTaskID)
+
+	// Nothing is really uploaded or added in dry-run or offline modes, so
+	// there's nothing to continue from or record.
+	var resume *continueManifest
+	if !args.DryRun && args.Offline == "" {
+		if resume, err = openContinueManifest(ctx, args.ContinueFromManifest); err != nil {
+			logger.F("error", err).Error("can't open continue manifest")
+			return 73
+		}
+		defer resume.close()
+	}
+
+	envs := syncEnvs(cfg, args)
+
+	// Nothing is published in dry-run or offline modes, so there's nothing
+	// to race with.
+	if !args.DryRun && args.Offline == "" {
+		release, code := acquirePublishLocks(ctx, envs, args.DestPath())
+		if code != 0 {
+			return code
+		}
+		defer release()
+	}
+
+	if args.Transcript != "" {
+		file, err := os.Create(args.Transcript)
+		if err != nil {
+			logger.F("error", err).Error("can't create transcript")
+			return 23
+		}
+		defer file.Close()
+
+		ctx = gw.WithTranscript(ctx, gw.NewTranscript(file))
+	}
+
+	if args.ThrottleOnError {
+		ctx = gw.WithThrottleOnError(ctx)
+	}
+	if args.ExpireAfter > 0 {
+		ctx = gw.WithExpireAfter(ctx, args.ExpireAfter)
+	}
+	if labels != nil {
+		ctx = gw.WithLabels(ctx, labels)
+	}
+
 	clientCtor := ext.gw.NewClient
 	if args.DryRun {
 		clientCtor = ext.gw.NewDryRunClient
 	}
-	gwClient, err := clientCtor(ctx, cfg)
-	if err != nil {
-		logger.F("error", err).Error("can't initialize exodus-gw client")
-		return 101
+	if args.Offline != "" {
+		clientCtor = func(ctx context.Context, cfg conf.Config) (gw.Client, error) {
+			dir := args.Offline
+			if len(envs) > 1 {
+				// Requests for each environment are kept apart.
+				dir = filepath.Join(dir, cfg.GwEnv())
+			}
+			return ext.gw.NewOfflineClient(ctx, cfg, dir)
+		}
 	}
 
-	var (
-		onlyThese []string
-		items     []walk.SyncItem
-	)
+	clients := make([]gw.Client, len(envs))
+	for i, env := range envs {
+		var err error
+		clients[i], err = clientCtor(ctx, env)
+		if err != nil {
+			logger.F("error", err).Error("can't initialize exodus-gw client")
+			return 101
+		}
+
+		// So that a dry run on different machines can be shown to compute
+		// the same keys.
+		if args.DryRun {
+			logger.F("env", env.GwEnv(), "algorithm", walk.KeyAlgorithm, "prefix", env.BlobKeyPrefix()).Info(
+				"Computing object keys")
+		}
+	}
+
+	var onlyThese []string
+
+	// Content from a URL is fetched while walking, so there's nothing to
+	// check up-front; a URL ending in a slash is a directory, whose files
+	// are named by --files-from.
+	srcIsURL := walk.IsURL(args.Src)
+	if srcIsURL && (args.Tar || args.Glob) {
+		logger.F("src", args.Src).Error("a URL source can't be used with --exodus-tar or --exodus-glob")
+		return 23
+	}
+
+	// With --exodus-glob, the matching files are published from beneath the
+	// directory preceding the pattern, as if listed in --files-from.
+	if args.Glob {
+		base, paths, err := expandSource(args.Src)
+		if err != nil {
+			logger.F("src", args.Src, "error", err).Error("can't expand source")
+			return 73
+		}
+		logger.F("src", args.Src, "base", base, "files", len(paths)).Info("Expanded source")
+
+		args.Src = strings.TrimSuffix(base, "/") + "/"
+		onlyThese = paths
+	}
+
+	// Check the source up-front, so that a mistyped path fails clearly rather
+	// than partway through the sync.
+	var fileStat os.FileInfo
+	if !srcIsURL {
+		fileStat, err = checkSource(args.Src, args.Tar)
+		if err != nil {
+			logger.F("src", args.Src, "error", err).Error("invalid source")
+			return 73
+		}
+	}
+
+	var items []walk.SyncItem
 
 	if args.FilesFrom != "" {
 		args.Relative = true
@@ -117,16 +447,31 @@ func exodusMain(ctx context.Context, cfg conf.Config, args args.Config) int {
 		scanner := bufio.NewScanner(f)
 		for scanner.Scan() {
 			path := filepath.Join(args.Src, strings.TrimSpace(scanner.Text()))
+			if srcIsURL {
+				// Joining would clean the "//" of the URL.
+				path = args.Src + strings.TrimPrefix(strings.TrimSpace(scanner.Text()), "/")
+			}
 			onlyThese = append(onlyThese, path)
 		}
 	}
 
-	fileStat, err := os.Stat(args.Src)
-	if err != nil {
-		logger.F("error", err).Error("can't stat file")
-		return 73
+	var remapRules []remapRule
+	if args.Remap != "" {
+		remapRules, err = loadRemapRules(args.Remap)
+		if err != nil {
+			logger.F("error", err).Error("can't read --exodus-remap file")
+			return 73
+		}
 	}
-	srcIsDir := fileStat.IsDir()
+
+	// A tar archive is treated as a directory containing the archive's entries.
+	srcIsDir := args.Tar || (srcIsURL && strings.HasSuffix(args.Src, "/")) || (fileStat != nil && fileStat.IsDir())
+
+	progress.FromContext(ctx).Emit(progress.Event{Type: progress.TypePhase, Phase: progress.PhaseWalk})
+
+	// Filters of the environment enforce its policy, so apply before those
+	// of the command line.
+	ctx = walk.WithBaseFilters(ctx, walk.Filters{Exclude: cfg.Exclude(), Include: cfg.Include()})
 
 	logger.Info("Walking directory tree")
 	err = walk.Walk(ctx, args, onlyThese, func(item walk.SyncItem) error {
@@ -150,6 +495,10 @@ func exodusMain(ctx context.Context, cfg conf.Config, args args.Config) int {
 			// the requested semantics, so make it an error.
 			return fmt.Errorf("--ignore-existing is not supported")
 		}
+		if cfg.SkipEmptyFiles() && item.LinkTo == "" && item.Info.Size() == 0 {
+			logger.F("src", item.SrcPath).Warn("Skipping empty file")
+			return nil
+		}
 		items = append(items, item)
 		return nil
 	})
@@ -158,109 +507,808 @@ func exodusMain(ctx context.Context, cfg conf.Config, args args.Config) int {
 		return 73
 	}
 
-	var publish gw.Publish
+	normalizer, err := newURINormalizer(cfg.URINormalize())
+	if err != nil {
+		logger.F("error", err).Error("invalid urinormalize configuration")
+		return 23
+	}
+
+	rules, err := newContentRules(cfg.ContentRules())
+	if err != nil {
+		logger.F("error", err).Error("invalid contentrules configuration")
+		return 23
+	}
+
+	aliases, err := newAliasRules(cfg.Aliases())
+	if err != nil {
+		logger.F("error", err).Error("invalid aliases configuration")
+		return 23
+	}
+
+	cacheControl, err := newCacheControlRules(cfg.CacheControlRules(), cfg.CacheControlDefault())
+	if err != nil {
+		logger.F("error", err).Error("invalid cachecontrolrules configuration")
+		return 23
+	}
+
+	// An exodus-gw not supporting the field would ignore it, so that the
+	// Cache-Control is silently never set.
+	if (len(cfg.CacheControlRules()) > 0 || cfg.CacheControlDefault() != "") && !cfg.GwCacheControl() {
+		logger.Error("'cachecontrolrules' and 'cachecontroldefault' require 'gwcachecontrol' in configuration")
+		return 23
+	}
+
+	verify := args.VerifyAfterCommit > 0 && !args.DryRun && args.Offline == ""
+	if verify && cfg.CdnURL() == "" {
+		logger.Error("--exodus-verify-after-commit requires 'cdnurl' in configuration")
+		return 23
+	}
+
+	// Where exodus-gw can't refuse to replace items itself, the CDN is
+	// checked for them instead.
+	checkReplace := args.NoReplace && !cfg.GwNoReplace() && args.Offline == ""
+	if checkReplace && cfg.CdnURL() == "" {
+		logger.Error("--exodus-no-replace requires 'gwnoreplace' or 'cdnurl' in configuration")
+		return 23
+	}
+
+	publishItems := []gw.ItemInput{}
+
+	// With --exodus-dest-set, the items are published beneath each of the
+	// destinations expanded from DEST.
+	dests := []string{args.Dest}
+	if len(args.DestSet) > 0 {
+		dests, err = expandDest(args.Dest, args.DestSet)
+		if err != nil {
+			logger.F("dest", args.Dest, "error", err).Error("can't expand destination")
+			return 23
+		}
+	}
+
+	// Web URIs of items seen so far, in lowercase, when checking for URIs
+	// which collide on a case-insensitive CDN.
+	var foldedURIs map[string]string
+	if cfg.URICaseInsensitive() {
+		foldedURIs = make(map[string]string, len(items)*len(dests))
+	}
+
+	strip := cfg.Strip()
+
+	// Each item once per destination, in step with publishItems.
+	var destItems []walk.SyncItem
+
+	for _, dest := range dests {
+		expanded := args
+		expanded.Dest = dest
+		destTree := cleanDestTree(expanded.DestPath(), strip)
+
+		// With --relative, SRC is incorporated into the destination path, which
+		// may then escape the destination given in DEST (e.g. SRC of "../foo").
+		// Items must stay within DEST as given on the command-line.
+		destArgs := expanded
+		destArgs.Relative = false
+
+		destURI, err := normalizer.normalize(cleanDestTree(destArgs.DestPath(), strip))
+		if err != nil {
+			logger.F("dest", dest, "error", err).Error("can't determine web URI")
+			return 49
+		}
+
+		// Web URIs are fully determined before anything is uploaded, so that an
+		// item which would be published outside of the destination tree prevents
+		// the entire sync.
+		for _, item := range items {
+			rawURI := webURI(item.SrcPath, args.Src, destTree, srcIsDir)
+			if remapped, ok := remap(remapRules, getRelPath(item.SrcPath, args.Src)); ok {
+				rawURI = path.Join(destTree, remapped)
+			}
+
+			uri, err := normalizer.normalize(rawURI)
+			if err == nil && !withinDest(uri, destURI) {
+				err = fmt.Errorf("refusing to publish to '%s': outside of destination '%s'", uri, destURI)
+			}
+			if err == nil && foldedURIs != nil {
+				folded := strings.ToLower(uri)
+				if other, ok := foldedURIs[folded]; ok && other != uri {
+					err = fmt.Errorf("refusing to publish to '%s': differs only in case from '%s'", uri, other)
+				}
+				foldedURIs[folded] = uri
+			}
+			if err != nil {
+				logger.F("src", item.SrcPath, "error", err).Error("can't determine web URI")
+				return 49
+			}
+			gwItem := gw.ItemInput{WebURI: uri}
+
+			if item.LinkTo != "" {
+				linkSrcDirRelative := path.Dir(getRelPath(item.SrcPath, args.Src))
+				linkSrcDirFull := path.Join(destTree, linkSrcDirRelative)
+
+				// Link targets are normalized in the same way as web URIs, so that
+				// they continue to resolve to the published items.
+				gwItem.LinkTo, err = normalizer.normalize(path.Join(linkSrcDirFull, "/", item.LinkTo))
+				if err != nil {
+					logger.F("src", item.SrcPath, "error", err).Error("can't determine link target")
+					return 49
+				}
+			} else {
+				gwItem.ObjectKey = item.Key
+				if item.Info != nil {
+					gwItem.Visibility = visibility.match(getRelPath(item.SrcPath, args.Src), item.Info.Mode())
+				}
+				gwItem.CacheControl = cacheControl.match(uri)
+
+				// Entries of a tar archive and content from a URL have no
+				// extended attributes of their own.
+				if args.CaptureXattrs && item.Archive == "" && item.URL == "" {
+					gwItem.Metadata, err = readXattrs(item.SrcPath, cfg.Xattrs())
+					if err != nil {
+						logger.F("src", item.SrcPath, "error", err).Error("can't read extended attributes")
+						return 73
+					}
+				}
+
+				rule := rules.match(uri)
+				if rule != nil {
+					gwItem.ContentEncoding = rule.ContentEncoding
+				}
+
+				if rule != nil && rule.ContentType != "" {
+					gwItem.ContentType = rule.ContentType
+				} else {
+					// Try to detect MIME type of file.
+					// mimetype will return "application/octet-stream" type if it
+					// can't make a determination or encounters an error.
+					mtype, err := detectDecodedMIME(ctx, item, gwItem.ContentEncoding)
+					logger.F(
+						"file", item.SrcPath,
+						"MIME type", mtype.String(),
+						"error", err,
+					).Debug("MIME type detection attempted")
+
+					gwItem.ContentType = mtype.String()
+
+					// A last attempt for extensionless files, which clients
+					// can't classify by name either.
+					if cfg.MIMESniff() && mtype.Is("application/octet-stream") && path.Ext(uri) == "" {
+						sniffed, err := sniffMIME(ctx, item, gwItem.ContentEncoding)
+						logger.F(
+							"file", item.SrcPath,
+							"MIME type", sniffed,
+							"error", err,
+						).Debug("MIME type sniffing attempted")
+
+						if err == nil {
+							gwItem.ContentType = sniffed
+						}
+					}
+				}
+			}
+
+			publishItems = append(publishItems, gwItem)
+			destItems = append(destItems, item)
+		}
+	}
+	items = destItems
+
+	aliasItems, err := aliases.items(publishItems, normalizer)
+	if err != nil {
+		logger.F("error", err).Error("can't determine aliases")
+		return 49
+	}
+	for _, aliasItem := range aliasItems {
+		logger.F("alias", aliasItem.WebURI, "target", aliasItem.LinkTo).Debug("Publishing alias")
+
+		// Aliases have no source, but are handled like any other link.
+		items = append(items, walk.SyncItem{SrcPath: aliasItem.WebURI, LinkTo: aliasItem.LinkTo})
+		publishItems = append(publishItems, aliasItem)
+	}
+
+	// The CDN or exodus-gw may refuse overly long URIs, which is better found
+	// out before uploading anything.
+	if err := checkURILengths(publishItems, cfg.MaxURILength()); err != nil {
+		logger.F("maxurilength", cfg.MaxURILength(), "error", err).Error("can't publish items with overly long web URIs")
+		return 49
+	}
+
+	// When the dump is also the base manifest, it's replaced only once its
+	// items are committed, as otherwise the next sync would skip items which
+	// were never published.
+	dumpCommitted := func(code int) int { return code }
+	if args.DumpItems != "" {
+		dumpedItems, dumpedPublishItems := items, publishItems
+		dump := func() int {
+			if err := dumpItems(args.DumpItems, itemsFormat, dumpedItems, dumpedPublishItems, labels); err != nil {
+				logger.F("path", args.DumpItems, "error", err).Error("can't dump items")
+				return 73
+			}
+			logger.F("path", args.DumpItems, "items", len(dumpedPublishItems)).Info("Wrote items to be published")
+			return 0
+		}
+
+		if args.BaseManifest == "" || !sameFile(args.DumpItems, args.BaseManifest) {
+			if code := dump(); code != 0 {
+				return code
+			}
+		} else {
+			dumpCommitted = func(code int) int {
+				if shouldCommit, mode := commitMode(cfg, args); code != 0 || !shouldCommit || mode == "phase1" ||
+					args.DryRun || args.Offline != "" {
+					logger.F("path", args.DumpItems).Info("Not updating base manifest, as items weren't all committed")
+					return code
+				}
+				return dump()
+			}
+		}
+	}
+
+	// Only items which differ from the base manifest are published. This is
+	// done after taking the items to dump, so that the dump can serve as the
+	// next base.
+	if base != nil {
+		delta := base.delta(publishItems)
+		items, publishItems = delta.changed(items, publishItems)
+
+		logger.F("path", args.BaseManifest, "added", len(delta.added), "updated", len(delta.updated),
+			"unchanged", delta.unchanged, "removed", len(delta.removed)).Info("Compared items with base manifest")
+		for _, uri := range delta.removed {
+			logger.F("uri", uri).Debug("Item removed since base manifest")
+		}
+		if len(delta.removed) > 0 {
+			logger.F("removed", len(delta.removed)).Warn("Items removed since the base manifest remain published")
+		}
+	}
+
+	// A joined publish may hold items from elsewhere, so it's committed
+	// regardless.
+	if len(publishItems) == 0 && args.Publish == "" {
+		switch args.OnEmpty {
+		case "error":
+			logger.F("src", args.Src).Error("No items to publish")
+			return 29
+		case "commit-empty":
+			logger.F("src", args.Src).Warn("No items to publish, committing an empty publish")
+		default:
+			logger.F("src", args.Src).Info("No items to publish, not creating a publish")
+			return dumpCommitted(0)
+		}
+	}
+
+	if code := checkPublishLimits(ctx, cfg, args, items); code != 0 {
+		return code
+	}
+
+	if args.NoReplace && cfg.GwNoReplace() {
+		for i := range publishItems {
+			publishItems[i].NoReplace = true
+		}
+	} else if checkReplace {
+		logger.Info("Checking for already published items")
+		if err := checkNotPublished(ctx, cfg.CdnURL(), publishItems, cfg.UploadThreads()); err != nil {
+			logger.F("error", err).Error("refusing to replace published items")
+			return 34
+		}
+	} else if args.NoReplace {
+		logger.Warn("Can't check for already published items in offline mode")
+	}
+
+	// Content of matching items is uploaded again, even if already present.
+	if len(forceUpload) > 0 {
+		keys := forceUpload.keys(publishItems, cfg.BlobKeyPrefix())
+		logger.F("blobs", len(keys)).Info("Forcing upload of matching items")
+		ctx = gw.WithForceUpload(ctx, keys)
+	}
+
+	if len(envs) == 1 {
+		return dumpCommitted(publishRetryingConflicts(ctx, cfg, clients[0], args, items, publishItems, verify, hold, publishIDs, taskIDs, resume))
+	}
+
+	// Content is published to every environment even if publishing to one
+	// of them fails, and the failures are reported together at the end.
+	exitCode := 0
+	failed := []string{}
+	for i, env := range envs {
+		logger.F("env", env.GwEnv()).Info("Publishing to environment")
+
+		if code := publishRetryingConflicts(ctx, env, clients[i], args, items, publishItems, verify, hold, publishIDs, taskIDs, resume); code != 0 {
+			failed = append(failed, env.GwEnv())
+			if exitCode == 0 {
+				exitCode = code
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		logger.F("failed", strings.Join(failed, ","), "envs", len(envs)).Error("Failed to publish to some environments")
+	}
+
+	return dumpCommitted(exitCode)
+}
+
+// withoutKeys returns items and the corresponding publishItems, except for
+// those whose content has one of the given keys, and links to them.
+func withoutKeys(items []walk.SyncItem, publishItems []gw.ItemInput, keys map[string]bool) ([]walk.SyncItem, []gw.ItemInput) {
+	var outItems []walk.SyncItem
+	outPublishItems := []gw.ItemInput{}
+
+	dropped := make(map[string]bool)
+	for i, item := range items {
+		if item.LinkTo == "" && keys[item.Key] {
+			dropped[publishItems[i].WebURI] = true
+		}
+	}
+
+	for i, item := range items {
+		if dropped[publishItems[i].WebURI] || dropped[publishItems[i].LinkTo] {
+			continue
+		}
+		outItems = append(outItems, item)
+		outPublishItems = append(outPublishItems, publishItems[i])
+	}
+
+	return outItems, outPublishItems
+}
+
+// withoutPublished returns items and the corresponding publishItems, except
+// for those already added onto a publish identically, as given by published,
+// along with the number of items left out.
+func withoutPublished(items []walk.SyncItem, publishItems []gw.ItemInput, published []gw.ItemInput) ([]walk.SyncItem, []gw.ItemInput, int) {
+	existing := make(map[string]bool)
+	for _, item := range published {
+		existing[publishedKey(item)] = true
+	}
+
+	var outItems []walk.SyncItem
+	outPublishItems := []gw.ItemInput{}
+
+	for i, item := range publishItems {
+		if existing[publishedKey(item)] {
+			continue
+		}
+		outItems = append(outItems, items[i])
+		outPublishItems = append(outPublishItems, publishItems[i])
+	}
+
+	return outItems, outPublishItems, len(publishItems) - len(outPublishItems)
+}
+
+// publishedKey returns a key identifying an item as added onto a publish, for
+// comparing items while ItemInput itself can't be compared.
+func publishedKey(item gw.ItemInput) string {
+	// exodus-gw doesn't return whether an item was added with no_replace,
+	// which matters only when committing anyway.
+	item.NoReplace = false
+
+	return string(jsonutil.MustMarshal(item))
+}
+
+// publishToEnv publishes items to the environment of cfg via the given client,
+// uploading their content as needed, and returns the exit code.
+func publishToEnv(
+	ctx context.Context,
+	cfg conf.Config,
+	gwClient gw.Client,
+	args args.Config,
+	items []walk.SyncItem,
+	publishItems []gw.ItemInput,
+	verify bool,
+	hold *commitHold,
+	publishIDs *idFile,
+	taskIDs *idFile,
+	resume *continueManifest,
+) int {
+	logger := log.FromContext(ctx)
+	events := progress.FromContext(ctx)
+
+	// Publishing the same items as the last publish committed to DEST would
+	// change nothing. A joined publish may hold other items, so is always
+	// committed, and with --exodus-force-upload the point is to upload the
+	// content again. Nothing is really committed in dry-run or offline modes,
+	// so there's nothing to compare with or record.
+	var state *publishState
+	if !args.DryRun && args.Offline == "" {
+		state = newPublishState(cfg.PublishState())
+	}
+	stateKey := publishStateKey(cfg, args)
+	itemsFingerprint := fingerprint(publishItems)
+	shouldCommit, mode := commitMode(cfg, args)
+	if last, ok := state.lookup(ctx, stateKey); ok && last.Fingerprint == itemsFingerprint && last.Mode == mode &&
+		shouldCommit && args.Publish == "" && len(gw.ForceUploadFromContext(ctx)) == 0 {
+		logger.F("env", cfg.GwEnv(), "publish", last.Publish, "items", len(publishItems)).Info(
+			"Items are identical to the last committed publish, not publishing")
+		return 0
+	}
+
+	var (
+		publish gw.Publish
+		err     error
+	)
 
 	logger.F("items", len(items)).Info("Preparing to publish items")
 
-	if args.Publish == "" {
+	// With --exodus-continue-from-manifest, a publish created by an earlier
+	// sync and never committed is continued, without the items recorded as
+	// added onto it. It may have since expired, or been committed by a sync
+	// which stopped before recording so, in which case everything is added
+	// onto a new publish.
+	recorded := resume.env(cfg.GwEnv())
+	if args.Publish == "" && recorded.publish != "" {
+		publish, err = gwClient.GetPublish(ctx, recorded.publish)
+		if err == nil && publish.State() != "" && !strings.EqualFold(publish.State(), "PENDING") {
+			err = fmt.Errorf("publish is %s", publish.State())
+		}
+		if err != nil {
+			logger.F("env", cfg.GwEnv(), "publish", recorded.publish, "error", err).Warn(
+				"Can't continue publish from manifest, creating a new publish")
+			publish = nil
+			recorded = continueEnv{}
+		} else {
+			var skipped int
+			items, publishItems, skipped = withoutPublished(items, publishItems, recorded.added)
+			logger.F("env", cfg.GwEnv(), "publish", publish.ID(), "skipped", skipped, "items", len(publishItems)).Info(
+				"Continuing publish from manifest")
+		}
+	}
+
+	if args.Publish == "" && publish == nil {
 		// No publish provided, then create a new one.
 		publish, err = gwClient.NewPublish(ctx)
 		if err != nil {
-			logger.F("error", err).Error("can't create publish")
+			logger.F("env", cfg.GwEnv(), "error", err).Error("can't create publish")
 			return 62
 		}
-		logger.F("publish", publish.ID()).Info("Created publish")
-	} else {
+		logger.F("env", cfg.GwEnv(), "publish", publish.ID()).Info("Created publish")
+		events.Emit(progress.Event{
+			Type: progress.TypePublish, Status: "created", Env: cfg.GwEnv(), Publish: publish.ID(),
+			Labels: gw.LabelsFromContext(ctx),
+		})
+
+		if publishIDs != nil {
+			if err := publishIDs.add(publish.ID()); err != nil {
+				logger.F("publish", publish.ID(), "error", err).Error("can't write publish ID")
+				return 33
+			}
+		}
+
+		if err := resume.record(continueRecord{Env: cfg.GwEnv(), Publish: publish.ID()}); err != nil {
+			logger.F("publish", publish.ID(), "error", err).Error("can't write continue manifest")
+			return 73
+		}
+	} else if args.Publish != "" {
 		publish, err = gwClient.GetPublish(ctx, args.Publish)
 		if err != nil {
 			logger.F("error", err).Error("can't join publish")
 			return 67
 		}
 		logger.F("publish", publish.ID()).Info("Joining publish")
+
+		// The publish may already hold some of the items, e.g. from an
+		// earlier attempt which failed part way through; those needn't be
+		// uploaded or added again.
+		published, err := publish.Items(ctx)
+		if err != nil {
+			logger.F("publish", publish.ID(), "error", err).Error("can't get items of publish")
+			return 67
+		}
+		var skipped int
+		items, publishItems, skipped = withoutPublished(items, publishItems, published)
+		if skipped > 0 {
+			logger.F("publish", publish.ID(), "skipped", skipped, "items", len(publishItems)).Info(
+				"Skipping items already on publish")
+		}
+	}
+
+	if args.Publish != "" || recorded.publish != "" {
+		events.Emit(progress.Event{
+			Type: progress.TypePublish, Status: "joined", Env: cfg.GwEnv(), Publish: publish.ID(),
+		})
 	}
 
+	publish = resume.publish(cfg.GwEnv(), publish)
+
 	logger.F("items", len(items)).Info("Preparing to upload items")
 
+	events.Emit(progress.Event{
+		Type: progress.TypePhase, Phase: progress.PhaseUpload, Env: cfg.GwEnv(), Publish: publish.ID(),
+	})
+
+	// With --exodus-pipeline, items are added and committed while uploading.
+	var pipe *pipeline
+	uploadCtx := ctx
+	if args.Pipeline {
+		if shouldCommit {
+			var cancel func()
+			uploadCtx, cancel = context.WithCancel(ctx)
+			defer cancel()
+
+			// Committing incrementally would make content visible before
+			// the final commit, which these arguments are meant to prevent.
+			incremental := !args.DelayUpdates && args.HoldCommit == ""
+			if !incremental {
+				logger.Info("Not committing incrementally, content will be committed at the end")
+			}
+
+			pipe = startPipeline(uploadCtx, cancel, publish, items, publishItems, incremental)
+		} else {
+			logger.Warn("Ignoring --exodus-pipeline as the publish won't be committed")
+		}
+	}
+
+	// With --exodus-keep-going, items which can't be uploaded are reported
+	// once the others are done.
+	if args.KeepGoing {
+		uploadCtx = gw.WithKeepGoing(uploadCtx)
+		if args.MaxErrors > 0 {
+			uploadCtx = gw.WithMaxErrors(uploadCtx, args.MaxErrors)
+		}
+	}
+
+	// With --exodus-fix-content-types, content must already be present, as
+	// the point is to only update the content types of published items.
+	if args.FixContentTypes {
+		uploadCtx = gw.WithNoUpload(uploadCtx)
+	}
+
+	// Blobs recorded as present needn't be checked for again.
+	if len(recorded.blobs) > 0 {
+		uploadCtx = gw.WithPresentBlobs(uploadCtx, recorded.blobs)
+	}
+	recordBlob := func(item walk.SyncItem) error {
+		return resume.record(continueRecord{Env: cfg.GwEnv(), Blob: cfg.BlobKeyPrefix() + item.Key})
+	}
+
 	uploadCount := 0
 	existingCount := 0
 	duplicateCount := 0
+	var uploadedItems []walk.SyncItem
+
+	emitUpload := func(item walk.SyncItem, status string) {
+		var size int64
+		if item.Info != nil {
+			size = item.Info.Size()
+		}
+		events.Emit(progress.Event{
+			Type:   progress.TypeUpload,
+			Env:    cfg.GwEnv(),
+			Path:   item.SrcPath,
+			Key:    item.Key,
+			Size:   size,
+			Status: status,
+			Done:   uploadCount + existingCount + duplicateCount,
+			Total:  len(items),
+		})
+	}
 
-	err = gwClient.EnsureUploaded(ctx, items,
+	err = gwClient.EnsureUploaded(uploadCtx, items,
 		func(uploadedItem walk.SyncItem) error {
 			uploadCount++
-			return nil
+			if args.DryRun {
+				uploadedItems = append(uploadedItems, uploadedItem)
+			}
+			emitUpload(uploadedItem, "uploaded")
+			if pipe != nil {
+				pipe.onUploaded(uploadedItem)
+			}
+			return recordBlob(uploadedItem)
 		},
 		func(existingItem walk.SyncItem) error {
 			existingCount++
-			return nil
+			emitUpload(existingItem, "existing")
+			if pipe != nil {
+				pipe.onExisting(existingItem)
+			}
+			return recordBlob(existingItem)
 		},
 		func(duplicateItem walk.SyncItem) error {
 			duplicateCount++
+			emitUpload(duplicateItem, "duplicate")
+			if pipe != nil {
+				pipe.onDuplicate(duplicateItem)
+			}
 			return nil
 		},
 	)
 
+	var uploadErrs *gw.UploadErrors
+	if errors.As(err, &uploadErrs) {
+		for _, failed := range uploadErrs.Failed {
+			logger.F("src", failed.Item.SrcPath, "key", failed.Item.Key, "error", failed.Err).Error("can't upload file")
+			events.Emit(progress.Event{
+				Type: progress.TypeUpload, Env: cfg.GwEnv(), Path: failed.Item.SrcPath, Key: failed.Item.Key,
+				Status: "failed", Error: failed.Err.Error(), Total: len(items),
+			})
+		}
+		logger.F("env", cfg.GwEnv(), "failed", len(uploadErrs.Failed), "items", len(items)).Error("Some files could not be uploaded")
+
+		if uploadErrs.Aborted {
+			// The other files weren't all handled, so there's nothing
+			// complete to publish.
+			logger.F("env", cfg.GwEnv(), "maxErrors", args.MaxErrors).Error(
+				"Stopped uploading, reached --exodus-max-errors")
+		} else if args.OnFailedItems != "fail" {
+			// The publish goes ahead without the failed files, and any
+			// others sharing their content.
+			items, publishItems = withoutKeys(items, publishItems, uploadErrs.Keys())
+			if pipe != nil {
+				pipe.drop(uploadErrs.Keys())
+			}
+			err = nil
+		}
+	}
+
+	if pipe != nil {
+		if pipeErr := pipe.finish(err != nil); pipeErr != nil {
+			logger.F("error", pipeErr).Error("can't add items to publish")
+			return 51
+		}
+	}
+
 	if err != nil {
 		logger.F("error", err).Error("can't upload files")
 		return 25
 	}
 
-	logger.F("uploaded", uploadCount, "existing", existingCount, "duplicate", duplicateCount).Info("Completed uploads")
+	logger.F("env", cfg.GwEnv(), "uploaded", uploadCount, "existing", existingCount, "duplicate", duplicateCount).Info("Completed uploads")
 
-	publishItems := []gw.ItemInput{}
+	if pipe == nil {
+		events.Emit(progress.Event{
+			Type: progress.TypePhase, Phase: progress.PhasePublish, Env: cfg.GwEnv(), Publish: publish.ID(),
+		})
 
-	strip := cfg.Strip()
-	destTree := cleanDestTree(args.DestPath(), strip)
-
-	for _, item := range items {
-		gwItem := gw.ItemInput{WebURI: webURI(item.SrcPath, args.Src, destTree, srcIsDir)}
+		err = publish.AddItems(ctx, publishItems)
+		if err != nil {
+			logger.F("error", err).Error("can't add items to publish")
+			return 51
+		}
+	}
 
-		if item.LinkTo != "" {
-			linkSrcDirRelative := path.Dir(getRelPath(item.SrcPath, args.Src))
-			linkSrcDirFull := path.Join(destTree, linkSrcDirRelative)
-			gwItem.LinkTo = path.Join(linkSrcDirFull, "/", item.LinkTo)
-		} else {
-			// Try to detect MIME type of file.
-			// mimetype will return "application/octet-stream" type if it
-			// can't make a determination or encounters an error.
-			mtype, err := mimetype.DetectFile(item.SrcPath)
-			logger.F(
-				"file", item.SrcPath,
-				"MIME type", mtype.String(),
-				"error", err,
-			).Debug("MIME type detection attempted")
+	logger.F("publish", publish.ID(), "items", len(publishItems)).Info("Added publish items")
 
-			gwItem.ObjectKey = item.Key
-			gwItem.ContentType = mtype.String()
+	if shouldCommit && hold != nil && !args.DryRun {
+		if !hold.done {
+			events.Emit(progress.Event{
+				Type: progress.TypePhase, Phase: progress.PhaseHold, Env: cfg.GwEnv(), Publish: publish.ID(),
+			})
+			logger.F("publish", publish.ID(), "path", args.HoldCommit).Info("Holding commit until signalled")
 		}
 
-		publishItems = append(publishItems, gwItem)
+		if err := hold.wait(ctx); err != nil {
+			logger.F("publish", publish.ID(), "error", err).Error("commit hold was not released, not committing publish")
+			return 45
+		}
 	}
 
-	err = publish.AddItems(ctx, publishItems)
-	if err != nil {
-		logger.F("error", err).Error("can't add items to publish")
-		return 51
+	// With --exodus-skip-unchanged, a publish which would change nothing is
+	// left uncommitted. --exodus-fix-content-types always commits, as it's
+	// meant to correct what exodus-gw records, whatever the CDN serves.
+	if shouldCommit && args.SkipUnchanged && !args.FixContentTypes {
+		if args.Offline != "" {
+			logger.Warn("Can't check for unchanged items in offline mode")
+		} else if isUnchanged(ctx, cfg.CdnURL(), items, publishItems, cfg.UploadThreads()) {
+			logger.F("env", cfg.GwEnv(), "publish", publish.ID(), "items", len(publishItems)).Info(
+				"Nothing to commit, items are identical to those published")
+			events.Emit(progress.Event{
+				Type: progress.TypeCommit, Status: "skipped", Env: cfg.GwEnv(), Publish: publish.ID(),
+			})
+			shouldCommit = false
+			verify = false
+		}
 	}
 
-	logger.F("publish", publish.ID(), "items", len(publishItems)).Info("Added publish items")
-
-	shouldCommit, mode := commitMode(cfg, args)
 	if shouldCommit {
+		events.Emit(progress.Event{
+			Type: progress.TypePhase, Phase: progress.PhaseCommit, Env: cfg.GwEnv(), Publish: publish.ID(),
+		})
+		events.Emit(progress.Event{
+			Type: progress.TypeCommit, Status: "started", Env: cfg.GwEnv(), Publish: publish.ID(),
+		})
+
 		logger.F("publish", publish.ID(), "mode", mode).Info("Preparing to commit publish")
 		err = publish.Commit(ctx, mode)
+
+		// The task is recorded even if it failed, for looking into why. The
+		// outcome of the commit matters more than the record of it, so a
+		// failure to write it is only warned of.
+		if taskIDs != nil && publish.TaskID() != "" {
+			if err := taskIDs.add(publish.TaskID()); err != nil {
+				logger.F("task", publish.TaskID(), "error", err).Warn("can't write task ID")
+			}
+		}
+
 		if err != nil {
+			events.Emit(progress.Event{
+				Type: progress.TypeCommit, Status: "failed", Env: cfg.GwEnv(), Publish: publish.ID(), Error: err.Error(),
+			})
+			if errors.Is(err, gw.ErrConflict) {
+				// A conflicting publish can't be continued; a retry or a
+				// later sync needs a new one.
+				if err := resume.record(continueRecord{Env: cfg.GwEnv(), Publish: publish.ID(), Finished: true}); err != nil {
+					logger.F("publish", publish.ID(), "error", err).Error("can't write continue manifest")
+				}
+				logger.F("publish", publish.ID(), "error", err).Error("can't commit publish, it conflicts with another publish")
+				return 75
+			}
 			logger.F("error", err).Error("can't commit publish")
 			return 71
 		}
+
+		if err := resume.record(continueRecord{Env: cfg.GwEnv(), Publish: publish.ID(), Finished: true}); err != nil {
+			logger.F("publish", publish.ID(), "error", err).Error("can't write continue manifest")
+			return 73
+		}
+
+		// The publish is already committed, so failing to audit it doesn't
+		// fail the sync, which a retry would only publish again.
+		if auditLog := cfg.AuditLog(); auditLog != "" && !args.DryRun && args.Offline == "" {
+			var size int64
+			for _, item := range items {
+				if item.LinkTo == "" && item.Info != nil {
+					size += item.Info.Size()
+				}
+			}
+			record := auditRecord{
+				Env:     cfg.GwEnv(),
+				Dest:    args.DestPath(),
+				Publish: publish.ID(),
+				Task:    publish.TaskID(),
+				Items:   len(publishItems),
+				Bytes:   size,
+				Labels:  gw.LabelsFromContext(ctx),
+			}
+			if err := appendAudit(auditLog, record); err != nil {
+				logger.F("path", auditLog, "publish", publish.ID(), "error", err).Error("can't write audit log")
+			}
+		}
+
+		events.Emit(progress.Event{
+			Type: progress.TypeCommit, Status: "succeeded", Env: cfg.GwEnv(), Publish: publish.ID(),
+		})
+
+		if verify {
+			events.Emit(progress.Event{
+				Type: progress.TypePhase, Phase: progress.PhaseVerify, Env: cfg.GwEnv(), Publish: publish.ID(),
+			})
+
+			sample := verifySample(items, publishItems, args.VerifyAfterCommit)
+			logger.F("items", len(sample)).Info("Verifying published items")
+
+			if err := verifyPublished(ctx, cfg.CdnURL(), sample); err != nil {
+				logger.F("error", err).Error("can't verify published items")
+				return 72
+			}
+		}
+	} else if verify {
+		logger.Warn("Skipping verification as publish was not committed")
+	}
+
+	if uploadErrs != nil {
+		logger.F("env", cfg.GwEnv(), "publish", publish.ID(), "failed", len(uploadErrs.Failed)).Error(
+			"Completed, but some files could not be uploaded and were not published")
+		return 27
+	}
+
+	if shouldCommit && args.Publish == "" {
+		if err := state.record(ctx, stateKey, itemsFingerprint, publish.ID(), mode); err != nil {
+			logger.F("path", cfg.PublishState(), "error", err).Warn("Can't write publish state")
+		}
 	}
 
 	msg := "Completed successfully!"
 	if args.DryRun {
 		msg = "Completed successfully (in dry-run mode - no changes written)"
+
+		estimate := estimateCost(uploadedItems, len(publishItems), cfg.GwBatchSize())
+		logger.F(
+			"env", cfg.GwEnv(),
+			"uploadBytes", estimate.uploadBytes,
+			"uploads", estimate.uploads,
+			"puts", estimate.puts,
+			"multipartRequests", estimate.multipartRequests,
+			"itemBatches", estimate.itemBatches,
+			"commit", shouldCommit,
+		).Info("Estimated cost of sync")
 	}
-	logger.Info(msg)
+	logger.F("env", cfg.GwEnv(), "publish", publish.ID()).Info(msg)
 
 	return 0
-
 }
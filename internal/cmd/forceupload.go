@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+// forceUploadPatterns match the web URIs of items whose content is uploaded
+// even if already present, as given by --exodus-force-upload.
+type forceUploadPatterns []string
+
+func newForceUploadPatterns(patterns []string) (forceUploadPatterns, error) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+		}
+	}
+	return forceUploadPatterns(patterns), nil
+}
+
+// match returns true if any pattern matches the web URI uri. As with
+// --exclude, a pattern without a "/" is matched against the item's name, and
+// otherwise against the whole of uri.
+func (p forceUploadPatterns) match(uri string) bool {
+	for _, pattern := range p {
+		var matched bool
+		if strings.Contains(pattern, "/") {
+			matched, _ = path.Match(pattern, uri)
+		} else {
+			matched, _ = path.Match(pattern, path.Base(uri))
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// keys returns the keys of the blobs, beneath prefix, of those publishItems
+// whose web URIs match. Links have no content of their own, so are never
+// matched.
+func (p forceUploadPatterns) keys(publishItems []gw.ItemInput, prefix string) map[string]bool {
+	out := make(map[string]bool)
+	for _, item := range publishItems {
+		if item.ObjectKey != "" && p.match(item.WebURI) {
+			out[prefix+item.ObjectKey] = true
+		}
+	}
+	return out
+}
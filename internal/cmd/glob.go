@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// expandSource expands the wildcards and braces in a SRC given with
+// --exodus-glob, returning the directory preceding the first pattern in SRC,
+// and the path of every file matching SRC, recursing into directories.
+//
+// Every alternative produced by brace expansion must match something, so
+// that a mistyped pattern can't silently omit content from a publish.
+// A backslash escapes the character following it, making it literal.
+func expandSource(src string) (string, []string, error) {
+	base := globBase(src)
+
+	seen := make(map[string]bool)
+	var paths []string
+
+	for _, pattern := range expandBraces(src) {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return "", nil, fmt.Errorf("no files match '%s'", pattern)
+		}
+
+		for _, match := range matches {
+			err := filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.IsDir() && !seen[path] {
+					seen[path] = true
+					paths = append(paths, path)
+				}
+				return nil
+			})
+			if err != nil {
+				return "", nil, err
+			}
+		}
+	}
+
+	// Matching only empty directories would otherwise publish everything
+	// beneath the base, as no paths means no restriction.
+	if len(paths) == 0 {
+		return "", nil, fmt.Errorf("no files match '%s'", src)
+	}
+
+	sort.Strings(paths)
+
+	return base, paths, nil
+}
+
+// globBase returns the leading directories of pattern up to the first
+// containing a wildcard or brace, or "." if the first does.
+func globBase(pattern string) string {
+	segments := strings.Split(pattern, "/")
+
+	i := 0
+	for ; i < len(segments)-1; i++ {
+		if hasMeta(segments[i]) {
+			break
+		}
+	}
+
+	base := strings.Join(segments[:i], "/")
+	if base == "" {
+		if strings.HasPrefix(pattern, "/") {
+			return "/"
+		}
+		return "."
+	}
+	return unescape(base)
+}
+
+func hasMeta(segment string) bool {
+	for i := 0; i < len(segment); i++ {
+		switch segment[i] {
+		case '\\':
+			i++
+		case '*', '?', '[', '{':
+			return true
+		}
+	}
+	return false
+}
+
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// expandBraces expands "{a,b}" into the alternatives "a" and "b", in the
+// same way as a shell: braces may be nested, and braces without a comma
+// between them are literal. Escapes are retained for use in glob patterns.
+func expandBraces(pattern string) []string {
+	start, end, commas := findBraces(pattern)
+	if start < 0 {
+		return []string{pattern}
+	}
+
+	var out []string
+	prefix, suffix := pattern[:start], pattern[end+1:]
+
+	from := start + 1
+	for _, to := range append(commas, end) {
+		out = append(out, expandBraces(prefix+pattern[from:to]+suffix)...)
+		from = to + 1
+	}
+
+	return out
+}
+
+// findBraces returns the positions of the first pair of braces in pattern
+// to be expanded, and of the commas separating the alternatives within them,
+// or a start of -1 if there are none.
+func findBraces(pattern string) (int, int, []int) {
+	for start := 0; start < len(pattern); start++ {
+		if pattern[start] == '\\' {
+			start++
+			continue
+		}
+		if pattern[start] != '{' {
+			continue
+		}
+
+		depth := 0
+		var commas []int
+	scan:
+		for i := start + 1; i < len(pattern); i++ {
+			switch pattern[i] {
+			case '\\':
+				i++
+			case '{':
+				depth++
+			case ',':
+				if depth == 0 {
+					commas = append(commas, i)
+				}
+			case '}':
+				if depth > 0 {
+					depth--
+					continue
+				}
+				if len(commas) > 0 {
+					return start, i, commas
+				}
+				break scan
+			}
+		}
+	}
+
+	return -1, -1, nil
+}
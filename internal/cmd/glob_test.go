@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandBraces(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected []string
+	}{
+		{"src/*.rpm", []string{"src/*.rpm"}},
+		{"src/{a,b}/*.rpm", []string{"src/a/*.rpm", "src/b/*.rpm"}},
+		{"{a,b}{1,2}", []string{"a1", "a2", "b1", "b2"}},
+		{"x{a,{b,c}d}", []string{"xa", "xbd", "xcd"}},
+		{"{a,}b", []string{"ab", "b"}},
+
+		// Braces without a comma, unbalanced or escaped are literal.
+		{"src/{a}", []string{"src/{a}"}},
+		{"src/{a,b", []string{"src/{a,b"}},
+		{"src/\\{a,b}", []string{"src/\\{a,b}"}},
+		{"src/{a\\,b,c}", []string{"src/a\\,b", "src/c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got := expandBraces(tt.pattern)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expandBraces(%q) = %q, expected %q", tt.pattern, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGlobBase(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected string
+	}{
+		{"src/{a,b}/*.rpm", "src"},
+		{"src/a/*.rpm", "src/a"},
+		{"src/a/file.rpm", "src/a"},
+		{"*.rpm", "."},
+		{"/*.rpm", "/"},
+		{"/data/x?/*", "/data"},
+		{"src/\\*/*.rpm", "src/*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if got := globBase(tt.pattern); got != tt.expected {
+				t.Errorf("globBase(%q) = %q, expected %q", tt.pattern, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExpandSource(t *testing.T) {
+	RestoreWd(t)
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{
+		"src/a/one.rpm", "src/a/one.txt", "src/b/two.rpm", "src/b/sub/three.rpm",
+		"src/c/four.rpm", "src/{x}/five.rpm",
+	} {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir("src/nothing", 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		src      string
+		base     string
+		expected []string
+		err      string
+	}{
+		{"glob and braces", "src/{a,b}/*.rpm", "src",
+			[]string{"src/a/one.rpm", "src/b/two.rpm"}, ""},
+
+		// Directories are included recursively.
+		{"directories", "src/b/*", "src/b",
+			[]string{"src/b/sub/three.rpm", "src/b/two.rpm"}, ""},
+
+		// Files matched more than once are included once.
+		{"overlapping", "src/{a/*.rpm,*/one.rpm}", "src",
+			[]string{"src/a/one.rpm"}, ""},
+
+		{"escaped", "src/\\{x\\}/*.rpm", "src/{x}",
+			[]string{"src/{x}/five.rpm"}, ""},
+
+		// Every alternative must match.
+		{"no match", "src/{a,d}/*.rpm", "", nil, "no files match 'src/d/*.rpm'"},
+		{"only empty directories", "src/nothing", "", nil, "no files match 'src/nothing'"},
+		{"invalid", "src/[a", "", nil, "invalid pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, paths, err := expandSource(tt.src)
+
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("did not get expected error, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if base != tt.base {
+				t.Errorf("got base %q, expected %q", base, tt.base)
+			}
+			if !reflect.DeepEqual(paths, tt.expected) {
+				t.Errorf("got paths %q, expected %q", paths, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// How often a lock file is checked for removal.
+var holdPollInterval = time.Second
+
+// commitHold holds back the commit of a publish until a signal is received
+// via a path given by --exodus-hold-commit, so that content can be made live
+// at a time chosen by another program.
+//
+// If the path is a named pipe, the signal is the line "commit" written to it;
+// any other line aborts the commit. Otherwise, the path is a lock file, and
+// the signal is its removal.
+//
+// The signal is awaited only once, so when publishing to several environments,
+// only the first commit is held back.
+type commitHold struct {
+	path string
+	pipe bool
+
+	done bool
+	err  error
+}
+
+// newCommitHold returns a hold on the given path, or nil if path is empty.
+//
+// The path must already exist, so that a mistyped lock file can't result
+// in committing without waiting.
+func newCommitHold(path string) (*commitHold, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &commitHold{path: path, pipe: info.Mode()&os.ModeNamedPipe != 0}, nil
+}
+
+// wait blocks until the signal to commit is received, returning an error if
+// the commit should not proceed. After the first call, it returns immediately.
+func (h *commitHold) wait(ctx context.Context) error {
+	if h == nil {
+		return nil
+	}
+
+	if !h.done {
+		if h.pipe {
+			h.err = h.readPipe(ctx)
+		} else {
+			h.err = h.pollLock(ctx)
+		}
+		h.done = true
+	}
+
+	return h.err
+}
+
+func (h *commitHold) readPipe(ctx context.Context) error {
+	// Opening for write as well as read means the open doesn't block until
+	// a writer appears, and a writer going away without sending anything
+	// doesn't end the hold.
+	file, err := os.OpenFile(h.path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	lines := make(chan string, 1)
+	go func() {
+		line, err := bufio.NewReader(file).ReadString('\n')
+		if err != nil {
+			close(lines)
+			return
+		}
+		lines <- strings.TrimSpace(line)
+	}()
+
+	select {
+	case line, ok := <-lines:
+		if !ok {
+			return fmt.Errorf("can't read from '%s'", h.path)
+		}
+		if line != "commit" {
+			return fmt.Errorf("commit aborted via '%s': received '%s'", h.path, line)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (h *commitHold) pollLock(ctx context.Context) error {
+	ticker := time.NewTicker(holdPollInterval)
+	defer ticker.Stop()
+
+	for {
+		_, err := os.Stat(h.path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func shortHoldPoll(t *testing.T) {
+	old := holdPollInterval
+	holdPollInterval = 5 * time.Millisecond
+	t.Cleanup(func() { holdPollInterval = old })
+}
+
+func TestCommitHoldNone(t *testing.T) {
+	hold, err := newCommitHold("")
+	if hold != nil || err != nil {
+		t.Fatalf("unexpected hold %v, err = %v", hold, err)
+	}
+
+	// A nil hold never waits.
+	if err := hold.wait(context.Background()); err != nil {
+		t.Errorf("wait failed, err = %v", err)
+	}
+}
+
+func TestCommitHoldMissing(t *testing.T) {
+	_, err := newCommitHold(filepath.Join(t.TempDir(), "no-such-lock"))
+	if !os.IsNotExist(err) {
+		t.Errorf("did not get expected error, got %v", err)
+	}
+}
+
+func TestCommitHoldLockFile(t *testing.T) {
+	shortHoldPoll(t)
+
+	lock := filepath.Join(t.TempDir(), "go-live.lock")
+	if err := os.WriteFile(lock, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	hold, err := newCommitHold(lock)
+	if err != nil {
+		t.Fatalf("can't create hold, err = %v", err)
+	}
+
+	released := make(chan error, 1)
+	go func() {
+		released <- hold.wait(context.Background())
+	}()
+
+	// It's held while the lock file exists...
+	select {
+	case err := <-released:
+		t.Fatalf("hold released while locked, err = %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// ...and released once it's removed.
+	if err := os.Remove(lock); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-released; err != nil {
+		t.Errorf("wait failed, err = %v", err)
+	}
+
+	// Later waits don't wait at all, even if the file returns.
+	if err := os.WriteFile(lock, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := hold.wait(context.Background()); err != nil {
+		t.Errorf("second wait failed, err = %v", err)
+	}
+}
+
+func TestCommitHoldPipe(t *testing.T) {
+	tests := []struct {
+		name  string
+		write string
+		err   string
+	}{
+		{"commit", "commit\n", ""},
+		{"abort", "abort\n", "received 'abort'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fifo := filepath.Join(t.TempDir(), "go-live")
+			if err := syscall.Mkfifo(fifo, 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			hold, err := newCommitHold(fifo)
+			if err != nil {
+				t.Fatalf("can't create hold, err = %v", err)
+			}
+
+			go func() {
+				// Opening for write blocks until the hold opens for read.
+				f, err := os.OpenFile(fifo, os.O_WRONLY, 0)
+				if err != nil {
+					return
+				}
+				defer f.Close()
+				f.WriteString(tt.write)
+			}()
+
+			err = hold.wait(context.Background())
+			if tt.err == "" && err != nil {
+				t.Errorf("wait failed, err = %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("did not get expected error, got %v", err)
+			}
+
+			// The outcome is remembered.
+			if err2 := hold.wait(context.Background()); err2 != err {
+				t.Errorf("second wait returned %v, first returned %v", err2, err)
+			}
+		})
+	}
+}
+
+func TestCommitHoldCancelled(t *testing.T) {
+	fifo := filepath.Join(t.TempDir(), "go-live")
+	if err := syscall.Mkfifo(fifo, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	hold, err := newCommitHold(fifo)
+	if err != nil {
+		t.Fatalf("can't create hold, err = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := hold.wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("did not get expected error, got %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/release-engineering/exodus-rsync/internal/atomicfile"
+)
+
+// idFile records IDs as soon as they're known, as requested by
+// --exodus-write-publish-id for the ID of each publish, so that a caller can
+// clean up the publish even if exodus-rsync doesn't get as far as committing
+// it, and by --exodus-write-task-id for the ID of each commit task.
+//
+// A publish is created and committed per environment, and per retry of a
+// conflicting publish, so the file holds one ID per line.
+type idFile struct {
+	path string
+	ids  []string
+}
+
+// newIDFile returns an idFile writing to path, or nil if path is empty.
+func newIDFile(path string) *idFile {
+	if path == "" {
+		return nil
+	}
+	return &idFile{path: path}
+}
+
+// add records a newly known ID.
+//
+// The file is written via a temporary file, so that a reader never sees it
+// partially written, even if exodus-rsync is killed.
+func (f *idFile) add(id string) error {
+	f.ids = append(f.ids, id)
+
+	return atomicfile.WriteFile(f.path, []byte(strings.Join(f.ids, "\n")+"\n"))
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// newLabels returns the labels of the created publish given by
+// --exodus-label, each of the form KEY=VALUE, or nil if there are none.
+func newLabels(labels []string) (map[string]string, error) {
+	if len(labels) == 0 {
+		return nil, nil
+	}
+
+	out := make(map[string]string, len(labels))
+	for _, label := range labels {
+		key, value, ok := strings.Cut(label, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid label '%s', must be KEY=VALUE", label)
+		}
+		if _, dup := out[key]; dup {
+			return nil, fmt.Errorf("label '%s' given more than once", key)
+		}
+		out[key] = value
+	}
+	return out, nil
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+// listPublishes writes the publishes in the exodus-gw environment of cfg to
+// stdout for --exodus-list-publishes, and returns the exit code.
+func listPublishes(ctx context.Context, cfg conf.Config, args args.Config) int {
+	logger := log.FromContext(ctx)
+
+	gwClient, err := ext.gw.NewClient(ctx, cfg)
+	if err != nil {
+		logger.F("error", err).Error("can't initialize exodus-gw client")
+		return 101
+	}
+
+	// exodus-gw uses upper case states, but they're accepted in any case.
+	filter := gw.PublishFilter{}
+	for _, state := range args.ListState {
+		filter.States = append(filter.States, strings.ToUpper(state))
+	}
+
+	publishes, err := gwClient.ListPublishes(ctx, filter)
+	if err != nil {
+		logger.F("env", cfg.GwEnv(), "error", err).Error("can't list publishes")
+		return 68
+	}
+
+	if args.ListFormat == "json" {
+		err = writeJSON(stdout, publishes)
+	} else {
+		err = writePublishesTable(stdout, publishes)
+	}
+	if err != nil {
+		logger.F("error", err).Error("can't write list of publishes")
+		return 68
+	}
+
+	return 0
+}
+
+func writePublishesTable(w io.Writer, publishes []gw.PublishInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "ID\tENV\tSTATE\tUPDATED")
+	for _, p := range publishes {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", p.ID, p.Env, p.State, p.Updated)
+	}
+
+	return tw.Flush()
+}
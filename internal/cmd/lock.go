@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+// How often a lock held by another sync is tried again, while waiting.
+var lockPollInterval = time.Second
+
+// How long a lock service holds a lock without its lease being renewed, so
+// that a sync which was killed doesn't hold its locks forever. Leases are
+// renewed at a third of this.
+var lockLease = time.Minute
+
+// How long to wait for each response from a lock service.
+var lockRequestTimeout = 30 * time.Second
+
+var errFlockUnsupported = errors.New("lock files are only supported on Linux")
+
+// publishLock is a lock on a path in an exodus-gw environment, held while
+// syncing so that syncs to overlapping destinations don't race, as
+// configured by 'publishlock'.
+//
+// A sync holds an exclusive lock on its destination and a shared lock on
+// each directory above it, so that it excludes syncs to the same path, to
+// any path beneath it or to any path above it, but not to its siblings.
+type publishLock interface {
+	// tryAcquire acquires the lock, returning false if it's held by
+	// another sync.
+	tryAcquire(ctx context.Context) (bool, error)
+
+	release(ctx context.Context) error
+}
+
+func newPublishLock(location string, key string, shared bool) publishLock {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return &httpLock{url: strings.TrimSuffix(location, "/") + "/" + url.PathEscape(key), shared: shared}
+	}
+	return &fileLock{path: filepath.Join(location, url.PathEscape(key)+".lock"), shared: shared}
+}
+
+// lockPaths returns the paths locked by a sync to dest: each directory above
+// it, from the root down, then dest itself.
+func lockPaths(dest string) []string {
+	dest = path.Clean("/" + dest)
+
+	out := []string{dest}
+	for dir := dest; dir != "/"; {
+		dir = path.Dir(dir)
+		out = append([]string{dir}, out...)
+	}
+	return out
+}
+
+// lockOwner identifies this process to anyone finding a lock held.
+func lockOwner() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// acquirePublishLocks acquires the locks for dest in each of envs configured
+// with 'publishlock', waiting for up to 'publishlockwait' for any held by
+// another sync. It returns a function releasing them, or else the exit code.
+func acquirePublishLocks(ctx context.Context, envs []conf.Config, dest string) (func(), int) {
+	logger := log.FromContext(ctx)
+
+	// Locks are acquired in a consistent order, by environment and then from
+	// the root down, so that syncs waiting for one another can't deadlock.
+	envs = append([]conf.Config{}, envs...)
+	sort.Slice(envs, func(i, j int) bool { return envs[i].GwEnv() < envs[j].GwEnv() })
+
+	acquired := []publishLock{}
+	release := func() {
+		// Locks are released even if the sync was interrupted.
+		ctx := context.WithoutCancel(ctx)
+		for _, lock := range acquired {
+			if err := lock.release(ctx); err != nil {
+				logger.F("error", err).Warn("can't release publish lock")
+			}
+		}
+	}
+
+	for _, env := range envs {
+		if env.PublishLock() == "" {
+			continue
+		}
+
+		deadline := time.Now().Add(time.Duration(env.PublishLockWait()) * time.Second)
+		waiting := false
+
+		paths := lockPaths(dest)
+		for i, lockPath := range paths {
+			key := env.GwEnv() + ":" + lockPath
+			shared := i < len(paths)-1
+			lock := newPublishLock(env.PublishLock(), key, shared)
+
+			for {
+				ok, err := lock.tryAcquire(ctx)
+				if err != nil {
+					logger.F("lock", key, "error", err).Error("can't acquire publish lock")
+					release()
+					return nil, 75
+				}
+				if ok {
+					logger.F("lock", key, "shared", shared).Debug("Acquired publish lock")
+					acquired = append(acquired, lock)
+					break
+				}
+
+				if !time.Now().Before(deadline) {
+					logger.F("lock", key).Error("Destination is locked by another sync")
+					release()
+					return nil, 75
+				}
+				if !waiting {
+					logger.F("lock", key, "wait", env.PublishLockWait()).Info("Waiting for another sync to the destination")
+					waiting = true
+				}
+
+				select {
+				case <-ctx.Done():
+					logger.F("lock", key, "error", ctx.Err()).Error("can't acquire publish lock")
+					release()
+					return nil, 75
+				case <-time.After(lockPollInterval):
+				}
+			}
+		}
+	}
+
+	return release, 0
+}
+
+// fileLock is a publishLock held by flock(2) on a file within a directory,
+// so that it's released even if the process holding it dies.
+type fileLock struct {
+	path   string
+	shared bool
+	file   *os.File
+}
+
+func (l *fileLock) tryAcquire(ctx context.Context) (bool, error) {
+	file, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return false, err
+	}
+
+	locked, err := flock(file, l.shared)
+	if err != nil || !locked {
+		file.Close()
+		return false, err
+	}
+
+	// The file is left in place when released, as removing it would race
+	// with another sync opening it; its content is only informative, naming
+	// the holder of an exclusive lock.
+	if !l.shared {
+		if err := file.Truncate(0); err == nil {
+			fmt.Fprintln(file, lockOwner())
+		}
+	}
+
+	l.file = file
+	return true, nil
+}
+
+func (l *fileLock) release(ctx context.Context) error {
+	return l.file.Close()
+}
+
+// httpLock is a publishLock held via an HTTP lock service, which is asked to
+// acquire the lock by a POST to its URL, to renew its lease by a PUT and to
+// release it by a DELETE. The service responds with 409 Conflict or 423 Locked
+// if another sync holds it, or if the lease had already expired on renewal.
+//
+// Each request has a JSON body with the "owner" of the lock, whether it's
+// "shared", and the "ttl" in seconds after which the service may release it
+// if the lease isn't renewed.
+type httpLock struct {
+	url    string
+	shared bool
+
+	// Closed to stop renewing the lease, which closes renewed once done.
+	stop    chan struct{}
+	renewed chan struct{}
+}
+
+type httpLockRequest struct {
+	Owner  string `json:"owner"`
+	Shared bool   `json:"shared"`
+	TTL    int64  `json:"ttl"`
+}
+
+func (l *httpLock) do(ctx context.Context, method string) (*http.Response, error) {
+	ttl := int64(lockLease / time.Second)
+	if ttl < 1 {
+		ttl = 1
+	}
+	body, err := json.Marshal(httpLockRequest{Owner: lockOwner(), Shared: l.shared, TTL: ttl})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, l.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: lockRequestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp, nil
+}
+
+func (l *httpLock) tryAcquire(ctx context.Context) (bool, error) {
+	resp, err := l.do(ctx, "POST")
+	switch {
+	case err != nil:
+		return false, err
+	case resp.StatusCode == http.StatusConflict || resp.StatusCode == http.StatusLocked:
+		return false, nil
+	case resp.StatusCode >= 300:
+		return false, fmt.Errorf("lock service at %s responded %s", l.url, resp.Status)
+	}
+
+	l.stop = make(chan struct{})
+	l.renewed = make(chan struct{})
+	go l.renew(context.WithoutCancel(ctx))
+
+	return true, nil
+}
+
+// renew renews the lease on the lock until it's released.
+func (l *httpLock) renew(ctx context.Context) {
+	defer close(l.renewed)
+
+	ticker := time.NewTicker(lockLease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+
+		resp, err := l.do(ctx, "PUT")
+		if err == nil && resp.StatusCode >= 300 {
+			err = fmt.Errorf("lock service at %s responded %s", l.url, resp.Status)
+		}
+		if err != nil {
+			// The lease may yet be renewed before it expires.
+			log.FromContext(ctx).F("lock", l.url, "error", err).Warn("can't renew publish lock")
+		}
+	}
+}
+
+func (l *httpLock) release(ctx context.Context) error {
+	close(l.stop)
+	<-l.renewed
+
+	resp, err := l.do(ctx, "DELETE")
+	if err == nil && resp.StatusCode >= 300 {
+		err = fmt.Errorf("lock service at %s responded %s", l.url, resp.Status)
+	}
+	return err
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package cmd
+
+import "os"
+
+// flock isn't supported here, so a sync configured with lock files fails;
+// a lock service may be used instead.
+func flock(file *os.File, shared bool) (bool, error) {
+	return false, errFlockUnsupported
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+// manifest is a previous run's items, as written by --exodus-dump-items and
+// read by --exodus-base-manifest, by web URI.
+type manifest map[string]dumpedItem
+
+// readManifest reads the items written by --exodus-dump-items into the file
+// at path, as CSV if it has a .csv extension, and otherwise as JSON. It
+// may be gzip-compressed.
+func readManifest(path string) (manifest, error) {
+	content, err := readMaybeCompressed(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []dumpedItem
+	if uncompressedExt(path) == ".csv" {
+		items, err = parseManifestCSV(content)
+	} else {
+		err = json.Unmarshal(content, &items)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	out := make(manifest, len(items))
+	for _, item := range items {
+		if item.WebURI == "" {
+			return nil, fmt.Errorf("parsing %s: item without web_uri", path)
+		}
+		out[item.WebURI] = item
+	}
+	return out, nil
+}
+
+func parseManifestCSV(content []byte) ([]dumpedItem, error) {
+	records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	// Columns are found by the header, as written by --exodus-dump-items.
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[name] = i
+	}
+	if _, ok := columns["web_uri"]; !ok {
+		return nil, fmt.Errorf("missing web_uri column")
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return record[i]
+		}
+		return ""
+	}
+
+	out := []dumpedItem{}
+	for _, record := range records[1:] {
+		item := dumpedItem{
+			WebURI:          field(record, "web_uri"),
+			ObjectKey:       field(record, "object_key"),
+			ContentType:     field(record, "content_type"),
+			ContentEncoding: field(record, "content_encoding"),
+			LinkTo:          field(record, "link_to"),
+		}
+		if labels := field(record, "labels"); labels != "" {
+			if err := json.Unmarshal([]byte(labels), &item.Labels); err != nil {
+				return nil, fmt.Errorf("invalid labels of %s: %w", item.WebURI, err)
+			}
+		}
+		if size := field(record, "size"); size != "" {
+			if item.Size, err = strconv.ParseInt(size, 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid size of %s: %w", item.WebURI, err)
+			}
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// manifestDelta is the difference between the items to be published and
+// those of a base manifest.
+type manifestDelta struct {
+	// Indices of the items which are new, or whose object key or link
+	// target differs from that in the manifest.
+	added   []int
+	updated []int
+
+	// Number of items identical to those in the manifest.
+	unchanged int
+
+	// Web URIs in the manifest with no item to be published, in order.
+	removed []string
+}
+
+// delta compares publishItems with the manifest. Items at the same web URI
+// are compared only by their object keys and link targets.
+func (m manifest) delta(publishItems []gw.ItemInput) manifestDelta {
+	out := manifestDelta{}
+	seen := make(map[string]bool, len(publishItems))
+
+	for i, item := range publishItems {
+		seen[item.WebURI] = true
+
+		base, ok := m[item.WebURI]
+		switch {
+		case !ok:
+			out.added = append(out.added, i)
+		case base.ObjectKey != item.ObjectKey || base.LinkTo != item.LinkTo:
+			out.updated = append(out.updated, i)
+		default:
+			out.unchanged++
+		}
+	}
+
+	for uri := range m {
+		if !seen[uri] {
+			out.removed = append(out.removed, uri)
+		}
+	}
+	sort.Strings(out.removed)
+
+	return out
+}
+
+// changed returns the items and publishItems which are added or updated
+// according to the delta, in their original order.
+func (d manifestDelta) changed(items []walk.SyncItem, publishItems []gw.ItemInput) ([]walk.SyncItem, []gw.ItemInput) {
+	indices := append(append([]int{}, d.added...), d.updated...)
+	sort.Ints(indices)
+
+	outItems := make([]walk.SyncItem, 0, len(indices))
+	outPublishItems := make([]gw.ItemInput, 0, len(indices))
+	for _, i := range indices {
+		outItems = append(outItems, items[i])
+		outPublishItems = append(outPublishItems, publishItems[i])
+	}
+	return outItems, outPublishItems
+}
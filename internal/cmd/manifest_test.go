@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+func TestManifestDelta(t *testing.T) {
+	base := manifest{
+		"/dest/same":        {WebURI: "/dest/same", ObjectKey: "aaa"},
+		"/dest/changed":     {WebURI: "/dest/changed", ObjectKey: "bbb"},
+		"/dest/link":        {WebURI: "/dest/link", LinkTo: "/dest/same"},
+		"/dest/relinked":    {WebURI: "/dest/relinked", LinkTo: "/dest/same"},
+		"/dest/removed":     {WebURI: "/dest/removed", ObjectKey: "ccc"},
+		"/dest/also-gone":   {WebURI: "/dest/also-gone", ObjectKey: "ddd"},
+		"/dest/retyped.txt": {WebURI: "/dest/retyped.txt", ObjectKey: "eee", ContentType: "text/plain"},
+	}
+
+	publishItems := []gw.ItemInput{
+		{WebURI: "/dest/new", ObjectKey: "fff"},
+		{WebURI: "/dest/same", ObjectKey: "aaa"},
+		{WebURI: "/dest/changed", ObjectKey: "ggg"},
+		{WebURI: "/dest/link", LinkTo: "/dest/same"},
+		{WebURI: "/dest/relinked", LinkTo: "/dest/new"},
+		// Only changes of content count.
+		{WebURI: "/dest/retyped.txt", ObjectKey: "eee", ContentType: "text/html"},
+	}
+
+	delta := base.delta(publishItems)
+
+	if !reflect.DeepEqual(delta.added, []int{0}) {
+		t.Errorf("unexpected added %v", delta.added)
+	}
+	if !reflect.DeepEqual(delta.updated, []int{2, 4}) {
+		t.Errorf("unexpected updated %v", delta.updated)
+	}
+	if delta.unchanged != 3 {
+		t.Errorf("unexpected unchanged %d", delta.unchanged)
+	}
+	if !reflect.DeepEqual(delta.removed, []string{"/dest/also-gone", "/dest/removed"}) {
+		t.Errorf("unexpected removed %v", delta.removed)
+	}
+
+	items := make([]walk.SyncItem, len(publishItems))
+	for i := range items {
+		items[i].SrcPath = publishItems[i].WebURI
+	}
+	outItems, outPublishItems := delta.changed(items, publishItems)
+	want := []gw.ItemInput{publishItems[0], publishItems[2], publishItems[4]}
+	if !reflect.DeepEqual(outPublishItems, want) {
+		t.Errorf("unexpected changed items %v", outPublishItems)
+	}
+	if len(outItems) != 3 || outItems[1].SrcPath != "/dest/changed" {
+		t.Errorf("items don't correspond to publish items: %v", outItems)
+	}
+}
+
+func TestReadManifest(t *testing.T) {
+	dir := t.TempDir()
+	publishItems := []gw.ItemInput{
+		{WebURI: "/dest/file", ObjectKey: "aaa", ContentType: "text/plain"},
+		{WebURI: "/dest/link", LinkTo: "/dest/file"},
+	}
+	items := []walk.SyncItem{{SrcPath: "file"}, {SrcPath: "link", LinkTo: "file"}}
+
+	// Whatever the format dumped, the same items are read back.
+	for _, name := range []string{"items.json", "items.csv"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := dumpItems(path, strings.TrimPrefix(filepath.Ext(name), "."), items, publishItems, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := readManifest(path)
+			if err != nil {
+				t.Fatalf("failed to read manifest, err = %v", err)
+			}
+			want := manifest{
+				"/dest/file": {WebURI: "/dest/file", ObjectKey: "aaa", ContentType: "text/plain"},
+				"/dest/link": {WebURI: "/dest/link", LinkTo: "/dest/file"},
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+
+	for name, content := range map[string]string{
+		"bad.json":    "[{",
+		"no-uri.json": `[{"object_key": "aaa"}]`,
+		"bad.csv":     "object_key\naaa\n",
+		"size.csv":    "web_uri,size\n/dest/file,big\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := readManifest(path); err == nil {
+				t.Error("unexpectedly read invalid manifest")
+			}
+		})
+	}
+
+	if _, err := readManifest(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("unexpectedly read missing manifest")
+	}
+}
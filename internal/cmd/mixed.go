@@ -11,6 +11,7 @@ import (
 	"github.com/release-engineering/exodus-rsync/internal/conf"
 	"github.com/release-engineering/exodus-rsync/internal/log"
 	"github.com/release-engineering/exodus-rsync/internal/rsync"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
 )
 
 // Mixed publish mode, publishing both via exodus and rsync.
@@ -18,6 +19,22 @@ import (
 func mixedMain(ctx context.Context, cfg conf.Config, args args.Config) int {
 	logger := log.FromContext(ctx)
 
+	// rsync would take the placeholder in DEST literally.
+	if len(args.DestSet) > 0 {
+		logger.Error("--exodus-dest-set can't be used in mixed mode")
+		return 23
+	}
+
+	// rsync applies the excludes from configuration just as exodus-rsync
+	// does, as they come before any other filters. An include only excepts
+	// files from those excludes, leaving the other filters to apply, which
+	// rsync has no way to express.
+	if len(cfg.Include()) > 0 {
+		logger.Error("'include' configuration can't be used in mixed mode")
+		return 23
+	}
+	ctx = walk.WithBaseFilters(ctx, walk.Filters{Exclude: cfg.Exclude()})
+
 	rsyncCmd, err := ext.rsync.Command(ctx, rsync.Arguments(ctx, args))
 	if err != nil {
 		logger.F("error", err).Error("Failed to generate rsync command")
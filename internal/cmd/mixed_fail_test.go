@@ -20,6 +20,12 @@ func TestExodusFailsFirst(t *testing.T) {
 	// Force exodus publish to fail by setting up broken cert/key path.
 	cfg.EXPECT().GwCert().Return("/not/exist/cert")
 	cfg.EXPECT().GwKey().Return("/not/exist/key")
+	cfg.EXPECT().GwCertCommand().Return("")
+	cfg.EXPECT().GwKeyCommand().Return("")
+	cfg.EXPECT().ProtectedEnvs().Return("").AnyTimes()
+	cfg.EXPECT().PublishLock().Return("").AnyTimes()
+	cfg.EXPECT().Exclude().Return(nil).AnyTimes()
+	cfg.EXPECT().Include().Return(nil).AnyTimes()
 
 	// Force rsync to succeed.
 	rsync := &fakeRsync{delegate: ext.rsync}
@@ -59,6 +65,12 @@ func TestExodusFailsLater(t *testing.T) {
 	})
 
 	cfg.EXPECT().GwKey().Return("/not/exist/key")
+	cfg.EXPECT().GwCertCommand().Return("")
+	cfg.EXPECT().GwKeyCommand().Return("")
+	cfg.EXPECT().ProtectedEnvs().Return("").AnyTimes()
+	cfg.EXPECT().PublishLock().Return("").AnyTimes()
+	cfg.EXPECT().Exclude().Return(nil).AnyTimes()
+	cfg.EXPECT().Include().Return(nil).AnyTimes()
 
 	// Force rsync to succeed.
 	rsync := &fakeRsync{delegate: ext.rsync}
@@ -84,6 +96,10 @@ func TestExodusFailsLater(t *testing.T) {
 func TestRsyncFailsFirst(t *testing.T) {
 	ctrl := MockController(t)
 	cfg := conf.NewMockConfig(ctrl)
+	cfg.EXPECT().ProtectedEnvs().Return("").AnyTimes()
+	cfg.EXPECT().PublishLock().Return("").AnyTimes()
+	cfg.EXPECT().Exclude().Return(nil).AnyTimes()
+	cfg.EXPECT().Include().Return(nil).AnyTimes()
 
 	mockGw := gw.NewMockInterface(ctrl)
 	ext.gw = mockGw
@@ -133,6 +149,8 @@ func TestRsyncFailsFirst(t *testing.T) {
 func TestNoRsyncCommand(t *testing.T) {
 	ctrl := MockController(t)
 	cfg := conf.NewMockConfig(ctrl)
+	cfg.EXPECT().Exclude().Return(nil).AnyTimes()
+	cfg.EXPECT().Include().Return(nil).AnyTimes()
 
 	logs := CaptureLogger(t)
 	ctx := testContext()
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/syncutil"
+)
+
+// checkNotPublished checks that none of the given items are already
+// published, by requesting each one's URI from the CDN, returning an error
+// listing any which are. Up to threads requests are made at once.
+//
+// This is how --exodus-no-replace is applied where exodus-gw doesn't support
+// refusing to replace items itself. It's only a best effort, as an item may
+// still be published by something else after the check.
+func checkNotPublished(ctx context.Context, cdnURL string, publishItems []gw.ItemInput, threads int) error {
+	client := &http.Client{}
+
+	// Any failure makes the outcome of the remaining checks moot.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Each check records its outcome at the index of its item, so that
+	// they're reported in order.
+	published := make([]bool, len(publishItems))
+	errs := make([]error, len(publishItems))
+
+	jobs := make(chan int, len(publishItems))
+	for i := range publishItems {
+		jobs <- i
+	}
+	close(jobs)
+
+	syncutil.RunWithGroup(max(threads, 1),
+		func() {
+			for i := range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+				published[i], errs[i] = isPublished(ctx, client, cdnURL, publishItems[i].WebURI)
+				if errs[i] != nil {
+					cancel()
+				}
+			}
+		},
+		func() {},
+	)
+
+	// A check cancelled by the failure of another isn't the failure to
+	// report, unless every check was cancelled.
+	var firstErr error
+	for _, err := range errs {
+		if err != nil && (firstErr == nil || errors.Is(firstErr, context.Canceled) && !errors.Is(err, context.Canceled)) {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+
+	var existing []string
+
+	for i, item := range publishItems {
+		if published[i] {
+			existing = append(existing, item.WebURI)
+		}
+	}
+
+	if len(existing) > 0 {
+		return fmt.Errorf("%d item(s) already published: %s",
+			len(existing), strings.Join(existing, ", "))
+	}
+
+	return nil
+}
+
+// isPublished returns true if anything is published at uri on the CDN.
+func isPublished(ctx context.Context, client *http.Client, cdnURL string, uri string) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	req, err := http.NewRequestWithContext(ctx, "HEAD", cdnURL+uri, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("checking %s: %w", uri, err)
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		logger.F("uri", uri).Debug("Not yet published")
+		return false, nil
+	case http.StatusOK:
+		logger.F("uri", uri).Error("Already published")
+		return true, nil
+	default:
+		return false, fmt.Errorf("checking %s: %s", uri, resp.Status)
+	}
+}
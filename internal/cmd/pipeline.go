@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+// pipeline adds items onto a publish as soon as their blobs are available,
+// while other blobs are still uploading, as requested by --exodus-pipeline.
+//
+// After each batch of items is added, the publish is committed in "phase1"
+// mode, so that content starts going live early, unless incremental commits
+// are disabled. Phase 1 commits aren't
+// supported by every exodus-gw deployment, so if one fails, the pipeline
+// falls back to only adding items, leaving everything to the final commit.
+type pipeline struct {
+	publish gw.Publish
+
+	// Items not yet passed to the pipeline, by source path. A source has
+	// several items when published to each of --exodus-dest-set.
+	publishItems map[string][]gw.ItemInput
+
+	// Items whose blobs are uploaded, and items awaiting the upload of a blob
+	// handled by another item, by key.
+	readyKeys map[string]bool
+	waiting   map[string][]gw.ItemInput
+
+	ready  chan gw.ItemInput
+	done   chan error
+	cancel func()
+}
+
+// startPipeline starts adding items onto publish as they're passed to
+// onUploaded or onExisting. Items may be passed to onDuplicate before the blob
+// they share is uploaded, so they're held back until it is.
+//
+// If adding items fails, cancel is called so that uploads stop.
+func startPipeline(
+	ctx context.Context,
+	cancel func(),
+	publish gw.Publish,
+	items []walk.SyncItem,
+	publishItems []gw.ItemInput,
+	incremental bool,
+) *pipeline {
+	p := &pipeline{
+		publish:      publish,
+		publishItems: make(map[string][]gw.ItemInput, len(items)),
+		readyKeys:    make(map[string]bool),
+		waiting:      make(map[string][]gw.ItemInput),
+		ready:        make(chan gw.ItemInput, len(items)),
+		done:         make(chan error, 1),
+		cancel:       cancel,
+	}
+
+	for i, item := range items {
+		p.publishItems[item.SrcPath] = append(p.publishItems[item.SrcPath], publishItems[i])
+	}
+
+	go func() {
+		err := p.run(ctx, incremental)
+
+		// The result is available before uploads are cancelled, so finish
+		// can tell whether the pipeline was the cause of their failure.
+		p.done <- err
+		if err != nil {
+			cancel()
+		}
+	}()
+
+	return p
+}
+
+func (p *pipeline) send(item walk.SyncItem) {
+	for _, publishItem := range p.publishItems[item.SrcPath] {
+		p.ready <- publishItem
+	}
+	delete(p.publishItems, item.SrcPath)
+}
+
+func (p *pipeline) onUploaded(item walk.SyncItem) {
+	p.send(item)
+
+	p.readyKeys[item.Key] = true
+	for _, publishItem := range p.waiting[item.Key] {
+		p.ready <- publishItem
+	}
+	delete(p.waiting, item.Key)
+}
+
+func (p *pipeline) onExisting(item walk.SyncItem) {
+	p.onUploaded(item)
+}
+
+func (p *pipeline) onDuplicate(item walk.SyncItem) {
+	if p.readyKeys[item.Key] {
+		p.send(item)
+		return
+	}
+
+	p.waiting[item.Key] = append(p.waiting[item.Key], p.publishItems[item.SrcPath]...)
+	delete(p.publishItems, item.SrcPath)
+}
+
+// drop stops items with the given keys from being added, as their blobs
+// couldn't be uploaded, along with links to them. It must be called only after
+// uploads have completed.
+func (p *pipeline) drop(keys map[string]bool) {
+	dropped := make(map[string]bool)
+
+	keep := func(drop func(gw.ItemInput) bool) {
+		for src, publishItems := range p.publishItems {
+			kept := publishItems[:0]
+			for _, publishItem := range publishItems {
+				if drop(publishItem) {
+					dropped[publishItem.WebURI] = true
+				} else {
+					kept = append(kept, publishItem)
+				}
+			}
+			p.publishItems[src] = kept
+		}
+	}
+
+	keep(func(publishItem gw.ItemInput) bool {
+		return keys[publishItem.ObjectKey]
+	})
+	for key := range keys {
+		for _, publishItem := range p.waiting[key] {
+			dropped[publishItem.WebURI] = true
+		}
+		delete(p.waiting, key)
+	}
+
+	keep(func(publishItem gw.ItemInput) bool {
+		return dropped[publishItem.LinkTo]
+	})
+}
+
+// finish adds any items not already added, such as links, and waits for the
+// pipeline to complete, returning the first error encountered. The publish is
+// not committed by finish.
+//
+// It must be called only after uploads have completed. If uploads failed, no
+// more items are added, and an error is returned only if the pipeline failed
+// first.
+func (p *pipeline) finish(uploadsFailed bool) error {
+	if uploadsFailed {
+		select {
+		case err := <-p.done:
+			return err
+		default:
+		}
+
+		p.cancel()
+		close(p.ready)
+		<-p.done
+		return nil
+	}
+
+	for _, publishItems := range p.publishItems {
+		for _, publishItem := range publishItems {
+			p.ready <- publishItem
+		}
+	}
+	p.publishItems = nil
+
+	close(p.ready)
+	return <-p.done
+}
+
+func (p *pipeline) run(ctx context.Context, incremental bool) error {
+	logger := log.FromContext(ctx)
+
+	for {
+		item, ok := <-p.ready
+		if !ok {
+			return nil
+		}
+
+		// Add whichever items have become ready since the last batch, which
+		// is more the longer that adding and committing the last one took.
+		batch := []gw.ItemInput{item}
+		closed := false
+	drain:
+		for {
+			select {
+			case item, ok := <-p.ready:
+				if !ok {
+					closed = true
+					break drain
+				}
+				batch = append(batch, item)
+			default:
+				break drain
+			}
+		}
+
+		if err := p.publish.AddItems(ctx, batch); err != nil {
+			return err
+		}
+
+		if closed {
+			return nil
+		}
+
+		if incremental {
+			if err := p.publish.Commit(ctx, "phase1"); err != nil {
+				if ctx.Err() != nil {
+					return err
+				}
+				logger.F("error", err).Warn("Can't commit publish incrementally, content will be committed at the end")
+				incremental = false
+				continue
+			}
+			logger.F("publish", p.publish.ID(), "items", len(batch)).Info("Committed items incrementally")
+		}
+	}
+}
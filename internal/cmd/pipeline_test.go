@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+func TestPipelineHoldsDuplicates(t *testing.T) {
+	items := []walk.SyncItem{
+		{SrcPath: "src/a", Key: "abc"},
+		{SrcPath: "src/b", Key: "abc"},
+		{SrcPath: "src/link", LinkTo: "a"},
+	}
+	publishItems := []gw.ItemInput{
+		{WebURI: "/dest/a", ObjectKey: "abc"},
+		{WebURI: "/dest/b", ObjectKey: "abc"},
+		{WebURI: "/dest/link", LinkTo: "/dest/a"},
+	}
+
+	publish := &pipelinePublish{phase1: true, idle: make(chan struct{}, 10)}
+	ctx := testContext()
+	p := startPipeline(ctx, func() {}, publish, items, publishItems, true)
+
+	// A duplicate reported before its blob is uploaded isn't added...
+	p.onDuplicate(items[1])
+
+	// ...until the blob is uploaded, when both items are ready.
+	p.onUploaded(items[0])
+	for added := 0; added < 2; {
+		<-publish.idle
+
+		publish.mu.Lock()
+		added = 0
+		for _, call := range publish.calls {
+			var n int
+			if _, err := fmt.Sscanf(call, "add %d", &n); err == nil {
+				added += n
+			}
+		}
+		publish.mu.Unlock()
+	}
+
+	// The link is only added at the end.
+	if err := p.finish(false); err != nil {
+		t.Fatalf("finish failed, err = %v", err)
+	}
+
+	// The items may be added in one or two batches, depending on timing.
+	got := fmt.Sprint(publish.calls)
+	if got != "[add 2 commit phase1 add 1]" && got != "[add 1 commit phase1 add 1 commit phase1 add 1]" {
+		t.Errorf("unexpected calls %v", got)
+	}
+}
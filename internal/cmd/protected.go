@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+// The terminal from which a publish to a protected environment may be
+// confirmed, or nil if exodus-rsync isn't run from a terminal.
+var confirmIn io.Reader = terminalStdin()
+
+// Where the prompt for confirmation is written; stdout may be taken by
+// --exodus-output=json.
+var confirmOut io.Writer = os.Stderr
+
+func terminalStdin() io.Reader {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		return os.Stdin
+	}
+	return nil
+}
+
+// protectedEnvs returns the names of the exodus-gw environments of envs
+// matching 'protectedenvs'.
+func protectedEnvs(envs []conf.Config) ([]string, error) {
+	var out []string
+
+	for _, env := range envs {
+		if env.ProtectedEnvs() == "" {
+			continue
+		}
+		pattern, err := regexp.Compile(env.ProtectedEnvs())
+		if err != nil {
+			return nil, fmt.Errorf("invalid protectedenvs configuration: %w", err)
+		}
+		if pattern.MatchString(env.GwEnv()) {
+			out = append(out, env.GwEnv())
+		}
+	}
+
+	return out, nil
+}
+
+// syncEnvs returns the environments to which a sync with cfg and args
+// publishes: that of cfg, or each of --exodus-env.
+func syncEnvs(cfg conf.Config, args args.Config) []conf.Config {
+	if len(args.Env) == 0 {
+		return []conf.Config{cfg}
+	}
+
+	out := []conf.Config{}
+	for _, name := range args.Env {
+		out = append(out, envConfig{cfg, name})
+	}
+	return out
+}
+
+// confirmProtected warns of a sync to any protected environment among envs,
+// and returns true if the sync should proceed: if it makes no changes, or
+// was confirmed by --exodus-yes or at the terminal.
+func confirmProtected(ctx context.Context, envs []conf.Config, args args.Config) bool {
+	logger := log.FromContext(ctx)
+
+	protected, err := protectedEnvs(envs)
+	if err != nil {
+		logger.F("error", err).Error("can't check for protected environments")
+		return false
+	}
+	if len(protected) == 0 {
+		return true
+	}
+
+	names := strings.Join(protected, ",")
+	logger.F("env", names, "dryRun", args.DryRun).Warn(
+		"!!! Syncing to protected exodus-gw environment !!!")
+
+	if args.DryRun || args.Offline != "" || args.Yes {
+		return true
+	}
+
+	if confirmIn != nil {
+		fmt.Fprintf(confirmOut, "Publish to protected environment %s? [y/N] ", names)
+		answer, _ := bufio.NewReader(confirmIn).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer == "y" || answer == "yes" {
+			return true
+		}
+	}
+
+	logger.F("env", names).Error(
+		"Refusing to publish to protected environment without confirmation; use --exodus-yes to confirm")
+	return false
+}
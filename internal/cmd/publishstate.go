@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/atomicfile"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+// publishState records a fingerprint of the items of the last publish
+// committed to each destination, in the file named by 'publishstate', so
+// that a run which would publish exactly the same items can skip publishing.
+type publishState struct {
+	path string
+}
+
+// publishStateFile is the content of a state file.
+type publishStateFile struct {
+	Publishes map[string]publishRecord `json:"publishes"`
+}
+
+// publishRecord describes the last publish committed to a destination.
+type publishRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	Publish     string `json:"publish"`
+
+	// The commit_mode of the commit, such as "phase1", or empty for the
+	// server's default. The same items committed in another mode, such as
+	// "phase2" after "phase1", still need publishing.
+	Mode string `json:"mode,omitempty"`
+
+	Committed time.Time `json:"committed"`
+}
+
+// newPublishState returns the state recorded in the file at path, or nil if
+// path is empty.
+func newPublishState(path string) *publishState {
+	if path == "" {
+		return nil
+	}
+	return &publishState{path: path}
+}
+
+// publishStateKey identifies the destination of a publish made with cfg
+// and args.
+func publishStateKey(cfg conf.Config, args args.Config) string {
+	return fmt.Sprintf("%s %s %s", cfg.GwURL(), cfg.GwEnv(), args.DestPath())
+}
+
+// fingerprint returns a checksum of the given items, which is independent of
+// their order.
+func fingerprint(publishItems []gw.ItemInput) string {
+	lines := make([]string, len(publishItems))
+	for i, item := range publishItems {
+		line, _ := json.Marshal(item)
+		lines[i] = string(line)
+	}
+	sort.Strings(lines)
+
+	hasher := sha256.New()
+	for _, line := range lines {
+		fmt.Fprintln(hasher, line)
+	}
+	return fmt.Sprintf("%x", hasher.Sum(nil))
+}
+
+// read returns the content of the state file. A missing or unreadable file is
+// treated as empty.
+func (s *publishState) read(ctx context.Context) publishStateFile {
+	file := publishStateFile{}
+
+	content, err := readMaybeCompressed(s.path)
+	if err == nil {
+		err = json.Unmarshal(content, &file)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		log.FromContext(ctx).F("path", s.path, "error", err).Warn("Ignoring unreadable publish state")
+	}
+
+	if file.Publishes == nil {
+		file.Publishes = make(map[string]publishRecord)
+	}
+	return file
+}
+
+// write replaces the content of the state file.
+func (s *publishState) write(file publishStateFile) error {
+	content, err := json.Marshal(file)
+	if err == nil {
+		content, err = compressFor(s.path, content)
+	}
+	if err != nil {
+		return err
+	}
+
+	return atomicfile.WriteFile(s.path, content)
+}
+
+// lookup returns the record of the last publish committed to the destination
+// identified by key, if any.
+func (s *publishState) lookup(ctx context.Context, key string) (publishRecord, bool) {
+	if s == nil {
+		return publishRecord{}, false
+	}
+	record, ok := s.read(ctx).Publishes[key]
+	return record, ok
+}
+
+// record notes that publishID, with items of itemsFingerprint, was
+// committed in mode to the destination identified by key, merging it with
+// records written by other runs.
+func (s *publishState) record(ctx context.Context, key string, itemsFingerprint string, publishID string, mode string) error {
+	if s == nil {
+		return nil
+	}
+
+	file := s.read(ctx)
+	file.Publishes[key] = publishRecord{itemsFingerprint, publishID, mode, time.Now().UTC()}
+
+	return s.write(file)
+}
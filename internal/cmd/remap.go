@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// remapRule rewrites source paths matching a pattern, as loaded from the
+// --exodus-remap file.
+type remapRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// loadRemapRules reads remap rules from a file.
+//
+// Each non-empty line which isn't a comment holds a regular expression and
+// a replacement, separated by whitespace. The replacement may refer to
+// capture groups, e.g. "$1".
+func loadRemapRules(path string) ([]remapRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []remapRule
+
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected 'PATTERN REPLACEMENT'", path, lineNo)
+		}
+
+		re, err := regexp.Compile(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+
+		out = append(out, remapRule{re, fields[1]})
+	}
+
+	return out, scanner.Err()
+}
+
+// remap applies the first rule matching relPath, returning the rewritten
+// path and true, or false if no rule matches.
+func remap(rules []remapRule, relPath string) (string, bool) {
+	for _, rule := range rules {
+		if rule.pattern.MatchString(relPath) {
+			return rule.pattern.ReplaceAllString(relPath, rule.replacement), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeRemapRules(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "remap")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRemap(t *testing.T) {
+	rules, err := loadRemapRules(writeRemapRules(t, `
+# Comments and blank lines are ignored.
+
+^build/out/(.*)\.rpm$     content/rhel/$1.rpm
+^build/(debug|release)/   $1-builds/
+^build/                   other/
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+		matched  bool
+	}{
+		{"build/out/x.rpm", "content/rhel/x.rpm", true},
+		{"build/release/sub/y", "release-builds/sub/y", true},
+		// First match wins, even though later rules also match.
+		{"build/out/x.txt", "other/out/x.txt", true},
+		{"src/z", "", false},
+	}
+
+	for _, tt := range tests {
+		got, matched := remap(rules, tt.input)
+		if got != tt.expected || matched != tt.matched {
+			t.Errorf("remap(%q) = %q, %v; expected %q, %v", tt.input, got, matched, tt.expected, tt.matched)
+		}
+	}
+}
+
+func TestRemapBadRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"missing replacement", "^foo\n", ":1: expected 'PATTERN REPLACEMENT'"},
+		{"too many fields", "\n^foo bar baz\n", ":2: expected 'PATTERN REPLACEMENT'"},
+		{"invalid regex", "^(foo bar\n", ":1: error parsing regexp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadRemapRules(writeRemapRules(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("did not get expected error, got: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	apexLog "github.com/apex/log"
+	"github.com/release-engineering/exodus-rsync/internal/progress"
+)
+
+// syncResult is written to stdout at the end of the command with
+// --exodus-output=json. Like progress events, its schema is intended to
+// remain stable between versions.
+type syncResult struct {
+	Src      string `json:"src"`
+	Dest     string `json:"dest"`
+	ExitCode int    `json:"exitCode"`
+
+	// Labels of the publishes created, from --exodus-label.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Publishes created or joined, in each environment.
+	Publishes []resultPublish `json:"publishes"`
+
+	// Number of files by the outcome of their upload.
+	Stats resultStats `json:"stats"`
+
+	// Outcome of each file processed for upload.
+	Items []resultItem `json:"items"`
+
+	// Every error logged, in order.
+	Errors []string `json:"errors"`
+}
+
+type resultPublish struct {
+	Env       string `json:"env"`
+	ID        string `json:"id"`
+	Committed bool   `json:"committed"`
+}
+
+type resultStats struct {
+	Uploaded  int `json:"uploaded"`
+	Existing  int `json:"existing"`
+	Duplicate int `json:"duplicate"`
+	Failed    int `json:"failed"`
+}
+
+type resultItem struct {
+	Env    string `json:"env,omitempty"`
+	Path   string `json:"path"`
+	Key    string `json:"key"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// resultCollector builds the result of the command from the progress
+// events emitted and the errors logged while it runs.
+type resultCollector struct {
+	mu     sync.Mutex
+	result syncResult
+}
+
+func newResultCollector() *resultCollector {
+	return &resultCollector{result: syncResult{
+		Publishes: []resultPublish{},
+		Items:     []resultItem{},
+		Errors:    []string{},
+	}}
+}
+
+func (c *resultCollector) publish(env string, id string) *resultPublish {
+	for i := range c.result.Publishes {
+		if p := &c.result.Publishes[i]; p.Env == env && p.ID == id {
+			return p
+		}
+	}
+	c.result.Publishes = append(c.result.Publishes, resultPublish{Env: env, ID: id})
+	return &c.result.Publishes[len(c.result.Publishes)-1]
+}
+
+func (c *resultCollector) observe(e progress.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := &c.result.Stats
+
+	switch e.Type {
+	case progress.TypeStart:
+		c.result.Src, c.result.Dest = e.Src, e.Dest
+	case progress.TypePublish:
+		c.publish(e.Env, e.Publish)
+		if e.Labels != nil {
+			c.result.Labels = e.Labels
+		}
+	case progress.TypePhase:
+		if e.Publish != "" {
+			c.publish(e.Env, e.Publish)
+		}
+	case progress.TypeUpload:
+		c.result.Items = append(c.result.Items, resultItem{e.Env, e.Path, e.Key, e.Status, e.Error})
+		switch e.Status {
+		case "uploaded":
+			stats.Uploaded++
+		case "existing":
+			stats.Existing++
+		case "duplicate":
+			stats.Duplicate++
+		case "failed":
+			stats.Failed++
+		}
+	case progress.TypeCommit:
+		if e.Status == "succeeded" {
+			c.publish(e.Env, e.Publish).Committed = true
+		}
+	case progress.TypeEnd:
+		c.result.ExitCode = *e.ExitCode
+	}
+}
+
+// HandleLog records the errors logged, so that collector is usable as
+// a log handler.
+func (c *resultCollector) HandleLog(e *apexLog.Entry) error {
+	if e.Level < apexLog.ErrorLevel {
+		return nil
+	}
+
+	msg := e.Message
+	if err, ok := e.Fields["error"]; ok {
+		msg = fmt.Sprintf("%s: %v", msg, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.result.Errors = append(c.result.Errors, msg)
+	return nil
+}
+
+func (c *resultCollector) write(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return writeJSON(w, c.result)
+}
+
+// writeJSON writes v to w as indented JSON, as for each JSON output of
+// exodus-rsync.
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+// selfCheckInput is the content whose key is computed by
+// --exodus-checksum-self-check, chosen as its SHA-256 digest is well known.
+const selfCheckInput = "The quick brown fox jumps over the lazy dog"
+
+// keyComputation is how object keys are computed for DEST, written by
+// --exodus-checksum-self-check.
+type keyComputation struct {
+	Env       string `json:"env"`
+	Algorithm string `json:"algorithm"`
+
+	// Prepended to the key of each blob uploaded, from 'blobkeyprefix'.
+	Prefix string `json:"prefix"`
+
+	// The known input, and its key as computed by this machine.
+	Input string `json:"input"`
+	Key   string `json:"key"`
+	Blob  string `json:"blob"`
+}
+
+// checksumSelfCheck writes the key computed for a known input to stdout for
+// --exodus-checksum-self-check, so that machines can be shown to compute the
+// same keys, and returns the exit code. exodus-gw isn't contacted.
+func checksumSelfCheck(ctx context.Context, cfg conf.Config, args args.Config) int {
+	logger := log.FromContext(ctx)
+
+	key, err := walk.ContentKey(strings.NewReader(selfCheckInput))
+	if err != nil {
+		logger.F("error", err).Error("can't compute key")
+		return 23
+	}
+
+	out := keyComputation{
+		Env:       cfg.GwEnv(),
+		Algorithm: walk.KeyAlgorithm,
+		Prefix:    cfg.BlobKeyPrefix(),
+		Input:     selfCheckInput,
+		Key:       key,
+		Blob:      cfg.BlobKeyPrefix() + key,
+	}
+
+	if args.ListFormat == "json" {
+		err = writeJSON(stdout, out)
+	} else {
+		err = writeKeyComputationTable(stdout, out)
+	}
+	if err != nil {
+		logger.F("error", err).Error("can't write key computation")
+		return 23
+	}
+
+	return 0
+}
+
+func writeKeyComputationTable(w io.Writer, c keyComputation) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "ENV\t%s\n", c.Env)
+	fmt.Fprintf(tw, "ALGORITHM\t%s\n", c.Algorithm)
+	fmt.Fprintf(tw, "PREFIX\t%s\n", c.Prefix)
+	fmt.Fprintf(tw, "INPUT\t%q\n", c.Input)
+	fmt.Fprintf(tw, "KEY\t%s\n", c.Key)
+	fmt.Fprintf(tw, "BLOB\t%s\n", c.Blob)
+
+	return tw.Flush()
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"gopkg.in/yaml.v3"
+)
+
+// Where commands such as --exodus-show-config write their output.
+var stdout io.Writer = os.Stdout
+
+// showConfig writes the settings in effect for dest to w, as YAML with
+// a comment giving the source of each value.
+func showConfig(w io.Writer, cfg conf.Config, dest string) error {
+	fmt.Fprintf(w, "# Configuration in effect for %s\n", dest)
+	if env, ok := cfg.(conf.EnvironmentConfig); ok {
+		fmt.Fprintf(w, "# Using environment with prefix: %s\n", env.Prefix())
+	} else {
+		fmt.Fprintf(w, "# No environment matches, using global configuration only\n")
+	}
+
+	for _, setting := range cfg.Settings() {
+		value, err := flowYAML(setting.Value)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s  # %s\n", setting.Name, value, setting.Source); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flowYAML returns value encoded as YAML on a single line.
+func flowYAML(value interface{}) (string, error) {
+	node := yaml.Node{}
+	if err := node.Encode(value); err != nil {
+		return "", err
+	}
+	node.Style = yaml.FlowStyle
+
+	out, err := yaml.Marshal(&node)
+	return strings.TrimSpace(string(out)), err
+}
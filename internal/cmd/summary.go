@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/release-engineering/exodus-rsync/internal/progress"
+)
+
+// summaryCollector builds the summary written to stdout at the end of a sync
+// with --verbose, in the format of the last lines written by rsync so that
+// tools parsing rsync's output also work with exodus-rsync:
+//
+//	sent 1,234 bytes  received 0 bytes  2,468.00 bytes/sec
+//	total size is 12,345  speedup is 10.00
+//
+// "sent" counts the content uploaded, "total size" the content of every file
+// in the sync, and nothing is counted as received. Like resultCollector, it's
+// put together from the progress events emitted.
+type summaryCollector struct {
+	mu     sync.Mutex
+	dryRun bool
+
+	start time.Time
+	end   time.Time
+
+	// Whether content was processed for upload at all, as the summary only
+	// applies to a sync.
+	synced bool
+
+	sent  int64
+	sizes map[string]int64
+}
+
+func newSummaryCollector(dryRun bool) *summaryCollector {
+	return &summaryCollector{dryRun: dryRun, sizes: make(map[string]int64)}
+}
+
+func (c *summaryCollector) observe(e progress.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch e.Type {
+	case progress.TypeStart:
+		c.start = e.Time
+	case progress.TypePhase:
+		if e.Phase == progress.PhaseUpload {
+			c.synced = true
+		}
+	case progress.TypeUpload:
+		// A file is counted once in the total size, even if published to
+		// several environments, but its content is sent to each.
+		c.sizes[e.Path] = e.Size
+		if e.Status == "uploaded" {
+			c.sent += e.Size
+		}
+	case progress.TypeEnd:
+		c.end = e.Time
+	}
+}
+
+func (c *summaryCollector) write(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.synced {
+		return nil
+	}
+
+	var total int64
+	for _, size := range c.sizes {
+		total += size
+	}
+
+	// rsync never transfers nothing at all, so a speedup is always defined;
+	// here at least a byte is taken to be transferred.
+	rate := 0.0
+	if elapsed := c.end.Sub(c.start).Seconds(); elapsed > 0 {
+		rate = float64(c.sent) / elapsed
+	}
+	speedup := float64(total) / float64(max(c.sent, 1))
+
+	dryRun := ""
+	if c.dryRun {
+		dryRun = " (DRY RUN)"
+	}
+
+	_, err := fmt.Fprintf(w, "sent %s bytes  received 0 bytes  %s bytes/sec\ntotal size is %s  speedup is %s%s\n",
+		groupDigits(strconv.FormatInt(c.sent, 10)),
+		groupDigits(strconv.FormatFloat(rate, 'f', 2, 64)),
+		groupDigits(strconv.FormatInt(total, 10)),
+		groupDigits(strconv.FormatFloat(speedup, 'f', 2, 64)),
+		dryRun)
+	return err
+}
+
+// groupDigits inserts commas between each group of three digits before the
+// decimal point of a formatted number, as rsync does.
+func groupDigits(number string) string {
+	whole, fraction, hasFraction := strings.Cut(number, ".")
+
+	var out strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			out.WriteByte(',')
+		}
+		out.WriteRune(digit)
+	}
+
+	if hasFraction {
+		out.WriteString("." + fraction)
+	}
+	return out.String()
+}
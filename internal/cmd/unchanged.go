@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"context"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sync"
+
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/syncutil"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+// unchangedItem is an item as it would be served from the CDN once
+// published, to be compared with what's served now.
+type unchangedItem struct {
+	verifyItem
+
+	contentType     string
+	contentEncoding string
+
+	// For a link, the web URI of its target, with whose content and headers
+	// the link is served.
+	linkTo string
+
+	// The item whose content is served.
+	src walk.SyncItem
+}
+
+// md5ETag matches an ETag which is the MD5 checksum of the content, as S3
+// and hence the CDN gives for an object not uploaded in parts. Other ETags,
+// such as weak or multipart ones, can't be compared with the local content.
+var md5ETag = regexp.MustCompile(`^"([0-9a-f]{32})"$`)
+
+// unchangedSample returns the items to check against the CDN to tell whether
+// publishing items would change anything, or false if it can't be told from
+// the CDN alone.
+//
+// A link is served with the content of its target, so is checked against
+// that. Visibility, Cache-Control and metadata aren't compared, so items
+// with any of those are always taken to have changed.
+func unchangedSample(items []walk.SyncItem, publishItems []gw.ItemInput) ([]unchangedItem, bool) {
+	content := make(map[string]unchangedItem, len(publishItems))
+	for i, item := range publishItems {
+		if item.ObjectKey != "" {
+			content[item.WebURI] = unchangedItem{
+				verifyItem:      verifyItem{item.WebURI, item.ObjectKey, items[i].Info.Size()},
+				contentType:     item.ContentType,
+				contentEncoding: item.ContentEncoding,
+				src:             items[i],
+			}
+		}
+	}
+
+	out := make([]unchangedItem, 0, len(publishItems))
+	for _, item := range publishItems {
+		if item.Visibility != "" || item.CacheControl != "" || len(item.Metadata) > 0 {
+			return nil, false
+		}
+
+		if item.LinkTo == "" {
+			out = append(out, content[item.WebURI])
+			continue
+		}
+
+		target, ok := content[item.LinkTo]
+		if !ok {
+			return nil, false
+		}
+		target.uri = item.WebURI
+		target.linkTo = item.LinkTo
+		out = append(out, target)
+	}
+
+	return out, true
+}
+
+// unchangedChecker checks items against what the CDN serves, computing the
+// MD5 checksum of each object's content no more than once.
+type unchangedChecker struct {
+	client *http.Client
+	cdnURL string
+
+	mu   sync.Mutex
+	md5s map[string]string
+}
+
+func (c *unchangedChecker) localMD5(ctx context.Context, item unchangedItem) (string, error) {
+	c.mu.Lock()
+	sum, ok := c.md5s[item.key]
+	c.mu.Unlock()
+	if ok {
+		return sum, nil
+	}
+
+	r, err := item.src.OpenContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	hasher := md5.New()
+	if _, err := io.Copy(hasher, r); err != nil {
+		return "", err
+	}
+	sum = fmt.Sprintf("%x", hasher.Sum(nil))
+
+	c.mu.Lock()
+	c.md5s[item.key] = sum
+	c.mu.Unlock()
+
+	return sum, nil
+}
+
+// check returns an error if the CDN doesn't serve item as it would be
+// published, otherwise the ETag with which it's served.
+//
+// Only the headers are fetched where the ETag tells the content is the
+// same; otherwise the content is fetched and compared by checksum.
+func (c *unchangedChecker) check(ctx context.Context, item unchangedItem) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", c.cdnURL+item.uri, nil)
+	if err != nil {
+		return "", err
+	}
+	// As when verifying, the headers of the blob itself are wanted.
+	req.Header.Set("Accept-Encoding", "identity")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s", resp.Status)
+	}
+	if resp.ContentLength >= 0 && resp.ContentLength != item.size {
+		return "", fmt.Errorf("expected %d bytes, got Content-Length %d", item.size, resp.ContentLength)
+	}
+	if got := resp.Header.Get("Content-Type"); got != item.contentType {
+		return "", fmt.Errorf("expected Content-Type %q, got %q", item.contentType, got)
+	}
+	if got := resp.Header.Get("Content-Encoding"); got != item.contentEncoding {
+		return "", fmt.Errorf("expected Content-Encoding %q, got %q", item.contentEncoding, got)
+	}
+
+	etag := resp.Header.Get("ETag")
+	if match := md5ETag.FindStringSubmatch(etag); match != nil {
+		sum, err := c.localMD5(ctx, item)
+		if err != nil {
+			return "", err
+		}
+		if sum == match[1] {
+			return etag, nil
+		}
+		// The ETag of an object encrypted with a KMS key isn't its MD5
+		// checksum, though it looks like one, so differing is no proof.
+	}
+
+	return etag, verifyOne(ctx, c.client, c.cdnURL, item.verifyItem)
+}
+
+// isUnchanged returns true if every one of the given items is already
+// published identically, by checking each one against the CDN with up to
+// threads requests at once. Any item which can't be checked is taken to have
+// changed, so that a commit is only skipped when it certainly would change
+// nothing.
+//
+// An item is compared by its size, Content-Type, Content-Encoding and
+// content. A link must also be served as its target is, by ETag, as the CDN
+// serves a link with its target's content rather than telling its target.
+//
+// This is how --exodus-skip-unchanged tells there's nothing to commit.
+func isUnchanged(ctx context.Context, cdnURL string, items []walk.SyncItem, publishItems []gw.ItemInput, threads int) bool {
+	logger := log.FromContext(ctx)
+	checker := &unchangedChecker{
+		client: &http.Client{},
+		cdnURL: cdnURL,
+		md5s:   make(map[string]string),
+	}
+
+	sample, ok := unchangedSample(items, publishItems)
+	if !ok || len(sample) == 0 {
+		return false
+	}
+
+	// The first changed item makes the outcome of the remaining checks moot.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	changed := make([]bool, len(sample))
+	etags := make([]string, len(sample))
+
+	jobs := make(chan int, len(sample))
+	for i := range sample {
+		jobs <- i
+	}
+	close(jobs)
+
+	syncutil.RunWithGroup(max(threads, 1),
+		func() {
+			for i := range jobs {
+				if ctx.Err() != nil {
+					changed[i] = true
+					return
+				}
+				etag, err := checker.check(ctx, sample[i])
+				if err != nil {
+					logger.F("uri", sample[i].uri, "error", err).Debug("Item differs from published item")
+					changed[i] = true
+					cancel()
+				}
+				etags[i] = etag
+			}
+		},
+		func() {},
+	)
+
+	for _, c := range changed {
+		if c {
+			return false
+		}
+	}
+
+	byURI := make(map[string]string, len(sample))
+	for i, item := range sample {
+		byURI[item.uri] = etags[i]
+	}
+	for i, item := range sample {
+		if item.linkTo != "" && etags[i] != byURI[item.linkTo] {
+			logger.F("uri", item.uri, "link_to", item.linkTo).Debug("Link isn't served as its target")
+			return false
+		}
+	}
+
+	return true
+}
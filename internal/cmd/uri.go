@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+)
+
+// Rules supported in the 'urinormalize' configuration, in the order in which
+// they're applied.
+var uriNormalizeRules = []string{"collapseslashes", "lowercase", "escape"}
+
+var duplicateSlashes = regexp.MustCompile("/{2,}")
+
+// uriNormalizer applies the configured normalization rules to web URIs, so that
+// the same source always produces the same canonical URI on the CDN.
+type uriNormalizer struct {
+	rules map[string]bool
+}
+
+func newURINormalizer(rules []string) (uriNormalizer, error) {
+	out := uriNormalizer{rules: make(map[string]bool)}
+
+	for _, rule := range rules {
+		if !contains(uriNormalizeRules, rule) {
+			return out, fmt.Errorf("unknown URI normalization rule '%s' (valid rules: %s)",
+				rule, strings.Join(uriNormalizeRules, ", "))
+		}
+		out.rules[rule] = true
+	}
+
+	return out, nil
+}
+
+// normalize returns the normalized form of a web URI, or an error if the URI
+// contains '..' segments.
+//
+// '..' segments are always rejected regardless of configured rules, as they
+// would allow a URI to escape from the intended destination tree.
+func (n uriNormalizer) normalize(uri string) (string, error) {
+	for _, segment := range strings.Split(uri, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("refusing to publish to '%s': path contains '..'", uri)
+		}
+	}
+
+	if n.rules["collapseslashes"] {
+		uri = duplicateSlashes.ReplaceAllString(uri, "/")
+	}
+
+	if n.rules["lowercase"] {
+		uri = strings.ToLower(uri)
+	}
+
+	if n.rules["escape"] {
+		segments := strings.Split(uri, "/")
+		for i := range segments {
+			segments[i] = url.PathEscape(segments[i])
+		}
+		uri = strings.Join(segments, "/")
+	}
+
+	return uri, nil
+}
+
+// withinDest returns true if uri refers to destURI or any path beneath it.
+func withinDest(uri string, destURI string) bool {
+	destURI = path.Clean(destURI)
+	uri = path.Clean(uri)
+
+	switch destURI {
+	case ".":
+		// Relative destination at the root of the environment.
+		return !path.IsAbs(uri) && uri != ".." && !strings.HasPrefix(uri, "../")
+	case "/":
+		return path.IsAbs(uri)
+	}
+
+	return uri == destURI || strings.HasPrefix(uri, destURI+"/")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// checkURILengths returns an error listing the web URIs of any items longer
+// than max bytes, if max is positive.
+func checkURILengths(publishItems []gw.ItemInput, max int) error {
+	if max <= 0 {
+		return nil
+	}
+
+	var tooLong []string
+	for _, item := range publishItems {
+		if len(item.WebURI) > max {
+			tooLong = append(tooLong, fmt.Sprintf("%s (%d bytes)", item.WebURI, len(item.WebURI)))
+		}
+	}
+
+	if len(tooLong) > 0 {
+		return fmt.Errorf("%d web URI(s) longer than %d bytes: %s",
+			len(tooLong), max, strings.Join(tooLong, ", "))
+	}
+	return nil
+}
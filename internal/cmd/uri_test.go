@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestURINormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    []string
+		input    string
+		expected string
+	}{
+		{"no rules", nil, "/dest//Some File", "/dest//Some File"},
+		{"collapseslashes", []string{"collapseslashes"}, "/dest//a///b", "/dest/a/b"},
+		{"lowercase", []string{"lowercase"}, "/Dest/README.TXT", "/dest/readme.txt"},
+		{"escape spaces", []string{"escape"}, "/dest/some file", "/dest/some%20file"},
+		{"escape unicode", []string{"escape"}, "/dest/ünï", "/dest/%C3%BCn%C3%AF"},
+		{"escape reserved", []string{"escape"}, "/dest/a?b#c%d", "/dest/a%3Fb%23c%25d"},
+		{"all rules", []string{"escape", "lowercase", "collapseslashes"},
+			"/Dest//Ünï File", "/dest/%C3%BCn%C3%AF%20file"},
+		{"dot segments allowed", []string{"escape"}, "/dest/./.hidden/a..b", "/dest/./.hidden/a..b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := newURINormalizer(tt.rules)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, err := n.normalize(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("normalize(%q) = %q, expected %q", tt.input, got, tt.expected)
+			}
+
+			// Normalization should be stable.
+			again, err := n.normalize(tt.input)
+			if err != nil || again != got {
+				t.Errorf("normalization not stable: %q vs %q (err %v)", got, again, err)
+			}
+		})
+	}
+}
+
+func TestURINormalizeRejectsTraversal(t *testing.T) {
+	for _, rules := range [][]string{nil, {"collapseslashes", "lowercase", "escape"}} {
+		n, err := newURINormalizer(rules)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for _, uri := range []string{"../dest", "/dest/../../etc/passwd", "/dest/.."} {
+			if got, err := n.normalize(uri); err == nil {
+				t.Errorf("normalize(%q) with rules %v unexpectedly succeeded: %q", uri, rules, got)
+			}
+		}
+	}
+}
+
+func TestURINormalizeUnknownRule(t *testing.T) {
+	_, err := newURINormalizer([]string{"lowercase", "uppercase"})
+	if err == nil {
+		t.Fatal("unexpectedly accepted unknown rule")
+	}
+
+	expected := "unknown URI normalization rule 'uppercase' (valid rules: collapseslashes, lowercase, escape)"
+	if err.Error() != expected {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWithinDest(t *testing.T) {
+	tests := []struct {
+		uri      string
+		dest     string
+		expected bool
+	}{
+		{"/dest/file", "/dest", true},
+		{"/dest/sub/file", "/dest/", true},
+		{"/dest", "/dest", true},
+		{"/destination/file", "/dest", false},
+		{"/other/file", "/dest", false},
+		{"/dest/../etc/passwd", "/dest", false},
+		{"/", "/dest", false},
+		{"/anything", "/", true},
+		{"relative", "/", false},
+		{"dest/file", "dest", true},
+		{"file", "", true},
+		{"../file", "", false},
+		{"/file", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := withinDest(tt.uri, tt.dest); got != tt.expected {
+			t.Errorf("withinDest(%q, %q) = %v, expected %v", tt.uri, tt.dest, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+// verifyItem is a published item which may be checked against the CDN.
+type verifyItem struct {
+	uri  string
+	key  string
+	size int64
+}
+
+// verifySample returns up to count randomly chosen items from those which
+// were published, excluding links.
+func verifySample(items []walk.SyncItem, publishItems []gw.ItemInput, count int) []verifyItem {
+	var candidates []verifyItem
+
+	for i, item := range publishItems {
+		if item.ObjectKey == "" {
+			continue
+		}
+		candidates = append(candidates, verifyItem{item.WebURI, item.ObjectKey, items[i].Info.Size()})
+	}
+
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+
+	if count < len(candidates) {
+		candidates = candidates[:count]
+	}
+	return candidates
+}
+
+// verifyOne fetches a single item from the CDN and checks that its content
+// matches what was published.
+func verifyOne(ctx context.Context, client *http.Client, cdnURL string, item verifyItem) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", cdnURL+item.uri, nil)
+	if err != nil {
+		return err
+	}
+
+	// The blob is what's verified, so the content mustn't be decompressed.
+	// Otherwise, Go asks for gzip and then transparently decompresses a
+	// response with Content-Encoding gzip, such as for an item published
+	// with a content encoding.
+	req.Header.Set("Accept-Encoding", "identity")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s", resp.Status)
+	}
+
+	// A wrong size is known up-front if the length is declared.
+	if resp.ContentLength >= 0 && resp.ContentLength != item.size {
+		return fmt.Errorf("expected %d bytes, got Content-Length %d", item.size, resp.ContentLength)
+	}
+
+	// Only a byte more than expected is read, to tell that there's too much
+	// content without fetching all of it.
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, io.LimitReader(resp.Body, item.size+1))
+	if err != nil {
+		return err
+	}
+
+	if size != item.size {
+		return fmt.Errorf("expected %d bytes, got %d", item.size, size)
+	}
+	if key := fmt.Sprintf("%x", hasher.Sum(nil)); key != item.key {
+		return fmt.Errorf("expected checksum %s, got %s", item.key, key)
+	}
+
+	return nil
+}
+
+// verifyPublished checks each of the given items against the CDN, returning
+// an error listing every item which failed verification.
+func verifyPublished(ctx context.Context, cdnURL string, sample []verifyItem) error {
+	logger := log.FromContext(ctx)
+	client := &http.Client{}
+
+	var failed []string
+
+	for _, item := range sample {
+		err := verifyOne(ctx, client, cdnURL, item)
+		if err != nil {
+			logger.F("uri", item.uri, "error", err).Error("Verification failed")
+			failed = append(failed, item.uri)
+			continue
+		}
+		logger.F("uri", item.uri).Debug("Verified")
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d item(s) failed verification: %s",
+			len(failed), len(sample), strings.Join(failed, ", "))
+	}
+
+	return nil
+}
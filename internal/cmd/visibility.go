@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// visibilityRule is a single rule of --exodus-visibility, setting the
+// visibility of files matching either a permission test or a glob pattern.
+type visibilityRule struct {
+	// The permission bits tested, and whether they must all be set (for
+	// WHO+PERMS) or any be unset (for WHO-PERMS).
+	perm    fs.FileMode
+	permSet bool
+
+	// Otherwise, a glob pattern as for path.Match.
+	glob string
+
+	visibility string
+}
+
+// visibilityRules set the visibility of published files, as given by
+// --exodus-visibility, in the way --chmod would set their permissions.
+type visibilityRules []visibilityRule
+
+// permTest matches the permission tests of visibility rules, e.g. "o-r".
+var permTest = regexp.MustCompile(`^([ugoa]+)([+-])([rwx]+)$`)
+
+func newVisibilityRules(rules []string) (visibilityRules, error) {
+	out := visibilityRules{}
+
+	for _, rule := range rules {
+		i := strings.LastIndex(rule, "=")
+		if i < 1 {
+			return nil, fmt.Errorf("invalid visibility rule '%s', must be MATCH=VISIBILITY", rule)
+		}
+		match, visibility := rule[:i], rule[i+1:]
+
+		if visibility != "public" && visibility != "restricted" {
+			return nil, fmt.Errorf("invalid visibility '%s' in rule '%s', must be public or restricted", visibility, rule)
+		}
+
+		r := visibilityRule{visibility: visibility}
+		if m := permTest.FindStringSubmatch(match); m != nil {
+			r.perm = permBits(m[1], m[3])
+			r.permSet = m[2] == "+"
+		} else if _, err := path.Match(match, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern in visibility rule '%s': %w", rule, err)
+		} else {
+			r.glob = match
+		}
+
+		out = append(out, r)
+	}
+
+	return out, nil
+}
+
+// permBits returns the permission bits for the given classes of users
+// ("u", "g", "o" or "a" for all) and permissions ("r", "w" or "x").
+func permBits(who string, perms string) fs.FileMode {
+	var bits fs.FileMode
+	for _, p := range perms {
+		bits |= map[rune]fs.FileMode{'r': 4, 'w': 2, 'x': 1}[p]
+	}
+
+	var out fs.FileMode
+	for _, w := range who {
+		switch w {
+		case 'u':
+			out |= bits << 6
+		case 'g':
+			out |= bits << 3
+		case 'o':
+			out |= bits
+		case 'a':
+			out |= bits<<6 | bits<<3 | bits
+		}
+	}
+	return out
+}
+
+// match returns the visibility of the first rule matching a file, at relPath
+// within SRC with the given mode, or an empty string if none match.
+//
+// As with --exclude, a pattern without a "/" is matched against the file's
+// name, and otherwise against relPath.
+func (r visibilityRules) match(relPath string, mode fs.FileMode) string {
+	for _, rule := range r {
+		var matched bool
+		switch {
+		case rule.glob == "":
+			if rule.permSet {
+				matched = mode.Perm()&rule.perm == rule.perm
+			} else {
+				matched = mode.Perm()&rule.perm != rule.perm
+			}
+		case strings.Contains(rule.glob, "/"):
+			matched, _ = path.Match(strings.TrimPrefix(rule.glob, "/"), relPath)
+		default:
+			matched, _ = path.Match(rule.glob, path.Base(relPath))
+		}
+
+		if matched {
+			return rule.visibility
+		}
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestVisibilityRules(t *testing.T) {
+	rules, err := newVisibilityRules([]string{
+		"*.iso=restricted",
+		"/public/*=public",
+		"o-r=restricted",
+		"ug+x=public",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		relPath  string
+		mode     fs.FileMode
+		expected string
+	}{
+		// Patterns without a slash match the name, in any directory.
+		{"images/boot.iso", 0644, "restricted"},
+		// First match wins.
+		{"public/boot.iso", 0644, "restricted"},
+		{"public/readme", 0600, "public"},
+		{"public/sub/readme", 0600, "restricted"},
+		{"private/readme", 0640, "restricted"},
+		{"bin/tool", 0775, "public"},
+		{"bin/script", 0745, ""},
+		{"readme", 0644, ""},
+	}
+
+	for _, tt := range tests {
+		if got := rules.match(tt.relPath, tt.mode); got != tt.expected {
+			t.Errorf("match(%q, %v) = %q, expected %q", tt.relPath, tt.mode, got, tt.expected)
+		}
+	}
+}
+
+func TestVisibilityRulesPermTests(t *testing.T) {
+	tests := []struct {
+		rule string
+		mode fs.FileMode
+		want bool
+	}{
+		{"o+r=public", 0604, true},
+		{"o+r=public", 0640, false},
+		{"a+r=public", 0444, true},
+		{"a+r=public", 0440, false},
+		// Lacking any of the bits.
+		{"go-r=restricted", 0640, true},
+		{"go-r=restricted", 0644, false},
+		{"u-wx=restricted", 0644, true},
+		{"u-wx=restricted", 0700, false},
+	}
+
+	for _, tt := range tests {
+		rules, err := newVisibilityRules([]string{tt.rule})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := rules.match("file", tt.mode) != ""; got != tt.want {
+			t.Errorf("%s on %v: matched %v, expected %v", tt.rule, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestVisibilityRulesInvalid(t *testing.T) {
+	tests := []struct {
+		rule string
+		err  string
+	}{
+		{"o-r", "must be MATCH=VISIBILITY"},
+		{"=public", "must be MATCH=VISIBILITY"},
+		{"o-r=hidden", "invalid visibility 'hidden'"},
+		{"[abc=public", "invalid pattern"},
+	}
+
+	for _, tt := range tests {
+		_, err := newVisibilityRules([]string{tt.rule})
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: did not get expected error, got: %v", tt.rule, err)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+// Suffixes of the roles exodus-gw grants within each environment, as in
+// "live-publisher".
+var envRoleSuffixes = []string{"-publisher", "-blob-uploader"}
+
+// identity is who exodus-gw authenticates a client as, written by
+// --exodus-whoami.
+type identity struct {
+	// The environment asked.
+	Env string `json:"env"`
+
+	Authenticated bool `json:"authenticated"`
+
+	// Service account of the client, and the user if any.
+	Client string `json:"client,omitempty"`
+	User   string `json:"user,omitempty"`
+
+	// Every role granted, and the environments to which any of them apply.
+	Roles        []string `json:"roles"`
+	Environments []string `json:"environments"`
+}
+
+// whoAmIResponse is the part of the response from exodus-gw's /whoami
+// endpoint describing the caller.
+type whoAmIResponse struct {
+	Client struct {
+		Roles            []string `json:"roles"`
+		Authenticated    bool     `json:"authenticated"`
+		ServiceAccountID string   `json:"serviceAccountId"`
+	} `json:"client"`
+	User struct {
+		Roles            []string `json:"roles"`
+		Authenticated    bool     `json:"authenticated"`
+		InternalUsername string   `json:"internalUsername"`
+	} `json:"user"`
+}
+
+// newIdentity returns the identity described by a response from /whoami.
+func newIdentity(env string, raw map[string]interface{}) (identity, error) {
+	out := identity{Env: env, Roles: []string{}, Environments: []string{}}
+
+	// The response is decoded generically by the client, for diagnostics.
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return out, err
+	}
+	var resp whoAmIResponse
+	if err := json.Unmarshal(encoded, &resp); err != nil {
+		return out, fmt.Errorf("unexpected response from exodus-gw: %w", err)
+	}
+
+	out.Authenticated = resp.Client.Authenticated || resp.User.Authenticated
+	out.Client = resp.Client.ServiceAccountID
+	out.User = resp.User.InternalUsername
+
+	roles := make(map[string]bool)
+	envs := make(map[string]bool)
+	for _, role := range append(resp.Client.Roles, resp.User.Roles...) {
+		roles[role] = true
+		for _, suffix := range envRoleSuffixes {
+			if name := strings.TrimSuffix(role, suffix); name != role && name != "" {
+				envs[name] = true
+			}
+		}
+	}
+	for role := range roles {
+		out.Roles = append(out.Roles, role)
+	}
+	for name := range envs {
+		out.Environments = append(out.Environments, name)
+	}
+	sort.Strings(out.Roles)
+	sort.Strings(out.Environments)
+
+	return out, nil
+}
+
+// whoAmI writes who the configured credentials authenticate as with the
+// exodus-gw environment of cfg to stdout for --exodus-whoami, and returns
+// the exit code. Nothing is published.
+func whoAmI(ctx context.Context, cfg conf.Config, args args.Config) int {
+	logger := log.FromContext(ctx)
+
+	gwClient, err := ext.gw.NewClient(ctx, cfg)
+	if err != nil {
+		logger.F("error", err).Error("can't initialize exodus-gw client")
+		return 101
+	}
+
+	raw, err := gwClient.WhoAmI(ctx)
+	if err != nil {
+		logger.F("env", cfg.GwEnv(), "error", err).Error("can't authenticate with exodus-gw")
+		return 68
+	}
+
+	who, err := newIdentity(cfg.GwEnv(), raw)
+	if err != nil {
+		logger.F("env", cfg.GwEnv(), "error", err).Error("can't authenticate with exodus-gw")
+		return 68
+	}
+
+	if args.ListFormat == "json" {
+		err = writeJSON(stdout, who)
+	} else {
+		err = writeIdentityTable(stdout, who)
+	}
+	if err != nil {
+		logger.F("error", err).Error("can't write identity")
+		return 68
+	}
+
+	if !who.Authenticated {
+		logger.F("env", cfg.GwEnv()).Error("Not authenticated with exodus-gw")
+		return 68
+	}
+
+	// A publish would fail part way through; better to know now.
+	authorized := false
+	for _, name := range who.Environments {
+		authorized = authorized || name == cfg.GwEnv()
+	}
+	if !authorized {
+		logger.F("env", cfg.GwEnv(), "environments", strings.Join(who.Environments, ",")).Warn(
+			"Not authorized for the exodus-gw environment")
+	}
+
+	return 0
+}
+
+func writeIdentityTable(w io.Writer, who identity) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "ENV\t%s\n", who.Env)
+	fmt.Fprintf(tw, "AUTHENTICATED\t%v\n", who.Authenticated)
+	if who.Client != "" {
+		fmt.Fprintf(tw, "CLIENT\t%s\n", who.Client)
+	}
+	if who.User != "" {
+		fmt.Fprintf(tw, "USER\t%s\n", who.User)
+	}
+	fmt.Fprintf(tw, "ROLES\t%s\n", strings.Join(who.Roles, ", "))
+	fmt.Fprintf(tw, "ENVIRONMENTS\t%s\n", strings.Join(who.Environments, ", "))
+
+	return tw.Flush()
+}
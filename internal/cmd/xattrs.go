@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+var (
+	// A file doesn't have an extended attribute of the requested name.
+	errXattrUnset = errors.New("extended attribute not set")
+
+	// A file is on a filesystem without support for extended attributes.
+	errXattrUnsupported = errors.New("extended attributes not supported")
+)
+
+// readXattrs returns the extended attributes of the file at path with the
+// given names, for --exodus-capture-xattrs. Attributes which aren't set are
+// left out, as are those whose values aren't valid UTF-8, which couldn't be
+// sent to exodus-gw intact.
+//
+// Files on filesystems without support for extended attributes have none,
+// rather than being an error.
+func readXattrs(path string, names []string) (map[string]string, error) {
+	out := map[string]string{}
+
+	for _, name := range names {
+		value, err := getXattr(path, name)
+		if errors.Is(err, errXattrUnset) {
+			continue
+		}
+		if errors.Is(err, errXattrUnsupported) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading extended attribute %s of %s: %w", name, path, err)
+		}
+		if utf8.Valid(value) {
+			out[name] = string(value)
+		}
+	}
+
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+func getXattr(path string, name string) ([]byte, error) {
+	for {
+		size, err := unix.Getxattr(path, name, nil)
+		if err == nil {
+			buf := make([]byte, size)
+			size, err = unix.Getxattr(path, name, buf)
+			if err == nil {
+				return buf[:size], nil
+			}
+		}
+
+		switch {
+		case errors.Is(err, unix.ERANGE):
+			// The attribute grew since its size was read.
+			continue
+		case errors.Is(err, unix.ENODATA):
+			return nil, errXattrUnset
+		case errors.Is(err, unix.ENOTSUP):
+			return nil, errXattrUnsupported
+		}
+		return nil, err
+	}
+}
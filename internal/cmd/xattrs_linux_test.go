@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"golang.org/x/sys/unix"
+)
+
+// Sets an extended attribute of the file at path, skipping the test where
+// the filesystem doesn't support setting it.
+func setXattr(t *testing.T, path string, name string, value string) {
+	err := unix.Setxattr(path, name, []byte(value), 0)
+	if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EPERM) {
+		t.Skipf("can't set extended attributes here: %v", err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadXattrs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	setXattr(t, path, "user.origin", "build-1")
+	setXattr(t, path, "user.other", "ignored")
+	setXattr(t, path, "user.binary", "\xff\xfe")
+
+	// Only the named attributes are read, leaving out those which aren't set
+	// or aren't valid UTF-8.
+	got, err := readXattrs(path, []string{"user.origin", "user.missing", "user.binary"})
+	if err != nil {
+		t.Fatalf("readXattrs failed, err = %v", err)
+	}
+	if !reflect.DeepEqual(got, map[string]string{"user.origin": "build-1"}) {
+		t.Errorf("unexpected attributes %v", got)
+	}
+
+	// A file with none of the attributes has no metadata at all.
+	got, err = readXattrs(path, []string{"user.missing"})
+	if got != nil || err != nil {
+		t.Errorf("unexpected attributes %v, err = %v", got, err)
+	}
+
+	if _, err := readXattrs(filepath.Join(path, "missing"), []string{"user.origin"}); err == nil {
+		t.Error("readXattrs unexpectedly succeeded for missing file")
+	}
+}
+
+func TestMainSyncCaptureXattrs(t *testing.T) {
+	srcPath := t.TempDir()
+	for _, name := range []string{"tagged", "untagged"} {
+		if err := os.WriteFile(filepath.Join(srcPath, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setXattr(t, filepath.Join(srcPath, "tagged"), "user.origin", "build-1")
+	setXattr(t, filepath.Join(srcPath, "tagged"), "user.other", "ignored")
+
+	for _, capture := range []bool{false, true} {
+		t.Run(fmt.Sprint(capture), func(t *testing.T) {
+			SetConfig(t, CONFIG+"xattrs: [user.origin]\n")
+			ctrl := MockController(t)
+
+			mockGw := gw.NewMockInterface(ctrl)
+			ext.gw = mockGw
+
+			client := FakeClient{blobs: map[string]string{}}
+			mockGw.EXPECT().NewClient(gomock.Any(), EnvMatcher{"best-env"}).Return(&client, nil)
+
+			argv := []string{"rsync"}
+			if capture {
+				argv = append(argv, "--exodus-capture-xattrs")
+			}
+			argv = append(argv, srcPath+"/", "exodus:/dest")
+
+			if got := Main(argv); got != 0 {
+				t.Fatal("returned incorrect exit code", got)
+			}
+
+			metadata := map[string]map[string]string{}
+			for _, item := range client.publishes[0].items {
+				metadata[item.WebURI] = item.Metadata
+			}
+
+			want := map[string]map[string]string{"/dest/tagged": nil, "/dest/untagged": nil}
+			if capture {
+				want["/dest/tagged"] = map[string]string{"user.origin": "build-1"}
+			}
+			if !reflect.DeepEqual(metadata, want) {
+				t.Errorf("got metadata %v, want %v", metadata, want)
+			}
+		})
+	}
+}
+
+func TestMainSyncCaptureXattrsUnconfigured(t *testing.T) {
+	SetConfig(t, CONFIG+"loglevel: none\n")
+	logs := CaptureLogger(t)
+
+	got := Main([]string{"rsync", "--exodus-capture-xattrs", ".", "exodus:/dest"})
+
+	if got != 23 {
+		t.Error("returned incorrect exit code", got)
+	}
+	if FindEntry(logs, "--exodus-capture-xattrs requires xattrs in configuration") == nil {
+		t.Error("missing expected log message")
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package cmd
+
+func getXattr(path string, name string) ([]byte, error) {
+	return nil, errXattrUnsupported
+}
@@ -28,9 +28,24 @@ type Config interface {
 	// Path to private key used to authenticate with exodus-gw.
 	GwKey() string
 
+	// Shell command whose output is the PEM-encoded certificate used to
+	// authenticate with exodus-gw; overrides GwCert if set.
+	GwCertCommand() string
+
+	// Shell command whose output is the PEM-encoded private key used to
+	// authenticate with exodus-gw; overrides GwKey if set.
+	GwKeyCommand() string
+
+	// Number of days before the certificate used to authenticate with
+	// exodus-gw expires within which --exodus-check-cert warns of it.
+	GwCertExpiryWarning() int
+
 	// Base URL of exodus-gw service in use.
 	GwURL() string
 
+	// Base URL of the CDN serving published content.
+	CdnURL() string
+
 	// exodus-gw environment in use (e.g. "live").
 	GwEnv() string
 
@@ -38,8 +53,43 @@ type Config interface {
 	GwPollInterval() int
 
 	// Max number of items to include in a single HTTP request to exodus-gw.
+	// If GwBatchSizeAuto, this is the initial number of items.
 	GwBatchSize() int
 
+	// Adapt the number of items per request to exodus-gw according to how
+	// quickly requests complete.
+	GwBatchSizeAuto() bool
+
+	// Bounds on the number of items per request if GwBatchSizeAuto.
+	GwBatchSizeMin() int
+	GwBatchSizeMax() int
+
+	// Max size in bytes of the items encoded in a single HTTP request to
+	// exodus-gw, whatever the number of items; 0 for no limit.
+	GwMaxBatchBytes() int
+
+	// Version of the schema of items added onto a publish, for compatibility
+	// with versions of exodus-gw.
+	GwItemSchema() int
+
+	// Whether exodus-gw supports the "no_replace" field of items, refusing
+	// to replace published items having it set.
+	GwNoReplace() bool
+
+	// Whether exodus-gw supports the "cache_control" field of items, setting
+	// the Cache-Control with which they're served.
+	GwCacheControl() bool
+
+	// Whether requests to exodus-gw which may be retried carry an
+	// idempotency key, letting exodus-gw recognise the retries; true unless
+	// disabled for versions of exodus-gw mishandling the key.
+	GwUseIdempotencyKeys() bool
+
+	// How redirects from exodus-gw are handled: "follow" (default) those
+	// keeping the method and body of the request, or "none" to fail rather
+	// than follow any.
+	GwRedirects() string
+
 	// Commit mode for publishes.
 	GwCommit() string
 
@@ -49,6 +99,35 @@ type Config interface {
 	// Maximum backoff between retried HTTP requests, in milliseconds.
 	GwMaxBackoff() int
 
+	// Timeout for each attempt of a request reading from exodus-gw,
+	// in milliseconds; 0 for no timeout.
+	GwReadTimeout() int
+
+	// Maximum attempts for requests reading from exodus-gw.
+	GwReadMaxAttempts() int
+
+	// Timeout for each attempt of a request writing to exodus-gw (e.g.
+	// adding items to a publish), in milliseconds; 0 for no timeout.
+	GwWriteTimeout() int
+
+	// Maximum attempts for requests writing to exodus-gw.
+	GwWriteMaxAttempts() int
+
+	// Timeout for each attempt of a request committing a publish,
+	// in milliseconds; 0 for no timeout.
+	GwCommitTimeout() int
+
+	// Maximum attempts for requests committing a publish.
+	GwCommitMaxAttempts() int
+
+	// HTTP statuses of responses from exodus-gw which are retried, in
+	// addition to the built-in 500, 502, 503 and 504.
+	GwRetryStatuses() []int
+
+	// HTTP statuses of responses from exodus-gw which are never retried,
+	// overriding both the built-in statuses and GwRetryStatuses.
+	GwPermanentStatuses() []int
+
 	// Execution mode for rsync.
 	RsyncMode() string
 
@@ -69,6 +148,186 @@ type Config interface {
 
 	// Number of threads used to upload files to the CDN.
 	UploadThreads() int
+
+	// Maximum number of attempts to upload each file, independent of the
+	// retries of each S3 request.
+	UploadMaxAttempts() int
+
+	// Maximum time (in milliseconds) to wait between attempts to upload
+	// a file.
+	UploadMaxBackoff() int
+
+	// Bounds on the number of uploads at once, as reduced while S3 is
+	// throttling requests (or with --exodus-throttle-on-error, while requests
+	// are failing) and ramped up again afterward. A maximum of 0 means
+	// UploadThreads.
+	ConcurrencyMin() int
+	ConcurrencyMax() int
+
+	// Normalization rules applied to the web URI of each published item.
+	URINormalize() []string
+
+	// Reject items whose web URIs differ only in case, as they collide on
+	// a CDN serving paths case-insensitively.
+	URICaseInsensitive() bool
+
+	// Rules overriding the content type and encoding of published items.
+	ContentRules() []ContentRule
+
+	// Rules publishing aliases, such as a "latest" path, linking to
+	// published items.
+	Aliases() []AliasRule
+
+	// Rules setting the Cache-Control of published items.
+	CacheControlRules() []CacheControlRule
+
+	// Cache-Control of published items matching no rule; empty for none.
+	CacheControlDefault() string
+
+	// Sniff the content type of extensionless files which can't otherwise
+	// be classified.
+	MIMESniff() bool
+
+	// Skip (with a warning) files having no content.
+	SkipEmptyFiles() bool
+
+	// Tags applied to each blob uploaded to S3.
+	UploadTags() map[string]string
+
+	// Storage class of each blob uploaded to S3; empty for the default.
+	UploadStorageClass() string
+
+	// Server-side encryption algorithm of each blob uploaded to S3, such as
+	// "AES256" or "aws:kms"; empty for the default.
+	UploadSSE() string
+
+	// ID of the KMS key for server-side encryption of each blob uploaded
+	// to S3; empty for the default.
+	UploadSSEKMSKeyID() string
+
+	// Prefix of the key of each blob in the bucket, e.g. "blobs/", which is
+	// prepended to the sha256 checksum of its content; empty for the root.
+	BlobKeyPrefix() string
+
+	// How blobs are uploaded: "gw" (default) via exodus-gw, which manages
+	// access to S3, or "direct" to S3 using credentials from the standard
+	// AWS credential chain.
+	S3Access() string
+
+	// Bucket to which blobs are uploaded with S3Access "direct"; empty for
+	// the bucket named after GwEnv.
+	S3Bucket() string
+
+	// Path of a file recording blobs known to be present in S3, so they
+	// aren't checked again in later runs; empty to not record them.
+	BlobCache() string
+
+	// Maximum age in seconds of an entry in BlobCache before the blob
+	// is checked again.
+	BlobCacheMaxAge() int
+
+	// Path of a file recording a fingerprint of the items of the last
+	// publish committed to each destination, so that publishing the same
+	// items again is skipped; empty to always publish.
+	PublishState() string
+
+	// Path of a file to which a JSON line is appended recording each publish
+	// committed, for auditing; empty to not record them.
+	AuditLog() string
+
+	// Directory for temporary files, such as content spooled to disk for
+	// upload; defaults to $TMPDIR, or /tmp.
+	TempDir() string
+
+	// Minimum free space in bytes to be kept on the filesystem of TempDir,
+	// checked before uploading and before spooling each file; 0 for no
+	// minimum.
+	TempMinFree() int64
+
+	// URL of a proxy used for requests to exodus-gw; empty to connect directly.
+	GwProxy() string
+
+	// URL of a proxy used for S3 requests (uploads); empty to connect directly.
+	S3Proxy() string
+
+	// Hosts for which GwProxy and S3Proxy are bypassed.
+	NoProxy() []string
+
+	// Timeout for establishing each connection to exodus-gw or S3,
+	// in milliseconds.
+	DialTimeout() int
+
+	// Timeout for the TLS handshake of each connection to exodus-gw or S3,
+	// in milliseconds.
+	TLSHandshakeTimeout() int
+
+	// Names of the only TLS cipher suites used for connections to exodus-gw
+	// or S3 with TLS 1.2 or earlier; by default, those chosen by Go.
+	TLSCipherSuites() []string
+
+	// Difference between the local clock and that of exodus-gw or S3 beyond
+	// which a warning is logged, in milliseconds.
+	MaxClockSkew() int
+
+	// Maximum total size in bytes of the files in a single publish;
+	// 0 for no limit.
+	MaxPublishBytes() int64
+
+	// Maximum number of items in a single publish; 0 for no limit.
+	MaxPublishItems() int
+
+	// Maximum length in bytes of the web URI of any item; 0 for no limit.
+	MaxURILength() int
+
+	// Patterns of files never published, as for --exclude. These apply
+	// before any filters from the command line.
+	Exclude() []string
+
+	// Patterns of files published despite matching Exclude, as for --include.
+	Include() []string
+
+	// Names of the extended attributes of source files attached to
+	// published items as metadata, with --exodus-capture-xattrs.
+	Xattrs() []string
+
+	// Regular expression matching the names of exodus-gw environments, such
+	// as production, to which publishing needs confirmation.
+	ProtectedEnvs() string
+
+	// Where a lock is held on each destination while syncing to it, so that
+	// syncs to the same destination don't race: a directory of lock files,
+	// or the URL of an HTTP lock service. Empty for no locking.
+	PublishLock() string
+
+	// How long (in seconds) to wait for a lock held by another sync before
+	// failing; 0 to fail at once.
+	PublishLockWait() int
+
+	// Every setting in effect, and where it came from.
+	Settings() []Setting
+}
+
+// ContentRule sets the content type and/or content encoding of each published
+// item having a web URI matching a regular expression.
+type ContentRule struct {
+	Pattern         string `yaml:"pattern"`
+	ContentType     string `yaml:"contenttype"`
+	ContentEncoding string `yaml:"contentencoding"`
+}
+
+// AliasRule publishes a link to each published item having a web URI matching
+// a regular expression, at the web URI obtained by replacing the match with
+// Alias.
+type AliasRule struct {
+	Pattern string `yaml:"pattern"`
+	Alias   string `yaml:"alias"`
+}
+
+// CacheControlRule sets the Cache-Control with which each published item
+// having a web URI matching a glob pattern is served.
+type CacheControlRule struct {
+	Pattern      string `yaml:"pattern"`
+	CacheControl string `yaml:"cachecontrol"`
 }
 
 // EnvironmentConfig provides configuration specific to one environment.
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/adrg/xdg"
@@ -40,15 +41,13 @@ func loadFromPath(path string, args args.Config) (*globalConfig, error) {
 		return &globalConfig{}, fmt.Errorf("can't parse %s: %w", path, err)
 	}
 
-	// A few vars support env var expansion for convenience
-	out.GwCertRaw = os.ExpandEnv(out.GwCertRaw)
-	out.GwKeyRaw = os.ExpandEnv(out.GwKeyRaw)
-	out.GwURLRaw = normalizeURL(os.ExpandEnv(out.GwURLRaw))
-	out.GwEnvRaw = os.ExpandEnv(out.GwEnvRaw)
+	out.resolve(args)
 
-	// Command-line arg overrides config from file
-	if args.Commit != "" {
-		out.GwCommitRaw = args.Commit
+	if _, err := CipherSuiteIDs(out.TLSCipherSuites()); err != nil {
+		return nil, fmt.Errorf("tlsciphersuites: %w", err)
+	}
+	if err := checkUploadSSE(out.UploadSSERaw); err != nil {
+		return nil, fmt.Errorf("uploadsse: %w", err)
 	}
 
 	// Fill in the Environment parent references
@@ -56,16 +55,7 @@ func loadFromPath(path string, args args.Config) (*globalConfig, error) {
 	for i := range out.EnvironmentsRaw {
 		env := &out.EnvironmentsRaw[i]
 
-		// A few vars support env var expansion for convenience
-		env.GwCertRaw = os.ExpandEnv(env.GwCertRaw)
-		env.GwKeyRaw = os.ExpandEnv(env.GwKeyRaw)
-		env.GwURLRaw = normalizeURL(os.ExpandEnv(env.GwURLRaw))
-		env.GwEnvRaw = os.ExpandEnv(env.GwEnvRaw)
-
-		// Command-line arg overrides config from file
-		if args.Commit != "" {
-			env.GwCommitRaw = args.Commit
-		}
+		env.resolve(args)
 
 		if !strings.HasPrefix(env.Prefix(), out.Strip()) {
 			return nil, fmt.Errorf("cannot strip '%s' prefix from '%s'", out.Strip(), env.Prefix())
@@ -73,6 +63,12 @@ func loadFromPath(path string, args args.Config) (*globalConfig, error) {
 		if prefs[env.Prefix()] {
 			return nil, fmt.Errorf("duplicate environment definitions for '%s'", env.Prefix())
 		}
+		if _, err := CipherSuiteIDs(env.TLSCipherSuitesRaw); err != nil {
+			return nil, fmt.Errorf("tlsciphersuites of '%s': %w", env.Prefix(), err)
+		}
+		if err := checkUploadSSE(env.UploadSSERaw); err != nil {
+			return nil, fmt.Errorf("uploadsse of '%s': %w", env.Prefix(), err)
+		}
 		prefs[env.Prefix()] = true
 		out.EnvironmentsRaw[i].parent = out
 
@@ -81,6 +77,65 @@ func loadFromPath(path string, args args.Config) (*globalConfig, error) {
 	return out, nil
 }
 
+// checkUploadSSE returns an error unless sse is empty or names a server-side
+// encryption algorithm of S3, since S3 would otherwise refuse every upload.
+func checkUploadSSE(sse string) error {
+	switch sse {
+	case "", "AES256", "aws:kms":
+		return nil
+	}
+	return fmt.Errorf("unsupported server-side encryption '%s', must be 'AES256' or 'aws:kms'", sse)
+}
+
+// resolve applies env var expansion and command-line args over config from
+// file, at either the global or environment level.
+func (s *sharedConfig) resolve(args args.Config) {
+	// A few vars support env var expansion for convenience
+	s.GwCertRaw = s.expand("gwcert", s.GwCertRaw)
+	s.GwKeyRaw = s.expand("gwkey", s.GwKeyRaw)
+	s.GwURLRaw = normalizeURL(s.expand("gwurl", s.GwURLRaw))
+	s.CdnURLRaw = normalizeURL(s.expand("cdnurl", s.CdnURLRaw))
+	s.GwProxyRaw = s.expand("gwproxy", s.GwProxyRaw)
+	s.S3ProxyRaw = s.expand("s3proxy", s.S3ProxyRaw)
+	s.GwEnvRaw = s.expand("gwenv", s.GwEnvRaw)
+	s.TempDirRaw = s.expand("tempdir", s.TempDirRaw)
+	s.BlobCacheRaw = s.expand("blobcache", s.BlobCacheRaw)
+	s.PublishStateRaw = s.expand("publishstate", s.PublishStateRaw)
+	s.AuditLogRaw = s.expand("auditlog", s.AuditLogRaw)
+
+	// Command-line arg overrides config from file
+	if args.Commit != "" {
+		s.GwCommitRaw = args.Commit
+		s.setSource("gwcommit", "argument --exodus-commit")
+	}
+	if args.Diag {
+		s.setSource("diag", "argument --exodus-diag")
+	}
+	if args.MinFreeSpace != 0 {
+		s.TempMinFreeRaw = args.MinFreeSpace
+		s.setSource("tempminfree", "argument --exodus-min-free-space")
+	}
+	applyBatchSizeArg(s, args.GwBatchSize)
+}
+
+// applyBatchSizeArg applies the --exodus-gw-batch-size argument, either "auto"
+// or a fixed number of items, over config from file.
+func applyBatchSizeArg(cfg *sharedConfig, arg string) {
+	const source = "argument --exodus-gw-batch-size"
+
+	if arg == "auto" {
+		auto := true
+		cfg.GwBatchSizeAutoRaw = &auto
+		cfg.setSource("gwbatchsizeauto", source)
+	} else if size, err := strconv.Atoi(arg); err == nil {
+		auto := false
+		cfg.GwBatchSizeRaw = size
+		cfg.GwBatchSizeAutoRaw = &auto
+		cfg.setSource("gwbatchsize", source)
+		cfg.setSource("gwbatchsizeauto", source)
+	}
+}
+
 func (impl) Load(ctx context.Context, args args.Config) (GlobalConfig, error) {
 	logger := log.FromContext(ctx)
 
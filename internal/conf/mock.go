@@ -73,6 +73,160 @@ func (m *MockConfig) EXPECT() *MockConfigMockRecorder {
 	return m.recorder
 }
 
+// Aliases mocks base method.
+func (m *MockConfig) Aliases() []AliasRule {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Aliases")
+	ret0, _ := ret[0].([]AliasRule)
+	return ret0
+}
+
+// Aliases indicates an expected call of Aliases.
+func (mr *MockConfigMockRecorder) Aliases() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Aliases", reflect.TypeOf((*MockConfig)(nil).Aliases))
+}
+
+// AuditLog mocks base method.
+func (m *MockConfig) AuditLog() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuditLog")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// AuditLog indicates an expected call of AuditLog.
+func (mr *MockConfigMockRecorder) AuditLog() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuditLog", reflect.TypeOf((*MockConfig)(nil).AuditLog))
+}
+
+// BlobCache mocks base method.
+func (m *MockConfig) BlobCache() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlobCache")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// BlobCache indicates an expected call of BlobCache.
+func (mr *MockConfigMockRecorder) BlobCache() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlobCache", reflect.TypeOf((*MockConfig)(nil).BlobCache))
+}
+
+// BlobCacheMaxAge mocks base method.
+func (m *MockConfig) BlobCacheMaxAge() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlobCacheMaxAge")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// BlobCacheMaxAge indicates an expected call of BlobCacheMaxAge.
+func (mr *MockConfigMockRecorder) BlobCacheMaxAge() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlobCacheMaxAge", reflect.TypeOf((*MockConfig)(nil).BlobCacheMaxAge))
+}
+
+// BlobKeyPrefix mocks base method.
+func (m *MockConfig) BlobKeyPrefix() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlobKeyPrefix")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// BlobKeyPrefix indicates an expected call of BlobKeyPrefix.
+func (mr *MockConfigMockRecorder) BlobKeyPrefix() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlobKeyPrefix", reflect.TypeOf((*MockConfig)(nil).BlobKeyPrefix))
+}
+
+// CacheControlDefault mocks base method.
+func (m *MockConfig) CacheControlDefault() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CacheControlDefault")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// CacheControlDefault indicates an expected call of CacheControlDefault.
+func (mr *MockConfigMockRecorder) CacheControlDefault() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CacheControlDefault", reflect.TypeOf((*MockConfig)(nil).CacheControlDefault))
+}
+
+// CacheControlRules mocks base method.
+func (m *MockConfig) CacheControlRules() []CacheControlRule {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CacheControlRules")
+	ret0, _ := ret[0].([]CacheControlRule)
+	return ret0
+}
+
+// CacheControlRules indicates an expected call of CacheControlRules.
+func (mr *MockConfigMockRecorder) CacheControlRules() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CacheControlRules", reflect.TypeOf((*MockConfig)(nil).CacheControlRules))
+}
+
+// CdnURL mocks base method.
+func (m *MockConfig) CdnURL() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CdnURL")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// CdnURL indicates an expected call of CdnURL.
+func (mr *MockConfigMockRecorder) CdnURL() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CdnURL", reflect.TypeOf((*MockConfig)(nil).CdnURL))
+}
+
+// ConcurrencyMax mocks base method.
+func (m *MockConfig) ConcurrencyMax() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConcurrencyMax")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// ConcurrencyMax indicates an expected call of ConcurrencyMax.
+func (mr *MockConfigMockRecorder) ConcurrencyMax() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConcurrencyMax", reflect.TypeOf((*MockConfig)(nil).ConcurrencyMax))
+}
+
+// ConcurrencyMin mocks base method.
+func (m *MockConfig) ConcurrencyMin() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConcurrencyMin")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// ConcurrencyMin indicates an expected call of ConcurrencyMin.
+func (mr *MockConfigMockRecorder) ConcurrencyMin() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConcurrencyMin", reflect.TypeOf((*MockConfig)(nil).ConcurrencyMin))
+}
+
+// ContentRules mocks base method.
+func (m *MockConfig) ContentRules() []ContentRule {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ContentRules")
+	ret0, _ := ret[0].([]ContentRule)
+	return ret0
+}
+
+// ContentRules indicates an expected call of ContentRules.
+func (mr *MockConfigMockRecorder) ContentRules() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContentRules", reflect.TypeOf((*MockConfig)(nil).ContentRules))
+}
+
 // Diag mocks base method.
 func (m *MockConfig) Diag() bool {
 	m.ctrl.T.Helper()
@@ -87,6 +241,34 @@ func (mr *MockConfigMockRecorder) Diag() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Diag", reflect.TypeOf((*MockConfig)(nil).Diag))
 }
 
+// DialTimeout mocks base method.
+func (m *MockConfig) DialTimeout() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DialTimeout")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// DialTimeout indicates an expected call of DialTimeout.
+func (mr *MockConfigMockRecorder) DialTimeout() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DialTimeout", reflect.TypeOf((*MockConfig)(nil).DialTimeout))
+}
+
+// Exclude mocks base method.
+func (m *MockConfig) Exclude() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exclude")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// Exclude indicates an expected call of Exclude.
+func (mr *MockConfigMockRecorder) Exclude() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exclude", reflect.TypeOf((*MockConfig)(nil).Exclude))
+}
+
 // GwBatchSize mocks base method.
 func (m *MockConfig) GwBatchSize() int {
 	m.ctrl.T.Helper()
@@ -101,6 +283,62 @@ func (mr *MockConfigMockRecorder) GwBatchSize() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwBatchSize", reflect.TypeOf((*MockConfig)(nil).GwBatchSize))
 }
 
+// GwBatchSizeAuto mocks base method.
+func (m *MockConfig) GwBatchSizeAuto() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwBatchSizeAuto")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// GwBatchSizeAuto indicates an expected call of GwBatchSizeAuto.
+func (mr *MockConfigMockRecorder) GwBatchSizeAuto() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwBatchSizeAuto", reflect.TypeOf((*MockConfig)(nil).GwBatchSizeAuto))
+}
+
+// GwBatchSizeMax mocks base method.
+func (m *MockConfig) GwBatchSizeMax() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwBatchSizeMax")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwBatchSizeMax indicates an expected call of GwBatchSizeMax.
+func (mr *MockConfigMockRecorder) GwBatchSizeMax() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwBatchSizeMax", reflect.TypeOf((*MockConfig)(nil).GwBatchSizeMax))
+}
+
+// GwBatchSizeMin mocks base method.
+func (m *MockConfig) GwBatchSizeMin() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwBatchSizeMin")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwBatchSizeMin indicates an expected call of GwBatchSizeMin.
+func (mr *MockConfigMockRecorder) GwBatchSizeMin() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwBatchSizeMin", reflect.TypeOf((*MockConfig)(nil).GwBatchSizeMin))
+}
+
+// GwCacheControl mocks base method.
+func (m *MockConfig) GwCacheControl() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwCacheControl")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// GwCacheControl indicates an expected call of GwCacheControl.
+func (mr *MockConfigMockRecorder) GwCacheControl() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCacheControl", reflect.TypeOf((*MockConfig)(nil).GwCacheControl))
+}
+
 // GwCert mocks base method.
 func (m *MockConfig) GwCert() string {
 	m.ctrl.T.Helper()
@@ -115,6 +353,34 @@ func (mr *MockConfigMockRecorder) GwCert() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCert", reflect.TypeOf((*MockConfig)(nil).GwCert))
 }
 
+// GwCertCommand mocks base method.
+func (m *MockConfig) GwCertCommand() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwCertCommand")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GwCertCommand indicates an expected call of GwCertCommand.
+func (mr *MockConfigMockRecorder) GwCertCommand() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCertCommand", reflect.TypeOf((*MockConfig)(nil).GwCertCommand))
+}
+
+// GwCertExpiryWarning mocks base method.
+func (m *MockConfig) GwCertExpiryWarning() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwCertExpiryWarning")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwCertExpiryWarning indicates an expected call of GwCertExpiryWarning.
+func (mr *MockConfigMockRecorder) GwCertExpiryWarning() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCertExpiryWarning", reflect.TypeOf((*MockConfig)(nil).GwCertExpiryWarning))
+}
+
 // GwCommit mocks base method.
 func (m *MockConfig) GwCommit() string {
 	m.ctrl.T.Helper()
@@ -129,6 +395,34 @@ func (mr *MockConfigMockRecorder) GwCommit() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCommit", reflect.TypeOf((*MockConfig)(nil).GwCommit))
 }
 
+// GwCommitMaxAttempts mocks base method.
+func (m *MockConfig) GwCommitMaxAttempts() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwCommitMaxAttempts")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwCommitMaxAttempts indicates an expected call of GwCommitMaxAttempts.
+func (mr *MockConfigMockRecorder) GwCommitMaxAttempts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCommitMaxAttempts", reflect.TypeOf((*MockConfig)(nil).GwCommitMaxAttempts))
+}
+
+// GwCommitTimeout mocks base method.
+func (m *MockConfig) GwCommitTimeout() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwCommitTimeout")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwCommitTimeout indicates an expected call of GwCommitTimeout.
+func (mr *MockConfigMockRecorder) GwCommitTimeout() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCommitTimeout", reflect.TypeOf((*MockConfig)(nil).GwCommitTimeout))
+}
+
 // GwEnv mocks base method.
 func (m *MockConfig) GwEnv() string {
 	m.ctrl.T.Helper()
@@ -143,6 +437,20 @@ func (mr *MockConfigMockRecorder) GwEnv() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwEnv", reflect.TypeOf((*MockConfig)(nil).GwEnv))
 }
 
+// GwItemSchema mocks base method.
+func (m *MockConfig) GwItemSchema() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwItemSchema")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwItemSchema indicates an expected call of GwItemSchema.
+func (mr *MockConfigMockRecorder) GwItemSchema() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwItemSchema", reflect.TypeOf((*MockConfig)(nil).GwItemSchema))
+}
+
 // GwKey mocks base method.
 func (m *MockConfig) GwKey() string {
 	m.ctrl.T.Helper()
@@ -157,6 +465,20 @@ func (mr *MockConfigMockRecorder) GwKey() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwKey", reflect.TypeOf((*MockConfig)(nil).GwKey))
 }
 
+// GwKeyCommand mocks base method.
+func (m *MockConfig) GwKeyCommand() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwKeyCommand")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GwKeyCommand indicates an expected call of GwKeyCommand.
+func (mr *MockConfigMockRecorder) GwKeyCommand() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwKeyCommand", reflect.TypeOf((*MockConfig)(nil).GwKeyCommand))
+}
+
 // GwMaxAttempts mocks base method.
 func (m *MockConfig) GwMaxAttempts() int {
 	m.ctrl.T.Helper()
@@ -185,325 +507,2658 @@ func (mr *MockConfigMockRecorder) GwMaxBackoff() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwMaxBackoff", reflect.TypeOf((*MockConfig)(nil).GwMaxBackoff))
 }
 
-// GwPollInterval mocks base method.
-func (m *MockConfig) GwPollInterval() int {
+// GwMaxBatchBytes mocks base method.
+func (m *MockConfig) GwMaxBatchBytes() int {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GwPollInterval")
+	ret := m.ctrl.Call(m, "GwMaxBatchBytes")
 	ret0, _ := ret[0].(int)
 	return ret0
 }
 
-// GwPollInterval indicates an expected call of GwPollInterval.
-func (mr *MockConfigMockRecorder) GwPollInterval() *gomock.Call {
+// GwMaxBatchBytes indicates an expected call of GwMaxBatchBytes.
+func (mr *MockConfigMockRecorder) GwMaxBatchBytes() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwPollInterval", reflect.TypeOf((*MockConfig)(nil).GwPollInterval))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwMaxBatchBytes", reflect.TypeOf((*MockConfig)(nil).GwMaxBatchBytes))
 }
 
-// GwURL mocks base method.
-func (m *MockConfig) GwURL() string {
+// GwNoReplace mocks base method.
+func (m *MockConfig) GwNoReplace() bool {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GwURL")
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "GwNoReplace")
+	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
-// GwURL indicates an expected call of GwURL.
-func (mr *MockConfigMockRecorder) GwURL() *gomock.Call {
+// GwNoReplace indicates an expected call of GwNoReplace.
+func (mr *MockConfigMockRecorder) GwNoReplace() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwURL", reflect.TypeOf((*MockConfig)(nil).GwURL))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwNoReplace", reflect.TypeOf((*MockConfig)(nil).GwNoReplace))
 }
 
-// LogLevel mocks base method.
-func (m *MockConfig) LogLevel() string {
+// GwPermanentStatuses mocks base method.
+func (m *MockConfig) GwPermanentStatuses() []int {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "LogLevel")
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "GwPermanentStatuses")
+	ret0, _ := ret[0].([]int)
 	return ret0
 }
 
-// LogLevel indicates an expected call of LogLevel.
-func (mr *MockConfigMockRecorder) LogLevel() *gomock.Call {
+// GwPermanentStatuses indicates an expected call of GwPermanentStatuses.
+func (mr *MockConfigMockRecorder) GwPermanentStatuses() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogLevel", reflect.TypeOf((*MockConfig)(nil).LogLevel))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwPermanentStatuses", reflect.TypeOf((*MockConfig)(nil).GwPermanentStatuses))
 }
 
-// Logger mocks base method.
-func (m *MockConfig) Logger() string {
+// GwPollInterval mocks base method.
+func (m *MockConfig) GwPollInterval() int {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Logger")
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "GwPollInterval")
+	ret0, _ := ret[0].(int)
 	return ret0
 }
 
-// Logger indicates an expected call of Logger.
-func (mr *MockConfigMockRecorder) Logger() *gomock.Call {
+// GwPollInterval indicates an expected call of GwPollInterval.
+func (mr *MockConfigMockRecorder) GwPollInterval() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logger", reflect.TypeOf((*MockConfig)(nil).Logger))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwPollInterval", reflect.TypeOf((*MockConfig)(nil).GwPollInterval))
 }
 
-// RsyncMode mocks base method.
-func (m *MockConfig) RsyncMode() string {
+// GwProxy mocks base method.
+func (m *MockConfig) GwProxy() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RsyncMode")
+	ret := m.ctrl.Call(m, "GwProxy")
 	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// RsyncMode indicates an expected call of RsyncMode.
-func (mr *MockConfigMockRecorder) RsyncMode() *gomock.Call {
+// GwProxy indicates an expected call of GwProxy.
+func (mr *MockConfigMockRecorder) GwProxy() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RsyncMode", reflect.TypeOf((*MockConfig)(nil).RsyncMode))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwProxy", reflect.TypeOf((*MockConfig)(nil).GwProxy))
 }
 
-// Strip mocks base method.
-func (m *MockConfig) Strip() string {
+// GwReadMaxAttempts mocks base method.
+func (m *MockConfig) GwReadMaxAttempts() int {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Strip")
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "GwReadMaxAttempts")
+	ret0, _ := ret[0].(int)
 	return ret0
 }
 
-// Strip indicates an expected call of Strip.
-func (mr *MockConfigMockRecorder) Strip() *gomock.Call {
+// GwReadMaxAttempts indicates an expected call of GwReadMaxAttempts.
+func (mr *MockConfigMockRecorder) GwReadMaxAttempts() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Strip", reflect.TypeOf((*MockConfig)(nil).Strip))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwReadMaxAttempts", reflect.TypeOf((*MockConfig)(nil).GwReadMaxAttempts))
 }
 
-// UploadThreads mocks base method.
-func (m *MockConfig) UploadThreads() int {
+// GwReadTimeout mocks base method.
+func (m *MockConfig) GwReadTimeout() int {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UploadThreads")
+	ret := m.ctrl.Call(m, "GwReadTimeout")
 	ret0, _ := ret[0].(int)
 	return ret0
 }
 
-// UploadThreads indicates an expected call of UploadThreads.
-func (mr *MockConfigMockRecorder) UploadThreads() *gomock.Call {
+// GwReadTimeout indicates an expected call of GwReadTimeout.
+func (mr *MockConfigMockRecorder) GwReadTimeout() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadThreads", reflect.TypeOf((*MockConfig)(nil).UploadThreads))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwReadTimeout", reflect.TypeOf((*MockConfig)(nil).GwReadTimeout))
 }
 
-// Verbosity mocks base method.
-func (m *MockConfig) Verbosity() int {
+// GwRedirects mocks base method.
+func (m *MockConfig) GwRedirects() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Verbosity")
-	ret0, _ := ret[0].(int)
+	ret := m.ctrl.Call(m, "GwRedirects")
+	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// Verbosity indicates an expected call of Verbosity.
-func (mr *MockConfigMockRecorder) Verbosity() *gomock.Call {
+// GwRedirects indicates an expected call of GwRedirects.
+func (mr *MockConfigMockRecorder) GwRedirects() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verbosity", reflect.TypeOf((*MockConfig)(nil).Verbosity))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwRedirects", reflect.TypeOf((*MockConfig)(nil).GwRedirects))
 }
 
-// MockEnvironmentConfig is a mock of EnvironmentConfig interface.
-type MockEnvironmentConfig struct {
-	ctrl     *gomock.Controller
-	recorder *MockEnvironmentConfigMockRecorder
+// GwRetryStatuses mocks base method.
+func (m *MockConfig) GwRetryStatuses() []int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwRetryStatuses")
+	ret0, _ := ret[0].([]int)
+	return ret0
 }
 
-// MockEnvironmentConfigMockRecorder is the mock recorder for MockEnvironmentConfig.
-type MockEnvironmentConfigMockRecorder struct {
-	mock *MockEnvironmentConfig
+// GwRetryStatuses indicates an expected call of GwRetryStatuses.
+func (mr *MockConfigMockRecorder) GwRetryStatuses() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwRetryStatuses", reflect.TypeOf((*MockConfig)(nil).GwRetryStatuses))
 }
 
-// NewMockEnvironmentConfig creates a new mock instance.
-func NewMockEnvironmentConfig(ctrl *gomock.Controller) *MockEnvironmentConfig {
-	mock := &MockEnvironmentConfig{ctrl: ctrl}
+// GwURL mocks base method.
+func (m *MockConfig) GwURL() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwURL")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GwURL indicates an expected call of GwURL.
+func (mr *MockConfigMockRecorder) GwURL() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwURL", reflect.TypeOf((*MockConfig)(nil).GwURL))
+}
+
+// GwUseIdempotencyKeys mocks base method.
+func (m *MockConfig) GwUseIdempotencyKeys() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwUseIdempotencyKeys")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// GwUseIdempotencyKeys indicates an expected call of GwUseIdempotencyKeys.
+func (mr *MockConfigMockRecorder) GwUseIdempotencyKeys() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwUseIdempotencyKeys", reflect.TypeOf((*MockConfig)(nil).GwUseIdempotencyKeys))
+}
+
+// GwWriteMaxAttempts mocks base method.
+func (m *MockConfig) GwWriteMaxAttempts() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwWriteMaxAttempts")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwWriteMaxAttempts indicates an expected call of GwWriteMaxAttempts.
+func (mr *MockConfigMockRecorder) GwWriteMaxAttempts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwWriteMaxAttempts", reflect.TypeOf((*MockConfig)(nil).GwWriteMaxAttempts))
+}
+
+// GwWriteTimeout mocks base method.
+func (m *MockConfig) GwWriteTimeout() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwWriteTimeout")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwWriteTimeout indicates an expected call of GwWriteTimeout.
+func (mr *MockConfigMockRecorder) GwWriteTimeout() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwWriteTimeout", reflect.TypeOf((*MockConfig)(nil).GwWriteTimeout))
+}
+
+// Include mocks base method.
+func (m *MockConfig) Include() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Include")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// Include indicates an expected call of Include.
+func (mr *MockConfigMockRecorder) Include() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Include", reflect.TypeOf((*MockConfig)(nil).Include))
+}
+
+// LogLevel mocks base method.
+func (m *MockConfig) LogLevel() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogLevel")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// LogLevel indicates an expected call of LogLevel.
+func (mr *MockConfigMockRecorder) LogLevel() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogLevel", reflect.TypeOf((*MockConfig)(nil).LogLevel))
+}
+
+// Logger mocks base method.
+func (m *MockConfig) Logger() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logger")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Logger indicates an expected call of Logger.
+func (mr *MockConfigMockRecorder) Logger() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logger", reflect.TypeOf((*MockConfig)(nil).Logger))
+}
+
+// MIMESniff mocks base method.
+func (m *MockConfig) MIMESniff() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MIMESniff")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// MIMESniff indicates an expected call of MIMESniff.
+func (mr *MockConfigMockRecorder) MIMESniff() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MIMESniff", reflect.TypeOf((*MockConfig)(nil).MIMESniff))
+}
+
+// MaxClockSkew mocks base method.
+func (m *MockConfig) MaxClockSkew() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxClockSkew")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// MaxClockSkew indicates an expected call of MaxClockSkew.
+func (mr *MockConfigMockRecorder) MaxClockSkew() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxClockSkew", reflect.TypeOf((*MockConfig)(nil).MaxClockSkew))
+}
+
+// MaxPublishBytes mocks base method.
+func (m *MockConfig) MaxPublishBytes() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxPublishBytes")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// MaxPublishBytes indicates an expected call of MaxPublishBytes.
+func (mr *MockConfigMockRecorder) MaxPublishBytes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxPublishBytes", reflect.TypeOf((*MockConfig)(nil).MaxPublishBytes))
+}
+
+// MaxPublishItems mocks base method.
+func (m *MockConfig) MaxPublishItems() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxPublishItems")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// MaxPublishItems indicates an expected call of MaxPublishItems.
+func (mr *MockConfigMockRecorder) MaxPublishItems() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxPublishItems", reflect.TypeOf((*MockConfig)(nil).MaxPublishItems))
+}
+
+// MaxURILength mocks base method.
+func (m *MockConfig) MaxURILength() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxURILength")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// MaxURILength indicates an expected call of MaxURILength.
+func (mr *MockConfigMockRecorder) MaxURILength() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxURILength", reflect.TypeOf((*MockConfig)(nil).MaxURILength))
+}
+
+// NoProxy mocks base method.
+func (m *MockConfig) NoProxy() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NoProxy")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// NoProxy indicates an expected call of NoProxy.
+func (mr *MockConfigMockRecorder) NoProxy() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NoProxy", reflect.TypeOf((*MockConfig)(nil).NoProxy))
+}
+
+// ProtectedEnvs mocks base method.
+func (m *MockConfig) ProtectedEnvs() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProtectedEnvs")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// ProtectedEnvs indicates an expected call of ProtectedEnvs.
+func (mr *MockConfigMockRecorder) ProtectedEnvs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProtectedEnvs", reflect.TypeOf((*MockConfig)(nil).ProtectedEnvs))
+}
+
+// PublishLock mocks base method.
+func (m *MockConfig) PublishLock() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishLock")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// PublishLock indicates an expected call of PublishLock.
+func (mr *MockConfigMockRecorder) PublishLock() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishLock", reflect.TypeOf((*MockConfig)(nil).PublishLock))
+}
+
+// PublishLockWait mocks base method.
+func (m *MockConfig) PublishLockWait() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishLockWait")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// PublishLockWait indicates an expected call of PublishLockWait.
+func (mr *MockConfigMockRecorder) PublishLockWait() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishLockWait", reflect.TypeOf((*MockConfig)(nil).PublishLockWait))
+}
+
+// PublishState mocks base method.
+func (m *MockConfig) PublishState() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishState")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// PublishState indicates an expected call of PublishState.
+func (mr *MockConfigMockRecorder) PublishState() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishState", reflect.TypeOf((*MockConfig)(nil).PublishState))
+}
+
+// RsyncMode mocks base method.
+func (m *MockConfig) RsyncMode() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RsyncMode")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// RsyncMode indicates an expected call of RsyncMode.
+func (mr *MockConfigMockRecorder) RsyncMode() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RsyncMode", reflect.TypeOf((*MockConfig)(nil).RsyncMode))
+}
+
+// S3Access mocks base method.
+func (m *MockConfig) S3Access() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "S3Access")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// S3Access indicates an expected call of S3Access.
+func (mr *MockConfigMockRecorder) S3Access() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "S3Access", reflect.TypeOf((*MockConfig)(nil).S3Access))
+}
+
+// S3Bucket mocks base method.
+func (m *MockConfig) S3Bucket() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "S3Bucket")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// S3Bucket indicates an expected call of S3Bucket.
+func (mr *MockConfigMockRecorder) S3Bucket() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "S3Bucket", reflect.TypeOf((*MockConfig)(nil).S3Bucket))
+}
+
+// S3Proxy mocks base method.
+func (m *MockConfig) S3Proxy() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "S3Proxy")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// S3Proxy indicates an expected call of S3Proxy.
+func (mr *MockConfigMockRecorder) S3Proxy() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "S3Proxy", reflect.TypeOf((*MockConfig)(nil).S3Proxy))
+}
+
+// Settings mocks base method.
+func (m *MockConfig) Settings() []Setting {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Settings")
+	ret0, _ := ret[0].([]Setting)
+	return ret0
+}
+
+// Settings indicates an expected call of Settings.
+func (mr *MockConfigMockRecorder) Settings() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Settings", reflect.TypeOf((*MockConfig)(nil).Settings))
+}
+
+// SkipEmptyFiles mocks base method.
+func (m *MockConfig) SkipEmptyFiles() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SkipEmptyFiles")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// SkipEmptyFiles indicates an expected call of SkipEmptyFiles.
+func (mr *MockConfigMockRecorder) SkipEmptyFiles() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SkipEmptyFiles", reflect.TypeOf((*MockConfig)(nil).SkipEmptyFiles))
+}
+
+// Strip mocks base method.
+func (m *MockConfig) Strip() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Strip")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Strip indicates an expected call of Strip.
+func (mr *MockConfigMockRecorder) Strip() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Strip", reflect.TypeOf((*MockConfig)(nil).Strip))
+}
+
+// TLSCipherSuites mocks base method.
+func (m *MockConfig) TLSCipherSuites() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TLSCipherSuites")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// TLSCipherSuites indicates an expected call of TLSCipherSuites.
+func (mr *MockConfigMockRecorder) TLSCipherSuites() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TLSCipherSuites", reflect.TypeOf((*MockConfig)(nil).TLSCipherSuites))
+}
+
+// TLSHandshakeTimeout mocks base method.
+func (m *MockConfig) TLSHandshakeTimeout() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TLSHandshakeTimeout")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// TLSHandshakeTimeout indicates an expected call of TLSHandshakeTimeout.
+func (mr *MockConfigMockRecorder) TLSHandshakeTimeout() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TLSHandshakeTimeout", reflect.TypeOf((*MockConfig)(nil).TLSHandshakeTimeout))
+}
+
+// TempDir mocks base method.
+func (m *MockConfig) TempDir() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TempDir")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// TempDir indicates an expected call of TempDir.
+func (mr *MockConfigMockRecorder) TempDir() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TempDir", reflect.TypeOf((*MockConfig)(nil).TempDir))
+}
+
+// TempMinFree mocks base method.
+func (m *MockConfig) TempMinFree() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TempMinFree")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// TempMinFree indicates an expected call of TempMinFree.
+func (mr *MockConfigMockRecorder) TempMinFree() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TempMinFree", reflect.TypeOf((*MockConfig)(nil).TempMinFree))
+}
+
+// URICaseInsensitive mocks base method.
+func (m *MockConfig) URICaseInsensitive() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "URICaseInsensitive")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// URICaseInsensitive indicates an expected call of URICaseInsensitive.
+func (mr *MockConfigMockRecorder) URICaseInsensitive() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "URICaseInsensitive", reflect.TypeOf((*MockConfig)(nil).URICaseInsensitive))
+}
+
+// URINormalize mocks base method.
+func (m *MockConfig) URINormalize() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "URINormalize")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// URINormalize indicates an expected call of URINormalize.
+func (mr *MockConfigMockRecorder) URINormalize() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "URINormalize", reflect.TypeOf((*MockConfig)(nil).URINormalize))
+}
+
+// UploadMaxAttempts mocks base method.
+func (m *MockConfig) UploadMaxAttempts() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadMaxAttempts")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// UploadMaxAttempts indicates an expected call of UploadMaxAttempts.
+func (mr *MockConfigMockRecorder) UploadMaxAttempts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadMaxAttempts", reflect.TypeOf((*MockConfig)(nil).UploadMaxAttempts))
+}
+
+// UploadMaxBackoff mocks base method.
+func (m *MockConfig) UploadMaxBackoff() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadMaxBackoff")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// UploadMaxBackoff indicates an expected call of UploadMaxBackoff.
+func (mr *MockConfigMockRecorder) UploadMaxBackoff() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadMaxBackoff", reflect.TypeOf((*MockConfig)(nil).UploadMaxBackoff))
+}
+
+// UploadSSE mocks base method.
+func (m *MockConfig) UploadSSE() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadSSE")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// UploadSSE indicates an expected call of UploadSSE.
+func (mr *MockConfigMockRecorder) UploadSSE() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadSSE", reflect.TypeOf((*MockConfig)(nil).UploadSSE))
+}
+
+// UploadSSEKMSKeyID mocks base method.
+func (m *MockConfig) UploadSSEKMSKeyID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadSSEKMSKeyID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// UploadSSEKMSKeyID indicates an expected call of UploadSSEKMSKeyID.
+func (mr *MockConfigMockRecorder) UploadSSEKMSKeyID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadSSEKMSKeyID", reflect.TypeOf((*MockConfig)(nil).UploadSSEKMSKeyID))
+}
+
+// UploadStorageClass mocks base method.
+func (m *MockConfig) UploadStorageClass() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadStorageClass")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// UploadStorageClass indicates an expected call of UploadStorageClass.
+func (mr *MockConfigMockRecorder) UploadStorageClass() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadStorageClass", reflect.TypeOf((*MockConfig)(nil).UploadStorageClass))
+}
+
+// UploadTags mocks base method.
+func (m *MockConfig) UploadTags() map[string]string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadTags")
+	ret0, _ := ret[0].(map[string]string)
+	return ret0
+}
+
+// UploadTags indicates an expected call of UploadTags.
+func (mr *MockConfigMockRecorder) UploadTags() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadTags", reflect.TypeOf((*MockConfig)(nil).UploadTags))
+}
+
+// UploadThreads mocks base method.
+func (m *MockConfig) UploadThreads() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadThreads")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// UploadThreads indicates an expected call of UploadThreads.
+func (mr *MockConfigMockRecorder) UploadThreads() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadThreads", reflect.TypeOf((*MockConfig)(nil).UploadThreads))
+}
+
+// Verbosity mocks base method.
+func (m *MockConfig) Verbosity() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verbosity")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Verbosity indicates an expected call of Verbosity.
+func (mr *MockConfigMockRecorder) Verbosity() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verbosity", reflect.TypeOf((*MockConfig)(nil).Verbosity))
+}
+
+// Xattrs mocks base method.
+func (m *MockConfig) Xattrs() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Xattrs")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// Xattrs indicates an expected call of Xattrs.
+func (mr *MockConfigMockRecorder) Xattrs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Xattrs", reflect.TypeOf((*MockConfig)(nil).Xattrs))
+}
+
+// MockEnvironmentConfig is a mock of EnvironmentConfig interface.
+type MockEnvironmentConfig struct {
+	ctrl     *gomock.Controller
+	recorder *MockEnvironmentConfigMockRecorder
+}
+
+// MockEnvironmentConfigMockRecorder is the mock recorder for MockEnvironmentConfig.
+type MockEnvironmentConfigMockRecorder struct {
+	mock *MockEnvironmentConfig
+}
+
+// NewMockEnvironmentConfig creates a new mock instance.
+func NewMockEnvironmentConfig(ctrl *gomock.Controller) *MockEnvironmentConfig {
+	mock := &MockEnvironmentConfig{ctrl: ctrl}
 	mock.recorder = &MockEnvironmentConfigMockRecorder{mock}
 	return mock
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockEnvironmentConfig) EXPECT() *MockEnvironmentConfigMockRecorder {
-	return m.recorder
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEnvironmentConfig) EXPECT() *MockEnvironmentConfigMockRecorder {
+	return m.recorder
+}
+
+// Aliases mocks base method.
+func (m *MockEnvironmentConfig) Aliases() []AliasRule {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Aliases")
+	ret0, _ := ret[0].([]AliasRule)
+	return ret0
+}
+
+// Aliases indicates an expected call of Aliases.
+func (mr *MockEnvironmentConfigMockRecorder) Aliases() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Aliases", reflect.TypeOf((*MockEnvironmentConfig)(nil).Aliases))
+}
+
+// AuditLog mocks base method.
+func (m *MockEnvironmentConfig) AuditLog() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuditLog")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// AuditLog indicates an expected call of AuditLog.
+func (mr *MockEnvironmentConfigMockRecorder) AuditLog() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuditLog", reflect.TypeOf((*MockEnvironmentConfig)(nil).AuditLog))
+}
+
+// BlobCache mocks base method.
+func (m *MockEnvironmentConfig) BlobCache() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlobCache")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// BlobCache indicates an expected call of BlobCache.
+func (mr *MockEnvironmentConfigMockRecorder) BlobCache() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlobCache", reflect.TypeOf((*MockEnvironmentConfig)(nil).BlobCache))
+}
+
+// BlobCacheMaxAge mocks base method.
+func (m *MockEnvironmentConfig) BlobCacheMaxAge() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlobCacheMaxAge")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// BlobCacheMaxAge indicates an expected call of BlobCacheMaxAge.
+func (mr *MockEnvironmentConfigMockRecorder) BlobCacheMaxAge() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlobCacheMaxAge", reflect.TypeOf((*MockEnvironmentConfig)(nil).BlobCacheMaxAge))
+}
+
+// BlobKeyPrefix mocks base method.
+func (m *MockEnvironmentConfig) BlobKeyPrefix() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlobKeyPrefix")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// BlobKeyPrefix indicates an expected call of BlobKeyPrefix.
+func (mr *MockEnvironmentConfigMockRecorder) BlobKeyPrefix() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlobKeyPrefix", reflect.TypeOf((*MockEnvironmentConfig)(nil).BlobKeyPrefix))
+}
+
+// CacheControlDefault mocks base method.
+func (m *MockEnvironmentConfig) CacheControlDefault() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CacheControlDefault")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// CacheControlDefault indicates an expected call of CacheControlDefault.
+func (mr *MockEnvironmentConfigMockRecorder) CacheControlDefault() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CacheControlDefault", reflect.TypeOf((*MockEnvironmentConfig)(nil).CacheControlDefault))
+}
+
+// CacheControlRules mocks base method.
+func (m *MockEnvironmentConfig) CacheControlRules() []CacheControlRule {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CacheControlRules")
+	ret0, _ := ret[0].([]CacheControlRule)
+	return ret0
+}
+
+// CacheControlRules indicates an expected call of CacheControlRules.
+func (mr *MockEnvironmentConfigMockRecorder) CacheControlRules() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CacheControlRules", reflect.TypeOf((*MockEnvironmentConfig)(nil).CacheControlRules))
+}
+
+// CdnURL mocks base method.
+func (m *MockEnvironmentConfig) CdnURL() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CdnURL")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// CdnURL indicates an expected call of CdnURL.
+func (mr *MockEnvironmentConfigMockRecorder) CdnURL() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CdnURL", reflect.TypeOf((*MockEnvironmentConfig)(nil).CdnURL))
+}
+
+// ConcurrencyMax mocks base method.
+func (m *MockEnvironmentConfig) ConcurrencyMax() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConcurrencyMax")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// ConcurrencyMax indicates an expected call of ConcurrencyMax.
+func (mr *MockEnvironmentConfigMockRecorder) ConcurrencyMax() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConcurrencyMax", reflect.TypeOf((*MockEnvironmentConfig)(nil).ConcurrencyMax))
+}
+
+// ConcurrencyMin mocks base method.
+func (m *MockEnvironmentConfig) ConcurrencyMin() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConcurrencyMin")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// ConcurrencyMin indicates an expected call of ConcurrencyMin.
+func (mr *MockEnvironmentConfigMockRecorder) ConcurrencyMin() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConcurrencyMin", reflect.TypeOf((*MockEnvironmentConfig)(nil).ConcurrencyMin))
+}
+
+// ContentRules mocks base method.
+func (m *MockEnvironmentConfig) ContentRules() []ContentRule {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ContentRules")
+	ret0, _ := ret[0].([]ContentRule)
+	return ret0
+}
+
+// ContentRules indicates an expected call of ContentRules.
+func (mr *MockEnvironmentConfigMockRecorder) ContentRules() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContentRules", reflect.TypeOf((*MockEnvironmentConfig)(nil).ContentRules))
+}
+
+// Diag mocks base method.
+func (m *MockEnvironmentConfig) Diag() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Diag")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Diag indicates an expected call of Diag.
+func (mr *MockEnvironmentConfigMockRecorder) Diag() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Diag", reflect.TypeOf((*MockEnvironmentConfig)(nil).Diag))
+}
+
+// DialTimeout mocks base method.
+func (m *MockEnvironmentConfig) DialTimeout() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DialTimeout")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// DialTimeout indicates an expected call of DialTimeout.
+func (mr *MockEnvironmentConfigMockRecorder) DialTimeout() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DialTimeout", reflect.TypeOf((*MockEnvironmentConfig)(nil).DialTimeout))
+}
+
+// Exclude mocks base method.
+func (m *MockEnvironmentConfig) Exclude() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exclude")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// Exclude indicates an expected call of Exclude.
+func (mr *MockEnvironmentConfigMockRecorder) Exclude() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exclude", reflect.TypeOf((*MockEnvironmentConfig)(nil).Exclude))
+}
+
+// GwBatchSize mocks base method.
+func (m *MockEnvironmentConfig) GwBatchSize() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwBatchSize")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwBatchSize indicates an expected call of GwBatchSize.
+func (mr *MockEnvironmentConfigMockRecorder) GwBatchSize() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwBatchSize", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwBatchSize))
+}
+
+// GwBatchSizeAuto mocks base method.
+func (m *MockEnvironmentConfig) GwBatchSizeAuto() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwBatchSizeAuto")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// GwBatchSizeAuto indicates an expected call of GwBatchSizeAuto.
+func (mr *MockEnvironmentConfigMockRecorder) GwBatchSizeAuto() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwBatchSizeAuto", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwBatchSizeAuto))
+}
+
+// GwBatchSizeMax mocks base method.
+func (m *MockEnvironmentConfig) GwBatchSizeMax() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwBatchSizeMax")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwBatchSizeMax indicates an expected call of GwBatchSizeMax.
+func (mr *MockEnvironmentConfigMockRecorder) GwBatchSizeMax() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwBatchSizeMax", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwBatchSizeMax))
+}
+
+// GwBatchSizeMin mocks base method.
+func (m *MockEnvironmentConfig) GwBatchSizeMin() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwBatchSizeMin")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwBatchSizeMin indicates an expected call of GwBatchSizeMin.
+func (mr *MockEnvironmentConfigMockRecorder) GwBatchSizeMin() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwBatchSizeMin", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwBatchSizeMin))
+}
+
+// GwCacheControl mocks base method.
+func (m *MockEnvironmentConfig) GwCacheControl() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwCacheControl")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// GwCacheControl indicates an expected call of GwCacheControl.
+func (mr *MockEnvironmentConfigMockRecorder) GwCacheControl() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCacheControl", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwCacheControl))
+}
+
+// GwCert mocks base method.
+func (m *MockEnvironmentConfig) GwCert() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwCert")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GwCert indicates an expected call of GwCert.
+func (mr *MockEnvironmentConfigMockRecorder) GwCert() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCert", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwCert))
+}
+
+// GwCertCommand mocks base method.
+func (m *MockEnvironmentConfig) GwCertCommand() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwCertCommand")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GwCertCommand indicates an expected call of GwCertCommand.
+func (mr *MockEnvironmentConfigMockRecorder) GwCertCommand() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCertCommand", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwCertCommand))
+}
+
+// GwCertExpiryWarning mocks base method.
+func (m *MockEnvironmentConfig) GwCertExpiryWarning() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwCertExpiryWarning")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwCertExpiryWarning indicates an expected call of GwCertExpiryWarning.
+func (mr *MockEnvironmentConfigMockRecorder) GwCertExpiryWarning() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCertExpiryWarning", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwCertExpiryWarning))
+}
+
+// GwCommit mocks base method.
+func (m *MockEnvironmentConfig) GwCommit() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwCommit")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GwCommit indicates an expected call of GwCommit.
+func (mr *MockEnvironmentConfigMockRecorder) GwCommit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCommit", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwCommit))
+}
+
+// GwCommitMaxAttempts mocks base method.
+func (m *MockEnvironmentConfig) GwCommitMaxAttempts() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwCommitMaxAttempts")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwCommitMaxAttempts indicates an expected call of GwCommitMaxAttempts.
+func (mr *MockEnvironmentConfigMockRecorder) GwCommitMaxAttempts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCommitMaxAttempts", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwCommitMaxAttempts))
+}
+
+// GwCommitTimeout mocks base method.
+func (m *MockEnvironmentConfig) GwCommitTimeout() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwCommitTimeout")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwCommitTimeout indicates an expected call of GwCommitTimeout.
+func (mr *MockEnvironmentConfigMockRecorder) GwCommitTimeout() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCommitTimeout", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwCommitTimeout))
+}
+
+// GwEnv mocks base method.
+func (m *MockEnvironmentConfig) GwEnv() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwEnv")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GwEnv indicates an expected call of GwEnv.
+func (mr *MockEnvironmentConfigMockRecorder) GwEnv() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwEnv", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwEnv))
+}
+
+// GwItemSchema mocks base method.
+func (m *MockEnvironmentConfig) GwItemSchema() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwItemSchema")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwItemSchema indicates an expected call of GwItemSchema.
+func (mr *MockEnvironmentConfigMockRecorder) GwItemSchema() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwItemSchema", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwItemSchema))
+}
+
+// GwKey mocks base method.
+func (m *MockEnvironmentConfig) GwKey() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwKey")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GwKey indicates an expected call of GwKey.
+func (mr *MockEnvironmentConfigMockRecorder) GwKey() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwKey", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwKey))
+}
+
+// GwKeyCommand mocks base method.
+func (m *MockEnvironmentConfig) GwKeyCommand() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwKeyCommand")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GwKeyCommand indicates an expected call of GwKeyCommand.
+func (mr *MockEnvironmentConfigMockRecorder) GwKeyCommand() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwKeyCommand", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwKeyCommand))
+}
+
+// GwMaxAttempts mocks base method.
+func (m *MockEnvironmentConfig) GwMaxAttempts() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwMaxAttempts")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwMaxAttempts indicates an expected call of GwMaxAttempts.
+func (mr *MockEnvironmentConfigMockRecorder) GwMaxAttempts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwMaxAttempts", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwMaxAttempts))
+}
+
+// GwMaxBackoff mocks base method.
+func (m *MockEnvironmentConfig) GwMaxBackoff() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwMaxBackoff")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwMaxBackoff indicates an expected call of GwMaxBackoff.
+func (mr *MockEnvironmentConfigMockRecorder) GwMaxBackoff() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwMaxBackoff", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwMaxBackoff))
+}
+
+// GwMaxBatchBytes mocks base method.
+func (m *MockEnvironmentConfig) GwMaxBatchBytes() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwMaxBatchBytes")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwMaxBatchBytes indicates an expected call of GwMaxBatchBytes.
+func (mr *MockEnvironmentConfigMockRecorder) GwMaxBatchBytes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwMaxBatchBytes", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwMaxBatchBytes))
+}
+
+// GwNoReplace mocks base method.
+func (m *MockEnvironmentConfig) GwNoReplace() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwNoReplace")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// GwNoReplace indicates an expected call of GwNoReplace.
+func (mr *MockEnvironmentConfigMockRecorder) GwNoReplace() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwNoReplace", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwNoReplace))
+}
+
+// GwPermanentStatuses mocks base method.
+func (m *MockEnvironmentConfig) GwPermanentStatuses() []int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwPermanentStatuses")
+	ret0, _ := ret[0].([]int)
+	return ret0
+}
+
+// GwPermanentStatuses indicates an expected call of GwPermanentStatuses.
+func (mr *MockEnvironmentConfigMockRecorder) GwPermanentStatuses() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwPermanentStatuses", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwPermanentStatuses))
+}
+
+// GwPollInterval mocks base method.
+func (m *MockEnvironmentConfig) GwPollInterval() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwPollInterval")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwPollInterval indicates an expected call of GwPollInterval.
+func (mr *MockEnvironmentConfigMockRecorder) GwPollInterval() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwPollInterval", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwPollInterval))
+}
+
+// GwProxy mocks base method.
+func (m *MockEnvironmentConfig) GwProxy() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwProxy")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GwProxy indicates an expected call of GwProxy.
+func (mr *MockEnvironmentConfigMockRecorder) GwProxy() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwProxy", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwProxy))
+}
+
+// GwReadMaxAttempts mocks base method.
+func (m *MockEnvironmentConfig) GwReadMaxAttempts() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwReadMaxAttempts")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwReadMaxAttempts indicates an expected call of GwReadMaxAttempts.
+func (mr *MockEnvironmentConfigMockRecorder) GwReadMaxAttempts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwReadMaxAttempts", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwReadMaxAttempts))
+}
+
+// GwReadTimeout mocks base method.
+func (m *MockEnvironmentConfig) GwReadTimeout() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwReadTimeout")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwReadTimeout indicates an expected call of GwReadTimeout.
+func (mr *MockEnvironmentConfigMockRecorder) GwReadTimeout() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwReadTimeout", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwReadTimeout))
+}
+
+// GwRedirects mocks base method.
+func (m *MockEnvironmentConfig) GwRedirects() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwRedirects")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GwRedirects indicates an expected call of GwRedirects.
+func (mr *MockEnvironmentConfigMockRecorder) GwRedirects() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwRedirects", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwRedirects))
+}
+
+// GwRetryStatuses mocks base method.
+func (m *MockEnvironmentConfig) GwRetryStatuses() []int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwRetryStatuses")
+	ret0, _ := ret[0].([]int)
+	return ret0
+}
+
+// GwRetryStatuses indicates an expected call of GwRetryStatuses.
+func (mr *MockEnvironmentConfigMockRecorder) GwRetryStatuses() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwRetryStatuses", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwRetryStatuses))
+}
+
+// GwURL mocks base method.
+func (m *MockEnvironmentConfig) GwURL() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwURL")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GwURL indicates an expected call of GwURL.
+func (mr *MockEnvironmentConfigMockRecorder) GwURL() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwURL", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwURL))
+}
+
+// GwUseIdempotencyKeys mocks base method.
+func (m *MockEnvironmentConfig) GwUseIdempotencyKeys() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwUseIdempotencyKeys")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// GwUseIdempotencyKeys indicates an expected call of GwUseIdempotencyKeys.
+func (mr *MockEnvironmentConfigMockRecorder) GwUseIdempotencyKeys() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwUseIdempotencyKeys", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwUseIdempotencyKeys))
+}
+
+// GwWriteMaxAttempts mocks base method.
+func (m *MockEnvironmentConfig) GwWriteMaxAttempts() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwWriteMaxAttempts")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwWriteMaxAttempts indicates an expected call of GwWriteMaxAttempts.
+func (mr *MockEnvironmentConfigMockRecorder) GwWriteMaxAttempts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwWriteMaxAttempts", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwWriteMaxAttempts))
+}
+
+// GwWriteTimeout mocks base method.
+func (m *MockEnvironmentConfig) GwWriteTimeout() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwWriteTimeout")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwWriteTimeout indicates an expected call of GwWriteTimeout.
+func (mr *MockEnvironmentConfigMockRecorder) GwWriteTimeout() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwWriteTimeout", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwWriteTimeout))
+}
+
+// Include mocks base method.
+func (m *MockEnvironmentConfig) Include() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Include")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// Include indicates an expected call of Include.
+func (mr *MockEnvironmentConfigMockRecorder) Include() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Include", reflect.TypeOf((*MockEnvironmentConfig)(nil).Include))
+}
+
+// LogLevel mocks base method.
+func (m *MockEnvironmentConfig) LogLevel() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogLevel")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// LogLevel indicates an expected call of LogLevel.
+func (mr *MockEnvironmentConfigMockRecorder) LogLevel() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogLevel", reflect.TypeOf((*MockEnvironmentConfig)(nil).LogLevel))
+}
+
+// Logger mocks base method.
+func (m *MockEnvironmentConfig) Logger() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logger")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Logger indicates an expected call of Logger.
+func (mr *MockEnvironmentConfigMockRecorder) Logger() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logger", reflect.TypeOf((*MockEnvironmentConfig)(nil).Logger))
+}
+
+// MIMESniff mocks base method.
+func (m *MockEnvironmentConfig) MIMESniff() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MIMESniff")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// MIMESniff indicates an expected call of MIMESniff.
+func (mr *MockEnvironmentConfigMockRecorder) MIMESniff() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MIMESniff", reflect.TypeOf((*MockEnvironmentConfig)(nil).MIMESniff))
+}
+
+// MaxClockSkew mocks base method.
+func (m *MockEnvironmentConfig) MaxClockSkew() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxClockSkew")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// MaxClockSkew indicates an expected call of MaxClockSkew.
+func (mr *MockEnvironmentConfigMockRecorder) MaxClockSkew() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxClockSkew", reflect.TypeOf((*MockEnvironmentConfig)(nil).MaxClockSkew))
+}
+
+// MaxPublishBytes mocks base method.
+func (m *MockEnvironmentConfig) MaxPublishBytes() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxPublishBytes")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// MaxPublishBytes indicates an expected call of MaxPublishBytes.
+func (mr *MockEnvironmentConfigMockRecorder) MaxPublishBytes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxPublishBytes", reflect.TypeOf((*MockEnvironmentConfig)(nil).MaxPublishBytes))
+}
+
+// MaxPublishItems mocks base method.
+func (m *MockEnvironmentConfig) MaxPublishItems() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxPublishItems")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// MaxPublishItems indicates an expected call of MaxPublishItems.
+func (mr *MockEnvironmentConfigMockRecorder) MaxPublishItems() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxPublishItems", reflect.TypeOf((*MockEnvironmentConfig)(nil).MaxPublishItems))
+}
+
+// MaxURILength mocks base method.
+func (m *MockEnvironmentConfig) MaxURILength() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxURILength")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// MaxURILength indicates an expected call of MaxURILength.
+func (mr *MockEnvironmentConfigMockRecorder) MaxURILength() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxURILength", reflect.TypeOf((*MockEnvironmentConfig)(nil).MaxURILength))
+}
+
+// NoProxy mocks base method.
+func (m *MockEnvironmentConfig) NoProxy() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NoProxy")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// NoProxy indicates an expected call of NoProxy.
+func (mr *MockEnvironmentConfigMockRecorder) NoProxy() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NoProxy", reflect.TypeOf((*MockEnvironmentConfig)(nil).NoProxy))
+}
+
+// Prefix mocks base method.
+func (m *MockEnvironmentConfig) Prefix() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Prefix")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Prefix indicates an expected call of Prefix.
+func (mr *MockEnvironmentConfigMockRecorder) Prefix() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prefix", reflect.TypeOf((*MockEnvironmentConfig)(nil).Prefix))
+}
+
+// ProtectedEnvs mocks base method.
+func (m *MockEnvironmentConfig) ProtectedEnvs() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProtectedEnvs")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// ProtectedEnvs indicates an expected call of ProtectedEnvs.
+func (mr *MockEnvironmentConfigMockRecorder) ProtectedEnvs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProtectedEnvs", reflect.TypeOf((*MockEnvironmentConfig)(nil).ProtectedEnvs))
+}
+
+// PublishLock mocks base method.
+func (m *MockEnvironmentConfig) PublishLock() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishLock")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// PublishLock indicates an expected call of PublishLock.
+func (mr *MockEnvironmentConfigMockRecorder) PublishLock() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishLock", reflect.TypeOf((*MockEnvironmentConfig)(nil).PublishLock))
+}
+
+// PublishLockWait mocks base method.
+func (m *MockEnvironmentConfig) PublishLockWait() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishLockWait")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// PublishLockWait indicates an expected call of PublishLockWait.
+func (mr *MockEnvironmentConfigMockRecorder) PublishLockWait() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishLockWait", reflect.TypeOf((*MockEnvironmentConfig)(nil).PublishLockWait))
+}
+
+// PublishState mocks base method.
+func (m *MockEnvironmentConfig) PublishState() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishState")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// PublishState indicates an expected call of PublishState.
+func (mr *MockEnvironmentConfigMockRecorder) PublishState() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishState", reflect.TypeOf((*MockEnvironmentConfig)(nil).PublishState))
+}
+
+// RsyncMode mocks base method.
+func (m *MockEnvironmentConfig) RsyncMode() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RsyncMode")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// RsyncMode indicates an expected call of RsyncMode.
+func (mr *MockEnvironmentConfigMockRecorder) RsyncMode() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RsyncMode", reflect.TypeOf((*MockEnvironmentConfig)(nil).RsyncMode))
+}
+
+// S3Access mocks base method.
+func (m *MockEnvironmentConfig) S3Access() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "S3Access")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// S3Access indicates an expected call of S3Access.
+func (mr *MockEnvironmentConfigMockRecorder) S3Access() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "S3Access", reflect.TypeOf((*MockEnvironmentConfig)(nil).S3Access))
+}
+
+// S3Bucket mocks base method.
+func (m *MockEnvironmentConfig) S3Bucket() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "S3Bucket")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// S3Bucket indicates an expected call of S3Bucket.
+func (mr *MockEnvironmentConfigMockRecorder) S3Bucket() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "S3Bucket", reflect.TypeOf((*MockEnvironmentConfig)(nil).S3Bucket))
+}
+
+// S3Proxy mocks base method.
+func (m *MockEnvironmentConfig) S3Proxy() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "S3Proxy")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// S3Proxy indicates an expected call of S3Proxy.
+func (mr *MockEnvironmentConfigMockRecorder) S3Proxy() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "S3Proxy", reflect.TypeOf((*MockEnvironmentConfig)(nil).S3Proxy))
+}
+
+// Settings mocks base method.
+func (m *MockEnvironmentConfig) Settings() []Setting {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Settings")
+	ret0, _ := ret[0].([]Setting)
+	return ret0
+}
+
+// Settings indicates an expected call of Settings.
+func (mr *MockEnvironmentConfigMockRecorder) Settings() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Settings", reflect.TypeOf((*MockEnvironmentConfig)(nil).Settings))
+}
+
+// SkipEmptyFiles mocks base method.
+func (m *MockEnvironmentConfig) SkipEmptyFiles() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SkipEmptyFiles")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// SkipEmptyFiles indicates an expected call of SkipEmptyFiles.
+func (mr *MockEnvironmentConfigMockRecorder) SkipEmptyFiles() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SkipEmptyFiles", reflect.TypeOf((*MockEnvironmentConfig)(nil).SkipEmptyFiles))
+}
+
+// Strip mocks base method.
+func (m *MockEnvironmentConfig) Strip() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Strip")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Strip indicates an expected call of Strip.
+func (mr *MockEnvironmentConfigMockRecorder) Strip() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Strip", reflect.TypeOf((*MockEnvironmentConfig)(nil).Strip))
+}
+
+// TLSCipherSuites mocks base method.
+func (m *MockEnvironmentConfig) TLSCipherSuites() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TLSCipherSuites")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// TLSCipherSuites indicates an expected call of TLSCipherSuites.
+func (mr *MockEnvironmentConfigMockRecorder) TLSCipherSuites() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TLSCipherSuites", reflect.TypeOf((*MockEnvironmentConfig)(nil).TLSCipherSuites))
+}
+
+// TLSHandshakeTimeout mocks base method.
+func (m *MockEnvironmentConfig) TLSHandshakeTimeout() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TLSHandshakeTimeout")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// TLSHandshakeTimeout indicates an expected call of TLSHandshakeTimeout.
+func (mr *MockEnvironmentConfigMockRecorder) TLSHandshakeTimeout() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TLSHandshakeTimeout", reflect.TypeOf((*MockEnvironmentConfig)(nil).TLSHandshakeTimeout))
+}
+
+// TempDir mocks base method.
+func (m *MockEnvironmentConfig) TempDir() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TempDir")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// TempDir indicates an expected call of TempDir.
+func (mr *MockEnvironmentConfigMockRecorder) TempDir() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TempDir", reflect.TypeOf((*MockEnvironmentConfig)(nil).TempDir))
+}
+
+// TempMinFree mocks base method.
+func (m *MockEnvironmentConfig) TempMinFree() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TempMinFree")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// TempMinFree indicates an expected call of TempMinFree.
+func (mr *MockEnvironmentConfigMockRecorder) TempMinFree() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TempMinFree", reflect.TypeOf((*MockEnvironmentConfig)(nil).TempMinFree))
+}
+
+// URICaseInsensitive mocks base method.
+func (m *MockEnvironmentConfig) URICaseInsensitive() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "URICaseInsensitive")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// URICaseInsensitive indicates an expected call of URICaseInsensitive.
+func (mr *MockEnvironmentConfigMockRecorder) URICaseInsensitive() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "URICaseInsensitive", reflect.TypeOf((*MockEnvironmentConfig)(nil).URICaseInsensitive))
+}
+
+// URINormalize mocks base method.
+func (m *MockEnvironmentConfig) URINormalize() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "URINormalize")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// URINormalize indicates an expected call of URINormalize.
+func (mr *MockEnvironmentConfigMockRecorder) URINormalize() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "URINormalize", reflect.TypeOf((*MockEnvironmentConfig)(nil).URINormalize))
+}
+
+// UploadMaxAttempts mocks base method.
+func (m *MockEnvironmentConfig) UploadMaxAttempts() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadMaxAttempts")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// UploadMaxAttempts indicates an expected call of UploadMaxAttempts.
+func (mr *MockEnvironmentConfigMockRecorder) UploadMaxAttempts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadMaxAttempts", reflect.TypeOf((*MockEnvironmentConfig)(nil).UploadMaxAttempts))
+}
+
+// UploadMaxBackoff mocks base method.
+func (m *MockEnvironmentConfig) UploadMaxBackoff() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadMaxBackoff")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// UploadMaxBackoff indicates an expected call of UploadMaxBackoff.
+func (mr *MockEnvironmentConfigMockRecorder) UploadMaxBackoff() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadMaxBackoff", reflect.TypeOf((*MockEnvironmentConfig)(nil).UploadMaxBackoff))
+}
+
+// UploadSSE mocks base method.
+func (m *MockEnvironmentConfig) UploadSSE() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadSSE")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// UploadSSE indicates an expected call of UploadSSE.
+func (mr *MockEnvironmentConfigMockRecorder) UploadSSE() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadSSE", reflect.TypeOf((*MockEnvironmentConfig)(nil).UploadSSE))
+}
+
+// UploadSSEKMSKeyID mocks base method.
+func (m *MockEnvironmentConfig) UploadSSEKMSKeyID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadSSEKMSKeyID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// UploadSSEKMSKeyID indicates an expected call of UploadSSEKMSKeyID.
+func (mr *MockEnvironmentConfigMockRecorder) UploadSSEKMSKeyID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadSSEKMSKeyID", reflect.TypeOf((*MockEnvironmentConfig)(nil).UploadSSEKMSKeyID))
+}
+
+// UploadStorageClass mocks base method.
+func (m *MockEnvironmentConfig) UploadStorageClass() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadStorageClass")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// UploadStorageClass indicates an expected call of UploadStorageClass.
+func (mr *MockEnvironmentConfigMockRecorder) UploadStorageClass() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadStorageClass", reflect.TypeOf((*MockEnvironmentConfig)(nil).UploadStorageClass))
+}
+
+// UploadTags mocks base method.
+func (m *MockEnvironmentConfig) UploadTags() map[string]string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadTags")
+	ret0, _ := ret[0].(map[string]string)
+	return ret0
+}
+
+// UploadTags indicates an expected call of UploadTags.
+func (mr *MockEnvironmentConfigMockRecorder) UploadTags() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadTags", reflect.TypeOf((*MockEnvironmentConfig)(nil).UploadTags))
+}
+
+// UploadThreads mocks base method.
+func (m *MockEnvironmentConfig) UploadThreads() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadThreads")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// UploadThreads indicates an expected call of UploadThreads.
+func (mr *MockEnvironmentConfigMockRecorder) UploadThreads() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadThreads", reflect.TypeOf((*MockEnvironmentConfig)(nil).UploadThreads))
+}
+
+// Verbosity mocks base method.
+func (m *MockEnvironmentConfig) Verbosity() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verbosity")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Verbosity indicates an expected call of Verbosity.
+func (mr *MockEnvironmentConfigMockRecorder) Verbosity() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verbosity", reflect.TypeOf((*MockEnvironmentConfig)(nil).Verbosity))
+}
+
+// Xattrs mocks base method.
+func (m *MockEnvironmentConfig) Xattrs() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Xattrs")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// Xattrs indicates an expected call of Xattrs.
+func (mr *MockEnvironmentConfigMockRecorder) Xattrs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Xattrs", reflect.TypeOf((*MockEnvironmentConfig)(nil).Xattrs))
+}
+
+// MockGlobalConfig is a mock of GlobalConfig interface.
+type MockGlobalConfig struct {
+	ctrl     *gomock.Controller
+	recorder *MockGlobalConfigMockRecorder
+}
+
+// MockGlobalConfigMockRecorder is the mock recorder for MockGlobalConfig.
+type MockGlobalConfigMockRecorder struct {
+	mock *MockGlobalConfig
+}
+
+// NewMockGlobalConfig creates a new mock instance.
+func NewMockGlobalConfig(ctrl *gomock.Controller) *MockGlobalConfig {
+	mock := &MockGlobalConfig{ctrl: ctrl}
+	mock.recorder = &MockGlobalConfigMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGlobalConfig) EXPECT() *MockGlobalConfigMockRecorder {
+	return m.recorder
+}
+
+// Aliases mocks base method.
+func (m *MockGlobalConfig) Aliases() []AliasRule {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Aliases")
+	ret0, _ := ret[0].([]AliasRule)
+	return ret0
+}
+
+// Aliases indicates an expected call of Aliases.
+func (mr *MockGlobalConfigMockRecorder) Aliases() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Aliases", reflect.TypeOf((*MockGlobalConfig)(nil).Aliases))
+}
+
+// AuditLog mocks base method.
+func (m *MockGlobalConfig) AuditLog() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuditLog")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// AuditLog indicates an expected call of AuditLog.
+func (mr *MockGlobalConfigMockRecorder) AuditLog() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuditLog", reflect.TypeOf((*MockGlobalConfig)(nil).AuditLog))
+}
+
+// BlobCache mocks base method.
+func (m *MockGlobalConfig) BlobCache() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlobCache")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// BlobCache indicates an expected call of BlobCache.
+func (mr *MockGlobalConfigMockRecorder) BlobCache() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlobCache", reflect.TypeOf((*MockGlobalConfig)(nil).BlobCache))
+}
+
+// BlobCacheMaxAge mocks base method.
+func (m *MockGlobalConfig) BlobCacheMaxAge() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlobCacheMaxAge")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// BlobCacheMaxAge indicates an expected call of BlobCacheMaxAge.
+func (mr *MockGlobalConfigMockRecorder) BlobCacheMaxAge() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlobCacheMaxAge", reflect.TypeOf((*MockGlobalConfig)(nil).BlobCacheMaxAge))
+}
+
+// BlobKeyPrefix mocks base method.
+func (m *MockGlobalConfig) BlobKeyPrefix() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlobKeyPrefix")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// BlobKeyPrefix indicates an expected call of BlobKeyPrefix.
+func (mr *MockGlobalConfigMockRecorder) BlobKeyPrefix() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlobKeyPrefix", reflect.TypeOf((*MockGlobalConfig)(nil).BlobKeyPrefix))
+}
+
+// CacheControlDefault mocks base method.
+func (m *MockGlobalConfig) CacheControlDefault() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CacheControlDefault")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// CacheControlDefault indicates an expected call of CacheControlDefault.
+func (mr *MockGlobalConfigMockRecorder) CacheControlDefault() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CacheControlDefault", reflect.TypeOf((*MockGlobalConfig)(nil).CacheControlDefault))
+}
+
+// CacheControlRules mocks base method.
+func (m *MockGlobalConfig) CacheControlRules() []CacheControlRule {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CacheControlRules")
+	ret0, _ := ret[0].([]CacheControlRule)
+	return ret0
+}
+
+// CacheControlRules indicates an expected call of CacheControlRules.
+func (mr *MockGlobalConfigMockRecorder) CacheControlRules() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CacheControlRules", reflect.TypeOf((*MockGlobalConfig)(nil).CacheControlRules))
+}
+
+// CdnURL mocks base method.
+func (m *MockGlobalConfig) CdnURL() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CdnURL")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// CdnURL indicates an expected call of CdnURL.
+func (mr *MockGlobalConfigMockRecorder) CdnURL() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CdnURL", reflect.TypeOf((*MockGlobalConfig)(nil).CdnURL))
+}
+
+// ConcurrencyMax mocks base method.
+func (m *MockGlobalConfig) ConcurrencyMax() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConcurrencyMax")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// ConcurrencyMax indicates an expected call of ConcurrencyMax.
+func (mr *MockGlobalConfigMockRecorder) ConcurrencyMax() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConcurrencyMax", reflect.TypeOf((*MockGlobalConfig)(nil).ConcurrencyMax))
+}
+
+// ConcurrencyMin mocks base method.
+func (m *MockGlobalConfig) ConcurrencyMin() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConcurrencyMin")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// ConcurrencyMin indicates an expected call of ConcurrencyMin.
+func (mr *MockGlobalConfigMockRecorder) ConcurrencyMin() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConcurrencyMin", reflect.TypeOf((*MockGlobalConfig)(nil).ConcurrencyMin))
+}
+
+// ContentRules mocks base method.
+func (m *MockGlobalConfig) ContentRules() []ContentRule {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ContentRules")
+	ret0, _ := ret[0].([]ContentRule)
+	return ret0
+}
+
+// ContentRules indicates an expected call of ContentRules.
+func (mr *MockGlobalConfigMockRecorder) ContentRules() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContentRules", reflect.TypeOf((*MockGlobalConfig)(nil).ContentRules))
+}
+
+// Diag mocks base method.
+func (m *MockGlobalConfig) Diag() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Diag")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Diag indicates an expected call of Diag.
+func (mr *MockGlobalConfigMockRecorder) Diag() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Diag", reflect.TypeOf((*MockGlobalConfig)(nil).Diag))
+}
+
+// DialTimeout mocks base method.
+func (m *MockGlobalConfig) DialTimeout() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DialTimeout")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// DialTimeout indicates an expected call of DialTimeout.
+func (mr *MockGlobalConfigMockRecorder) DialTimeout() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DialTimeout", reflect.TypeOf((*MockGlobalConfig)(nil).DialTimeout))
+}
+
+// EnvironmentForDest mocks base method.
+func (m *MockGlobalConfig) EnvironmentForDest(arg0 context.Context, arg1 string) EnvironmentConfig {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnvironmentForDest", arg0, arg1)
+	ret0, _ := ret[0].(EnvironmentConfig)
+	return ret0
+}
+
+// EnvironmentForDest indicates an expected call of EnvironmentForDest.
+func (mr *MockGlobalConfigMockRecorder) EnvironmentForDest(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnvironmentForDest", reflect.TypeOf((*MockGlobalConfig)(nil).EnvironmentForDest), arg0, arg1)
+}
+
+// Exclude mocks base method.
+func (m *MockGlobalConfig) Exclude() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exclude")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// Exclude indicates an expected call of Exclude.
+func (mr *MockGlobalConfigMockRecorder) Exclude() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exclude", reflect.TypeOf((*MockGlobalConfig)(nil).Exclude))
+}
+
+// GwBatchSize mocks base method.
+func (m *MockGlobalConfig) GwBatchSize() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwBatchSize")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwBatchSize indicates an expected call of GwBatchSize.
+func (mr *MockGlobalConfigMockRecorder) GwBatchSize() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwBatchSize", reflect.TypeOf((*MockGlobalConfig)(nil).GwBatchSize))
+}
+
+// GwBatchSizeAuto mocks base method.
+func (m *MockGlobalConfig) GwBatchSizeAuto() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwBatchSizeAuto")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// GwBatchSizeAuto indicates an expected call of GwBatchSizeAuto.
+func (mr *MockGlobalConfigMockRecorder) GwBatchSizeAuto() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwBatchSizeAuto", reflect.TypeOf((*MockGlobalConfig)(nil).GwBatchSizeAuto))
+}
+
+// GwBatchSizeMax mocks base method.
+func (m *MockGlobalConfig) GwBatchSizeMax() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwBatchSizeMax")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwBatchSizeMax indicates an expected call of GwBatchSizeMax.
+func (mr *MockGlobalConfigMockRecorder) GwBatchSizeMax() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwBatchSizeMax", reflect.TypeOf((*MockGlobalConfig)(nil).GwBatchSizeMax))
+}
+
+// GwBatchSizeMin mocks base method.
+func (m *MockGlobalConfig) GwBatchSizeMin() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwBatchSizeMin")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwBatchSizeMin indicates an expected call of GwBatchSizeMin.
+func (mr *MockGlobalConfigMockRecorder) GwBatchSizeMin() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwBatchSizeMin", reflect.TypeOf((*MockGlobalConfig)(nil).GwBatchSizeMin))
+}
+
+// GwCacheControl mocks base method.
+func (m *MockGlobalConfig) GwCacheControl() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwCacheControl")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// GwCacheControl indicates an expected call of GwCacheControl.
+func (mr *MockGlobalConfigMockRecorder) GwCacheControl() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCacheControl", reflect.TypeOf((*MockGlobalConfig)(nil).GwCacheControl))
+}
+
+// GwCert mocks base method.
+func (m *MockGlobalConfig) GwCert() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwCert")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GwCert indicates an expected call of GwCert.
+func (mr *MockGlobalConfigMockRecorder) GwCert() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCert", reflect.TypeOf((*MockGlobalConfig)(nil).GwCert))
 }
 
-// Diag mocks base method.
-func (m *MockEnvironmentConfig) Diag() bool {
+// GwCertCommand mocks base method.
+func (m *MockGlobalConfig) GwCertCommand() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Diag")
+	ret := m.ctrl.Call(m, "GwCertCommand")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GwCertCommand indicates an expected call of GwCertCommand.
+func (mr *MockGlobalConfigMockRecorder) GwCertCommand() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCertCommand", reflect.TypeOf((*MockGlobalConfig)(nil).GwCertCommand))
+}
+
+// GwCertExpiryWarning mocks base method.
+func (m *MockGlobalConfig) GwCertExpiryWarning() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwCertExpiryWarning")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwCertExpiryWarning indicates an expected call of GwCertExpiryWarning.
+func (mr *MockGlobalConfigMockRecorder) GwCertExpiryWarning() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCertExpiryWarning", reflect.TypeOf((*MockGlobalConfig)(nil).GwCertExpiryWarning))
+}
+
+// GwCommit mocks base method.
+func (m *MockGlobalConfig) GwCommit() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwCommit")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GwCommit indicates an expected call of GwCommit.
+func (mr *MockGlobalConfigMockRecorder) GwCommit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCommit", reflect.TypeOf((*MockGlobalConfig)(nil).GwCommit))
+}
+
+// GwCommitMaxAttempts mocks base method.
+func (m *MockGlobalConfig) GwCommitMaxAttempts() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwCommitMaxAttempts")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwCommitMaxAttempts indicates an expected call of GwCommitMaxAttempts.
+func (mr *MockGlobalConfigMockRecorder) GwCommitMaxAttempts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCommitMaxAttempts", reflect.TypeOf((*MockGlobalConfig)(nil).GwCommitMaxAttempts))
+}
+
+// GwCommitTimeout mocks base method.
+func (m *MockGlobalConfig) GwCommitTimeout() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwCommitTimeout")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwCommitTimeout indicates an expected call of GwCommitTimeout.
+func (mr *MockGlobalConfigMockRecorder) GwCommitTimeout() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCommitTimeout", reflect.TypeOf((*MockGlobalConfig)(nil).GwCommitTimeout))
+}
+
+// GwEnv mocks base method.
+func (m *MockGlobalConfig) GwEnv() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwEnv")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GwEnv indicates an expected call of GwEnv.
+func (mr *MockGlobalConfigMockRecorder) GwEnv() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwEnv", reflect.TypeOf((*MockGlobalConfig)(nil).GwEnv))
+}
+
+// GwItemSchema mocks base method.
+func (m *MockGlobalConfig) GwItemSchema() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwItemSchema")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwItemSchema indicates an expected call of GwItemSchema.
+func (mr *MockGlobalConfigMockRecorder) GwItemSchema() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwItemSchema", reflect.TypeOf((*MockGlobalConfig)(nil).GwItemSchema))
+}
+
+// GwKey mocks base method.
+func (m *MockGlobalConfig) GwKey() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwKey")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GwKey indicates an expected call of GwKey.
+func (mr *MockGlobalConfigMockRecorder) GwKey() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwKey", reflect.TypeOf((*MockGlobalConfig)(nil).GwKey))
+}
+
+// GwKeyCommand mocks base method.
+func (m *MockGlobalConfig) GwKeyCommand() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwKeyCommand")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GwKeyCommand indicates an expected call of GwKeyCommand.
+func (mr *MockGlobalConfigMockRecorder) GwKeyCommand() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwKeyCommand", reflect.TypeOf((*MockGlobalConfig)(nil).GwKeyCommand))
+}
+
+// GwMaxAttempts mocks base method.
+func (m *MockGlobalConfig) GwMaxAttempts() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwMaxAttempts")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwMaxAttempts indicates an expected call of GwMaxAttempts.
+func (mr *MockGlobalConfigMockRecorder) GwMaxAttempts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwMaxAttempts", reflect.TypeOf((*MockGlobalConfig)(nil).GwMaxAttempts))
+}
+
+// GwMaxBackoff mocks base method.
+func (m *MockGlobalConfig) GwMaxBackoff() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwMaxBackoff")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwMaxBackoff indicates an expected call of GwMaxBackoff.
+func (mr *MockGlobalConfigMockRecorder) GwMaxBackoff() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwMaxBackoff", reflect.TypeOf((*MockGlobalConfig)(nil).GwMaxBackoff))
+}
+
+// GwMaxBatchBytes mocks base method.
+func (m *MockGlobalConfig) GwMaxBatchBytes() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwMaxBatchBytes")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwMaxBatchBytes indicates an expected call of GwMaxBatchBytes.
+func (mr *MockGlobalConfigMockRecorder) GwMaxBatchBytes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwMaxBatchBytes", reflect.TypeOf((*MockGlobalConfig)(nil).GwMaxBatchBytes))
+}
+
+// GwNoReplace mocks base method.
+func (m *MockGlobalConfig) GwNoReplace() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwNoReplace")
 	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
-// Diag indicates an expected call of Diag.
-func (mr *MockEnvironmentConfigMockRecorder) Diag() *gomock.Call {
+// GwNoReplace indicates an expected call of GwNoReplace.
+func (mr *MockGlobalConfigMockRecorder) GwNoReplace() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Diag", reflect.TypeOf((*MockEnvironmentConfig)(nil).Diag))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwNoReplace", reflect.TypeOf((*MockGlobalConfig)(nil).GwNoReplace))
 }
 
-// GwBatchSize mocks base method.
-func (m *MockEnvironmentConfig) GwBatchSize() int {
+// GwPermanentStatuses mocks base method.
+func (m *MockGlobalConfig) GwPermanentStatuses() []int {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GwBatchSize")
+	ret := m.ctrl.Call(m, "GwPermanentStatuses")
+	ret0, _ := ret[0].([]int)
+	return ret0
+}
+
+// GwPermanentStatuses indicates an expected call of GwPermanentStatuses.
+func (mr *MockGlobalConfigMockRecorder) GwPermanentStatuses() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwPermanentStatuses", reflect.TypeOf((*MockGlobalConfig)(nil).GwPermanentStatuses))
+}
+
+// GwPollInterval mocks base method.
+func (m *MockGlobalConfig) GwPollInterval() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwPollInterval")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwPollInterval indicates an expected call of GwPollInterval.
+func (mr *MockGlobalConfigMockRecorder) GwPollInterval() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwPollInterval", reflect.TypeOf((*MockGlobalConfig)(nil).GwPollInterval))
+}
+
+// GwProxy mocks base method.
+func (m *MockGlobalConfig) GwProxy() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwProxy")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GwProxy indicates an expected call of GwProxy.
+func (mr *MockGlobalConfigMockRecorder) GwProxy() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwProxy", reflect.TypeOf((*MockGlobalConfig)(nil).GwProxy))
+}
+
+// GwReadMaxAttempts mocks base method.
+func (m *MockGlobalConfig) GwReadMaxAttempts() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwReadMaxAttempts")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwReadMaxAttempts indicates an expected call of GwReadMaxAttempts.
+func (mr *MockGlobalConfigMockRecorder) GwReadMaxAttempts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwReadMaxAttempts", reflect.TypeOf((*MockGlobalConfig)(nil).GwReadMaxAttempts))
+}
+
+// GwReadTimeout mocks base method.
+func (m *MockGlobalConfig) GwReadTimeout() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwReadTimeout")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwReadTimeout indicates an expected call of GwReadTimeout.
+func (mr *MockGlobalConfigMockRecorder) GwReadTimeout() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwReadTimeout", reflect.TypeOf((*MockGlobalConfig)(nil).GwReadTimeout))
+}
+
+// GwRedirects mocks base method.
+func (m *MockGlobalConfig) GwRedirects() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwRedirects")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GwRedirects indicates an expected call of GwRedirects.
+func (mr *MockGlobalConfigMockRecorder) GwRedirects() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwRedirects", reflect.TypeOf((*MockGlobalConfig)(nil).GwRedirects))
+}
+
+// GwRetryStatuses mocks base method.
+func (m *MockGlobalConfig) GwRetryStatuses() []int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwRetryStatuses")
+	ret0, _ := ret[0].([]int)
+	return ret0
+}
+
+// GwRetryStatuses indicates an expected call of GwRetryStatuses.
+func (mr *MockGlobalConfigMockRecorder) GwRetryStatuses() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwRetryStatuses", reflect.TypeOf((*MockGlobalConfig)(nil).GwRetryStatuses))
+}
+
+// GwURL mocks base method.
+func (m *MockGlobalConfig) GwURL() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwURL")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GwURL indicates an expected call of GwURL.
+func (mr *MockGlobalConfigMockRecorder) GwURL() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwURL", reflect.TypeOf((*MockGlobalConfig)(nil).GwURL))
+}
+
+// GwUseIdempotencyKeys mocks base method.
+func (m *MockGlobalConfig) GwUseIdempotencyKeys() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwUseIdempotencyKeys")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// GwUseIdempotencyKeys indicates an expected call of GwUseIdempotencyKeys.
+func (mr *MockGlobalConfigMockRecorder) GwUseIdempotencyKeys() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwUseIdempotencyKeys", reflect.TypeOf((*MockGlobalConfig)(nil).GwUseIdempotencyKeys))
+}
+
+// GwWriteMaxAttempts mocks base method.
+func (m *MockGlobalConfig) GwWriteMaxAttempts() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwWriteMaxAttempts")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GwWriteMaxAttempts indicates an expected call of GwWriteMaxAttempts.
+func (mr *MockGlobalConfigMockRecorder) GwWriteMaxAttempts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwWriteMaxAttempts", reflect.TypeOf((*MockGlobalConfig)(nil).GwWriteMaxAttempts))
+}
+
+// GwWriteTimeout mocks base method.
+func (m *MockGlobalConfig) GwWriteTimeout() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GwWriteTimeout")
 	ret0, _ := ret[0].(int)
 	return ret0
 }
 
-// GwBatchSize indicates an expected call of GwBatchSize.
-func (mr *MockEnvironmentConfigMockRecorder) GwBatchSize() *gomock.Call {
+// GwWriteTimeout indicates an expected call of GwWriteTimeout.
+func (mr *MockGlobalConfigMockRecorder) GwWriteTimeout() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwWriteTimeout", reflect.TypeOf((*MockGlobalConfig)(nil).GwWriteTimeout))
+}
+
+// Include mocks base method.
+func (m *MockGlobalConfig) Include() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Include")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// Include indicates an expected call of Include.
+func (mr *MockGlobalConfigMockRecorder) Include() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Include", reflect.TypeOf((*MockGlobalConfig)(nil).Include))
+}
+
+// LogLevel mocks base method.
+func (m *MockGlobalConfig) LogLevel() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogLevel")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// LogLevel indicates an expected call of LogLevel.
+func (mr *MockGlobalConfigMockRecorder) LogLevel() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwBatchSize", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwBatchSize))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogLevel", reflect.TypeOf((*MockGlobalConfig)(nil).LogLevel))
 }
 
-// GwCert mocks base method.
-func (m *MockEnvironmentConfig) GwCert() string {
+// Logger mocks base method.
+func (m *MockGlobalConfig) Logger() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GwCert")
+	ret := m.ctrl.Call(m, "Logger")
 	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// GwCert indicates an expected call of GwCert.
-func (mr *MockEnvironmentConfigMockRecorder) GwCert() *gomock.Call {
+// Logger indicates an expected call of Logger.
+func (mr *MockGlobalConfigMockRecorder) Logger() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCert", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwCert))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logger", reflect.TypeOf((*MockGlobalConfig)(nil).Logger))
 }
 
-// GwCommit mocks base method.
-func (m *MockEnvironmentConfig) GwCommit() string {
+// MIMESniff mocks base method.
+func (m *MockGlobalConfig) MIMESniff() bool {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GwCommit")
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "MIMESniff")
+	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
-// GwCommit indicates an expected call of GwCommit.
-func (mr *MockEnvironmentConfigMockRecorder) GwCommit() *gomock.Call {
+// MIMESniff indicates an expected call of MIMESniff.
+func (mr *MockGlobalConfigMockRecorder) MIMESniff() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCommit", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwCommit))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MIMESniff", reflect.TypeOf((*MockGlobalConfig)(nil).MIMESniff))
 }
 
-// GwEnv mocks base method.
-func (m *MockEnvironmentConfig) GwEnv() string {
+// MaxClockSkew mocks base method.
+func (m *MockGlobalConfig) MaxClockSkew() int {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GwEnv")
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "MaxClockSkew")
+	ret0, _ := ret[0].(int)
 	return ret0
 }
 
-// GwEnv indicates an expected call of GwEnv.
-func (mr *MockEnvironmentConfigMockRecorder) GwEnv() *gomock.Call {
+// MaxClockSkew indicates an expected call of MaxClockSkew.
+func (mr *MockGlobalConfigMockRecorder) MaxClockSkew() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwEnv", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwEnv))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxClockSkew", reflect.TypeOf((*MockGlobalConfig)(nil).MaxClockSkew))
 }
 
-// GwKey mocks base method.
-func (m *MockEnvironmentConfig) GwKey() string {
+// MaxPublishBytes mocks base method.
+func (m *MockGlobalConfig) MaxPublishBytes() int64 {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GwKey")
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "MaxPublishBytes")
+	ret0, _ := ret[0].(int64)
 	return ret0
 }
 
-// GwKey indicates an expected call of GwKey.
-func (mr *MockEnvironmentConfigMockRecorder) GwKey() *gomock.Call {
+// MaxPublishBytes indicates an expected call of MaxPublishBytes.
+func (mr *MockGlobalConfigMockRecorder) MaxPublishBytes() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwKey", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwKey))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxPublishBytes", reflect.TypeOf((*MockGlobalConfig)(nil).MaxPublishBytes))
 }
 
-// GwMaxAttempts mocks base method.
-func (m *MockEnvironmentConfig) GwMaxAttempts() int {
+// MaxPublishItems mocks base method.
+func (m *MockGlobalConfig) MaxPublishItems() int {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GwMaxAttempts")
+	ret := m.ctrl.Call(m, "MaxPublishItems")
 	ret0, _ := ret[0].(int)
 	return ret0
 }
 
-// GwMaxAttempts indicates an expected call of GwMaxAttempts.
-func (mr *MockEnvironmentConfigMockRecorder) GwMaxAttempts() *gomock.Call {
+// MaxPublishItems indicates an expected call of MaxPublishItems.
+func (mr *MockGlobalConfigMockRecorder) MaxPublishItems() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwMaxAttempts", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwMaxAttempts))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxPublishItems", reflect.TypeOf((*MockGlobalConfig)(nil).MaxPublishItems))
 }
 
-// GwMaxBackoff mocks base method.
-func (m *MockEnvironmentConfig) GwMaxBackoff() int {
+// MaxURILength mocks base method.
+func (m *MockGlobalConfig) MaxURILength() int {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GwMaxBackoff")
+	ret := m.ctrl.Call(m, "MaxURILength")
 	ret0, _ := ret[0].(int)
 	return ret0
 }
 
-// GwMaxBackoff indicates an expected call of GwMaxBackoff.
-func (mr *MockEnvironmentConfigMockRecorder) GwMaxBackoff() *gomock.Call {
+// MaxURILength indicates an expected call of MaxURILength.
+func (mr *MockGlobalConfigMockRecorder) MaxURILength() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwMaxBackoff", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwMaxBackoff))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxURILength", reflect.TypeOf((*MockGlobalConfig)(nil).MaxURILength))
 }
 
-// GwPollInterval mocks base method.
-func (m *MockEnvironmentConfig) GwPollInterval() int {
+// NoProxy mocks base method.
+func (m *MockGlobalConfig) NoProxy() []string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GwPollInterval")
-	ret0, _ := ret[0].(int)
+	ret := m.ctrl.Call(m, "NoProxy")
+	ret0, _ := ret[0].([]string)
 	return ret0
 }
 
-// GwPollInterval indicates an expected call of GwPollInterval.
-func (mr *MockEnvironmentConfigMockRecorder) GwPollInterval() *gomock.Call {
+// NoProxy indicates an expected call of NoProxy.
+func (mr *MockGlobalConfigMockRecorder) NoProxy() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwPollInterval", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwPollInterval))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NoProxy", reflect.TypeOf((*MockGlobalConfig)(nil).NoProxy))
 }
 
-// GwURL mocks base method.
-func (m *MockEnvironmentConfig) GwURL() string {
+// ProtectedEnvs mocks base method.
+func (m *MockGlobalConfig) ProtectedEnvs() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GwURL")
+	ret := m.ctrl.Call(m, "ProtectedEnvs")
 	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// GwURL indicates an expected call of GwURL.
-func (mr *MockEnvironmentConfigMockRecorder) GwURL() *gomock.Call {
+// ProtectedEnvs indicates an expected call of ProtectedEnvs.
+func (mr *MockGlobalConfigMockRecorder) ProtectedEnvs() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwURL", reflect.TypeOf((*MockEnvironmentConfig)(nil).GwURL))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProtectedEnvs", reflect.TypeOf((*MockGlobalConfig)(nil).ProtectedEnvs))
 }
 
-// LogLevel mocks base method.
-func (m *MockEnvironmentConfig) LogLevel() string {
+// PublishLock mocks base method.
+func (m *MockGlobalConfig) PublishLock() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "LogLevel")
+	ret := m.ctrl.Call(m, "PublishLock")
 	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// LogLevel indicates an expected call of LogLevel.
-func (mr *MockEnvironmentConfigMockRecorder) LogLevel() *gomock.Call {
+// PublishLock indicates an expected call of PublishLock.
+func (mr *MockGlobalConfigMockRecorder) PublishLock() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogLevel", reflect.TypeOf((*MockEnvironmentConfig)(nil).LogLevel))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishLock", reflect.TypeOf((*MockGlobalConfig)(nil).PublishLock))
 }
 
-// Logger mocks base method.
-func (m *MockEnvironmentConfig) Logger() string {
+// PublishLockWait mocks base method.
+func (m *MockGlobalConfig) PublishLockWait() int {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Logger")
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "PublishLockWait")
+	ret0, _ := ret[0].(int)
 	return ret0
 }
 
-// Logger indicates an expected call of Logger.
-func (mr *MockEnvironmentConfigMockRecorder) Logger() *gomock.Call {
+// PublishLockWait indicates an expected call of PublishLockWait.
+func (mr *MockGlobalConfigMockRecorder) PublishLockWait() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logger", reflect.TypeOf((*MockEnvironmentConfig)(nil).Logger))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishLockWait", reflect.TypeOf((*MockGlobalConfig)(nil).PublishLockWait))
 }
 
-// Prefix mocks base method.
-func (m *MockEnvironmentConfig) Prefix() string {
+// PublishState mocks base method.
+func (m *MockGlobalConfig) PublishState() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Prefix")
+	ret := m.ctrl.Call(m, "PublishState")
 	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// Prefix indicates an expected call of Prefix.
-func (mr *MockEnvironmentConfigMockRecorder) Prefix() *gomock.Call {
+// PublishState indicates an expected call of PublishState.
+func (mr *MockGlobalConfigMockRecorder) PublishState() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prefix", reflect.TypeOf((*MockEnvironmentConfig)(nil).Prefix))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishState", reflect.TypeOf((*MockGlobalConfig)(nil).PublishState))
 }
 
 // RsyncMode mocks base method.
-func (m *MockEnvironmentConfig) RsyncMode() string {
+func (m *MockGlobalConfig) RsyncMode() string {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "RsyncMode")
 	ret0, _ := ret[0].(string)
@@ -511,284 +3166,261 @@ func (m *MockEnvironmentConfig) RsyncMode() string {
 }
 
 // RsyncMode indicates an expected call of RsyncMode.
-func (mr *MockEnvironmentConfigMockRecorder) RsyncMode() *gomock.Call {
+func (mr *MockGlobalConfigMockRecorder) RsyncMode() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RsyncMode", reflect.TypeOf((*MockEnvironmentConfig)(nil).RsyncMode))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RsyncMode", reflect.TypeOf((*MockGlobalConfig)(nil).RsyncMode))
 }
 
-// Strip mocks base method.
-func (m *MockEnvironmentConfig) Strip() string {
+// S3Access mocks base method.
+func (m *MockGlobalConfig) S3Access() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Strip")
+	ret := m.ctrl.Call(m, "S3Access")
 	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// Strip indicates an expected call of Strip.
-func (mr *MockEnvironmentConfigMockRecorder) Strip() *gomock.Call {
+// S3Access indicates an expected call of S3Access.
+func (mr *MockGlobalConfigMockRecorder) S3Access() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Strip", reflect.TypeOf((*MockEnvironmentConfig)(nil).Strip))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "S3Access", reflect.TypeOf((*MockGlobalConfig)(nil).S3Access))
 }
 
-// UploadThreads mocks base method.
-func (m *MockEnvironmentConfig) UploadThreads() int {
+// S3Bucket mocks base method.
+func (m *MockGlobalConfig) S3Bucket() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UploadThreads")
-	ret0, _ := ret[0].(int)
+	ret := m.ctrl.Call(m, "S3Bucket")
+	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// UploadThreads indicates an expected call of UploadThreads.
-func (mr *MockEnvironmentConfigMockRecorder) UploadThreads() *gomock.Call {
+// S3Bucket indicates an expected call of S3Bucket.
+func (mr *MockGlobalConfigMockRecorder) S3Bucket() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadThreads", reflect.TypeOf((*MockEnvironmentConfig)(nil).UploadThreads))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "S3Bucket", reflect.TypeOf((*MockGlobalConfig)(nil).S3Bucket))
 }
 
-// Verbosity mocks base method.
-func (m *MockEnvironmentConfig) Verbosity() int {
+// S3Proxy mocks base method.
+func (m *MockGlobalConfig) S3Proxy() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Verbosity")
-	ret0, _ := ret[0].(int)
+	ret := m.ctrl.Call(m, "S3Proxy")
+	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// Verbosity indicates an expected call of Verbosity.
-func (mr *MockEnvironmentConfigMockRecorder) Verbosity() *gomock.Call {
+// S3Proxy indicates an expected call of S3Proxy.
+func (mr *MockGlobalConfigMockRecorder) S3Proxy() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verbosity", reflect.TypeOf((*MockEnvironmentConfig)(nil).Verbosity))
-}
-
-// MockGlobalConfig is a mock of GlobalConfig interface.
-type MockGlobalConfig struct {
-	ctrl     *gomock.Controller
-	recorder *MockGlobalConfigMockRecorder
-}
-
-// MockGlobalConfigMockRecorder is the mock recorder for MockGlobalConfig.
-type MockGlobalConfigMockRecorder struct {
-	mock *MockGlobalConfig
-}
-
-// NewMockGlobalConfig creates a new mock instance.
-func NewMockGlobalConfig(ctrl *gomock.Controller) *MockGlobalConfig {
-	mock := &MockGlobalConfig{ctrl: ctrl}
-	mock.recorder = &MockGlobalConfigMockRecorder{mock}
-	return mock
-}
-
-// EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockGlobalConfig) EXPECT() *MockGlobalConfigMockRecorder {
-	return m.recorder
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "S3Proxy", reflect.TypeOf((*MockGlobalConfig)(nil).S3Proxy))
 }
 
-// Diag mocks base method.
-func (m *MockGlobalConfig) Diag() bool {
+// Settings mocks base method.
+func (m *MockGlobalConfig) Settings() []Setting {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Diag")
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "Settings")
+	ret0, _ := ret[0].([]Setting)
 	return ret0
 }
 
-// Diag indicates an expected call of Diag.
-func (mr *MockGlobalConfigMockRecorder) Diag() *gomock.Call {
+// Settings indicates an expected call of Settings.
+func (mr *MockGlobalConfigMockRecorder) Settings() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Diag", reflect.TypeOf((*MockGlobalConfig)(nil).Diag))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Settings", reflect.TypeOf((*MockGlobalConfig)(nil).Settings))
 }
 
-// EnvironmentForDest mocks base method.
-func (m *MockGlobalConfig) EnvironmentForDest(arg0 context.Context, arg1 string) EnvironmentConfig {
+// SkipEmptyFiles mocks base method.
+func (m *MockGlobalConfig) SkipEmptyFiles() bool {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "EnvironmentForDest", arg0, arg1)
-	ret0, _ := ret[0].(EnvironmentConfig)
+	ret := m.ctrl.Call(m, "SkipEmptyFiles")
+	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
-// EnvironmentForDest indicates an expected call of EnvironmentForDest.
-func (mr *MockGlobalConfigMockRecorder) EnvironmentForDest(arg0, arg1 interface{}) *gomock.Call {
+// SkipEmptyFiles indicates an expected call of SkipEmptyFiles.
+func (mr *MockGlobalConfigMockRecorder) SkipEmptyFiles() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnvironmentForDest", reflect.TypeOf((*MockGlobalConfig)(nil).EnvironmentForDest), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SkipEmptyFiles", reflect.TypeOf((*MockGlobalConfig)(nil).SkipEmptyFiles))
 }
 
-// GwBatchSize mocks base method.
-func (m *MockGlobalConfig) GwBatchSize() int {
+// Strip mocks base method.
+func (m *MockGlobalConfig) Strip() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GwBatchSize")
-	ret0, _ := ret[0].(int)
+	ret := m.ctrl.Call(m, "Strip")
+	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// GwBatchSize indicates an expected call of GwBatchSize.
-func (mr *MockGlobalConfigMockRecorder) GwBatchSize() *gomock.Call {
+// Strip indicates an expected call of Strip.
+func (mr *MockGlobalConfigMockRecorder) Strip() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwBatchSize", reflect.TypeOf((*MockGlobalConfig)(nil).GwBatchSize))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Strip", reflect.TypeOf((*MockGlobalConfig)(nil).Strip))
 }
 
-// GwCert mocks base method.
-func (m *MockGlobalConfig) GwCert() string {
+// TLSCipherSuites mocks base method.
+func (m *MockGlobalConfig) TLSCipherSuites() []string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GwCert")
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "TLSCipherSuites")
+	ret0, _ := ret[0].([]string)
 	return ret0
 }
 
-// GwCert indicates an expected call of GwCert.
-func (mr *MockGlobalConfigMockRecorder) GwCert() *gomock.Call {
+// TLSCipherSuites indicates an expected call of TLSCipherSuites.
+func (mr *MockGlobalConfigMockRecorder) TLSCipherSuites() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCert", reflect.TypeOf((*MockGlobalConfig)(nil).GwCert))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TLSCipherSuites", reflect.TypeOf((*MockGlobalConfig)(nil).TLSCipherSuites))
 }
 
-// GwCommit mocks base method.
-func (m *MockGlobalConfig) GwCommit() string {
+// TLSHandshakeTimeout mocks base method.
+func (m *MockGlobalConfig) TLSHandshakeTimeout() int {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GwCommit")
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "TLSHandshakeTimeout")
+	ret0, _ := ret[0].(int)
 	return ret0
 }
 
-// GwCommit indicates an expected call of GwCommit.
-func (mr *MockGlobalConfigMockRecorder) GwCommit() *gomock.Call {
+// TLSHandshakeTimeout indicates an expected call of TLSHandshakeTimeout.
+func (mr *MockGlobalConfigMockRecorder) TLSHandshakeTimeout() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwCommit", reflect.TypeOf((*MockGlobalConfig)(nil).GwCommit))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TLSHandshakeTimeout", reflect.TypeOf((*MockGlobalConfig)(nil).TLSHandshakeTimeout))
 }
 
-// GwEnv mocks base method.
-func (m *MockGlobalConfig) GwEnv() string {
+// TempDir mocks base method.
+func (m *MockGlobalConfig) TempDir() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GwEnv")
+	ret := m.ctrl.Call(m, "TempDir")
 	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// GwEnv indicates an expected call of GwEnv.
-func (mr *MockGlobalConfigMockRecorder) GwEnv() *gomock.Call {
+// TempDir indicates an expected call of TempDir.
+func (mr *MockGlobalConfigMockRecorder) TempDir() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwEnv", reflect.TypeOf((*MockGlobalConfig)(nil).GwEnv))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TempDir", reflect.TypeOf((*MockGlobalConfig)(nil).TempDir))
 }
 
-// GwKey mocks base method.
-func (m *MockGlobalConfig) GwKey() string {
+// TempMinFree mocks base method.
+func (m *MockGlobalConfig) TempMinFree() int64 {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GwKey")
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "TempMinFree")
+	ret0, _ := ret[0].(int64)
 	return ret0
 }
 
-// GwKey indicates an expected call of GwKey.
-func (mr *MockGlobalConfigMockRecorder) GwKey() *gomock.Call {
+// TempMinFree indicates an expected call of TempMinFree.
+func (mr *MockGlobalConfigMockRecorder) TempMinFree() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwKey", reflect.TypeOf((*MockGlobalConfig)(nil).GwKey))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TempMinFree", reflect.TypeOf((*MockGlobalConfig)(nil).TempMinFree))
 }
 
-// GwMaxAttempts mocks base method.
-func (m *MockGlobalConfig) GwMaxAttempts() int {
+// URICaseInsensitive mocks base method.
+func (m *MockGlobalConfig) URICaseInsensitive() bool {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GwMaxAttempts")
-	ret0, _ := ret[0].(int)
+	ret := m.ctrl.Call(m, "URICaseInsensitive")
+	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
-// GwMaxAttempts indicates an expected call of GwMaxAttempts.
-func (mr *MockGlobalConfigMockRecorder) GwMaxAttempts() *gomock.Call {
+// URICaseInsensitive indicates an expected call of URICaseInsensitive.
+func (mr *MockGlobalConfigMockRecorder) URICaseInsensitive() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwMaxAttempts", reflect.TypeOf((*MockGlobalConfig)(nil).GwMaxAttempts))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "URICaseInsensitive", reflect.TypeOf((*MockGlobalConfig)(nil).URICaseInsensitive))
 }
 
-// GwMaxBackoff mocks base method.
-func (m *MockGlobalConfig) GwMaxBackoff() int {
+// URINormalize mocks base method.
+func (m *MockGlobalConfig) URINormalize() []string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GwMaxBackoff")
-	ret0, _ := ret[0].(int)
+	ret := m.ctrl.Call(m, "URINormalize")
+	ret0, _ := ret[0].([]string)
 	return ret0
 }
 
-// GwMaxBackoff indicates an expected call of GwMaxBackoff.
-func (mr *MockGlobalConfigMockRecorder) GwMaxBackoff() *gomock.Call {
+// URINormalize indicates an expected call of URINormalize.
+func (mr *MockGlobalConfigMockRecorder) URINormalize() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwMaxBackoff", reflect.TypeOf((*MockGlobalConfig)(nil).GwMaxBackoff))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "URINormalize", reflect.TypeOf((*MockGlobalConfig)(nil).URINormalize))
 }
 
-// GwPollInterval mocks base method.
-func (m *MockGlobalConfig) GwPollInterval() int {
+// UploadMaxAttempts mocks base method.
+func (m *MockGlobalConfig) UploadMaxAttempts() int {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GwPollInterval")
+	ret := m.ctrl.Call(m, "UploadMaxAttempts")
 	ret0, _ := ret[0].(int)
 	return ret0
 }
 
-// GwPollInterval indicates an expected call of GwPollInterval.
-func (mr *MockGlobalConfigMockRecorder) GwPollInterval() *gomock.Call {
+// UploadMaxAttempts indicates an expected call of UploadMaxAttempts.
+func (mr *MockGlobalConfigMockRecorder) UploadMaxAttempts() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwPollInterval", reflect.TypeOf((*MockGlobalConfig)(nil).GwPollInterval))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadMaxAttempts", reflect.TypeOf((*MockGlobalConfig)(nil).UploadMaxAttempts))
 }
 
-// GwURL mocks base method.
-func (m *MockGlobalConfig) GwURL() string {
+// UploadMaxBackoff mocks base method.
+func (m *MockGlobalConfig) UploadMaxBackoff() int {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GwURL")
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "UploadMaxBackoff")
+	ret0, _ := ret[0].(int)
 	return ret0
 }
 
-// GwURL indicates an expected call of GwURL.
-func (mr *MockGlobalConfigMockRecorder) GwURL() *gomock.Call {
+// UploadMaxBackoff indicates an expected call of UploadMaxBackoff.
+func (mr *MockGlobalConfigMockRecorder) UploadMaxBackoff() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GwURL", reflect.TypeOf((*MockGlobalConfig)(nil).GwURL))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadMaxBackoff", reflect.TypeOf((*MockGlobalConfig)(nil).UploadMaxBackoff))
 }
 
-// LogLevel mocks base method.
-func (m *MockGlobalConfig) LogLevel() string {
+// UploadSSE mocks base method.
+func (m *MockGlobalConfig) UploadSSE() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "LogLevel")
+	ret := m.ctrl.Call(m, "UploadSSE")
 	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// LogLevel indicates an expected call of LogLevel.
-func (mr *MockGlobalConfigMockRecorder) LogLevel() *gomock.Call {
+// UploadSSE indicates an expected call of UploadSSE.
+func (mr *MockGlobalConfigMockRecorder) UploadSSE() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogLevel", reflect.TypeOf((*MockGlobalConfig)(nil).LogLevel))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadSSE", reflect.TypeOf((*MockGlobalConfig)(nil).UploadSSE))
 }
 
-// Logger mocks base method.
-func (m *MockGlobalConfig) Logger() string {
+// UploadSSEKMSKeyID mocks base method.
+func (m *MockGlobalConfig) UploadSSEKMSKeyID() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Logger")
+	ret := m.ctrl.Call(m, "UploadSSEKMSKeyID")
 	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// Logger indicates an expected call of Logger.
-func (mr *MockGlobalConfigMockRecorder) Logger() *gomock.Call {
+// UploadSSEKMSKeyID indicates an expected call of UploadSSEKMSKeyID.
+func (mr *MockGlobalConfigMockRecorder) UploadSSEKMSKeyID() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logger", reflect.TypeOf((*MockGlobalConfig)(nil).Logger))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadSSEKMSKeyID", reflect.TypeOf((*MockGlobalConfig)(nil).UploadSSEKMSKeyID))
 }
 
-// RsyncMode mocks base method.
-func (m *MockGlobalConfig) RsyncMode() string {
+// UploadStorageClass mocks base method.
+func (m *MockGlobalConfig) UploadStorageClass() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RsyncMode")
+	ret := m.ctrl.Call(m, "UploadStorageClass")
 	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// RsyncMode indicates an expected call of RsyncMode.
-func (mr *MockGlobalConfigMockRecorder) RsyncMode() *gomock.Call {
+// UploadStorageClass indicates an expected call of UploadStorageClass.
+func (mr *MockGlobalConfigMockRecorder) UploadStorageClass() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RsyncMode", reflect.TypeOf((*MockGlobalConfig)(nil).RsyncMode))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadStorageClass", reflect.TypeOf((*MockGlobalConfig)(nil).UploadStorageClass))
 }
 
-// Strip mocks base method.
-func (m *MockGlobalConfig) Strip() string {
+// UploadTags mocks base method.
+func (m *MockGlobalConfig) UploadTags() map[string]string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Strip")
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "UploadTags")
+	ret0, _ := ret[0].(map[string]string)
 	return ret0
 }
 
-// Strip indicates an expected call of Strip.
-func (mr *MockGlobalConfigMockRecorder) Strip() *gomock.Call {
+// UploadTags indicates an expected call of UploadTags.
+func (mr *MockGlobalConfigMockRecorder) UploadTags() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Strip", reflect.TypeOf((*MockGlobalConfig)(nil).Strip))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadTags", reflect.TypeOf((*MockGlobalConfig)(nil).UploadTags))
 }
 
 // UploadThreads mocks base method.
@@ -818,3 +3450,17 @@ func (mr *MockGlobalConfigMockRecorder) Verbosity() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verbosity", reflect.TypeOf((*MockGlobalConfig)(nil).Verbosity))
 }
+
+// Xattrs mocks base method.
+func (m *MockGlobalConfig) Xattrs() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Xattrs")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// Xattrs indicates an expected call of Xattrs.
+func (mr *MockGlobalConfigMockRecorder) Xattrs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Xattrs", reflect.TypeOf((*MockGlobalConfig)(nil).Xattrs))
+}
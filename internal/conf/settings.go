@@ -0,0 +1,129 @@
+package conf
+
+import (
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Setting is a single value of the configuration in effect, for display.
+type Setting struct {
+	// Name of the setting in the configuration file.
+	Name string
+
+	// Value in effect, with any secrets redacted.
+	Value interface{}
+
+	// Where the value came from: SourceDefault, SourceFile, SourceEnvironment,
+	// or the environment variable(s) or argument which provided it.
+	Source string
+}
+
+// Sources of settings, other than environment variables and arguments.
+const (
+	SourceDefault     = "default"
+	SourceFile        = "file"
+	SourceEnvironment = "environment"
+)
+
+const redacted = "<redacted>"
+
+func (s *sharedConfig) setSource(name string, source string) {
+	if s.sources == nil {
+		s.sources = make(map[string]string)
+	}
+	s.sources[name] = source
+}
+
+// expand applies env var expansion to the named setting's value, recording
+// the variables used as its source.
+func (s *sharedConfig) expand(name string, value string) string {
+	vars := []string{}
+	out := os.Expand(value, func(v string) string {
+		vars = append(vars, "$"+v)
+		return os.Getenv(v)
+	})
+
+	if len(vars) > 0 {
+		s.setSource(name, "env var "+strings.Join(vars, ", "))
+	}
+	return out
+}
+
+// layer is one level of configuration from which settings may be taken.
+type layer struct {
+	config *sharedConfig
+	from   string
+}
+
+// source returns where the setting at index i of the layer came from, or
+// an empty string if the layer doesn't set it.
+func (l layer) source(i int, name string) string {
+	if source, ok := l.config.sources[name]; ok {
+		return source
+	}
+	if !reflect.ValueOf(l.config).Elem().Field(i).IsZero() {
+		return l.from
+	}
+	return ""
+}
+
+func (g *globalConfig) Settings() []Setting {
+	return settings(g, layer{&g.sharedConfig, SourceFile})
+}
+
+func (e *environment) Settings() []Setting {
+	return settings(e,
+		layer{&e.sharedConfig, SourceEnvironment},
+		layer{&e.parent.sharedConfig, SourceFile})
+}
+
+// settings returns every setting of cfg, attributed to the first of the given
+// layers which sets it, or else to the default.
+//
+// Each value is obtained from the Config method named after the field holding
+// it, so it reflects any fallbacks applied by that method.
+func settings(cfg Config, layers ...layer) []Setting {
+	out := []Setting{}
+	fields := reflect.TypeOf(sharedConfig{})
+	methods := reflect.ValueOf(cfg)
+
+	for i := 0; i < fields.NumField(); i++ {
+		field := fields.Field(i)
+		name, ok := field.Tag.Lookup("yaml")
+		if !ok {
+			continue
+		}
+
+		value := methods.MethodByName(strings.TrimSuffix(field.Name, "Raw")).Call(nil)[0].Interface()
+		setting := Setting{Name: name, Value: redact(name, value), Source: SourceDefault}
+
+		for _, l := range layers {
+			if source := l.source(i, name); source != "" {
+				setting.Source = source
+				break
+			}
+		}
+
+		out = append(out, setting)
+	}
+
+	return out
+}
+
+// redact hides any secrets in the value of a setting.
+func redact(name string, value interface{}) interface{} {
+	switch name {
+	case "gwcertcommand", "gwkeycommand":
+		// Commands may include tokens as arguments.
+		if value != "" {
+			return redacted
+		}
+	case "gwurl", "cdnurl", "gwproxy", "s3proxy":
+		if u, err := url.Parse(value.(string)); err == nil && u.User != nil {
+			return u.Redacted()
+		}
+	}
+	return value
+}
@@ -2,6 +2,7 @@ package conf
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/release-engineering/exodus-rsync/internal/args"
@@ -23,6 +24,134 @@ type sharedConfig struct {
 	DiagRaw           bool   `yaml:"diag"`
 	StripRaw          string `yaml:"strip"`
 	UploadThreadsRaw  int    `yaml:"uploadthreads"`
+
+	// Retry policy per uploaded file.
+	UploadMaxAttemptsRaw int `yaml:"uploadmaxattempts"`
+	UploadMaxBackoffRaw  int `yaml:"uploadmaxbackoff"`
+
+	// Bounds on the adaptive number of uploads at once.
+	ConcurrencyMinRaw int `yaml:"concurrencymin"`
+	ConcurrencyMaxRaw int `yaml:"concurrencymax"`
+
+	// Timeouts & retry policy per class of exodus-gw request.
+	// Timeouts are pointers, so that an environment can set 0 for no
+	// timeout where one is set globally.
+	GwReadTimeoutRaw       *int `yaml:"gwreadtimeout"`
+	GwReadMaxAttemptsRaw   int  `yaml:"gwreadmaxattempts"`
+	GwWriteTimeoutRaw      *int `yaml:"gwwritetimeout"`
+	GwWriteMaxAttemptsRaw  int  `yaml:"gwwritemaxattempts"`
+	GwCommitTimeoutRaw     *int `yaml:"gwcommittimeout"`
+	GwCommitMaxAttemptsRaw int  `yaml:"gwcommitmaxattempts"`
+
+	// Classification of exodus-gw responses as retryable or permanent.
+	GwRetryStatusesRaw     []int `yaml:"gwretrystatuses"`
+	GwPermanentStatusesRaw []int `yaml:"gwpermanentstatuses"`
+
+	URINormalizeRaw []string `yaml:"urinormalize"`
+
+	URICaseInsensitiveRaw bool `yaml:"uricaseinsensitive"`
+
+	ContentRulesRaw []ContentRule `yaml:"contentrules"`
+
+	AliasesRaw []AliasRule `yaml:"aliases"`
+
+	CacheControlRulesRaw   []CacheControlRule `yaml:"cachecontrolrules"`
+	CacheControlDefaultRaw string             `yaml:"cachecontroldefault"`
+
+	MIMESniffRaw bool `yaml:"mimesniff"`
+
+	SkipEmptyFilesRaw bool `yaml:"skipemptyfiles"`
+
+	CdnURLRaw string `yaml:"cdnurl"`
+
+	// Properties of uploaded blobs.
+	UploadTagsRaw         map[string]string `yaml:"uploadtags"`
+	UploadStorageClassRaw string            `yaml:"uploadstorageclass"`
+	UploadSSERaw          string            `yaml:"uploadsse"`
+	UploadSSEKMSKeyIDRaw  string            `yaml:"uploadssekmskeyid"`
+	BlobKeyPrefixRaw      string            `yaml:"blobkeyprefix"`
+
+	// Direct access to S3.
+	S3AccessRaw string `yaml:"s3access"`
+	S3BucketRaw string `yaml:"s3bucket"`
+
+	TempDirRaw     string `yaml:"tempdir"`
+	TempMinFreeRaw int64  `yaml:"tempminfree"`
+
+	// Cache of blobs known to be present.
+	BlobCacheRaw       string `yaml:"blobcache"`
+	BlobCacheMaxAgeRaw int    `yaml:"blobcachemaxage"`
+
+	// Fingerprints of the last committed publishes.
+	PublishStateRaw string `yaml:"publishstate"`
+
+	// Record of committed publishes.
+	AuditLogRaw string `yaml:"auditlog"`
+
+	// Adaptive batch size. A pointer, so that an environment can disable
+	// what's enabled globally.
+	GwBatchSizeAutoRaw *bool `yaml:"gwbatchsizeauto"`
+	GwBatchSizeMinRaw  int   `yaml:"gwbatchsizemin"`
+	GwBatchSizeMaxRaw  int   `yaml:"gwbatchsizemax"`
+
+	// Limit on the size of each batch of items.
+	GwMaxBatchBytesRaw int `yaml:"gwmaxbatchbytes"`
+
+	GwItemSchemaRaw int `yaml:"gwitemschema"`
+
+	GwNoReplaceRaw bool `yaml:"gwnoreplace"`
+
+	GwCacheControlRaw bool `yaml:"gwcachecontrol"`
+
+	// A pointer, so that an environment can disable what's enabled by
+	// default, or globally.
+	GwUseIdempotencyKeysRaw *bool `yaml:"gwuseidempotencykeys"`
+
+	GwRedirectsRaw string `yaml:"gwredirects"`
+
+	// Proxies for outbound connections.
+	GwProxyRaw string   `yaml:"gwproxy"`
+	S3ProxyRaw string   `yaml:"s3proxy"`
+	NoProxyRaw []string `yaml:"noproxy"`
+
+	// Timeouts per connection.
+	DialTimeoutRaw         int `yaml:"dialtimeout"`
+	TLSHandshakeTimeoutRaw int `yaml:"tlshandshaketimeout"`
+
+	TLSCipherSuitesRaw []string `yaml:"tlsciphersuites"`
+
+	MaxClockSkewRaw int `yaml:"maxclockskew"`
+
+	// Commands providing credentials for exodus-gw.
+	GwCertCommandRaw string `yaml:"gwcertcommand"`
+	GwKeyCommandRaw  string `yaml:"gwkeycommand"`
+
+	// A pointer, so that 0 can disable the warning.
+	GwCertExpiryWarningRaw *int `yaml:"gwcertexpirywarning"`
+
+	// Safety limits on the size of a publish.
+	MaxPublishBytesRaw int64 `yaml:"maxpublishbytes"`
+	MaxPublishItemsRaw int   `yaml:"maxpublishitems"`
+
+	// Limit on the length of web URIs.
+	MaxURILengthRaw int `yaml:"maxurilength"`
+
+	// Filters applied before those from the command line.
+	ExcludeRaw []string `yaml:"exclude"`
+	IncludeRaw []string `yaml:"include"`
+
+	// Extended attributes captured into item metadata.
+	XattrsRaw []string `yaml:"xattrs"`
+
+	// Environments to which publishing needs confirmation.
+	ProtectedEnvsRaw string `yaml:"protectedenvs"`
+
+	// Locking of destinations while syncing.
+	PublishLockRaw     string `yaml:"publishlock"`
+	PublishLockWaitRaw int    `yaml:"publishlockwait"`
+
+	// Sources of settings not taken as-is from file, by name.
+	sources map[string]string
 }
 
 type environment struct {
@@ -64,6 +193,10 @@ func (g *globalConfig) GwURL() string {
 	return g.GwURLRaw
 }
 
+func (g *globalConfig) CdnURL() string {
+	return g.CdnURLRaw
+}
+
 func (g *globalConfig) GwEnv() string {
 	return g.GwEnvRaw
 }
@@ -88,10 +221,254 @@ func (g *globalConfig) GwMaxBackoff() int {
 	return nonEmptyInt(g.GwMaxBackoffRaw, 20000)
 }
 
+func (g *globalConfig) GwReadTimeout() int {
+	return nonNilInt(g.GwReadTimeoutRaw, 0)
+}
+
+func (g *globalConfig) GwReadMaxAttempts() int {
+	return nonEmptyInt(g.GwReadMaxAttemptsRaw, g.GwMaxAttempts())
+}
+
+func (g *globalConfig) GwWriteTimeout() int {
+	return nonNilInt(g.GwWriteTimeoutRaw, 0)
+}
+
+func (g *globalConfig) GwWriteMaxAttempts() int {
+	return nonEmptyInt(g.GwWriteMaxAttemptsRaw, g.GwMaxAttempts())
+}
+
+func (g *globalConfig) GwCommitTimeout() int {
+	return nonNilInt(g.GwCommitTimeoutRaw, 0)
+}
+
+func (g *globalConfig) GwCommitMaxAttempts() int {
+	return nonEmptyInt(g.GwCommitMaxAttemptsRaw, g.GwMaxAttempts())
+}
+
+func (g *globalConfig) GwRetryStatuses() []int {
+	return g.GwRetryStatusesRaw
+}
+
+func (g *globalConfig) GwPermanentStatuses() []int {
+	return g.GwPermanentStatusesRaw
+}
+
 func (g *globalConfig) UploadThreads() int {
 	return nonEmptyInt(g.UploadThreadsRaw, 4)
 }
 
+func (g *globalConfig) UploadMaxAttempts() int {
+	return nonEmptyInt(g.UploadMaxAttemptsRaw, 3)
+}
+
+func (g *globalConfig) UploadMaxBackoff() int {
+	return nonEmptyInt(g.UploadMaxBackoffRaw, 20000)
+}
+
+func (g *globalConfig) ConcurrencyMin() int {
+	return nonEmptyInt(g.ConcurrencyMinRaw, 1)
+}
+
+func (g *globalConfig) ConcurrencyMax() int {
+	return g.ConcurrencyMaxRaw
+}
+
+func (g *globalConfig) URINormalize() []string {
+	return g.URINormalizeRaw
+}
+
+func (g *globalConfig) URICaseInsensitive() bool {
+	return g.URICaseInsensitiveRaw
+}
+
+func (g *globalConfig) ContentRules() []ContentRule {
+	return g.ContentRulesRaw
+}
+
+func (g *globalConfig) Aliases() []AliasRule {
+	return g.AliasesRaw
+}
+
+func (g *globalConfig) CacheControlRules() []CacheControlRule {
+	return g.CacheControlRulesRaw
+}
+
+func (g *globalConfig) CacheControlDefault() string {
+	return g.CacheControlDefaultRaw
+}
+
+func (g *globalConfig) MIMESniff() bool {
+	return g.MIMESniffRaw
+}
+
+func (g *globalConfig) SkipEmptyFiles() bool {
+	return g.SkipEmptyFilesRaw
+}
+
+func (g *globalConfig) UploadTags() map[string]string {
+	return g.UploadTagsRaw
+}
+
+func (g *globalConfig) UploadStorageClass() string {
+	return g.UploadStorageClassRaw
+}
+
+func (g *globalConfig) UploadSSE() string {
+	return g.UploadSSERaw
+}
+
+func (g *globalConfig) UploadSSEKMSKeyID() string {
+	return g.UploadSSEKMSKeyIDRaw
+}
+
+func (g *globalConfig) BlobKeyPrefix() string {
+	return g.BlobKeyPrefixRaw
+}
+
+func (g *globalConfig) S3Access() string {
+	return nonEmptyString(g.S3AccessRaw, "gw")
+}
+
+func (g *globalConfig) S3Bucket() string {
+	return g.S3BucketRaw
+}
+
+func (g *globalConfig) BlobCache() string {
+	return g.BlobCacheRaw
+}
+
+func (g *globalConfig) PublishState() string {
+	return g.PublishStateRaw
+}
+
+func (g *globalConfig) AuditLog() string {
+	return g.AuditLogRaw
+}
+
+func (g *globalConfig) BlobCacheMaxAge() int {
+	return nonEmptyInt(g.BlobCacheMaxAgeRaw, 7*24*60*60)
+}
+
+func (g *globalConfig) TempDir() string {
+	return nonEmptyString(g.TempDirRaw, os.TempDir())
+}
+
+func (g *globalConfig) TempMinFree() int64 {
+	return g.TempMinFreeRaw
+}
+
+func (g *globalConfig) GwProxy() string {
+	return g.GwProxyRaw
+}
+
+func (g *globalConfig) S3Proxy() string {
+	return g.S3ProxyRaw
+}
+
+func (g *globalConfig) NoProxy() []string {
+	return g.NoProxyRaw
+}
+
+func (g *globalConfig) DialTimeout() int {
+	return nonEmptyInt(g.DialTimeoutRaw, 30000)
+}
+
+func (g *globalConfig) TLSHandshakeTimeout() int {
+	return nonEmptyInt(g.TLSHandshakeTimeoutRaw, 10000)
+}
+
+func (g *globalConfig) TLSCipherSuites() []string {
+	return g.TLSCipherSuitesRaw
+}
+
+func (g *globalConfig) MaxClockSkew() int {
+	return nonEmptyInt(g.MaxClockSkewRaw, 60000)
+}
+
+func (g *globalConfig) GwBatchSizeAuto() bool {
+	return g.GwBatchSizeAutoRaw != nil && *g.GwBatchSizeAutoRaw
+}
+
+func (g *globalConfig) GwBatchSizeMin() int {
+	return nonEmptyInt(g.GwBatchSizeMinRaw, 100)
+}
+
+func (g *globalConfig) GwBatchSizeMax() int {
+	return nonEmptyInt(g.GwBatchSizeMaxRaw, 50000)
+}
+
+func (g *globalConfig) GwMaxBatchBytes() int {
+	return g.GwMaxBatchBytesRaw
+}
+
+func (g *globalConfig) GwItemSchema() int {
+	return nonEmptyInt(g.GwItemSchemaRaw, 1)
+}
+
+func (g *globalConfig) GwNoReplace() bool {
+	return g.GwNoReplaceRaw
+}
+
+func (g *globalConfig) GwCacheControl() bool {
+	return g.GwCacheControlRaw
+}
+
+func (g *globalConfig) GwUseIdempotencyKeys() bool {
+	return g.GwUseIdempotencyKeysRaw == nil || *g.GwUseIdempotencyKeysRaw
+}
+
+func (g *globalConfig) GwRedirects() string {
+	return nonEmptyString(g.GwRedirectsRaw, "follow")
+}
+
+func (g *globalConfig) GwCertCommand() string {
+	return g.GwCertCommandRaw
+}
+
+func (g *globalConfig) GwKeyCommand() string {
+	return g.GwKeyCommandRaw
+}
+
+func (g *globalConfig) GwCertExpiryWarning() int {
+	return nonNilInt(g.GwCertExpiryWarningRaw, 14)
+}
+
+func (g *globalConfig) MaxPublishBytes() int64 {
+	return g.MaxPublishBytesRaw
+}
+
+func (g *globalConfig) MaxPublishItems() int {
+	return g.MaxPublishItemsRaw
+}
+
+func (g *globalConfig) MaxURILength() int {
+	return g.MaxURILengthRaw
+}
+
+func (g *globalConfig) Exclude() []string {
+	return g.ExcludeRaw
+}
+
+func (g *globalConfig) Include() []string {
+	return g.IncludeRaw
+}
+
+func (g *globalConfig) Xattrs() []string {
+	return g.XattrsRaw
+}
+
+func (g *globalConfig) ProtectedEnvs() string {
+	return g.ProtectedEnvsRaw
+}
+
+func (g *globalConfig) PublishLock() string {
+	return g.PublishLockRaw
+}
+
+func (g *globalConfig) PublishLockWait() int {
+	return g.PublishLockWaitRaw
+}
+
 func nonEmptyString(a, b string) string {
 	if a != "" {
 		return a
@@ -106,6 +483,14 @@ func nonEmptyInt(a, b int) int {
 	return b
 }
 
+// nonNilInt is like nonEmptyInt, for values where 0 is meaningful.
+func nonNilInt(a *int, b int) int {
+	if a != nil {
+		return *a
+	}
+	return b
+}
+
 func (g *globalConfig) RsyncMode() string {
 	return nonEmptyString(g.RsyncModeRaw, "exodus")
 }
@@ -142,6 +527,10 @@ func (e *environment) GwURL() string {
 	return nonEmptyString(e.GwURLRaw, e.parent.GwURL())
 }
 
+func (e *environment) CdnURL() string {
+	return nonEmptyString(e.CdnURLRaw, e.parent.CdnURL())
+}
+
 func (e *environment) GwEnv() string {
 	return nonEmptyString(e.GwEnvRaw, e.parent.GwEnv())
 }
@@ -166,6 +555,48 @@ func (e *environment) GwMaxBackoff() int {
 	return nonEmptyInt(e.GwMaxBackoffRaw, e.parent.GwMaxBackoff())
 }
 
+func (e *environment) GwReadTimeout() int {
+	return nonNilInt(e.GwReadTimeoutRaw, e.parent.GwReadTimeout())
+}
+
+func (e *environment) GwReadMaxAttempts() int {
+	// The per-operation max attempts fall back to the most specific value of
+	// gwmaxattempts, so an environment overriding only gwmaxattempts will
+	// also apply that value to each operation.
+	return nonEmptyInt(nonEmptyInt(e.GwReadMaxAttemptsRaw, e.parent.GwReadMaxAttemptsRaw), e.GwMaxAttempts())
+}
+
+func (e *environment) GwWriteTimeout() int {
+	return nonNilInt(e.GwWriteTimeoutRaw, e.parent.GwWriteTimeout())
+}
+
+func (e *environment) GwWriteMaxAttempts() int {
+	return nonEmptyInt(nonEmptyInt(e.GwWriteMaxAttemptsRaw, e.parent.GwWriteMaxAttemptsRaw), e.GwMaxAttempts())
+}
+
+func (e *environment) GwCommitTimeout() int {
+	return nonNilInt(e.GwCommitTimeoutRaw, e.parent.GwCommitTimeout())
+}
+
+func (e *environment) GwCommitMaxAttempts() int {
+	return nonEmptyInt(nonEmptyInt(e.GwCommitMaxAttemptsRaw, e.parent.GwCommitMaxAttemptsRaw), e.GwMaxAttempts())
+}
+
+func (e *environment) GwRetryStatuses() []int {
+	// As with URINormalize, an environment's statuses replace the global ones.
+	if e.GwRetryStatusesRaw != nil {
+		return e.GwRetryStatusesRaw
+	}
+	return e.parent.GwRetryStatuses()
+}
+
+func (e *environment) GwPermanentStatuses() []int {
+	if e.GwPermanentStatusesRaw != nil {
+		return e.GwPermanentStatusesRaw
+	}
+	return e.parent.GwPermanentStatuses()
+}
+
 func (e *environment) RsyncMode() string {
 	return nonEmptyString(e.RsyncModeRaw, e.parent.RsyncMode())
 }
@@ -200,3 +631,265 @@ func (e *environment) Strip() string {
 func (e *environment) UploadThreads() int {
 	return nonEmptyInt(e.UploadThreadsRaw, e.parent.UploadThreads())
 }
+
+func (e *environment) UploadMaxAttempts() int {
+	return nonEmptyInt(e.UploadMaxAttemptsRaw, e.parent.UploadMaxAttempts())
+}
+
+func (e *environment) UploadMaxBackoff() int {
+	return nonEmptyInt(e.UploadMaxBackoffRaw, e.parent.UploadMaxBackoff())
+}
+
+func (e *environment) ConcurrencyMin() int {
+	return nonEmptyInt(e.ConcurrencyMinRaw, e.parent.ConcurrencyMin())
+}
+
+func (e *environment) ConcurrencyMax() int {
+	return nonEmptyInt(e.ConcurrencyMaxRaw, e.parent.ConcurrencyMax())
+}
+
+func (e *environment) URINormalize() []string {
+	// An environment's rules replace rather than extend the global rules.
+	if e.URINormalizeRaw != nil {
+		return e.URINormalizeRaw
+	}
+	return e.parent.URINormalize()
+}
+
+func (e *environment) ContentRules() []ContentRule {
+	// An environment's rules replace rather than extend the global rules.
+	if e.ContentRulesRaw != nil {
+		return e.ContentRulesRaw
+	}
+	return e.parent.ContentRules()
+}
+
+func (e *environment) Aliases() []AliasRule {
+	// As with ContentRules, an environment's rules replace the global rules.
+	if e.AliasesRaw != nil {
+		return e.AliasesRaw
+	}
+	return e.parent.Aliases()
+}
+
+func (e *environment) CacheControlRules() []CacheControlRule {
+	// As with ContentRules, an environment's rules replace the global rules.
+	if e.CacheControlRulesRaw != nil {
+		return e.CacheControlRulesRaw
+	}
+	return e.parent.CacheControlRules()
+}
+
+func (e *environment) CacheControlDefault() string {
+	return nonEmptyString(e.CacheControlDefaultRaw, e.parent.CacheControlDefault())
+}
+
+func (e *environment) URICaseInsensitive() bool {
+	return e.URICaseInsensitiveRaw || e.parent.URICaseInsensitive()
+}
+
+func (e *environment) MIMESniff() bool {
+	return e.MIMESniffRaw || e.parent.MIMESniff()
+}
+
+func (e *environment) SkipEmptyFiles() bool {
+	return e.SkipEmptyFilesRaw || e.parent.SkipEmptyFiles()
+}
+
+func (e *environment) UploadTags() map[string]string {
+	// An environment's tags replace rather than extend the global tags.
+	if e.UploadTagsRaw != nil {
+		return e.UploadTagsRaw
+	}
+	return e.parent.UploadTags()
+}
+
+func (e *environment) UploadStorageClass() string {
+	return nonEmptyString(e.UploadStorageClassRaw, e.parent.UploadStorageClass())
+}
+
+func (e *environment) UploadSSE() string {
+	return nonEmptyString(e.UploadSSERaw, e.parent.UploadSSE())
+}
+
+func (e *environment) UploadSSEKMSKeyID() string {
+	return nonEmptyString(e.UploadSSEKMSKeyIDRaw, e.parent.UploadSSEKMSKeyID())
+}
+
+func (e *environment) BlobKeyPrefix() string {
+	return nonEmptyString(e.BlobKeyPrefixRaw, e.parent.BlobKeyPrefix())
+}
+
+func (e *environment) S3Access() string {
+	return nonEmptyString(e.S3AccessRaw, e.parent.S3Access())
+}
+
+func (e *environment) S3Bucket() string {
+	return nonEmptyString(e.S3BucketRaw, e.parent.S3Bucket())
+}
+
+func (e *environment) BlobCache() string {
+	return nonEmptyString(e.BlobCacheRaw, e.parent.BlobCache())
+}
+
+func (e *environment) PublishState() string {
+	return nonEmptyString(e.PublishStateRaw, e.parent.PublishState())
+}
+
+func (e *environment) AuditLog() string {
+	return nonEmptyString(e.AuditLogRaw, e.parent.AuditLog())
+}
+
+func (e *environment) BlobCacheMaxAge() int {
+	return nonEmptyInt(e.BlobCacheMaxAgeRaw, e.parent.BlobCacheMaxAge())
+}
+
+func (e *environment) TempDir() string {
+	return nonEmptyString(e.TempDirRaw, e.parent.TempDir())
+}
+
+func (e *environment) TempMinFree() int64 {
+	if e.TempMinFreeRaw != 0 {
+		return e.TempMinFreeRaw
+	}
+	return e.parent.TempMinFree()
+}
+
+func (e *environment) GwProxy() string {
+	return nonEmptyString(e.GwProxyRaw, e.parent.GwProxy())
+}
+
+func (e *environment) S3Proxy() string {
+	return nonEmptyString(e.S3ProxyRaw, e.parent.S3Proxy())
+}
+
+func (e *environment) NoProxy() []string {
+	if e.NoProxyRaw != nil {
+		return e.NoProxyRaw
+	}
+	return e.parent.NoProxy()
+}
+
+func (e *environment) DialTimeout() int {
+	return nonEmptyInt(e.DialTimeoutRaw, e.parent.DialTimeout())
+}
+
+func (e *environment) TLSHandshakeTimeout() int {
+	return nonEmptyInt(e.TLSHandshakeTimeoutRaw, e.parent.TLSHandshakeTimeout())
+}
+
+func (e *environment) TLSCipherSuites() []string {
+	// As with URINormalize, an environment's suites replace the global ones.
+	if e.TLSCipherSuitesRaw != nil {
+		return e.TLSCipherSuitesRaw
+	}
+	return e.parent.TLSCipherSuites()
+}
+
+func (e *environment) MaxClockSkew() int {
+	return nonEmptyInt(e.MaxClockSkewRaw, e.parent.MaxClockSkew())
+}
+
+func (e *environment) GwBatchSizeAuto() bool {
+	if e.GwBatchSizeAutoRaw != nil {
+		return *e.GwBatchSizeAutoRaw
+	}
+	return e.parent.GwBatchSizeAuto()
+}
+
+func (e *environment) GwBatchSizeMin() int {
+	return nonEmptyInt(e.GwBatchSizeMinRaw, e.parent.GwBatchSizeMin())
+}
+
+func (e *environment) GwBatchSizeMax() int {
+	return nonEmptyInt(e.GwBatchSizeMaxRaw, e.parent.GwBatchSizeMax())
+}
+
+func (e *environment) GwMaxBatchBytes() int {
+	return nonEmptyInt(e.GwMaxBatchBytesRaw, e.parent.GwMaxBatchBytes())
+}
+
+func (e *environment) GwItemSchema() int {
+	return nonEmptyInt(e.GwItemSchemaRaw, e.parent.GwItemSchema())
+}
+
+func (e *environment) GwNoReplace() bool {
+	return e.GwNoReplaceRaw || e.parent.GwNoReplace()
+}
+
+func (e *environment) GwCacheControl() bool {
+	return e.GwCacheControlRaw || e.parent.GwCacheControl()
+}
+
+func (e *environment) GwUseIdempotencyKeys() bool {
+	if e.GwUseIdempotencyKeysRaw != nil {
+		return *e.GwUseIdempotencyKeysRaw
+	}
+	return e.parent.GwUseIdempotencyKeys()
+}
+
+func (e *environment) GwRedirects() string {
+	return nonEmptyString(e.GwRedirectsRaw, e.parent.GwRedirects())
+}
+
+func (e *environment) GwCertCommand() string {
+	return nonEmptyString(e.GwCertCommandRaw, e.parent.GwCertCommand())
+}
+
+func (e *environment) GwKeyCommand() string {
+	return nonEmptyString(e.GwKeyCommandRaw, e.parent.GwKeyCommand())
+}
+
+func (e *environment) GwCertExpiryWarning() int {
+	return nonNilInt(e.GwCertExpiryWarningRaw, e.parent.GwCertExpiryWarning())
+}
+
+func (e *environment) MaxPublishBytes() int64 {
+	if e.MaxPublishBytesRaw != 0 {
+		return e.MaxPublishBytesRaw
+	}
+	return e.parent.MaxPublishBytes()
+}
+
+func (e *environment) MaxPublishItems() int {
+	return nonEmptyInt(e.MaxPublishItemsRaw, e.parent.MaxPublishItems())
+}
+
+func (e *environment) MaxURILength() int {
+	return nonEmptyInt(e.MaxURILengthRaw, e.parent.MaxURILength())
+}
+
+func (e *environment) Exclude() []string {
+	// An environment's filters replace rather than extend the global filters,
+	// so that excludes and the includes excepting them are kept together.
+	if e.ExcludeRaw != nil || e.IncludeRaw != nil {
+		return e.ExcludeRaw
+	}
+	return e.parent.Exclude()
+}
+
+func (e *environment) Include() []string {
+	if e.ExcludeRaw != nil || e.IncludeRaw != nil {
+		return e.IncludeRaw
+	}
+	return e.parent.Include()
+}
+
+func (e *environment) Xattrs() []string {
+	if e.XattrsRaw != nil {
+		return e.XattrsRaw
+	}
+	return e.parent.Xattrs()
+}
+
+func (e *environment) ProtectedEnvs() string {
+	return nonEmptyString(e.ProtectedEnvsRaw, e.parent.ProtectedEnvs())
+}
+
+func (e *environment) PublishLock() string {
+	return nonEmptyString(e.PublishLockRaw, e.parent.PublishLock())
+}
+
+func (e *environment) PublishLockWait() int {
+	return nonEmptyInt(e.PublishLockWaitRaw, e.parent.PublishLockWait())
+}
@@ -0,0 +1,37 @@
+package conf
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// CipherSuiteIDs returns the IDs of the TLS cipher suites of the given names,
+// such as "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", or nil if names is empty.
+// Only suites which Go considers secure may be named.
+func CipherSuiteIDs(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	secure := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		secure[suite.Name] = suite.ID
+	}
+	insecure := make(map[string]bool)
+	for _, suite := range tls.InsecureCipherSuites() {
+		insecure[suite.Name] = true
+	}
+
+	out := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := secure[name]
+		if insecure[name] {
+			return nil, fmt.Errorf("insecure cipher suite '%s' is not allowed", name)
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown cipher suite '%s'", name)
+		}
+		out = append(out, id)
+	}
+	return out, nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"io/fs"
+	"net/url"
 	"os"
 	"path/filepath"
 	"time"
@@ -52,6 +53,25 @@ func (impl) Run(ctx context.Context, cfg conf.Config, args args.Config) {
 	logger.Warn("=============== diagnostics: end ====================")
 }
 
+// redact returns what's logged in place of a setting which may hold secrets,
+// such as a command embedding a token, telling only whether it's set.
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "REDACTED"
+}
+
+// redactURL returns the URL in value with any password redacted, or all of
+// value if it can't be parsed.
+func redactURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil {
+		return redact(value)
+	}
+	return u.Redacted()
+}
+
 func logConfig(ctx context.Context, cfg conf.Config) {
 	logger := log.FromContext(ctx)
 
@@ -60,12 +80,64 @@ func logConfig(ctx context.Context, cfg conf.Config) {
 	logger.F(
 		"gwcert", cfg.GwCert(),
 		"gwkey", cfg.GwKey(),
+		"gwcertcommand", redact(cfg.GwCertCommand()),
+		"gwkeycommand", redact(cfg.GwKeyCommand()),
+		"gwcertexpirywarning", cfg.GwCertExpiryWarning(),
 		"gwurl", cfg.GwURL(),
+		"cdnurl", cfg.CdnURL(),
 		"gwenv", cfg.GwEnv(),
 		"gwpollinterval", cfg.GwPollInterval(),
 		"gwbatchsize", cfg.GwBatchSize(),
+		"gwbatchsizeauto", cfg.GwBatchSizeAuto(),
+		"gwbatchsizemin", cfg.GwBatchSizeMin(),
+		"gwbatchsizemax", cfg.GwBatchSizeMax(),
+		"gwmaxbatchbytes", cfg.GwMaxBatchBytes(),
+		"gwitemschema", cfg.GwItemSchema(),
+		"gwnoreplace", cfg.GwNoReplace(),
+		"gwcachecontrol", cfg.GwCacheControl(),
+		"gwuseidempotencykeys", cfg.GwUseIdempotencyKeys(),
+		"gwredirects", cfg.GwRedirects(),
 		"gwmaxattempts", cfg.GwMaxAttempts(),
 		"gwmaxbackoff", cfg.GwMaxBackoff(),
+		"gwreadtimeout", cfg.GwReadTimeout(),
+		"gwreadmaxattempts", cfg.GwReadMaxAttempts(),
+		"gwwritetimeout", cfg.GwWriteTimeout(),
+		"gwwritemaxattempts", cfg.GwWriteMaxAttempts(),
+		"gwcommittimeout", cfg.GwCommitTimeout(),
+		"gwcommitmaxattempts", cfg.GwCommitMaxAttempts(),
+		"gwretrystatuses", cfg.GwRetryStatuses(),
+		"gwpermanentstatuses", cfg.GwPermanentStatuses(),
+		"uploadmaxattempts", cfg.UploadMaxAttempts(),
+		"uploadmaxbackoff", cfg.UploadMaxBackoff(),
+		"concurrencymin", cfg.ConcurrencyMin(),
+		"concurrencymax", cfg.ConcurrencyMax(),
+		"uploadtags", cfg.UploadTags(),
+		"uploadstorageclass", cfg.UploadStorageClass(),
+		"uploadsse", cfg.UploadSSE(),
+		"uploadssekmskeyid", cfg.UploadSSEKMSKeyID(),
+		"blobkeyprefix", cfg.BlobKeyPrefix(),
+		"tempdir", cfg.TempDir(),
+		"tempminfree", cfg.TempMinFree(),
+		"s3access", cfg.S3Access(),
+		"s3bucket", cfg.S3Bucket(),
+		"blobcache", cfg.BlobCache(),
+		"blobcachemaxage", cfg.BlobCacheMaxAge(),
+		"publishstate", cfg.PublishState(),
+		"auditlog", cfg.AuditLog(),
+		"gwproxy", redactURL(cfg.GwProxy()),
+		"s3proxy", redactURL(cfg.S3Proxy()),
+		"noproxy", cfg.NoProxy(),
+		"dialtimeout", cfg.DialTimeout(),
+		"tlshandshaketimeout", cfg.TLSHandshakeTimeout(),
+		"tlsciphersuites", cfg.TLSCipherSuites(),
+		"maxclockskew", cfg.MaxClockSkew(),
+		"maxpublishbytes", cfg.MaxPublishBytes(),
+		"maxpublishitems", cfg.MaxPublishItems(),
+		"maxurilength", cfg.MaxURILength(),
+		"xattrs", cfg.Xattrs(),
+		"protectedenvs", cfg.ProtectedEnvs(),
+		"publishlock", cfg.PublishLock(),
+		"publishlockwait", cfg.PublishLockWait(),
 	).Warn("exodus-gw")
 
 	logger.F(
@@ -119,7 +191,10 @@ func logCommand(ctx context.Context, cfg conf.Config, args args.Config) {
 	}
 
 	logger.F("src", args.Src, "dest", args.Dest, "prefix", prefix,
-		"strip", strip).Warn("paths")
+		"strip", strip, "urinormalize", cfg.URINormalize(),
+		"uricaseinsensitive", cfg.URICaseInsensitive(), "contentrules", cfg.ContentRules(),
+		"aliases", cfg.Aliases(), "cachecontrolrules", cfg.CacheControlRules(),
+		"cachecontroldefault", cfg.CacheControlDefault(), "mimesniff", cfg.MIMESniff()).Warn("paths")
 
 	cmd, err := ext.rsync.Command(ctx, rsync.Arguments(ctx, args))
 	if err != nil {
@@ -170,7 +245,10 @@ func logFilters(ctx context.Context, cfg conf.Config, args args.Config) {
 	logger.Warn("=============== diagnostics: filters ================")
 
 	logger.F("exclude", args.Exclude, "include", args.Include,
-		"filter", args.Filter, "filesfrom", args.FilesFrom).Warn("filter arguments")
+		"filter", args.Filter, "filesfrom", args.FilesFrom,
+		"skipemptyfiles", cfg.SkipEmptyFiles()).Warn("filter arguments")
+
+	logger.F("exclude", cfg.Exclude(), "include", cfg.Include()).Warn("environment filters")
 
 	if args.FilesFrom != "" {
 		content, err := os.ReadFile(args.FilesFrom)
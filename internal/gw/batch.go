@@ -0,0 +1,83 @@
+package gw
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+)
+
+// Requests adding items to a publish are considered fast if they complete
+// within autoBatchFast, and slow if they take longer than autoBatchSlow.
+var (
+	autoBatchFast = 2 * time.Second
+	autoBatchSlow = 10 * time.Second
+)
+
+// batchClock times requests adding items, and may be replaced in tests.
+var batchClock = time.Now
+
+// batchTuner adapts the number of items sent in each request to exodus-gw,
+// growing it while requests are fast and shrinking it when requests are slow
+// or fail, within configured bounds.
+type batchTuner struct {
+	size int
+	min  int
+	max  int
+}
+
+func newBatchTuner(cfg conf.Config) *batchTuner {
+	out := &batchTuner{
+		min: max(cfg.GwBatchSizeMin(), 1),
+		max: cfg.GwBatchSizeMax(),
+	}
+	if out.max < out.min {
+		out.max = out.min
+	}
+	out.size = out.clamp(cfg.GwBatchSize())
+	return out
+}
+
+func (t *batchTuner) clamp(size int) int {
+	return min(max(size, t.min), t.max)
+}
+
+// batchTooLarge returns true if err from a request adding items to a publish
+// may be due to the size of the batch: the request was refused as too large,
+// timed out or failed on the server's side. Other errors, such as an item
+// failing validation, would only recur with a smaller batch.
+func batchTooLarge(err error) bool {
+	var httpErr *httpError
+	if errors.As(err, &httpErr) {
+		return httpErr.status == http.StatusRequestEntityTooLarge || httpErr.status >= 500
+	}
+
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// observe updates the batch size from the outcome of a request which took
+// elapsed to complete.
+//
+// If the request failed, returns true if it's worth retrying the request
+// with a smaller batch.
+func (t *batchTuner) observe(elapsed time.Duration, err error) bool {
+	if err != nil {
+		if t.size == t.min {
+			return false
+		}
+		t.size = t.clamp(t.size / 2)
+		return true
+	}
+
+	if elapsed < autoBatchFast {
+		t.size = t.clamp(t.size * 2)
+	} else if elapsed > autoBatchSlow {
+		t.size = t.clamp(t.size / 2)
+	}
+
+	return false
+}
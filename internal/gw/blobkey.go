@@ -0,0 +1,42 @@
+package gw
+
+import (
+	"strings"
+
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+// blobKey returns the key of the blob holding the content of item within the
+// bucket, beneath 'blobkeyprefix'.
+func (c *client) blobKey(item walk.SyncItem) string {
+	return c.cfg.BlobKeyPrefix() + item.Key
+}
+
+// withKeyPrefix returns a copy of items with prefix prepended to the object
+// key of each item having content, so that they refer to blobs as uploaded.
+func withKeyPrefix(items []ItemInput, prefix string) []ItemInput {
+	if prefix == "" {
+		return items
+	}
+
+	out := append([]ItemInput(nil), items...)
+	for i := range out {
+		if out[i].ObjectKey != "" {
+			out[i].ObjectKey = prefix + out[i].ObjectKey
+		}
+	}
+	return out
+}
+
+// withoutKeyPrefix is the inverse of withKeyPrefix, for items as returned by
+// exodus-gw.
+func withoutKeyPrefix(items []ItemInput, prefix string) []ItemInput {
+	if prefix == "" {
+		return items
+	}
+
+	for i := range items {
+		items[i].ObjectKey = strings.TrimPrefix(items[i].ObjectKey, prefix)
+	}
+	return items
+}
@@ -3,13 +3,15 @@ package gw
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
-	"os"
+	"net/url"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,6 +19,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
@@ -33,36 +36,157 @@ func logConnectionClose(ctx context.Context, url string) {
 	log.FromContext(ctx).F("url", url).Info("Closing connection")
 }
 
+// operation identifies the class of an exodus-gw request, used to select
+// the timeout and retry policy applied to that request.
+type operation int
+
+const (
+	opRead   operation = iota // GET requests, e.g. looking up a publish or polling a task
+	opWrite                   // requests creating a publish or adding items onto it
+	opCommit                  // requests committing a publish
+)
+
+type operationKey struct{}
+
+func withOperation(ctx context.Context, op operation) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+func operationFromContext(ctx context.Context) operation {
+	op, _ := ctx.Value(operationKey{}).(operation)
+	return op
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context under which every request to exodus-gw
+// carries the given ID, for correlating those requests in exodus-gw logs.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the ID set via WithRequestID, or an empty
+// string if unset.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestIDHeader is the header of each request holding the ID set
+// via WithRequestID.
+const requestIDHeader = "X-Request-ID"
+
+// requestIDHandler is an S3 build handler which adds the ID set via
+// WithRequestID onto S3 requests.
+var requestIDHandler = request.NamedHandler{
+	Name: "exodus-rsync.requestIDHandler",
+	Fn: func(r *request.Request) {
+		if id := RequestIDFromContext(r.Context()); id != "" {
+			r.HTTPRequest.Header.Set(requestIDHeader, id)
+		}
+	},
+}
+
 type client struct {
 	cfg        conf.Config
 	httpClient *http.Client
 	s3         *s3.S3
 	uploader   *s3manager.Uploader
+	bucket     string
 	dryRun     bool
+
+	// Loaded on first use by EnsureUploaded.
+	presence     *presenceCache
+	presenceOnce sync.Once
+
+	// Pauses requests as asked by exodus-gw's rate limit headers.
+	rateLimit *rateLimiter
+
+	// The time according to exodus-gw and S3.
+	clock *serverClock
+
+	// Bounds the number of uploads at once, shared by every EnsureUploaded.
+	limiter *uploadLimiter
+
+	// Blobs being uploaded by any EnsureUploaded.
+	inflight *inflightUploads
 }
 
-func (c *client) doJSONRequest(ctx context.Context, method string, url string, body interface{}, target interface{}, headers map[string][]string) error {
-	var bodyReader io.Reader
-	if body == nil {
-		bodyReader = nil
-	} else {
+// httpError is returned for an unsuccessful response from exodus-gw.
+type httpError struct {
+	status int
+	msg    string
+
+	// The start of the response body, if any.
+	body []byte
+}
+
+func (e *httpError) Error() string {
+	return e.msg
+}
+
+func (c *client) doJSONRequest(ctx context.Context, op operation, method string, url string, body interface{}, target interface{}, headers map[string][]string) error {
+	var bodyBytes []byte
+	if body != nil {
 		buf := bytes.Buffer{}
 		enc := json.NewEncoder(&buf)
 		if err := enc.Encode(body); err != nil {
 			return fmt.Errorf("encoding request body: %w", err)
 		}
-		bodyReader = &buf
+		bodyBytes = buf.Bytes()
 	}
 
 	fullURL := c.cfg.GwURL() + url
+
+	// Requests refused by exodus-gw's rate limit are retried here, as the
+	// retry transport doesn't know to wait as long as exodus-gw asks.
+	_, maxAttempts := policy(c.cfg, op)
+
+	for attempt := 1; ; attempt++ {
+		req, resp, err := c.doRequestOnce(ctx, op, method, fullURL, bodyBytes, headers)
+		if err != nil {
+			return err
+		}
+
+		if newRetryClassifier(c.cfg).rateLimited(resp.StatusCode) && attempt < maxAttempts {
+			resp.Body.Close()
+			log.FromContext(ctx).F("url", fullURL, "attempt", attempt).Warn(
+				"Rate limited by exodus-gw, will retry")
+			continue
+		}
+
+		err = c.decodeResponse(ctx, req, resp, target)
+		c.observeRequest(ctx, err)
+		return err
+	}
+}
+
+// doRequestOnce makes a single request to exodus-gw, after any pause
+// requested by exodus-gw's rate limit.
+func (c *client) doRequestOnce(ctx context.Context, op operation, method string, fullURL string, body []byte, headers map[string][]string) (*http.Request, *http.Response, error) {
+	if err := c.rateLimit.wait(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
 	req, err := http.NewRequestWithContext(ctx, method, fullURL, bodyReader)
 
 	if err != nil {
-		return fmt.Errorf("preparing request to %s: %w", fullURL, err)
+		return nil, nil, fmt.Errorf("preparing request to %s: %w", fullURL, err)
 	}
 
+	// Tag the request so the appropriate retry policy is applied.
+	req = req.WithContext(withOperation(ctx, op))
+
 	req.Header["Accept"] = []string{"application/json"}
 	req.Header["Content-Type"] = []string{"application/json"}
+	if id := RequestIDFromContext(ctx); id != "" {
+		req.Header.Set(requestIDHeader, id)
+	}
 	// Adding provided headers after setting Accept and Content-Type
 	// headers allows caller to overwrite them if necessary.
 	for key, value := range headers {
@@ -74,9 +198,19 @@ func (c *client) doJSONRequest(ctx context.Context, method string, url string, b
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return err
+		c.observeRequest(ctx, err)
+		return nil, nil, err
 	}
 
+	c.clock.observe(ctx, resp.Header, time.Now())
+	c.rateLimit.observe(ctx, resp)
+
+	return req, resp, nil
+}
+
+// decodeResponse decodes the JSON body of a successful response to req into
+// target, closing the body.
+func (c *client) decodeResponse(ctx context.Context, req *http.Request, resp *http.Response, target interface{}) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode > 299 {
@@ -86,14 +220,17 @@ func (c *client) doJSONRequest(ctx context.Context, method string, url string, b
 				"No body in response for '%s %s'", req.Method, req.URL,
 			)
 		} else if len(byteSlice) > 0 {
-			return fmt.Errorf("%s %s: %s, %s", req.Method, req.URL, resp.Status, byteSlice)
+			return &httpError{
+				status: resp.StatusCode,
+				msg:    fmt.Sprintf("%s %s: %s, %s", req.Method, req.URL, resp.Status, byteSlice),
+				body:   byteSlice,
+			}
 		}
-		return fmt.Errorf("%s %s: %s", req.Method, req.URL, resp.Status)
+		return &httpError{status: resp.StatusCode, msg: fmt.Sprintf("%s %s: %s", req.Method, req.URL, resp.Status)}
 	}
 
 	dec := json.NewDecoder(resp.Body)
-	err = dec.Decode(target)
-	if err != nil {
+	if err := dec.Decode(target); err != nil {
 		return fmt.Errorf("%s %s: %w", req.Method, req.URL, err)
 	}
 
@@ -102,24 +239,33 @@ func (c *client) doJSONRequest(ctx context.Context, method string, url string, b
 
 func (c *client) WhoAmI(ctx context.Context) (map[string]interface{}, error) {
 	out := make(map[string]interface{})
-	err := c.doJSONRequest(ctx, "GET", "/whoami", nil, &out, nil)
+	err := c.doJSONRequest(ctx, opRead, "GET", "/whoami", nil, &out, nil)
 	return out, err
 }
 
 func (c *client) haveBlob(ctx context.Context, item walk.SyncItem) (bool, error) {
 	logger := log.FromContext(ctx)
 
-	fullURL := c.s3.Endpoint + "/" + c.cfg.GwEnv() + "/" + item.Key
+	fullURL := c.s3.Endpoint + "/" + c.bucket + "/" + c.blobKey(item)
+
+	if have, known := c.presence.lookup(fullURL); known {
+		if have {
+			logger.F("key", item.Key).Info("Skipping upload, blob is known to be present")
+		}
+		return have, nil
+	}
+
 	logConnectionOpen(ctx, fullURL)
 	defer logConnectionClose(ctx, fullURL)
 
-	_, err := c.s3.HeadObject(&s3.HeadObjectInput{
-		Bucket: aws.String(c.cfg.GwEnv()),
-		Key:    aws.String(item.Key),
+	_, err := c.s3.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(c.blobKey(item)),
 	})
 
 	if err == nil {
 		logger.F("key", item.Key).Info("Skipping upload, blob is present")
+		c.presence.store(fullURL, true)
 		return true, nil
 	}
 
@@ -128,6 +274,7 @@ func (c *client) haveBlob(ctx context.Context, item walk.SyncItem) (bool, error)
 	if isAwsErr && awsErr.Code() == "NotFound" {
 		// Fine, object doesn't exist yet
 		logger.F("key", item.Key).Debug("blob is not present")
+		c.presence.store(fullURL, false)
 		return false, nil
 	}
 
@@ -148,31 +295,117 @@ func (c *client) uploadBlob(ctx context.Context, item walk.SyncItem) error {
 		return nil
 	}
 
-	file, err := os.Open(item.SrcPath)
+	file, err := item.OpenContext(ctx)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	fullURL := c.s3.Endpoint + "/" + c.cfg.GwEnv() + "/" + item.Key
+	var body io.Reader = file
+	if item.Archive != "" && item.Info != nil && item.Info.Size() > spoolMinSize {
+		dir := c.cfg.TempDir()
+		if err := checkFreeSpace(dir, c.cfg.TempMinFree(), item.Info.Size()); err != nil {
+			return err
+		}
+
+		spooled, err := spool(dir, file)
+		if err != nil {
+			return fmt.Errorf("spool %s to %s: %w", item.SrcPath, dir, err)
+		}
+		defer spooled.Close()
+
+		logger.F("src", item.SrcPath, "dir", dir).Debug("spooled archive entry for upload")
+		body = spooled
+	}
+
+	fullURL := c.s3.Endpoint + "/" + c.bucket + "/" + c.blobKey(item)
 	logConnectionOpen(ctx, fullURL)
 	defer logConnectionClose(ctx, fullURL)
 
-	res, err := c.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
-		Bucket: aws.String(c.cfg.GwEnv()),
-		Key:    &item.Key,
-		Body:   file,
-	})
+	input := &s3manager.UploadInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(c.blobKey(item)),
+		Body:   body,
+	}
+	if tags := c.cfg.UploadTags(); len(tags) > 0 {
+		values := url.Values{}
+		for key, value := range tags {
+			values.Set(key, value)
+		}
+		input.Tagging = aws.String(values.Encode())
+	}
+	if storageClass := c.cfg.UploadStorageClass(); storageClass != "" {
+		input.StorageClass = aws.String(storageClass)
+	}
+	if sse := c.cfg.UploadSSE(); sse != "" {
+		input.ServerSideEncryption = aws.String(sse)
+	}
+	if keyID := c.cfg.UploadSSEKMSKeyID(); keyID != "" {
+		// A KMS key only applies to KMS encryption, so it implies it.
+		if input.ServerSideEncryption == nil {
+			input.ServerSideEncryption = aws.String(s3.ServerSideEncryptionAwsKms)
+		}
+		input.SSEKMSKeyId = aws.String(keyID)
+	}
+
+	res, err := c.uploader.UploadWithContext(ctx, input)
 
 	if err != nil {
-		return fmt.Errorf("upload %s: %w", item.SrcPath, err)
+		return &uploadError{item.SrcPath, err}
 	}
 
 	logger.F("location", res.Location).Debug("uploaded blob")
+	c.presence.store(fullURL, true)
 
 	return nil
 }
 
+// uploadError is a failure to upload the content of a blob to S3, as opposed
+// to a failure reading that content, so the upload may be worth retrying.
+type uploadError struct {
+	src string
+	err error
+}
+
+func (e *uploadError) Error() string {
+	return fmt.Sprintf("upload %s: %v", e.src, e.err)
+}
+
+func (e *uploadError) Unwrap() error {
+	return e.err
+}
+
+// uploadBlobWithRetries is like uploadBlob, but retries failed uploads up to
+// UploadMaxAttempts times in total, with an increasing delay between them.
+func (c *client) uploadBlobWithRetries(ctx context.Context, item walk.SyncItem) error {
+	logger := log.FromContext(ctx)
+
+	maxAttempts := c.cfg.UploadMaxAttempts()
+	maxBackoff := time.Duration(c.cfg.UploadMaxBackoff()) * time.Millisecond
+	delay := time.Second
+
+	for attempt := 1; ; attempt++ {
+		err := c.uploadBlob(ctx, item)
+
+		var uploadErr *uploadError
+		if err == nil || attempt >= maxAttempts || !errors.As(err, &uploadErr) || ctx.Err() != nil {
+			return err
+		}
+
+		if delay > maxBackoff {
+			delay = maxBackoff
+		}
+		logger.F("src", item.SrcPath, "key", item.Key, "attempt", attempt, "delay", delay, "error", err).Warn("Retrying failed upload")
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
 type uploadState int
 
 const (
@@ -198,31 +431,102 @@ func (c *client) uploadWorker(
 ) {
 	defer wg.Done()
 
+	limiter := uploadLimiterFromContext(ctx)
+	keepGoing := KeepGoingFromContext(ctx)
+	noUpload := NoUploadFromContext(ctx)
+	forceUpload := ForceUploadFromContext(ctx)
+	presentBlobs := PresentBlobsFromContext(ctx)
+
 	for item := range items {
+		// Blobs are told apart by their key within the bucket, as for any
+		// other client with the same 'blobkeyprefix'.
+		blobKey := c.blobKey(item)
+
 		// Skip item if upload has already begun (by another worker)
-		if _, taken := takenItems.LoadOrStore(item.Key, true); taken {
+		if _, taken := takenItems.LoadOrStore(blobKey, true); taken {
 			log.FromContext(ctx).F("key", item.Key).Debug("Item is already being uploaded")
 			continue
 		}
 
-		// Determine if the blob is already present in the bucket
-		have, err := c.haveBlob(ctx, item)
+		// Skip item if its blob is known to be present already
+		if presentBlobs[blobKey] && !forceUpload[blobKey] {
+			log.FromContext(ctx).F("key", item.Key).Debug("Blob is known to be present")
+			results <- uploadResult{present, nil, item}
+			continue
+		}
+
+		// Wait for any other upload of the same blob, such as by a concurrent
+		// EnsureUploaded, rather than uploading it again
+		if owned, err := c.inflight.claim(ctx, blobKey, forceUpload[blobKey]); err != nil {
+			results <- uploadResult{failed, err, item}
+			return
+		} else if !owned {
+			log.FromContext(ctx).F("key", item.Key).Debug("Blob was made present by another upload")
+			results <- uploadResult{present, nil, item}
+			continue
+		}
+
+		// Wait until S3 can take another upload
+		if err := limiter.acquire(ctx); err != nil {
+			c.inflight.finish(blobKey, false, false)
+			results <- uploadResult{failed, err, item}
+			return
+		}
+
+		// Determine if the blob is already present in the bucket, unless it's
+		// to be replaced anyway
+		var have bool
+		var err error
+		if forceUpload[blobKey] {
+			log.FromContext(ctx).F("key", item.Key, "src", item.SrcPath).Info("Forcing upload of blob")
+		} else {
+			have, err = c.haveBlob(ctx, item)
+		}
 		if err != nil {
+			limiter.release(false)
+			c.inflight.finish(blobKey, false, false)
 			results <- uploadResult{
 				failed,
 				fmt.Errorf("checking for presence of %s: %w", item.Key, err),
 				item}
+			if keepGoing {
+				continue
+			}
 			return
 		}
 
 		// If so, no need to upload it
 		if have {
+			limiter.release(true)
+			c.inflight.finish(blobKey, true, false)
 			results <- uploadResult{present, nil, item}
 			continue
 		}
 
-		if err := c.uploadBlob(ctx, item); err != nil {
+		if noUpload {
+			limiter.release(true)
+			c.inflight.finish(blobKey, false, false)
+			results <- uploadResult{
+				failed,
+				fmt.Errorf("blob %s of %s is not present, and uploads are disabled", item.Key, item.SrcPath),
+				item}
+			if keepGoing {
+				continue
+			}
+			break
+		}
+
+		err = c.uploadBlobWithRetries(ctx, item)
+		c.inflight.finish(blobKey, err == nil, err == nil)
+		if limit, reduced := limiter.release(err == nil); reduced {
+			log.FromContext(ctx).F("key", item.Key, "error", err, "limit", limit).Warn(
+				"Upload failed, reducing upload concurrency")
+		}
+		if err != nil {
 			results <- uploadResult{failed, err, item}
+			if keepGoing {
+				continue
+			}
 			break
 		}
 
@@ -234,6 +538,8 @@ func (c *client) uploadWorker(
 func readUploadResults(
 	out chan<- error,
 	cancelFn func(),
+	keepGoing bool,
+	maxErrors int,
 	results <-chan uploadResult,
 	onUploaded func(walk.SyncItem) error,
 	onPresent func(walk.SyncItem) error,
@@ -247,11 +553,26 @@ func readUploadResults(
 		}
 	}
 
+	var failedItems []ItemError
+	aborted := false
+
 	defer close(out)
 	defer sendError(nil)
 
 	for result := range results {
-		if result.State == failed {
+		if result.State == failed && keepGoing {
+			// Items failing only as uploads are stopped aren't counted, so
+			// that exactly maxErrors are reported.
+			if aborted {
+				continue
+			}
+			failedItems = append(failedItems, ItemError{result.Item, result.Error})
+			if maxErrors > 0 && len(failedItems) >= maxErrors {
+				aborted = true
+				sendError(&UploadErrors{failedItems, true})
+				cancelFn()
+			}
+		} else if result.State == failed {
 			sendError(result.Error)
 			cancelFn()
 		}
@@ -276,6 +597,10 @@ func readUploadResults(
 			cancelFn()
 		}
 	}
+
+	if len(failedItems) > 0 {
+		sendError(&UploadErrors{failedItems, false})
+	}
 }
 
 func (c *client) EnsureUploaded(
@@ -285,10 +610,21 @@ func (c *client) EnsureUploaded(
 	onPresent func(walk.SyncItem) error,
 	onDuplicate func(walk.SyncItem) error,
 ) error {
+	c.presenceOnce.Do(func() {
+		maxAge := time.Duration(c.cfg.BlobCacheMaxAge()) * time.Second
+		c.presence = newPresenceCache(ctx, c.cfg.BlobCache(), maxAge)
+	})
+
 	// Maintain a map of items processed thus far
 	processedItems := make(map[string]walk.SyncItem)
 
-	numThreads := c.cfg.UploadThreads()
+	// Uploads are spread over enough goroutines for the most allowed at
+	// once, but fewer may be active at once while S3 is throttling requests.
+	limiter := c.limiter
+	if limiter == nil {
+		limiter = newUploadLimiter(c.cfg.UploadThreads())
+	}
+	numThreads := max(c.cfg.UploadThreads(), c.cfg.ConcurrencyMax())
 	var wg sync.WaitGroup
 	results := make(chan uploadResult, len(items))
 	jobs := make(chan walk.SyncItem, len(items))
@@ -304,6 +640,8 @@ func (c *client) EnsureUploaded(
 	// in any of them.
 	uploadCtx, uploadCancel := context.WithCancel(ctx)
 
+	uploadCtx = withUploadLimiter(uploadCtx, limiter)
+
 	// These goroutines are responsible for handling each item by reading
 	// from 'jobs' and writing a result per item to 'results'.
 	for i := 0; i < numThreads; i++ {
@@ -319,7 +657,7 @@ func (c *client) EnsureUploaded(
 	// from a single goroutine.
 	out := make(chan error, 1)
 	go readUploadResults(
-		out, uploadCancel, results,
+		out, uploadCancel, KeepGoingFromContext(ctx), MaxErrorsFromContext(ctx), results,
 		onUploaded, onPresent, onDuplicate)
 
 	// Now send all the items
@@ -352,9 +690,22 @@ func (c *client) EnsureUploaded(
 	// Let the results reader know there are no more results coming.
 	close(results)
 
+	// Blobs found to be present are kept for later runs even if this one fails.
+	if err := c.presence.save(ctx); err != nil {
+		log.FromContext(ctx).F("path", c.cfg.BlobCache(), "error", err).Warn("Can't write blob cache")
+	}
+
 	// Block for the result reader to complete and return whatever
 	// error (or nil) it calculated.
-	return <-out
+	err := <-out
+
+	// Items which failed only because the sync was interrupted aren't
+	// failures to keep going past.
+	if _, partial := err.(*UploadErrors); partial && ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return err
 }
 
 func retryWithLogging(logger *log.Logger, fn rehttp.RetryFn) rehttp.RetryFn {
@@ -390,14 +741,44 @@ func retryWithLogging(logger *log.Logger, fn rehttp.RetryFn) rehttp.RetryFn {
 	}
 }
 
+// opTransport dispatches each request to a RoundTripper according to the
+// operation class found in the request's context.
+type opTransport map[operation]http.RoundTripper
+
+func (t opTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	return t[operationFromContext(r.Context())].RoundTrip(r)
+}
+
+// policy returns the timeout (in milliseconds) and max attempts configured
+// for requests of the given operation class.
+func policy(cfg conf.Config, op operation) (int, int) {
+	switch op {
+	case opWrite:
+		return cfg.GwWriteTimeout(), cfg.GwWriteMaxAttempts()
+	case opCommit:
+		return cfg.GwCommitTimeout(), cfg.GwCommitMaxAttempts()
+	}
+	return cfg.GwReadTimeout(), cfg.GwReadMaxAttempts()
+}
+
 func retryTransport(ctx context.Context, cfg conf.Config, rt http.RoundTripper) http.RoundTripper {
-	// Wrap a roundtripper with retries.
+	// Wrap a roundtripper with retries, using a separate policy for each
+	// class of operation.
+	out := opTransport{}
+	for _, op := range []operation{opRead, opWrite, opCommit} {
+		timeout, maxAttempts := policy(cfg, op)
+		out[op] = opRetryTransport(ctx, cfg, rt, timeout, maxAttempts)
+	}
+	return out
+}
+
+func opRetryTransport(ctx context.Context, cfg conf.Config, rt http.RoundTripper, timeout int, maxAttempts int) http.RoundTripper {
 	logger := log.FromContext(ctx)
 
 	retryFn := rehttp.RetryAll(
-		rehttp.RetryMaxRetries(cfg.GwMaxAttempts()),
+		rehttp.RetryMaxRetries(maxAttempts),
 		rehttp.RetryAny(
-			rehttp.RetryStatuses(500, 502, 503, 504),
+			newRetryClassifier(cfg).retryFn(),
 			rehttp.RetryTimeoutErr(),
 			rehttp.RetryIsErr(func(err error) bool {
 				return err == io.EOF
@@ -406,61 +787,203 @@ func retryTransport(ctx context.Context, cfg conf.Config, rt http.RoundTripper)
 	)
 	retryFn = retryWithLogging(logger, retryFn)
 
-	return rehttp.NewTransport(rt,
+	out := rehttp.NewTransport(rt,
 		retryFn,
 		rehttp.ExpJitterDelay(
 			time.Duration(2)*time.Second,
 			time.Duration(cfg.GwMaxBackoff())*time.Millisecond,
 		),
 	)
+	out.PerAttemptTimeout = time.Duration(timeout) * time.Millisecond
+
+	return out
+}
+
+// proxyFunc returns a function selecting the proxy for each request made via
+// an http.Transport: the proxy at proxyURL, unless the request's host matches
+// an entry in noProxy. An empty proxyURL means requests are never proxied.
+func proxyFunc(proxyURL string, noProxy []string) (func(*http.Request) (*url.URL, error), error) {
+	if proxyURL == "" {
+		return nil, nil
+	}
+
+	parsed, err := url.Parse(proxyURL)
+	if err == nil && (parsed.Scheme == "" || parsed.Host == "") {
+		err = fmt.Errorf("missing scheme or host")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL '%s': %w", proxyURL, err)
+	}
+
+	return func(r *http.Request) (*url.URL, error) {
+		if bypassProxy(r.URL.Hostname(), noProxy) {
+			return nil, nil
+		}
+		return parsed, nil
+	}, nil
+}
+
+// bypassProxy returns true if host matches any entry of noProxy.
+//
+// As with the NO_PROXY environment variable, an entry matches the host itself
+// and any subdomains, with or without a leading ".", and "*" matches any host.
+func bypassProxy(host string, noProxy []string) bool {
+	host = strings.ToLower(host)
+
+	for _, entry := range noProxy {
+		entry = strings.ToLower(strings.TrimPrefix(entry, "."))
+		if entry == "*" || host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (impl) NewClient(ctx context.Context, cfg conf.Config) (Client, error) {
-	cert, err := tls.LoadX509KeyPair(cfg.GwCert(), cfg.GwKey())
+	cert, err := loadKeyPair(ctx, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("can't load cert/key: %w", err)
 	}
 
-	out := &client{cfg: cfg}
+	if err := checkItemSchema(cfg.GwItemSchema()); err != nil {
+		return nil, err
+	}
 
-	transport := http.Transport{
-		TLSClientConfig: &tls.Config{
-			Certificates: []tls.Certificate{cert},
-		},
+	if err := checkRetryStatuses(cfg); err != nil {
+		return nil, err
+	}
+
+	// Fail before uploading anything if there's not enough room to spool
+	// content, rather than part way through.
+	if err := checkFreeSpace(cfg.TempDir(), cfg.TempMinFree(), 0); err != nil {
+		return nil, err
+	}
+
+	out := &client{
+		cfg:       cfg,
+		rateLimit: newRateLimiter(time.Duration(cfg.GwMaxBackoff()) * time.Millisecond),
+		clock:     newServerClock(time.Duration(cfg.MaxClockSkew()) * time.Millisecond),
+		limiter:   newClientLimiter(cfg, ThrottleOnErrorFromContext(ctx)),
+		inflight:  newInflightUploads(),
+	}
+
+	// exodus-gw and S3 requests may each be routed through their own proxy,
+	// so they can't share a transport.
+	gwProxy, err := proxyFunc(cfg.GwProxy(), cfg.NoProxy())
+	if err != nil {
+		return nil, fmt.Errorf("gwproxy: %w", err)
+	}
+	s3Proxy, err := proxyFunc(cfg.S3Proxy(), cfg.NoProxy())
+	if err != nil {
+		return nil, fmt.Errorf("s3proxy: %w", err)
+	}
+
+	// Connecting is limited separately from each request, so that an
+	// unreachable endpoint fails quickly and the request can be retried.
+	dialer := &net.Dialer{Timeout: time.Duration(cfg.DialTimeout()) * time.Millisecond}
+	handshakeTimeout := time.Duration(cfg.TLSHandshakeTimeout()) * time.Millisecond
+
+	gwTLSConfig, err := newTLSConfig(cfg, cert)
+	if err != nil {
+		return nil, err
+	}
+	gwTransport := http.Transport{
+		TLSClientConfig:     gwTLSConfig,
+		Proxy:               gwProxy,
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: handshakeTimeout,
+	}
+	s3Transport := http.Transport{
+		TLSClientConfig:     gwTLSConfig.Clone(),
+		Proxy:               s3Proxy,
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: handshakeTimeout,
 	}
 
 	// This client is passed into AWS SDK and it should not add any
 	// retry logic because the AWS SDK already does that:
-	s3HttpClient := &http.Client{Transport: &transport}
+	s3HttpClient := &http.Client{Transport: &s3Transport}
+
+	var gwRT http.RoundTripper = &gwTransport
+	transcript := TranscriptFromContext(ctx)
+	if transcript != nil {
+		// Beneath the retries, so that each attempt is recorded.
+		gwRT = transcript.transport(gwRT)
+	}
 
 	// This client is used outside of the AWS SDK (i.e. for requests
 	// to "publish" API) and it should wrap the transport to enable
 	// retries for certain types of error.
-	out.httpClient = &http.Client{Transport: retryTransport(ctx, cfg, &transport)}
+	checkRedirect, err := newCheckRedirect(cfg.GwRedirects())
+	if err != nil {
+		return nil, err
+	}
+	out.httpClient = &http.Client{Transport: retryTransport(ctx, cfg, gwRT), CheckRedirect: checkRedirect}
 
 	awsLogLevel := aws.LogOff
 	if cfg.Verbosity() > 2 || cfg.LogLevel() == "trace" {
 		awsLogLevel = aws.LogDebug
 	}
 
-	sess, err := ext.awsSessionProvider(session.Options{
-		SharedConfigState: session.SharedConfigDisable,
+	opts := session.Options{
 		Config: aws.Config{
-			Endpoint:         aws.String(cfg.GwURL() + "/upload"),
-			S3ForcePathStyle: aws.Bool(true),
-			Region:           aws.String("us-east-1"),
-			Credentials:      credentials.AnonymousCredentials,
-			HTTPClient:       s3HttpClient,
-			Logger:           log.FromContext(ctx),
-			LogLevel:         aws.LogLevel(awsLogLevel),
-			MaxRetries:       aws.Int(cfg.GwMaxAttempts()),
+			HTTPClient: s3HttpClient,
+			Logger:     log.FromContext(ctx),
+			LogLevel:   aws.LogLevel(awsLogLevel),
+			MaxRetries: aws.Int(cfg.GwMaxAttempts()),
 		},
-	})
+	}
+
+	switch cfg.S3Access() {
+	case "gw":
+		// exodus-gw serves an S3 API for each environment, granting access
+		// by the client's certificate.
+		opts.SharedConfigState = session.SharedConfigDisable
+		opts.Config.Endpoint = aws.String(cfg.GwURL() + "/upload")
+		opts.Config.S3ForcePathStyle = aws.Bool(true)
+		opts.Config.Region = aws.String("us-east-1")
+		opts.Config.Credentials = credentials.AnonymousCredentials
+		out.bucket = cfg.GwEnv()
+	case "direct":
+		// With no credentials given, the session uses the standard chain:
+		// environment variables, shared config and credentials files,
+		// web identity, then the container or instance role.
+		opts.SharedConfigState = session.SharedConfigEnable
+
+		// The default is resolved here rather than by the config, so that
+		// it follows any override of GwEnv, as with --exodus-env.
+		out.bucket = cfg.S3Bucket()
+		if out.bucket == "" {
+			out.bucket = cfg.GwEnv()
+		}
+	default:
+		return nil, fmt.Errorf("s3access: unsupported value '%s'", cfg.S3Access())
+	}
+
+	sess, err := ext.awsSessionProvider(opts)
 	if err != nil {
 		return nil, fmt.Errorf("create AWS session: %w", err)
 	}
+	if aws.StringValue(sess.Config.Region) == "" {
+		sess.Config.Region = aws.String("us-east-1")
+	}
 
 	out.s3 = s3.New(sess)
+	out.s3.Handlers.Build.PushBackNamed(requestIDHandler)
+	out.s3.Handlers.Retry.PushBackNamed(throttleHandler)
+
+	// S3 refuses requests signed at a time too far from its own, so they're
+	// signed at the time according to S3.
+	clockObserve, clockSign, clockRetry := out.clock.s3Handlers()
+	out.s3.Handlers.Send.PushBackNamed(clockObserve)
+	out.s3.Handlers.Sign.Swap(clockSign.Name, clockSign)
+	out.s3.Handlers.Retry.PushBackNamed(clockRetry)
+	if transcript != nil {
+		// The AWS SDK may require its own transport, so S3 requests are
+		// recorded from a handler instead.
+		out.s3.Handlers.Send.PushBackNamed(transcript.s3Handler())
+	}
 	out.uploader = s3manager.NewUploaderWithClient(out.s3)
 
 	return out, nil
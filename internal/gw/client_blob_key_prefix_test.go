@@ -0,0 +1,165 @@
+package gw
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+// Config holding blobs beneath a key prefix.
+type blobKeyPrefixConfig struct {
+	conf.Config
+	prefix string
+}
+
+func (c blobKeyPrefixConfig) BlobKeyPrefix() string {
+	return c.prefix
+}
+
+func TestClientUploadBlobKeyPrefix(t *testing.T) {
+	ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+
+	iface, err := Package.NewClient(ctx, blobKeyPrefixConfig{testConfig(t), "blobs/"})
+	if err != nil {
+		t.Fatal("creating client:", err)
+	}
+	client := iface.(*client)
+	s3 := newFakeS3(t, client)
+
+	// A blob at the root of the bucket doesn't count as present.
+	s3.blobs["abc123"] = nil
+	s3.blobs["blobs/def456"] = nil
+
+	chdirInTest(t, "../../test/data/srctrees/just-files")
+
+	items := []walk.SyncItem{
+		{SrcPath: "hello-copy-one", Key: "abc123"},
+		{SrcPath: "hello-copy-two", Key: "def456"},
+	}
+
+	uploaded := []string{}
+	present := []string{}
+	err = client.EnsureUploaded(ctx, items,
+		func(item walk.SyncItem) error {
+			uploaded = append(uploaded, item.Key)
+			return nil
+		},
+		func(item walk.SyncItem) error {
+			present = append(present, item.Key)
+			return nil
+		},
+		func(walk.SyncItem) error { return nil },
+	)
+	if err != nil {
+		t.Fatalf("got unexpected error %v", err)
+	}
+
+	if !reflect.DeepEqual(uploaded, []string{"abc123"}) || !reflect.DeepEqual(present, []string{"def456"}) {
+		t.Errorf("uploaded %v, present %v", uploaded, present)
+	}
+	if s3.heads["blobs/abc123"] != 1 || s3.heads["blobs/def456"] != 1 || s3.heads["abc123"] != 0 {
+		t.Errorf("unexpected checks for presence %v", s3.heads)
+	}
+	if s3.puts["blobs/abc123"] == nil {
+		t.Errorf("blob was not uploaded beneath prefix, uploads: %v", s3.puts)
+	}
+}
+
+func TestClientPublishItemsBlobKeyPrefix(t *testing.T) {
+	ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+
+	clientIface, err := Package.NewClient(ctx, blobKeyPrefixConfig{testConfig(t), "tenant/"})
+	if err != nil {
+		t.Fatalf("failed to create client, err = %v", err)
+	}
+
+	gw := newFakeGw(t, clientIface.(*client))
+	gw.publishes["some-publish"] = &fakePublish{id: "some-publish"}
+
+	publish, err := clientIface.GetPublish(ctx, "some-publish")
+	if err != nil {
+		t.Fatalf("failed to get publish, err = %v", err)
+	}
+
+	addItems := []ItemInput{
+		{WebURI: "/some/link", LinkTo: "/some/path"},
+		{WebURI: "/some/path", ObjectKey: "1234", ContentType: "mime/type"},
+	}
+	if err := publish.AddItems(ctx, addItems); err != nil {
+		t.Fatalf("failed to add items, err = %v", err)
+	}
+
+	// exodus-gw should get object keys beneath the prefix, except for links.
+	want := []ItemInput{
+		{WebURI: "/some/link", LinkTo: "/some/path"},
+		{WebURI: "/some/path", ObjectKey: "tenant/1234", ContentType: "mime/type"},
+	}
+	if got := gw.publishes["some-publish"].items; !reflect.DeepEqual(got, want) {
+		t.Errorf("exodus-gw got items %v, want %v", got, want)
+	}
+
+	// The caller's items are left alone.
+	if addItems[1].ObjectKey != "1234" {
+		t.Errorf("items were modified: %v", addItems)
+	}
+
+	// Items are returned with the same keys as they were added.
+	items, err := publish.Items(ctx)
+	if err != nil {
+		t.Fatalf("failed to get items, err = %v", err)
+	}
+	if !reflect.DeepEqual(items, addItems) {
+		t.Errorf("got items %v, want %v", items, addItems)
+	}
+}
+
+func TestClientUploadPresentBlobsKeyPrefix(t *testing.T) {
+	ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+
+	iface, err := Package.NewClient(ctx, blobKeyPrefixConfig{testConfig(t), "blobs/"})
+	if err != nil {
+		t.Fatal("creating client:", err)
+	}
+	client := iface.(*client)
+	s3 := newFakeS3(t, client)
+
+	chdirInTest(t, "../../test/data/srctrees/just-files")
+
+	items := []walk.SyncItem{
+		{SrcPath: "hello-copy-one", Key: "abc123"},
+		{SrcPath: "hello-copy-two", Key: "def456"},
+	}
+
+	// Blobs are known by their key within the bucket, so one known at the
+	// root of the bucket isn't taken to be beneath the prefix.
+	ctx = WithPresentBlobs(ctx, map[string]bool{"abc123": true, "blobs/def456": true})
+
+	uploaded := []string{}
+	present := []string{}
+	err = client.EnsureUploaded(ctx, items,
+		func(item walk.SyncItem) error {
+			uploaded = append(uploaded, item.Key)
+			return nil
+		},
+		func(item walk.SyncItem) error {
+			present = append(present, item.Key)
+			return nil
+		},
+		func(walk.SyncItem) error { return nil },
+	)
+	if err != nil {
+		t.Fatalf("got unexpected error %v", err)
+	}
+
+	if !reflect.DeepEqual(uploaded, []string{"abc123"}) || !reflect.DeepEqual(present, []string{"def456"}) {
+		t.Errorf("uploaded %v, present %v", uploaded, present)
+	}
+	if s3.heads["blobs/def456"] != 0 || s3.puts["blobs/abc123"] == nil {
+		t.Errorf("unexpected checks %v, uploads %v", s3.heads, s3.puts)
+	}
+}
@@ -0,0 +1,86 @@
+package gw
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+type connTimeoutConfig struct {
+	proxyConfig
+	dialTimeout      int
+	handshakeTimeout int
+}
+
+func (c connTimeoutConfig) DialTimeout() int {
+	return c.dialTimeout
+}
+
+func (c connTimeoutConfig) TLSHandshakeTimeout() int {
+	return c.handshakeTimeout
+}
+
+// stallingListener accepts TCP connections, but never responds on them.
+func stallingListener(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conns := make(chan net.Conn, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+		}
+	}()
+
+	t.Cleanup(func() {
+		listener.Close()
+		close(conns)
+		for conn := range conns {
+			conn.Close()
+		}
+	})
+
+	return listener
+}
+
+func TestClientTLSHandshakeTimeout(t *testing.T) {
+	listener := stallingListener(t)
+
+	cfg := connTimeoutConfig{
+		proxyConfig:      proxyConfig{Config: testConfig(t), gwURL: "https://" + listener.Addr().String()},
+		dialTimeout:      30000,
+		handshakeTimeout: 50,
+	}
+
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	clientIface, err := Package.NewClient(ctx, cfg)
+	if err != nil {
+		t.Fatalf("failed to create client, err = %v", err)
+	}
+
+	start := time.Now()
+	_, err = clientIface.NewPublish(ctx)
+	elapsed := time.Since(start)
+
+	// It should fail quickly, rather than waiting on the server, even with
+	// retries.
+	if err == nil || !strings.Contains(err.Error(), "TLS handshake timeout") {
+		t.Errorf("did not get expected error, err = %v", err)
+	}
+	if elapsed > 5*time.Second {
+		t.Errorf("took %v to fail", elapsed)
+	}
+}
@@ -0,0 +1,86 @@
+package gw
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+// A RoundTripper failing the first POST with a 503, and recording the
+// idempotency key of every POST.
+type flakyCreateGw struct {
+	gw   *fakeGw
+	keys []string
+}
+
+func (f *flakyCreateGw) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Method != "POST" {
+		return f.gw.RoundTrip(r)
+	}
+
+	f.keys = append(f.keys, r.Header.Get("X-Idempotency-Key"))
+	if len(f.keys) == 1 {
+		return &http.Response{
+			Status:     "503 Service Unavailable",
+			StatusCode: 503,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}, nil
+	}
+
+	return f.gw.RoundTrip(r)
+}
+
+func TestClientNewPublishRetry(t *testing.T) {
+	cfg := testConfig(t)
+
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	clientIface, err := Package.NewClient(ctx, cfg)
+	if err != nil {
+		t.Fatalf("failed to create client, err = %v", err)
+	}
+	c := clientIface.(*client)
+
+	gw := newFakeGw(t, c)
+	gw.createPublishIds = []string{"abc-123", "def-456"}
+
+	flaky := &flakyCreateGw{gw: gw}
+	c.httpClient.Transport = retryTransport(ctx, cfg, flaky)
+
+	publish, err := c.NewPublish(ctx)
+	if err != nil {
+		t.Fatalf("failed to create publish, err = %v", err)
+	}
+
+	// The publish from the retried request should be usable.
+	if publish.ID() != "abc-123" {
+		t.Errorf("got unexpected id %s", publish.ID())
+	}
+	if err := publish.AddItems(ctx, []ItemInput{{WebURI: "/some/path", ObjectKey: "1234", ContentType: "mime/type"}}); err != nil {
+		t.Errorf("failed to add items, err = %v", err)
+	}
+	if err := publish.Commit(ctx, ""); err != nil {
+		t.Errorf("failed to commit, err = %v", err)
+	}
+
+	// Both attempts should have carried the same key, so exodus-gw
+	// can recognize the retry.
+	if len(flaky.keys) < 3 || flaky.keys[0] == "" || flaky.keys[0] != flaky.keys[1] {
+		t.Fatalf("unexpected idempotency keys %q", flaky.keys)
+	}
+
+	// A later publish is distinct, so it has another key.
+	if _, err := c.NewPublish(ctx); err != nil {
+		t.Fatalf("failed to create second publish, err = %v", err)
+	}
+	last := flaky.keys[len(flaky.keys)-1]
+	if last == "" || last == flaky.keys[0] {
+		t.Errorf("unexpected idempotency keys %q", flaky.keys)
+	}
+}
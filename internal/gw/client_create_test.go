@@ -17,6 +17,8 @@ func TestNewClientCertError(t *testing.T) {
 
 	cfg.EXPECT().GwCert().Return("cert-does-not-exist")
 	cfg.EXPECT().GwKey().Return("key-does-not-exist")
+	cfg.EXPECT().GwCertCommand().Return("")
+	cfg.EXPECT().GwKeyCommand().Return("")
 
 	_, err := Package.NewClient(context.Background(), cfg)
 
@@ -0,0 +1,137 @@
+package gw
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+)
+
+type credentialsConfig struct {
+	conf.Config
+	certCommand string
+	keyCommand  string
+}
+
+func (c credentialsConfig) GwCert() string {
+	return "cert-does-not-exist"
+}
+
+func (c credentialsConfig) GwKey() string {
+	return "key-does-not-exist"
+}
+
+func (c credentialsConfig) GwCertCommand() string {
+	return c.certCommand
+}
+
+func (c credentialsConfig) GwKeyCommand() string {
+	return c.keyCommand
+}
+
+// Writes a fake credential helper which outputs the requested testdata file,
+// or fails if asked for anything else.
+func fakeHelper(t *testing.T) string {
+	dataDir, err := filepath.Abs("../../test/data")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helper := filepath.Join(t.TempDir(), "helper")
+	script := `#!/bin/sh
+case "$1" in
+cert) cat ` + dataDir + `/service.pem ;;
+key) cat ` + dataDir + `/service-key.pem ;;
+garbage) echo not a PEM ;;
+*) echo "no such secret: $1" >&2; exit 3 ;;
+esac
+`
+	if err := os.WriteFile(helper, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	return helper
+}
+
+func TestNewClientCredentialCommands(t *testing.T) {
+	helper := fakeHelper(t)
+
+	cfg := credentialsConfig{
+		Config:      testConfig(t),
+		certCommand: helper + " cert",
+		keyCommand:  helper + " key",
+	}
+
+	// The configured files don't exist, so this only works if the commands
+	// were used.
+	client, err := Package.NewClient(context.Background(), cfg)
+	if client == nil || err != nil {
+		t.Errorf("unexpectedly failed to make client, client = %v, err = %v", client, err)
+	}
+}
+
+func TestNewClientCredentialCommandErrors(t *testing.T) {
+	helper := fakeHelper(t)
+
+	tests := []struct {
+		name        string
+		certCommand string
+		keyCommand  string
+		expected    string
+	}{
+		{"cert command fails", helper + " other", helper + " key",
+			"can't load cert/key: gwcertcommand failed: exit status 3: no such secret: other"},
+		{"key command fails", helper + " cert", helper + " other",
+			"can't load cert/key: gwkeycommand failed: exit status 3: no such secret: other"},
+		{"invalid output", helper + " garbage", helper + " key",
+			"can't load cert/key: tls: failed to find any PEM data in certificate input"},
+		{"only cert command", helper + " cert", "",
+			"can't load cert/key: open key-does-not-exist: no such file or directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := credentialsConfig{
+				Config:      testConfig(t),
+				certCommand: tt.certCommand,
+				keyCommand:  tt.keyCommand,
+			}
+
+			_, err := Package.NewClient(context.Background(), cfg)
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("did not get expected error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewClientCredentialCommandCancelled(t *testing.T) {
+	cfg := credentialsConfig{
+		Config:      testConfig(t),
+		certCommand: "sleep 10",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Package.NewClient(ctx, cfg)
+	if err == nil || !strings.Contains(err.Error(), "gwcertcommand failed") {
+		t.Errorf("did not get expected error, got: %v", err)
+	}
+}
+
+func TestNewClientCredentialCommandRedacted(t *testing.T) {
+	cfg := credentialsConfig{
+		Config:      testConfig(t),
+		certCommand: "exit 1; --token=s3cr3t",
+	}
+
+	// The command may hold secrets, so isn't included in the error.
+	_, err := Package.NewClient(context.Background(), cfg)
+	if err == nil || strings.Contains(err.Error(), "s3cr3t") {
+		t.Errorf("did not get expected error, got: %v", err)
+	}
+}
@@ -20,6 +20,8 @@ func TestNewDryRunClientCertError(t *testing.T) {
 
 	cfg.EXPECT().GwCert().Return("cert-does-not-exist")
 	cfg.EXPECT().GwKey().Return("key-does-not-exist")
+	cfg.EXPECT().GwCertCommand().Return("")
+	cfg.EXPECT().GwKeyCommand().Return("")
 
 	_, err := Package.NewDryRunClient(context.Background(), cfg)
 
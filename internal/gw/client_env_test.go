@@ -0,0 +1,99 @@
+package gw
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+func TestClientEnvFromDest(t *testing.T) {
+	var mu sync.Mutex
+	paths := []string{}
+
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+
+		w.Write([]byte(`{"id": "1234", "links": {}}`))
+	}))
+	t.Cleanup(gw.Close)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	confPath := filepath.Join(t.TempDir(), "exodus-rsync.conf")
+	err = os.WriteFile(confPath, []byte(`
+gwurl: `+gw.URL+`
+gwcert: `+wd+`/../../test/data/service.pem
+gwkey: `+wd+`/../../test/data/service-key.pem
+gwenv: default-env
+
+environments:
+- prefix: exodus:/content/stage
+  gwenv: stage
+
+- prefix: exodus:/content/prod
+  gwenv: prod
+
+- prefix: exodus:/content
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	cfg, err := conf.Package.Load(ctx, args.Config{ExodusConfig: args.ExodusConfig{Conf: confPath}})
+	if err != nil {
+		t.Fatalf("can't load config: %v", err)
+	}
+
+	tests := []struct {
+		dest     string
+		expected string
+	}{
+		{"exodus:/content/stage/some/path", "POST /stage/publish"},
+		{"exodus:/content/prod/some/path", "POST /prod/publish"},
+		{"exodus:/content/other", "POST /default-env/publish"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dest, func(t *testing.T) {
+			mu.Lock()
+			paths = []string{}
+			mu.Unlock()
+
+			env := cfg.EnvironmentForDest(ctx, tt.dest)
+			if env == nil {
+				t.Fatal("no environment for dest")
+			}
+
+			client, err := Package.NewClient(ctx, env)
+			if err != nil {
+				t.Fatalf("failed to create client, err = %v", err)
+			}
+
+			if _, err := client.NewPublish(ctx); err != nil {
+				t.Fatalf("failed to create publish, err = %v", err)
+			}
+
+			// The publish should have been created in the environment
+			// matching the destination.
+			if len(paths) != 1 || paths[0] != tt.expected {
+				t.Errorf("unexpected requests: %v", paths)
+			}
+		})
+	}
+}
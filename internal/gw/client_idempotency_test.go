@@ -0,0 +1,88 @@
+package gw
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+type idempotencyConfig struct {
+	conf.Config
+	enabled bool
+}
+
+func (c idempotencyConfig) GwUseIdempotencyKeys() bool {
+	return c.enabled
+}
+
+// A RoundTripper recording the idempotency keys of each request other than
+// a GET, or nil where the header is absent.
+type keyRecordingGw struct {
+	gw   *fakeGw
+	keys [][]string
+}
+
+func (g *keyRecordingGw) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Method != "GET" {
+		g.keys = append(g.keys, r.Header.Values("X-Idempotency-Key"))
+	}
+	return g.gw.RoundTrip(r)
+}
+
+func TestClientIdempotencyKeys(t *testing.T) {
+	ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+
+	for _, enabled := range []bool{true, false} {
+		cfg := idempotencyConfig{testConfig(t), enabled}
+
+		clientIface, err := Package.NewClient(ctx, cfg)
+		if err != nil {
+			t.Fatalf("failed to create client, err = %v", err)
+		}
+		c := clientIface.(*client)
+
+		gw := newFakeGw(t, c)
+		gw.createPublishIds = []string{"abc-123"}
+
+		recorder := &keyRecordingGw{gw: gw}
+		c.httpClient.Transport = retryTransport(ctx, cfg, recorder)
+
+		publish, err := c.NewPublish(ctx)
+		if err != nil {
+			t.Fatalf("failed to create publish, err = %v", err)
+		}
+		if err := publish.AddItems(ctx, []ItemInput{{WebURI: "/some/path", ObjectKey: "1234"}}); err != nil {
+			t.Fatalf("failed to add items, err = %v", err)
+		}
+		if err := publish.Commit(ctx, ""); err != nil {
+			t.Fatalf("failed to commit, err = %v", err)
+		}
+
+		// Create, add items and commit.
+		if len(recorder.keys) != 3 {
+			t.Fatalf("enabled=%v: unexpected requests, keys %q", enabled, recorder.keys)
+		}
+
+		seen := make(map[string]bool)
+		for i, keys := range recorder.keys {
+			if !enabled {
+				if keys != nil {
+					t.Errorf("request %d carried idempotency key %q", i, keys)
+				}
+				continue
+			}
+
+			// Each request has a key of its own.
+			if len(keys) != 1 || keys[0] == "" || seen[keys[0]] {
+				t.Errorf("request %d: unexpected idempotency keys %q", i, keys)
+			}
+			if len(keys) > 0 {
+				seen[keys[0]] = true
+			}
+		}
+	}
+}
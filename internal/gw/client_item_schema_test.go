@@ -0,0 +1,123 @@
+package gw
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+type itemSchemaConfig struct {
+	conf.Config
+	version int
+}
+
+func (c itemSchemaConfig) GwItemSchema() int {
+	return c.version
+}
+
+var schemaTestItems = []ItemInput{
+	{WebURI: "/some/file", ObjectKey: "abc123", ContentType: "text/plain", Visibility: "restricted",
+		Metadata: map[string]string{"user.origin": "build-1"}},
+	{WebURI: "/some/file.gz", ObjectKey: "def456", ContentType: "text/plain", ContentEncoding: "gzip", NoReplace: true},
+	{WebURI: "/some/link", LinkTo: "/some/file"},
+}
+
+var schemaTestJSON = map[int]string{
+	1: `[` +
+		`{"web_uri":"/some/file","object_key":"abc123","content_type":"text/plain","link_to":"","visibility":"restricted","metadata":{"user.origin":"build-1"}},` +
+		`{"web_uri":"/some/file.gz","object_key":"def456","content_type":"text/plain","link_to":"","content_encoding":"gzip","no_replace":true},` +
+		`{"web_uri":"/some/link","object_key":"","content_type":"","link_to":"/some/file"}` +
+		`]`,
+	2: `[` +
+		`{"web_uri":"/some/file","object_key":"abc123","content_type":"text/plain","visibility":"restricted","metadata":{"user.origin":"build-1"}},` +
+		`{"web_uri":"/some/file.gz","object_key":"def456","content_type":"text/plain","content_encoding":"gzip","no_replace":true},` +
+		`{"web_uri":"/some/link","link_to":"/some/file"}` +
+		`]`,
+}
+
+func TestSchemaItems(t *testing.T) {
+	for version, want := range schemaTestJSON {
+		got, err := json.Marshal(schemaItems(schemaTestItems, version))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("version %d: got %s, expected %s", version, got, want)
+		}
+	}
+}
+
+// A RoundTripper recording the body of each PUT request.
+type bodyRecordingGw struct {
+	gw     *fakeGw
+	bodies []string
+}
+
+func (b *bodyRecordingGw) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Method == "PUT" {
+		body, _ := io.ReadAll(r.Body)
+		b.bodies = append(b.bodies, strings.TrimSpace(string(body)))
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return b.gw.RoundTrip(r)
+}
+
+func TestClientItemSchema(t *testing.T) {
+	ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+
+	for version, want := range schemaTestJSON {
+		cfg := itemSchemaConfig{testConfig(t), version}
+
+		clientIface, err := Package.NewClient(ctx, cfg)
+		if err != nil {
+			t.Fatalf("failed to create client, err = %v", err)
+		}
+		c := clientIface.(*client)
+
+		gw := newFakeGw(t, c)
+		gw.createPublishIds = []string{"abc-123"}
+		recorder := &bodyRecordingGw{gw: gw}
+		c.httpClient.Transport = retryTransport(ctx, cfg, recorder)
+
+		publish, err := c.NewPublish(ctx)
+		if err != nil {
+			t.Fatalf("failed to create publish, err = %v", err)
+		}
+
+		// Items are sorted by web URI, as they are already.
+		if err := publish.AddItems(ctx, schemaTestItems); err != nil {
+			t.Fatalf("failed to add items, err = %v", err)
+		}
+
+		if len(recorder.bodies) != 1 || recorder.bodies[0] != want {
+			t.Errorf("version %d: sent %v, expected %s", version, recorder.bodies, want)
+		}
+
+		// The items should be understood however they're sent.
+		if got := gw.publishes["abc-123"].items; len(got) != len(schemaTestItems) || !reflect.DeepEqual(got[1:], schemaTestItems[1:]) {
+			t.Errorf("version %d: publish has unexpected items %v", version, got)
+		}
+	}
+}
+
+func TestNewClientItemSchemaInvalid(t *testing.T) {
+	for _, version := range []int{-1, 3} {
+		cfg := itemSchemaConfig{testConfig(t), version}
+
+		if _, err := Package.NewClient(context.Background(), cfg); err == nil || !strings.Contains(err.Error(), "gwitemschema: unsupported version") {
+			t.Errorf("version %d: did not get expected error, err = %v", version, err)
+		}
+		if _, err := Package.NewOfflineClient(context.Background(), cfg, t.TempDir()); err == nil {
+			t.Errorf("version %d: offline client did not fail", version)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package gw
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+const listPublishesResponse = `[
+	{"id": "publish-1", "env": "env", "state": "PENDING", "updated": "2026-10-01T10:00:00"},
+	{"id": "publish-2", "env": "env", "state": "COMMITTED", "updated": "2026-10-01T11:00:00"},
+	{"id": "publish-3", "env": "env", "state": "COMMITTING"},
+	{"id": "publish-4", "env": "env", "state": "pending", "links": {"self": "/env/publish/publish-4"}}
+]`
+
+func TestClientListPublishes(t *testing.T) {
+	cfg := testConfig(t)
+
+	clientIface, err := Package.NewClient(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("failed to create client, err = %v", err)
+	}
+
+	ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+
+	gw := newFakeGw(t, clientIface.(*client))
+
+	tests := []struct {
+		name   string
+		states []string
+		want   []string
+	}{
+		{"all", nil, []string{"publish-1", "publish-2", "publish-3", "publish-4"}},
+		// States are matched regardless of case, even if exodus-gw doesn't filter.
+		{"pending", []string{"PENDING"}, []string{"publish-1", "publish-4"}},
+		{"several", []string{"committing", "COMMITTED"}, []string{"publish-2", "publish-3"}},
+		{"none matching", []string{"FAILED"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gw.nextHTTPResponse = &http.Response{
+				Status:     "200 OK",
+				StatusCode: 200,
+				Body:       io.NopCloser(strings.NewReader(listPublishesResponse)),
+			}
+
+			publishes, err := clientIface.ListPublishes(ctx, PublishFilter{States: tt.states})
+			if err != nil {
+				t.Fatalf("failed to list publishes, err = %v", err)
+			}
+
+			ids := []string{}
+			for _, p := range publishes {
+				ids = append(ids, p.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("got publishes %v, want %v", ids, tt.want)
+			}
+		})
+	}
+
+	t.Run("fields", func(t *testing.T) {
+		gw.nextHTTPResponse = &http.Response{
+			Status:     "200 OK",
+			StatusCode: 200,
+			Body:       io.NopCloser(strings.NewReader(listPublishesResponse)),
+		}
+
+		publishes, err := clientIface.ListPublishes(ctx, PublishFilter{})
+		if err != nil {
+			t.Fatalf("failed to list publishes, err = %v", err)
+		}
+
+		want := PublishInfo{ID: "publish-1", Env: "env", State: "PENDING", Updated: "2026-10-01T10:00:00"}
+		if publishes[0] != want {
+			t.Errorf("got %+v, want %+v", publishes[0], want)
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		// The fake has no route for listing, as older exodus-gw doesn't.
+		_, err := clientIface.ListPublishes(ctx, PublishFilter{States: []string{"PENDING"}})
+
+		if err == nil || !strings.Contains(err.Error(), "exodus-gw does not support listing publishes") {
+			t.Errorf("Did not get expected error, got: %v", err)
+		}
+		if err != nil && !strings.Contains(err.Error(), "/env/publish?state=PENDING") {
+			t.Errorf("Error did not include URL, got: %v", err)
+		}
+	})
+}
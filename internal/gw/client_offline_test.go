@@ -0,0 +1,258 @@
+package gw
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+// A RoundTripper which records the body of every request and responds
+// successfully with an empty object.
+type recordingGw struct {
+	bodies []string
+}
+
+func (r *recordingGw) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.bodies = append(r.bodies, string(body))
+
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: 200,
+		Body:       io.NopCloser(strings.NewReader("{}")),
+	}, nil
+}
+
+func testItems(count int) []ItemInput {
+	out := []ItemInput{}
+	for i := 0; i < count; i++ {
+		out = append(out, ItemInput{
+			WebURI:      fmt.Sprintf("/some/uri-%d", i),
+			ObjectKey:   fmt.Sprintf("key-%d", i),
+			ContentType: "text/plain",
+		})
+	}
+	return out
+}
+
+func TestOfflineAddItemsMatchesRequests(t *testing.T) {
+	cfg := testConfig(t)
+
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	// Add items via a real client to see what would be sent.
+	clientIface, err := Package.NewClient(ctx, cfg)
+	if err != nil {
+		t.Fatalf("failed to create client, err = %v", err)
+	}
+	c := clientIface.(*client)
+
+	gw := &recordingGw{}
+	c.httpClient.Transport = gw
+
+	p := &publish{client: c}
+	p.raw.Links = map[string]string{"self": "/env/publish/1234"}
+
+	items := testItems(8)
+
+	if err := p.AddItems(ctx, items); err != nil {
+		t.Fatalf("AddItems failed: %v", err)
+	}
+
+	// Then add the same items via an offline client.
+	dir := t.TempDir()
+	offline, err := Package.NewOfflineClient(ctx, cfg, dir)
+	if err != nil {
+		t.Fatalf("failed to create offline client, err = %v", err)
+	}
+
+	offlinePublish, err := offline.NewPublish(ctx)
+	if err != nil {
+		t.Fatalf("NewPublish failed: %v", err)
+	}
+	if err := offlinePublish.AddItems(ctx, items); err != nil {
+		t.Fatalf("AddItems failed: %v", err)
+	}
+
+	// With batch size of 3, there should be 3 batches.
+	if len(gw.bodies) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(gw.bodies))
+	}
+
+	written, err := filepath.Glob(filepath.Join(dir, "items-*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var writtenBodies []string
+	for _, path := range written {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writtenBodies = append(writtenBodies, string(content))
+	}
+
+	// Every written batch should be identical to the request body.
+	if !reflect.DeepEqual(writtenBodies, gw.bodies) {
+		t.Errorf("written batches differ from requests\nwritten: %v\nrequests: %v", writtenBodies, gw.bodies)
+	}
+}
+
+func TestOfflineUploadAndCommit(t *testing.T) {
+	cfg := testConfig(t)
+
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	// Output directory is created if needed.
+	dir := filepath.Join(t.TempDir(), "some", "dir")
+	c, err := Package.NewOfflineClient(ctx, cfg, dir)
+	if err != nil {
+		t.Fatalf("failed to create offline client, err = %v", err)
+	}
+
+	items := []walk.SyncItem{
+		{SrcPath: "file1", Key: "abc"},
+		{SrcPath: "file2", Key: "def"},
+		{SrcPath: "file3", Key: "abc"},
+		{SrcPath: "link", LinkTo: "file1"},
+	}
+
+	var uploaded, duplicate []string
+	err = c.EnsureUploaded(ctx, items,
+		func(item walk.SyncItem) error {
+			uploaded = append(uploaded, item.SrcPath)
+			return nil
+		},
+		func(item walk.SyncItem) error {
+			t.Error("unexpectedly found existing item", item)
+			return nil
+		},
+		func(item walk.SyncItem) error {
+			duplicate = append(duplicate, item.SrcPath)
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("EnsureUploaded failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(uploaded, []string{"file1", "file2"}) {
+		t.Errorf("unexpected uploaded items: %v", uploaded)
+	}
+	if !reflect.DeepEqual(duplicate, []string{"file3"}) {
+		t.Errorf("unexpected duplicate items: %v", duplicate)
+	}
+
+	var uploads []OfflineUpload
+	content, err := os.ReadFile(filepath.Join(dir, "uploads.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(content, &uploads); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []OfflineUpload{{"file1", "abc"}, {"file2", "def"}}
+	if !reflect.DeepEqual(uploads, expected) {
+		t.Errorf("unexpected planned uploads: %v", uploads)
+	}
+
+	p, err := c.GetPublish(ctx, "1234")
+	if err != nil {
+		t.Fatalf("GetPublish failed: %v", err)
+	}
+	if p.ID() != "1234" {
+		t.Errorf("unexpected publish ID: %v", p.ID())
+	}
+
+	if err := p.Commit(ctx, "phase1"); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	content, err = os.ReadFile(filepath.Join(dir, "commit.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != `{"commit_mode":"phase1"}`+"\n" {
+		t.Errorf("unexpected commit content: %s", content)
+	}
+
+	// WhoAmI can't work without exodus-gw.
+	if _, err := c.WhoAmI(ctx); err == nil {
+		t.Error("WhoAmI unexpectedly succeeded")
+	}
+}
+
+func TestOfflineClientBadDir(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Package.NewOfflineClient(context.Background(), testConfig(t), filepath.Join(file, "dir"))
+	if !strings.Contains(fmt.Sprint(err), "can't create offline output directory") {
+		t.Errorf("did not get expected error, err = %v", err)
+	}
+}
+
+func TestOfflineUploadDisabled(t *testing.T) {
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	dir := t.TempDir()
+	c, err := Package.NewOfflineClient(ctx, testConfig(t), dir)
+	if err != nil {
+		t.Fatalf("failed to create offline client, err = %v", err)
+	}
+
+	items := []walk.SyncItem{
+		{SrcPath: "file1", Key: "abc"},
+		{SrcPath: "file2", Key: "abc"},
+	}
+
+	// Blobs are assumed to be present, so no uploads are planned.
+	var present, duplicate []string
+	err = c.EnsureUploaded(WithNoUpload(ctx), items,
+		func(item walk.SyncItem) error {
+			t.Error("unexpectedly planned upload of", item)
+			return nil
+		},
+		func(item walk.SyncItem) error {
+			present = append(present, item.SrcPath)
+			return nil
+		},
+		func(item walk.SyncItem) error {
+			duplicate = append(duplicate, item.SrcPath)
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("EnsureUploaded failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(present, []string{"file1"}) || !reflect.DeepEqual(duplicate, []string{"file2"}) {
+		t.Errorf("unexpected present items %v, duplicates %v", present, duplicate)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "uploads.json"))
+	if err != nil || string(content) != "[]\n" {
+		t.Errorf("unexpected planned uploads %q, err = %v", content, err)
+	}
+}
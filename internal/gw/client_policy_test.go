@@ -0,0 +1,187 @@
+package gw
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+// A RoundTripper which fails every request with a retryable error,
+// counting the requests made per HTTP method.
+type failingGw struct {
+	mu     sync.Mutex
+	counts map[string]int
+
+	// If true, requests block until their context is done rather than
+	// returning an error response.
+	hang bool
+}
+
+func (f *failingGw) RoundTrip(r *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	f.counts[r.Method]++
+	f.mu.Unlock()
+
+	if f.hang {
+		<-r.Context().Done()
+		return nil, r.Context().Err()
+	}
+
+	return &http.Response{
+		Status:     "503 Service Unavailable",
+		StatusCode: 503,
+		Body:       io.NopCloser(strings.NewReader("")),
+	}, nil
+}
+
+func policyConfig(t *testing.T, timeouts [3]int, attempts [3]int) conf.Config {
+	ctrl := gomock.NewController(t)
+	cfg := conf.NewMockConfig(ctrl)
+
+	cfg.EXPECT().GwCert().AnyTimes().Return("../../test/data/service.pem")
+	cfg.EXPECT().GwKey().AnyTimes().Return("../../test/data/service-key.pem")
+	cfg.EXPECT().GwURL().AnyTimes().Return("https://exodus-gw.example.com")
+	cfg.EXPECT().GwEnv().AnyTimes().Return("env")
+	cfg.EXPECT().GwBatchSize().AnyTimes().Return(3)
+	cfg.EXPECT().GwBatchSizeAuto().AnyTimes().Return(false)
+	cfg.EXPECT().GwMaxBatchBytes().AnyTimes().Return(0)
+	cfg.EXPECT().GwMaxAttempts().AnyTimes().Return(3)
+	cfg.EXPECT().GwMaxBackoff().AnyTimes().Return(1)
+	cfg.EXPECT().GwReadTimeout().AnyTimes().Return(timeouts[0])
+	cfg.EXPECT().GwReadMaxAttempts().AnyTimes().Return(attempts[0])
+	cfg.EXPECT().GwWriteTimeout().AnyTimes().Return(timeouts[1])
+	cfg.EXPECT().GwWriteMaxAttempts().AnyTimes().Return(attempts[1])
+	cfg.EXPECT().GwCommitTimeout().AnyTimes().Return(timeouts[2])
+	cfg.EXPECT().GwCommitMaxAttempts().AnyTimes().Return(attempts[2])
+	cfg.EXPECT().GwRetryStatuses().AnyTimes().Return(nil)
+	cfg.EXPECT().GwPermanentStatuses().AnyTimes().Return(nil)
+	cfg.EXPECT().LogLevel().AnyTimes().Return("info")
+	cfg.EXPECT().Verbosity().AnyTimes().Return(3)
+	cfg.EXPECT().GwProxy().AnyTimes().Return("")
+	cfg.EXPECT().S3Proxy().AnyTimes().Return("")
+	cfg.EXPECT().S3Access().AnyTimes().Return("gw")
+	cfg.EXPECT().GwItemSchema().AnyTimes().Return(1)
+	cfg.EXPECT().GwNoReplace().AnyTimes().Return(false)
+	cfg.EXPECT().GwUseIdempotencyKeys().AnyTimes().Return(true)
+	cfg.EXPECT().GwRedirects().AnyTimes().Return("follow")
+	cfg.EXPECT().TempDir().AnyTimes().Return(t.TempDir())
+	cfg.EXPECT().TempMinFree().AnyTimes().Return(int64(0))
+	cfg.EXPECT().BlobKeyPrefix().AnyTimes().Return("")
+	cfg.EXPECT().NoProxy().AnyTimes().Return(nil)
+	cfg.EXPECT().DialTimeout().AnyTimes().Return(30000)
+	cfg.EXPECT().MaxClockSkew().AnyTimes().Return(60000)
+	cfg.EXPECT().TLSHandshakeTimeout().AnyTimes().Return(10000)
+	cfg.EXPECT().TLSCipherSuites().AnyTimes().Return(nil)
+	cfg.EXPECT().GwCertCommand().AnyTimes().Return("")
+	cfg.EXPECT().GwKeyCommand().AnyTimes().Return("")
+	cfg.EXPECT().UploadThreads().AnyTimes().Return(4)
+	cfg.EXPECT().ConcurrencyMin().AnyTimes().Return(1)
+	cfg.EXPECT().ConcurrencyMax().AnyTimes().Return(0)
+
+	return cfg
+}
+
+func TestClientPolicyPerOperation(t *testing.T) {
+	cfg := policyConfig(t, [3]int{0, 0, 0}, [3]int{1, 2, 4})
+
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	clientIface, err := Package.NewClient(ctx, cfg)
+	if err != nil {
+		t.Fatalf("failed to create client, err = %v", err)
+	}
+	c := clientIface.(*client)
+
+	gw := &failingGw{counts: make(map[string]int)}
+	c.httpClient.Transport = retryTransport(ctx, cfg, gw)
+
+	p := &publish{client: c}
+	p.raw.Links = map[string]string{
+		"self":   "/env/publish/1234",
+		"commit": "/env/publish/1234/commit",
+	}
+
+	// Read operation.
+	if _, err := c.GetPublish(ctx, "1234"); err == nil {
+		t.Error("GetPublish unexpectedly succeeded")
+	}
+
+	// Write operation.
+	if err := p.AddItems(ctx, []ItemInput{{WebURI: "/some/uri", ObjectKey: "abc123", ContentType: "mime/type"}}); err == nil {
+		t.Error("AddItems unexpectedly succeeded")
+	}
+
+	// Commit operation.
+	if err := p.Commit(ctx, ""); err == nil {
+		t.Error("Commit unexpectedly succeeded")
+	}
+
+	// Each operation should have been retried according to its own policy.
+	// As with gwmaxattempts, the initial attempt comes on top of the
+	// configured value.
+	expected := map[string]int{"GET": 2, "PUT": 3, "POST": 5}
+	for method, count := range expected {
+		if gw.counts[method] != count {
+			t.Errorf("%s: expected %d requests, got %d", method, count, gw.counts[method])
+		}
+	}
+}
+
+func TestClientTimeoutPerOperation(t *testing.T) {
+	// Only read operations have a timeout.
+	cfg := policyConfig(t, [3]int{10, 0, 0}, [3]int{1, 1, 1})
+
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	clientIface, err := Package.NewClient(ctx, cfg)
+	if err != nil {
+		t.Fatalf("failed to create client, err = %v", err)
+	}
+	c := clientIface.(*client)
+
+	gw := &failingGw{counts: make(map[string]int), hang: true}
+	c.httpClient.Transport = retryTransport(ctx, cfg, gw)
+
+	// A read should time out on every attempt.
+	_, err = c.GetPublish(ctx, "1234")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("did not get expected timeout error, got: %v", err)
+	}
+	if gw.counts["GET"] != 2 {
+		t.Errorf("expected 2 attempts, got %d", gw.counts["GET"])
+	}
+
+	// A write has no timeout, so it should only return once the caller
+	// gives up.
+	writeCtx, cancel := context.WithCancel(ctx)
+	p := &publish{client: c}
+	p.raw.Links = map[string]string{"self": "/env/publish/1234"}
+
+	done := make(chan error)
+	go func() {
+		done <- p.AddItems(writeCtx, []ItemInput{{WebURI: "/some/uri", ObjectKey: "abc123", ContentType: "mime/type"}})
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("write returned before caller cancelled, err = %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	if err := <-done; err == nil {
+		t.Error("write unexpectedly succeeded")
+	}
+}
@@ -0,0 +1,179 @@
+package gw
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+// Config overriding the blob cache.
+type blobCacheConfig struct {
+	conf.Config
+	path   string
+	maxAge int
+}
+
+func (c blobCacheConfig) BlobCache() string {
+	return c.path
+}
+
+func (c blobCacheConfig) BlobCacheMaxAge() int {
+	return c.maxAge
+}
+
+// presenceRun uploads items via a new client using the given blob cache,
+// where only the blobs in have are initially present, returning the fake S3
+// and the keys of items found to be present.
+func presenceRun(t *testing.T, cfg blobCacheConfig, items []walk.SyncItem, have ...string) (*fakeS3, []string) {
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	cfg.Config = testConfig(t)
+	iface, err := Package.NewClient(ctx, cfg)
+	if err != nil {
+		t.Fatal("creating client:", err)
+	}
+	s3 := newFakeS3(t, iface.(*client))
+	for _, key := range have {
+		s3.blobs[key] = nil
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir("../../test/data/srctrees/just-files"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	return s3, presenceUpload(t, ctx, iface, items)
+}
+
+func presenceUpload(t *testing.T, ctx context.Context, client Client, items []walk.SyncItem) []string {
+	present := []string{}
+	err := client.EnsureUploaded(ctx, items,
+		func(walk.SyncItem) error { return nil },
+		func(item walk.SyncItem) error {
+			present = append(present, item.Key)
+			return nil
+		},
+		func(walk.SyncItem) error { return nil },
+	)
+	if err != nil {
+		t.Fatalf("got unexpected error %v", err)
+	}
+	return present
+}
+
+var presenceItems = []walk.SyncItem{
+	{SrcPath: "hello-copy-one", Key: "present"},
+	{SrcPath: "subdir/some-binary", Key: "absent"},
+}
+
+func TestClientPresenceCachedInRun(t *testing.T) {
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	client, s3 := newClientWithFakeS3(t)
+	s3.blobs["present"] = nil
+
+	chdirInTest(t, "../../test/data/srctrees/just-files")
+
+	if got := presenceUpload(t, ctx, client, presenceItems); !reflect.DeepEqual(got, []string{"present"}) {
+		t.Errorf("unexpected present items %v", got)
+	}
+
+	// Uploading again, e.g. when retrying a publish, finds both blobs
+	// present without checking S3 again.
+	got := presenceUpload(t, ctx, client, presenceItems)
+	if len(got) != 2 {
+		t.Errorf("unexpected present items %v", got)
+	}
+	if !reflect.DeepEqual(s3.heads, map[string]int{"present": 1, "absent": 1}) {
+		t.Errorf("unexpected presence checks %v", s3.heads)
+	}
+}
+
+func TestClientPresenceCachedBetweenRuns(t *testing.T) {
+	cfg := blobCacheConfig{path: filepath.Join(t.TempDir(), "blobs.json"), maxAge: 3600}
+
+	s3, _ := presenceRun(t, cfg, presenceItems, "present")
+	if !reflect.DeepEqual(s3.heads, map[string]int{"present": 1, "absent": 1}) {
+		t.Errorf("unexpected presence checks %v", s3.heads)
+	}
+
+	content, err := os.ReadFile(cfg.path)
+	if err != nil {
+		t.Fatalf("blob cache was not written, err = %v", err)
+	}
+	if !strings.Contains(string(content), "/env/present") {
+		t.Errorf("present blob was not recorded: %s", content)
+	}
+
+	// In a later run, the cached-present blob isn't checked at all. The blob
+	// uploaded too is known to be present, as is a blob found to be present
+	// by another run in the meantime.
+	items := append(presenceItems, walk.SyncItem{SrcPath: "hello-copy-two", Key: "other"})
+	s3, present := presenceRun(t, cfg, items, "other")
+	if !reflect.DeepEqual(s3.heads, map[string]int{"other": 1}) {
+		t.Errorf("unexpected presence checks %v", s3.heads)
+	}
+	if len(present) != 3 {
+		t.Errorf("unexpected present items %v", present)
+	}
+
+	content, _ = os.ReadFile(cfg.path)
+	for _, key := range []string{"present", "absent", "other"} {
+		if !strings.Contains(string(content), "/env/"+key) {
+			t.Errorf("blob %s was not recorded: %s", key, content)
+		}
+	}
+}
+
+func TestClientPresenceCacheExpired(t *testing.T) {
+	cfg := blobCacheConfig{path: filepath.Join(t.TempDir(), "blobs.json"), maxAge: 3600}
+
+	client, _ := newClientWithFakeS3(t)
+	id := client.s3.Endpoint + "/env/present"
+
+	old := time.Now().Add(-2 * time.Hour).Format(time.RFC3339)
+	err := os.WriteFile(cfg.path, []byte(fmt.Sprintf(`{"present": {"%s": "%s"}}`, id, old)), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An entry older than the max age is checked again.
+	s3, present := presenceRun(t, cfg, presenceItems[0:1], "present")
+	if s3.heads["present"] != 1 || len(present) != 1 {
+		t.Errorf("unexpected presence checks %v, present items %v", s3.heads, present)
+	}
+}
+
+func TestClientPresenceCacheInvalid(t *testing.T) {
+	cfg := blobCacheConfig{path: filepath.Join(t.TempDir(), "blobs.json"), maxAge: 3600}
+	if err := os.WriteFile(cfg.path, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// An invalid cache is ignored, and replaced.
+	s3, _ := presenceRun(t, cfg, presenceItems, "present")
+	if len(s3.heads) != 2 {
+		t.Errorf("unexpected presence checks %v", s3.heads)
+	}
+
+	content, _ := os.ReadFile(cfg.path)
+	if !strings.Contains(string(content), "/env/present") {
+		t.Errorf("present blob was not recorded: %s", content)
+	}
+}
@@ -0,0 +1,194 @@
+package gw
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+type proxyConfig struct {
+	conf.Config
+	gwURL   string
+	gwProxy string
+	s3Proxy string
+	noProxy []string
+}
+
+func (c proxyConfig) GwURL() string {
+	if c.gwURL != "" {
+		return c.gwURL
+	}
+	return c.Config.GwURL()
+}
+
+func (c proxyConfig) GwProxy() string {
+	return c.gwProxy
+}
+
+func (c proxyConfig) S3Proxy() string {
+	return c.s3Proxy
+}
+
+func (c proxyConfig) NoProxy() []string {
+	return c.noProxy
+}
+
+// A proxy which records the target of each CONNECT request and refuses
+// to tunnel any of them.
+type fakeProxy struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	targets []string
+}
+
+func newFakeProxy(t *testing.T) *fakeProxy {
+	out := &fakeProxy{}
+	out.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		out.mu.Lock()
+		out.targets = append(out.targets, r.Method+" "+r.Host)
+		out.mu.Unlock()
+
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	t.Cleanup(out.Close)
+	return out
+}
+
+func (p *fakeProxy) requests() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string{}, p.targets...)
+}
+
+func TestClientProxy(t *testing.T) {
+	gwProxy := newFakeProxy(t)
+	s3Proxy := newFakeProxy(t)
+
+	cfg := proxyConfig{Config: testConfig(t), gwProxy: gwProxy.URL, s3Proxy: s3Proxy.URL}
+
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	clientIface, err := Package.NewClient(ctx, cfg)
+	if err != nil {
+		t.Fatalf("failed to create client, err = %v", err)
+	}
+	c := clientIface.(*client)
+
+	// The proxy refuses to connect, so requests can't succeed.
+	if _, err := c.WhoAmI(ctx); err == nil {
+		t.Error("WhoAmI unexpectedly succeeded")
+	}
+
+	if got := gwProxy.requests(); len(got) != 1 || got[0] != "CONNECT exodus-gw.example.com:443" {
+		t.Errorf("unexpected requests via gw proxy: %v", got)
+	}
+	if got := s3Proxy.requests(); len(got) != 0 {
+		t.Errorf("unexpected requests via S3 proxy: %v", got)
+	}
+
+	_, err = c.s3.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String("env"),
+		Key:    aws.String("abc123"),
+	})
+	if err == nil {
+		t.Error("HeadObject unexpectedly succeeded")
+	}
+
+	// The SDK may retry the request, but every attempt should have gone
+	// via the S3 proxy.
+	got := s3Proxy.requests()
+	if len(got) == 0 {
+		t.Error("no requests via S3 proxy")
+	}
+	for _, req := range got {
+		if req != "CONNECT exodus-gw.example.com:443" {
+			t.Errorf("unexpected request via S3 proxy: %v", req)
+		}
+	}
+	if got := gwProxy.requests(); len(got) != 1 {
+		t.Errorf("unexpected requests via gw proxy: %v", got)
+	}
+}
+
+func TestClientNoProxy(t *testing.T) {
+	proxy := newFakeProxy(t)
+
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"user": "someone"}`))
+	}))
+	t.Cleanup(gw.Close)
+
+	cfg := proxyConfig{
+		Config:  testConfig(t),
+		gwURL:   gw.URL,
+		gwProxy: proxy.URL,
+		s3Proxy: proxy.URL,
+		noProxy: []string{"127.0.0.1"},
+	}
+
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	clientIface, err := Package.NewClient(ctx, cfg)
+	if err != nil {
+		t.Fatalf("failed to create client, err = %v", err)
+	}
+
+	// The request should have bypassed the proxy and reached exodus-gw.
+	whoami, err := clientIface.WhoAmI(ctx)
+	if err != nil {
+		t.Errorf("WhoAmI failed, err = %v", err)
+	}
+	if whoami["user"] != "someone" {
+		t.Errorf("unexpected response from WhoAmI: %v", whoami)
+	}
+
+	if got := proxy.requests(); len(got) != 0 {
+		t.Errorf("unexpected requests via proxy: %v", got)
+	}
+}
+
+func TestClientInvalidProxy(t *testing.T) {
+	cfg := proxyConfig{Config: testConfig(t), s3Proxy: "proxy.example.com:3128"}
+
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	_, err := Package.NewClient(ctx, cfg)
+	if err == nil || !strings.Contains(err.Error(), "s3proxy: invalid proxy URL 'proxy.example.com:3128'") {
+		t.Errorf("did not get expected error, got: %v", err)
+	}
+}
+
+func TestBypassProxy(t *testing.T) {
+	tests := []struct {
+		host    string
+		noProxy []string
+		want    bool
+	}{
+		{"exodus-gw.example.com", nil, false},
+		{"exodus-gw.example.com", []string{"example.com"}, true},
+		{"exodus-gw.example.com", []string{".example.com"}, true},
+		{"exodus-gw.example.com", []string{"EXODUS-GW.example.com"}, true},
+		{"exodus-gw.example.com", []string{"gw.example.com"}, false},
+		{"exodus-gw.example.com", []string{"other.example.com", "*"}, true},
+		{"example.com", []string{".example.com"}, true},
+	}
+
+	for _, tt := range tests {
+		if got := bypassProxy(tt.host, tt.noProxy); got != tt.want {
+			t.Errorf("bypassProxy(%q, %v) = %v, want %v", tt.host, tt.noProxy, got, tt.want)
+		}
+	}
+}
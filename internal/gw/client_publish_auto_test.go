@@ -0,0 +1,267 @@
+package gw
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+type autoBatchConfig struct {
+	conf.Config
+	size int
+	min  int
+	max  int
+}
+
+func (c autoBatchConfig) GwBatchSize() int {
+	return c.size
+}
+
+func (c autoBatchConfig) GwBatchSizeAuto() bool {
+	return true
+}
+
+func (c autoBatchConfig) GwBatchSizeMin() int {
+	return c.min
+}
+
+func (c autoBatchConfig) GwBatchSizeMax() int {
+	return c.max
+}
+
+// A RoundTripper accepting items onto a publish, recording the size of each
+// batch and simulating latency proportional to the size.
+type batchGw struct {
+	mu    sync.Mutex
+	sizes []int
+	items int
+
+	// Latency of each request per item in the batch, by which each request
+	// advances the clock.
+	perItem time.Duration
+	clock   time.Time
+
+	// Batches larger than this are refused; 0 for no limit.
+	limit int
+
+	// If non-zero, every batch is refused with this status.
+	status int
+}
+
+func (g *batchGw) RoundTrip(r *http.Request) (*http.Response, error) {
+	var batch []ItemInput
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	g.sizes = append(g.sizes, len(batch))
+	g.clock = g.clock.Add(g.perItem * time.Duration(len(batch)))
+	g.mu.Unlock()
+
+	if g.status != 0 {
+		return &http.Response{
+			Status:     fmt.Sprintf("%d %s", g.status, http.StatusText(g.status)),
+			StatusCode: g.status,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}, nil
+	}
+
+	if g.limit != 0 && len(batch) > g.limit {
+		return &http.Response{
+			Status:     "413 Payload Too Large",
+			StatusCode: 413,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}, nil
+	}
+
+	g.mu.Lock()
+	g.items += len(batch)
+	g.mu.Unlock()
+
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: 200,
+		Body:       io.NopCloser(strings.NewReader("{}")),
+	}, nil
+}
+
+func (g *batchGw) now() time.Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.clock
+}
+
+func autoBatchPublish(t *testing.T, cfg conf.Config, gw *batchGw) (context.Context, *publish) {
+	// Requests are timed by the simulated latency alone, so that results
+	// don't depend on how loaded the machine running tests is.
+	oldClock := batchClock
+	batchClock = gw.now
+	t.Cleanup(func() {
+		batchClock = oldClock
+	})
+
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	clientIface, err := Package.NewClient(ctx, cfg)
+	if err != nil {
+		t.Fatalf("failed to create client, err = %v", err)
+	}
+	c := clientIface.(*client)
+	c.httpClient.Transport = gw
+
+	p := &publish{client: c}
+	p.raw.Links = map[string]string{"self": "/env/publish/1234"}
+
+	return ctx, p
+}
+
+func autoBatchItems(count int) []ItemInput {
+	out := []ItemInput{}
+	for i := 0; i < count; i++ {
+		out = append(out, ItemInput{WebURI: fmt.Sprintf("/some/uri/%d", i), ObjectKey: "abc123", ContentType: "mime/type"})
+	}
+	return out
+}
+
+func TestAddItemsAutoFast(t *testing.T) {
+	gw := &batchGw{}
+	ctx, p := autoBatchPublish(t, autoBatchConfig{testConfig(t), 2, 1, 8}, gw)
+
+	if err := p.AddItems(ctx, autoBatchItems(40)); err != nil {
+		t.Fatalf("AddItems failed, err = %v", err)
+	}
+
+	// Fast requests should grow the batch size up to the max.
+	expected := []int{2, 4, 8, 8, 8, 8, 2}
+	if fmt.Sprint(gw.sizes) != fmt.Sprint(expected) {
+		t.Errorf("unexpected batch sizes %v, expected %v", gw.sizes, expected)
+	}
+	if gw.items != 40 {
+		t.Errorf("expected 40 items added, got %d", gw.items)
+	}
+}
+
+func TestAddItemsAutoSlow(t *testing.T) {
+	// Batches of more than 5 items are slow, and only batches of 1 item
+	// are fast.
+	gw := &batchGw{perItem: 1800 * time.Millisecond}
+	ctx, p := autoBatchPublish(t, autoBatchConfig{testConfig(t), 16, 1, 32}, gw)
+
+	if err := p.AddItems(ctx, autoBatchItems(30)); err != nil {
+		t.Fatalf("AddItems failed, err = %v", err)
+	}
+
+	// Slow requests should shrink the batch size until requests are no
+	// longer slow.
+	expected := []int{16, 8, 4, 2}
+	if fmt.Sprint(gw.sizes) != fmt.Sprint(expected) {
+		t.Errorf("unexpected batch sizes %v, expected %v", gw.sizes, expected)
+	}
+	if gw.items != 30 {
+		t.Errorf("expected 30 items added, got %d", gw.items)
+	}
+}
+
+func TestAddItemsAutoErrors(t *testing.T) {
+	gw := &batchGw{limit: 3}
+	ctx, p := autoBatchPublish(t, autoBatchConfig{testConfig(t), 10, 2, 10}, gw)
+
+	if err := p.AddItems(ctx, autoBatchItems(6)); err != nil {
+		t.Fatalf("AddItems failed, err = %v", err)
+	}
+
+	// Failed requests should be retried with smaller batches, while the
+	// batch size keeps growing after each success.
+	expected := []int{6, 5, 2, 4, 2, 2}
+	if fmt.Sprint(gw.sizes) != fmt.Sprint(expected) {
+		t.Errorf("unexpected batch sizes %v, expected %v", gw.sizes, expected)
+	}
+	if gw.items != 6 {
+		t.Errorf("expected 6 items added, got %d", gw.items)
+	}
+}
+
+func TestAddItemsAutoErrorsAtMin(t *testing.T) {
+	gw := &batchGw{limit: 1}
+	ctx, p := autoBatchPublish(t, autoBatchConfig{testConfig(t), 8, 2, 8}, gw)
+
+	err := p.AddItems(ctx, autoBatchItems(6))
+
+	// Once at the minimum batch size, there's nothing left to try.
+	if err == nil || !strings.Contains(err.Error(), "413 Payload Too Large") {
+		t.Errorf("did not get expected error, got: %v", err)
+	}
+
+	expected := []int{6, 4, 2}
+	if fmt.Sprint(gw.sizes) != fmt.Sprint(expected) {
+		t.Errorf("unexpected batch sizes %v, expected %v", gw.sizes, expected)
+	}
+}
+
+func TestAddItemsAutoErrorsNotRetried(t *testing.T) {
+	gw := &batchGw{status: http.StatusBadRequest}
+	ctx, p := autoBatchPublish(t, autoBatchConfig{testConfig(t), 8, 2, 8}, gw)
+
+	err := p.AddItems(ctx, autoBatchItems(6))
+
+	// A request refused for any reason other than its size fails at once,
+	// since a smaller batch wouldn't fare any better.
+	if err == nil || !strings.Contains(err.Error(), "400 Bad Request") {
+		t.Errorf("did not get expected error, got: %v", err)
+	}
+
+	expected := []int{6}
+	if fmt.Sprint(gw.sizes) != fmt.Sprint(expected) {
+		t.Errorf("unexpected batch sizes %v, expected %v", gw.sizes, expected)
+	}
+}
+
+func TestBatchTooLarge(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{&httpError{status: http.StatusRequestEntityTooLarge, msg: "too large"}, true},
+		{&httpError{status: http.StatusInternalServerError, msg: "error"}, true},
+		{&httpError{status: http.StatusGatewayTimeout, msg: "timeout"}, true},
+		{&httpError{status: http.StatusBadRequest, msg: "invalid item"}, false},
+		{&httpError{status: http.StatusConflict, msg: "conflict"}, false},
+		{fmt.Errorf("request failed: %w", context.DeadlineExceeded), true},
+		{&net.DNSError{IsTimeout: true}, true},
+		{&net.DNSError{}, false},
+		{errors.New("some other error"), false},
+	}
+
+	for _, tt := range tests {
+		if got := batchTooLarge(tt.err); got != tt.expected {
+			t.Errorf("batchTooLarge(%v) = %v, expected %v", tt.err, got, tt.expected)
+		}
+	}
+}
+
+func TestBatchTunerBounds(t *testing.T) {
+	// Initial size is brought within the bounds, and bounds are sane.
+	tuner := newBatchTuner(autoBatchConfig{testConfig(t), 100, 0, 10})
+	if tuner.size != 10 || tuner.min != 1 || tuner.max != 10 {
+		t.Errorf("unexpected tuner %+v", tuner)
+	}
+
+	tuner = newBatchTuner(autoBatchConfig{testConfig(t), 1, 5, 3})
+	if tuner.size != 5 || tuner.min != 5 || tuner.max != 5 {
+		t.Errorf("unexpected tuner %+v", tuner)
+	}
+}
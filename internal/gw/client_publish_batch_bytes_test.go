@@ -0,0 +1,108 @@
+package gw
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+// Config limiting the size of each batch of items.
+type batchBytesConfig struct {
+	conf.Config
+	maxBytes int
+	auto     bool
+}
+
+func (c batchBytesConfig) GwMaxBatchBytes() int {
+	return c.maxBytes
+}
+
+func (c batchBytesConfig) GwBatchSizeAuto() bool {
+	return c.auto
+}
+
+// Returns count items with web URIs of around 500 bytes.
+func longURIItems(count int) []ItemInput {
+	out := []ItemInput{}
+	for i := 0; i < count; i++ {
+		out = append(out, ItemInput{
+			WebURI:    fmt.Sprintf("/some/%s/uri-%02d", strings.Repeat("long", 120), i),
+			ObjectKey: fmt.Sprintf("key-%02d", i),
+		})
+	}
+	return out
+}
+
+func TestAddItemsBatchBytes(t *testing.T) {
+	ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+
+	items := longURIItems(7)
+	itemSize := encodedItemSize(items[0], 1)
+
+	tests := []struct {
+		name     string
+		maxBytes int
+		auto     bool
+		batches  []int
+	}{
+		// Without a limit, batches are split only by gwbatchsize of 3.
+		{"unlimited", 0, false, []int{3, 3, 1}},
+
+		// Room for two items, though gwbatchsize would allow three.
+		{"two items", 2*itemSize + 10, false, []int{2, 2, 2, 1}},
+
+		// Exactly two items fit, with their brackets, comma and newline.
+		{"exact fit", 2*(itemSize+1) + 2, false, []int{2, 2, 2, 1}},
+		{"one byte short", 2*(itemSize+1) + 1, false, []int{1, 1, 1, 1, 1, 1, 1}},
+
+		// Items larger than the limit are still sent, one at a time.
+		{"oversized", 100, false, []int{1, 1, 1, 1, 1, 1, 1}},
+
+		// The limit also applies while the batch size adapts.
+		{"auto", 2*itemSize + 10, true, []int{2, 2, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := batchBytesConfig{testConfig(t), tt.maxBytes, tt.auto}
+
+			iface, err := Package.NewClient(ctx, cfg)
+			if err != nil {
+				t.Fatal("creating client:", err)
+			}
+			c := iface.(*client)
+
+			gw := &recordingGw{}
+			c.httpClient.Transport = gw
+
+			p := &publish{client: c}
+			p.raw.Links = map[string]string{"self": "/env/publish/1234"}
+
+			if err := p.AddItems(ctx, items); err != nil {
+				t.Fatalf("AddItems failed: %v", err)
+			}
+
+			got := []int{}
+			for _, body := range gw.bodies {
+				batch := []ItemInput{}
+				if err := json.Unmarshal([]byte(body), &batch); err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, len(batch))
+
+				if tt.maxBytes > 0 && len(batch) > 1 && len(body) > tt.maxBytes {
+					t.Errorf("batch of %d bytes exceeds limit of %d", len(body), tt.maxBytes)
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.batches) {
+				t.Errorf("got batches of %v items, want %v", got, tt.batches)
+			}
+		})
+	}
+}
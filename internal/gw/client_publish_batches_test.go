@@ -0,0 +1,117 @@
+package gw
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+// Config overriding the number of upload threads.
+type threadsConfig struct {
+	conf.Config
+	threads int
+}
+
+func (c threadsConfig) UploadThreads() int {
+	return c.threads
+}
+
+func TestAddItemsBatchesIndependentOfConcurrency(t *testing.T) {
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	dir := t.TempDir()
+	files := []walk.SyncItem{}
+	for i := 0; i < 20; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("file-%02d", i))
+		if err := os.WriteFile(path, []byte(path), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, walk.SyncItem{SrcPath: path, Key: fmt.Sprintf("key-%02d", i)})
+	}
+
+	var first []string
+
+	for _, threads := range []int{1, 4, 16} {
+		t.Run(fmt.Sprint(threads), func(t *testing.T) {
+			iface, err := Package.NewClient(ctx, threadsConfig{testConfig(t), threads})
+			if err != nil {
+				t.Fatal("creating client:", err)
+			}
+			c := iface.(*client)
+			newFakeS3(t, c)
+
+			gw := &recordingGw{}
+			c.httpClient.Transport = gw
+
+			// Items are found in a different order in each run...
+			items := []walk.SyncItem{}
+			for _, i := range rand.New(rand.NewSource(int64(threads))).Perm(len(files)) {
+				items = append(items, files[i])
+			}
+
+			// ...and added in the order their uploads complete.
+			var mu sync.Mutex
+			publishItems := []ItemInput{}
+			onUploaded := func(item walk.SyncItem) error {
+				mu.Lock()
+				defer mu.Unlock()
+				publishItems = append(publishItems, ItemInput{
+					WebURI:    "/content/" + filepath.Base(item.SrcPath),
+					ObjectKey: item.Key,
+				})
+				return nil
+			}
+
+			err = c.EnsureUploaded(ctx, items, onUploaded,
+				func(walk.SyncItem) error { return nil },
+				func(walk.SyncItem) error { return nil },
+			)
+			if err != nil {
+				t.Fatalf("got unexpected error %v", err)
+			}
+
+			p := &publish{client: c}
+			p.raw.Links = map[string]string{"self": "/env/publish/1234"}
+
+			if err := p.AddItems(ctx, publishItems); err != nil {
+				t.Fatalf("AddItems failed: %v", err)
+			}
+
+			// With batch size of 3, the first batch always holds the
+			// first 3 items by web URI.
+			batch := []ItemInput{}
+			if err := json.Unmarshal([]byte(gw.bodies[0]), &batch); err != nil {
+				t.Fatal(err)
+			}
+			uris := []string{}
+			for _, item := range batch {
+				uris = append(uris, item.WebURI)
+			}
+			if !reflect.DeepEqual(uris, []string{"/content/file-00", "/content/file-01", "/content/file-02"}) {
+				t.Errorf("unexpected first batch: %v", uris)
+			}
+
+			if first == nil {
+				first = gw.bodies
+			} else if !reflect.DeepEqual(gw.bodies, first) {
+				t.Errorf("batches differ from first run\ngot:  %v\nwant: %v", gw.bodies, first)
+			}
+		})
+	}
+
+	if len(first) != 7 {
+		t.Errorf("expected 7 batches, got %d", len(first))
+	}
+}
@@ -2,6 +2,7 @@ package gw
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -181,7 +182,7 @@ func TestClientPublishErrors(t *testing.T) {
 			)),
 		}
 
-		err := publish.AddItems(ctx, []ItemInput{{"/some/uri", "abc123", "mime/type", ""}})
+		err := publish.AddItems(ctx, []ItemInput{{WebURI: "/some/uri", ObjectKey: "abc123", ContentType: "mime/type"}})
 
 		if err == nil {
 			t.Error("Unexpectedly failed to return an error")
@@ -207,6 +208,32 @@ func TestClientPublishErrors(t *testing.T) {
 		if !strings.Contains(err.Error(), "404 Not Found") {
 			t.Errorf("Did not get expected error, got: %v", err)
 		}
+		if errors.Is(err, ErrConflict) {
+			t.Errorf("Unexpectedly reported a conflict: %v", err)
+		}
+	})
+
+	t.Run("commit conflicts", func(t *testing.T) {
+		publish := publish{client: clientIface.(*client)}
+		publish.raw.Links = make(map[string]string)
+		publish.raw.Links["commit"] = "/env/publish/1234/commit"
+
+		gw.nextHTTPResponse = &http.Response{
+			Status:     "409 Conflict",
+			StatusCode: 409,
+			Body: io.NopCloser(strings.NewReader(
+				"{\"detail\": \"Another publish is in progress\"}",
+			)),
+		}
+
+		err := publish.Commit(ctx, "")
+
+		if !errors.Is(err, ErrConflict) {
+			t.Errorf("Did not get expected error, got: %v", err)
+		}
+		if err != nil && !strings.Contains(err.Error(), "Another publish is in progress") {
+			t.Errorf("Error did not include response, got: %v", err)
+		}
 	})
 
 }
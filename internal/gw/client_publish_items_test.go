@@ -0,0 +1,56 @@
+package gw
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+func TestClientPublishItems(t *testing.T) {
+	cfg := testConfig(t)
+
+	clientIface, err := Package.NewClient(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("failed to create client, err = %v", err)
+	}
+
+	ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+
+	gw := newFakeGw(t, clientIface.(*client))
+	gw.publishes["some-publish"] = &fakePublish{id: "some-publish"}
+
+	publish, err := clientIface.GetPublish(ctx, "some-publish")
+	if err != nil {
+		t.Fatalf("failed to get publish, err = %v", err)
+	}
+
+	// A publish starts out empty.
+	items, err := publish.Items(ctx)
+	if err != nil {
+		t.Fatalf("failed to get items, err = %v", err)
+	}
+	if len(items) != 0 {
+		t.Errorf("unexpected items %v", items)
+	}
+
+	addItems := []ItemInput{
+		{WebURI: "/other/path", ObjectKey: "223344", ContentType: "mime/type"},
+		{WebURI: "/some/link", LinkTo: "/some/path"},
+		{WebURI: "/some/path", ObjectKey: "1234", ContentType: "mime/type", ContentEncoding: "gzip"},
+	}
+	if err := publish.AddItems(ctx, addItems); err != nil {
+		t.Fatalf("failed to add items, err = %v", err)
+	}
+
+	// It should then return the items as added.
+	items, err = publish.Items(ctx)
+	if err != nil {
+		t.Fatalf("failed to get items, err = %v", err)
+	}
+	if !reflect.DeepEqual(items, addItems) {
+		t.Errorf("got items %v, want %v", items, addItems)
+	}
+}
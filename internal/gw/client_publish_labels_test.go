@@ -0,0 +1,68 @@
+package gw
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClientNewPublishLabels(t *testing.T) {
+	labels := map[string]string{"build": "1234", "git-sha": "0a1b2c3d"}
+
+	tests := []struct {
+		name        string
+		expireAfter time.Duration
+		labels      map[string]string
+		unsupported bool
+		bodies      []string
+		dropped     []string
+	}{
+		{"labels", 0, labels, false, []string{`{"labels":{"build":"1234","git-sha":"0a1b2c3d"}}` + "\n"}, nil},
+		{"labels and expiration", time.Hour, labels, false,
+			[]string{`{"ttl":3600,"labels":{"build":"1234","git-sha":"0a1b2c3d"}}` + "\n"}, nil},
+		{"empty labels", 0, map[string]string{}, false, []string{""}, nil},
+		{"unsupported", 0, labels, true,
+			[]string{`{"labels":{"build":"1234","git-sha":"0a1b2c3d"}}` + "\n", ""}, []string{"labels"}},
+		{"unsupported with expiration", time.Hour, labels, true,
+			[]string{`{"ttl":3600,"labels":{"build":"1234","git-sha":"0a1b2c3d"}}` + "\n", ""},
+			[]string{"ttl", "labels"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, logs := memoryContext()
+
+			iface, err := Package.NewClient(ctx, testConfig(t))
+			if err != nil {
+				t.Fatal("creating client:", err)
+			}
+			c := iface.(*client)
+
+			gw := &ttlGw{unsupported: tt.unsupported}
+			c.httpClient.Transport = gw
+
+			ctx = WithLabels(ctx, tt.labels)
+			if tt.expireAfter > 0 {
+				ctx = WithExpireAfter(ctx, tt.expireAfter)
+			}
+			if _, err := c.NewPublish(ctx); err != nil {
+				t.Fatalf("failed to create publish, err = %v", err)
+			}
+
+			if !reflect.DeepEqual(gw.bodies, tt.bodies) {
+				t.Errorf("got request bodies %q, want %q", gw.bodies, tt.bodies)
+			}
+
+			// Whatever is dropped is named in a warning.
+			var dropped []string
+			for _, entry := range logs.Entries {
+				if entry.Message == "exodus-gw refused publish options, creating publish without them" {
+					dropped, _ = entry.Fields["dropped"].([]string)
+				}
+			}
+			if !reflect.DeepEqual(dropped, tt.dropped) {
+				t.Errorf("warned of dropping %v, want %v", dropped, tt.dropped)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package gw
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/progress"
+)
+
+type bufferCloser struct {
+	bytes.Buffer
+}
+
+func (*bufferCloser) Close() error {
+	return nil
+}
+
+// Returns the items, done and total fields of each batch event written to buf.
+func batchEvents(t *testing.T, buf *bufferCloser) []string {
+	out := []string{}
+
+	scanner := bufio.NewScanner(&buf.Buffer)
+	for scanner.Scan() {
+		var event progress.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("invalid event %q: %v", scanner.Text(), err)
+		}
+		if event.Type != progress.TypeBatch || event.Env != "env" || event.Publish != "1234" {
+			t.Errorf("unexpected event %+v", event)
+		}
+		out = append(out, fmt.Sprintf("%d:%d/%d", event.Items, event.Done, event.Total))
+	}
+
+	return out
+}
+
+func TestAddItemsProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		auto     bool
+		limit    int
+		items    int
+		expected []string
+	}{
+		// testConfig uses batches of 3 items.
+		{"fixed", false, 0, 7, []string{"3:3/7", "3:6/7", "1:7/7"}},
+
+		// Only successful batches are reported.
+		{"auto", true, 3, 6, []string{"2:2/6", "2:4/6", "2:6/6"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gw := &batchGw{limit: tt.limit}
+
+			cfg := testConfig(t)
+			if tt.auto {
+				cfg = autoBatchConfig{cfg, 10, 2, 10}
+			}
+			ctx, p := autoBatchPublish(t, cfg, gw)
+			p.raw.ID = "1234"
+
+			buf := &bufferCloser{}
+			ctx = progress.NewContext(ctx, progress.NewStream(buf))
+
+			if err := p.AddItems(ctx, autoBatchItems(tt.items)); err != nil {
+				t.Fatalf("AddItems failed, err = %v", err)
+			}
+
+			got := batchEvents(t, buf)
+			if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+				t.Errorf("unexpected batch events %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
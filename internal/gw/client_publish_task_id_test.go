@@ -0,0 +1,44 @@
+package gw
+
+import (
+	"testing"
+)
+
+func TestClientCommitTaskID(t *testing.T) {
+	ctx, logs := memoryContext()
+
+	clientIface, err := Package.NewClient(ctx, testConfig(t))
+	if err != nil {
+		t.Fatalf("failed to create client, err = %v", err)
+	}
+
+	gw := newFakeGw(t, clientIface.(*client))
+	gw.publishes["some-publish"] = &fakePublish{id: "some-publish", taskStates: []string{"NOT_STARTED", "FAILED"}}
+
+	publish, err := clientIface.GetPublish(ctx, "some-publish")
+	if err != nil {
+		t.Fatalf("failed to get publish, err = %v", err)
+	}
+
+	if publish.TaskID() != "" {
+		t.Errorf("got task ID %q before commit", publish.TaskID())
+	}
+
+	// The task is known even if it fails.
+	if err := publish.Commit(ctx, ""); err == nil {
+		t.Error("unexpectedly failed to get an error from commit")
+	}
+	if publish.TaskID() != "task-some-publish" {
+		t.Errorf("got task ID %q", publish.TaskID())
+	}
+
+	found := false
+	for _, entry := range logs.Entries {
+		if entry.Message == "Awaiting commit task" {
+			found = entry.Fields["task"] == "task-some-publish" && entry.Fields["publish"] == "some-publish"
+		}
+	}
+	if !found {
+		t.Error("task ID was not logged")
+	}
+}
@@ -38,17 +38,17 @@ func TestClientPublish(t *testing.T) {
 
 	// It should be able to add some items
 	addItems := []ItemInput{
-		{"/some/path", "1234", "mime/type", ""},
-		{"/other/path", "223344", "mime/type", ""},
+		{WebURI: "/some/path", ObjectKey: "1234", ContentType: "mime/type"},
+		{WebURI: "/other/path", ObjectKey: "223344", ContentType: "mime/type"},
 	}
 	err = publish.AddItems(ctx, addItems)
 	if err != nil {
 		t.Errorf("failed to add items to publish, err = %v", err)
 	}
 
-	// Those items should have made it in
+	// Those items should have made it in, sorted by web URI
 	gotItems := gw.publishes[publish.ID()].items
-	if !reflect.DeepEqual(gotItems, addItems) {
+	if !reflect.DeepEqual(gotItems, []ItemInput{addItems[1], addItems[0]}) {
 		t.Errorf("publish state incorrect after adding items, have items: %v", gotItems)
 	}
 
@@ -104,6 +104,7 @@ func TestClientGetPublish(t *testing.T) {
 
 	gw := newFakeGw(t, clientIface.(*client))
 	gw.publishes["some-id"] = &fakePublish{id: "some-id"}
+	gw.publishes["committed-id"] = &fakePublish{id: "committed-id", state: "COMMITTED"}
 
 	// It should be able to get a publish
 	var p Publish
@@ -118,19 +119,27 @@ func TestClientGetPublish(t *testing.T) {
 		t.Errorf("got unexpected id %s", id)
 	}
 
+	// It should have the state returned by exodus-gw
+	if p.State() != "PENDING" {
+		t.Errorf("got unexpected state %s", p.State())
+	}
+	if committed, err := clientIface.GetPublish(ctx, "committed-id"); err != nil || committed.State() != "COMMITTED" {
+		t.Errorf("got unexpected publish %v, err = %v", committed, err)
+	}
+
 	// It should be able to add some items
 	addItems := []ItemInput{
-		{"/some/path", "1234", "mime/type", ""},
-		{"/other/path", "223344", "mime/type", ""},
+		{WebURI: "/some/path", ObjectKey: "1234", ContentType: "mime/type"},
+		{WebURI: "/other/path", ObjectKey: "223344", ContentType: "mime/type"},
 	}
 	err = p.AddItems(ctx, addItems)
 	if err != nil {
 		t.Errorf("failed to add items to publish, err = %v", err)
 	}
 
-	// Those items should have made it in
+	// Those items should have made it in, sorted by web URI
 	gotItems := gw.publishes[p.ID()].items
-	if !reflect.DeepEqual(gotItems, addItems) {
+	if !reflect.DeepEqual(gotItems, []ItemInput{addItems[1], addItems[0]}) {
 		t.Errorf("publish state incorrect after adding items, have items: %v", gotItems)
 	}
 
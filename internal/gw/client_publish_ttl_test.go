@@ -0,0 +1,142 @@
+package gw
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+// ttlGw records request bodies as recordingGw does, but refuses any request
+// with a body if unsupported is set, as a version of exodus-gw without
+// support for expiring or labelling publishes would, reporting each field as
+// unknown. If refusal is set, such a request is instead refused with that
+// response.
+type ttlGw struct {
+	recordingGw
+	unsupported bool
+	refusal     *http.Response
+}
+
+func (g *ttlGw) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil {
+		req.Body = http.NoBody
+	}
+	resp, err := g.recordingGw.RoundTrip(req)
+	body := g.bodies[len(g.bodies)-1]
+	if err != nil || body == "" {
+		return resp, err
+	}
+
+	if g.refusal != nil {
+		return g.refusal, nil
+	}
+	if g.unsupported {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(body), &fields); err != nil {
+			return nil, err
+		}
+		details := []string{}
+		for field := range fields {
+			details = append(details, fmt.Sprintf(
+				`{"loc": ["body", %q], "msg": "extra fields not permitted", "type": "value_error.extra"}`, field))
+		}
+		resp = &http.Response{
+			Status:     "422 Unprocessable Entity",
+			StatusCode: 422,
+			Body:       io.NopCloser(strings.NewReader(`{"detail": [` + strings.Join(details, ", ") + `]}`)),
+		}
+	}
+	return resp, err
+}
+
+func TestClientNewPublishExpireAfter(t *testing.T) {
+	tests := []struct {
+		name        string
+		expireAfter time.Duration
+		unsupported bool
+		bodies      []string
+	}{
+		{"no expiration", 0, false, []string{""}},
+		{"expiration", 36 * time.Hour, false, []string{`{"ttl":129600}` + "\n"}},
+		{"rounded to seconds", 1500 * time.Millisecond, false, []string{`{"ttl":2}` + "\n"}},
+		{"unsupported", time.Hour, true, []string{`{"ttl":3600}` + "\n", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+
+			iface, err := Package.NewClient(ctx, testConfig(t))
+			if err != nil {
+				t.Fatal("creating client:", err)
+			}
+			c := iface.(*client)
+
+			gw := &ttlGw{unsupported: tt.unsupported}
+			c.httpClient.Transport = gw
+
+			if tt.expireAfter > 0 {
+				ctx = WithExpireAfter(ctx, tt.expireAfter)
+			}
+			if _, err := c.NewPublish(ctx); err != nil {
+				t.Fatalf("failed to create publish, err = %v", err)
+			}
+
+			if !reflect.DeepEqual(gw.bodies, tt.bodies) {
+				t.Errorf("got request bodies %q, want %q", gw.bodies, tt.bodies)
+			}
+		})
+	}
+}
+
+func TestClientNewPublishRefused(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{"bad request", 400, `{"detail": "extra fields not permitted"}`},
+		{"invalid ttl", 422, `{"detail": [{"loc": ["body", "ttl"], "msg": "value is not a valid integer", "type": "type_error.integer"}]}`},
+		{"other unknown field", 422, `{"detail": [{"loc": ["body", "other"], "msg": "extra fields not permitted", "type": "extra_forbidden"}]}`},
+		{"not json", 422, `invalid`},
+		{"no details", 422, `{"detail": []}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+
+			iface, err := Package.NewClient(ctx, testConfig(t))
+			if err != nil {
+				t.Fatal("creating client:", err)
+			}
+			c := iface.(*client)
+
+			gw := &ttlGw{refusal: &http.Response{
+				Status:     fmt.Sprint(tt.status),
+				StatusCode: tt.status,
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			}}
+			c.httpClient.Transport = gw
+
+			// Refusals other than of unknown fields aren't taken to mean
+			// that the fields are unsupported.
+			ctx = WithExpireAfter(ctx, time.Hour)
+			if _, err := c.NewPublish(ctx); err == nil {
+				t.Error("unexpectedly created publish")
+			}
+			if len(gw.bodies) != 1 {
+				t.Errorf("unexpected requests %q", gw.bodies)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package gw
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+type redirectsConfig struct {
+	conf.Config
+	mode string
+}
+
+func (c redirectsConfig) GwRedirects() string {
+	return c.mode
+}
+
+// A record of a request seen by redirectingGw.
+type redirectedRequest struct {
+	method string
+	path   string
+	key    string
+	body   bool
+}
+
+// A RoundTripper redirecting every request other than a GET to the same path
+// beneath /regional, as if to a regional endpoint, which is served by gw.
+type redirectingGw struct {
+	gw       *fakeGw
+	status   int
+	location string
+	requests []redirectedRequest
+}
+
+func (g *redirectingGw) RoundTrip(r *http.Request) (*http.Response, error) {
+	g.requests = append(g.requests, redirectedRequest{
+		r.Method, r.URL.Path, r.Header.Get("X-Idempotency-Key"), r.ContentLength > 0,
+	})
+
+	if regional := strings.TrimPrefix(r.URL.Path, "/regional"); regional != r.URL.Path {
+		r = r.Clone(r.Context())
+		r.URL.Path = regional
+		return g.gw.RoundTrip(r)
+	}
+
+	if r.Method == "GET" {
+		return g.gw.RoundTrip(r)
+	}
+
+	location := g.location + "/regional" + r.URL.RequestURI()
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", g.status, http.StatusText(g.status)),
+		StatusCode: g.status,
+		Header:     http.Header{"Location": {location}},
+		Body:       io.NopCloser(strings.NewReader("")),
+	}, nil
+}
+
+func newRedirectingClient(t *testing.T, mode string, status int) (*client, *redirectingGw) {
+	ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+	cfg := redirectsConfig{testConfig(t), mode}
+
+	iface, err := Package.NewClient(ctx, cfg)
+	if err != nil {
+		t.Fatalf("failed to create client, err = %v", err)
+	}
+	c := iface.(*client)
+
+	gw := newFakeGw(t, c)
+	gw.createPublishIds = []string{"abc-123"}
+
+	redirecting := &redirectingGw{gw: gw, status: status}
+	c.httpClient.Transport = retryTransport(ctx, cfg, redirecting)
+	return c, redirecting
+}
+
+func TestClientRedirectFollowed(t *testing.T) {
+	ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+
+	for _, status := range []int{http.StatusTemporaryRedirect, http.StatusPermanentRedirect} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			c, redirecting := newRedirectingClient(t, "follow", status)
+
+			publish, err := c.NewPublish(ctx)
+			if err != nil {
+				t.Fatalf("failed to create publish, err = %v", err)
+			}
+			if err := publish.AddItems(ctx, []ItemInput{{WebURI: "/some/path", ObjectKey: "1234"}}); err != nil {
+				t.Fatalf("failed to add items, err = %v", err)
+			}
+
+			// The request is repeated at the new location with the same method,
+			// body and idempotency key.
+			reqs := redirecting.requests
+			if len(reqs) != 4 {
+				t.Fatalf("unexpected requests %+v", reqs)
+			}
+			for i := 0; i < len(reqs); i += 2 {
+				original, redirected := reqs[i], reqs[i+1]
+				want := redirectedRequest{original.method, "/regional" + original.path, original.key, original.body}
+				if original.key == "" || redirected != want {
+					t.Errorf("redirected %+v as %+v", original, redirected)
+				}
+			}
+			if !reqs[3].body || reqs[3].method != "PUT" {
+				t.Errorf("items weren't added at the new location, got %+v", reqs[3])
+			}
+
+			items := redirecting.gw.publishes["abc-123"].items
+			if !reflect.DeepEqual(items, []ItemInput{{WebURI: "/some/path", ObjectKey: "1234"}}) {
+				t.Errorf("unexpected items %v", items)
+			}
+		})
+	}
+}
+
+func TestClientRedirectRefused(t *testing.T) {
+	ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+
+	tests := []struct {
+		name     string
+		mode     string
+		status   int
+		location string
+		want     string
+	}{
+		// net/http would otherwise turn the POST into a GET.
+		{"changes method", "follow", http.StatusFound, "", "which would change the method to GET"},
+		{"not https", "follow", http.StatusPermanentRedirect, "http://exodus-gw.example.com", "which isn't https"},
+		{"disabled", "none", http.StatusPermanentRedirect, "", "308 Permanent Redirect"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, redirecting := newRedirectingClient(t, tt.mode, tt.status)
+			redirecting.location = tt.location
+
+			_, err := c.NewPublish(ctx)
+
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want %q", err, tt.want)
+			}
+			if len(redirecting.requests) != 1 {
+				t.Errorf("unexpected requests %+v", redirecting.requests)
+			}
+		})
+	}
+}
+
+func TestClientRedirectsInvalid(t *testing.T) {
+	ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+
+	_, err := Package.NewClient(ctx, redirectsConfig{testConfig(t), "sometimes"})
+
+	if err == nil || err.Error() != "gwredirects: unsupported value 'sometimes'" {
+		t.Errorf("got unexpected error %v", err)
+	}
+}
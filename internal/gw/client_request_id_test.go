@@ -0,0 +1,78 @@
+package gw
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+func TestClientRequestID(t *testing.T) {
+	var mu sync.Mutex
+	ids := map[string][]string{}
+
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ids[r.Method] = append(ids[r.Method], r.Header.Get("X-Request-ID"))
+		mu.Unlock()
+
+		if r.Method == "HEAD" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(gw.Close)
+
+	// proxyConfig is used only to point the client at the test server.
+	cfg := proxyConfig{Config: testConfig(t), gwURL: gw.URL}
+
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	clientIface, err := Package.NewClient(ctx, cfg)
+	if err != nil {
+		t.Fatalf("failed to create client, err = %v", err)
+	}
+	c := clientIface.(*client)
+
+	if _, err := c.WhoAmI(ctx); err != nil {
+		t.Fatalf("WhoAmI failed, err = %v", err)
+	}
+
+	idCtx := WithRequestID(ctx, "run-1234")
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.WhoAmI(idCtx); err != nil {
+			t.Fatalf("WhoAmI failed, err = %v", err)
+		}
+	}
+	if _, err := c.haveBlob(idCtx, walk.SyncItem{Key: "abc123"}); err != nil {
+		t.Fatalf("haveBlob failed, err = %v", err)
+	}
+
+	// Requests without an ID set shouldn't have the header, while every
+	// request with the ID set should have the same one, including those
+	// to S3.
+	expected := map[string][]string{
+		"GET":  {"", "run-1234", "run-1234"},
+		"HEAD": {"run-1234"},
+	}
+	for method, want := range expected {
+		got := ids[method]
+		if len(got) != len(want) {
+			t.Errorf("%s: expected %d requests, got %v", method, len(want), got)
+			continue
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s: request %d had ID %q, expected %q", method, i, got[i], want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package gw
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+// A config overriding the classification of retryable statuses.
+type retryStatusConfig struct {
+	conf.Config
+	retry     []int
+	permanent []int
+}
+
+func (c retryStatusConfig) GwRetryStatuses() []int     { return c.retry }
+func (c retryStatusConfig) GwPermanentStatuses() []int { return c.permanent }
+
+// A RoundTripper responding to every request with the given status,
+// counting the requests made.
+type countingStatusGw struct {
+	mu     sync.Mutex
+	status int
+	count  int
+}
+
+func (g *countingStatusGw) RoundTrip(r *http.Request) (*http.Response, error) {
+	g.mu.Lock()
+	g.count++
+	g.mu.Unlock()
+
+	return &http.Response{
+		Status:     http.StatusText(g.status),
+		StatusCode: g.status,
+		Body:       io.NopCloser(strings.NewReader("")),
+	}, nil
+}
+
+func TestClientRetryStatuses(t *testing.T) {
+	ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+
+	tests := []struct {
+		name      string
+		retry     []int
+		permanent []int
+		status    int
+		attempts  int
+	}{
+		{"default retryable", nil, nil, 503, 3},
+		{"default permanent", nil, nil, 403, 1},
+		{"custom retryable", []int{403}, nil, 403, 3},
+		{"custom permanent", nil, []int{503}, 503, 1},
+		{"permanent overrides retryable", []int{403}, []int{403}, 403, 1},
+		// Rate limited requests are retried outside of the retry transport,
+		// and there the initial attempt counts towards the max attempts.
+		{"rate limit retryable", nil, nil, 429, 2},
+		{"rate limit permanent", nil, []int{429}, 429, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := retryStatusConfig{
+				Config:    policyConfig(t, [3]int{0, 0, 0}, [3]int{2, 2, 2}),
+				retry:     tt.retry,
+				permanent: tt.permanent,
+			}
+
+			clientIface, err := Package.NewClient(ctx, cfg)
+			if err != nil {
+				t.Fatalf("failed to create client, err = %v", err)
+			}
+			c := clientIface.(*client)
+
+			gw := &countingStatusGw{status: tt.status}
+			c.httpClient.Transport = retryTransport(ctx, cfg, gw)
+
+			if _, err := c.GetPublish(ctx, "1234"); err == nil {
+				t.Fatal("GetPublish unexpectedly succeeded")
+			}
+			if gw.count != tt.attempts {
+				t.Errorf("expected %d requests, got %d", tt.attempts, gw.count)
+			}
+		})
+	}
+}
+
+func TestClientRetryStatusesInvalid(t *testing.T) {
+	ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+
+	for _, cfg := range []retryStatusConfig{
+		{retry: []int{600}},
+		{permanent: []int{0}},
+	} {
+		cfg.Config = policyConfig(t, [3]int{0, 0, 0}, [3]int{2, 2, 2})
+
+		_, err := Package.NewClient(ctx, cfg)
+		if err == nil || !strings.Contains(err.Error(), "invalid HTTP status") {
+			t.Errorf("did not get expected error for %s, got: %v", fmt.Sprint(cfg.retry, cfg.permanent), err)
+		}
+	}
+}
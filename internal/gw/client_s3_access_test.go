@@ -0,0 +1,210 @@
+package gw
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+type s3AccessConfig struct {
+	conf.Config
+	access string
+	bucket string
+}
+
+func (c s3AccessConfig) S3Access() string {
+	return c.access
+}
+
+func (c s3AccessConfig) S3Bucket() string {
+	return c.bucket
+}
+
+// isolateAWSConfig ensures the AWS credential chain finds only what the
+// test provides.
+func isolateAWSConfig(t *testing.T) string {
+	dir := t.TempDir()
+
+	for _, name := range []string{
+		"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN",
+		"AWS_PROFILE", "AWS_REGION", "AWS_DEFAULT_REGION",
+		"AWS_ROLE_ARN", "AWS_WEB_IDENTITY_TOKEN_FILE",
+	} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+
+	// Instance metadata must not be consulted from tests.
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	return dir
+}
+
+func TestClientS3AccessDirect(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T, dir string)
+		provider string
+		keyID    string
+		region   string
+	}{
+		{"env", func(t *testing.T, _ string) {
+			t.Setenv("AWS_ACCESS_KEY_ID", "AKIDENV")
+			t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret")
+			t.Setenv("AWS_REGION", "eu-west-1")
+		}, "EnvConfigCredentials", "AKIDENV", "eu-west-1"},
+
+		{"shared config", func(t *testing.T, dir string) {
+			os.WriteFile(filepath.Join(dir, "credentials"),
+				[]byte("[default]\naws_access_key_id = AKIDSHARED\naws_secret_access_key = shared-secret\n"), 0600)
+			os.WriteFile(filepath.Join(dir, "config"),
+				[]byte("[default]\nregion = ap-south-1\n"), 0600)
+		}, "SharedConfigCredentials", "AKIDSHARED", "ap-south-1"},
+
+		{"no region", func(t *testing.T, _ string) {
+			t.Setenv("AWS_ACCESS_KEY_ID", "AKIDENV")
+			t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret")
+		}, "EnvConfigCredentials", "AKIDENV", "us-east-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(t, isolateAWSConfig(t))
+
+			cfg := s3AccessConfig{Config: testConfig(t), access: "direct", bucket: "my-bucket"}
+
+			ctx := context.Background()
+			ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+			clientIface, err := Package.NewClient(ctx, cfg)
+			if err != nil {
+				t.Fatalf("failed to create client, err = %v", err)
+			}
+			c := clientIface.(*client)
+
+			creds, err := c.s3.Config.Credentials.Get()
+			if err != nil {
+				t.Fatalf("can't get credentials, err = %v", err)
+			}
+			if !strings.HasPrefix(creds.ProviderName, tt.provider) || creds.AccessKeyID != tt.keyID {
+				t.Errorf("used credentials %s from %s, expected %s from %s",
+					creds.AccessKeyID, creds.ProviderName, tt.keyID, tt.provider)
+			}
+
+			// Requests should be sent to the bucket, signed by those
+			// credentials.
+			var sent *http.Request
+			c.s3.Handlers.Send.Clear()
+			c.s3.Handlers.Send.PushBack(func(r *request.Request) {
+				sent = r.HTTPRequest
+				r.HTTPResponse = &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}
+			})
+
+			if _, err := c.haveBlob(ctx, walk.SyncItem{Key: "abc123"}); err != nil {
+				t.Fatalf("haveBlob failed, err = %v", err)
+			}
+
+			if sent == nil {
+				t.Fatal("no request sent")
+			}
+			if !strings.Contains(sent.URL.String(), "my-bucket") || !strings.HasSuffix(sent.URL.Path, "/abc123") {
+				t.Errorf("request sent to unexpected URL %s", sent.URL)
+			}
+			auth := sent.Header.Get("Authorization")
+			if !strings.Contains(auth, "Credential="+tt.keyID+"/") || !strings.Contains(auth, "/"+tt.region+"/s3/") {
+				t.Errorf("request has unexpected authorization %q", auth)
+			}
+		})
+	}
+}
+
+// Config overriding the environment, as done for each of --exodus-env.
+type s3EnvConfig struct {
+	s3AccessConfig
+	gwEnv string
+}
+
+func (c s3EnvConfig) GwEnv() string {
+	return c.gwEnv
+}
+
+func TestClientS3AccessDirectMultiEnv(t *testing.T) {
+	isolateAWSConfig(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDENV")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret")
+
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	tests := []struct {
+		name     string
+		bucket   string
+		expected []string
+	}{
+		// With no bucket configured, each environment uses its own bucket.
+		{"default", "", []string{"env-a", "env-b"}},
+
+		// A configured bucket is used for every environment.
+		{"configured", "my-bucket", []string{"my-bucket", "my-bucket"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := s3AccessConfig{Config: testConfig(t), access: "direct", bucket: tt.bucket}
+
+			for i, env := range []string{"env-a", "env-b"} {
+				clientIface, err := Package.NewClient(ctx, s3EnvConfig{base, env})
+				if err != nil {
+					t.Fatalf("failed to create client, err = %v", err)
+				}
+				if bucket := clientIface.(*client).bucket; bucket != tt.expected[i] {
+					t.Errorf("client for %s uses bucket %s, expected %s", env, bucket, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestClientS3AccessGw(t *testing.T) {
+	// Credentials in the environment shouldn't be used for exodus-gw.
+	isolateAWSConfig(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDENV")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret")
+
+	cfg := testConfig(t)
+
+	clientIface, err := Package.NewClient(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("failed to create client, err = %v", err)
+	}
+	c := clientIface.(*client)
+
+	if c.s3.Config.Credentials != credentials.AnonymousCredentials {
+		t.Errorf("unexpected credentials %v", c.s3.Config.Credentials)
+	}
+	if c.bucket != "env" || aws.StringValue(c.s3.Config.Endpoint) != "https://exodus-gw.example.com/upload" {
+		t.Errorf("unexpected bucket %s at %s", c.bucket, aws.StringValue(c.s3.Config.Endpoint))
+	}
+}
+
+func TestClientS3AccessInvalid(t *testing.T) {
+	cfg := s3AccessConfig{Config: testConfig(t), access: "sideways"}
+
+	_, err := Package.NewClient(context.Background(), cfg)
+	if err == nil || err.Error() != "s3access: unsupported value 'sideways'" {
+		t.Errorf("did not get expected error, err = %v", err)
+	}
+}
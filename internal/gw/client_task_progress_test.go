@@ -0,0 +1,70 @@
+package gw
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClientCommitProgress(t *testing.T) {
+	ctx, logs := memoryContext()
+
+	clientIface, err := Package.NewClient(ctx, testConfig(t))
+	if err != nil {
+		t.Fatalf("failed to create client, err = %v", err)
+	}
+
+	gw := newFakeGw(t, clientIface.(*client))
+	gw.publishes["some-publish"] = &fakePublish{
+		id:         "some-publish",
+		taskStates: []string{"NOT_STARTED", "IN_PROGRESS", "IN_PROGRESS", "IN_PROGRESS", "IN_PROGRESS", "COMPLETE"},
+		taskProgress: []string{
+			"",
+			`"processed": 100, "total": 400`,
+			`"percent": 62.5, "processed": 250, "total": 400`,
+			// Progress isn't always reported.
+			"",
+			`"processed": 400`,
+			`"percent": 100, "processed": 400, "total": 400`,
+		},
+	}
+
+	publish, err := clientIface.GetPublish(ctx, "some-publish")
+	if err != nil {
+		t.Fatalf("failed to get publish, err = %v", err)
+	}
+
+	if err := publish.Commit(ctx, ""); err != nil {
+		t.Fatalf("unexpected error from commit: %v", err)
+	}
+
+	type progress struct {
+		percent   interface{}
+		processed interface{}
+		total     interface{}
+	}
+	got := []progress{}
+	for _, entry := range logs.Entries {
+		if entry.Message != "Task in progress" {
+			continue
+		}
+		if entry.Fields["task"] != "task-some-publish" || entry.Fields["state"] != "IN_PROGRESS" {
+			t.Errorf("unexpected fields %v", entry.Fields)
+		}
+		got = append(got, progress{entry.Fields["percent"], entry.Fields["processed"], entry.Fields["total"]})
+	}
+
+	// Progress is logged on each poll of the task which reports it, with a
+	// percentage worked out if not given.
+	want := []progress{
+		{"25.0", int64(100), int64(400)},
+		{"62.5", int64(250), int64(400)},
+		{nil, int64(400), nil},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got progress %v, want %v", got, want)
+	}
+
+	if countEntries(logs, "Task completed") != 1 {
+		t.Error("task did not complete")
+	}
+}
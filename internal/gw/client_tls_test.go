@@ -0,0 +1,81 @@
+package gw
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+// Config restricting connections to some TLS cipher suites.
+type cipherSuitesConfig struct {
+	conf.Config
+	suites []string
+}
+
+func (c cipherSuitesConfig) TLSCipherSuites() []string {
+	return c.suites
+}
+
+func TestClientTLSCipherSuites(t *testing.T) {
+	ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+
+	cfg := cipherSuitesConfig{testConfig(t), []string{
+		"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+		"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+	}}
+	want := []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384}
+
+	tlsConfig, err := newTLSConfig(cfg, tls.Certificate{})
+	if err != nil {
+		t.Fatalf("got unexpected error %v", err)
+	}
+	if !reflect.DeepEqual(tlsConfig.CipherSuites, want) {
+		t.Errorf("got cipher suites %v, want %v", tlsConfig.CipherSuites, want)
+	}
+
+	// TLS 1.3 would ignore the suites.
+	if tlsConfig.MaxVersion != tls.VersionTLS12 {
+		t.Errorf("got max version %x, want TLS 1.2", tlsConfig.MaxVersion)
+	}
+
+	clientIface, err := Package.NewClient(ctx, cfg)
+	if err != nil {
+		t.Fatalf("failed to create client, err = %v", err)
+	}
+
+	// Uploads to S3 are restricted too.
+	transport := clientIface.(*client).s3.Client.Config.HTTPClient.Transport.(*http.Transport)
+	if !reflect.DeepEqual(transport.TLSClientConfig.CipherSuites, want) ||
+		transport.TLSClientConfig.MaxVersion != tls.VersionTLS12 {
+		t.Errorf("got S3 cipher suites %v, max version %x", transport.TLSClientConfig.CipherSuites,
+			transport.TLSClientConfig.MaxVersion)
+	}
+}
+
+func TestClientTLSCipherSuitesDefault(t *testing.T) {
+	tlsConfig, err := newTLSConfig(cipherSuitesConfig{testConfig(t), nil}, tls.Certificate{})
+	if err != nil {
+		t.Fatalf("got unexpected error %v", err)
+	}
+
+	// Go's own choice of suites applies.
+	if tlsConfig.CipherSuites != nil || tlsConfig.MaxVersion != 0 {
+		t.Errorf("got cipher suites %v, max version %x", tlsConfig.CipherSuites, tlsConfig.MaxVersion)
+	}
+}
+
+func TestClientTLSCipherSuitesUnknown(t *testing.T) {
+	ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+
+	_, err := Package.NewClient(ctx, cipherSuitesConfig{testConfig(t), []string{"TLS_NO_SUCH_SUITE"}})
+	if err == nil || !strings.Contains(err.Error(), "tlsciphersuites: unknown cipher suite 'TLS_NO_SUCH_SUITE'") {
+		t.Errorf("got unexpected error %v", err)
+	}
+}
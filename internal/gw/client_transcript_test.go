@@ -0,0 +1,143 @@
+package gw
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+func TestClientTranscript(t *testing.T) {
+	fake := &fakeGw{t: t, createPublishIds: []string{"abc-123"}, publishes: make(publishMap)}
+
+	// Serves the fake over HTTP, as the transcript is recorded beneath
+	// the transports created by NewClient.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		resp, _ := fake.RoundTrip(r)
+		w.WriteHeader(resp.StatusCode)
+		if resp.Body != nil {
+			io.Copy(w, resp.Body)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	// Credentials in the URL are sent as an Authorization header, and
+	// neither should be recorded.
+	gwURL := strings.Replace(server.URL, "://", "://user:hunter2@", 1)
+	cfg := proxyConfig{Config: testConfig(t), gwURL: gwURL}
+
+	buf := &bytes.Buffer{}
+
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+	ctx = WithTranscript(ctx, NewTranscript(buf))
+
+	clientIface, err := Package.NewClient(ctx, cfg)
+	if err != nil {
+		t.Fatalf("failed to create client, err = %v", err)
+	}
+
+	publish, err := clientIface.NewPublish(ctx)
+	if err != nil {
+		t.Fatalf("failed to create publish, err = %v", err)
+	}
+	if err := publish.AddItems(ctx, []ItemInput{{WebURI: "/some/path", ObjectKey: "1234", ContentType: "mime/type"}}); err != nil {
+		t.Fatalf("failed to add items, err = %v", err)
+	}
+	if err := publish.Commit(ctx, ""); err != nil {
+		t.Fatalf("failed to commit, err = %v", err)
+	}
+	if _, err := clientIface.(*client).haveBlob(ctx, walk.SyncItem{Key: "abc123"}); err != nil {
+		t.Fatalf("haveBlob failed, err = %v", err)
+	}
+
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Errorf("transcript contains a secret: %s", buf.String())
+	}
+
+	entries := []transcriptEntry{}
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		entry := transcriptEntry{}
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatalf("invalid transcript: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	type exchange struct {
+		method, path string
+		status       int
+		reqBody      string
+		respBody     string
+	}
+	expected := []exchange{
+		{"POST", "/env/publish", 200, "", `"id": "abc-123"`},
+		{"PUT", "/env/publish/abc-123", 200, `"web_uri":"/some/path"`, "{}"},
+		{"POST", "/env/publish/abc-123/commit", 200, "", `"state": "NOT_STARTED"`},
+		{"GET", "/task/task-abc-123", 200, "", `"state": "IN_PROGRESS"`},
+		{"GET", "/task/task-abc-123", 200, "", `"state": "COMPLETE"`},
+		{"HEAD", "/upload/env/abc123", 404, "", ""},
+	}
+
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %+v", len(expected), entries)
+	}
+
+	for i, want := range expected {
+		got := entries[i]
+		if got.Request.Method != want.method || !strings.HasSuffix(strings.SplitN(got.Request.URL, "?", 2)[0], want.path) {
+			t.Errorf("entry %d: got %s %s, expected %s %s", i, got.Request.Method, got.Request.URL, want.method, want.path)
+		}
+		if !strings.Contains(got.Request.URL, "://REDACTED@") {
+			t.Errorf("entry %d: credentials not redacted from %s", i, got.Request.URL)
+		}
+		if i < 5 && got.Request.Headers.Get("Authorization") != "REDACTED" {
+			t.Errorf("entry %d: authorization not redacted, headers %v", i, got.Request.Headers)
+		}
+		if got.Request.Headers.Get("Accept") == "" && i < 5 {
+			t.Errorf("entry %d: missing headers %v", i, got.Request.Headers)
+		}
+		if !strings.Contains(got.Request.Body, want.reqBody) || (want.reqBody == "" && got.Request.Body != "") {
+			t.Errorf("entry %d: unexpected request body %q", i, got.Request.Body)
+		}
+		if got.Response == nil || got.Response.Status != want.status || !strings.Contains(got.Response.Body, want.respBody) {
+			t.Errorf("entry %d: unexpected response %+v", i, got.Response)
+		}
+	}
+}
+
+func TestTranscriptRedacts(t *testing.T) {
+	req, err := http.NewRequest("PUT", "https://exodus-gw.example.com/upload/env/key?X-Amz-Signature=abc&partNumber=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Amz-Security-Token", "abc")
+	req.Header.Set("Cookie", "session=abc")
+	req.Header.Set("Content-Type", "text/plain")
+
+	if got := redactURL(req.URL); got != "https://exodus-gw.example.com/upload/env/key?X-Amz-Signature=REDACTED&partNumber=1" {
+		t.Errorf("unexpected redacted URL %s", got)
+	}
+
+	headers := redactHeaders(req.Header)
+	if headers.Get("X-Amz-Security-Token") != "REDACTED" || headers.Get("Cookie") != "REDACTED" {
+		t.Errorf("secrets not redacted from %v", headers)
+	}
+	if headers.Get("Content-Type") != "text/plain" {
+		t.Errorf("unexpectedly redacted %v", headers)
+	}
+}
@@ -0,0 +1,79 @@
+package gw
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+func TestClientUploadDisabled(t *testing.T) {
+	client, s3 := newClientWithFakeS3(t)
+
+	chdirInTest(t, "../../test/data/srctrees/just-files")
+
+	client.cfg = threadsConfig{client.cfg, 1}
+
+	items := []walk.SyncItem{
+		{SrcPath: "hello-copy-one", Key: "abc123"},
+		{SrcPath: "subdir/some-binary", Key: "aabbcc"},
+		{SrcPath: "hello-copy-two", Key: "def456"},
+	}
+
+	tests := []struct {
+		name      string
+		keepGoing bool
+		present   []string
+	}{
+		{"stop at first missing blob", false, []string{"hello-copy-one"}},
+		{"keep going", true, []string{"hello-copy-one", "hello-copy-two"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s3.reset()
+			s3.blobs["abc123"] = nil
+			s3.blobs["def456"] = nil
+			client.presence = newPresenceCache(context.Background(), "", 0)
+
+			ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+			ctx = WithNoUpload(ctx)
+			if tt.keepGoing {
+				ctx = WithKeepGoing(ctx)
+			}
+
+			present := []string{}
+			err := client.EnsureUploaded(ctx, items, func(item walk.SyncItem) error {
+				t.Error("unexpectedly uploaded", item)
+				return nil
+			}, func(item walk.SyncItem) error {
+				present = append(present, item.SrcPath)
+				return nil
+			}, func(item walk.SyncItem) error {
+				return nil
+			})
+
+			expected := "blob aabbcc of subdir/some-binary is not present, and uploads are disabled"
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Fatalf("did not get expected error, got %v", err)
+			}
+
+			var uploadErrs *UploadErrors
+			if errors.As(err, &uploadErrs) != tt.keepGoing {
+				t.Fatalf("unexpected type of error %T", err)
+			}
+
+			if !reflect.DeepEqual(present, tt.present) {
+				t.Errorf("found %v present, expected %v", present, tt.present)
+			}
+			if len(s3.puts) != 0 || len(s3.multiparts) != 0 {
+				t.Errorf("unexpectedly uploaded %v %v", s3.puts, s3.multiparts)
+			}
+		})
+	}
+}
@@ -63,6 +63,10 @@ func TestClientUploadErrors(t *testing.T) {
 			s3.reset()
 			tt.setup(s3.blobs)
 
+			// Each case starts from a fresh S3, so forget which blobs
+			// were found by the previous one.
+			client.presence = newPresenceCache(ctx, "", 0)
+
 			err := client.EnsureUploaded(ctx, tt.items, func(item walk.SyncItem) error {
 				t.Fatal("unexpectedly uploaded something", item)
 				return nil
@@ -0,0 +1,62 @@
+package gw
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+func TestClientUploadForce(t *testing.T) {
+	ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+
+	iface, err := Package.NewClient(ctx, testConfig(t))
+	if err != nil {
+		t.Fatal("creating client:", err)
+	}
+	client := iface.(*client)
+	s3 := newFakeS3(t, client)
+
+	// Both blobs are already present.
+	s3.blobs["abc123"] = nil
+	s3.blobs["def456"] = nil
+
+	chdirInTest(t, "../../test/data/srctrees/just-files")
+
+	items := []walk.SyncItem{
+		{SrcPath: "hello-copy-one", Key: "abc123"},
+		{SrcPath: "hello-copy-two", Key: "def456"},
+	}
+
+	uploaded := []string{}
+	present := []string{}
+	err = client.EnsureUploaded(WithForceUpload(ctx, map[string]bool{"abc123": true}), items,
+		func(item walk.SyncItem) error {
+			uploaded = append(uploaded, item.Key)
+			return nil
+		},
+		func(item walk.SyncItem) error {
+			present = append(present, item.Key)
+			return nil
+		},
+		func(walk.SyncItem) error { return nil },
+	)
+	if err != nil {
+		t.Fatalf("got unexpected error %v", err)
+	}
+
+	// Only the forced blob should have been uploaded again, without checking
+	// whether it's present.
+	if !reflect.DeepEqual(uploaded, []string{"abc123"}) || !reflect.DeepEqual(present, []string{"def456"}) {
+		t.Errorf("uploaded %v, present %v", uploaded, present)
+	}
+	if s3.heads["abc123"] != 0 || s3.heads["def456"] != 1 {
+		t.Errorf("unexpected checks for presence %v", s3.heads)
+	}
+	if s3.puts["abc123"] == nil || s3.puts["def456"] != nil {
+		t.Errorf("unexpected uploads %v", s3.puts)
+	}
+}
@@ -0,0 +1,120 @@
+package gw
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+func TestClientUploadInflight(t *testing.T) {
+	tests := []struct {
+		name string
+
+		// Source of the item handled first, which may fail.
+		firstSrc string
+
+		// Whether the blob is present from the start, and whether the
+		// second item forces its upload.
+		existing    bool
+		forceSecond bool
+
+		firstState  uploadState
+		secondState uploadState
+		puts        int
+	}{
+		{"first uploads", "hello-copy-one", false, false, uploaded, present, 1},
+		{"first fails", "no-such-file", false, false, failed, uploaded, 1},
+		{"first finds present", "hello-copy-one", true, false, present, present, 0},
+		{"forced after found present", "hello-copy-one", true, true, present, uploaded, 1},
+		{"forced after upload", "hello-copy-one", false, true, uploaded, present, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, fakeS3 := newClientWithFakeS3(t)
+			chdirInTest(t, "../../test/data/srctrees/just-files")
+			if tt.existing {
+				fakeS3.blobs["abc123"] = nil
+			}
+
+			ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+			ctx = WithKeepGoing(ctx)
+
+			// The first check for the blob is held up until the second item
+			// is waiting on it.
+			var mu sync.Mutex
+			heads := 0
+			puts := 0
+			headStarted := make(chan struct{})
+			release := make(chan struct{})
+			client.s3.Client.Handlers.Send.PushFront(func(r *request.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				switch r.Params.(type) {
+				case *s3.HeadObjectInput:
+					heads++
+					if heads == 1 {
+						close(headStarted)
+						mu.Unlock()
+						<-release
+						mu.Lock()
+					}
+				case *s3.PutObjectInput:
+					puts++
+				}
+			})
+
+			// Each item has the same content, but is handled by its own
+			// EnsureUploaded, as for items found incrementally.
+			states := make([]uploadState, 2)
+			var wg sync.WaitGroup
+			ensure := func(i int, src string) {
+				defer wg.Done()
+				record := func(state uploadState) func(walk.SyncItem) error {
+					return func(walk.SyncItem) error {
+						states[i] = state
+						return nil
+					}
+				}
+				states[i] = failed
+				items := []walk.SyncItem{{SrcPath: src, Key: "abc123"}}
+				itemCtx := ctx
+				if i == 1 && tt.forceSecond {
+					itemCtx = WithForceUpload(ctx, map[string]bool{"abc123": true})
+				}
+				client.EnsureUploaded(itemCtx, items, record(uploaded), record(present), record(duplicate))
+			}
+
+			wg.Add(1)
+			go ensure(0, tt.firstSrc)
+			<-headStarted
+
+			wg.Add(1)
+			go ensure(1, "hello-copy-two")
+			for deadline := time.Now().Add(5 * time.Second); client.inflight.waiting("abc123") == 0; {
+				if time.Now().After(deadline) {
+					t.Fatal("second item didn't wait for the first")
+				}
+				time.Sleep(time.Millisecond)
+			}
+
+			close(release)
+			wg.Wait()
+
+			if states[0] != tt.firstState || states[1] != tt.secondState {
+				t.Errorf("got states %v, want %v", states, []uploadState{tt.firstState, tt.secondState})
+			}
+			if puts != tt.puts {
+				t.Errorf("blob was uploaded %d times, want %d", puts, tt.puts)
+			}
+		})
+	}
+}
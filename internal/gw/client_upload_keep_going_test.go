@@ -0,0 +1,150 @@
+package gw
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+func TestClientUploadKeepGoing(t *testing.T) {
+	client, s3 := newClientWithFakeS3(t)
+
+	chdirInTest(t, "../../test/data/srctrees/just-files")
+
+	// With a single upload thread, nothing after the failure is uploaded
+	// unless keeping going.
+	client.cfg = threadsConfig{client.cfg, 1}
+
+	items := []walk.SyncItem{
+		{SrcPath: "hello-copy-one", Key: "abc123"},
+		{SrcPath: "nonexistent-file", Key: "def456"},
+		{SrcPath: "subdir/some-binary", Key: "aabbcc"},
+		{SrcPath: "hello-copy-two", Key: "def456"},
+	}
+
+	tests := []struct {
+		name      string
+		keepGoing bool
+		uploaded  int
+	}{
+		{"stop at first error", false, 0},
+		{"keep going", true, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s3.reset()
+			client.presence = newPresenceCache(context.Background(), "", 0)
+
+			ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+			if tt.keepGoing {
+				ctx = WithKeepGoing(ctx)
+			}
+
+			uploaded := []string{}
+			err := client.EnsureUploaded(ctx, items, func(item walk.SyncItem) error {
+				uploaded = append(uploaded, item.SrcPath)
+				return nil
+			}, func(item walk.SyncItem) error {
+				t.Fatal("unexpectedly found blob", item)
+				return nil
+			}, func(item walk.SyncItem) error {
+				return nil
+			})
+
+			if err == nil || !strings.Contains(err.Error(), "open nonexistent-file") {
+				t.Fatalf("did not get expected error, got %v", err)
+			}
+
+			var uploadErrs *UploadErrors
+			if errors.As(err, &uploadErrs) != tt.keepGoing {
+				t.Fatalf("unexpected type of error %T", err)
+			}
+
+			if tt.keepGoing {
+				if len(uploadErrs.Failed) != 1 || uploadErrs.Failed[0].Item.SrcPath != "nonexistent-file" {
+					t.Errorf("unexpected failed items %v", uploadErrs.Failed)
+				}
+				if keys := uploadErrs.Keys(); len(keys) != 1 || !keys["def456"] {
+					t.Errorf("unexpected failed keys %v", keys)
+				}
+			}
+
+			if tt.keepGoing && len(uploaded) != tt.uploaded {
+				t.Errorf("uploaded %v, wanted %d items", uploaded, tt.uploaded)
+			}
+			if !tt.keepGoing && len(uploaded) > 1 {
+				t.Errorf("continued uploading after failure: %v", uploaded)
+			}
+		})
+	}
+}
+
+func TestClientUploadKeepGoingMaxErrors(t *testing.T) {
+	client, s3 := newClientWithFakeS3(t)
+
+	chdirInTest(t, "../../test/data/srctrees/just-files")
+
+	client.cfg = threadsConfig{client.cfg, 1}
+
+	items := []walk.SyncItem{
+		{SrcPath: "nonexistent-one", Key: "aa"},
+		{SrcPath: "hello-copy-one", Key: "abc123"},
+		{SrcPath: "nonexistent-two", Key: "bb"},
+		{SrcPath: "nonexistent-three", Key: "cc"},
+		{SrcPath: "subdir/some-binary", Key: "aabbcc"},
+		{SrcPath: "nonexistent-four", Key: "dd"},
+	}
+
+	tests := []struct {
+		name      string
+		maxErrors int
+		failed    int
+		aborted   bool
+	}{
+		{"unlimited", 0, 4, false},
+		{"stop at first", 1, 1, true},
+		{"stop at third", 3, 3, true},
+		{"at the limit", 4, 4, true},
+		{"under the limit", 5, 4, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s3.reset()
+			client.presence = newPresenceCache(context.Background(), "", 0)
+
+			ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+			ctx = WithMaxErrors(WithKeepGoing(ctx), tt.maxErrors)
+
+			err := client.EnsureUploaded(ctx, items, func(item walk.SyncItem) error {
+				return nil
+			}, func(item walk.SyncItem) error {
+				return nil
+			}, func(item walk.SyncItem) error {
+				return nil
+			})
+
+			var uploadErrs *UploadErrors
+			if !errors.As(err, &uploadErrs) {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			// Exactly the limit is reported, without whatever failed only as
+			// uploads were stopped.
+			if len(uploadErrs.Failed) != tt.failed || uploadErrs.Aborted != tt.aborted {
+				t.Errorf("got %d failed items, aborted %v: %v", len(uploadErrs.Failed), uploadErrs.Aborted, uploadErrs.Failed)
+			}
+			for _, failed := range uploadErrs.Failed {
+				if !strings.HasPrefix(failed.Item.SrcPath, "nonexistent-") {
+					t.Errorf("unexpected failed item %v", failed)
+				}
+			}
+		})
+	}
+}
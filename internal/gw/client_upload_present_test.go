@@ -0,0 +1,57 @@
+package gw
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+func TestClientUploadPresentBlobs(t *testing.T) {
+	ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+
+	iface, err := Package.NewClient(ctx, testConfig(t))
+	if err != nil {
+		t.Fatal("creating client:", err)
+	}
+	client := iface.(*client)
+	s3 := newFakeS3(t, client)
+
+	chdirInTest(t, "../../test/data/srctrees/just-files")
+
+	items := []walk.SyncItem{
+		{SrcPath: "hello-copy-one", Key: "abc123"},
+		{SrcPath: "hello-copy-two", Key: "def456"},
+	}
+
+	// Neither blob is really present, but one is known to be.
+	ctx = WithPresentBlobs(ctx, map[string]bool{"abc123": true})
+
+	uploaded := []string{}
+	present := []string{}
+	err = client.EnsureUploaded(ctx, items,
+		func(item walk.SyncItem) error {
+			uploaded = append(uploaded, item.Key)
+			return nil
+		},
+		func(item walk.SyncItem) error {
+			present = append(present, item.Key)
+			return nil
+		},
+		func(walk.SyncItem) error { return nil },
+	)
+	if err != nil {
+		t.Fatalf("got unexpected error %v", err)
+	}
+
+	// The known blob is neither checked for nor uploaded.
+	if !reflect.DeepEqual(uploaded, []string{"def456"}) || !reflect.DeepEqual(present, []string{"abc123"}) {
+		t.Errorf("uploaded %v, present %v", uploaded, present)
+	}
+	if s3.heads["abc123"] != 0 || s3.puts["abc123"] != nil || s3.puts["def456"] == nil {
+		t.Errorf("unexpected checks %v, uploads %v", s3.heads, s3.puts)
+	}
+}
@@ -0,0 +1,129 @@
+package gw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+type uploadAttemptsConfig struct {
+	conf.Config
+	attempts int
+}
+
+func (c uploadAttemptsConfig) UploadMaxAttempts() int {
+	return c.attempts
+}
+
+// failingPuts makes the blob absent, then fails this many uploads of it.
+func failingPuts(n int) []error {
+	out := []error{awserr.New("NotFound", "not found", nil)}
+	for i := 0; i < n; i++ {
+		out = append(out, fmt.Errorf("simulated error"))
+	}
+	return out
+}
+
+func TestClientUploadRetry(t *testing.T) {
+	client, s3 := newClientWithFakeS3(t)
+
+	chdirInTest(t, "../../test/data/srctrees/just-files")
+
+	client.cfg = uploadAttemptsConfig{client.cfg, 3}
+
+	tests := []struct {
+		name      string
+		failures  int
+		keepGoing bool
+		uploaded  []string
+		wantError string
+	}{
+		{"fails twice then succeeds", 2, false,
+			[]string{"hello-copy-one", "subdir/some-binary"}, ""},
+
+		// Had it been tried again, the upload would have succeeded.
+		{"exhausts retries", 3, false,
+			nil, "upload hello-copy-one: simulated error"},
+
+		{"exhausts retries and keeps going", 3, true,
+			[]string{"subdir/some-binary"}, "upload hello-copy-one: simulated error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s3.reset()
+			s3.blobs["abc123"] = failingPuts(tt.failures)
+			client.presence = newPresenceCache(context.Background(), "", 0)
+
+			ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+			if tt.keepGoing {
+				ctx = WithKeepGoing(ctx)
+			}
+
+			items := []walk.SyncItem{
+				{SrcPath: "hello-copy-one", Key: "abc123"},
+				{SrcPath: "subdir/some-binary", Key: "aabbcc"},
+			}
+			if !tt.keepGoing && tt.wantError != "" {
+				// Otherwise, whether the other item was uploaded before
+				// stopping would depend on timing.
+				items = items[:1]
+			}
+
+			uploaded := map[string]bool{}
+			err := client.EnsureUploaded(ctx, items, func(item walk.SyncItem) error {
+				uploaded[item.SrcPath] = true
+				return nil
+			}, func(item walk.SyncItem) error {
+				t.Fatal("unexpectedly found blob", item)
+				return nil
+			}, func(item walk.SyncItem) error {
+				t.Fatal("unexpectedly created duplicate blob", item)
+				return nil
+			})
+
+			if tt.wantError == "" && err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if tt.wantError != "" {
+				if err == nil || !containsError(err, tt.wantError) {
+					t.Fatalf("did not get expected error, got %v", err)
+				}
+			}
+
+			var uploadErrs *UploadErrors
+			if errors.As(err, &uploadErrs) != tt.keepGoing {
+				t.Errorf("unexpected type of error %T", err)
+			}
+
+			if len(uploaded) != len(tt.uploaded) {
+				t.Errorf("uploaded %v, wanted %v", uploaded, tt.uploaded)
+			}
+			for _, src := range tt.uploaded {
+				if !uploaded[src] {
+					t.Errorf("%s was not uploaded, uploaded %v", src, uploaded)
+				}
+			}
+
+			// Every failure was consumed by an attempt.
+			if remaining := len(s3.blobs["abc123"]); remaining != 0 {
+				t.Errorf("%d failures were not reached", remaining)
+			}
+		})
+	}
+}
+
+func containsError(err error, want string) bool {
+	var uploadErrs *UploadErrors
+	if errors.As(err, &uploadErrs) {
+		err = uploadErrs.Failed[0].Err
+	}
+	return err.Error() == want
+}
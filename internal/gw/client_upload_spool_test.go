@@ -0,0 +1,272 @@
+package gw
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+// Config overriding the directory for temporary files.
+type tempDirConfig struct {
+	conf.Config
+	tempDir string
+}
+
+func (c tempDirConfig) TempDir() string {
+	return c.tempDir
+}
+
+// archiveItem writes a tar archive holding a single file with the given
+// content into dir, returning an item for that file.
+func archiveItem(t *testing.T, dir string, content string) walk.SyncItem {
+	archive := filepath.Join(dir, "test.tar")
+
+	file, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	hdr := tar.Header{Name: "file", Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}
+
+	tw := tar.NewWriter(file)
+	if err := tw.WriteHeader(&hdr); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(tw, content); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return walk.SyncItem{
+		SrcPath:      filepath.Join(dir, "file"),
+		Key:          "abc123",
+		Info:         hdr.FileInfo(),
+		Archive:      archive,
+		ArchiveEntry: "file",
+
+		// Content follows the single header block.
+		ArchiveOffset: 512,
+	}
+}
+
+func TestSpool(t *testing.T) {
+	dir := t.TempDir()
+
+	file, err := spool(dir, strings.NewReader("some content"))
+	if err != nil {
+		t.Fatalf("can't spool, err = %v", err)
+	}
+	defer file.Close()
+
+	if filepath.Dir(file.Name()) != dir {
+		t.Errorf("spooled to %s, not within %s", file.Name(), dir)
+	}
+
+	// Nothing is left in the directory, even while the file is open...
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 0 {
+		t.Errorf("unexpected entries in %s: %v, err = %v", dir, entries, err)
+	}
+
+	// ...but the content can be read back from the start.
+	content, err := io.ReadAll(file)
+	if err != nil || string(content) != "some content" {
+		t.Errorf("read back %q, err = %v", content, err)
+	}
+}
+
+func TestClientUploadSpooled(t *testing.T) {
+	oldMinSize := spoolMinSize
+	spoolMinSize = 4
+	t.Cleanup(func() { spoolMinSize = oldMinSize })
+
+	tempDir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		tempDir string
+		content string
+		err     string
+	}{
+		{"spooled", tempDir, "larger than the minimum", ""},
+		{"not spooled", filepath.Join(tempDir, "missing"), "tiny", ""},
+		{"missing temp dir", filepath.Join(tempDir, "missing"), "larger than the minimum",
+			"to " + filepath.Join(tempDir, "missing")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+			iface, err := Package.NewClient(ctx, tempDirConfig{testConfig(t), tt.tempDir})
+			if err != nil {
+				t.Fatal("creating client:", err)
+			}
+			client := iface.(*client)
+			s3 := newFakeS3(t, client)
+
+			item := archiveItem(t, t.TempDir(), tt.content)
+
+			err = client.EnsureUploaded(ctx, []walk.SyncItem{item},
+				func(walk.SyncItem) error { return nil },
+				func(walk.SyncItem) error { return nil },
+				func(walk.SyncItem) error { return nil },
+			)
+
+			if tt.err == "" && err != nil {
+				t.Fatalf("got unexpected error %v", err)
+			}
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("did not get expected error, got %v", err)
+				}
+				return
+			}
+
+			if s3.puts["abc123"] == nil {
+				t.Error("blob was not uploaded")
+			}
+
+			// No spooled content is left behind.
+			entries, err := os.ReadDir(tempDir)
+			if err != nil || len(entries) != 0 {
+				t.Errorf("unexpected entries in %s: %v, err = %v", tempDir, entries, err)
+			}
+		})
+	}
+}
+
+// Config requiring free space in the directory for temporary files.
+type minFreeConfig struct {
+	conf.Config
+	tempDir string
+	minFree int64
+}
+
+func (c minFreeConfig) TempDir() string {
+	return c.tempDir
+}
+
+func (c minFreeConfig) TempMinFree() int64 {
+	return c.minFree
+}
+
+// fakeFreeSpace makes every filesystem appear to have the given number of
+// bytes free.
+func fakeFreeSpace(t *testing.T, free int64) {
+	oldFreeSpace := freeSpace
+	freeSpace = func(string) (int64, error) { return free, nil }
+	t.Cleanup(func() { freeSpace = oldFreeSpace })
+}
+
+func TestCheckFreeSpace(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := checkFreeSpace(dir, 1, 0); err != nil {
+		t.Errorf("unexpected error checking for 1 byte, err = %v", err)
+	}
+
+	err := checkFreeSpace(dir, 1<<62, 0)
+	if err == nil || !strings.HasPrefix(err.Error(), "insufficient free space in "+dir+": ") {
+		t.Errorf("did not get expected error, err = %v", err)
+	}
+
+	err = checkFreeSpace(filepath.Join(dir, "missing"), 1, 0)
+	if err == nil || !strings.HasPrefix(err.Error(), "can't check free space in ") {
+		t.Errorf("did not get expected error, err = %v", err)
+	}
+
+	// There's no check without a minimum.
+	if err := checkFreeSpace(filepath.Join(dir, "missing"), 0, 1<<62); err != nil {
+		t.Errorf("unexpected error without minimum, err = %v", err)
+	}
+}
+
+func TestNewClientMinFreeSpace(t *testing.T) {
+	fakeFreeSpace(t, 1000)
+
+	dir := t.TempDir()
+
+	if _, err := Package.NewClient(context.Background(), minFreeConfig{testConfig(t), dir, 1000}); err != nil {
+		t.Errorf("failed to create client, err = %v", err)
+	}
+
+	_, err := Package.NewClient(context.Background(), minFreeConfig{testConfig(t), dir, 2000})
+	expected := "insufficient free space in " + dir + ": 1000 bytes available, 2000 required"
+	if err == nil || err.Error() != expected {
+		t.Errorf("did not get expected error, err = %v", err)
+	}
+}
+
+func TestClientUploadSpooledMinFreeSpace(t *testing.T) {
+	oldMinSize := spoolMinSize
+	spoolMinSize = 4
+	t.Cleanup(func() { spoolMinSize = oldMinSize })
+
+	fakeFreeSpace(t, 100)
+
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"enough space", strings.Repeat("x", 50), ""},
+		{"not enough space", strings.Repeat("x", 51),
+			"insufficient free space in %s: 100 bytes available, 101 required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+			tempDir := t.TempDir()
+
+			iface, err := Package.NewClient(ctx, minFreeConfig{testConfig(t), tempDir, 50})
+			if err != nil {
+				t.Fatal("creating client:", err)
+			}
+			client := iface.(*client)
+			s3 := newFakeS3(t, client)
+
+			item := archiveItem(t, t.TempDir(), tt.content)
+
+			err = client.EnsureUploaded(ctx, []walk.SyncItem{item},
+				func(walk.SyncItem) error { return nil },
+				func(walk.SyncItem) error { return nil },
+				func(walk.SyncItem) error { return nil },
+			)
+
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("got unexpected error %v", err)
+				}
+				if s3.puts["abc123"] == nil {
+					t.Error("blob was not uploaded")
+				}
+				return
+			}
+
+			expected := strings.Replace(tt.err, "%s", tempDir, 1)
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Fatalf("did not get expected error, got %v", err)
+			}
+			if s3.puts["abc123"] != nil {
+				t.Error("blob was unexpectedly uploaded")
+			}
+		})
+	}
+}
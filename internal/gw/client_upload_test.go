@@ -3,11 +3,16 @@ package gw
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sync"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
 	"github.com/release-engineering/exodus-rsync/internal/log"
 	"github.com/release-engineering/exodus-rsync/internal/walk"
 	"github.com/stretchr/testify/assert"
@@ -298,3 +303,192 @@ func TestUploadWorkerDuplicates(t *testing.T) {
 	// This also produces the expected log message,
 	// "Item is already being uploaded", but is is impractical to test
 }
+
+func TestClientUploadEmptyFile(t *testing.T) {
+	client, s3 := newClientWithFakeS3(t)
+
+	chdirInTest(t, t.TempDir())
+	if err := os.WriteFile("empty", []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	// sha256 of empty content.
+	key := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	items := []walk.SyncItem{{SrcPath: "empty", Key: key}}
+
+	uploaded := 0
+	err := client.EnsureUploaded(ctx, items, func(item walk.SyncItem) error {
+		uploaded++
+		return nil
+	}, func(item walk.SyncItem) error {
+		t.Error("unexpectedly found existing item", item)
+		return nil
+	}, func(item walk.SyncItem) error {
+		t.Error("unexpectedly found duplicate item", item)
+		return nil
+	})
+
+	if err != nil {
+		t.Errorf("got unexpected error %v", err)
+	}
+
+	// It should have uploaded the empty file like any other.
+	if uploaded != 1 {
+		t.Errorf("expected 1 upload, got %d", uploaded)
+	}
+	if _, ok := s3.blobs[key]; !ok {
+		t.Errorf("empty blob was not uploaded, blobs: %v", s3.blobs)
+	}
+}
+
+// Config overriding the properties of uploaded blobs.
+type uploadPropsConfig struct {
+	conf.Config
+	tags         map[string]string
+	storageClass string
+	sse          string
+	kmsKeyID     string
+}
+
+func (c uploadPropsConfig) UploadTags() map[string]string {
+	return c.tags
+}
+
+func (c uploadPropsConfig) UploadStorageClass() string {
+	return c.storageClass
+}
+
+func (c uploadPropsConfig) UploadSSE() string {
+	return c.sse
+}
+
+func (c uploadPropsConfig) UploadSSEKMSKeyID() string {
+	return c.kmsKeyID
+}
+
+func TestClientUploadProperties(t *testing.T) {
+	tests := []struct {
+		name                 string
+		cfg                  uploadPropsConfig
+		expectedTagging      *string
+		expectedStorageClass *string
+	}{
+		{"unset", uploadPropsConfig{}, nil, nil},
+		{"tags and storage class",
+			uploadPropsConfig{
+				tags:         map[string]string{"lifecycle": "archive", "team": "rel eng"},
+				storageClass: "STANDARD_IA",
+			},
+			aws.String("lifecycle=archive&team=rel+eng"),
+			aws.String("STANDARD_IA"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Config = testConfig(t)
+
+			ctx := context.Background()
+			ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+			iface, err := Package.NewClient(ctx, tt.cfg)
+			if err != nil {
+				t.Fatal("creating client:", err)
+			}
+			client := iface.(*client)
+			s3 := newFakeS3(t, client)
+
+			chdirInTest(t, "../../test/data/srctrees/just-files")
+
+			items := []walk.SyncItem{{SrcPath: "hello-copy-one", Key: "abc123"}}
+
+			err = client.EnsureUploaded(ctx, items,
+				func(walk.SyncItem) error { return nil },
+				func(walk.SyncItem) error { return nil },
+				func(walk.SyncItem) error { return nil },
+			)
+			if err != nil {
+				t.Fatalf("got unexpected error %v", err)
+			}
+
+			input := s3.puts["abc123"]
+			if input == nil {
+				t.Fatal("blob was not uploaded")
+			}
+
+			assert.Equal(t, tt.expectedTagging, input.Tagging)
+			assert.Equal(t, tt.expectedStorageClass, input.StorageClass)
+		})
+	}
+}
+
+func TestClientUploadEncryption(t *testing.T) {
+	// A file large enough to be uploaded in multiple parts.
+	large := filepath.Join(t.TempDir(), "large")
+	if err := os.WriteFile(large, make([]byte, s3manager.MinUploadPartSize+1), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		cfg         uploadPropsConfig
+		expectedSSE *string
+		expectedKey *string
+	}{
+		{"unset", uploadPropsConfig{}, nil, nil},
+		{"AES256", uploadPropsConfig{sse: "AES256"}, aws.String("AES256"), nil},
+		{"KMS with key",
+			uploadPropsConfig{sse: "aws:kms", kmsKeyID: "arn:aws:kms:us-east-1:1234:key/abcd"},
+			aws.String("aws:kms"), aws.String("arn:aws:kms:us-east-1:1234:key/abcd")},
+		{"key only",
+			uploadPropsConfig{kmsKeyID: "alias/exodus"},
+			aws.String("aws:kms"), aws.String("alias/exodus")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Config = testConfig(t)
+
+			ctx := context.Background()
+			ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+			iface, err := Package.NewClient(ctx, tt.cfg)
+			if err != nil {
+				t.Fatal("creating client:", err)
+			}
+			client := iface.(*client)
+			s3 := newFakeS3(t, client)
+
+			items := []walk.SyncItem{
+				{SrcPath: "../../test/data/srctrees/just-files/hello-copy-one", Key: "small"},
+				{SrcPath: large, Key: "large"},
+			}
+
+			err = client.EnsureUploaded(ctx, items,
+				func(walk.SyncItem) error { return nil },
+				func(walk.SyncItem) error { return nil },
+				func(walk.SyncItem) error { return nil },
+			)
+			if err != nil {
+				t.Fatalf("got unexpected error %v", err)
+			}
+
+			put := s3.puts["small"]
+			if put == nil {
+				t.Fatal("small blob was not uploaded")
+			}
+			assert.Equal(t, tt.expectedSSE, put.ServerSideEncryption)
+			assert.Equal(t, tt.expectedKey, put.SSEKMSKeyId)
+
+			multipart := s3.multiparts["large"]
+			if multipart == nil {
+				t.Fatal("large blob was not uploaded in parts")
+			}
+			assert.Equal(t, tt.expectedSSE, multipart.ServerSideEncryption)
+			assert.Equal(t, tt.expectedKey, multipart.SSEKMSKeyId)
+		})
+	}
+}
@@ -47,3 +47,33 @@ func TestClientWhoAmI(t *testing.T) {
 		t.Errorf("unexpected whoami response, actual: %v, expected: %v", whoami, expected)
 	}
 }
+
+func TestClientWhoAmIUnauthorized(t *testing.T) {
+	cfg := testConfig(t)
+
+	clientIface, err := Package.NewClient(context.Background(), cfg)
+	if clientIface == nil {
+		t.Fatalf("failed to create client, err = %v", err)
+	}
+
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	gw := newFakeGw(t, clientIface.(*client))
+
+	gw.nextHTTPResponse = &http.Response{
+		Status:     "401 Unauthorized",
+		StatusCode: 401,
+		Body:       io.NopCloser(strings.NewReader("{\"detail\": \"Not authenticated\"}")),
+	}
+
+	whoami, err := clientIface.WhoAmI(ctx)
+
+	// It should have failed with the status from exodus-gw.
+	if err == nil || !strings.Contains(err.Error(), "401 Unauthorized") {
+		t.Errorf("unexpected error %v", err)
+	}
+	if len(whoami) != 0 {
+		t.Errorf("unexpected whoami response %v", whoami)
+	}
+}
@@ -0,0 +1,118 @@
+package gw
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+// serverClock estimates the time according to exodus-gw and S3 from the Date
+// header of their responses, so that decisions involving their timestamps
+// aren't thrown off by the local clock being wrong.
+//
+// All methods are safe to call on a nil clock, which uses the local time.
+type serverClock struct {
+	mu     sync.Mutex
+	offset time.Duration
+	warned bool
+
+	// Skew beyond which a warning is logged.
+	maxSkew time.Duration
+}
+
+func newServerClock(maxSkew time.Duration) *serverClock {
+	return &serverClock{maxSkew: maxSkew}
+}
+
+// now returns the current time according to the server, as best known.
+func (c *serverClock) now() time.Time {
+	if c == nil {
+		return time.Now()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return time.Now().Add(c.offset)
+}
+
+// observe updates the clock from the Date header of a response received at
+// local time received, warning once if the clocks differ by more than the
+// clock's maxSkew.
+func (c *serverClock) observe(ctx context.Context, header http.Header, received time.Time) {
+	if c == nil {
+		return
+	}
+
+	date, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		return
+	}
+
+	// Date has a resolution of a second, so less than that is no skew.
+	offset := date.Sub(received.Truncate(time.Second))
+	if offset > -time.Second && offset < time.Second {
+		offset = 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.offset = offset
+
+	if (offset > c.maxSkew || -offset > c.maxSkew) && !c.warned {
+		c.warned = true
+		log.FromContext(ctx).F(
+			"local", received.UTC().Format(time.RFC3339),
+			"server", date.UTC().Format(time.RFC3339),
+			"skew", offset.Round(time.Second).String(),
+		).Warn("Local clock differs from server clock, using server time")
+	}
+}
+
+// serverTime returns the time at which resp was sent according to its Date
+// header, or the local time if it hasn't one.
+func serverTime(resp *http.Response) time.Time {
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		return date
+	}
+	return time.Now()
+}
+
+// s3Handlers returns S3 handlers which update clock from each response,
+// sign requests at the time according to clock rather than the local time,
+// and retry requests which S3 refused because of skew.
+func (c *serverClock) s3Handlers() (observe, sign, retry request.NamedHandler) {
+	observe = request.NamedHandler{
+		Name: "exodus-rsync.clockObserveHandler",
+		Fn: func(r *request.Request) {
+			if r.HTTPResponse != nil {
+				c.observe(r.Context(), r.HTTPResponse.Header, time.Now())
+			}
+		},
+	}
+	sign = request.NamedHandler{
+		Name: v4.SignRequestHandler.Name,
+		Fn: func(r *request.Request) {
+			v4.SignSDKRequestWithCurrentTime(r, c.now)
+		},
+	}
+	retry = request.NamedHandler{
+		Name: "exodus-rsync.clockRetryHandler",
+		Fn: func(r *request.Request) {
+			if awsErr, ok := r.Error.(awserr.Error); ok && awsErr.Code() == "RequestTimeTooSkewed" {
+				// The clock was updated from this response, so a retry
+				// is signed at the right time.
+				r.Retryable = aws.Bool(true)
+			}
+		},
+	}
+	return
+}
@@ -0,0 +1,160 @@
+package gw
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/apex/log/handlers/memory"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+const skewWarning = "Local clock differs from server clock, using server time"
+
+// skewedGw returns a fake exodus-gw whose clock is ahead of the local clock
+// by skew.
+func skewedGw(t *testing.T, skew time.Duration) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(skew).UTC().Format(http.TimeFormat))
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func memoryContext() (context.Context, *memory.Handler) {
+	handler := memory.New()
+	logger := log.Logger{}
+	logger.Handler = handler
+	return log.NewContext(context.Background(), &logger), handler
+}
+
+func countEntries(handler *memory.Handler, msg string) int {
+	count := 0
+	for _, entry := range handler.Entries {
+		if entry.Message == msg {
+			count++
+		}
+	}
+	return count
+}
+
+func TestClientClockSkew(t *testing.T) {
+	tests := []struct {
+		name     string
+		skew     time.Duration
+		warnings int
+	}{
+		{"in sync", 0, 0},
+		{"within threshold", 30 * time.Second, 0},
+		{"behind", -2 * time.Hour, 1},
+		{"ahead", 10 * time.Minute, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gw := skewedGw(t, tt.skew)
+			ctx, logs := memoryContext()
+
+			clientIface, err := Package.NewClient(ctx, proxyConfig{Config: testConfig(t), gwURL: gw.URL})
+			if err != nil {
+				t.Fatalf("failed to create client, err = %v", err)
+			}
+			c := clientIface.(*client)
+
+			for i := 0; i < 3; i++ {
+				if _, err := c.WhoAmI(ctx); err != nil {
+					t.Fatalf("WhoAmI failed, err = %v", err)
+				}
+			}
+
+			// It should warn only once, however many responses are skewed.
+			if got := countEntries(logs, skewWarning); got != tt.warnings {
+				t.Errorf("got %d warnings, expected %d", got, tt.warnings)
+			}
+
+			// The server's time should be known.
+			if diff := time.Until(c.clock.now()) - tt.skew; diff < -2*time.Second || diff > 2*time.Second {
+				t.Errorf("server time is off by %v", diff)
+			}
+		})
+	}
+}
+
+func TestRateLimiterServerTime(t *testing.T) {
+	// The server's clock is 2 hours behind, so its times would otherwise
+	// all appear to have passed.
+	server := time.Now().Add(-2 * time.Hour)
+
+	header := http.Header{}
+	header.Set("Date", server.UTC().Format(http.TimeFormat))
+	header.Set("Retry-After", server.Add(20*time.Second).UTC().Format(http.TimeFormat))
+
+	l := newRateLimiter(time.Minute)
+	ctx, _ := memoryContext()
+	l.observe(ctx, &http.Response{StatusCode: http.StatusTooManyRequests, Header: header})
+
+	if delay := time.Until(l.until); delay < 18*time.Second || delay > 21*time.Second {
+		t.Errorf("paused for %v, expected 20s", delay)
+	}
+
+	header.Del("Retry-After")
+	header.Set("X-RateLimit-Remaining", "0")
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(server.Add(40*time.Second).Unix(), 10))
+
+	l = newRateLimiter(time.Minute)
+	l.observe(ctx, &http.Response{StatusCode: http.StatusOK, Header: header})
+
+	if delay := time.Until(l.until); delay < 38*time.Second || delay > 41*time.Second {
+		t.Errorf("paused for %v, expected 40s", delay)
+	}
+}
+
+func TestS3ClockHandlers(t *testing.T) {
+	clock := newServerClock(time.Minute)
+	ctx, _ := memoryContext()
+
+	skewed := time.Now().Add(-3 * time.Hour).UTC()
+	header := http.Header{}
+	header.Set("Date", skewed.Format(http.TimeFormat))
+	clock.observe(ctx, header, time.Now())
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String("https://s3.example.com"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+	svc := s3.New(sess)
+	_, sign, retry := clock.s3Handlers()
+	svc.Handlers.Sign.Swap(sign.Name, sign)
+
+	req, _ := svc.HeadObjectRequest(&s3.HeadObjectInput{Bucket: aws.String("bucket"), Key: aws.String("key")})
+	if err := req.Sign(); err != nil {
+		t.Fatalf("failed to sign, err = %v", err)
+	}
+
+	// The request should be signed at the time according to S3.
+	signed, err := time.Parse("20060102T150405Z", req.HTTPRequest.Header.Get("X-Amz-Date"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := signed.Sub(skewed); diff < -2*time.Second || diff > 2*time.Second {
+		t.Errorf("signed at %v, expected around %v", signed, skewed)
+	}
+
+	// A request refused for skew should be retried, as it's then signed at
+	// the right time.
+	req.Error = awserr.New("RequestTimeTooSkewed", "too skewed", nil)
+	retry.Fn(req)
+	if !aws.BoolValue(req.Retryable) {
+		t.Error("skewed request not retryable")
+	}
+}
@@ -0,0 +1,92 @@
+package gw
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+)
+
+// loadKeyPair returns the certificate and key used to authenticate with
+// exodus-gw, each read from the output of its configured command or else
+// from its configured file.
+func loadKeyPair(ctx context.Context, cfg conf.Config) (tls.Certificate, error) {
+	certCommand, certPath := cfg.GwCertCommand(), cfg.GwCert()
+	keyCommand, keyPath := cfg.GwKeyCommand(), cfg.GwKey()
+
+	certPEM, err := readPEM(ctx, "gwcertcommand", certCommand, certPath)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	keyPEM, err := readPEM(ctx, "gwkeycommand", keyCommand, keyPath)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+func (impl) LoadCert(ctx context.Context, cfg conf.Config) (*x509.Certificate, error) {
+	cert, err := loadKeyPair(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("can't load cert/key: %w", err)
+	}
+	if cert.Leaf != nil {
+		return cert.Leaf, nil
+	}
+	return x509.ParseCertificate(cert.Certificate[0])
+}
+
+func readPEM(ctx context.Context, setting string, command string, path string) ([]byte, error) {
+	if command == "" {
+		return os.ReadFile(path)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		// The command's own explanation of the failure is more useful than
+		// its exit status alone, but its output must not be included as it
+		// may hold secrets. Nor is the command itself, which may include
+		// tokens as arguments, so it's identified by its setting instead.
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s failed: %w: %s", setting, err, msg)
+		}
+		return nil, fmt.Errorf("%s failed: %w", setting, err)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// newTLSConfig returns the TLS configuration for connections to exodus-gw or
+// S3, presenting cert and restricted to any cipher suites in cfg.
+func newTLSConfig(cfg conf.Config, cert tls.Certificate) (*tls.Config, error) {
+	cipherSuites, err := conf.CipherSuiteIDs(cfg.TLSCipherSuites())
+	if err != nil {
+		return nil, fmt.Errorf("tlsciphersuites: %w", err)
+	}
+
+	out := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		CipherSuites: cipherSuites,
+	}
+
+	// The suites of TLS 1.3 can't be restricted, so it's only used if any
+	// suite may be.
+	if len(cipherSuites) > 0 {
+		out.MaxVersion = tls.VersionTLS12
+	}
+
+	return out, nil
+}
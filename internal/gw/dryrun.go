@@ -26,6 +26,18 @@ func (*dryRunPublish) AddItems(ctx context.Context, _ []ItemInput) error {
 	return ctx.Err()
 }
 
+func (*dryRunPublish) Items(ctx context.Context) ([]ItemInput, error) {
+	return nil, ctx.Err()
+}
+
 func (*dryRunPublish) Commit(ctx context.Context, _ string) error {
 	return ctx.Err()
 }
+
+func (*dryRunPublish) TaskID() string {
+	return ""
+}
+
+func (*dryRunPublish) State() string {
+	return ""
+}
@@ -0,0 +1,89 @@
+package gw
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+)
+
+type expireAfterKey struct{}
+
+// WithExpireAfter returns a context under which NewPublish asks exodus-gw to
+// expire the created publish once d has passed.
+func WithExpireAfter(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, expireAfterKey{}, d)
+}
+
+// ExpireAfterFromContext returns the duration from WithExpireAfter, or 0.
+func ExpireAfterFromContext(ctx context.Context) time.Duration {
+	if ctx == nil {
+		return 0
+	}
+	d, _ := ctx.Value(expireAfterKey{}).(time.Duration)
+	return d
+}
+
+// newPublishRequest is the body of a request creating a publish.
+type newPublishRequest struct {
+	// Seconds after which exodus-gw may clean up the publish.
+	TTL int64 `json:"ttl,omitempty"`
+
+	// Arbitrary labels of the publish, for tracking it.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// fields returns the names of the fields set in the request.
+func (r newPublishRequest) fields() []string {
+	var out []string
+	if r.TTL > 0 {
+		out = append(out, "ttl")
+	}
+	if len(r.Labels) > 0 {
+		out = append(out, "labels")
+	}
+	return out
+}
+
+// validationErrors is the body of a 422 response from exodus-gw, as FastAPI
+// reports a request which failed validation.
+type validationErrors struct {
+	Detail []struct {
+		// Location of the invalid input, e.g. ["body", "ttl"].
+		Loc []interface{} `json:"loc"`
+
+		Type string `json:"type"`
+	} `json:"detail"`
+}
+
+// isUnsupportedRequest returns true if exodus-gw refused a request only
+// because of unknown fields among the optional fields given, which means it
+// doesn't support them. Any other refusal is a real error.
+func isUnsupportedRequest(err error, fields []string) bool {
+	var httpErr *httpError
+	if !errors.As(err, &httpErr) || httpErr.status != http.StatusUnprocessableEntity {
+		return false
+	}
+
+	var body validationErrors
+	if json.Unmarshal(httpErr.body, &body) != nil || len(body.Detail) == 0 {
+		return false
+	}
+
+	for _, detail := range body.Detail {
+		// The type of an unknown field as of pydantic 1, and 2.
+		if detail.Type != "value_error.extra" && detail.Type != "extra_forbidden" {
+			return false
+		}
+		if len(detail.Loc) != 2 || detail.Loc[0] != "body" {
+			return false
+		}
+		field, _ := detail.Loc[1].(string)
+		if !slices.Contains(fields, field) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,18 @@
+package gw
+
+import "context"
+
+type forceUploadKey struct{}
+
+// WithForceUpload returns a context under which EnsureUploaded uploads the
+// blobs with the given keys within the bucket, including any 'blobkeyprefix',
+// even if they're already present, replacing them.
+func WithForceUpload(ctx context.Context, keys map[string]bool) context.Context {
+	return context.WithValue(ctx, forceUploadKey{}, keys)
+}
+
+// ForceUploadFromContext returns the keys from WithForceUpload, or nil.
+func ForceUploadFromContext(ctx context.Context) map[string]bool {
+	keys, _ := ctx.Value(forceUploadKey{}).(map[string]bool)
+	return keys
+}
@@ -2,6 +2,8 @@ package gw
 
 import (
 	"context"
+	"crypto/x509"
+	"errors"
 
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/release-engineering/exodus-rsync/internal/conf"
@@ -19,6 +21,15 @@ type Interface interface {
 	// NewDryRunClient creates and returns a new exodus-gw client in dry-run
 	// mode. This client replaces any write operations with stubs.
 	NewDryRunClient(context.Context, conf.Config) (Client, error)
+
+	// NewOfflineClient creates and returns a client which never contacts
+	// exodus-gw, instead writing the bodies of requests which would have been
+	// made into the given directory.
+	NewOfflineClient(ctx context.Context, cfg conf.Config, dir string) (Client, error)
+
+	// LoadCert returns the certificate with which a client created with the
+	// given configuration would authenticate with exodus-gw.
+	LoadCert(context.Context, conf.Config) (*x509.Certificate, error)
 }
 
 type impl struct{}
@@ -44,6 +55,9 @@ type Client interface {
 	//
 	// Returning from the callback with an error will cause EnsureUploaded to stop and
 	// return the same error.
+	//
+	// By default, EnsureUploaded stops at the first item which can't be uploaded.
+	// Under a context from WithKeepGoing, it handles every other item first.
 	EnsureUploaded(ctx context.Context, items []walk.SyncItem,
 		onUploaded func(walk.SyncItem) error,
 		onPresent func(walk.SyncItem) error,
@@ -56,6 +70,11 @@ type Client interface {
 	// GetPublish returns a handle to an existing publish object within exodus-gw.
 	GetPublish(ctx context.Context, id string) (Publish, error)
 
+	// ListPublishes returns the publishes within the exodus-gw environment
+	// which match the filter. Versions of exodus-gw without an endpoint for
+	// listing publishes result in an error.
+	ListPublishes(ctx context.Context, filter PublishFilter) ([]PublishInfo, error)
+
 	// WhoAmI returns raw authentication & authorization info for this exodus-gw client
 	// in the format provided by the "/whoami" endpoint.
 	//
@@ -72,6 +91,10 @@ type Publish interface {
 	// This may involve multiple requests to exodus-gw.
 	AddItems(context.Context, []ItemInput) error
 
+	// Items returns the items which have been added onto this publish so far,
+	// e.g. by an earlier attempt at the same sync.
+	Items(context.Context) ([]ItemInput, error)
+
 	// Commit will cause this publish object to become committed, making all of
 	// the included content available from the CDN.
 	//
@@ -81,7 +104,38 @@ type Publish interface {
 	//
 	// 'mode' is the desired commit mode (see exodus-gw docs). It can be empty
 	// to not request any particular mode.
+	//
+	// If exodus-gw refuses the commit because it conflicts with another publish,
+	// the returned error wraps ErrConflict.
 	Commit(ctx context.Context, mode string) error
+
+	// TaskID returns the ID of the exodus-gw task created by the last call
+	// to Commit, for correlating with operations within exodus-gw, or an
+	// empty string if there's no such task.
+	TaskID() string
+
+	// State returns the state of this publish as last returned by exodus-gw,
+	// e.g. "PENDING" or "COMMITTED", or an empty string if unknown.
+	State() string
+}
+
+// ErrConflict is wrapped by errors from Commit when exodus-gw reports that
+// the publish conflicts with another, such as one concurrently publishing
+// some of the same paths.
+var ErrConflict = errors.New("publish conflicts with another publish")
+
+// PublishFilter selects the publishes returned by ListPublishes.
+type PublishFilter struct {
+	// States of the publishes to include, e.g. "PENDING"; all if empty.
+	States []string
+}
+
+// PublishInfo describes a publish returned by ListPublishes.
+type PublishInfo struct {
+	ID      string `json:"id"`
+	Env     string `json:"env"`
+	State   string `json:"state"`
+	Updated string `json:"updated,omitempty"`
 }
 
 // Task represents a single task object within exodus-gw.
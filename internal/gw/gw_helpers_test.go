@@ -38,15 +38,30 @@ type fakePublish struct {
 	items      []ItemInput
 	lastCommit string
 
+	// The state reported for the publish, or "PENDING" if empty.
+	state string
+
 	// If publish is committed, then each time the task state is polled,
 	// we'll pop the next state from here.
 	taskStates []string
+
+	// Optionally, JSON fields reporting progress to go with each of
+	// taskStates, e.g. `"percent": 50`.
+	taskProgress []string
 }
 
-func (p *fakePublish) nextState() string {
+func (p *fakePublish) nextState() (string, string) {
 	out := p.taskStates[0]
 	p.taskStates = p.taskStates[1:]
-	return out
+
+	progress := ""
+	if len(p.taskProgress) > 0 {
+		if p.taskProgress[0] != "" {
+			progress = p.taskProgress[0] + ","
+		}
+		p.taskProgress = p.taskProgress[1:]
+	}
+	return out, progress
 }
 
 // Implement RoundTripper interface for fake handling of HTTP requests.
@@ -171,7 +186,7 @@ func (f *fakeGw) addPublishItems(r *http.Request, id string) *http.Response {
 	}
 
 	dec := json.NewDecoder(r.Body)
-	requestItems := make([]map[string]string, 0)
+	requestItems := make([]ItemInput, 0)
 
 	err := dec.Decode(&requestItems)
 	if err != nil {
@@ -181,9 +196,7 @@ func (f *fakeGw) addPublishItems(r *http.Request, id string) *http.Response {
 		return out
 	}
 
-	for _, item := range requestItems {
-		publish.items = append(publish.items, ItemInput{item["web_uri"], item["object_key"], item["content_type"], item["link_to"]})
-	}
+	publish.items = append(publish.items, requestItems...)
 
 	out.Status = "200 OK"
 	out.StatusCode = 200
@@ -211,16 +224,16 @@ func (f *fakeGw) commitPublish(id string, mode string) *http.Response {
 
 	publish.lastCommit = mode
 
-	state := publish.nextState()
+	state, progress := publish.nextState()
 	taskID := "task-" + publish.id
 	content := fmt.Sprintf(`{
 		"id": "%s",
 		"publish_id": "%s",
-		"state": "%s",
+		"state": "%s",%s
 		"links": {
 			"self": "/task/%[1]s"
 		}
-	}`, taskID, id, state)
+	}`, taskID, id, state, progress)
 
 	out.Status = "200 OK"
 	out.StatusCode = 200
@@ -246,15 +259,15 @@ func (f *fakeGw) getTask(id string) *http.Response {
 		return out
 	}
 
-	state := publish.nextState()
+	state, progress := publish.nextState()
 	content := fmt.Sprintf(`{
 		"id": "%s",
 		"publish_id": "%s",
-		"state": "%s",
+		"state": "%s",%s
 		"links": {
 			"self": "/task/%[1]s"
 		}
-	}`, id, publishID, state)
+	}`, id, publishID, state, progress)
 
 	out.Status = "200 OK"
 	out.StatusCode = 200
@@ -265,7 +278,7 @@ func (f *fakeGw) getTask(id string) *http.Response {
 func (f *fakeGw) getPublish(id string) *http.Response {
 	out := &http.Response{}
 
-	_, havePublish := f.publishes[id]
+	publish, havePublish := f.publishes[id]
 	if !havePublish {
 		f.t.Logf("requested nonexistent publish %s", id)
 		out.Status = "404 Not Found"
@@ -273,15 +286,26 @@ func (f *fakeGw) getPublish(id string) *http.Response {
 		return out
 	}
 
+	items, err := json.Marshal(append([]ItemInput{}, publish.items...))
+	if err != nil {
+		f.t.Fatal(err)
+	}
+
+	state := publish.state
+	if state == "" {
+		state = "PENDING"
+	}
+
 	content := fmt.Sprintf(`{
 		"id": "%s",
 		"env": "env",
+		"state": "%s",
 		"links": {
 			"self": "/env/publish/%[1]s",
 			"commit": "/env/publish/%[1]s/commit"
 		},
-		"items": []
-	}`, id)
+		"items": %[3]s
+	}`, id, state, items)
 
 	out.Status = "200 OK"
 	out.StatusCode = 200
@@ -0,0 +1,311 @@
+// Package gwtest provides an in-memory implementation of the exodus-gw client,
+// for testing code which publishes via exodus-gw without a real exodus-gw.
+//
+// Content is "uploaded" by recording the key of each item, and publishes
+// record the items added onto them and the modes in which they're committed.
+// Any step can be made to fail via Client.FailAt.
+package gwtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/uuid"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+// Step is a step of publishing at which a failure can be injected.
+type Step string
+
+// Steps at which a failure can be injected, named after the methods
+// implementing them.
+const (
+	StepEnsureUploaded Step = "EnsureUploaded"
+	StepNewPublish     Step = "NewPublish"
+	StepGetPublish     Step = "GetPublish"
+	StepListPublishes  Step = "ListPublishes"
+	StepAddItems       Step = "AddItems"
+	StepCommit         Step = "Commit"
+)
+
+// Client is an in-memory gw.Client. The zero value is ready to use, and it's
+// safe for concurrent use.
+type Client struct {
+	mu        sync.Mutex
+	blobs     map[string]string
+	publishes []*Publish
+	failures  map[Step]error
+}
+
+// Publish is an in-memory gw.Publish, as created by a Client.
+type Publish struct {
+	client    *Client
+	id        string
+	items     []gw.ItemInput
+	modes     []string
+	committed bool
+	taskID    string
+}
+
+// NewClient returns a client with no content and no publishes.
+func NewClient() *Client {
+	return &Client{}
+}
+
+// FailAt makes every later call implementing step fail with err, or no
+// longer fail if err is nil. An error wrapping gw.ErrConflict at StepCommit
+// simulates a conflicting publish.
+func (c *Client) FailAt(step Step, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.failures == nil {
+		c.failures = make(map[Step]error)
+	}
+	if err == nil {
+		delete(c.failures, step)
+	} else {
+		c.failures[step] = err
+	}
+}
+
+func (c *Client) failure(step Step) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.failures[step]
+}
+
+// AddBlob makes the content with the given key present, as if uploaded
+// earlier.
+func (c *Client) AddBlob(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.blobs == nil {
+		c.blobs = make(map[string]string)
+	}
+	c.blobs[key] = ""
+}
+
+// Blobs returns the content present, as a map from each key to the source
+// path from which it was uploaded, or "" if added by AddBlob.
+func (c *Client) Blobs() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := make(map[string]string, len(c.blobs))
+	for key, src := range c.blobs {
+		out[key] = src
+	}
+	return out
+}
+
+// Publishes returns every publish created by NewPublish, in order.
+func (c *Client) Publishes() []*Publish {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*Publish(nil), c.publishes...)
+}
+
+// EnsureUploaded records the content of each item as present, invoking the
+// callbacks as the real client does. Under a context from gw.WithNoUpload,
+// content which isn't already present fails to upload.
+func (c *Client) EnsureUploaded(ctx context.Context, items []walk.SyncItem,
+	onUploaded func(walk.SyncItem) error,
+	onPresent func(walk.SyncItem) error,
+	onDuplicate func(walk.SyncItem) error,
+) error {
+	if err := c.failure(StepEnsureUploaded); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Unfollowed symlinks have no content to upload.
+		if item.Key == "" && item.LinkTo != "" {
+			continue
+		}
+
+		c.mu.Lock()
+		_, present := c.blobs[item.Key]
+		if !present && !seen[item.Key] && !gw.NoUploadFromContext(ctx) {
+			if c.blobs == nil {
+				c.blobs = make(map[string]string)
+			}
+			c.blobs[item.Key] = item.SrcPath
+		}
+		c.mu.Unlock()
+
+		var err error
+		switch {
+		case seen[item.Key]:
+			err = onDuplicate(item)
+		case present:
+			err = onPresent(item)
+		case gw.NoUploadFromContext(ctx):
+			err = fmt.Errorf("blob %s of %s is not present, and uploads are disabled", item.Key, item.SrcPath)
+		default:
+			err = onUploaded(item)
+		}
+		if err != nil {
+			return err
+		}
+		seen[item.Key] = true
+	}
+
+	return nil
+}
+
+// NewPublish creates an empty publish with a new ID.
+func (c *Client) NewPublish(ctx context.Context) (gw.Publish, error) {
+	if err := c.failure(StepNewPublish); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	publish := &Publish{client: c, id: uuid.New()}
+	c.publishes = append(c.publishes, publish)
+	return publish, ctx.Err()
+}
+
+// GetPublish returns the publish created by NewPublish with the given ID.
+func (c *Client) GetPublish(ctx context.Context, id string) (gw.Publish, error) {
+	if err := c.failure(StepGetPublish); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, publish := range c.publishes {
+		if publish.id == id {
+			return publish, ctx.Err()
+		}
+	}
+	return nil, fmt.Errorf("publish not found: '%s'", id)
+}
+
+// ListPublishes returns the publishes, which are either PENDING or COMMITTED.
+func (c *Client) ListPublishes(ctx context.Context, filter gw.PublishFilter) ([]gw.PublishInfo, error) {
+	if err := c.failure(StepListPublishes); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := []gw.PublishInfo{}
+	for _, publish := range c.publishes {
+		info := gw.PublishInfo{ID: publish.id, Env: "test", State: publish.state()}
+		if len(filter.States) == 0 {
+			out = append(out, info)
+			continue
+		}
+		for _, state := range filter.States {
+			if strings.EqualFold(state, info.State) {
+				out = append(out, info)
+				break
+			}
+		}
+	}
+	return out, ctx.Err()
+}
+
+// WhoAmI returns fixed information.
+func (c *Client) WhoAmI(ctx context.Context) (map[string]interface{}, error) {
+	return map[string]interface{}{"user": "gwtest"}, ctx.Err()
+}
+
+func (p *Publish) state() string {
+	if p.committed {
+		return "COMMITTED"
+	}
+	return "PENDING"
+}
+
+// ID returns the ID of the publish.
+func (p *Publish) ID() string {
+	return p.id
+}
+
+// AddItems records items onto the publish. As with exodus-gw, a committed
+// publish can't be modified.
+func (p *Publish) AddItems(ctx context.Context, items []gw.ItemInput) error {
+	if err := p.client.failure(StepAddItems); err != nil {
+		return err
+	}
+
+	p.client.mu.Lock()
+	defer p.client.mu.Unlock()
+
+	if p.committed {
+		return fmt.Errorf("publish %s is committed, can't add items", p.id)
+	}
+	p.items = append(p.items, items...)
+	return ctx.Err()
+}
+
+// Items returns the items added onto the publish, in the order added.
+func (p *Publish) Items(ctx context.Context) ([]gw.ItemInput, error) {
+	p.client.mu.Lock()
+	defer p.client.mu.Unlock()
+	return append([]gw.ItemInput(nil), p.items...), ctx.Err()
+}
+
+// Commit records a commit of the publish in the given mode. Commits of any
+// mode other than "phase1" complete the publish.
+func (p *Publish) Commit(ctx context.Context, mode string) error {
+	if err := p.client.failure(StepCommit); err != nil {
+		return err
+	}
+
+	p.client.mu.Lock()
+	defer p.client.mu.Unlock()
+
+	p.modes = append(p.modes, mode)
+	p.taskID = uuid.New()
+	if mode != "phase1" {
+		p.committed = true
+	}
+	return ctx.Err()
+}
+
+// TaskID returns a new ID for each successful commit.
+func (p *Publish) TaskID() string {
+	p.client.mu.Lock()
+	defer p.client.mu.Unlock()
+	return p.taskID
+}
+
+// State returns "COMMITTED" once the publish has been completely committed,
+// and "PENDING" until then.
+func (p *Publish) State() string {
+	if p.Committed() {
+		return "COMMITTED"
+	}
+	return "PENDING"
+}
+
+// CommitModes returns the mode of each successful commit of the publish,
+// in order.
+func (p *Publish) CommitModes() []string {
+	p.client.mu.Lock()
+	defer p.client.mu.Unlock()
+	return append([]string(nil), p.modes...)
+}
+
+// Committed returns whether the publish has been completely committed.
+func (p *Publish) Committed() bool {
+	p.client.mu.Lock()
+	defer p.client.mu.Unlock()
+	return p.committed
+}
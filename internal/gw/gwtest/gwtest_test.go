@@ -0,0 +1,172 @@
+package gwtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/release-engineering/exodus-rsync/internal/gw"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+func TestPublishRecordsCalls(t *testing.T) {
+	ctx := context.Background()
+
+	var client gw.Client = NewClient()
+
+	publish, err := client.NewPublish(ctx)
+	if err != nil {
+		t.Fatalf("failed to create publish, err = %v", err)
+	}
+
+	items := []gw.ItemInput{
+		{WebURI: "/some/path", ObjectKey: "1234", ContentType: "text/plain"},
+		{WebURI: "/some/link", LinkTo: "/some/path"},
+	}
+	if err := publish.AddItems(ctx, items[:1]); err != nil {
+		t.Fatalf("failed to add items, err = %v", err)
+	}
+	if err := publish.AddItems(ctx, items[1:]); err != nil {
+		t.Fatalf("failed to add items, err = %v", err)
+	}
+
+	if err := publish.Commit(ctx, "phase1"); err != nil {
+		t.Fatalf("failed to commit, err = %v", err)
+	}
+	if err := publish.Commit(ctx, ""); err != nil {
+		t.Fatalf("failed to commit, err = %v", err)
+	}
+
+	recorded := client.(*Client).Publishes()
+	if len(recorded) != 1 || recorded[0].ID() != publish.ID() {
+		t.Fatalf("unexpected publishes %v", recorded)
+	}
+
+	got, err := recorded[0].Items(ctx)
+	if err != nil {
+		t.Fatalf("failed to get items, err = %v", err)
+	}
+	if !reflect.DeepEqual(got, items) {
+		t.Errorf("got items %v, want %v", got, items)
+	}
+
+	if modes := recorded[0].CommitModes(); !reflect.DeepEqual(modes, []string{"phase1", ""}) {
+		t.Errorf("unexpected commit modes %q", modes)
+	}
+	if !recorded[0].Committed() {
+		t.Error("publish not committed")
+	}
+
+	// A committed publish can't be modified.
+	if err := publish.AddItems(ctx, items); err == nil {
+		t.Error("unexpectedly added items to committed publish")
+	}
+
+	// It can be joined, and is listed as committed.
+	joined, err := client.GetPublish(ctx, publish.ID())
+	if err != nil || joined.ID() != publish.ID() {
+		t.Errorf("failed to get publish, err = %v", err)
+	}
+	infos, err := client.ListPublishes(ctx, gw.PublishFilter{States: []string{"committed"}})
+	if err != nil || len(infos) != 1 || infos[0].ID != publish.ID() {
+		t.Errorf("unexpected publishes %v, err = %v", infos, err)
+	}
+}
+
+func TestEnsureUploaded(t *testing.T) {
+	ctx := context.Background()
+
+	client := NewClient()
+	client.AddBlob("present")
+
+	items := []walk.SyncItem{
+		{SrcPath: "/src/a", Key: "new"},
+		{SrcPath: "/src/b", Key: "present"},
+		{SrcPath: "/src/c", Key: "new"},
+		{SrcPath: "/src/link", LinkTo: "a"},
+	}
+
+	var calls []string
+	record := func(kind string) func(walk.SyncItem) error {
+		return func(item walk.SyncItem) error {
+			calls = append(calls, kind+" "+item.SrcPath)
+			return nil
+		}
+	}
+
+	err := client.EnsureUploaded(ctx, items, record("uploaded"), record("present"), record("duplicate"))
+	if err != nil {
+		t.Fatalf("failed to upload, err = %v", err)
+	}
+
+	want := []string{"uploaded /src/a", "present /src/b", "duplicate /src/c"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("got calls %q, want %q", calls, want)
+	}
+
+	if blobs := client.Blobs(); !reflect.DeepEqual(blobs, map[string]string{"new": "/src/a", "present": ""}) {
+		t.Errorf("unexpected blobs %v", blobs)
+	}
+
+	// Without uploads, only present content is accepted.
+	err = client.EnsureUploaded(gw.WithNoUpload(ctx), []walk.SyncItem{{SrcPath: "/src/d", Key: "other"}},
+		record("uploaded"), record("present"), record("duplicate"))
+	if err == nil {
+		t.Error("unexpectedly uploaded with uploads disabled")
+	}
+}
+
+func TestFailAt(t *testing.T) {
+	ctx := context.Background()
+
+	client := NewClient()
+	publish, err := client.NewPublish(ctx)
+	if err != nil {
+		t.Fatalf("failed to create publish, err = %v", err)
+	}
+
+	client.FailAt(StepCommit, fmt.Errorf("%w: simulated", gw.ErrConflict))
+
+	err = publish.Commit(ctx, "")
+	if !errors.Is(err, gw.ErrConflict) {
+		t.Fatalf("did not get expected error, got: %v", err)
+	}
+
+	// A failed commit isn't recorded.
+	p := client.Publishes()[0]
+	if p.Committed() || len(p.CommitModes()) != 0 {
+		t.Errorf("failed commit recorded, modes %q", p.CommitModes())
+	}
+
+	// Other steps are unaffected.
+	if err := publish.AddItems(ctx, []gw.ItemInput{{WebURI: "/some/path", ObjectKey: "1234"}}); err != nil {
+		t.Errorf("failed to add items, err = %v", err)
+	}
+
+	// Once cleared, the commit succeeds.
+	client.FailAt(StepCommit, nil)
+	if err := publish.Commit(ctx, ""); err != nil {
+		t.Errorf("failed to commit, err = %v", err)
+	}
+	if !p.Committed() {
+		t.Error("publish not committed")
+	}
+
+	for _, step := range []Step{StepEnsureUploaded, StepNewPublish, StepGetPublish, StepListPublishes} {
+		client.FailAt(step, fmt.Errorf("simulated error"))
+	}
+	if err := client.EnsureUploaded(ctx, nil, nil, nil, nil); err == nil {
+		t.Error("EnsureUploaded unexpectedly succeeded")
+	}
+	if _, err := client.NewPublish(ctx); err == nil {
+		t.Error("NewPublish unexpectedly succeeded")
+	}
+	if _, err := client.GetPublish(ctx, publish.ID()); err == nil {
+		t.Error("GetPublish unexpectedly succeeded")
+	}
+	if _, err := client.ListPublishes(ctx, gw.PublishFilter{}); err == nil {
+		t.Error("ListPublishes unexpectedly succeeded")
+	}
+}
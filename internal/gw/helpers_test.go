@@ -36,12 +36,61 @@ func testConfig(t *testing.T) conf.Config {
 	cfg.EXPECT().GwPollInterval().AnyTimes().Return(1)
 	cfg.EXPECT().GwEnv().AnyTimes().Return("env")
 	cfg.EXPECT().GwBatchSize().AnyTimes().Return(3)
+	cfg.EXPECT().GwBatchSizeAuto().AnyTimes().Return(false)
+	cfg.EXPECT().GwBatchSizeMin().AnyTimes().Return(1)
+	cfg.EXPECT().GwBatchSizeMax().AnyTimes().Return(100)
+	cfg.EXPECT().GwMaxBatchBytes().AnyTimes().Return(0)
+	cfg.EXPECT().GwItemSchema().AnyTimes().Return(1)
+	cfg.EXPECT().GwNoReplace().AnyTimes().Return(false)
+	cfg.EXPECT().GwUseIdempotencyKeys().AnyTimes().Return(true)
+	cfg.EXPECT().GwRedirects().AnyTimes().Return("follow")
 	cfg.EXPECT().GwMaxAttempts().AnyTimes().Return(3)
 	// Fast backoff (1ms) to not slow down tests
 	cfg.EXPECT().GwMaxBackoff().AnyTimes().Return(1)
+	cfg.EXPECT().GwReadTimeout().AnyTimes().Return(0)
+	cfg.EXPECT().GwReadMaxAttempts().AnyTimes().Return(3)
+	cfg.EXPECT().GwWriteTimeout().AnyTimes().Return(0)
+	cfg.EXPECT().GwWriteMaxAttempts().AnyTimes().Return(3)
+	cfg.EXPECT().GwCommitTimeout().AnyTimes().Return(0)
+	cfg.EXPECT().GwCommitMaxAttempts().AnyTimes().Return(3)
+	cfg.EXPECT().GwRetryStatuses().AnyTimes().Return(nil)
+	cfg.EXPECT().GwPermanentStatuses().AnyTimes().Return(nil)
 	cfg.EXPECT().LogLevel().AnyTimes().Return("info")
 	cfg.EXPECT().Verbosity().AnyTimes().Return(3)
 	cfg.EXPECT().UploadThreads().AnyTimes().Return(4)
+	cfg.EXPECT().ConcurrencyMin().AnyTimes().Return(1)
+	cfg.EXPECT().ConcurrencyMax().AnyTimes().Return(0)
+	// Files are tried once, unless a test says otherwise.
+	cfg.EXPECT().UploadMaxAttempts().AnyTimes().Return(1)
+	cfg.EXPECT().UploadMaxBackoff().AnyTimes().Return(1)
+	cfg.EXPECT().URINormalize().AnyTimes().Return(nil)
+	cfg.EXPECT().URICaseInsensitive().AnyTimes().Return(false)
+	cfg.EXPECT().ContentRules().AnyTimes().Return(nil)
+	cfg.EXPECT().SkipEmptyFiles().AnyTimes().Return(false)
+	cfg.EXPECT().MIMESniff().AnyTimes().Return(false)
+	cfg.EXPECT().UploadTags().AnyTimes().Return(nil)
+	cfg.EXPECT().UploadStorageClass().AnyTimes().Return("")
+	cfg.EXPECT().UploadSSE().AnyTimes().Return("")
+	cfg.EXPECT().UploadSSEKMSKeyID().AnyTimes().Return("")
+	cfg.EXPECT().BlobKeyPrefix().AnyTimes().Return("")
+	cfg.EXPECT().TempDir().AnyTimes().Return(t.TempDir())
+	cfg.EXPECT().TempMinFree().AnyTimes().Return(int64(0))
+	cfg.EXPECT().S3Access().AnyTimes().Return("gw")
+	cfg.EXPECT().S3Bucket().AnyTimes().Return("env")
+	cfg.EXPECT().BlobCache().AnyTimes().Return("")
+	cfg.EXPECT().PublishState().AnyTimes().Return("")
+	cfg.EXPECT().AuditLog().AnyTimes().Return("")
+	cfg.EXPECT().BlobCacheMaxAge().AnyTimes().Return(604800)
+	cfg.EXPECT().CdnURL().AnyTimes().Return("")
+	cfg.EXPECT().GwProxy().AnyTimes().Return("")
+	cfg.EXPECT().S3Proxy().AnyTimes().Return("")
+	cfg.EXPECT().NoProxy().AnyTimes().Return(nil)
+	cfg.EXPECT().DialTimeout().AnyTimes().Return(30000)
+	cfg.EXPECT().MaxClockSkew().AnyTimes().Return(60000)
+	cfg.EXPECT().TLSHandshakeTimeout().AnyTimes().Return(10000)
+	cfg.EXPECT().TLSCipherSuites().AnyTimes().Return(nil)
+	cfg.EXPECT().GwCertCommand().AnyTimes().Return("")
+	cfg.EXPECT().GwKeyCommand().AnyTimes().Return("")
 
 	return cfg
 }
@@ -0,0 +1,102 @@
+package gw
+
+import (
+	"context"
+	"sync"
+)
+
+// inflightUploads tracks the blobs being uploaded by every EnsureUploaded of
+// a client, so that a blob needed by several calls at once, such as for
+// items found incrementally with the same content, is uploaded only once.
+//
+// All methods are safe to call on a nil tracker, which tracks nothing.
+type inflightUploads struct {
+	mu      sync.Mutex
+	uploads map[string]*inflightUpload
+}
+
+// inflightUpload is the handling of a single blob by one worker.
+type inflightUpload struct {
+	// Closed once the worker is done with the blob.
+	done chan struct{}
+
+	// Whether the blob was found or made present, and whether it was made
+	// present by uploading it, set before done is closed.
+	present  bool
+	uploaded bool
+
+	// The number of other workers waiting on this one, for tests.
+	waiting int
+}
+
+func newInflightUploads() *inflightUploads {
+	return &inflightUploads{uploads: make(map[string]*inflightUpload)}
+}
+
+// claim returns true if the caller is to handle the blob of key, in which
+// case it must later call finish, or false if another worker has just made
+// the blob present. While another worker is handling the blob, claim waits
+// for it to finish, and claims the blob if that worker failed.
+//
+// With force, as for a blob to be uploaded even if present, the blob is
+// claimed unless the other worker uploaded it, rather than finding it present.
+func (u *inflightUploads) claim(ctx context.Context, key string, force bool) (bool, error) {
+	if u == nil {
+		return true, nil
+	}
+
+	for {
+		u.mu.Lock()
+		upload, busy := u.uploads[key]
+		if !busy {
+			u.uploads[key] = &inflightUpload{done: make(chan struct{})}
+			u.mu.Unlock()
+			return true, nil
+		}
+		upload.waiting++
+		u.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-upload.done:
+		}
+
+		if upload.uploaded || (upload.present && !force) {
+			return false, nil
+		}
+	}
+}
+
+// finish records that the caller of a successful claim is done with the blob
+// of key, which is now present or not, having been uploaded or not, waking
+// any workers waiting on it.
+func (u *inflightUploads) finish(key string, present bool, uploaded bool) {
+	if u == nil {
+		return
+	}
+
+	u.mu.Lock()
+	upload := u.uploads[key]
+	delete(u.uploads, key)
+	u.mu.Unlock()
+
+	upload.present, upload.uploaded = present, uploaded
+	close(upload.done)
+}
+
+// waiting returns the number of workers waiting on the handling of the blob
+// of key.
+func (u *inflightUploads) waiting(key string) int {
+	if u == nil {
+		return 0
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if upload, busy := u.uploads[key]; busy {
+		return upload.waiting
+	}
+	return 0
+}
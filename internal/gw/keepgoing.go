@@ -0,0 +1,71 @@
+package gw
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+type keepGoingKey struct{}
+
+// WithKeepGoing returns a context under which EnsureUploaded continues past
+// items which can't be uploaded, returning an *UploadErrors describing them
+// once every other item has been handled.
+func WithKeepGoing(ctx context.Context) context.Context {
+	return context.WithValue(ctx, keepGoingKey{}, true)
+}
+
+// KeepGoingFromContext returns true if the context is from WithKeepGoing.
+func KeepGoingFromContext(ctx context.Context) bool {
+	keepGoing, _ := ctx.Value(keepGoingKey{}).(bool)
+	return keepGoing
+}
+
+type maxErrorsKey struct{}
+
+// WithMaxErrors returns a context under which EnsureUploaded, under a context
+// from WithKeepGoing, stops handling items as soon as n couldn't be uploaded,
+// on the theory that something more than those items is broken.
+func WithMaxErrors(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, maxErrorsKey{}, n)
+}
+
+// MaxErrorsFromContext returns the limit from WithMaxErrors, or 0 for none.
+func MaxErrorsFromContext(ctx context.Context) int {
+	n, _ := ctx.Value(maxErrorsKey{}).(int)
+	return n
+}
+
+// ItemError is the failure to upload a single item.
+type ItemError struct {
+	Item walk.SyncItem
+	Err  error
+}
+
+// UploadErrors is returned by EnsureUploaded under a context from
+// WithKeepGoing if any items couldn't be uploaded. Items sharing a blob with
+// one of these were passed to onDuplicate, but their content isn't present.
+type UploadErrors struct {
+	Failed []ItemError
+
+	// Whether uploads stopped on reaching the limit from WithMaxErrors,
+	// leaving other items unhandled.
+	Aborted bool
+}
+
+func (e *UploadErrors) Error() string {
+	if e.Aborted {
+		return fmt.Sprintf("%d item(s) could not be uploaded, stopped at the limit, first error: %v", len(e.Failed), e.Failed[0].Err)
+	}
+	return fmt.Sprintf("%d item(s) could not be uploaded, first error: %v", len(e.Failed), e.Failed[0].Err)
+}
+
+// Keys returns the keys of the blobs which couldn't be uploaded.
+func (e *UploadErrors) Keys() map[string]bool {
+	out := make(map[string]bool, len(e.Failed))
+	for _, failed := range e.Failed {
+		out[failed.Item.Key] = true
+	}
+	return out
+}
@@ -0,0 +1,21 @@
+package gw
+
+import "context"
+
+type labelsKey struct{}
+
+// WithLabels returns a context under which NewPublish asks exodus-gw to
+// label the created publish with labels, such as the build which produced
+// its content.
+func WithLabels(ctx context.Context, labels map[string]string) context.Context {
+	return context.WithValue(ctx, labelsKey{}, labels)
+}
+
+// LabelsFromContext returns the labels from WithLabels, or nil.
+func LabelsFromContext(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	labels, _ := ctx.Value(labelsKey{}).(map[string]string)
+	return labels
+}
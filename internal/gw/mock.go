@@ -6,6 +6,7 @@ package gw
 
 import (
 	context "context"
+	x509 "crypto/x509"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -36,6 +37,21 @@ func (m *MockInterface) EXPECT() *MockInterfaceMockRecorder {
 	return m.recorder
 }
 
+// LoadCert mocks base method.
+func (m *MockInterface) LoadCert(arg0 context.Context, arg1 conf.Config) (*x509.Certificate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadCert", arg0, arg1)
+	ret0, _ := ret[0].(*x509.Certificate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadCert indicates an expected call of LoadCert.
+func (mr *MockInterfaceMockRecorder) LoadCert(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadCert", reflect.TypeOf((*MockInterface)(nil).LoadCert), arg0, arg1)
+}
+
 // NewClient mocks base method.
 func (m *MockInterface) NewClient(arg0 context.Context, arg1 conf.Config) (Client, error) {
 	m.ctrl.T.Helper()
@@ -66,6 +82,21 @@ func (mr *MockInterfaceMockRecorder) NewDryRunClient(arg0, arg1 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewDryRunClient", reflect.TypeOf((*MockInterface)(nil).NewDryRunClient), arg0, arg1)
 }
 
+// NewOfflineClient mocks base method.
+func (m *MockInterface) NewOfflineClient(ctx context.Context, cfg conf.Config, dir string) (Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewOfflineClient", ctx, cfg, dir)
+	ret0, _ := ret[0].(Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewOfflineClient indicates an expected call of NewOfflineClient.
+func (mr *MockInterfaceMockRecorder) NewOfflineClient(ctx, cfg, dir interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewOfflineClient", reflect.TypeOf((*MockInterface)(nil).NewOfflineClient), ctx, cfg, dir)
+}
+
 // MockClient is a mock of Client interface.
 type MockClient struct {
 	ctrl     *gomock.Controller
@@ -118,6 +149,21 @@ func (mr *MockClientMockRecorder) GetPublish(ctx, id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPublish", reflect.TypeOf((*MockClient)(nil).GetPublish), ctx, id)
 }
 
+// ListPublishes mocks base method.
+func (m *MockClient) ListPublishes(ctx context.Context, filter PublishFilter) ([]PublishInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPublishes", ctx, filter)
+	ret0, _ := ret[0].([]PublishInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPublishes indicates an expected call of ListPublishes.
+func (mr *MockClientMockRecorder) ListPublishes(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPublishes", reflect.TypeOf((*MockClient)(nil).ListPublishes), ctx, filter)
+}
+
 // NewPublish mocks base method.
 func (m *MockClient) NewPublish(arg0 context.Context) (Publish, error) {
 	m.ctrl.T.Helper()
@@ -213,6 +259,49 @@ func (mr *MockPublishMockRecorder) ID() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ID", reflect.TypeOf((*MockPublish)(nil).ID))
 }
 
+// Items mocks base method.
+func (m *MockPublish) Items(arg0 context.Context) ([]ItemInput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Items", arg0)
+	ret0, _ := ret[0].([]ItemInput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Items indicates an expected call of Items.
+func (mr *MockPublishMockRecorder) Items(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Items", reflect.TypeOf((*MockPublish)(nil).Items), arg0)
+}
+
+// State mocks base method.
+func (m *MockPublish) State() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "State")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// State indicates an expected call of State.
+func (mr *MockPublishMockRecorder) State() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "State", reflect.TypeOf((*MockPublish)(nil).State))
+}
+
+// TaskID mocks base method.
+func (m *MockPublish) TaskID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TaskID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// TaskID indicates an expected call of TaskID.
+func (mr *MockPublishMockRecorder) TaskID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskID", reflect.TypeOf((*MockPublish)(nil).TaskID))
+}
+
 // MockTask is a mock of Task interface.
 type MockTask struct {
 	ctrl     *gomock.Controller
@@ -0,0 +1,17 @@
+package gw
+
+import "context"
+
+type noUploadKey struct{}
+
+// WithNoUpload returns a context under which EnsureUploaded uploads nothing,
+// instead failing for items whose blobs aren't already present.
+func WithNoUpload(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noUploadKey{}, true)
+}
+
+// NoUploadFromContext returns true if the context is from WithNoUpload.
+func NoUploadFromContext(ctx context.Context) bool {
+	noUpload, _ := ctx.Value(noUploadKey{}).(bool)
+	return noUpload
+}
@@ -0,0 +1,165 @@
+package gw
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+// offlineClient is a client which never contacts exodus-gw, instead writing
+// the content of requests it would have made into a directory.
+type offlineClient struct {
+	cfg conf.Config
+	dir string
+}
+
+type offlinePublish struct {
+	client *offlineClient
+	id     string
+	count  int
+}
+
+// OfflineUpload is a single entry in the list of planned uploads written
+// by the offline client.
+type OfflineUpload struct {
+	SrcPath   string `json:"src_path"`
+	ObjectKey string `json:"object_key"`
+}
+
+func (impl) NewOfflineClient(ctx context.Context, cfg conf.Config, dir string) (Client, error) {
+	if err := checkItemSchema(cfg.GwItemSchema()); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("can't create offline output directory: %w", err)
+	}
+
+	return &offlineClient{cfg: cfg, dir: dir}, nil
+}
+
+// writeJSON writes v to the named file within the output directory, encoded
+// exactly as it would be in a request body.
+func (c *offlineClient) writeJSON(ctx context.Context, name string, v interface{}) error {
+	buf := bytes.Buffer{}
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		return fmt.Errorf("encoding %s: %w", name, err)
+	}
+
+	path := filepath.Join(c.dir, name)
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).F("path", path).Info("Wrote offline request")
+	return nil
+}
+
+func (c *offlineClient) EnsureUploaded(ctx context.Context, items []walk.SyncItem,
+	onUploaded func(walk.SyncItem) error,
+	onPresent func(walk.SyncItem) error,
+	onDuplicate func(walk.SyncItem) error,
+) error {
+	// It's not possible to know which blobs are already present without
+	// contacting exodus-gw, so every unique blob is planned for upload,
+	// unless uploads are disabled, in which case every blob is assumed to
+	// be present.
+	uploads := []OfflineUpload{}
+	seen := make(map[string]bool)
+	noUpload := NoUploadFromContext(ctx)
+
+	for _, item := range items {
+		if item.LinkTo != "" {
+			continue
+		}
+
+		var err error
+		if seen[item.Key] {
+			err = onDuplicate(item)
+		} else if noUpload {
+			seen[item.Key] = true
+			err = onPresent(item)
+		} else {
+			seen[item.Key] = true
+			uploads = append(uploads, OfflineUpload{item.SrcPath, c.cfg.BlobKeyPrefix() + item.Key})
+			err = onUploaded(item)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.writeJSON(ctx, "uploads.json", uploads)
+}
+
+func (c *offlineClient) NewPublish(ctx context.Context) (Publish, error) {
+	return &offlinePublish{client: c}, ctx.Err()
+}
+
+func (c *offlineClient) GetPublish(ctx context.Context, id string) (Publish, error) {
+	return &offlinePublish{client: c, id: id}, ctx.Err()
+}
+
+func (c *offlineClient) ListPublishes(context.Context, PublishFilter) ([]PublishInfo, error) {
+	return nil, fmt.Errorf("exodus-gw is not contacted in offline mode")
+}
+
+func (c *offlineClient) WhoAmI(context.Context) (map[string]interface{}, error) {
+	return nil, fmt.Errorf("exodus-gw is not contacted in offline mode")
+}
+
+func (p *offlinePublish) ID() string {
+	// A new publish has no ID until it's created in exodus-gw.
+	return p.id
+}
+
+// AddItems writes each batch of items to a separate file, numbered in the order
+// the batches would have been sent.
+func (p *offlinePublish) AddItems(ctx context.Context, items []ItemInput) error {
+	cfg := p.client.cfg
+
+	for _, batch := range itemBatches(sortedItems(withKeyPrefix(items, cfg.BlobKeyPrefix())), cfg.GwBatchSize(), cfg.GwMaxBatchBytes(), cfg.GwItemSchema()) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		p.count++
+		body := schemaItems(batch, p.client.cfg.GwItemSchema())
+		if err := p.client.writeJSON(ctx, fmt.Sprintf("items-%04d.json", p.count), body); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Items returns no items, as those of an existing publish can't be known
+// without contacting exodus-gw.
+func (p *offlinePublish) Items(ctx context.Context) ([]ItemInput, error) {
+	return nil, ctx.Err()
+}
+
+func (p *offlinePublish) Commit(ctx context.Context, mode string) error {
+	// The commit request has no body, so record the mode which would have
+	// been passed as a query parameter.
+	return p.client.writeJSON(ctx, "commit.json", map[string]string{"commit_mode": mode})
+}
+
+// TaskID returns an empty string, as no task is created until the output is
+// applied to exodus-gw.
+func (p *offlinePublish) TaskID() string {
+	return ""
+}
+
+// State returns an empty string, as the state of the publish can't be known
+// without contacting exodus-gw.
+func (p *offlinePublish) State() string {
+	return ""
+}
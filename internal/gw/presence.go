@@ -0,0 +1,137 @@
+package gw
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/release-engineering/exodus-rsync/internal/atomicfile"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+// presenceCache remembers whether blobs are present in S3, so that each blob
+// is checked at most once per run.
+//
+// Blobs found to be present may also be recorded in a file, so that they
+// aren't checked again in later runs until the record is older than maxAge.
+// Blobs found to be absent are only remembered for the current run, as they
+// may be uploaded at any time.
+type presenceCache struct {
+	mu     sync.Mutex
+	path   string
+	maxAge time.Duration
+
+	// Whether each blob was present, as found during this run.
+	known map[string]bool
+
+	// When each blob was last found to be present, for the cache file.
+	seen map[string]time.Time
+}
+
+// presenceFile is the content of a cache file.
+type presenceFile struct {
+	Present map[string]time.Time `json:"present"`
+}
+
+// newPresenceCache returns a cache, loading any blobs recorded as present
+// in the file at path. A missing or unreadable file is treated as empty.
+func newPresenceCache(ctx context.Context, path string, maxAge time.Duration) *presenceCache {
+	out := &presenceCache{
+		path:   path,
+		maxAge: maxAge,
+		known:  make(map[string]bool),
+		seen:   make(map[string]time.Time),
+	}
+
+	if path == "" {
+		return out
+	}
+
+	for id, when := range readPresenceFile(ctx, path) {
+		if time.Since(when) < maxAge {
+			out.seen[id] = when
+			out.known[id] = true
+		}
+	}
+
+	return out
+}
+
+func readPresenceFile(ctx context.Context, path string) map[string]time.Time {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	file := presenceFile{}
+	if err == nil {
+		err = json.Unmarshal(content, &file)
+	}
+	if err != nil {
+		log.FromContext(ctx).F("path", path, "error", err).Warn("Ignoring unreadable blob cache")
+		return nil
+	}
+
+	return file.Present
+}
+
+// lookup returns whether the blob with the given ID is present, and whether
+// that's known at all. A nil cache knows nothing.
+func (c *presenceCache) lookup(id string) (bool, bool) {
+	if c == nil {
+		return false, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	have, ok := c.known[id]
+	return have, ok
+}
+
+// store records whether the blob with the given ID is present.
+func (c *presenceCache) store(id string, have bool) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.known[id] = have
+	if have {
+		c.seen[id] = time.Now()
+	}
+}
+
+// save writes the blobs known to be present into the cache file, if any,
+// merging them with blobs recorded by other runs since it was loaded.
+func (c *presenceCache) save(ctx context.Context) error {
+	if c == nil || c.path == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	file := presenceFile{Present: make(map[string]time.Time)}
+	for id, when := range readPresenceFile(ctx, c.path) {
+		if time.Since(when) < c.maxAge {
+			file.Present[id] = when
+		}
+	}
+	for id, when := range c.seen {
+		if when.After(file.Present[id]) {
+			file.Present[id] = when
+		}
+	}
+
+	content, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	return atomicfile.WriteFile(c.path, content)
+}
@@ -0,0 +1,20 @@
+package gw
+
+import "context"
+
+type presentBlobsKey struct{}
+
+// WithPresentBlobs returns a context under which EnsureUploaded takes the
+// blobs with the given keys within the bucket, including any 'blobkeyprefix',
+// to be present without checking, such as those recorded as uploaded by an
+// earlier run. Blobs from WithForceUpload are
+// uploaded regardless.
+func WithPresentBlobs(ctx context.Context, keys map[string]bool) context.Context {
+	return context.WithValue(ctx, presentBlobsKey{}, keys)
+}
+
+// PresentBlobsFromContext returns the keys from WithPresentBlobs, or nil.
+func PresentBlobsFromContext(ctx context.Context) map[string]bool {
+	keys, _ := ctx.Value(presentBlobsKey{}).(map[string]bool)
+	return keys
+}
@@ -2,10 +2,18 @@ package gw
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/release-engineering/exodus-rsync/internal/jsonutil"
 	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/progress"
+	"github.com/release-engineering/exodus-rsync/internal/uuid"
 )
 
 type publish struct {
@@ -16,6 +24,9 @@ type publish struct {
 		State string
 		Links map[string]string
 	}
+
+	// The task of the last commit.
+	taskID string
 }
 
 // ItemInput is a single item accepted for publish by the AddItems method.
@@ -24,6 +35,74 @@ type ItemInput struct {
 	ObjectKey   string `json:"object_key"`
 	ContentType string `json:"content_type"`
 	LinkTo      string `json:"link_to"`
+
+	// Omitted unless set, as only needed for precompressed content.
+	ContentEncoding string `json:"content_encoding,omitempty"`
+
+	// If set, exodus-gw refuses to replace an item already published at
+	// WebURI; only sent where exodus-gw supports it (see 'gwnoreplace').
+	NoReplace bool `json:"no_replace,omitempty"`
+
+	// Who may access the item, "public" or "restricted", where exodus-gw
+	// supports it; omitted unless set by --exodus-visibility.
+	Visibility string `json:"visibility,omitempty"`
+
+	// Cache-Control with which the item is served, where exodus-gw supports
+	// it (see 'gwcachecontrol'); omitted unless set by 'cachecontrolrules' or
+	// 'cachecontroldefault'.
+	CacheControl string `json:"cache_control,omitempty"`
+
+	// Extended attributes of the source file, by name, where exodus-gw
+	// stores them; omitted unless captured by --exodus-capture-xattrs.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// itemV2 is an item in version 2 of the item schema, which omits fields
+// not applying to the item.
+type itemV2 struct {
+	WebURI          string            `json:"web_uri"`
+	ObjectKey       string            `json:"object_key,omitempty"`
+	ContentType     string            `json:"content_type,omitempty"`
+	LinkTo          string            `json:"link_to,omitempty"`
+	ContentEncoding string            `json:"content_encoding,omitempty"`
+	NoReplace       bool              `json:"no_replace,omitempty"`
+	Visibility      string            `json:"visibility,omitempty"`
+	CacheControl    string            `json:"cache_control,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+}
+
+// maxItemSchema is the latest supported version of the item schema.
+const maxItemSchema = 2
+
+func checkItemSchema(version int) error {
+	if version < 1 || version > maxItemSchema {
+		return fmt.Errorf("gwitemschema: unsupported version %d", version)
+	}
+	return nil
+}
+
+// schemaItems returns items in the form of the given version of the item
+// schema, for encoding into a request to exodus-gw.
+func schemaItems(items []ItemInput, version int) interface{} {
+	if version < 2 {
+		return items
+	}
+
+	out := make([]itemV2, len(items))
+	for i, item := range items {
+		out[i] = itemV2(item)
+	}
+	return out
+}
+
+// idempotencyHeaders returns the headers of a request carrying a new
+// idempotency key, which is kept across retries of the request, or nil if
+// disabled by 'gwuseidempotencykeys'.
+func (c *client) idempotencyHeaders() map[string][]string {
+	if !c.cfg.GwUseIdempotencyKeys() {
+		return nil
+	}
+	return map[string][]string{"X-Idempotency-Key": {uuid.New()}}
 }
 
 // NewPublish creates and returns a new publish object within exodus-gw.
@@ -35,8 +114,30 @@ func (c *client) NewPublish(ctx context.Context) (Publish, error) {
 	url := "/" + c.cfg.GwEnv() + "/publish"
 
 	out := &publish{}
-	headers := map[string][]string{"X-Idempotency-Key": {}}
-	if err := c.doJSONRequest(ctx, "POST", url, nil, &out.raw, headers); err != nil {
+
+	var body interface{}
+	request := newPublishRequest{
+		TTL:    int64(ExpireAfterFromContext(ctx).Round(time.Second) / time.Second),
+		Labels: LabelsFromContext(ctx),
+	}
+	if request.TTL > 0 || len(request.Labels) > 0 {
+		body = request
+	}
+
+	// The request is retried like any other write, so it carries a key (if
+	// enabled) letting exodus-gw return the publish created by an earlier
+	// attempt whose response was lost, rather than leaving that publish
+	// orphaned.
+	err := c.doJSONRequest(ctx, opWrite, "POST", url, body, &out.raw, c.idempotencyHeaders())
+
+	// An expiration and labels are only hints, so a publish is still created
+	// if they can't be requested.
+	if err != nil && body != nil && isUnsupportedRequest(err, request.fields()) {
+		log.FromContext(ctx).F("dropped", request.fields(), "error", err).Warn(
+			"exodus-gw refused publish options, creating publish without them")
+		err = c.doJSONRequest(ctx, opWrite, "POST", url, nil, &out.raw, c.idempotencyHeaders())
+	}
+	if err != nil {
 		return out, err
 	}
 
@@ -66,10 +167,46 @@ func (c *client) GetPublish(ctx context.Context, id string) (Publish, error) {
 	out.raw.Links["commit"] = url + "/commit"
 
 	// Verify that the publish ID is valid before uploading blobs.
-	empty := struct{}{}
-	if err := c.doJSONRequest(ctx, "GET", url, nil, &empty, nil); err != nil {
+	got := struct {
+		State string `json:"state"`
+	}{}
+	if err := c.doJSONRequest(ctx, opRead, "GET", url, nil, &got, nil); err != nil {
 		return nil, err
 	}
+	out.raw.State = got.State
+
+	return out, nil
+}
+
+func (c *client) ListPublishes(ctx context.Context, filter PublishFilter) ([]PublishInfo, error) {
+	path := "/" + c.cfg.GwEnv() + "/publish"
+	if len(filter.States) > 0 {
+		path += "?" + url.Values{"state": filter.States}.Encode()
+	}
+
+	all := []PublishInfo{}
+	if err := c.doJSONRequest(ctx, opRead, "GET", path, nil, &all, nil); err != nil {
+		var httpErr *httpError
+		if errors.As(err, &httpErr) && (httpErr.status == http.StatusNotFound || httpErr.status == http.StatusMethodNotAllowed) {
+			err = fmt.Errorf("exodus-gw does not support listing publishes: %w", err)
+		}
+		return nil, err
+	}
+
+	// The states are also checked here, in case exodus-gw ignores the filter.
+	if len(filter.States) == 0 {
+		return all, nil
+	}
+
+	out := []PublishInfo{}
+	for _, info := range all {
+		for _, state := range filter.States {
+			if strings.EqualFold(info.State, state) {
+				out = append(out, info)
+				break
+			}
+		}
+	}
 
 	return out, nil
 }
@@ -78,6 +215,24 @@ func (p *publish) ID() string {
 	return p.raw.ID
 }
 
+// Items returns the items which have been added onto this publish so far,
+// as included in the publish object by exodus-gw.
+func (p *publish) Items(ctx context.Context) ([]ItemInput, error) {
+	url, ok := p.raw.Links["self"]
+	if !ok {
+		return nil, fmt.Errorf("publish object is missing 'self' link: %+v", p.raw)
+	}
+
+	out := struct {
+		Items []ItemInput `json:"items"`
+	}{}
+	if err := p.client.doJSONRequest(ctx, opRead, "GET", url, nil, &out, nil); err != nil {
+		return nil, err
+	}
+
+	return withoutKeyPrefix(out.Items, p.client.cfg.BlobKeyPrefix()), nil
+}
+
 // AddItems will add all of the specified items onto this publish.
 // This may involve multiple requests to exodus-gw.
 func (p *publish) AddItems(ctx context.Context, items []ItemInput) error {
@@ -87,42 +242,156 @@ func (p *publish) AddItems(ctx context.Context, items []ItemInput) error {
 		return fmt.Errorf("publish object is missing 'self' link: %+v", p.raw)
 	}
 
+	items = sortedItems(withKeyPrefix(items, c.cfg.BlobKeyPrefix()))
+
+	if c.cfg.GwBatchSizeAuto() {
+		return p.addItemsAuto(ctx, url, items)
+	}
+
 	logger := log.FromContext(ctx)
 
-	var batch []ItemInput
-	batchSize := p.client.cfg.GwBatchSize()
+	batches := itemBatches(items, c.cfg.GwBatchSize(), c.cfg.GwMaxBatchBytes(), c.cfg.GwItemSchema())
+
+	done := 0
+	for i, batch := range batches {
+		// Log the current batch number at Info to serve as a gradual progress indicator.
+		logger.F("currentBatch", i+1, "totalBatches", len(batches)).Info("Preparing the next batch of items")
 
-	nextBatch := func() {
-		if batchSize > len(items) {
-			batchSize = len(items)
+		if err := p.putItems(ctx, url, batch); err != nil {
+			return err
 		}
-		batch = items[0:batchSize]
-		items = items[batchSize:]
+
+		done += len(batch)
+		p.emitBatch(ctx, len(batch), done, len(items))
 	}
 
-	count := 0
-	empty := struct{}{}
-	totalBatches := math.Ceil(float64(len(items)) / float64(batchSize))
+	return nil
+}
 
-	for nextBatch(); len(batch) > 0; nextBatch() {
-		count++
-		// Log the current batch number at Info to serve as a gradual progress indicator.
-		logger.F("currentBatch", count, "totalBatches", totalBatches).Info("Preparing the next batch of items")
+// addItemsAuto is the counterpart of AddItems where the number of items in
+// each batch adapts to how quickly exodus-gw is handling the requests.
+func (p *publish) addItemsAuto(ctx context.Context, url string, items []ItemInput) error {
+	logger := log.FromContext(ctx)
 
-		for _, item := range batch {
-			logger.F("item", item, "url", url).Debug("Adding to publish object")
-		}
+	tuner := newBatchTuner(p.client.cfg)
+	total := len(items)
+
+	for len(items) > 0 {
+		cfg := p.client.cfg
+		batch := items[0:batchLen(items, tuner.size, cfg.GwMaxBatchBytes(), cfg.GwItemSchema())]
+
+		// The total number of batches isn't known up front, so the remaining
+		// number of items serves as the progress indicator instead.
+		logger.F("batchSize", len(batch), "remainingItems", len(items)).Info("Preparing the next batch of items")
+
+		start := batchClock()
+		err := p.putItems(ctx, url, batch)
+		elapsed := batchClock().Sub(start)
 
-		headers := map[string][]string{"X-Idempotency-Key": {}}
-		err := c.doJSONRequest(ctx, "PUT", url, batch, &empty, headers)
 		if err != nil {
-			return err
+			// Nothing to be gained by retrying if the caller has given up,
+			// or if a smaller batch would fail just the same.
+			if ctx.Err() != nil || !batchTooLarge(err) || !tuner.observe(elapsed, err) {
+				return err
+			}
+			logger.F("error", err, "batchSize", tuner.size).Warn("Retrying with a smaller batch of items")
+			continue
 		}
+
+		tuner.observe(elapsed, nil)
+		items = items[len(batch):]
+
+		p.emitBatch(ctx, len(batch), total-len(items), total)
 	}
 
 	return nil
 }
 
+// emitBatch reports progress after a batch of items has been added.
+func (p *publish) emitBatch(ctx context.Context, items, done, total int) {
+	progress.FromContext(ctx).Emit(progress.Event{
+		Type:    progress.TypeBatch,
+		Env:     p.client.cfg.GwEnv(),
+		Publish: p.ID(),
+		Items:   items,
+		Done:    done,
+		Total:   total,
+	})
+}
+
+// putItems adds a single batch of items onto this publish.
+func (p *publish) putItems(ctx context.Context, url string, batch []ItemInput) error {
+	logger := log.FromContext(ctx)
+
+	for _, item := range batch {
+		logger.F("item", item, "url", url).Debug("Adding to publish object")
+	}
+
+	empty := struct{}{}
+	body := schemaItems(batch, p.client.cfg.GwItemSchema())
+	return p.client.doJSONRequest(ctx, opWrite, "PUT", url, body, &empty, p.client.idempotencyHeaders())
+}
+
+// sortedItems returns a copy of items sorted by web URI.
+//
+// Items are sorted before being split into batches, so that the content of
+// each batch depends only on the items and the batch size, and not on the
+// order in which they were found or uploaded.
+func sortedItems(items []ItemInput) []ItemInput {
+	out := append([]ItemInput(nil), items...)
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].WebURI < out[j].WebURI
+	})
+	return out
+}
+
+// itemBatches splits items into batches of at most batchSize items, as sent
+// in each request to exodus-gw, and of at most maxBytes bytes once encoded
+// in the given version of the item schema, if maxBytes is set.
+func itemBatches(items []ItemInput, batchSize int, maxBytes int, version int) [][]ItemInput {
+	var out [][]ItemInput
+
+	if batchSize < 1 {
+		batchSize = len(items)
+	}
+
+	for len(items) > 0 {
+		n := batchLen(items, batchSize, maxBytes, version)
+		out = append(out, items[0:n])
+		items = items[n:]
+	}
+
+	return out
+}
+
+// batchLen returns the number of items from the start of items to send in
+// the next batch: at most batchSize, and as many as fit within maxBytes, if
+// set. A single item larger than maxBytes is still sent in a batch of its
+// own, for exodus-gw to accept or refuse.
+func batchLen(items []ItemInput, batchSize int, maxBytes int, version int) int {
+	n := min(batchSize, len(items))
+	if maxBytes < 1 {
+		return n
+	}
+
+	// The encoded items are wrapped in brackets, separated by commas and
+	// followed by a newline.
+	size := 2
+	for i := 0; i < n; i++ {
+		size += encodedItemSize(items[i], version) + 1
+		if size > maxBytes && i > 0 {
+			return i
+		}
+	}
+	return n
+}
+
+// encodedItemSize returns the size in bytes of item once encoded into a
+// request to exodus-gw.
+func encodedItemSize(item ItemInput, version int) int {
+	return len(jsonutil.MustMarshal(schemaItems([]ItemInput{item}, version))) - 2
+}
+
 // Commit will cause this publish object to become committed, making all of
 // the included content available from the CDN.
 //
@@ -147,13 +416,27 @@ func (p *publish) Commit(ctx context.Context, mode string) error {
 	}
 
 	task := task{}
-	headers := map[string][]string{"X-Idempotency-Key": {}}
-	if err := c.doJSONRequest(ctx, "POST", url, nil, &task.raw, headers); err != nil {
+	if err = c.doJSONRequest(ctx, opCommit, "POST", url, nil, &task.raw, c.idempotencyHeaders()); err != nil {
+		var httpErr *httpError
+		if errors.As(err, &httpErr) && httpErr.status == http.StatusConflict {
+			err = fmt.Errorf("%w: %v", ErrConflict, err)
+		}
 		return err
 	}
 
 	task.client = c
+	p.taskID = task.ID()
+
+	logger.F("publish", p.ID(), "task", task.ID()).Info("Awaiting commit task")
 
 	err = task.Await(ctx)
 	return err
 }
+
+func (p *publish) TaskID() string {
+	return p.taskID
+}
+
+func (p *publish) State() string {
+	return p.raw.State
+}
@@ -0,0 +1,148 @@
+package gw
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+// rateLimiter delays requests to exodus-gw as requested by the rate limit
+// headers of its responses, so that we slow down before exceeding a quota
+// rather than only after requests start failing.
+//
+// All methods are safe to call on a nil limiter, which never delays.
+type rateLimiter struct {
+	mu    sync.Mutex
+	until time.Time
+
+	// Longest delay we'll honor.
+	max time.Duration
+}
+
+func newRateLimiter(max time.Duration) *rateLimiter {
+	return &rateLimiter{max: max}
+}
+
+// wait blocks until any pause requested by an earlier response is over, or
+// ctx is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	delay := time.Until(l.until)
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// pause delays all requests made from now on by d, capped to the limiter's
+// max.
+func (l *rateLimiter) pause(ctx context.Context, d time.Duration) {
+	if l == nil || d <= 0 {
+		return
+	}
+	if d > l.max {
+		d = l.max
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	until := time.Now().Add(d)
+	if until.After(l.until) {
+		log.FromContext(ctx).F("delay", d).Debug("Pausing requests to exodus-gw for rate limit")
+		l.until = until
+	}
+}
+
+// observe updates the limiter from the headers of resp. Times in the headers
+// are relative to resp's own Date, in case the local clock is off.
+//
+// A 429 response pauses requests for the duration given by Retry-After.
+// Otherwise, if X-RateLimit-Remaining shows the quota is used up, requests
+// are paused until X-RateLimit-Reset; while it's low (a tenth or less of
+// X-RateLimit-Limit), requests are spread out over the rest of the window.
+func (l *rateLimiter) observe(ctx context.Context, resp *http.Response) {
+	if l == nil {
+		return
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		delay, ok := retryAfter(resp.Header, serverTime(resp))
+		if !ok {
+			// No hint from the server, but we must back off somehow.
+			delay = time.Second
+		}
+		l.pause(ctx, delay)
+		return
+	}
+
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, ok := rateLimitReset(resp.Header, serverTime(resp))
+	if !ok {
+		return
+	}
+
+	if remaining <= 0 {
+		l.pause(ctx, reset)
+		return
+	}
+
+	limit, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	if err == nil && remaining*10 <= limit {
+		l.pause(ctx, reset/time.Duration(remaining+1))
+	}
+}
+
+// retryAfter parses the Retry-After header, which holds either a number of
+// seconds or an HTTP date.
+func retryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return at.Sub(now), true
+	}
+	return 0, false
+}
+
+// rateLimitReset parses the X-RateLimit-Reset header, which holds either the
+// number of seconds until the quota resets or, as used by some services, the
+// Unix time at which it resets.
+func rateLimitReset(header http.Header, now time.Time) (time.Duration, bool) {
+	secs, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil || secs < 0 {
+		return 0, false
+	}
+
+	// Anything beyond a year is surely a Unix time.
+	if secs > 365*24*60*60 {
+		return time.Unix(secs, 0).Sub(now), true
+	}
+	return time.Duration(secs) * time.Second, true
+}
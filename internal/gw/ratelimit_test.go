@@ -0,0 +1,201 @@
+package gw
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+type rateLimitConfig struct {
+	proxyConfig
+	maxBackoff int
+}
+
+func (c rateLimitConfig) GwMaxBackoff() int {
+	return c.maxBackoff
+}
+
+// rateLimitedGw serves each request with the next of responses, recording the
+// time of each request.
+type rateLimitedGw struct {
+	mu        sync.Mutex
+	responses []func(w http.ResponseWriter)
+	times     []time.Time
+}
+
+func (g *rateLimitedGw) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.times = append(g.times, time.Now())
+	if len(g.responses) == 0 {
+		w.Write([]byte(`{}`))
+		return
+	}
+	respond := g.responses[0]
+	g.responses = g.responses[1:]
+	respond(w)
+}
+
+func rateLimitClient(t *testing.T, gw *rateLimitedGw) (context.Context, *client) {
+	server := httptest.NewServer(gw)
+	t.Cleanup(server.Close)
+
+	cfg := rateLimitConfig{
+		proxyConfig: proxyConfig{Config: testConfig(t), gwURL: server.URL},
+		maxBackoff:  5000,
+	}
+
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	clientIface, err := Package.NewClient(ctx, cfg)
+	if err != nil {
+		t.Fatalf("failed to create client, err = %v", err)
+	}
+	return ctx, clientIface.(*client)
+}
+
+func tooManyRequests(retryAfter string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+	}
+}
+
+func TestClientRateLimitRetryAfter(t *testing.T) {
+	gw := &rateLimitedGw{responses: []func(w http.ResponseWriter){tooManyRequests("1")}}
+	ctx, c := rateLimitClient(t, gw)
+
+	if _, err := c.WhoAmI(ctx); err != nil {
+		t.Fatalf("WhoAmI failed, err = %v", err)
+	}
+
+	// It should have retried after waiting as long as it was asked.
+	if len(gw.times) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(gw.times))
+	}
+	if delay := gw.times[1].Sub(gw.times[0]); delay < time.Second {
+		t.Errorf("retried after only %v", delay)
+	}
+}
+
+func TestClientRateLimitExhausted(t *testing.T) {
+	gw := &rateLimitedGw{responses: []func(w http.ResponseWriter){
+		tooManyRequests("0"), tooManyRequests("0"), tooManyRequests("0"), tooManyRequests("0"),
+	}}
+	ctx, c := rateLimitClient(t, gw)
+
+	_, err := c.WhoAmI(ctx)
+
+	// It should give up after the configured number of attempts.
+	var httpErr *httpError
+	if !errors.As(err, &httpErr) || httpErr.status != http.StatusTooManyRequests {
+		t.Errorf("did not get expected error, err = %v", err)
+	}
+	if len(gw.times) != 3 {
+		t.Errorf("expected 3 requests, got %d", len(gw.times))
+	}
+}
+
+func TestClientRateLimitRemaining(t *testing.T) {
+	gw := &rateLimitedGw{responses: []func(w http.ResponseWriter){
+		func(w http.ResponseWriter) {
+			w.Header().Set("X-RateLimit-Limit", "100")
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", "1")
+			w.Write([]byte(`{}`))
+		},
+	}}
+	ctx, c := rateLimitClient(t, gw)
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.WhoAmI(ctx); err != nil {
+			t.Fatalf("WhoAmI failed, err = %v", err)
+		}
+	}
+
+	// The first request succeeded but used up the quota, so the next one
+	// should have waited for the quota to reset.
+	if len(gw.times) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(gw.times))
+	}
+	if delay := gw.times[1].Sub(gw.times[0]); delay < time.Second {
+		t.Errorf("next request made after only %v", delay)
+	}
+}
+
+func TestClientRateLimitCancel(t *testing.T) {
+	gw := &rateLimitedGw{responses: []func(w http.ResponseWriter){tooManyRequests("60")}}
+	ctx, c := rateLimitClient(t, gw)
+
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+
+	// It should stop waiting once the context is done.
+	_, err := c.WhoAmI(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("did not get expected error, err = %v", err)
+	}
+}
+
+func TestRateLimiterObserve(t *testing.T) {
+	now := time.Now()
+	unixReset := strconv.FormatInt(now.Add(30*time.Second).Unix(), 10)
+
+	tests := []struct {
+		name    string
+		status  int
+		headers map[string]string
+		min     time.Duration
+		max     time.Duration
+	}{
+		{"no headers", 200, nil, 0, 0},
+		{"plenty remaining", 200, map[string]string{
+			"X-RateLimit-Limit": "100", "X-RateLimit-Remaining": "50", "X-RateLimit-Reset": "30"}, 0, 0},
+		{"low remaining", 200, map[string]string{
+			"X-RateLimit-Limit": "100", "X-RateLimit-Remaining": "9", "X-RateLimit-Reset": "30"},
+			2 * time.Second, 3 * time.Second},
+		{"none remaining", 200, map[string]string{
+			"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "30"}, 29 * time.Second, 30 * time.Second},
+		{"reset as unix time", 200, map[string]string{
+			"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": unixReset}, 28 * time.Second, 31 * time.Second},
+		{"retry after seconds", 429, map[string]string{"Retry-After": "20"}, 19 * time.Second, 20 * time.Second},
+		{"retry after date", 429, map[string]string{
+			"Retry-After": now.Add(20 * time.Second).UTC().Format(http.TimeFormat)}, 18 * time.Second, 21 * time.Second},
+		{"retry after missing", 429, nil, 900 * time.Millisecond, time.Second},
+		{"capped", 429, map[string]string{"Retry-After": "3600"}, 59 * time.Second, 60 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newRateLimiter(time.Minute)
+
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			for key, value := range tt.headers {
+				resp.Header.Set(key, value)
+			}
+
+			ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+			l.observe(ctx, resp)
+
+			delay := time.Duration(0)
+			if !l.until.IsZero() {
+				delay = time.Until(l.until)
+			}
+			if delay < tt.min || delay > tt.max {
+				t.Errorf("paused for %v, expected between %v and %v", delay, tt.min, tt.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package gw
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+// Maximum number of redirects followed for a single request to exodus-gw.
+const maxRedirects = 10
+
+// Headers of a request carried over to each redirect of it. net/http already
+// copies them, but only while the redirect stays on the same host; without
+// the idempotency key in particular, exodus-gw couldn't recognise a retry of
+// the redirected request.
+var redirectHeaders = []string{"X-Idempotency-Key", requestIDHeader}
+
+// newCheckRedirect returns the CheckRedirect of the client for requests to
+// exodus-gw, according to 'gwredirects'.
+func newCheckRedirect(mode string) (func(*http.Request, []*http.Request) error, error) {
+	switch mode {
+	case "follow":
+		return followRedirect, nil
+	case "none":
+		return refuseRedirect, nil
+	}
+	return nil, fmt.Errorf("gwredirects: unsupported value '%s'", mode)
+}
+
+// followRedirect follows a redirect only if the request keeps its method and
+// therefore its body: always on 307 and 308, while net/http turns a POST or
+// PUT into a GET without a body on 301, 302 and 303, which exodus-gw would
+// take as a different request entirely.
+func followRedirect(req *http.Request, via []*http.Request) error {
+	first := via[0]
+
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("%s %s: stopped after %d redirects", first.Method, first.URL, maxRedirects)
+	}
+	if req.Method != first.Method {
+		return fmt.Errorf("%s %s: refusing %s redirect to %s, which would change the method to %s",
+			first.Method, first.URL, req.Response.Status, req.URL, req.Method)
+	}
+	if first.URL.Scheme == "https" && req.URL.Scheme != "https" {
+		return fmt.Errorf("%s %s: refusing %s redirect to %s, which isn't https",
+			first.Method, first.URL, req.Response.Status, req.URL)
+	}
+
+	for _, key := range redirectHeaders {
+		if value := first.Header.Values(key); len(value) > 0 {
+			req.Header[key] = value
+		}
+	}
+
+	log.FromContext(req.Context()).F(
+		"method", req.Method, "status", req.Response.StatusCode, "from", via[len(via)-1].URL.String(), "to", req.URL.String(),
+	).Info("Following redirect from exodus-gw")
+	return nil
+}
+
+// refuseRedirect returns a redirect as the response, failing the request.
+func refuseRedirect(req *http.Request, via []*http.Request) error {
+	log.FromContext(req.Context()).F(
+		"method", req.Method, "status", req.Response.StatusCode, "from", via[len(via)-1].URL.String(), "to", req.URL.String(),
+	).Warn("Not following redirect from exodus-gw")
+	return http.ErrUseLastResponse
+}
@@ -0,0 +1,76 @@
+package gw
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/PuerkitoBio/rehttp"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+)
+
+// defaultRetryStatuses are the statuses of responses from exodus-gw which
+// are retried unless configured otherwise.
+var defaultRetryStatuses = []int{500, 502, 503, 504}
+
+// retryClassifier decides whether a response from exodus-gw with a given
+// status is worth retrying, as configured by gwretrystatuses and
+// gwpermanentstatuses.
+type retryClassifier struct {
+	retry     map[int]bool
+	permanent map[int]bool
+}
+
+func newRetryClassifier(cfg conf.Config) retryClassifier {
+	out := retryClassifier{retry: map[int]bool{}, permanent: map[int]bool{}}
+	for _, status := range defaultRetryStatuses {
+		out.retry[status] = true
+	}
+	for _, status := range cfg.GwRetryStatuses() {
+		out.retry[status] = true
+	}
+	for _, status := range cfg.GwPermanentStatuses() {
+		out.permanent[status] = true
+	}
+	return out
+}
+
+// retryable returns true if a response with the given status may be retried.
+func (r retryClassifier) retryable(status int) bool {
+	return r.retry[status] && !r.permanent[status]
+}
+
+// rateLimited returns true if a response with the given status was refused
+// by exodus-gw's rate limit and may be retried. Those are retried separately
+// from other statuses, so they're retryable unless configured as permanent.
+func (r retryClassifier) rateLimited(status int) bool {
+	return status == http.StatusTooManyRequests && !r.permanent[status]
+}
+
+// retryFn returns a function for the retry transport, retrying responses
+// other than those refused by the rate limit when retryable.
+func (r retryClassifier) retryFn() rehttp.RetryFn {
+	return func(attempt rehttp.Attempt) bool {
+		if attempt.Response == nil {
+			return false
+		}
+		status := attempt.Response.StatusCode
+		return status != http.StatusTooManyRequests && r.retryable(status)
+	}
+}
+
+func checkRetryStatuses(cfg conf.Config) error {
+	for _, setting := range []struct {
+		name     string
+		statuses []int
+	}{
+		{"gwretrystatuses", cfg.GwRetryStatuses()},
+		{"gwpermanentstatuses", cfg.GwPermanentStatuses()},
+	} {
+		for _, status := range setting.statuses {
+			if status < 100 || status > 599 {
+				return fmt.Errorf("%s: invalid HTTP status %d", setting.name, status)
+			}
+		}
+	}
+	return nil
+}
@@ -2,9 +2,14 @@ package gw
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
 	"sync"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/s3"
@@ -17,10 +22,19 @@ type fakeS3 struct {
 	mu sync.Mutex
 
 	blobs blobMap
+
+	// The most recent input for each uploaded blob.
+	puts map[string]*s3.PutObjectInput
+
+	// The most recent input creating a multipart upload, per blob.
+	multiparts map[string]*s3.CreateMultipartUploadInput
+
+	// The number of times each blob was checked for presence.
+	heads map[string]int
 }
 
 func newFakeS3(t *testing.T, client *client) *fakeS3 {
-	out := fakeS3{t: t, blobs: make(blobMap)}
+	out := fakeS3{t: t, blobs: make(blobMap), puts: make(map[string]*s3.PutObjectInput), multiparts: make(map[string]*s3.CreateMultipartUploadInput), heads: make(map[string]int)}
 
 	out.install(client)
 
@@ -38,6 +52,12 @@ func (f *fakeS3) install(client *client) {
 	// actually being sent or parsed.
 	handlers.Clear()
 
+	// Some operations have handlers of their own which look for errors in
+	// the response body, so each request gets a successful response.
+	handlers.Send.PushBack(func(r *request.Request) {
+		r.HTTPResponse = &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("<Result/>"))}
+	})
+
 	// This handler is invoked to unpack the response from AWS into
 	// an output object and is an appropriate place to hook in our own logic.
 	handlers.Unmarshal.PushBack(f.unmarshal)
@@ -49,6 +69,13 @@ func (f *fakeS3) unmarshal(r *request.Request) {
 		f.headObject(r, v)
 	case *s3.PutObjectInput:
 		f.putObject(r, v)
+	case *s3.CreateMultipartUploadInput:
+		f.createMultipartUpload(r, v)
+	case *s3.UploadPartInput:
+		// Parts are accepted without being recorded.
+		r.Data.(*s3.UploadPartOutput).ETag = aws.String(fmt.Sprintf("etag-%d", *v.PartNumber))
+	case *s3.CompleteMultipartUploadInput:
+		// The blob was recorded when the upload was created.
 	default:
 		r.Error = awserr.New("NotImplemented", "not supported by fake S3", nil)
 	}
@@ -58,6 +85,8 @@ func (f *fakeS3) headObject(r *request.Request, input *s3.HeadObjectInput) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
+	f.heads[*input.Key]++
+
 	errors, haveBlob := f.blobs[*input.Key]
 	if !haveBlob {
 		r.Error = awserr.New("NotFound", "object not found", nil)
@@ -82,6 +111,8 @@ func (f *fakeS3) putObject(r *request.Request, input *s3.PutObjectInput) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
+	f.puts[*input.Key] = input
+
 	errors, haveBlob := f.blobs[*input.Key]
 	if !haveBlob {
 		// Mark that we have this blob, and don't return any errors for it.
@@ -102,6 +133,16 @@ func (f *fakeS3) putObject(r *request.Request, input *s3.PutObjectInput) {
 	}
 }
 
+func (f *fakeS3) createMultipartUpload(r *request.Request, input *s3.CreateMultipartUploadInput) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.multiparts[*input.Key] = input
+	f.blobs[*input.Key] = make([]error, 0)
+
+	r.Data.(*s3.CreateMultipartUploadOutput).UploadId = aws.String("upload-" + *input.Key)
+}
+
 func newClientWithFakeS3(t *testing.T) (*client, *fakeS3) {
 	cfg := testConfig(t)
 
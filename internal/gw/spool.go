@@ -0,0 +1,75 @@
+package gw
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// Content of archive entries larger than this is spooled to disk before
+// upload. The S3 uploader can't seek within such content, so it would
+// otherwise buffer every part of a multipart upload in memory.
+var spoolMinSize int64 = s3manager.DefaultUploadPartSize
+
+// spool copies the content of r into a new temporary file within dir,
+// returning the file positioned at its start.
+//
+// The file is removed from dir as soon as it's created, so the space it
+// uses is released once it's closed, whether exodus-rsync exits normally,
+// fails or is killed by a signal.
+func spool(dir string, r io.Reader) (*os.File, error) {
+	file, err := os.CreateTemp(dir, "exodus-rsync-spool-*")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Remove(file.Name()); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// freeSpace returns the number of bytes available to unprivileged users on
+// the filesystem holding dir.
+var freeSpace = func(dir string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
+
+// checkFreeSpace returns an error if writing size bytes into dir would leave
+// less than min bytes free on its filesystem. A min of 0 disables the check.
+func checkFreeSpace(dir string, min int64, size int64) error {
+	if min <= 0 {
+		return nil
+	}
+
+	free, err := freeSpace(dir)
+	if err != nil {
+		return fmt.Errorf("can't check free space in %s: %w", dir, err)
+	}
+
+	if free-size < min {
+		return fmt.Errorf("insufficient free space in %s: %d bytes available, %d required",
+			dir, free, min+size)
+	}
+
+	return nil
+}
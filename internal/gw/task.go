@@ -15,9 +15,50 @@ type task struct {
 		PublishID string
 		State     string
 		Links     map[string]string
+		taskProgress
 	}
 }
 
+// taskProgress is how far a task has got, for versions of exodus-gw which
+// report it. Any of the fields may be missing.
+type taskProgress struct {
+	Percent   *float64 `json:"percent"`
+	Processed *int64   `json:"processed"`
+	Total     *int64   `json:"total"`
+}
+
+// percent returns the percentage of the task done, and whether it's known.
+func (p taskProgress) percent() (float64, bool) {
+	if p.Percent != nil {
+		return *p.Percent, true
+	}
+	if p.Processed != nil && p.Total != nil && *p.Total > 0 {
+		return float64(*p.Processed) * 100 / float64(*p.Total), true
+	}
+	return 0, false
+}
+
+// logProgress logs how far the task has got, if exodus-gw says.
+func (t *task) logProgress(ctx context.Context) {
+	percent, havePercent := t.raw.percent()
+	if !havePercent && t.raw.Processed == nil {
+		return
+	}
+
+	fields := []interface{}{"task", t.raw.ID, "state", t.raw.State}
+	if havePercent {
+		fields = append(fields, "percent", fmt.Sprintf("%.1f", percent))
+	}
+	if t.raw.Processed != nil {
+		fields = append(fields, "processed", *t.raw.Processed)
+	}
+	if t.raw.Total != nil {
+		fields = append(fields, "total", *t.raw.Total)
+	}
+
+	log.FromContext(ctx).F(fields...).Info("Task in progress")
+}
+
 func (t *task) refresh(ctx context.Context) error {
 	logger := log.FromContext(ctx)
 
@@ -28,7 +69,10 @@ func (t *task) refresh(ctx context.Context) error {
 
 	logger.F("url", url).Debug("polling task")
 
-	return t.client.doJSONRequest(ctx, "GET", url, nil, &t.raw, nil)
+	// Progress missing from the response isn't known any more.
+	t.raw.taskProgress = taskProgress{}
+
+	return t.client.doJSONRequest(ctx, opRead, "GET", url, nil, &t.raw, nil)
 }
 
 func (t *task) ID() string {
@@ -52,6 +96,8 @@ func (t *task) Await(ctx context.Context) error {
 		}
 
 		// Not in a terminal state - query it again soon
+		t.logProgress(ctx)
+
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
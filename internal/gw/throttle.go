@@ -0,0 +1,267 @@
+package gw
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+// uploadLimiter bounds the number of uploads in progress at once, reducing
+// the bound while S3 is throttling requests and ramping it back up to the
+// configured number of upload threads afterward.
+//
+// If created with onError, the bound is also reduced whenever an upload or
+// a request to exodus-gw fails, in the same way.
+//
+// All methods are safe to call on a nil limiter, which imposes no bound.
+type uploadLimiter struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	min    int
+	max    int
+	limit  int
+	active int
+
+	onError bool
+
+	// Uploads completed since the limit last changed.
+	successes int
+}
+
+func newUploadLimiter(max int) *uploadLimiter {
+	if max < 1 {
+		max = 1
+	}
+	out := &uploadLimiter{min: 1, max: max, limit: max}
+	out.cond = sync.NewCond(&out.mu)
+	return out
+}
+
+// newClientLimiter returns the limiter shared by every upload and request of
+// a client with cfg. The limit starts at the number of upload threads, and
+// stays between concurrencymin and concurrencymax.
+func newClientLimiter(cfg conf.Config, onError bool) *uploadLimiter {
+	threads := max(cfg.UploadThreads(), 1)
+
+	out := newUploadLimiter(threads)
+	if cfg.ConcurrencyMax() > 0 {
+		out.max = cfg.ConcurrencyMax()
+	}
+	out.min = min(max(cfg.ConcurrencyMin(), 1), out.max)
+	out.limit = min(max(threads, out.min), out.max)
+	out.onError = onError
+
+	return out
+}
+
+type throttleOnErrorKey struct{}
+
+// WithThrottleOnError returns a context under which NewClient creates a
+// client reducing the concurrency of its uploads whenever uploads or
+// requests to exodus-gw fail, and ramping it back up as they succeed.
+func WithThrottleOnError(ctx context.Context) context.Context {
+	return context.WithValue(ctx, throttleOnErrorKey{}, true)
+}
+
+// ThrottleOnErrorFromContext returns true if the context is from
+// WithThrottleOnError.
+func ThrottleOnErrorFromContext(ctx context.Context) bool {
+	throttle, _ := ctx.Value(throttleOnErrorKey{}).(bool)
+	return throttle
+}
+
+type uploadLimiterKey struct{}
+
+func withUploadLimiter(ctx context.Context, l *uploadLimiter) context.Context {
+	return context.WithValue(ctx, uploadLimiterKey{}, l)
+}
+
+func uploadLimiterFromContext(ctx context.Context) *uploadLimiter {
+	l, _ := ctx.Value(uploadLimiterKey{}).(*uploadLimiter)
+	return l
+}
+
+// acquire blocks until an upload may begin, or ctx is done.
+func (l *uploadLimiter) acquire(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.cond.Broadcast()
+	})
+	defer stop()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for l.active >= l.limit {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		l.cond.Wait()
+	}
+
+	l.active++
+	return nil
+}
+
+// release marks the end of an upload, which succeeded if ok is true.
+//
+// It returns the new limit, and whether the limit was reduced by a failure.
+func (l *uploadLimiter) release(ok bool) (int, bool) {
+	if l == nil {
+		return 0, false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.active--
+	defer l.cond.Broadcast()
+
+	return l.outcome(ok)
+}
+
+// observe notes the outcome of a request made without acquiring the
+// limiter, such as a request to exodus-gw, as release does.
+func (l *uploadLimiter) observe(ok bool) (int, bool) {
+	if l == nil {
+		return 0, false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.outcome(ok)
+}
+
+// outcome adjusts the limit after a request. The caller holds l.mu.
+func (l *uploadLimiter) outcome(ok bool) (int, bool) {
+	if !ok {
+		if !l.onError {
+			return l.limit, false
+		}
+		return l.reduce(), true
+	}
+
+	// Ramp up by one upload after a full limit's worth of uploads have
+	// succeeded without throttling.
+	if l.limit < l.max {
+		l.successes++
+		if l.successes >= l.limit {
+			l.limit++
+			l.successes = 0
+		}
+	}
+
+	return l.limit, false
+}
+
+// throttled halves the number of concurrent uploads, returning the new limit.
+func (l *uploadLimiter) throttled() int {
+	if l == nil {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.reduce()
+}
+
+// reduce halves the limit, down to the minimum. The caller holds l.mu.
+func (l *uploadLimiter) reduce() int {
+	l.limit = max(l.limit/2, l.min)
+	l.successes = 0
+
+	return l.limit
+}
+
+// current returns the number of uploads currently allowed at once.
+func (l *uploadLimiter) current() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// observeRequest adjusts the client's limiter after a request to exodus-gw,
+// which failed with err if not nil. Only failures suggesting exodus-gw is
+// struggling count: a response such as 404 says nothing about its load.
+func (c *client) observeRequest(ctx context.Context, err error) {
+	if err != nil && !isServerError(ctx, err) {
+		return
+	}
+
+	if limit, reduced := c.limiter.observe(err == nil); reduced {
+		log.FromContext(ctx).F("error", err, "limit", limit).Warn(
+			"Request to exodus-gw failed, reducing upload concurrency")
+	}
+}
+
+// isServerError returns true if err, from a request to exodus-gw, is an
+// error response from the server or a failure to get any response.
+func isServerError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var httpErr *httpError
+	if errors.As(err, &httpErr) {
+		return httpErr.status >= 500 || httpErr.status == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// isSlowDown returns true if S3 responded to r by asking for requests to
+// slow down.
+//
+// S3 does so only via 503 SlowDown. The SDK treats other statuses such as
+// 502 and 504 as throttling, too, but those come from proxies and load
+// balancers along the way and say nothing about the rate of requests to S3,
+// so they don't reduce concurrency; they're still retried as usual.
+func isSlowDown(r *request.Request) bool {
+	var awsErr awserr.Error
+	if errors.As(r.Error, &awsErr) && awsErr.Code() == "SlowDown" {
+		return true
+	}
+	return r.HTTPResponse != nil && r.HTTPResponse.StatusCode == http.StatusServiceUnavailable
+}
+
+// throttleHandler is an S3 retry handler which reduces upload concurrency
+// whenever S3 responds to a request by throttling it.
+var throttleHandler = request.NamedHandler{
+	Name: "exodus-rsync.throttleHandler",
+	Fn: func(r *request.Request) {
+		// The SDK doesn't know the SlowDown code. Listing it as a throttle
+		// code makes the SDK's retryer retry SlowDown with its throttling
+		// delays, even if the status of the response wasn't seen, e.g.
+		// when the error is raised by a handler.
+		if !slices.Contains(r.ThrottleErrorCodes, "SlowDown") {
+			r.ThrottleErrorCodes = append(r.ThrottleErrorCodes, "SlowDown")
+		}
+
+		if !isSlowDown(r) {
+			return
+		}
+
+		ctx := r.Context()
+		entry := log.FromContext(ctx).F("operation", r.Operation.Name, "error", r.Error)
+
+		if l := uploadLimiterFromContext(ctx); l != nil {
+			entry = entry.WithField("limit", l.throttled())
+		}
+
+		entry.Warn("S3 is throttling requests, reducing upload concurrency")
+	},
+}
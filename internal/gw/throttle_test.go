@@ -0,0 +1,413 @@
+package gw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsclient "github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/corehandlers"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/conf"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
+)
+
+// enableRetries restores the SDK's retry handling, removed when installing
+// a fakeS3, with delays short enough not to slow down tests.
+func enableRetries(c *client) {
+	handlers := &c.s3.Client.Handlers
+
+	// fakeS3 fails requests without any response, which the SDK's retry
+	// logic doesn't expect.
+	handlers.Retry.PushBack(func(r *request.Request) {
+		if r.HTTPResponse == nil {
+			r.HTTPResponse = &http.Response{Header: http.Header{}}
+		}
+	})
+	handlers.Retry.PushBackNamed(throttleHandler)
+	handlers.AfterRetry.PushBackNamed(corehandlers.AfterRetryHandler)
+
+	c.s3.Client.Retryer = awsclient.DefaultRetryer{
+		NumMaxRetries:    3,
+		MinRetryDelay:    time.Millisecond,
+		MaxRetryDelay:    time.Millisecond,
+		MinThrottleDelay: time.Millisecond,
+		MaxThrottleDelay: time.Millisecond,
+	}
+}
+
+func slowDown() error {
+	return awserr.New("SlowDown", "Please reduce your request rate.", nil)
+}
+
+func notFound() error {
+	return awserr.New("NotFound", "object not found", nil)
+}
+
+func TestClientUploadSlowDown(t *testing.T) {
+	c, s3 := newClientWithFakeS3(t)
+	enableRetries(c)
+
+	chdirInTest(t, "../../test/data/srctrees/just-files")
+
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	limiter := newUploadLimiter(4)
+	ctx = withUploadLimiter(ctx, limiter)
+
+	item := walk.SyncItem{SrcPath: "hello-copy-one", Key: "abc123"}
+
+	// Both HEAD and PUT are throttled once before succeeding.
+	s3.blobs["abc123"] = []error{slowDown(), notFound(), slowDown()}
+
+	have, err := c.haveBlob(ctx, item)
+	if err != nil {
+		t.Fatalf("haveBlob failed, err = %v", err)
+	}
+	if have {
+		t.Error("haveBlob unexpectedly found blob")
+	}
+
+	// Throttling should have reduced the concurrency.
+	if limiter.current() != 2 {
+		t.Errorf("expected limit 2 after throttled HEAD, got %d", limiter.current())
+	}
+
+	if err := c.uploadBlob(ctx, item); err != nil {
+		t.Fatalf("uploadBlob failed, err = %v", err)
+	}
+
+	if limiter.current() != 1 {
+		t.Errorf("expected limit 1 after throttled PUT, got %d", limiter.current())
+	}
+	if s3.puts["abc123"] == nil {
+		t.Error("blob was not uploaded")
+	}
+}
+
+func TestClientEnsureUploadedSlowDown(t *testing.T) {
+	c, s3 := newClientWithFakeS3(t)
+	enableRetries(c)
+
+	chdirInTest(t, "../../test/data/srctrees/just-files")
+
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	items := []walk.SyncItem{
+		{SrcPath: "hello-copy-one", Key: "abc123"},
+		{SrcPath: "hello-copy-two", Key: "abc456"},
+		{SrcPath: "subdir/some-binary", Key: "aabbcc"},
+	}
+	for _, item := range items {
+		s3.blobs[item.Key] = []error{slowDown(), notFound(), slowDown(), slowDown()}
+	}
+
+	uploaded := 0
+	noop := func(walk.SyncItem) error { return nil }
+	err := c.EnsureUploaded(ctx, items, func(walk.SyncItem) error {
+		uploaded++
+		return nil
+	}, noop, noop)
+
+	// Every item should be uploaded despite the throttling.
+	if err != nil {
+		t.Errorf("got unexpected error %v", err)
+	}
+	if uploaded != len(items) {
+		t.Errorf("expected %d uploads, got %d", len(items), uploaded)
+	}
+}
+
+func TestClientUploadSlowDownExhausted(t *testing.T) {
+	c, s3 := newClientWithFakeS3(t)
+	enableRetries(c)
+
+	chdirInTest(t, "../../test/data/srctrees/just-files")
+
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+	// More throttling than retries permit.
+	s3.blobs["abc123"] = []error{notFound(), slowDown(), slowDown(), slowDown(), slowDown()}
+
+	noop := func(walk.SyncItem) error { return nil }
+	err := c.EnsureUploaded(ctx, []walk.SyncItem{{SrcPath: "hello-copy-one", Key: "abc123"}}, noop, noop, noop)
+
+	if err == nil {
+		t.Fatal("upload unexpectedly succeeded")
+	}
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) || awsErr.Code() != "SlowDown" {
+		t.Errorf("did not get expected error, got: %v", err)
+	}
+}
+
+func TestThrottleHandlerStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		code    string
+		limited bool
+	}{
+		{"slow down", http.StatusServiceUnavailable, "SlowDown", true},
+		{"unavailable", http.StatusServiceUnavailable, "ServiceUnavailable", true},
+
+		// Errors from a proxy along the way aren't throttling by S3.
+		{"bad gateway", http.StatusBadGateway, "BadGateway", false},
+		{"gateway timeout", http.StatusGatewayTimeout, "GatewayTimeout", false},
+		{"too many requests", http.StatusTooManyRequests, "TooManyRequests", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			ctx = log.NewContext(ctx, log.Package.NewLogger(args.Config{}))
+
+			limiter := newUploadLimiter(4)
+			ctx = withUploadLimiter(ctx, limiter)
+
+			r := &request.Request{
+				Operation:    &request.Operation{Name: "PutObject"},
+				HTTPRequest:  &http.Request{},
+				HTTPResponse: &http.Response{StatusCode: tt.status},
+				Error:        awserr.New(tt.code, "failed", nil),
+			}
+			r.SetContext(ctx)
+
+			throttleHandler.Fn(r)
+
+			expected := 4
+			if tt.limited {
+				expected = 2
+			}
+			if limiter.current() != expected {
+				t.Errorf("expected limit %d, got %d", expected, limiter.current())
+			}
+		})
+	}
+}
+
+func TestUploadLimiter(t *testing.T) {
+	ctx := context.Background()
+	l := newUploadLimiter(4)
+
+	for i := 0; i < 4; i++ {
+		if err := l.acquire(ctx); err != nil {
+			t.Fatalf("acquire %d failed, err = %v", i, err)
+		}
+	}
+
+	// Each throttle halves the limit, down to a minimum of 1.
+	for _, expected := range []int{2, 1, 1} {
+		if limit := l.throttled(); limit != expected {
+			t.Errorf("expected limit %d, got %d", expected, limit)
+		}
+	}
+
+	// Successful uploads should ramp the limit back up, by one each time
+	// a full limit's worth has succeeded.
+	for i := 0; i < 4; i++ {
+		l.release(true)
+	}
+	if l.current() != 3 {
+		t.Errorf("expected limit 3 after ramping up, got %d", l.current())
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := l.acquire(ctx); err != nil {
+			t.Fatalf("acquire %d failed, err = %v", i, err)
+		}
+	}
+
+	// The limit is reached, so this should block until cancelled.
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := l.acquire(timeoutCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquire beyond limit did not time out, err = %v", err)
+	}
+
+	// Or until another upload completes, regardless of its outcome.
+	acquired := make(chan error)
+	go func() {
+		acquired <- l.acquire(ctx)
+	}()
+
+	select {
+	case err := <-acquired:
+		t.Fatalf("acquire returned while at limit, err = %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	l.release(false)
+	if err := <-acquired; err != nil {
+		t.Errorf("acquire failed after release, err = %v", err)
+	}
+
+	// Failed uploads don't ramp up the limit.
+	if l.current() != 3 {
+		t.Errorf("expected limit 3, got %d", l.current())
+	}
+}
+
+func TestUploadLimiterNil(t *testing.T) {
+	var l *uploadLimiter
+
+	// A nil limiter never blocks.
+	for i := 0; i < 10; i++ {
+		if err := l.acquire(context.Background()); err != nil {
+			t.Fatalf("acquire failed, err = %v", err)
+		}
+	}
+	l.release(true)
+
+	if l.throttled() != 0 {
+		t.Error("unexpected limit from nil limiter")
+	}
+}
+
+// Config overriding the bounds on concurrency.
+type concurrencyConfig struct {
+	conf.Config
+	threads, min, max int
+}
+
+func (c concurrencyConfig) UploadThreads() int  { return c.threads }
+func (c concurrencyConfig) ConcurrencyMin() int { return c.min }
+func (c concurrencyConfig) ConcurrencyMax() int { return c.max }
+
+func TestClientLimiterBounds(t *testing.T) {
+	tests := []struct {
+		name                    string
+		threads, cfgMin, cfgMax int
+		limit, min, max         int
+	}{
+		{"defaults", 4, 1, 0, 4, 1, 4},
+		{"ramp beyond threads", 4, 2, 8, 4, 2, 8},
+		{"below threads", 4, 1, 2, 2, 1, 2},
+		{"min above max", 4, 8, 2, 2, 2, 2},
+		{"min above threads", 2, 3, 0, 2, 2, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newClientLimiter(concurrencyConfig{testConfig(t), tt.threads, tt.cfgMin, tt.cfgMax}, false)
+			if l.limit != tt.limit || l.min != tt.min || l.max != tt.max {
+				t.Errorf("got limit %d in [%d, %d], want %d in [%d, %d]",
+					l.limit, l.min, l.max, tt.limit, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestUploadLimiterOnError(t *testing.T) {
+	ctx := context.Background()
+	l := newClientLimiter(concurrencyConfig{testConfig(t), 8, 2, 8}, true)
+
+	// A burst of errors halves the limit each time, down to the minimum.
+	for _, expected := range []int{4, 2, 2} {
+		if err := l.acquire(ctx); err != nil {
+			t.Fatal(err)
+		}
+		limit, reduced := l.release(false)
+		if !reduced || limit != expected {
+			t.Errorf("expected limit %d reduced, got %d reduced %v", expected, limit, reduced)
+		}
+	}
+
+	// Successes, including of requests made without acquiring, ramp the
+	// limit back up to the maximum.
+	for i := 0; i < 2+3+4+5+6+7; i++ {
+		if _, reduced := l.observe(true); reduced {
+			t.Fatal("limit reduced by success")
+		}
+	}
+	if l.current() != 8 {
+		t.Errorf("expected limit 8 after recovering, got %d", l.current())
+	}
+
+	// Without onError, failures alone don't reduce the limit.
+	l = newClientLimiter(concurrencyConfig{testConfig(t), 8, 2, 8}, false)
+	if limit, reduced := l.observe(false); reduced || limit != 8 {
+		t.Errorf("limit reduced without onError, got %d", limit)
+	}
+}
+
+// statusGw responds to every request with the given status.
+type statusGw struct {
+	status int
+}
+
+func (s *statusGw) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		Status:     http.StatusText(s.status),
+		StatusCode: s.status,
+		Body:       io.NopCloser(strings.NewReader(`{}`)),
+	}, nil
+}
+
+func TestClientThrottleOnError(t *testing.T) {
+	ctx := log.NewContext(context.Background(), log.Package.NewLogger(args.Config{}))
+
+	for _, throttle := range []bool{false, true} {
+		t.Run(fmt.Sprint(throttle), func(t *testing.T) {
+			clientCtx := ctx
+			if throttle {
+				clientCtx = WithThrottleOnError(ctx)
+			}
+
+			iface, err := Package.NewClient(clientCtx, testConfig(t))
+			if err != nil {
+				t.Fatal("creating client:", err)
+			}
+			c := iface.(*client)
+
+			gw := &statusGw{http.StatusNotFound}
+			c.httpClient.Transport = gw
+
+			// Errors which say nothing about the load on exodus-gw don't
+			// reduce concurrency.
+			if _, err := c.WhoAmI(ctx); err == nil {
+				t.Fatal("request unexpectedly succeeded")
+			}
+			if c.limiter.current() != 4 {
+				t.Errorf("limit reduced by 404, got %d", c.limiter.current())
+			}
+
+			// A burst of server errors does, only if enabled...
+			gw.status = http.StatusBadGateway
+			for i := 0; i < 2; i++ {
+				if _, err := c.WhoAmI(ctx); err == nil {
+					t.Fatal("request unexpectedly succeeded")
+				}
+			}
+			expected := 4
+			if throttle {
+				expected = 1
+			}
+			if c.limiter.current() != expected {
+				t.Errorf("expected limit %d after errors, got %d", expected, c.limiter.current())
+			}
+
+			// ...and recovers as requests succeed.
+			gw.status = http.StatusOK
+			for i := 0; i < 1+2+3; i++ {
+				if _, err := c.WhoAmI(ctx); err != nil {
+					t.Fatal("request failed:", err)
+				}
+			}
+			if c.limiter.current() != 4 {
+				t.Errorf("expected limit 4 after recovering, got %d", c.limiter.current())
+			}
+		})
+	}
+}
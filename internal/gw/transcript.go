@@ -0,0 +1,215 @@
+package gw
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// Transcript records every request made to exodus-gw and the response to
+// it, as lines of JSON, with secrets redacted.
+type Transcript struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewTranscript returns a transcript written to w.
+func NewTranscript(w io.Writer) *Transcript {
+	return &Transcript{w: w}
+}
+
+type transcriptKey struct{}
+
+// WithTranscript returns a context under which clients created by NewClient
+// or NewDryRunClient record their requests into t.
+func WithTranscript(ctx context.Context, t *Transcript) context.Context {
+	return context.WithValue(ctx, transcriptKey{}, t)
+}
+
+// TranscriptFromContext returns the transcript set via WithTranscript,
+// or nil if unset.
+func TranscriptFromContext(ctx context.Context) *Transcript {
+	t, _ := ctx.Value(transcriptKey{}).(*Transcript)
+	return t
+}
+
+type transcriptEntry struct {
+	Time     string              `json:"time"`
+	Request  transcriptRequest   `json:"request"`
+	Response *transcriptResponse `json:"response,omitempty"`
+	Error    string              `json:"error,omitempty"`
+}
+
+type transcriptRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers"`
+	Body    string      `json:"body,omitempty"`
+}
+
+type transcriptResponse struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// redacted is the value recorded in place of a secret.
+const redacted = "REDACTED"
+
+// secretNames are substrings of the (lowercased) names of headers and query
+// parameters whose values are never recorded.
+var secretNames = []string{"authorization", "cookie", "token", "secret", "password", "signature", "credential"}
+
+func isSecret(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range secretNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactHeaders(h http.Header) http.Header {
+	out := http.Header{}
+	for key, values := range h {
+		if isSecret(key) {
+			values = []string{redacted}
+		}
+		out[key] = values
+	}
+	return out
+}
+
+func redactURL(u *url.URL) string {
+	out := *u
+	if out.User != nil {
+		out.User = url.User(redacted)
+	}
+
+	query := out.Query()
+	changed := false
+	for key := range query {
+		if isSecret(key) {
+			query.Set(key, redacted)
+			changed = true
+		}
+	}
+	if changed {
+		out.RawQuery = query.Encode()
+	}
+
+	return out.String()
+}
+
+func (t *Transcript) write(entry transcriptEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// A transcript is a debugging aid, so failing to write it doesn't fail
+	// the request.
+	json.NewEncoder(t.w).Encode(entry)
+}
+
+func newTranscriptEntry(r *http.Request) transcriptEntry {
+	return transcriptEntry{
+		Time: time.Now().UTC().Format(time.RFC3339Nano),
+		Request: transcriptRequest{
+			Method:  r.Method,
+			URL:     redactURL(r.URL),
+			Headers: redactHeaders(r.Header),
+		},
+	}
+}
+
+// recordResponse adds resp onto entry and writes it, returning a copy of the
+// response body which was consumed.
+func (t *Transcript) recordResponse(entry transcriptEntry, resp *http.Response) (io.ReadCloser, error) {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	entry.Response = &transcriptResponse{
+		Status:  resp.StatusCode,
+		Headers: redactHeaders(resp.Header),
+		Body:    string(body),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	t.write(entry)
+
+	return io.NopCloser(bytes.NewReader(body)), err
+}
+
+// transport returns a RoundTripper recording each request made via rt.
+func (t *Transcript) transport(rt http.RoundTripper) http.RoundTripper {
+	return &transcriptTransport{rt, t}
+}
+
+type transcriptTransport struct {
+	rt         http.RoundTripper
+	transcript *Transcript
+}
+
+func (t *transcriptTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	entry := newTranscriptEntry(r)
+
+	if r.Body != nil && r.Body != http.NoBody {
+		body, err := io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		// The body is consumed, so the request is passed on with a copy.
+		r = r.Clone(r.Context())
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		entry.Request.Body = string(body)
+	}
+
+	resp, err := t.rt.RoundTrip(r)
+	if err != nil {
+		entry.Error = err.Error()
+		t.transcript.write(entry)
+		return resp, err
+	}
+
+	resp.Body, err = t.transcript.recordResponse(entry, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// s3Handler returns an S3 send handler recording each request sent to S3.
+// The bodies of these requests are uploaded blobs, so they're not recorded.
+func (t *Transcript) s3Handler() request.NamedHandler {
+	return request.NamedHandler{
+		Name: "exodus-rsync.transcriptHandler",
+		Fn: func(r *request.Request) {
+			entry := newTranscriptEntry(r.HTTPRequest)
+
+			if r.HTTPResponse == nil {
+				if r.Error != nil {
+					entry.Error = r.Error.Error()
+				}
+				t.write(entry)
+				return
+			}
+
+			var err error
+			r.HTTPResponse.Body, err = t.recordResponse(entry, r.HTTPResponse)
+			if err != nil && r.Error == nil {
+				r.Error = err
+			}
+		},
+	}
+}
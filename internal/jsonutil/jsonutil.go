@@ -0,0 +1,15 @@
+// Package jsonutil holds helpers for encoding JSON.
+package jsonutil
+
+import "encoding/json"
+
+// MustMarshal returns the JSON encoding of v, for values of types which can
+// always be encoded, such as structs of strings, numbers and maps of them.
+// It panics if v can't be encoded, which is a bug in the caller.
+func MustMarshal(v interface{}) []byte {
+	out, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
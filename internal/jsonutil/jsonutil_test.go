@@ -0,0 +1,21 @@
+package jsonutil
+
+import "testing"
+
+func TestMustMarshal(t *testing.T) {
+	got := MustMarshal(map[string]int{"a": 1})
+	if string(got) != `{"a":1}` {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestMustMarshalPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("didn't panic")
+		}
+	}()
+
+	// Channels can't be encoded.
+	MustMarshal(make(chan int))
+}
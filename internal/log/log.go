@@ -106,7 +106,13 @@ func (impl) NewLogger(args args.Config) *Logger {
 		logLevel = DebugLevel
 	}
 
-	handler, _ := newBaseHandler(os.Stdout)
+	// With JSON output, stdout is reserved for the result of the command.
+	out := os.Stdout
+	if args.Output == "json" {
+		out = os.Stderr
+	}
+
+	handler, _ := newBaseHandler(out)
 	logger.Handler = level.New(handler, logLevel)
 
 	return &logger
@@ -162,15 +168,59 @@ func (l *Logger) StartPlatformLogger(cfg ConfigProvider) {
 	}
 
 	// platform logger only logs messages at lvl and higher.
-	handler = level.New(handler, lvl)
-
 	// logger object writes to CLI *and* to platform logger.
-	l.Handler = multi.New(
-		l.Handler,
+	l.AddHandler(level.New(handler, lvl))
+}
+
+// AddHandler passes every entry subsequently logged via this logger to
+// handler, as well as to the existing handlers, regardless of level.
+func (l *Logger) AddHandler(handler apexLog.Handler) {
+	// Any fields added to the logger should apply to the new handler too,
+	// so it's installed beneath them.
+	target := &l.Handler
+	if fields, ok := l.Handler.(*fieldsHandler); ok {
+		target = &fields.Handler
+	}
+
+	*target = multi.New(
+		*target,
 		handler,
 	)
 }
 
+// fieldsHandler adds a fixed set of fields onto each entry before passing
+// it to another handler.
+type fieldsHandler struct {
+	apexLog.Handler
+	fields apexLog.Fields
+}
+
+func (h *fieldsHandler) HandleLog(e *apexLog.Entry) error {
+	fields := apexLog.Fields{}
+	for key, value := range h.fields {
+		fields[key] = value
+	}
+	// Fields of the entry itself take precedence.
+	for key, value := range e.Fields {
+		fields[key] = value
+	}
+
+	entry := *e
+	entry.Fields = fields
+
+	return h.Handler.HandleLog(&entry)
+}
+
+// AddField adds a field onto every entry subsequently logged via this logger.
+func (l *Logger) AddField(key string, value interface{}) {
+	if h, ok := l.Handler.(*fieldsHandler); ok {
+		h.fields[key] = value
+		return
+	}
+
+	l.Handler = &fieldsHandler{l.Handler, apexLog.Fields{key: value}}
+}
+
 // Log is intended for use by the AWS SDK logger and is necessary for
 // compatiblity with said package. Will log messages at debug level.
 func (l *Logger) Log(v ...interface{}) {
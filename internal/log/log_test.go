@@ -168,3 +168,58 @@ func TestLogFunc(t *testing.T) {
 	assert.Equal(t, e.Message, "hello")
 	assert.Equal(t, apexLog.Fields{"aws": 1}, e.Fields)
 }
+
+func TestAddField(t *testing.T) {
+	h := memory.New()
+	logger := Package.NewLogger(args.Config{})
+	logger.Handler = h
+
+	logger.AddField("request_id", "abc")
+	logger.AddField("other", 1)
+
+	logger.Info("plain")
+	logger.F("foo", "bar").Info("with fields")
+	logger.F("other", 2).Info("overriding")
+
+	assert.Equal(t, apexLog.Fields{"request_id": "abc", "other": 1}, h.Entries[0].Fields)
+	assert.Equal(t, apexLog.Fields{"request_id": "abc", "other": 1, "foo": "bar"}, h.Entries[1].Fields)
+	assert.Equal(t, apexLog.Fields{"request_id": "abc", "other": 2}, h.Entries[2].Fields)
+}
+
+func TestAddFieldPlatformLogger(t *testing.T) {
+	file, _ := os.CreateTemp("", "tmpfile-")
+	defer os.Remove(file.Name())
+
+	h := memory.New()
+	logger := Package.NewLogger(args.Config{})
+	logger.Handler = h
+
+	logger.AddField("request_id", "abc")
+
+	// Fields should also apply to a platform logger started afterward.
+	logger.StartPlatformLogger(&testcase{"info", "file:" + file.Name()})
+
+	logger.Info("hello")
+
+	assert.Equal(t, apexLog.Fields{"request_id": "abc"}, h.Entries[0].Fields)
+
+	content, _ := os.ReadFile(file.Name())
+	assert.Contains(t, string(content), `hello {"request_id":"abc"}`)
+}
+
+func TestAddHandler(t *testing.T) {
+	h := memory.New()
+	logger := Package.NewLogger(args.Config{})
+	logger.Handler = h
+
+	logger.AddField("request_id", "abc")
+
+	added := memory.New()
+	logger.AddHandler(added)
+
+	logger.Info("hello")
+
+	// Both handlers get the entry, with fields added to the logger.
+	assert.Equal(t, apexLog.Fields{"request_id": "abc"}, h.Entries[0].Fields)
+	assert.Equal(t, apexLog.Fields{"request_id": "abc"}, added.Entries[0].Fields)
+}
@@ -0,0 +1,223 @@
+package progress
+
+// This package writes a stream of events describing the progress of a sync,
+// for consumption by programs supervising exodus-rsync. Unlike log messages,
+// events follow a fixed schema intended to remain stable between versions.
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/release-engineering/exodus-rsync/internal/jsonutil"
+)
+
+// Types of event.
+const (
+	// A sync is starting.
+	TypeStart = "start"
+
+	// The sync has entered a new phase, one of the Phase* constants.
+	TypePhase = "phase"
+
+	// A publish has been created, or an existing one joined.
+	TypePublish = "publish"
+
+	// A blob has been processed for upload.
+	TypeUpload = "upload"
+
+	// A batch of items has been added onto a publish.
+	TypeBatch = "batch"
+
+	// A publish commit has started, succeeded or failed.
+	TypeCommit = "commit"
+
+	// The sync has ended. This is always the final event.
+	TypeEnd = "end"
+)
+
+// Phases of a sync, in the order they happen.
+const (
+	PhaseWalk    = "walk"
+	PhaseUpload  = "upload"
+	PhasePublish = "publish"
+	PhaseHold    = "hold"
+	PhaseCommit  = "commit"
+	PhaseVerify  = "verify"
+)
+
+// Event is a single message within the stream, written as one line of JSON.
+//
+// Fields not relevant to an event's type are omitted.
+type Event struct {
+	Time time.Time `json:"time"`
+	Type string    `json:"type"`
+
+	// Phase being entered, for TypePhase.
+	Phase string `json:"phase,omitempty"`
+
+	// exodus-gw environment and publish to which the event relates, if any.
+	Env     string `json:"env,omitempty"`
+	Publish string `json:"publish,omitempty"`
+
+	// Source and destination of the sync, for TypeStart.
+	Src  string `json:"src,omitempty"`
+	Dest string `json:"dest,omitempty"`
+
+	// Blob processed and the size of its content, for TypeUpload.
+	Path string `json:"path,omitempty"`
+	Key  string `json:"key,omitempty"`
+	Size int64  `json:"size,omitempty"`
+
+	// Outcome of the event: for TypePublish, "created" or "joined"; for
+	// TypeUpload, one of "uploaded", "existing", "duplicate" or "failed";
+	// for TypeCommit, one of "started", "succeeded", "failed" or "skipped".
+	Status string `json:"status,omitempty"`
+
+	// Labels with which a publish was created, for TypePublish.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Progress through the current phase: for TypeUpload, Done of Total
+	// blobs have been processed; for TypeBatch, Done of Total items have
+	// been added, Items of them in the latest batch.
+	Items int `json:"items,omitempty"`
+	Done  int `json:"done,omitempty"`
+	Total int `json:"total,omitempty"`
+
+	// Exit code, for TypeEnd.
+	ExitCode *int `json:"exitCode,omitempty"`
+
+	// Description of an error, for failure events.
+	Error string `json:"error,omitempty"`
+}
+
+// Stream writes events to a file, socket or other destination.
+//
+// All methods are safe to call concurrently, and on a nil stream, which
+// discards all events.
+type Stream struct {
+	mu  sync.Mutex
+	w   io.WriteCloser
+	err error
+
+	observers []func(Event)
+}
+
+// Open returns a stream writing to the given destination, which may be:
+//
+//   - "fd:N", to write to the already open file descriptor N
+//   - the path of a Unix socket, to connect to the socket
+//   - the path of any other file, to append to the file, creating it if needed
+func Open(dest string) (*Stream, error) {
+	if fd, ok := strings.CutPrefix(dest, "fd:"); ok {
+		n, err := strconv.ParseUint(fd, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file descriptor '%s'", fd)
+		}
+		return NewStream(os.NewFile(uintptr(n), dest)), nil
+	}
+
+	if info, err := os.Stat(dest); err == nil && info.Mode()&os.ModeSocket != 0 {
+		conn, err := net.Dial("unix", dest)
+		if err != nil {
+			return nil, err
+		}
+		return NewStream(conn), nil
+	}
+
+	file, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return NewStream(file), nil
+}
+
+// NewStream returns a stream writing to w.
+func NewStream(w io.WriteCloser) *Stream {
+	return &Stream{w: w}
+}
+
+// Observe returns a stream which passes each event to fn before writing it
+// to s. If s is nil, the returned stream only passes events to fn.
+func (s *Stream) Observe(fn func(Event)) *Stream {
+	if s == nil {
+		s = &Stream{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.observers = append(s.observers, fn)
+	return s
+}
+
+// Emit writes an event to the stream, filling in its time if unset.
+//
+// Errors are not returned, as a supervising program going away shouldn't
+// interrupt a sync. Instead, the stream stops writing after the first error,
+// which is returned by Close.
+func (s *Stream) Emit(event Event) {
+	if s == nil {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	line := jsonutil.MustMarshal(event)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, fn := range s.observers {
+		fn(event)
+	}
+
+	if s.w == nil || s.err != nil {
+		return
+	}
+	_, s.err = s.w.Write(append(line, '\n'))
+}
+
+// Close closes the stream, returning the first error encountered while
+// writing to it, if any.
+func (s *Stream) Close() error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.w == nil {
+		return nil
+	}
+
+	err := s.w.Close()
+	if s.err != nil {
+		return s.err
+	}
+	return err
+}
+
+type streamKey struct{}
+
+// NewContext returns a context containing the given stream, which can later
+// be accessed via FromContext.
+func NewContext(ctx context.Context, s *Stream) context.Context {
+	return context.WithValue(ctx, streamKey{}, s)
+}
+
+// FromContext returns the stream within a context previously created via
+// NewContext, or nil if there is none.
+func FromContext(ctx context.Context) *Stream {
+	s, _ := ctx.Value(streamKey{}).(*Stream)
+	return s
+}
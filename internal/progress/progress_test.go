@@ -0,0 +1,260 @@
+package progress
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Returns the events written to the file at path.
+func readEvents(t *testing.T, path string) []Event {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := []Event{}
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var event Event
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("invalid event %q: %v", line, err)
+		}
+		out = append(out, event)
+	}
+	return out
+}
+
+func TestStreamFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.json")
+
+	// An existing file is appended to.
+	if err := os.WriteFile(path, []byte(`{"type":"old"}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed, err = %v", err)
+	}
+
+	code := 0
+	s.Emit(Event{Type: TypePhase, Phase: PhaseWalk})
+	s.Emit(Event{Type: TypeEnd, ExitCode: &code})
+
+	if err := s.Close(); err != nil {
+		t.Errorf("Close failed, err = %v", err)
+	}
+
+	events := readEvents(t, path)
+	if len(events) != 3 {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	if events[0].Type != "old" || events[1].Type != TypePhase || events[1].Phase != PhaseWalk {
+		t.Errorf("unexpected events %+v", events)
+	}
+
+	// Times should be filled in.
+	if time.Since(events[1].Time) > time.Minute {
+		t.Errorf("unexpected time %v", events[1].Time)
+	}
+
+	// A zero exit code must still be included.
+	if events[2].ExitCode == nil || *events[2].ExitCode != 0 {
+		t.Errorf("unexpected end event %+v", events[2])
+	}
+}
+
+func TestStreamSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.json")
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Emit(Event{
+		Time:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Type:   TypeUpload,
+		Env:    "live",
+		Path:   "/src/file",
+		Key:    "abc123",
+		Status: "uploaded",
+		Done:   1,
+		Total:  2,
+	})
+	s.Close()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Irrelevant fields should be omitted.
+	expected := `{"time":"2024-01-02T03:04:05Z","type":"upload","env":"live","path":"/src/file",` +
+		`"key":"abc123","status":"uploaded","done":1,"total":2}` + "\n"
+	if string(content) != expected {
+		t.Errorf("unexpected content %s", content)
+	}
+}
+
+func TestStreamSocket(t *testing.T) {
+	// Socket paths are limited in length, so can't be within t.TempDir().
+	dir, err := os.MkdirTemp("", "progress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan Event)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			close(received)
+			return
+		}
+		defer conn.Close()
+
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			var event Event
+			json.Unmarshal(scanner.Bytes(), &event)
+			received <- event
+		}
+		close(received)
+	}()
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed, err = %v", err)
+	}
+	s.Emit(Event{Type: TypeStart, Src: "src", Dest: "dest"})
+	s.Close()
+
+	event := <-received
+	if event.Type != TypeStart || event.Src != "src" || event.Dest != "dest" {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if _, ok := <-received; ok {
+		t.Error("unexpected additional events")
+	}
+}
+
+func TestStreamFd(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	s, err := Open("fd:" + strconv.Itoa(int(w.Fd())))
+	if err != nil {
+		t.Fatalf("Open failed, err = %v", err)
+	}
+	s.Emit(Event{Type: TypePhase, Phase: PhaseUpload})
+	s.Close()
+
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(line, `"phase":"upload"`) {
+		t.Errorf("unexpected line %q", line)
+	}
+}
+
+type failingWriter struct {
+	writes int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("simulated error")
+}
+
+func (w *failingWriter) Close() error {
+	return nil
+}
+
+func TestStreamWriteError(t *testing.T) {
+	w := &failingWriter{}
+	s := NewStream(w)
+
+	s.Emit(Event{Type: TypeStart})
+	s.Emit(Event{Type: TypeEnd})
+
+	// Writing should stop at the first error, which is returned on close.
+	if w.writes != 1 {
+		t.Errorf("expected 1 write, got %d", w.writes)
+	}
+	if err := s.Close(); err == nil || err.Error() != "simulated error" {
+		t.Errorf("did not get expected error, got: %v", err)
+	}
+}
+
+func TestStreamOpenErrors(t *testing.T) {
+	for _, dest := range []string{"fd:foo", "fd:-1", filepath.Join(t.TempDir(), "no/such/dir")} {
+		if _, err := Open(dest); err == nil {
+			t.Errorf("Open(%q) unexpectedly succeeded", dest)
+		}
+	}
+}
+
+func TestStreamNil(t *testing.T) {
+	// Without a stream in the context, events are discarded.
+	s := FromContext(context.Background())
+	if s != nil {
+		t.Fatalf("unexpected stream %v", s)
+	}
+
+	s.Emit(Event{Type: TypeStart})
+	if err := s.Close(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestStreamObserve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.json")
+
+	file, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed, err = %v", err)
+	}
+
+	for name, s := range map[string]*Stream{"file": file, "nil": nil} {
+		t.Run(name, func(t *testing.T) {
+			var observed []Event
+			s = s.Observe(func(e Event) { observed = append(observed, e) })
+
+			s.Emit(Event{Type: TypeStart})
+			s.Emit(Event{Type: TypePhase, Phase: PhaseWalk})
+
+			if err := s.Close(); err != nil {
+				t.Errorf("Close failed, err = %v", err)
+			}
+
+			// Observers see each event with its time filled in.
+			if len(observed) != 2 || observed[1].Phase != PhaseWalk || observed[0].Time.IsZero() {
+				t.Errorf("unexpected observed events %+v", observed)
+			}
+		})
+	}
+
+	// Events are still written as usual.
+	if events := readEvents(t, path); len(events) != 2 {
+		t.Errorf("unexpected events %+v", events)
+	}
+}
@@ -0,0 +1,156 @@
+package progress
+
+import (
+	"sync"
+	"time"
+
+	"github.com/release-engineering/exodus-rsync/internal/atomicfile"
+	"github.com/release-engineering/exodus-rsync/internal/jsonutil"
+)
+
+// Counts is progress through a phase, as Done of Total.
+type Counts struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// Snapshot is the state of a sync as of the latest event, for monitoring a
+// sync without following the whole stream of events.
+type Snapshot struct {
+	// Time of the latest event.
+	Time time.Time `json:"time"`
+
+	Src  string `json:"src,omitempty"`
+	Dest string `json:"dest,omitempty"`
+
+	// Current phase, and the environment and publish to which it relates.
+	Phase   string `json:"phase,omitempty"`
+	Env     string `json:"env,omitempty"`
+	Publish string `json:"publish,omitempty"`
+
+	// Blobs processed for upload, and items added onto the publish, in the
+	// latest publish.
+	Uploads Counts `json:"uploads"`
+	Items   Counts `json:"items"`
+
+	// Number of blobs which failed to upload, with --exodus-keep-going.
+	Failed int `json:"failed"`
+
+	// Status of the latest commit, as in TypeCommit events.
+	Commit string `json:"commit,omitempty"`
+
+	// Exit code, once the sync has ended.
+	ExitCode *int `json:"exitCode,omitempty"`
+}
+
+// observe updates the snapshot from an event.
+func (s *Snapshot) observe(e Event) {
+	s.Time = e.Time
+
+	switch e.Type {
+	case TypeStart:
+		s.Src, s.Dest = e.Src, e.Dest
+	case TypePhase:
+		if e.Phase == PhaseUpload {
+			// A new publish, e.g. to the next of several environments.
+			s.Uploads, s.Items, s.Failed, s.Commit = Counts{}, Counts{}, 0, ""
+		}
+		s.Phase, s.Env, s.Publish = e.Phase, e.Env, e.Publish
+	case TypeUpload:
+		s.Uploads = Counts{e.Done, e.Total}
+		if e.Status == "failed" {
+			s.Failed++
+		}
+	case TypeBatch:
+		s.Items = Counts{e.Done, e.Total}
+	case TypeCommit:
+		s.Commit = e.Status
+	case TypeEnd:
+		s.ExitCode = e.ExitCode
+	}
+}
+
+// Snapshotter periodically writes a snapshot of a sync to a file, replacing
+// the file's content each time, so that the file can be watched from another
+// terminal.
+type Snapshotter struct {
+	path string
+
+	mu    sync.Mutex
+	snap  Snapshot
+	dirty bool
+	err   error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewSnapshotter returns a snapshotter writing to path once per interval,
+// while there are new events. Events are passed to it via Stream.Observe.
+func NewSnapshotter(path string, interval time.Duration) *Snapshotter {
+	s := &Snapshotter{
+		path: path,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.flush()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+
+	return s
+}
+
+// Observe updates the snapshot from an event, to be written at the next
+// interval.
+func (s *Snapshotter) Observe(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.snap.observe(e)
+	s.dirty = true
+}
+
+// flush writes the snapshot if it changed since last written.
+func (s *Snapshotter) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.dirty || s.err != nil {
+		return
+	}
+	s.dirty = false
+	s.err = s.write()
+}
+
+// write replaces the file with the snapshot, such that readers never see a
+// partial snapshot.
+func (s *Snapshotter) write() error {
+	return atomicfile.WriteFile(s.path, append(jsonutil.MustMarshal(s.snap), '\n'))
+}
+
+// Close stops writing periodically and writes the final snapshot, returning
+// the first error encountered while writing, if any. As with Stream, the
+// snapshotter stops writing after the first error.
+func (s *Snapshotter) Close() error {
+	close(s.stop)
+	<-s.done
+
+	s.flush()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
@@ -0,0 +1,98 @@
+package progress
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Waits until the snapshot at path satisfies cond, failing if it doesn't
+// within a reasonable time.
+func waitSnapshot(t *testing.T, path string, cond func(Snapshot) bool) Snapshot {
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		var snap Snapshot
+		content, err := os.ReadFile(path)
+		if err == nil {
+			err = json.Unmarshal(content, &snap)
+		}
+		if err == nil && cond(snap) {
+			return snap
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("snapshot not updated, last content %q, err = %v", content, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSnapshotterPeriodic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+
+	snapshotter := NewSnapshotter(path, 10*time.Millisecond)
+	s := (*Stream)(nil).Observe(snapshotter.Observe)
+
+	s.Emit(Event{Type: TypeStart, Src: "src/", Dest: "exodus:/dest"})
+	s.Emit(Event{Type: TypePhase, Phase: PhaseUpload, Env: "live", Publish: "1234"})
+	s.Emit(Event{Type: TypeUpload, Status: "uploaded", Done: 1, Total: 3})
+
+	snap := waitSnapshot(t, path, func(s Snapshot) bool { return s.Uploads.Done == 1 })
+	if snap.Src != "src/" || snap.Phase != PhaseUpload || snap.Env != "live" || snap.Publish != "1234" ||
+		snap.Uploads.Total != 3 {
+		t.Errorf("unexpected snapshot %+v", snap)
+	}
+
+	// Later events update the snapshot while the sync goes on.
+	s.Emit(Event{Type: TypeUpload, Status: "failed", Done: 2, Total: 3})
+	s.Emit(Event{Type: TypeUpload, Status: "uploaded", Done: 3, Total: 3})
+	s.Emit(Event{Type: TypePhase, Phase: PhasePublish, Env: "live", Publish: "1234"})
+	s.Emit(Event{Type: TypeBatch, Items: 2, Done: 2, Total: 2})
+
+	snap = waitSnapshot(t, path, func(s Snapshot) bool { return s.Items.Done == 2 })
+	if snap.Phase != PhasePublish || snap.Uploads.Done != 3 || snap.Failed != 1 {
+		t.Errorf("unexpected snapshot %+v", snap)
+	}
+
+	// A new publish starts from zero.
+	s.Emit(Event{Type: TypePhase, Phase: PhaseUpload, Env: "other", Publish: "5678"})
+
+	snap = waitSnapshot(t, path, func(s Snapshot) bool { return s.Env == "other" })
+	if snap.Uploads.Done != 0 || snap.Items.Done != 0 || snap.Failed != 0 {
+		t.Errorf("unexpected snapshot %+v", snap)
+	}
+
+	// Closing writes the final snapshot at once.
+	code := 0
+	s.Emit(Event{Type: TypeEnd, ExitCode: &code})
+	if err := snapshotter.Close(); err != nil {
+		t.Errorf("Close failed, err = %v", err)
+	}
+
+	snap = waitSnapshot(t, path, func(Snapshot) bool { return true })
+	if snap.ExitCode == nil || *snap.ExitCode != 0 {
+		t.Errorf("final snapshot not written, got %+v", snap)
+	}
+
+	// No temporary files are left behind.
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("unexpected files %v", entries)
+	}
+}
+
+func TestSnapshotterWriteError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "snapshot.json")
+
+	snapshotter := NewSnapshotter(path, time.Hour)
+	snapshotter.Observe(Event{Type: TypeStart, Time: time.Now()})
+
+	if err := snapshotter.Close(); err == nil {
+		t.Error("Close unexpectedly succeeded")
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/release-engineering/exodus-rsync/internal/args"
 	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
 )
 
 //go:generate go run -modfile ../../go.tools.mod github.com/golang/mock/mockgen -package $GOPACKAGE -destination mock.go -source $GOFILE
@@ -113,6 +114,9 @@ func Arguments(ctx context.Context, args args.Config) []string {
 	if args.OmitDirTimes {
 		argv = append(argv, "--omit-dir-times")
 	}
+	if args.ModifyWindow != 0 {
+		argv = append(argv, "--modify-window", fmt.Sprint(args.ModifyWindow))
+	}
 	if args.DryRun {
 		argv = append(argv, "--dry-run")
 	}
@@ -125,6 +129,9 @@ func Arguments(ctx context.Context, args args.Config) []string {
 	if args.Delete {
 		argv = append(argv, "--delete")
 	}
+	if args.DelayUpdates {
+		argv = append(argv, "--delay-updates")
+	}
 	if args.PruneEmptyDirs {
 		argv = append(argv, "--prune-empty-dirs")
 	}
@@ -134,6 +141,18 @@ func Arguments(ctx context.Context, args args.Config) []string {
 	if args.Compress {
 		argv = append(argv, "--compress")
 	}
+	// Excludes from configuration apply, as when walking SRC, before any
+	// filters from the command line, so that those can't override them.
+	for _, ex := range walk.BaseFiltersFromContext(ctx).Exclude {
+		argv = append(argv, "--exclude", ex)
+	}
+	// As with -FF, rules are read from the filter file of each directory,
+	// which is itself never copied.
+	if args.FilterFiles {
+		argv = append(argv,
+			"--filter", "- "+walk.DirFilterFile,
+			"--filter", "dir-merge /"+walk.DirFilterFile)
+	}
 	for _, rule := range args.Filter {
 		argv = append(argv, "--filter", fmt.Sprint(rule))
 	}
@@ -146,6 +165,9 @@ func Arguments(ctx context.Context, args args.Config) []string {
 	if args.FilesFrom != "" {
 		argv = append(argv, "--files-from", fmt.Sprint(args.FilesFrom))
 	}
+	if args.ChecksumChoice != "" {
+		argv = append(argv, "--checksum-choice", args.ChecksumChoice)
+	}
 	if args.Stats {
 		argv = append(argv, "--stats")
 	}
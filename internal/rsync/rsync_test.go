@@ -10,6 +10,7 @@ import (
 
 	"github.com/release-engineering/exodus-rsync/internal/args"
 	"github.com/release-engineering/exodus-rsync/internal/log"
+	"github.com/release-engineering/exodus-rsync/internal/walk"
 )
 
 // Like os.Getwd but fails test on error.
@@ -86,6 +87,7 @@ func TestExec(t *testing.T) {
 	tests := []struct {
 		name         string
 		args         args.Config
+		filters      walk.Filters
 		expectedArgv []string
 	}{
 		{"basic",
@@ -93,9 +95,29 @@ func TestExec(t *testing.T) {
 				Src:  "some-src",
 				Dest: "some-dest",
 			},
+			walk.Filters{},
 			[]string{testBinPath(t) + "/rsync", "some-src", "some-dest"},
 		},
 
+		// Excludes from configuration and filter files come before any other
+		// filters.
+		{"filters",
+			args.Config{
+				Src:          "some-src",
+				Dest:         "some-dest",
+				Exclude:      []string{"*.txt"},
+				ExodusConfig: args.ExodusConfig{FilterFiles: true},
+			},
+			walk.Filters{Exclude: []string{"*.src.rpm"}},
+			[]string{
+				testBinPath(t) + "/rsync",
+				"--exclude", "*.src.rpm",
+				"--filter", "- .exodus-rsync-filter", "--filter", "dir-merge /.exodus-rsync-filter",
+				"--exclude", "*.txt",
+				"some-src", "some-dest",
+			},
+		},
+
 		{"all args",
 			args.Config{
 				Src:     "src",
@@ -120,6 +142,7 @@ func TestExec(t *testing.T) {
 					Atimes:         true,
 					Crtimes:        true,
 					OmitDirTimes:   true,
+					ModifyWindow:   -1,
 					Rsh:            "some-rsh",
 					Delete:         true,
 					PruneEmptyDirs: true,
@@ -131,20 +154,26 @@ func TestExec(t *testing.T) {
 				Relative:       true,
 				Links:          true,
 				IgnoreExisting: true,
+				DelayUpdates:   true,
 				Filter:         []string{"some-filter"},
 				Exclude:        []string{".*"},
 				Include:        []string{"**/dir"},
 				FilesFrom:      "sources.txt",
+				ChecksumChoice: "xxh128",
+				// Has nothing to do with rsync's --ignore-errors, so isn't
+				// passed on as that.
+				ExodusConfig: args.ExodusConfig{SkipUnreadable: true},
 			},
+			walk.Filters{},
 			[]string{
 				testBinPath(t) + "/rsync", "-vvv",
 				"--archive", "--recursive", "--relative", "--links", "--copy-links",
 				"--keep-dirlinks", "--hard-links", "--perms", "--executability", "--acls",
 				"--xattrs", "--owner", "--group", "--devices", "--specials", "--times",
-				"--atimes", "--crtimes", "--omit-dir-times", "--dry-run", "--rsh", "some-rsh",
-				"--ignore-existing", "--delete", "--prune-empty-dirs", "--timeout", "1234",
+				"--atimes", "--crtimes", "--omit-dir-times", "--modify-window", "-1", "--dry-run", "--rsh", "some-rsh",
+				"--ignore-existing", "--delete", "--delay-updates", "--prune-empty-dirs", "--timeout", "1234",
 				"--compress", "--filter", "some-filter", "--exclude", ".*", "--include", "**/dir",
-				"--files-from", "sources.txt", "--stats", "--itemize-changes",
+				"--files-from", "sources.txt", "--checksum-choice", "xxh128", "--stats", "--itemize-changes",
 				"src", "dest",
 			},
 		},
@@ -164,6 +193,7 @@ func TestExec(t *testing.T) {
 
 			ctx := context.Background()
 			ctx = log.NewContext(ctx, log.Package.NewLogger(tt.args))
+			ctx = walk.WithBaseFilters(ctx, tt.filters)
 
 			err := Package.Exec(ctx, tt.args)
 			if err.Error() != "simulated error" {
@@ -0,0 +1,18 @@
+package uuid
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	re := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	a, b := New(), New()
+	if !re.MatchString(a) {
+		t.Errorf("not a version 4 UUID: %s", a)
+	}
+	if a == b {
+		t.Errorf("got the same UUID twice: %s", a)
+	}
+}
@@ -0,0 +1,345 @@
+package walk
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+type archiveReader struct {
+	*tar.Reader
+	closers []io.Closer
+
+	// Stream of the archive decompressed, counting bytes read by Reader.
+	stream *countingReader
+
+	// File of the archive if not compressed, and so supporting seeks.
+	file *os.File
+}
+
+// countingReader counts the bytes read from it.
+type countingReader struct {
+	io.Reader
+	count int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
+
+func (r *archiveReader) Close() error {
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		r.closers[i].Close()
+	}
+	return nil
+}
+
+// openArchive opens the tar archive at the given path, transparently
+// decompressing it if gzip-compressed.
+func openArchive(archive string) (*archiveReader, error) {
+	file, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &archiveReader{closers: []io.Closer{file}, file: file}
+
+	buffered := bufio.NewReader(file)
+	var r io.Reader = buffered
+
+	// Detect gzip via its magic number rather than the file name.
+	magic, _ := buffered.Peek(2)
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			out.Close()
+			return nil, fmt.Errorf("decompressing %s: %w", archive, err)
+		}
+		out.closers = append(out.closers, gz)
+		out.file = nil
+		r = gz
+	}
+
+	out.stream = &countingReader{Reader: r}
+	out.Reader = tar.NewReader(out.stream)
+	return out, nil
+}
+
+// offset returns the offset within the decompressed archive of the content
+// of the entry last returned by Next.
+//
+// tar.Reader reads exactly the header blocks of an entry before returning it,
+// so this is the count of bytes read so far.
+func (r *archiveReader) offset() int64 {
+	return r.stream.count
+}
+
+// entryPath returns the path of an archive entry relative to the root of
+// the archive.
+func entryPath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+// archiveFiltered returns true if an archive entry at the given relative path
+// should be skipped according to base filters or --exclude/--include arguments.
+//
+// Archives don't necessarily contain entries for directories, so each parent
+// directory of the entry is filtered as well.
+func archiveFiltered(logger *log.Logger, base Filters, args args.Config, relPath string) (bool, error) {
+	components := strings.Split(relPath, "/")
+
+	for i := range components {
+		filterPath := "/" + strings.Join(components[0:i+1], "/")
+		isDir := i < len(components)-1
+
+		err := filter(logger, filterPath, base.Exclude, base.Include, isDir)
+		if err == nil {
+			err = filter(logger, filterPath, args.Excluded(), args.Included(), isDir)
+		}
+		if err == fs.SkipDir || (err != nil && err.Error() == fmt.Sprintf("filtered '%s'", filterPath)) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return false, nil
+}
+
+// walkArchive is the counterpart of Walk for a source which is a tar archive.
+//
+// Each regular file within the archive produces an item whose SrcPath is the
+// path of the entry as if the archive had been extracted into a directory
+// at args.Src. As when extracting, if several entries have the same path,
+// only the last is used; items are therefore only passed to handler once the
+// whole archive has been read. A hard link produces an item with the content
+// of the file it links to, just as a hard link on disk would.
+func walkArchive(ctx context.Context, args args.Config, onlyThese []string, handler SyncItemHandler) error {
+	logger := log.FromContext(ctx)
+
+	// SRC may have a trailing slash to indicate only the archive's content
+	// should be published, but that's not part of the archive's file name.
+	archivePath := strings.TrimSuffix(args.Src, "/")
+
+	since, err := newerThan(args.NewerThan)
+	if err != nil {
+		return err
+	}
+
+	archive, err := openArchive(archivePath)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	var items []SyncItem
+	itemIndex := make(map[string]int)
+
+	// Content of each regular file so far, by relative path, for any hard
+	// links to it.
+	contents := make(map[string]archiveContent)
+
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		hdr, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", args.Src, err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeLink {
+			logger.F("entry", hdr.Name, "type", string(hdr.Typeflag)).Debug("skipping non-regular archive entry")
+			continue
+		}
+
+		relPath := entryPath(hdr.Name)
+		srcPath := filepath.Join(args.Src, relPath)
+
+		// Recorded whether or not the file itself is published, since a
+		// hard link to it might be.
+		if hdr.Typeflag == tar.TypeReg {
+			contents[relPath] = archiveContent{entry: hdr.Name, offset: archive.offset(), size: hdr.Size}
+		}
+
+		if len(onlyThese) > 0 && !contains(onlyThese, srcPath) {
+			logger.F("path", srcPath).Debug("skipping; not included in --files-from file")
+			continue
+		}
+
+		filtered, err := archiveFiltered(logger, BaseFiltersFromContext(ctx), args, relPath)
+		if err != nil {
+			return err
+		}
+		if filtered {
+			continue
+		}
+
+		if !since.IsZero() && !hdr.ModTime.After(since) {
+			logger.F("path", srcPath).Debug("skipping; not newer than --exodus-newer-than")
+			continue
+		}
+
+		var item SyncItem
+		if hdr.Typeflag == tar.TypeLink {
+			var ok bool
+			item, ok, err = archiveLinkItem(logger, archivePath, contents, hdr, srcPath)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+		} else {
+			key, err := readerHash(archive, newKeyHash())
+			if err != nil {
+				return fmt.Errorf("checksum %s: %w", srcPath, err)
+			}
+
+			content := contents[relPath]
+			content.key = key
+			contents[relPath] = content
+
+			item = SyncItem{
+				SrcPath:       srcPath,
+				Key:           key,
+				Info:          hdr.FileInfo(),
+				Archive:       archivePath,
+				ArchiveEntry:  hdr.Name,
+				ArchiveOffset: content.offset,
+			}
+		}
+
+		logger.F("item", item).Debug("got item")
+
+		if i, ok := itemIndex[srcPath]; ok {
+			logger.F("path", srcPath).Debug("replacing earlier archive entry of the same path")
+			items[i] = item
+			continue
+		}
+		itemIndex[srcPath] = len(items)
+		items = append(items, item)
+	}
+
+	for _, item := range items {
+		if err := handler(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archiveContent is where the content of a regular file lies within an
+// archive, and its key if already known.
+type archiveContent struct {
+	entry  string
+	offset int64
+	size   int64
+	key    string
+}
+
+// archiveLinkItem returns an item for a hard link entry, with the content of
+// the regular file it links to. The target must come earlier in the archive,
+// as when extracting; if it doesn't, the link is skipped with a warning and
+// false is returned.
+func archiveLinkItem(logger *log.Logger, archivePath string, contents map[string]archiveContent,
+	hdr *tar.Header, srcPath string) (SyncItem, bool, error) {
+	target, ok := contents[entryPath(hdr.Linkname)]
+	if !ok {
+		logger.F("entry", hdr.Name, "target", hdr.Linkname).Warn("Skipping hard link to a file not found earlier in the archive")
+		return SyncItem{}, false, nil
+	}
+
+	// The target wasn't hashed if it isn't itself published.
+	if target.key == "" {
+		r, err := openArchiveEntry(archivePath, target.offset, target.size)
+		if err != nil {
+			return SyncItem{}, false, err
+		}
+		defer r.Close()
+
+		target.key, err = readerHash(r, newKeyHash())
+		if err != nil {
+			return SyncItem{}, false, fmt.Errorf("checksum %s: %w", srcPath, err)
+		}
+		contents[entryPath(hdr.Linkname)] = target
+	}
+
+	// Described as the regular file it stands for.
+	fileHdr := *hdr
+	fileHdr.Typeflag = tar.TypeReg
+	fileHdr.Linkname = ""
+	fileHdr.Size = target.size
+
+	return SyncItem{
+		SrcPath:       srcPath,
+		Key:           target.key,
+		Info:          fileHdr.FileInfo(),
+		Archive:       archivePath,
+		ArchiveEntry:  target.entry,
+		ArchiveOffset: target.offset,
+	}, true, nil
+}
+
+// openArchiveEntry returns a reader for size bytes of content at offset within
+// a tar archive, as recorded by walkArchive.
+//
+// An entry is opened by its offset rather than its name since an archive may
+// hold several entries of the same name. An uncompressed archive seeks
+// directly to the entry, while a compressed archive must be decompressed up
+// to the entry.
+func openArchiveEntry(archive string, offset int64, size int64) (io.ReadCloser, error) {
+	r, err := openArchive(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	var content io.Reader = r.stream.Reader
+	if r.file != nil {
+		_, err = r.file.Seek(offset, io.SeekStart)
+		content = r.file
+	} else {
+		_, err = io.CopyN(io.Discard, content, offset)
+	}
+	if err != nil {
+		r.Close()
+		return nil, fmt.Errorf("reading %s: %w", archive, err)
+	}
+
+	return &entryReader{r, io.LimitReader(content, size), size}, nil
+}
+
+// entryReader reads the content of an archive entry, failing if the archive
+// ends before the entry does.
+type entryReader struct {
+	io.Closer
+	r         io.Reader
+	remaining int64
+}
+
+func (r *entryReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.remaining -= int64(n)
+	if err == io.EOF && r.remaining > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
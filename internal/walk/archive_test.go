@@ -0,0 +1,256 @@
+package walk
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/apex/log/handlers/cli"
+	"github.com/apex/log/handlers/memory"
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+// writeTestArchive writes a tar archive with a few entries of various types
+// into dir, returning its path.
+func writeTestArchive(t *testing.T, dir string, compress bool) string {
+	archive := filepath.Join(dir, "test.tar")
+
+	file, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var w io.Writer = file
+	if compress {
+		gz := gzip.NewWriter(file)
+		defer gz.Close()
+		w = gz
+	}
+
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+
+	entries := []struct {
+		hdr     tar.Header
+		content string
+	}{
+		{tar.Header{Name: "./", Typeflag: tar.TypeDir, Mode: 0755}, ""},
+		{tar.Header{Name: "./hello", Typeflag: tar.TypeReg, Mode: 0644}, "hello world\n"},
+		{tar.Header{Name: "./subdir/", Typeflag: tar.TypeDir, Mode: 0755}, ""},
+		{tar.Header{Name: "./subdir/other", Typeflag: tar.TypeReg, Mode: 0644}, "other content\n"},
+		{tar.Header{Name: "./subdir/link", Typeflag: tar.TypeSymlink, Linkname: "other", Mode: 0777}, ""},
+		{tar.Header{Name: "./skipped/file", Typeflag: tar.TypeReg, Mode: 0644}, "excluded\n"},
+		{tar.Header{Name: "./subdir/hardlink", Typeflag: tar.TypeLink, Linkname: "./subdir/other", Mode: 0644}, ""},
+		{tar.Header{Name: "./linked", Typeflag: tar.TypeLink, Linkname: "skipped/file", Mode: 0644}, ""},
+		{tar.Header{Name: "./dangling", Typeflag: tar.TypeLink, Linkname: "nonexistent", Mode: 0644}, ""},
+	}
+
+	for _, e := range entries {
+		hdr := e.hdr
+		hdr.Size = int64(len(e.content))
+		if err := tw.WriteHeader(&hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(tw, e.content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return archive
+}
+
+func TestWalkArchive(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		t.Run(map[bool]string{false: "plain", true: "gzip"}[compress], func(t *testing.T) {
+			ctx := context.Background()
+			logs := memory.New()
+			logger := log.Logger{}
+			logger.Handler = logs
+			ctx = log.NewContext(ctx, &logger)
+
+			archive := writeTestArchive(t, t.TempDir(), compress)
+
+			cfg := args.Config{Src: archive}
+			cfg.Exclude = []string{"skipped"}
+			cfg.Tar = true
+
+			got := make(map[string]string)
+			content := make(map[string]string)
+
+			err := Walk(ctx, cfg, []string{}, func(item SyncItem) error {
+				got[item.SrcPath] = item.Key
+
+				r, err := item.Open()
+				if err != nil {
+					return err
+				}
+				defer r.Close()
+
+				data, err := io.ReadAll(r)
+				content[item.SrcPath] = string(data)
+				return err
+			})
+
+			if err != nil {
+				t.Fatalf("walk failed: %v", err)
+			}
+
+			// Hard links have the content of their target, even one which
+			// isn't itself published.
+			expected := map[string]string{
+				archive + "/hello":           "a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447",
+				archive + "/subdir/other":    "c9c35465c79d12978ce82af86aa8652840acdc22c8b5bcd7d828a855a55dbd57",
+				archive + "/subdir/hardlink": "c9c35465c79d12978ce82af86aa8652840acdc22c8b5bcd7d828a855a55dbd57",
+				archive + "/linked":          "e47f9eb7f0365ba81c0655e703402773e0c212a73d24ffa541c9abd5bfc3c721",
+			}
+			expectedContent := map[string]string{
+				archive + "/hello":           "hello world\n",
+				archive + "/subdir/other":    "other content\n",
+				archive + "/subdir/hardlink": "other content\n",
+				archive + "/linked":          "excluded\n",
+			}
+
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("unexpected items: %v", got)
+			}
+			if !reflect.DeepEqual(content, expectedContent) {
+				t.Errorf("unexpected content: %v", content)
+			}
+
+			// A hard link to nothing can't be published.
+			warned := false
+			for _, entry := range logs.Entries {
+				if entry.Message == "Skipping hard link to a file not found earlier in the archive" {
+					warned = entry.Fields["entry"] == "./dangling"
+				}
+			}
+			if !warned {
+				t.Error("missing warning for dangling hard link")
+			}
+		})
+	}
+}
+
+func TestWalkArchiveDuplicates(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		t.Run(map[bool]string{false: "plain", true: "gzip"}[compress], func(t *testing.T) {
+			ctx := context.Background()
+			logger := log.Logger{}
+			logger.Handler = cli.New(os.Stdout)
+			ctx = log.NewContext(ctx, &logger)
+
+			archive := filepath.Join(t.TempDir(), "test.tar")
+			file, err := os.Create(archive)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var w io.Writer = file
+			var gz *gzip.Writer
+			if compress {
+				gz = gzip.NewWriter(file)
+				w = gz
+			}
+
+			// As with an archive appended to by "tar -r", the same file
+			// appears more than once.
+			tw := tar.NewWriter(w)
+			for _, content := range []string{"old content\n", "other\n", "new content\n"} {
+				name := "file"
+				if content == "other\n" {
+					name = "other"
+				}
+				hdr := tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}
+				if err := tw.WriteHeader(&hdr); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := io.WriteString(tw, content); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tw.Close()
+			if gz != nil {
+				gz.Close()
+			}
+			file.Close()
+
+			cfg := args.Config{Src: archive}
+			cfg.Tar = true
+
+			var paths []string
+			content := make(map[string]string)
+
+			err = Walk(ctx, cfg, []string{}, func(item SyncItem) error {
+				paths = append(paths, item.SrcPath)
+
+				r, err := item.Open()
+				if err != nil {
+					return err
+				}
+				defer r.Close()
+
+				data, err := io.ReadAll(r)
+				content[item.SrcPath] = string(data)
+				return err
+			})
+			if err != nil {
+				t.Fatalf("walk failed: %v", err)
+			}
+
+			// Only the last entry of a path is used, and its content is
+			// what's read back rather than that of the first entry.
+			if !reflect.DeepEqual(paths, []string{archive + "/file", archive + "/other"}) {
+				t.Errorf("unexpected items: %v", paths)
+			}
+			expectedContent := map[string]string{
+				archive + "/file":  "new content\n",
+				archive + "/other": "other\n",
+			}
+			if !reflect.DeepEqual(content, expectedContent) {
+				t.Errorf("unexpected content: %v", content)
+			}
+		})
+	}
+}
+
+func TestOpenArchiveEntryTruncated(t *testing.T) {
+	archive := writeTestArchive(t, t.TempDir(), false)
+
+	// Asking for more than the archive holds fails rather than silently
+	// returning short content.
+	r, err := openArchiveEntry(archive, 1024, 1<<20)
+	if err != nil {
+		t.Fatalf("can't open entry, err = %v", err)
+	}
+	defer r.Close()
+
+	if _, err := io.ReadAll(r); err != io.ErrUnexpectedEOF {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestWalkArchiveMissing(t *testing.T) {
+	ctx := context.Background()
+	logger := log.Logger{}
+	logger.Handler = cli.New(os.Stdout)
+	ctx = log.NewContext(ctx, &logger)
+
+	cfg := args.Config{Src: "/this/archive/does/not/exist.tar"}
+	cfg.Tar = true
+
+	err := Walk(ctx, cfg, []string{}, func(item SyncItem) error {
+		t.Error("handler called unexpectedly")
+		return nil
+	})
+
+	if !os.IsNotExist(err) {
+		t.Errorf("did not get expected error, got: %v", err)
+	}
+}
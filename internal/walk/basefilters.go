@@ -0,0 +1,26 @@
+package walk
+
+import "context"
+
+// Filters are patterns of files to exclude, as for --exclude, and of files to
+// include despite matching those, as for --include.
+type Filters struct {
+	Exclude []string
+	Include []string
+}
+
+type baseFiltersKey struct{}
+
+// WithBaseFilters returns a context under which Walk applies filters before
+// any others. A file they exclude is never walked, whatever the filters from
+// args or from filter files; includes only except files from their own
+// excludes.
+func WithBaseFilters(ctx context.Context, filters Filters) context.Context {
+	return context.WithValue(ctx, baseFiltersKey{}, filters)
+}
+
+// BaseFiltersFromContext returns the filters from WithBaseFilters, if any.
+func BaseFiltersFromContext(ctx context.Context) Filters {
+	filters, _ := ctx.Value(baseFiltersKey{}).(Filters)
+	return filters
+}
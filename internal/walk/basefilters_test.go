@@ -0,0 +1,54 @@
+package walk
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/apex/log/handlers/discard"
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+func TestWalkBaseFilters(t *testing.T) {
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{
+		"pkg.rpm":           "",
+		"pkg.src.rpm":       "",
+		"keep.src.rpm":      "",
+		"notes.txt":         "",
+		"sub/other.rpm":     "",
+		"sub/other.src.rpm": "",
+
+		// Filter files don't override the base filters either.
+		"sub/" + DirFilterFile: "+ *.src.rpm\n",
+	})
+
+	ctx := context.Background()
+	logger := log.Logger{}
+	logger.Handler = discard.New()
+	ctx = log.NewContext(ctx, &logger)
+	ctx = WithBaseFilters(ctx, Filters{Exclude: []string{"*.src.rpm"}, Include: []string{"keep.src.rpm"}})
+
+	// Files excluded by the base filters stay excluded, even when matching
+	// --include.
+	cfg := args.Config{Src: src, Include: []string{"*/", "*.rpm"}, Exclude: []string{"*"}}
+	cfg.FilterFiles = true
+
+	var got []string
+	err := Walk(ctx, cfg, []string{}, func(item SyncItem) error {
+		got = append(got, strings.TrimPrefix(item.SrcPath, src+"/"))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	sort.Strings(got)
+
+	want := []string{"keep.src.rpm", "pkg.rpm", "sub/other.rpm"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
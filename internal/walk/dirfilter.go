@@ -0,0 +1,164 @@
+package walk
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DirFilterFile is the name of per-directory filter files applied with
+// --exodus-filter-files, akin to rsync's ".rsync-filter".
+const DirFilterFile = ".exodus-rsync-filter"
+
+// dirRule is a single include or exclude rule from a DirFilterFile.
+type dirRule struct {
+	include bool
+	pattern string
+}
+
+// loadDirRules reads rules from the DirFilterFile within dir, returning no
+// rules if there's no such file.
+//
+// Each non-empty line which isn't a comment (starting with '#' or ';') holds
+// a rule in one of rsync's forms: "- PATTERN" or "exclude PATTERN", and
+// "+ PATTERN" or "include PATTERN".
+func loadDirRules(dir string) ([]dirRule, error) {
+	path := filepath.Join(dir, DirFilterFile)
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []dirRule
+
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		kind, pattern, _ := strings.Cut(line, " ")
+		pattern = strings.TrimLeft(pattern, " ")
+
+		var rule dirRule
+		switch kind {
+		case "-", "exclude":
+			rule = dirRule{false, pattern}
+		case "+", "include":
+			rule = dirRule{true, pattern}
+		default:
+			return nil, fmt.Errorf("%s:%d: expected '- PATTERN' or '+ PATTERN'", path, lineNo)
+		}
+		if rule.pattern == "" {
+			return nil, fmt.Errorf("%s:%d: missing pattern", path, lineNo)
+		}
+
+		out = append(out, rule)
+	}
+
+	return out, scanner.Err()
+}
+
+// matchDirRule determines if relPath, relative to the directory holding
+// a rule, matches the rule's pattern.
+//
+// As in rsync, a pattern starting with '/' is anchored to that directory,
+// while other patterns are matched as for --exclude.
+func matchDirRule(relPath string, pattern string, isDir bool) (bool, error) {
+	if !strings.HasPrefix(pattern, "/") {
+		return matchPattern(relPath, pattern, isDir)
+	}
+
+	pattern = strings.TrimPrefix(pattern, "/")
+	if strings.HasSuffix(pattern, "/") {
+		if !isDir {
+			return false, nil
+		}
+		pattern = strings.TrimRight(pattern, "/")
+	}
+
+	re, err := makeRegexp(pattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(relPath), nil
+}
+
+// dirFilter holds the rules of each DirFilterFile found while walking,
+// keyed by the directory's path relative to root.
+type dirFilter struct {
+	root  string
+	rules map[string][]dirRule
+}
+
+func newDirFilter(root string) *dirFilter {
+	return &dirFilter{root: root, rules: make(map[string][]dirRule)}
+}
+
+// enter loads the rules applying to the subtree at dir.
+func (f *dirFilter) enter(dir string) error {
+	rel, err := filepath.Rel(f.root, dir)
+	if err != nil {
+		return err
+	}
+
+	rules, err := loadDirRules(dir)
+	if err != nil {
+		return err
+	}
+	if len(rules) > 0 {
+		f.rules[rel] = rules
+	}
+	return nil
+}
+
+// match applies the rules of the directories holding path, innermost first,
+// returning whether the first matching rule includes path; matched is false
+// if no rule matches.
+//
+// The filter files themselves are always excluded.
+func (f *dirFilter) match(path string, isDir bool) (included bool, matched bool, err error) {
+	if filepath.Base(path) == DirFilterFile && !isDir {
+		return false, true, nil
+	}
+
+	rel, err := filepath.Rel(f.root, path)
+	if err != nil {
+		return false, false, err
+	}
+
+	for dir := rel; dir != "."; {
+		dir = filepath.Dir(dir)
+
+		for _, rule := range f.rules[dir] {
+			relPath, err := filepath.Rel(dir, rel)
+			if err != nil {
+				return false, false, err
+			}
+
+			isMatch, err := matchDirRule(relPath, rule.pattern, isDir)
+			if err != nil {
+				return false, false, fmt.Errorf("could not process rule `%s` in %s: %w",
+					rule.pattern, filepath.Join(f.root, dir, DirFilterFile), err)
+			}
+			if isMatch {
+				return rule.include, true, nil
+			}
+		}
+	}
+
+	return false, false, nil
+}
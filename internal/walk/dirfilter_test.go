@@ -0,0 +1,126 @@
+package walk
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/apex/log/handlers/discard"
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func walkPaths(t *testing.T, cfg args.Config) ([]string, error) {
+	ctx := context.Background()
+	logger := log.Logger{}
+	logger.Handler = discard.New()
+	ctx = log.NewContext(ctx, &logger)
+
+	var out []string
+	err := Walk(ctx, cfg, []string{}, func(item SyncItem) error {
+		out = append(out, strings.TrimPrefix(item.SrcPath, cfg.Src+"/"))
+		return nil
+	})
+	sort.Strings(out)
+	return out, err
+}
+
+func TestWalkFilterFiles(t *testing.T) {
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{
+		DirFilterFile: "# top-level rules\n- *.log\n- /private/\n\n",
+		"a.log":       "",
+		"keep.txt":    "",
+		"x.tmp":       "",
+		"private/x":   "",
+
+		// Rules here take precedence over those of the parent, and over
+		// --exclude.
+		"sub/" + DirFilterFile: "+ debug.log\ninclude keep.tmp\n",
+		"sub/debug.log":        "",
+		"sub/other.log":        "",
+		"sub/keep.tmp":         "",
+		"sub/private/y":        "",
+
+		"other/" + DirFilterFile: "exclude *\n",
+		"other/z":                "",
+	})
+
+	cfg := args.Config{Src: src, Exclude: []string{"*.tmp"}}
+	cfg.FilterFiles = true
+
+	got, err := walkPaths(t, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"keep.txt", "sub/debug.log", "sub/keep.tmp", "sub/private/y"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+}
+
+func TestWalkFilterFilesDisabled(t *testing.T) {
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{
+		DirFilterFile: "- *.log\n",
+		"a.log":       "",
+	})
+
+	got, err := walkPaths(t, args.Config{Src: src})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Without --exodus-filter-files, filter files are just files.
+	expected := []string{DirFilterFile, "a.log"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+}
+
+func TestWalkFilterFilesInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"bad rule", "- a\n? b\n", DirFilterFile + ":2: expected '- PATTERN' or '+ PATTERN'"},
+		{"no pattern", "+\n", DirFilterFile + ":1: missing pattern"},
+		{"bad pattern", "- a(b*\n", "could not process rule `a(b*`"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := t.TempDir()
+			writeFiles(t, src, map[string]string{
+				"sub/" + DirFilterFile: tt.content,
+				"sub/abc":              "",
+			})
+
+			cfg := args.Config{Src: src}
+			cfg.FilterFiles = true
+
+			_, err := walkPaths(t, cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("did not get expected error, err = %v", err)
+			}
+		})
+	}
+}
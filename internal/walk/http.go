@@ -0,0 +1,280 @@
+package walk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+// Maximum number of redirects followed when fetching a URL.
+const maxRedirects = 10
+
+// httpClient fetches the content of URL sources. There's no overall timeout,
+// as content may be large, but a server which doesn't respond at all fails,
+// as does one which stops sending content for idleTimeout.
+var httpClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ResponseHeaderTimeout: time.Minute,
+	},
+	CheckRedirect: checkRedirect,
+}
+
+// Longest a response body may go without any content being received before
+// its fetch fails, as a server may stall partway through a response; a var
+// so that tests can shorten it.
+var idleTimeout = time.Minute
+
+// IsURL returns true if src is an http(s) URL rather than a local path.
+func IsURL(src string) bool {
+	return strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://")
+}
+
+// checkRedirect limits the redirects followed when fetching a URL, and
+// refuses to be redirected from https to plain http.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if via[0].URL.Scheme == "https" && req.URL.Scheme != "https" {
+		return fmt.Errorf("refusing redirect from https to %s", req.URL.Redacted())
+	}
+	return nil
+}
+
+// getURL returns the response to a GET of rawURL, failing unless it
+// succeeded. Reading the body fails once it has been idle for idleTimeout.
+func getURL(ctx context.Context, rawURL string) (*http.Response, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		cancel(nil)
+		return nil, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		cancel(nil)
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel(nil)
+		return nil, fmt.Errorf("GET %s: %s", rawURL, resp.Status)
+	}
+
+	idle := fmt.Errorf("GET %s: no content received for %v", rawURL, idleTimeout)
+	resp.Body = &idleBody{
+		ReadCloser: resp.Body,
+		ctx:        ctx,
+		cancel:     cancel,
+		timer:      time.AfterFunc(idleTimeout, func() { cancel(idle) }),
+	}
+	return resp, nil
+}
+
+// idleBody is a response body whose request is cancelled once nothing has
+// been read from it for idleTimeout.
+type idleBody struct {
+	io.ReadCloser
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	timer  *time.Timer
+}
+
+func (b *idleBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF && context.Cause(b.ctx) != nil {
+		// Report why the request was cancelled, rather than just that it
+		// was.
+		err = context.Cause(b.ctx)
+	}
+	if n > 0 {
+		b.timer.Reset(idleTimeout)
+	}
+	return n, err
+}
+
+func (b *idleBody) Close() error {
+	b.timer.Stop()
+	err := b.ReadCloser.Close()
+	b.cancel(nil)
+	return err
+}
+
+// urlFileInfo is the fs.FileInfo of content fetched from a URL.
+type urlFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (i urlFileInfo) Name() string       { return i.name }
+func (i urlFileInfo) Size() int64        { return i.size }
+func (i urlFileInfo) Mode() fs.FileMode  { return 0644 }
+func (i urlFileInfo) ModTime() time.Time { return i.modTime }
+func (i urlFileInfo) IsDir() bool        { return false }
+func (i urlFileInfo) Sys() interface{}   { return nil }
+
+// urlItem returns the item for content fetched from rawURL, published as if
+// it were a file at srcPath. The content is streamed through the hash rather
+// than stored, and fetched again for upload.
+//
+// Content not modified after since is skipped, returning nil.
+func urlItem(ctx context.Context, rawURL string, srcPath string, since time.Time) (*SyncItem, error) {
+	resp, err := getURL(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if final := resp.Request.URL.String(); final != rawURL {
+		log.FromContext(ctx).F("url", rawURL, "location", final).Debug("followed redirect")
+	}
+
+	info := urlFileInfo{name: path.Base(srcPath)}
+	if modTime, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.modTime = modTime
+	}
+
+	if !since.IsZero() && !info.modTime.After(since) {
+		log.FromContext(ctx).F("path", srcPath).Debug("skipping; not newer than --exodus-newer-than")
+		return nil, nil
+	}
+
+	// The length read is the size, as Content-Length may be missing.
+	body := &countingReader{Reader: resp.Body}
+	key, err := readerHash(body, newKeyHash())
+	if err != nil {
+		return nil, fmt.Errorf("checksum %s: %w", rawURL, err)
+	}
+	info.size = body.count
+
+	return &SyncItem{SrcPath: srcPath, Key: key, Info: info, URL: rawURL}, nil
+}
+
+// walkURL is the counterpart of Walk for a source which is an http(s) URL.
+//
+// A URL can't be listed as a directory can, so the source is either the URL
+// of a single file, or of a directory with onlyThese naming the files
+// beneath it, as given by --files-from.
+func walkURL(ctx context.Context, args args.Config, onlyThese []string, handler SyncItemHandler) error {
+	logger := log.FromContext(ctx)
+
+	since, err := newerThan(args.NewerThan)
+	if err != nil {
+		return err
+	}
+
+	if len(onlyThese) == 0 {
+		if strings.HasSuffix(args.Src, "/") {
+			return fmt.Errorf("%s: can't list the files beneath a URL, use --files-from", args.Src)
+		}
+		onlyThese = []string{args.Src}
+	}
+
+	base, err := url.Parse(args.Src)
+	if err != nil {
+		return err
+	}
+
+	for _, srcPath := range onlyThese {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// As for a local SRC, a URL of a single file is filtered by its name.
+		fileURL, relPath := base, path.Base(base.Path)
+		if srcPath != args.Src {
+			relPath = strings.TrimPrefix(srcPath, args.Src)
+			if fileURL, err = urlBeneath(base, relPath); err != nil {
+				return err
+			}
+		}
+
+		filtered, err := archiveFiltered(logger, BaseFiltersFromContext(ctx), args, relPath)
+		if err != nil {
+			return err
+		}
+		if filtered {
+			continue
+		}
+
+		item, err := urlItem(ctx, fileURL.String(), srcPath, since)
+		if err != nil {
+			return err
+		}
+		if item == nil {
+			continue
+		}
+
+		logger.F("item", item).Debug("got item")
+		if err := handler(*item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// urlBeneath returns the URL of the file at relPath beneath the directory URL
+// base, refusing any path which would escape it.
+func urlBeneath(base *url.URL, relPath string) (*url.URL, error) {
+	for _, segment := range strings.Split(relPath, "/") {
+		if segment == ".." {
+			return nil, fmt.Errorf("refusing to fetch '%s' beneath %s: path contains '..'", relPath, base.Redacted())
+		}
+	}
+	return base.ResolveReference(&url.URL{Path: strings.TrimPrefix(relPath, "/")}), nil
+}
+
+// openURL returns a reader for the content at rawURL, failing if it's no
+// longer the content of the given size and key, as when it was walked.
+func openURL(ctx context.Context, rawURL string, size int64, key string) (io.ReadCloser, error) {
+	resp, err := getURL(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return &urlReader{resp.Body, rawURL, size, key, newKeyHash()}, nil
+}
+
+// urlReader reads the content of a URL, checking it against its key once
+// fully read.
+type urlReader struct {
+	io.ReadCloser
+	url       string
+	remaining int64
+	key       string
+	hash      hash.Hash
+}
+
+var errURLChanged = errors.New("content changed since it was read")
+
+func (r *urlReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n])
+	r.remaining -= int64(n)
+
+	if r.remaining < 0 {
+		return n, fmt.Errorf("%s: %w", r.url, errURLChanged)
+	}
+	if err == io.EOF {
+		sum := fmt.Sprintf("%x", r.hash.Sum(nil))
+		if r.remaining != 0 || sum != r.key {
+			return n, fmt.Errorf("%s: %w", r.url, errURLChanged)
+		}
+	}
+	return n, err
+}
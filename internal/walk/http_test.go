@@ -0,0 +1,228 @@
+package walk
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apex/log/handlers/cli"
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+// newTestHTTPServer returns a server of a few files, with some redirects
+// and failures.
+func newTestHTTPServer(t *testing.T, files map[string]string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Last-Modified", "Tue, 02 Jan 2024 03:04:05 GMT")
+		io.WriteString(w, content)
+	})
+	mux.HandleFunc("/moved/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/files/"+strings.TrimPrefix(r.URL.Path, "/moved/"), http.StatusFound)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testHTTPContext() context.Context {
+	logger := log.Logger{}
+	logger.Handler = cli.New(os.Stdout)
+	return log.NewContext(context.Background(), &logger)
+}
+
+func TestWalkURL(t *testing.T) {
+	server := newTestHTTPServer(t, map[string]string{
+		"/files/hello":        "hello world\n",
+		"/files/subdir/other": "other content\n",
+		"/files/skipped":      "excluded\n",
+	})
+
+	tests := []struct {
+		name      string
+		src       string
+		onlyThese []string
+		want      map[string]string
+	}{
+		{"single file", server.URL + "/files/hello", nil, map[string]string{
+			server.URL + "/files/hello": "hello world\n",
+		}},
+		{"redirected", server.URL + "/moved/hello", nil, map[string]string{
+			server.URL + "/moved/hello": "hello world\n",
+		}},
+		// A single file is filtered by its name.
+		{"single file excluded", server.URL + "/files/skipped", nil, map[string]string{}},
+		{"files from", server.URL + "/files/", []string{
+			server.URL + "/files/hello",
+			server.URL + "/files/subdir/other",
+			server.URL + "/files/skipped",
+		}, map[string]string{
+			server.URL + "/files/hello":        "hello world\n",
+			server.URL + "/files/subdir/other": "other content\n",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := args.Config{Src: tt.src}
+			cfg.Exclude = []string{"skipped"}
+
+			got := make(map[string]string)
+			err := Walk(testHTTPContext(), cfg, tt.onlyThese, func(item SyncItem) error {
+				r, err := item.Open()
+				if err != nil {
+					return err
+				}
+				defer r.Close()
+
+				data, err := io.ReadAll(r)
+				got[item.SrcPath] = string(data)
+
+				if item.Info.Size() != int64(len(data)) || item.Info.ModTime().Year() != 2024 {
+					t.Errorf("unexpected info of %s: size %d, modified %v", item.SrcPath, item.Info.Size(), item.Info.ModTime())
+				}
+				return err
+			})
+
+			if err != nil {
+				t.Fatalf("walk failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected content: %v", got)
+			}
+		})
+	}
+}
+
+func TestWalkURLErrors(t *testing.T) {
+	server := newTestHTTPServer(t, map[string]string{"/files/hello": "hello world\n"})
+
+	tests := []struct {
+		name      string
+		src       string
+		onlyThese []string
+		want      string
+	}{
+		{"not found", server.URL + "/files/missing", nil, "404 Not Found"},
+		{"redirect loop", server.URL + "/loop", nil, "stopped after 10 redirects"},
+		{"directory", server.URL + "/files/", nil, "can't list the files beneath a URL, use --files-from"},
+		{"escapes directory", server.URL + "/files/", []string{server.URL + "/files/../secret"},
+			"path contains '..'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Walk(testHTTPContext(), args.Config{Src: tt.src}, tt.onlyThese, func(item SyncItem) error {
+				t.Errorf("unexpected item %v", item)
+				return nil
+			})
+
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenURLChanged(t *testing.T) {
+	files := map[string]string{"/files/hello": "hello world\n"}
+	server := newTestHTTPServer(t, files)
+
+	var item SyncItem
+	err := Walk(testHTTPContext(), args.Config{Src: server.URL + "/files/hello"}, nil, func(i SyncItem) error {
+		item = i
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	// Content of the same length, but not the same content.
+	files["/files/hello"] = "HELLO WORLD\n"
+
+	r, err := item.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if _, err := io.ReadAll(r); !errors.Is(err, errURLChanged) {
+		t.Errorf("got error %v, want %v", err, errURLChanged)
+	}
+}
+
+func TestOpenURLStalled(t *testing.T) {
+	oldTimeout := idleTimeout
+	idleTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { idleTimeout = oldTimeout })
+
+	// The server sends part of the content, then nothing more until the
+	// client gives up.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "12")
+		io.WriteString(w, "hello")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+
+	r, err := openURL(context.Background(), server.URL+"/hello", 12, "unused")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	_, err = io.ReadAll(r)
+	if err == nil || !strings.Contains(err.Error(), "no content received for 50ms") {
+		t.Errorf("got error %v, want idle timeout", err)
+	}
+}
+
+func TestOpenURLCancelled(t *testing.T) {
+	files := map[string]string{"/files/hello": "hello world\n"}
+	server := newTestHTTPServer(t, files)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	item := SyncItem{SrcPath: "hello", Info: urlFileInfo{name: "hello", size: 12}, URL: server.URL + "/files/hello"}
+	if _, err := item.OpenContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestCheckRedirect(t *testing.T) {
+	req := func(rawURL string) *http.Request {
+		out, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	via := []*http.Request{req("https://example.com/a")}
+
+	if err := checkRedirect(req("https://example.com/b"), via); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := checkRedirect(req("http://example.com/b"), via); err == nil {
+		t.Error("redirect from https to http was allowed")
+	}
+}
@@ -0,0 +1,21 @@
+package walk
+
+import (
+	"crypto/sha256"
+	"hash"
+	"io"
+)
+
+// KeyAlgorithm is the algorithm by which the key of each item is computed:
+// the hex-encoded digest of its content, without any seed or salt, so that
+// the same content has the same key on every machine.
+const KeyAlgorithm = "sha256"
+
+func newKeyHash() hash.Hash {
+	return sha256.New()
+}
+
+// ContentKey returns the key of an item with the content read from r.
+func ContentKey(r io.Reader) (string, error) {
+	return readerHash(r, newKeyHash())
+}
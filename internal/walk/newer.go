@@ -0,0 +1,51 @@
+package walk
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// newerThan returns the time given by --exodus-newer-than, which is either
+// a time in RFC 3339 format, a date, or the path of a reference file whose
+// modification time is used. The zero time is returned if unset.
+func newerThan(arg string) (time.Time, error) {
+	if arg == "" {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, arg); err == nil {
+			return t, nil
+		}
+	}
+
+	info, err := os.Stat(arg)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("--exodus-newer-than '%s' is neither a time nor a usable reference file: %w", arg, err)
+	}
+
+	return info.ModTime(), nil
+}
+
+// olderFile returns true if the file at path, found while walking, was not
+// modified after t. Symlinks are judged by their targets unless they're
+// published as links.
+func olderFile(path string, d fs.DirEntry, links bool, t time.Time) (bool, error) {
+	var (
+		info fs.FileInfo
+		err  error
+	)
+
+	if d.Type()&fs.ModeSymlink != 0 && !links {
+		info, err = os.Stat(path)
+	} else {
+		info, err = d.Info()
+	}
+	if err != nil {
+		return false, fmt.Errorf("get file info for %s: %w", path, err)
+	}
+
+	return !info.ModTime().After(t), nil
+}
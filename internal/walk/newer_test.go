@@ -0,0 +1,142 @@
+package walk
+
+import (
+	"archive/tar"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apex/log/handlers/cli"
+	"github.com/release-engineering/exodus-rsync/internal/args"
+	"github.com/release-engineering/exodus-rsync/internal/log"
+)
+
+var (
+	oldTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newTime = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// writeAgedFiles writes files into dir with the given modification times,
+// keyed by relative path.
+func writeAgedFiles(t *testing.T, dir string, files map[string]time.Time) {
+	for name, mtime := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func walkedPaths(t *testing.T, cfg args.Config) ([]string, error) {
+	logger := log.Logger{}
+	logger.Handler = cli.New(os.Stdout)
+	ctx := log.NewContext(context.Background(), &logger)
+
+	out := []string{}
+	err := Walk(ctx, cfg, nil, func(item SyncItem) error {
+		out = append(out, strings.TrimPrefix(item.SrcPath, strings.TrimSuffix(cfg.Src, "/")+"/"))
+		return nil
+	})
+	sort.Strings(out)
+	return out, err
+}
+
+func TestWalkNewerThan(t *testing.T) {
+	src := t.TempDir()
+	writeAgedFiles(t, src, map[string]time.Time{
+		"old":          oldTime,
+		"new":          newTime,
+		"subdir/old":   oldTime,
+		"subdir/new":   newTime,
+		"excluded/new": newTime,
+	})
+
+	// A reference file modified between the old and new files.
+	ref := filepath.Join(t.TempDir(), "last-publish")
+	writeAgedFiles(t, filepath.Dir(ref), map[string]time.Time{"last-publish": oldTime.Add(time.Hour)})
+
+	newFiles := []string{"new", "subdir/new"}
+
+	tests := []struct {
+		name string
+		cfg  args.Config
+		want []string
+	}{
+		{"unset", args.Config{},
+			[]string{"excluded/new", "new", "old", "subdir/new", "subdir/old"}},
+		{"time", args.Config{ExodusConfig: args.ExodusConfig{NewerThan: "2024-03-01T00:00:00Z"}},
+			append([]string{"excluded/new"}, newFiles...)},
+		{"date", args.Config{ExodusConfig: args.ExodusConfig{NewerThan: "2024-03-01"}},
+			append([]string{"excluded/new"}, newFiles...)},
+		{"reference file", args.Config{ExodusConfig: args.ExodusConfig{NewerThan: ref}},
+			append([]string{"excluded/new"}, newFiles...)},
+		{"exactly as old", args.Config{ExodusConfig: args.ExodusConfig{NewerThan: newTime.Format(time.RFC3339)}},
+			[]string{}},
+		{"with other filters", args.Config{Exclude: []string{"excluded/"}, ExodusConfig: args.ExodusConfig{NewerThan: ref}},
+			newFiles},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Src = src + "/"
+
+			got, err := walkedPaths(t, tt.cfg)
+			if err != nil {
+				t.Fatalf("walk failed, err = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("walked %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWalkNewerThanInvalid(t *testing.T) {
+	cfg := args.Config{Src: t.TempDir() + "/", ExodusConfig: args.ExodusConfig{NewerThan: "yesterday"}}
+
+	_, err := walkedPaths(t, cfg)
+	if err == nil || !strings.Contains(err.Error(), "--exodus-newer-than 'yesterday' is neither a time nor a usable reference file") {
+		t.Errorf("did not get expected error, got %v", err)
+	}
+}
+
+func TestWalkArchiveNewerThan(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "test.tar")
+
+	file, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tw := tar.NewWriter(file)
+	for name, mtime := range map[string]time.Time{"old": oldTime, "new": newTime} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(name)), ModTime: mtime}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	file.Close()
+
+	cfg := args.Config{Src: archive + "/", ExodusConfig: args.ExodusConfig{Tar: true, NewerThan: "2024-03-01"}}
+
+	got, err := walkedPaths(t, cfg)
+	if err != nil {
+		t.Fatalf("walk failed, err = %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"new"}) {
+		t.Errorf("walked %v, wanted only the newer entry", got)
+	}
+}
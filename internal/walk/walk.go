@@ -2,7 +2,6 @@ package walk
 
 import (
 	"context"
-	"crypto/sha256"
 	"fmt"
 	"hash"
 	"io"
@@ -33,6 +32,34 @@ type SyncItem struct {
 	Key     string
 	LinkTo  string
 	Info    fs.FileInfo
+
+	// If non-empty, the item's content is read from this entry within a tar
+	// archive at path Archive, rather than from a file at SrcPath. The content
+	// starts at ArchiveOffset within the decompressed archive.
+	Archive       string
+	ArchiveEntry  string
+	ArchiveOffset int64
+
+	// If non-empty, the item's content is fetched from this http(s) URL,
+	// rather than read from a file at SrcPath.
+	URL string
+}
+
+// Open returns a reader for the content of this item.
+func (i SyncItem) Open() (io.ReadCloser, error) {
+	return i.OpenContext(context.Background())
+}
+
+// OpenContext is like Open, but content fetched from a URL is fetched within
+// ctx, so that cancelling ctx stops the fetch.
+func (i SyncItem) OpenContext(ctx context.Context) (io.ReadCloser, error) {
+	if i.Archive != "" {
+		return openArchiveEntry(i.Archive, i.ArchiveOffset, i.Info.Size())
+	}
+	if i.URL != "" {
+		return openURL(ctx, i.URL, i.Info.Size(), i.Key)
+	}
+	return os.Open(i.SrcPath)
 }
 
 type syncItemPrivate struct {
@@ -47,7 +74,11 @@ func fileHash(path string, hasher hash.Hash) (string, error) {
 	}
 	defer file.Close()
 
-	if _, err := io.Copy(hasher, file); err != nil {
+	return readerHash(file, hasher)
+}
+
+func readerHash(r io.Reader, hasher hash.Hash) (string, error) {
+	if _, err := io.Copy(hasher, r); err != nil {
 		return "", err
 	}
 
@@ -84,7 +115,7 @@ func fillItem(ctx context.Context, c chan<- syncItemPrivate, w walkItem, links b
 			return err
 		}
 	} else {
-		key, err = fileHash(w.SrcPath, sha256.New())
+		key, err = fileHash(w.SrcPath, newKeyHash())
 		if err != nil {
 			return fmt.Errorf("checksum %s: %w", w.SrcPath, err)
 		}
@@ -123,7 +154,7 @@ func fillItems(ctx context.Context, in <-chan walkItem, c chan<- syncItemPrivate
 			}
 
 			if err := fillItem(ctx, c, item, links); err != nil {
-				c <- syncItemPrivate{Error: err}
+				c <- syncItemPrivate{SyncItem{SrcPath: item.SrcPath}, err}
 			}
 		}
 	}
@@ -136,10 +167,12 @@ func getSyncItems(ctx context.Context, args args.Config, onlyThese []string) <-c
 	go func() {
 		err := walkDirWithLinks(ctx, args, onlyThese,
 			func(path string, d fs.DirEntry, err error) error {
-				if err != nil {
+				if err != nil && !args.SkipUnreadable {
 					return err
 				}
-				walkItemCh <- walkItem{SrcPath: path, Entry: d}
+				// With --exodus-skip-unreadable, an entry which can't be
+				// read is passed on to be skipped, and the walk carries on.
+				walkItemCh <- walkItem{SrcPath: path, Entry: d, Error: err}
 				return nil
 			})
 
@@ -164,15 +197,39 @@ func getSyncItems(ctx context.Context, args args.Config, onlyThese []string) <-c
 
 // Walk will walk the directory tree at the given path and invoke a handler
 // for every discovered item eligible for sync.
+//
+// If args.Tar is set, the path is instead a tar archive and the handler
+// is invoked for every regular file within the archive. If the path is an
+// http(s) URL, the handler is invoked for the file at that URL, or for each
+// file beneath it in onlyThese.
+//
+// If args.SkipUnreadable is set, files and directories which can't be read
+// are skipped with a warning, rather than stopping the walk.
 func Walk(ctx context.Context, args args.Config, onlyThese []string, handler SyncItemHandler) error {
 	logger := log.FromContext(ctx)
 
+	if args.Tar {
+		return walkArchive(ctx, args, onlyThese, handler)
+	}
+	if IsURL(args.Src) {
+		return walkURL(ctx, args, onlyThese, handler)
+	}
+
+	skipped := 0
+
 	for item := range getSyncItems(ctx, args, onlyThese) {
 		logger.F("item", item).Debug("got item")
 
 		if ctx.Err() != nil {
 			return ctx.Err()
 		}
+		// Errors reading a single entry come with its path; any other error,
+		// such as from invalid filters, still stops the walk.
+		if item.Error != nil && args.SkipUnreadable && item.SrcPath != "" {
+			logger.F("src", item.SrcPath, "error", item.Error).Warn("Skipping unreadable file")
+			skipped++
+			continue
+		}
 		if item.Error != nil {
 			return item.Error
 		}
@@ -181,5 +238,9 @@ func Walk(ctx context.Context, args args.Config, onlyThese []string, handler Syn
 		}
 	}
 
+	if skipped > 0 {
+		logger.F("skipped", skipped).Warn("Skipped files and directories which couldn't be read")
+	}
+
 	return ctx.Err()
 }
@@ -143,6 +143,18 @@ func filter(logger *log.Logger, path string, exclude []string, include []string,
 func walkDirWithLinks(ctx context.Context, args args.Config, onlyThese []string, fn fs.WalkDirFunc) error {
 	logger := log.FromContext(ctx)
 
+	since, err := newerThan(args.NewerThan)
+	if err != nil {
+		return err
+	}
+
+	base := BaseFiltersFromContext(ctx)
+
+	var dirFilter *dirFilter
+	if args.FilterFiles {
+		dirFilter = newDirFilter(args.Src)
+	}
+
 	var walkFunc fs.WalkDirFunc
 
 	walkFunc = func(path string, d fs.DirEntry, err error) error {
@@ -160,14 +172,55 @@ func walkDirWithLinks(ctx context.Context, args args.Config, onlyThese []string,
 
 		// The path filtered should be relative.
 		filterPath := strings.TrimPrefix(filepath.Clean(path), filepath.Clean(args.Src+"/"))
-		filterErr := filter(logger, filterPath, args.Excluded(), args.Included(), d.IsDir())
-		if filterErr != nil {
+
+		// As with rsync, a SRC which is a single file is filtered by its
+		// name, so that it can't bypass the filters.
+		if filterPath == "" && !d.IsDir() {
+			filterPath = filepath.Base(path)
+		}
+
+		// Base filters apply before any others.
+		if filterErr := filter(logger, filterPath, base.Exclude, base.Include, d.IsDir()); filterErr != nil {
 			if strings.Contains(filterErr.Error(), fmt.Sprintf("filtered '%s'", filterPath)) {
 				return nil
 			}
 			return filterErr
 		}
 
+		// As with rsync's dir-merge rules, rules from per-directory filter
+		// files take precedence over --exclude and --include, and those of
+		// deeper directories over those of their parents.
+		dirIncluded, dirMatched := false, false
+		if dirFilter != nil {
+			dirIncluded, dirMatched, err = dirFilter.match(path, d.IsDir())
+			if err != nil {
+				return err
+			}
+			if dirMatched && !dirIncluded {
+				logger.F("path", path).Debug("path excluded by filter file")
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+		}
+
+		if !dirMatched {
+			filterErr := filter(logger, filterPath, args.Excluded(), args.Included(), d.IsDir())
+			if filterErr != nil {
+				if strings.Contains(filterErr.Error(), fmt.Sprintf("filtered '%s'", filterPath)) {
+					return nil
+				}
+				return filterErr
+			}
+		}
+
+		if dirFilter != nil && d.IsDir() {
+			if err := dirFilter.enter(path); err != nil {
+				return fn(path, d, err)
+			}
+		}
+
 		if d.Type()&fs.ModeSymlink != 0 && !args.Links {
 			var info fs.FileInfo
 
@@ -192,6 +245,17 @@ func walkDirWithLinks(ctx context.Context, args args.Config, onlyThese []string,
 			}
 		}
 
+		if !since.IsZero() && !d.IsDir() {
+			older, err := olderFile(path, d, args.Links, since)
+			if err != nil {
+				return fn(path, d, err)
+			}
+			if older {
+				logger.F("path", path).Debug("skipping; not newer than --exodus-newer-than")
+				return nil
+			}
+		}
+
 		// We are not looking at a symlink-to-dir, just call the real handler.
 		return fn(path, d, err)
 	}